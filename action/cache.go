@@ -1,10 +1,19 @@
 package action
 
 import (
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
 
 	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/mirrors"
 	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/glide/repo"
 )
 
 // CacheClear clears the Glide cache
@@ -21,3 +30,141 @@ func CacheClear() {
 
 	msg.Info("Glide cache has been cleared.")
 }
+
+// CacheList lists the repos in the Glide cache with their size and when they
+// were last used, least recently used first.
+func CacheList() {
+	entries, err := cache.Entries()
+	if err != nil {
+		msg.Die("Unable to read the cache: %s", err)
+	}
+
+	var total int64
+	w := tabwriter.NewWriter(msg.Default.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tSIZE\tLAST USED")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", e.Key, formatSize(e.Size), e.LastUsed.Format("2006-01-02 15:04"))
+		total += e.Size
+	}
+	w.Flush()
+	msg.Puts("%d entries using %s", len(entries), formatSize(total))
+}
+
+// CacheWarm fetches the repos of the dependencies and tools in the lock file
+// at lockPath into the Glide cache and checks out their locked revisions there.
+// No vendor directory is touched, so it can run without the project, such as
+// when building a CI image. A glide.yaml file next to the lock file is read
+// for its mirrors and local copies when there is one.
+func CacheWarm(installer *repo.Installer, lockPath string) {
+	EnsureCacheLock()
+
+	lock, err := cfg.ReadLockFile(lockPath)
+	if err != nil {
+		msg.Die("Unable to read the lock file %s: %s", lockPath, err)
+	}
+
+	conf := &cfg.Config{}
+	yamlpath := filepath.Join(filepath.Dir(lockPath), gpath.GlideFile)
+	if yml, err := ioutil.ReadFile(yamlpath); err == nil {
+		conf, err = cfg.ConfigFromYaml(yml)
+		if err != nil {
+			msg.Die("Failed to parse %s: %s", yamlpath, err)
+		}
+		if err := conf.ResolveExtends(filepath.Dir(yamlpath)); err != nil {
+			msg.Die("Failed to load the config %s extends: %s", yamlpath, err)
+		}
+	}
+	if err := mirrors.Load(); err != nil {
+		msg.Err("Unable to load mirrors: %s", err)
+	}
+	mirrors.Add(conf.Mirrors)
+
+	// Tools are warmed along with the imports as they are fetched the same
+	// way.
+	l := lock.Clone()
+	l.Imports = append(l.Imports, l.Tools...)
+	warmed, err := installer.Install(l, conf)
+	if err != nil {
+		msg.Die("Failed to warm the cache: %s", err)
+	}
+	if err := repo.SetReference(warmed, true); err != nil {
+		msg.Die("Failed to check out the locked revisions in the cache: %s", err)
+	}
+	msg.Info("Cached %d dependencies from %s", len(warmed.Imports)+len(warmed.DevImports), lockPath)
+}
+
+// CacheClean evicts repos from the Glide cache. Repos not used for more than
+// days are removed, when days is above zero, followed by the least recently
+// used repos until the cache fits in maxSize. When they are not set the
+// max-age and max-size settings from the config.yaml file in GLIDE_HOME apply.
+func CacheClean(days int, maxSize string) {
+	EnsureCacheLock()
+
+	s, err := cache.ReadSettings()
+	if err != nil {
+		msg.Die("Unable to read the cache settings: %s", err)
+	}
+	if days <= 0 {
+		days = s.MaxAge
+	}
+	if maxSize == "" {
+		maxSize = s.MaxSize
+	}
+	if days <= 0 && maxSize == "" {
+		msg.Die("Nothing to clean. Set --older-than, --max-size, or the cache settings in config.yaml")
+	}
+
+	evictCache(days, maxSize)
+}
+
+// enforceCacheLimits evicts repos from the cache based on the settings in the
+// config.yaml file in GLIDE_HOME. Problems are reported but not fatal.
+func enforceCacheLimits() {
+	s, err := cache.ReadSettings()
+	if err != nil {
+		msg.Warn("Unable to read the cache settings: %s", err)
+		return
+	}
+	if s.MaxAge <= 0 && s.MaxSize == "" {
+		return
+	}
+	evictCache(s.MaxAge, s.MaxSize)
+}
+
+func evictCache(days int, maxSize string) {
+	var size int64
+	if maxSize != "" {
+		var err error
+		size, err = cache.ParseSize(maxSize)
+		if err != nil {
+			msg.Die("Invalid cache size limit: %s", err)
+		}
+	}
+
+	removed, err := cache.Evict(time.Duration(days)*24*time.Hour, size)
+	var freed int64
+	for _, e := range removed {
+		msg.Debug("Evicted %s from the cache", e.Key)
+		freed += e.Size
+	}
+	if err != nil {
+		msg.Die("Unable to clean the cache: %s", err)
+	}
+	if len(removed) > 0 {
+		msg.Info("Evicted %d entries (%s) from the cache", len(removed), formatSize(freed))
+	}
+}
+
+// formatSize returns a size in bytes in a human readable form.
+func formatSize(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%dB", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%c", float64(b)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,104 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/cfg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/glide/repo"
+)
+
+func TestCacheWarm(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+	home, err := ioutil.TempDir("", "glide-cache-warm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer gpath.SetHome(gpath.Home())
+	gpath.SetHome(home)
+	cache.SetupReset()
+	defer cache.SetupReset()
+	defer cache.SystemUnlock()
+
+	src := filepath.Join(home, "dep")
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = src
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=glide", "GIT_AUTHOR_EMAIL=glide@example.com",
+			"GIT_COMMITTER_NAME=glide", "GIT_COMMITTER_EMAIL=glide@example.com")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %s: %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	git("init", "-q")
+	git("commit", "-q", "--allow-empty", "-m", "one")
+	locked := git("rev-parse", "HEAD")
+	git("commit", "-q", "--allow-empty", "-m", "two")
+
+	// Only the lock file is needed, no project or vendor directory.
+	remote := "file://" + filepath.ToSlash(src)
+	lock := &cfg.Lockfile{
+		Hash: "abc",
+		Imports: cfg.Locks{
+			{Name: "example.com/dep", Version: locked, Repository: remote, VcsType: "git"},
+		},
+	}
+	lockPath := filepath.Join(home, "ci", gpath.LockFile)
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := lock.WriteFile(lockPath); err != nil {
+		t.Fatal(err)
+	}
+
+	installer := repo.NewInstaller()
+	installer.Home = home
+	CacheWarm(installer, lockPath)
+
+	key, err := cache.Key(remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = filepath.Join(cache.Location(), "src", key)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("Expected the dependency in the cache: %s", err)
+	}
+	if v := strings.TrimSpace(string(out)); v != locked {
+		t.Errorf("Expected the cache at the locked revision %s, got %s", locked, v)
+	}
+	if _, err := os.Stat(filepath.Join(home, "ci", "vendor")); !os.IsNotExist(err) {
+		t.Errorf("Expected no vendor directory to be created: %v", err)
+	}
+}
+
+// linkCached hardlinks f to stand in for its copy in the cache, which has to
+// stay as it was when f is rewritten. The returned function checks the copy
+// still holds want. Where hardlinks cannot be made the check is skipped.
+func linkCached(t *testing.T, f string) func(want string) {
+	cached := f + ".cached"
+	if err := os.Link(f, cached); err != nil {
+		t.Logf("Not checking the cached copy of %s: %s", filepath.Base(f), err)
+		return func(string) {}
+	}
+	return func(want string) {
+		if b, _ := ioutil.ReadFile(cached); string(b) != want {
+			t.Errorf("Expected the hardlinked copy of %s in the cache to be left alone", filepath.Base(f))
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package action
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// warnCaseDuplicates warns about locked dependencies whose names only differ
+// by case. On case-insensitive file systems, as used by default on macOS and
+// Windows, one overwrites the other in the vendor directory.
+func warnCaseDuplicates(lock *cfg.Lockfile) {
+	for _, d := range caseDuplicates(lockNames(lock)) {
+		msg.Warn("%s and %s only differ by case. On case-insensitive file systems one overwrites the other in the vendor directory", d[0], d[1])
+		msg.Warn("Run 'glide doctor --fix-case' to use one spelling")
+	}
+}
+
+func lockNames(lock *cfg.Lockfile) []string {
+	var names []string
+	for _, l := range append(append(cfg.Locks{}, lock.Imports...), lock.DevImports...) {
+		names = append(names, l.Name)
+	}
+	return names
+}
+
+// FixCase settles on one spelling of each dependency whose name only differs
+// by case from another in the glide.yaml or glide.lock file. The other
+// spelling is ignored while resolving and relocated to the chosen one, so
+// 'glide vendor --relocate' rewrites the imports using it.
+func FixCase() {
+	conf := EnsureConfig()
+	yamlpath, err := gpath.Glide()
+	if err != nil {
+		msg.Die("Failed to find %s file in directory tree: %s", gpath.GlideFile, err)
+	}
+
+	var names []string
+	for _, d := range append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...) {
+		names = append(names, d.Name)
+	}
+	if lock, err := cfg.ReadLockFile(filepath.Join(filepath.Dir(yamlpath), gpath.LockFile)); err == nil {
+		names = append(names, lockNames(lock)...)
+	}
+	dups := caseDuplicates(names)
+	if len(dups) == 0 {
+		msg.Info("No dependencies only differ by case")
+		return
+	}
+
+	for _, d := range dups {
+		keep, drop := fixCase(conf, d)
+		msg.Info("Using %s rather than %s", keep, drop)
+	}
+	if err := conf.WriteFile(yamlpath); err != nil {
+		msg.Die("Unable to write %s: %s", gpath.GlideFile, err)
+	}
+	msg.Info("Run 'glide up' and then 'glide vendor --relocate' to rewrite the imports")
+}
+
+// fixCase picks the spelling to keep of a pair of names only differing by case
+// and changes conf to use it. The spelling with the fewest upper case letters
+// is kept, as that is what projects usually move to, or the one already in
+// conf on a tie. The spelling kept and the one dropped are returned.
+func fixCase(conf *cfg.Config, pair [2]string) (string, string) {
+	keep, drop := pair[0], pair[1]
+	if n, m := upperCount(keep), upperCount(drop); m < n || (m == n && conf.HasDependency(drop) && !conf.HasDependency(keep)) {
+		keep, drop = drop, keep
+	}
+
+	// The dependency is renamed, or dropped when both spellings are listed,
+	// keeping the details set for it.
+	var dep *cfg.Dependency
+	for _, deps := range []*cfg.Dependencies{&conf.Imports, &conf.DevImports} {
+		if d := deps.Get(keep); d != nil {
+			dep = d
+		}
+	}
+	for _, deps := range []*cfg.Dependencies{&conf.Imports, &conf.DevImports} {
+		if d := deps.Get(drop); d != nil {
+			if dep == nil {
+				d.Name = keep
+				dep = d
+			} else {
+				*deps = deps.Remove(drop)
+			}
+		}
+	}
+	if dep == nil {
+		dep = &cfg.Dependency{Name: keep}
+		conf.Imports = append(conf.Imports, dep)
+	}
+
+	if !stringsContain(dep.Relocate, drop) {
+		dep.Relocate = append(dep.Relocate, drop)
+	}
+	if !stringsContain(conf.Ignore, drop) {
+		conf.Ignore = append(conf.Ignore, drop)
+	}
+	return keep, drop
+}
+
+func upperCount(s string) int {
+	n := 0
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// caseDuplicates returns the pairs of paths that are the same when compared
+// case-insensitively.
+func caseDuplicates(paths []string) [][2]string {
+	seen := make(map[string]string)
+	reported := make(map[string]bool)
+	var dups [][2]string
+	for _, p := range paths {
+		l := strings.ToLower(p)
+		if o, ok := seen[l]; ok && o != p && !reported[l+"\x00"+p] {
+			reported[l+"\x00"+p] = true
+			dups = append(dups, [2]string{o, p})
+		} else if !ok {
+			seen[l] = p
+		}
+	}
+	return dups
+}
@@ -0,0 +1,168 @@
+package action
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/hosting"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/semver"
+	"github.com/Masterminds/vcs"
+)
+
+// CheckLock verifies the lock file is consistent with glide.yaml without
+// resolving the dependency tree. Glide exits non-zero when the lock file needs
+// to be regenerated.
+//
+// The lock file is stale when the hash of glide.yaml does not match the one
+// recorded, when a dependency from glide.yaml is missing, or when a locked
+// version does not satisfy the constraint in glide.yaml. Constraints are
+// checked against the tags in the cache, if the repo is there, or else against
+// the tags listed by the API of GitHub, GitLab, or Bitbucket, so no repo is
+// cloned.
+func CheckLock(base string) {
+	conf := EnsureConfig()
+	if !gpath.HasLock(base) {
+		msg.Die("Lock file (glide.lock) does not exist. Run 'glide up' to create it")
+	}
+	lock, err := cfg.ReadLockFile(filepath.Join(base, gpath.LockFile))
+	if err != nil {
+		msg.Die("Could not load lockfile: %s", err)
+	}
+
+	problems := lockProblems(conf, lock)
+	if len(problems) == 0 {
+		msg.Info("Lock file is up to date")
+		return
+	}
+	for _, p := range problems {
+		msg.Err(p)
+	}
+	msg.Die("Lock file is out of date. Run 'glide up' to regenerate it")
+}
+
+// lockProblems returns the reasons lock is inconsistent with conf.
+func lockProblems(conf *cfg.Config, lock *cfg.Lockfile) []string {
+	var problems []string
+
+	hash, err := conf.Hash()
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("Unable to hash glide.yaml: %s", err))
+	} else if hash != lock.Hash {
+		problems = append(problems, "The hash of glide.yaml does not match the one in glide.lock")
+	}
+
+	check := func(d *cfg.Dependency, l *cfg.Lock) {
+		if conf.HasIgnore(d.Name) {
+			return
+		}
+		if l == nil {
+			problems = append(problems, fmt.Sprintf("%s is in glide.yaml but not in glide.lock", d.Name))
+			return
+		}
+		if d.Repository != "" && d.Repository != l.Repository {
+			problems = append(problems, fmt.Sprintf("%s uses the repo %s but glide.lock has %s", d.Name, d.Repository, l.Repository))
+		}
+		if err := lockSatisfies(d, l); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	for _, d := range conf.Imports {
+		check(d, lock.Imports.Get(d.Name))
+	}
+	for _, d := range conf.DevImports {
+		l := lock.DevImports.Get(d.Name)
+		if l == nil {
+			l = lock.Imports.Get(d.Name)
+		}
+		check(d, l)
+	}
+
+	return problems
+}
+
+// lockSatisfies returns an error when the locked version does not meet the
+// reference of the dependency. References that cannot be checked without
+// fetching, such as branches, are assumed to be met.
+func lockSatisfies(d *cfg.Dependency, l *cfg.Lock) error {
+	if d.Reference == "" || d.Reference == l.Version {
+		return nil
+	}
+
+	if commitRe.MatchString(d.Reference) {
+		if strings.HasPrefix(l.Version, d.Reference) {
+			return nil
+		}
+		return fmt.Errorf("%s is pinned to %s but glide.lock has %s", d.Name, d.Reference, l.Version)
+	}
+
+	con, cerr := cfg.NewConstraint(d.Reference)
+	if v, err := semver.NewVersion(l.Version); err == nil && cerr == nil {
+		if con.Check(v) {
+			return nil
+		}
+		return fmt.Errorf("%s is locked to %s which does not meet the constraint %s", d.Name, l.Version, d.Reference)
+	}
+
+	tags, err := cachedTags(d, l.Version)
+	if err != nil {
+		msg.Debug("Unable to check the version of %s: %s", d.Name, err)
+		return nil
+	}
+	for _, t := range tags {
+		if t == d.Reference {
+			return nil
+		}
+	}
+
+	if cerr != nil {
+		// A branch or tag name. Tags are found above and branches move so
+		// there is nothing more to check.
+		return nil
+	}
+	for _, t := range tags {
+		tv, ok := d.TagVersion(t)
+		if !ok {
+			continue
+		}
+		if v, err := semver.NewVersion(tv); err == nil && con.Check(v) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is locked to %s which does not meet the constraint %s", d.Name, l.Version, d.Reference)
+}
+
+// cachedTags returns the tags pointing at a commit using the copy of the repo
+// in the cache or, when it is not cached, the API of its host. It fails when
+// neither is available.
+func cachedTags(d *cfg.Dependency, commit string) ([]string, error) {
+	repo, err := cachedRepo(d)
+	if err != nil {
+		refs, herr := hosting.ListRefs(d.Remote())
+		if herr != nil {
+			return nil, err
+		}
+		return refs.TagsAt(commit), nil
+	}
+	return repo.TagsFromCommit(commit)
+}
+
+// cachedRepo returns the copy of the repo of a dependency in the cache. It
+// fails when the repo is not cached.
+func cachedRepo(d *cfg.Dependency) (vcs.Repo, error) {
+	key, err := cache.Key(d.Remote())
+	if err != nil {
+		return nil, err
+	}
+	cdir := filepath.Join(cache.Location(), "src", key)
+	if _, err := os.Stat(cdir); err != nil {
+		return nil, err
+	}
+	return d.GetRepo(cdir)
+}
@@ -0,0 +1,44 @@
+package action
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/glide/cfg"
+)
+
+func TestLockProblems(t *testing.T) {
+	conf := &cfg.Config{
+		Name: "example.com/foo",
+		Imports: cfg.Dependencies{
+			&cfg.Dependency{Name: "github.com/Masterminds/semver", Reference: "^1.2.0"},
+			&cfg.Dependency{Name: "github.com/Masterminds/vcs", Reference: "3084677c2c18"},
+		},
+	}
+	hash, err := conf.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock := &cfg.Lockfile{
+		Hash: hash,
+		Imports: cfg.Locks{
+			&cfg.Lock{Name: "github.com/Masterminds/semver", Version: "1.3.0"},
+			&cfg.Lock{Name: "github.com/Masterminds/vcs", Version: "3084677c2c188840777bff30054f2b553729d329"},
+		},
+	}
+
+	if p := lockProblems(conf, lock); len(p) != 0 {
+		t.Errorf("Expected no problems, got %v", p)
+	}
+
+	lock.Hash = "abc"
+	lock.Imports[0].Version = "2.0.0"
+	lock.Imports = lock.Imports[:1]
+	p := lockProblems(conf, lock)
+	if len(p) != 3 {
+		t.Fatalf("Expected 3 problems, got %v", p)
+	}
+	if !strings.Contains(p[1], "constraint ^1.2.0") || !strings.Contains(p[2], "not in glide.lock") {
+		t.Errorf("Unexpected problems %v", p)
+	}
+}
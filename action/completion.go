@@ -0,0 +1,246 @@
+package action
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// CompletionCommand describes a command, and the commands under it, for
+// generating shell completions.
+type CompletionCommand struct {
+	// Names holds the name of the command followed by its aliases.
+	Names []string
+	Usage string
+
+	// Flags holds the names of the flags, without dashes. A flag with more
+	// than one name is listed once for each.
+	Flags []string
+
+	// Dependencies is true for commands taking the names of dependencies
+	// listed in glide.yaml as arguments.
+	Dependencies bool
+
+	Subcommands []*CompletionCommand
+}
+
+// Completion writes a script completing the commands, flags and dependency
+// names for glide in the given shell.
+//
+// Params:
+//  - shell (string): One of bash, zsh, or fish
+//  - root (*CompletionCommand): The glide command itself
+func Completion(shell string, root *CompletionCommand) {
+	w := msg.Default.Stdout
+	paths := completionPaths(root, "", nil)
+	switch shell {
+	case "bash":
+		writeBashCompletion(w, paths)
+	case "zsh":
+		writeZshCompletion(w, paths)
+	case "fish":
+		writeFishCompletion(w, paths)
+	default:
+		msg.Die("Unsupported shell %q: must be one of: bash|zsh|fish", shell)
+	}
+}
+
+// CompletionDependencies prints the names of the dependencies listed in
+// glide.yaml, one per line, for completion scripts. Nothing is printed when
+// there is no usable glide.yaml.
+func CompletionDependencies() {
+	yamlpath, err := gpath.Glide()
+	if err != nil {
+		return
+	}
+	yml, err := ioutil.ReadFile(yamlpath)
+	if err != nil {
+		return
+	}
+	conf, err := cfg.ConfigFromYaml(yml)
+	if err != nil {
+		return
+	}
+	for _, d := range conf.Imports {
+		msg.Puts("%s", d.Name)
+	}
+	for _, d := range conf.DevImports {
+		msg.Puts("%s", d.Name)
+	}
+}
+
+// completionPath is a command along with the path of names leading to it, such
+// as "glide import godep". Scripts find the path from the words typed so far.
+type completionPath struct {
+	path    string
+	cmd     *CompletionCommand
+	parent  string
+	aliases []string
+}
+
+// completionPaths lists c and the commands under it, parents first.
+func completionPaths(c *CompletionCommand, parent string, paths []*completionPath) []*completionPath {
+	p := c.Names[0]
+	if parent != "" {
+		p = parent + " " + p
+	}
+	paths = append(paths, &completionPath{path: p, cmd: c, parent: parent, aliases: c.Names})
+	for _, s := range c.Subcommands {
+		paths = completionPaths(s, p, paths)
+	}
+	return paths
+}
+
+// dashed returns flag names with the dashes used on the command line.
+func dashed(flags []string) []string {
+	d := make([]string, len(flags))
+	for i, f := range flags {
+		if len(f) == 1 {
+			d[i] = "-" + f
+		} else {
+			d[i] = "--" + f
+		}
+	}
+	return d
+}
+
+// quote returns s in single quotes for a shell script.
+func quote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+func writeBashCompletion(w io.Writer, paths []*completionPath) {
+	fmt.Fprint(w, `# bash completion for glide, generated by 'glide completion bash'.
+_glide() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" cmdpath="glide" words w i
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        w="${COMP_WORDS[i]}"
+        [[ "$w" == -* ]] && continue
+        case "$cmdpath $w" in
+`)
+	for _, p := range paths[1:] {
+		var cases []string
+		for _, a := range p.aliases {
+			cases = append(cases, quote(p.parent+" "+a))
+		}
+		fmt.Fprintf(w, "            %s) cmdpath=%s ;;\n", strings.Join(cases, "|"), quote(p.path))
+	}
+	fmt.Fprint(w, `        esac
+    done
+    case "$cmdpath" in
+`)
+	for _, p := range paths {
+		var words []string
+		for _, s := range p.cmd.Subcommands {
+			words = append(words, s.Names...)
+		}
+		words = append(words, dashed(p.cmd.Flags)...)
+		fmt.Fprintf(w, "        %s) words=%s", quote(p.path), quote(strings.Join(words, " ")))
+		if p.cmd.Dependencies {
+			fmt.Fprint(w, `" $(glide completion --dependencies 2>/dev/null)"`)
+		}
+		fmt.Fprint(w, " ;;\n")
+	}
+	fmt.Fprint(w, `    esac
+    COMPREPLY=($(compgen -W "$words" -- "$cur"))
+}
+complete -F _glide glide
+`)
+}
+
+func writeZshCompletion(w io.Writer, paths []*completionPath) {
+	fmt.Fprint(w, `#compdef glide
+# zsh completion for glide, generated by 'glide completion zsh'.
+_glide() {
+    local cmdpath="glide" w
+    local -a cmds flags deps
+    for w in ${words[2,CURRENT-1]}; do
+        [[ "$w" == -* ]] && continue
+        case "$cmdpath $w" in
+`)
+	for _, p := range paths[1:] {
+		var cases []string
+		for _, a := range p.aliases {
+			cases = append(cases, quote(p.parent+" "+a))
+		}
+		fmt.Fprintf(w, "            %s) cmdpath=%s ;;\n", strings.Join(cases, "|"), quote(p.path))
+	}
+	fmt.Fprint(w, `        esac
+    done
+    case "$cmdpath" in
+`)
+	for _, p := range paths {
+		var cmds []string
+		for _, s := range p.cmd.Subcommands {
+			for _, n := range s.Names {
+				cmds = append(cmds, quote(n+":"+strings.Replace(s.Usage, ":", `\:`, -1)))
+			}
+		}
+		fmt.Fprintf(w, "        %s)\n", quote(p.path))
+		fmt.Fprintf(w, "            cmds=(%s)\n", strings.Join(cmds, " "))
+		fmt.Fprintf(w, "            flags=(%s)\n", strings.Join(dashed(p.cmd.Flags), " "))
+		if p.cmd.Dependencies {
+			fmt.Fprint(w, "            deps=(${(f)\"$(glide completion --dependencies 2>/dev/null)\"})\n")
+		}
+		fmt.Fprint(w, "            ;;\n")
+	}
+	fmt.Fprint(w, `    esac
+    (( ${#cmds} )) && _describe -t commands 'glide command' cmds
+    (( ${#deps} )) && compadd -a deps
+    compadd -a flags
+}
+compdef _glide glide
+`)
+}
+
+func writeFishCompletion(w io.Writer, paths []*completionPath) {
+	fmt.Fprint(w, `# fish completion for glide, generated by 'glide completion fish'.
+function __glide_path
+    set -l cmdpath glide
+    set -l words (commandline -opc)
+    set -e words[1]
+    for w in $words
+        switch $w
+            case '-*'
+                continue
+        end
+        switch "$cmdpath $w"
+`)
+	for _, p := range paths[1:] {
+		var cases []string
+		for _, a := range p.aliases {
+			cases = append(cases, quote(p.parent+" "+a))
+		}
+		fmt.Fprintf(w, "            case %s\n                set cmdpath %s\n", strings.Join(cases, " "), quote(p.path))
+	}
+	fmt.Fprint(w, `        end
+    end
+    echo $cmdpath
+end
+
+complete -c glide -f
+`)
+	for _, p := range paths {
+		cond := quote("test (__glide_path) = \"" + p.path + "\"")
+		for _, s := range p.cmd.Subcommands {
+			for _, n := range s.Names {
+				fmt.Fprintf(w, "complete -c glide -n %s -a %s -d %s\n", cond, quote(n), quote(s.Usage))
+			}
+		}
+		for _, f := range p.cmd.Flags {
+			opt := "-l"
+			if len(f) == 1 {
+				opt = "-s"
+			}
+			fmt.Fprintf(w, "complete -c glide -n %s %s %s\n", cond, opt, quote(f))
+		}
+		if p.cmd.Dependencies {
+			fmt.Fprintf(w, "complete -c glide -n %s -a '(glide completion --dependencies 2>/dev/null)'\n", cond)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package action
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func testCompletionPaths() []*completionPath {
+	root := &CompletionCommand{
+		Names: []string{"glide"},
+		Flags: []string{"yaml", "y"},
+		Subcommands: []*CompletionCommand{
+			{Names: []string{"remove", "rm"}, Usage: "Remove a package", Flags: []string{"delete", "d"}, Dependencies: true},
+			{Names: []string{"mirror"}, Usage: "Manage mirrors", Subcommands: []*CompletionCommand{
+				{Names: []string{"set"}, Usage: "Set a mirror"},
+			}},
+		},
+	}
+	return completionPaths(root, "", nil)
+}
+
+func TestCompletionPaths(t *testing.T) {
+	paths := testCompletionPaths()
+	var names []string
+	for _, p := range paths {
+		names = append(names, p.path)
+	}
+	if strings.Join(names, ",") != "glide,glide remove,glide mirror,glide mirror set" {
+		t.Errorf("Unexpected completion paths %v", names)
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	paths := testCompletionPaths()
+
+	var b bytes.Buffer
+	writeBashCompletion(&b, paths)
+	for _, s := range []string{
+		`'glide remove'|'glide rm') cmdpath='glide remove' ;;`,
+		`'glide') words='remove rm mirror --yaml -y' ;;`,
+		`'glide remove') words='--delete -d'" $(glide completion --dependencies 2>/dev/null)" ;;`,
+		`'glide mirror set') words='' ;;`,
+	} {
+		if !strings.Contains(b.String(), s) {
+			t.Errorf("Expected the bash script to contain %s, got:\n%s", s, b.String())
+		}
+	}
+
+	b.Reset()
+	writeZshCompletion(&b, paths)
+	if !strings.Contains(b.String(), `cmds=('remove:Remove a package' 'rm:Remove a package' 'mirror:Manage mirrors')`) {
+		t.Errorf("Expected the zsh script to describe commands, got:\n%s", b.String())
+	}
+
+	b.Reset()
+	writeFishCompletion(&b, paths)
+	for _, s := range []string{
+		`complete -c glide -n 'test (__glide_path) = "glide"' -a 'rm' -d 'Remove a package'`,
+		`complete -c glide -n 'test (__glide_path) = "glide remove"' -s 'd'`,
+		`complete -c glide -n 'test (__glide_path) = "glide remove"' -a '(glide completion --dependencies 2>/dev/null)'`,
+	} {
+		if !strings.Contains(b.String(), s) {
+			t.Errorf("Expected the fish script to contain %s, got:\n%s", s, b.String())
+		}
+	}
+}
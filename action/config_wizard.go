@@ -9,6 +9,7 @@ import (
 
 	"github.com/Masterminds/glide/cache"
 	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/hosting"
 	"github.com/Masterminds/glide/msg"
 	gpath "github.com/Masterminds/glide/path"
 	"github.com/Masterminds/semver"
@@ -18,7 +19,7 @@ import (
 // ConfigWizard reads configuration from a glide.yaml file and attempts to suggest
 // improvements. The wizard is interactive.
 func ConfigWizard(base string) {
-	cache.SystemLock()
+	EnsureCacheLock()
 	_, err := gpath.Glide()
 	glidefile := gpath.GlideFile
 	if err != nil {
@@ -36,7 +37,7 @@ func ConfigWizard(base string) {
 		}
 	}
 
-	conf := EnsureConfig()
+	conf := EnsureValidConfig()
 
 	cache.Setup()
 
@@ -239,7 +240,7 @@ func wizardAskLatest(latest string, d *cfg.Dependency) bool {
 }
 
 func wizardLookInto(d *cfg.Dependency) bool {
-	_, err := semver.NewConstraint(d.Reference)
+	_, err := cfg.NewConstraint(d.Reference)
 
 	// The existing version is already a valid semver constraint so we skip suggestions.
 	if err == nil {
@@ -263,7 +264,7 @@ func wizardFindVersions(d *cfg.Dependency) {
 	}
 
 	local := filepath.Join(l, "src", key)
-	repo, err := vcs.NewRepo(remote, local)
+	repo, err := d.GetRepo(local)
 	if err != nil {
 		msg.Debug("Problem getting repo instance: %s", err)
 		return
@@ -274,12 +275,30 @@ func wizardFindVersions(d *cfg.Dependency) {
 		useLocal = true
 	}
 
+	// The APIs of GitHub, GitLab, and Bitbucket list the tags without
+	// fetching the codebase locally.
+	if !useLocal {
+		if refs, err := hosting.ListRefs(remote); err == nil {
+			cache.MemTouch(remote)
+			for _, tg := range refs.TagNames() {
+				cache.MemPut(remote, tg)
+			}
+			for _, tg := range refs.TagsAt(d.Reference) {
+				cache.MemSetCurrent(remote, tg)
+			}
+			return
+		} else if err != hosting.ErrUnsupported {
+			msg.Debug("Problem listing tags of %s: %s", remote, err)
+		}
+	}
+
 	// Git endpoints allow for querying without fetching the codebase locally.
 	// We try that first to avoid fetching right away. Is this premature
 	// optimization?
 	cc := true
 	if !useLocal && repo.Vcs() == vcs.Git {
 		out, err2 := exec.Command("git", "ls-remote", remote).CombinedOutput()
+		msg.Output("git", out)
 		if err2 == nil {
 			cache.MemTouch(remote)
 			cc = false
@@ -1,19 +1,23 @@
 package action
 
 import (
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 
+	"github.com/Masterminds/glide/cache"
 	"github.com/Masterminds/glide/cfg"
 	"github.com/Masterminds/glide/dependency"
 	"github.com/Masterminds/glide/gb"
 	"github.com/Masterminds/glide/godep"
 	"github.com/Masterminds/glide/gpm"
+	"github.com/Masterminds/glide/mirrors"
 	"github.com/Masterminds/glide/msg"
 	gpath "github.com/Masterminds/glide/path"
 	"github.com/Masterminds/glide/util"
+	"github.com/Masterminds/semver"
 )
 
 // Create creates/initializes a new Glide repository.
@@ -25,18 +29,59 @@ import (
 // If skipImport is set to true, this will not attempt to import from an existing
 // GPM, Godep, or GB project if one should exist. However, it will still attempt
 // to read the local source to determine required packages.
-func Create(base string, skipImport, nonInteractive bool) {
+//
+// If fromVendor is set to true, the versions of the dependencies are taken from
+// the copies already in the vendor directory and a lock file is written too.
+//
+// If importsOnly is set to true, only the packages imported by the code of the
+// project are listed, without versions. Other package managers are not
+// imported from, import paths are not looked up over the network, and there
+// are no prompts.
+//
+// If suggestVersions is set to true, dependencies left without a version are
+// given a caret constraint on the latest release of their repo.
+func Create(base string, skipImport, nonInteractive, fromVendor, importsOnly, suggestVersions bool) {
+	if importsOnly {
+		if fromVendor {
+			msg.Die("--from-imports-only cannot be used with --from-vendor")
+		}
+		if suggestVersions {
+			msg.Die("--from-imports-only cannot be used with --suggest-versions")
+		}
+		skipImport = true
+		nonInteractive = true
+		util.Offline = true
+	}
+
 	glidefile := gpath.GlideFile
 	// Guard against overwrites.
 	guardYAML(glidefile)
+	if fromVendor {
+		guardYAML(gpath.LockFile)
+	}
 
 	// Guess deps
 	conf := guessDeps(base, skipImport)
+	var lock *cfg.Lockfile
+	if fromVendor {
+		msg.Info("Finding the versions of the vendored dependencies")
+		lock = vendorLock(base, conf)
+	}
+	if suggestVersions {
+		suggestDepVersions(conf)
+	}
 	// Write YAML
 	msg.Info("Writing configuration file (%s)", glidefile)
 	if err := conf.WriteFile(glidefile); err != nil {
 		msg.Die("Could not save %s: %s", glidefile, err)
 	}
+	if lock != nil {
+		msg.Info("Writing lock file (%s)", gpath.LockFile)
+		if err := lock.WriteFile(gpath.LockFile); err != nil {
+			msg.Die("Could not save %s: %s", gpath.LockFile, err)
+		}
+	}
+	writeIgnoreFile(base)
 
 	var res bool
 	if !nonInteractive {
@@ -57,6 +102,33 @@ func Create(base string, skipImport, nonInteractive bool) {
 	}
 }
 
+// suggestDepVersions sets the version of each dependency in conf that has none
+// to a caret constraint on the latest release of its repo. Prereleases are not
+// suggested. Dependencies without releases are left as they are.
+func suggestDepVersions(conf *cfg.Config) {
+	EnsureCacheLock()
+	cache.Setup()
+	if err := mirrors.Load(); err != nil {
+		msg.Err("Unable to load mirrors: %s", err)
+	}
+
+	msg.Info("Looking up the releases of the dependencies to suggest versions")
+	for _, d := range append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...) {
+		if d.Reference != "" {
+			continue
+		}
+		wizardFindVersions(d)
+		latest := latestRelease(cache.MemVersions(d.Remote()))
+		if latest == "" {
+			msg.Info("--> No releases found for %s, leaving its version blank", d.Name)
+			continue
+		}
+		v, _ := semver.NewVersion(latest)
+		d.Reference = "^" + v.String()
+		msg.Info("--> Suggesting %s for %s", d.Reference, d.Name)
+	}
+}
+
 // guardYAML fails if the given file already exists.
 //
 // This prevents an important file from being overwritten.
@@ -243,3 +315,26 @@ func guessImportGB(dir string) ([]*cfg.Dependency, bool) {
 
 	return d, true
 }
+
+// ignoreTemplate is written to new projects without a .glideignore file.
+const ignoreTemplate = `# Paths Glide skips when scanning this project for dependencies, and when
+# copying it into the vendor/ directory of other projects, in .gitignore syntax.
+# For example:
+#
+# /examples/
+# /internal/generated/
+# fixtures/
+`
+
+// writeIgnoreFile writes a commented .glideignore file to base unless there
+// already is one.
+func writeIgnoreFile(base string) {
+	p := filepath.Join(base, gpath.IgnoreFile)
+	if _, err := os.Stat(p); err == nil {
+		return
+	}
+	msg.Info("Writing %s for paths to skip when scanning for dependencies", gpath.IgnoreFile)
+	if err := ioutil.WriteFile(p, []byte(ignoreTemplate), 0644); err != nil {
+		msg.Warn("Could not save %s: %s", p, err)
+	}
+}
@@ -0,0 +1,155 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/glide/util"
+)
+
+func TestCreateFromImportsOnly(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "glide-create")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(gopath)
+	defer gpath.SetHome(gpath.Home())
+	gpath.SetHome(filepath.Join(gopath, "home"))
+
+	base := filepath.Join(gopath, "src", "example.com", "app")
+	write := func(p, content string) {
+		p = filepath.Join(base, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("main.go", "package main\n\nimport (\n\t_ \"example.invalid/foo\"\n\t_ \"github.com/Masterminds/semver\"\n)\n\nfunc main() {}\n")
+	write("main_test.go", "package main\n\nimport _ \"github.com/stretchr/testify/assert\"\n")
+	// Another package manager would give versions, and is to be left alone.
+	write("Godeps/Godeps.json", `{"ImportPath": "example.com/app", "Deps": [{"ImportPath": "github.com/Masterminds/semver", "Rev": "abc123"}]}`)
+
+	oldGopath := os.Getenv("GOPATH")
+	defer os.Setenv("GOPATH", oldGopath)
+	os.Setenv("GOPATH", gopath)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(base); err != nil {
+		t.Fatal(err)
+	}
+	defer func(o bool) { util.Offline = o }(util.Offline)
+	util.Offline = false
+
+	oldPanic, oldErr := msg.Default.PanicOnDie, msg.Default.Stderr
+	defer func() {
+		msg.Default.PanicOnDie, msg.Default.Stderr = oldPanic, oldErr
+	}()
+	msg.Default.PanicOnDie = true
+	msg.Default.Stderr = ioutil.Discard
+
+	// Prompting would block on stdin, so finishing shows there were none.
+	Create(base, false, false, false, true, false)
+
+	if !util.Offline {
+		t.Error("Expected import paths not to be looked up over the network")
+	}
+	yml, err := ioutil.ReadFile(filepath.Join(base, gpath.GlideFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf, err := cfg.ConfigFromYaml(yml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []string{"example.invalid/foo", "github.com/Masterminds/semver"} {
+		if !conf.Imports.Has(n) {
+			t.Errorf("Expected %s to be listed", n)
+		}
+	}
+	if !conf.DevImports.Has("github.com/stretchr/testify") {
+		t.Error("Expected github.com/stretchr/testify to be listed as a test import")
+	}
+	for _, d := range append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...) {
+		if d.Reference != "" || d.Repository != "" {
+			t.Errorf("Expected %s to be listed without a version or repo, got %q %q", d.Name, d.Reference, d.Repository)
+		}
+	}
+}
+
+func TestSuggestDepVersions(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+	home, err := ioutil.TempDir("", "glide-suggest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer gpath.SetHome(gpath.Home())
+	gpath.SetHome(home)
+	cache.SetupReset()
+	defer cache.SetupReset()
+	defer cache.SystemUnlock()
+
+	newRepo := func(name string, tags ...string) string {
+		dir := filepath.Join(home, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		git := func(args ...string) {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = dir
+			cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=glide", "GIT_AUTHOR_EMAIL=glide@example.com",
+				"GIT_COMMITTER_NAME=glide", "GIT_COMMITTER_EMAIL=glide@example.com")
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v: %s: %s", args, err, out)
+			}
+		}
+		git("init", "-q")
+		for _, tag := range tags {
+			git("commit", "-q", "--allow-empty", "-m", tag)
+			git("tag", tag)
+		}
+		if len(tags) == 0 {
+			git("commit", "-q", "--allow-empty", "-m", "first")
+		}
+		return "file://" + filepath.ToSlash(dir)
+	}
+
+	conf := &cfg.Config{
+		Name: "example.com/app",
+		Imports: cfg.Dependencies{
+			{Name: "example.com/released", Repository: newRepo("released", "v1.0.0", "v1.2.0", "v2.0.0-beta.1")},
+			{Name: "example.com/unreleased", Repository: newRepo("unreleased")},
+			{Name: "example.com/set", Repository: newRepo("set", "v1.0.0", "v1.1.0"), Reference: "~1.0.0"},
+		},
+		DevImports: cfg.Dependencies{
+			{Name: "example.com/prerelease", Repository: newRepo("prerelease", "v0.9.0", "v1.0.0-rc.1")},
+		},
+	}
+	suggestDepVersions(conf)
+
+	expected := map[string]string{
+		"example.com/released":   "^1.2.0",
+		"example.com/unreleased": "",
+		"example.com/set":        "~1.0.0",
+		"example.com/prerelease": "^0.9.0",
+	}
+	for _, d := range append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...) {
+		if d.Reference != expected[d.Name] {
+			t.Errorf("Expected %q to be suggested for %s, got %q", expected[d.Name], d.Name, d.Reference)
+		}
+	}
+}
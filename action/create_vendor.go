@@ -0,0 +1,207 @@
+package action
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/mirrors"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/glide/repo"
+	"github.com/Masterminds/semver"
+	"github.com/Masterminds/vcs"
+)
+
+// vendorLock finds the origin and revision of the dependencies already in the
+// vendor directory, records them in conf, and returns the lock for them. The
+// VCS metadata of a vendored copy is used when it has some. Otherwise the
+// copy is compared to the tags of the repo. Nil is returned when the revision
+// of a dependency cannot be found.
+func vendorLock(base string, conf *cfg.Config) *cfg.Lockfile {
+	vpath := filepath.Join(base, gpath.VendorDir)
+	if _, err := os.Stat(vpath); err != nil {
+		msg.Die("No %s directory to create the configuration from", gpath.VendorDir)
+	}
+	EnsureCacheLock()
+	if err := mirrors.Load(); err != nil {
+		msg.Err("Unable to load mirrors: %s", err)
+	}
+
+	complete := true
+	for _, d := range append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...) {
+		dir := filepath.Join(vpath, filepath.FromSlash(d.Name))
+		if _, err := os.Stat(dir); err != nil {
+			msg.Warn("--> %s is not in the %s directory", d.Name, gpath.VendorDir)
+			complete = false
+			continue
+		}
+		if err := vendoredVersion(d, dir); err != nil {
+			msg.Warn("--> Unable to find the version of %s: %s", d.Name, err)
+			complete = false
+			continue
+		}
+		if d.Reference == d.Pin {
+			msg.Info("--> Found %s at %s", d.Name, d.Pin)
+		} else {
+			msg.Info("--> Found %s at %s (%s)", d.Name, d.Reference, d.Pin)
+		}
+	}
+	if !complete {
+		msg.Warn("Not writing %s as some versions are unknown. Set them in %s and run 'glide update'", gpath.LockFile, gpath.GlideFile)
+		return nil
+	}
+
+	hash, err := conf.Hash()
+	if err != nil {
+		msg.Die("Failed to generate config hash. Unable to generate lock file.")
+	}
+	lock, err := cfg.NewLockfile(conf.Imports, conf.DevImports, hash)
+	if err != nil {
+		msg.Die("Failed to generate lock file: %s", err)
+	}
+	return lock
+}
+
+// vendoredVersion sets the revision and, when it is not the default one, the
+// repo of a dependency from its vendored copy in dir. The version is the tag
+// pointing at the revision, if there is one, or else the revision.
+func vendoredVersion(d *cfg.Dependency, dir string) error {
+	r, err := vendoredRepo(dir)
+	if err == nil {
+		if d.Pin, err = r.Version(); err != nil {
+			return err
+		}
+		if r.Remote() != "" && r.Remote() != d.Remote() {
+			d.Repository = r.Remote()
+			d.VcsType = string(r.Vcs())
+		}
+	} else {
+		msg.Debug("No VCS metadata in %s, comparing it to the tags of %s", dir, d.Remote())
+		if r, err = matchVendored(d, dir); err != nil {
+			return err
+		}
+	}
+
+	d.Reference = d.Pin
+	tags, err := r.TagsFromCommit(d.Pin)
+	if err != nil {
+		return nil
+	}
+	if t := newestTag(tags); t != "" {
+		d.Reference = t
+	}
+	return nil
+}
+
+// vendoredRepo returns the repo in dir using its VCS metadata. It fails when
+// dir is not a checkout.
+func vendoredRepo(dir string) (vcs.Repo, error) {
+	return repo.OpenRepo(dir)
+}
+
+// matchVendored sets the revision of a dependency to the newest tag of its
+// repo holding the same files as the vendored copy in dir. It returns the
+// copy of the repo in the cache.
+func matchVendored(d *cfg.Dependency, dir string) (vcs.Repo, error) {
+	if err := repo.VcsGet(d); err != nil {
+		return nil, err
+	}
+	r, err := cachedRepo(d)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := r.Tags()
+	if err != nil {
+		return nil, err
+	}
+	sortTagsNewestFirst(tags)
+	for _, t := range tags {
+		if err := r.UpdateVersion(t); err != nil {
+			msg.Debug("Unable to check out %s of %s: %s", t, d.Name, err)
+			continue
+		}
+		if sameFiles(dir, r.LocalPath()) {
+			d.Pin, err = r.Version()
+			return r, err
+		}
+	}
+	return nil, errNoMatchingTag
+}
+
+var (
+	errNoMatchingTag = errors.New("the vendored copy does not match any tag")
+	errNotSame       = errors.New("the files differ")
+)
+
+// sameFiles returns true if every file in the vendored copy in dir has the
+// same content in src. Vendored copies are often trimmed, so src may have
+// more files.
+func sameFiles(dir, src string) bool {
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			switch fi.Name() {
+			case ".git", ".hg", ".bzr", ".svn", "_darcs":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		sfi, err := os.Stat(filepath.Join(src, rel))
+		if err != nil || sfi.Size() != fi.Size() {
+			return errNotSame
+		}
+		a, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadFile(filepath.Join(src, rel))
+		if err != nil || !bytes.Equal(a, b) {
+			return errNotSame
+		}
+		return nil
+	})
+	return err == nil
+}
+
+// sortTagsNewestFirst sorts semantic version tags from the newest to the
+// oldest followed by the other tags.
+func sortTagsNewestFirst(tags []string) {
+	sort.Sort(tagsNewestFirst(tags))
+}
+
+// newestTag returns the newest of the tags, preferring semantic versions.
+func newestTag(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	t := append([]string{}, tags...)
+	sortTagsNewestFirst(t)
+	return t[0]
+}
+
+type tagsNewestFirst []string
+
+func (t tagsNewestFirst) Len() int      { return len(t) }
+func (t tagsNewestFirst) Swap(i, j int) { t[i], t[j] = t[j], t[i] }
+func (t tagsNewestFirst) Less(i, j int) bool {
+	a, aerr := semver.NewVersion(t[i])
+	b, berr := semver.NewVersion(t[j])
+	switch {
+	case aerr == nil && berr == nil:
+		return a.GreaterThan(b)
+	case aerr == nil || berr == nil:
+		return aerr == nil
+	}
+	return t[i] < t[j]
+}
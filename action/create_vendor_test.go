@@ -0,0 +1,58 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSameFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-create-vendor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(p, content string) {
+		p = filepath.Join(dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("vendored/foo.go", "package foo")
+	write("vendored/bar/bar.go", "package bar")
+	write("vendored/.git/HEAD", "ref: refs/heads/master")
+	write("repo/foo.go", "package foo")
+	write("repo/foo_test.go", "package foo")
+	write("repo/bar/bar.go", "package bar")
+	write("changed/foo.go", "package foo // changed")
+	write("changed/bar/bar.go", "package bar")
+
+	vendored := filepath.Join(dir, "vendored")
+	if !sameFiles(vendored, filepath.Join(dir, "repo")) {
+		t.Error("Expected a trimmed copy to match the repo")
+	}
+	if sameFiles(vendored, filepath.Join(dir, "changed")) {
+		t.Error("Expected a changed file not to match")
+	}
+	if sameFiles(filepath.Join(dir, "repo"), vendored) {
+		t.Error("Expected a missing file not to match")
+	}
+}
+
+func TestSortTagsNewestFirst(t *testing.T) {
+	tags := []string{"v1.0.0", "release", "v1.10.0", "1.2.0", "beta", "v2.0.0-rc.1"}
+	sortTagsNewestFirst(tags)
+	expected := []string{"v2.0.0-rc.1", "v1.10.0", "1.2.0", "v1.0.0", "beta", "release"}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("Expected %v, got %v", expected, tags)
+	}
+	if n := newestTag([]string{"latest", "v1.1.0", "v1.0.0"}); n != "v1.1.0" {
+		t.Errorf("Expected v1.1.0 to be the newest tag, got %s", n)
+	}
+}
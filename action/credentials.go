@@ -0,0 +1,98 @@
+package action
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/glide/credentials"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// askPassEnv marks Glide being run by Git to answer a credential prompt and
+// holds the GLIDE_HOME to read config.yaml from.
+const askPassEnv = "GLIDE_ASKPASS"
+
+// Credentials lets Git ask Glide for the credentials in config.yaml when it
+// has none from .netrc or its credential helpers, by setting GIT_ASKPASS to
+// Glide. It is left alone when GIT_ASKPASS is already set or config.yaml has
+// no credentials. See AskPass.
+func Credentials() {
+	if os.Getenv("GIT_ASKPASS") != "" || !credentials.Configured() {
+		return
+	}
+	self, err := glideExecutable()
+	if err != nil {
+		msg.Warn("Unable to find the Glide executable to answer Git credential prompts: %s", err)
+		return
+	}
+	home, err := filepath.Abs(gpath.Home())
+	if err != nil {
+		msg.Warn("Unable to answer Git credential prompts: %s", err)
+		return
+	}
+	os.Setenv("GIT_ASKPASS", self)
+	os.Setenv(askPassEnv, home)
+}
+
+// AskPass answers the credential prompt Glide was run with by Git, as set up
+// by Credentials, from config.yaml and exits. Otherwise it returns right away.
+func AskPass() {
+	home := os.Getenv(askPassEnv)
+	if home == "" || len(os.Args) != 2 {
+		return
+	}
+	field, host := askPassPrompt(os.Args[1])
+	if field == "" {
+		return
+	}
+	gpath.SetHome(home)
+	c := credentials.FromConfig(host)
+	if c == nil {
+		os.Exit(1)
+	}
+	if field == "username" {
+		fmt.Println(c.Username)
+	} else {
+		fmt.Println(c.Password)
+	}
+	os.Exit(0)
+}
+
+// askPassPrompt returns whether a Git prompt, such as
+// "Username for 'https://git.example.com': ", asks for the username or the
+// password, and of which host. The field is empty for other prompts.
+func askPassPrompt(prompt string) (string, string) {
+	var field string
+	switch {
+	case strings.HasPrefix(prompt, "Username for "):
+		field = "username"
+	case strings.HasPrefix(prompt, "Password for "):
+		field = "password"
+	default:
+		return "", ""
+	}
+	start := strings.Index(prompt, "'")
+	end := strings.LastIndex(prompt, "'")
+	if start < 0 || end <= start {
+		return "", ""
+	}
+	u, err := url.Parse(prompt[start+1 : end])
+	if err != nil || u.Host == "" {
+		return "", ""
+	}
+	return field, u.Host
+}
+
+// glideExecutable returns the absolute path to the running Glide.
+func glideExecutable() (string, error) {
+	self, err := exec.LookPath(os.Args[0])
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(self)
+}
@@ -0,0 +1,20 @@
+package action
+
+import "testing"
+
+func TestAskPassPrompt(t *testing.T) {
+	tests := []struct {
+		prompt, field, host string
+	}{
+		{"Username for 'https://git.example.com': ", "username", "git.example.com"},
+		{"Password for 'https://ci@git.example.com:8443': ", "password", "git.example.com:8443"},
+		{"Are you sure you want to continue connecting (yes/no)? ", "", ""},
+		{"Username for git.example.com: ", "", ""},
+	}
+	for _, tt := range tests {
+		field, host := askPassPrompt(tt.prompt)
+		if field != tt.field || host != tt.host {
+			t.Errorf("Expected %q to ask for %q of %q, got %q of %q", tt.prompt, tt.field, tt.host, field, host)
+		}
+	}
+}
@@ -1,7 +1,14 @@
 package action
 
 import (
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"runtime"
+
 	"github.com/Masterminds/glide/msg"
+	"github.com/Masterminds/vcs"
 )
 
 // Debug sets the debugging flags across components.
@@ -18,3 +25,70 @@ func Quiet(on bool) {
 func NoColor(on bool) {
 	msg.Default.NoColor = on
 }
+
+// Progress turns the live status line for install and update on or off. It is
+// only shown when messages are displayed as text on a terminal.
+func Progress(on bool) {
+	m := msg.Default
+	m.Progress = on && !m.Quiet && m.Format != msg.JSONFormat && isTerminal(m.Stderr)
+}
+
+// isTerminal returns true if w is a terminal rather than a file or pipe.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Logging sets the level and format of messages and, when file is set, the
+// file every message is written to. Output of VCS commands is logged at the
+// debug level. When it is displayed or written to the file, the VCS commands
+// are traced as with --debug-vcs so the output of those the VCS library runs
+// itself is logged too, except on Windows.
+func Logging(level, format, file string) {
+	l, err := msg.ParseLevel(level)
+	if err != nil {
+		msg.Die("%s", err)
+	}
+	msg.Default.Level = l
+
+	switch format {
+	case msg.TextFormat, msg.JSONFormat:
+		msg.Default.Format = format
+	default:
+		msg.Die("Unknown log format %q. Use %s or %s", format, msg.TextFormat, msg.JSONFormat)
+	}
+
+	if file != "" {
+		f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			msg.Die("Unable to open log file: %s", err)
+		}
+		msg.Default.LogFile = f
+	}
+
+	vcs.Logger = log.New(msg.Default.Writer("vcs"), "", 0)
+	if (file != "" || (!msg.Default.Quiet && (msg.Default.IsDebugging || l <= msg.DebugLevel))) && runtime.GOOS != "windows" {
+		if err := traceVCS(); err != nil {
+			msg.Debug("Unable to trace VCS commands for their output: %s", err)
+		} else {
+			// The trace has the output the logger would get.
+			vcs.Logger = log.New(ioutil.Discard, "", 0)
+		}
+	}
+}
+
+// ErrorFormat sets how errors are displayed. In the JSON format each error is
+// a JSON object naming its kind, and the exit code for the error Glide exits
+// with, so wrappers can act on the kind of failure.
+func ErrorFormat(format string) {
+	switch format {
+	case msg.TextFormat, msg.JSONFormat:
+		msg.Default.ErrorFormat = format
+	default:
+		msg.Die("Unknown error format %q. Use %s or %s", format, msg.TextFormat, msg.JSONFormat)
+	}
+}
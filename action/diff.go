@@ -0,0 +1,237 @@
+package action
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/vcs"
+)
+
+// LockChange describes how a dependency differs between two lock files. Old is
+// nil for added dependencies and New for removed ones.
+type LockChange struct {
+	Name string
+	Old  *cfg.Lock
+	New  *cfg.Lock
+
+	// Transitive is set for added dependencies that are not listed in the
+	// glide.yaml file.
+	Transitive bool
+}
+
+// Diff prints the dependencies added, removed, and changed between two lock
+// files.
+//
+// Params:
+//   - from (string): A lock file, or a git revision to read glide.lock from.
+//     It defaults to HEAD.
+//   - to (string): Like from. It defaults to the glide.lock in the project.
+//   - log (bool): Also print the commits between the old and new version of
+//     changed dependencies, using the copies of their repos in the cache.
+func Diff(from, to string, log bool) {
+	base := gpath.Basepath()
+	if from == "" {
+		from = "HEAD"
+	}
+	if to == "" {
+		to = filepath.Join(base, gpath.LockFile)
+	}
+	a, err := readLockArg(from, base)
+	if err != nil {
+		msg.Die("Unable to read the lock file %s: %s", from, err)
+	}
+	b, err := readLockArg(to, base)
+	if err != nil {
+		msg.Die("Unable to read the lock file %s: %s", to, err)
+	}
+
+	changes := lockDiff(a, b)
+	if len(changes) == 0 {
+		msg.Info("No dependencies changed")
+		return
+	}
+	printChanges(changes, log, 0)
+}
+
+// printChangelog prints the dependencies changed between two lock files along
+// with the commits between their old and new versions.
+func printChangelog(old, cur *cfg.Lockfile) {
+	changes := lockDiff(old, cur)
+	if len(changes) == 0 {
+		return
+	}
+	msg.Info("Changes to dependencies:")
+	printChanges(changes, true, changelogCommits)
+}
+
+// printChanges prints changed dependencies, one per line marked with +, -, or ~
+// for added, removed, and changed ones. With log the commits between the old
+// and new versions are listed, at most max per dependency when max is above
+// zero.
+func printChanges(changes []*LockChange, log bool, max int) {
+	for _, c := range changes {
+		switch {
+		case c.Old == nil && c.Transitive:
+			msg.Puts("+ %s %s (transitive)", c.Name, shortVersion(c.New.Version))
+		case c.Old == nil:
+			msg.Puts("+ %s %s", c.Name, shortVersion(c.New.Version))
+		case c.New == nil:
+			msg.Puts("- %s %s", c.Name, shortVersion(c.Old.Version))
+		default:
+			msg.Puts("~ %s %s -> %s", c.Name, shortVersion(c.Old.Version), shortVersion(c.New.Version))
+			if c.Old.Repository != c.New.Repository {
+				msg.Puts("    repo %s -> %s", remoteOf(c.Old), remoteOf(c.New))
+			} else if log && c.Old.Version != c.New.Version {
+				printCommitLog(c.New, c.Old.Version, c.New.Version, max)
+			}
+		}
+	}
+}
+
+// changelogCommits is the most commits printChangelog lists per dependency.
+const changelogCommits = 20
+
+// readLockArg reads a lock file from a path or, when there is no such file,
+// the glide.lock of the project at a git revision.
+func readLockArg(arg, base string) (*cfg.Lockfile, error) {
+	if _, err := os.Stat(arg); err == nil {
+		return cfg.ReadLockFile(arg)
+	}
+	rel, err := filepath.Rel(gitTopLevel(base), filepath.Join(base, gpath.LockFile))
+	if err != nil {
+		return nil, err
+	}
+	c := exec.Command("git", "show", arg+":"+filepath.ToSlash(rel))
+	c.Dir = base
+	out, err := c.Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+			return nil, fmt.Errorf("no such file or git revision: %s", strings.TrimSpace(string(ee.Stderr)))
+		}
+		return nil, err
+	}
+	return cfg.LockfileFromYaml(out)
+}
+
+// gitTopLevel returns the root of the git working tree holding dir, or dir
+// itself when it cannot be found.
+func gitTopLevel(dir string) string {
+	c := exec.Command("git", "rev-parse", "--show-toplevel")
+	c.Dir = dir
+	out, err := c.Output()
+	if err != nil {
+		return dir
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// lockDiff lists the dependencies, including test ones, that differ between
+// two lock files, sorted by name. A dependency differs when its version or
+// repository changes.
+func lockDiff(a, b *cfg.Lockfile) []*LockChange {
+	old := locksByName(a)
+	cur := locksByName(b)
+
+	var changes []*LockChange
+	for n, o := range old {
+		c, ok := cur[n]
+		if !ok {
+			changes = append(changes, &LockChange{Name: n, Old: o})
+		} else if o.Version != c.Version || o.Repository != c.Repository {
+			changes = append(changes, &LockChange{Name: n, Old: o, New: c})
+		}
+	}
+	for n, c := range cur {
+		if _, ok := old[n]; !ok {
+			changes = append(changes, &LockChange{Name: n, New: c})
+		}
+	}
+	sort.Sort(lockChanges(changes))
+	return changes
+}
+
+type lockChanges []*LockChange
+
+func (l lockChanges) Len() int           { return len(l) }
+func (l lockChanges) Less(i, j int) bool { return l[i].Name < l[j].Name }
+func (l lockChanges) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+
+// locksByName maps the names of the dependencies in a lock file to their
+// locks. A dependency locked for both imports and tests is taken from imports.
+func locksByName(lf *cfg.Lockfile) map[string]*cfg.Lock {
+	m := make(map[string]*cfg.Lock, len(lf.Imports)+len(lf.DevImports))
+	for _, l := range lf.DevImports {
+		m[l.Name] = l
+	}
+	for _, l := range lf.Imports {
+		m[l.Name] = l
+	}
+	return m
+}
+
+// remoteOf returns where a locked dependency is fetched from.
+func remoteOf(l *cfg.Lock) string {
+	if l.Repository != "" {
+		return l.Repository
+	}
+	return "https://" + l.Name
+}
+
+// printCommitLog prints the commits of a dependency between two revisions, at
+// most max of them when max is above zero. Only Git and Mercurial repos in the
+// cache are supported.
+func printCommitLog(l *cfg.Lock, from, to string, max int) {
+	lines, more, err := commitLog(l, from, to, max)
+	if err != nil {
+		msg.Warn("%s", err)
+		return
+	}
+	for _, line := range lines {
+		msg.Puts("    %s", line)
+	}
+	if more > 0 {
+		msg.Puts("    ... and %d more", more)
+	}
+}
+
+// commitLog returns the commits of a dependency between two revisions, one
+// line each, at most max of them when max is above zero along with how many
+// more there are. Only Git and Mercurial repos in the cache are supported.
+func commitLog(l *cfg.Lock, from, to string, max int) ([]string, int, error) {
+	repo, err := cachedRepo(cfg.DependencyFromLock(l))
+	if err != nil {
+		return nil, 0, fmt.Errorf("Unable to show the commits of %s as it is not in the cache: %s", l.Name, err)
+	}
+	var out []byte
+	switch repo.Vcs() {
+	case vcs.Git:
+		out, err = repo.RunFromDir("git", "log", "--oneline", "--no-decorate", from+".."+to)
+	case vcs.Hg:
+		out, err = repo.RunFromDir("hg", "log", "-r", "only("+to+", "+from+")", "--template", "{node|short} {desc|firstline}\n")
+	default:
+		msg.Debug("Showing commits is not supported for %s repos", repo.Vcs())
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("Unable to show the commits of %s: %s", l.Name, strings.TrimSpace(string(out)))
+	}
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	more := 0
+	if max > 0 && len(lines) > max {
+		more = len(lines) - max
+		lines = lines[:max]
+	}
+	return lines, more, nil
+}
@@ -0,0 +1,139 @@
+package action
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+func TestLockDiff(t *testing.T) {
+	a := &cfg.Lockfile{
+		Imports: cfg.Locks{
+			{Name: "github.com/Masterminds/semver", Version: "1.3.0"},
+			{Name: "github.com/Masterminds/vcs", Version: "1.11.0"},
+			{Name: "github.com/codegangsta/cli", Version: "1.14.0"},
+		},
+		DevImports: cfg.Locks{
+			{Name: "github.com/stretchr/testify", Version: "1.1.0"},
+		},
+	}
+	b := &cfg.Lockfile{
+		Imports: cfg.Locks{
+			{Name: "github.com/Masterminds/semver", Version: "1.4.1"},
+			{Name: "github.com/Masterminds/vcs", Version: "1.11.0", Repository: "https://example.com/vcs.git"},
+			{Name: "gopkg.in/yaml.v2", Version: "a5b47d31c556af34a302ce5d659e6fea44d90de0"},
+		},
+		DevImports: cfg.Locks{
+			{Name: "github.com/stretchr/testify", Version: "1.1.0"},
+		},
+	}
+
+	changes := lockDiff(a, b)
+	want := []struct {
+		name     string
+		old, new string
+	}{
+		{"github.com/Masterminds/semver", "1.3.0", "1.4.1"},
+		{"github.com/Masterminds/vcs", "1.11.0", "1.11.0"},
+		{"github.com/codegangsta/cli", "1.14.0", ""},
+		{"gopkg.in/yaml.v2", "", "a5b47d31c556af34a302ce5d659e6fea44d90de0"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("Expected %d changes, got %d", len(want), len(changes))
+	}
+	for i, w := range want {
+		c := changes[i]
+		var o, n string
+		if c.Old != nil {
+			o = c.Old.Version
+		}
+		if c.New != nil {
+			n = c.New.Version
+		}
+		if c.Name != w.name || o != w.old || n != w.new {
+			t.Errorf("Expected change %d to be %s %q -> %q, got %s %q -> %q", i, w.name, w.old, w.new, c.Name, o, n)
+		}
+	}
+
+	if len(lockDiff(a, a)) != 0 {
+		t.Error("Expected no changes between a lock file and itself")
+	}
+}
+
+func TestCommitLog(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+	home, err := ioutil.TempDir("", "glide-changelog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer gpath.SetHome(gpath.Home())
+	gpath.SetHome(home)
+	cache.SetupReset()
+	defer cache.SetupReset()
+
+	src := filepath.Join(home, "dep")
+	remote := "file://" + filepath.ToSlash(src)
+	git := func(dir string, args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=glide", "GIT_AUTHOR_EMAIL=glide@example.com",
+			"GIT_COMMITTER_NAME=glide", "GIT_COMMITTER_EMAIL=glide@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s: %s", args, err, out)
+		}
+	}
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	git(src, "init", "-q")
+	git(src, "commit", "-q", "--allow-empty", "-m", "Initial release")
+	git(src, "tag", "v1.0.0")
+	git(src, "commit", "-q", "--allow-empty", "-m", "Add support for excluding versions")
+	git(src, "commit", "-q", "--allow-empty", "-m", "Fix parsing of wildcards")
+	git(src, "tag", "v1.1.0")
+
+	key, err := cache.Key(remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	git(home, "clone", "-q", remote, filepath.Join(cache.Location(), "src", key))
+
+	l := &cfg.Lock{Name: "example.com/dep", Version: "v1.1.0", Repository: remote, VcsType: "git"}
+	lines, more, err := commitLog(l, "v1.0.0", "v1.1.0", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 2 || more != 0 || !strings.HasSuffix(lines[0], " Fix parsing of wildcards") ||
+		!strings.HasSuffix(lines[1], " Add support for excluding versions") {
+		t.Errorf("Expected the two commits between the versions, newest first, got %q (%d more)", lines, more)
+	}
+	lines, more, err = commitLog(l, "v1.0.0", "v1.1.0", 1)
+	if err != nil || len(lines) != 1 || more != 1 {
+		t.Errorf("Expected one commit and one more, got %q (%d more, %v)", lines, more, err)
+	}
+
+	old := msg.Default.Stdout
+	defer func() {
+		msg.Default.Stdout = old
+	}()
+	var buf bytes.Buffer
+	msg.Default.Stdout = &buf
+	changes := []*LockChange{{Name: l.Name, Old: &cfg.Lock{Name: l.Name, Version: "v1.0.0", Repository: remote}, New: l}}
+	printChanges(changes, true, changelogCommits)
+	out := buf.String()
+	if !strings.HasPrefix(out, "~ example.com/dep v1.0.0 -> v1.1.0\n") || !strings.Contains(out, " Fix parsing of wildcards\n") {
+		t.Errorf("Expected the change followed by its commits, got:\n%s", out)
+	}
+}
@@ -0,0 +1,355 @@
+package action
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// diagnosis is a problem found by the doctor along with how to fix it.
+type diagnosis struct {
+	Problem, Fix string
+}
+
+// doctorCheck is a named check run by the doctor.
+type doctorCheck struct {
+	name string
+	run  func() []diagnosis
+}
+
+// goVersionRe matches the release in the output of go version.
+var goVersionRe = regexp.MustCompile(`go(\d+)\.(\d+)`)
+
+// Doctor checks the environment and the project for problems that commonly
+// break Glide or builds using the vendor directory and prints how to fix them.
+// Glide exits non-zero when a problem is found.
+func Doctor(base string) {
+	checks := []doctorCheck{
+		{"Go version", checkGoVersion},
+		{"GOPATH", func() []diagnosis { return checkGopath(base) }},
+		{"Cache", checkCache},
+	}
+
+	yamlpath, err := gpath.Glide()
+	if err != nil {
+		msg.Info("No %s found. Skipping the project checks", gpath.GlideFile)
+	} else {
+		base = filepath.Dir(yamlpath)
+		vpath := filepath.Join(base, gpath.VendorDir)
+		checks = append(checks,
+			doctorCheck{"Vendor symlinks", func() []diagnosis { return checkVendorSymlinks(vpath) }},
+			doctorCheck{"Vendor and lock file", func() []diagnosis { return checkVendorLock(yamlpath, vpath) }},
+			doctorCheck{"Case-insensitive paths", func() []diagnosis { return checkCaseDuplicates(base, vpath) }},
+		)
+	}
+
+	count := 0
+	for _, c := range checks {
+		found := c.run()
+		if len(found) == 0 {
+			msg.Info("%s: OK", c.name)
+			continue
+		}
+		for _, d := range found {
+			msg.Warn("%s: %s", c.name, d.Problem)
+			msg.Puts("\tFix: %s", d.Fix)
+		}
+		count += len(found)
+	}
+
+	if count > 0 {
+		msg.Die("Found %d problem(s)", count)
+	}
+	msg.Info("No problems found")
+}
+
+// checkGoVersion makes sure the Go toolchain supports the vendor directory.
+func checkGoVersion() []diagnosis {
+	out, err := exec.Command(goExecutable(), "version").CombinedOutput()
+	if err != nil {
+		return []diagnosis{{
+			Problem: fmt.Sprintf("Unable to run %s version: %s", goExecutable(), err),
+			Fix:     "Install Go and add it to your PATH, or set GLIDE_GO_EXECUTABLE",
+		}}
+	}
+	return goVersionProblems(string(out), os.Getenv("GO15VENDOREXPERIMENT"))
+}
+
+// goVersionProblems checks the output of go version along with the value of
+// GO15VENDOREXPERIMENT.
+func goVersionProblems(version, experiment string) []diagnosis {
+	m := goVersionRe.FindStringSubmatch(version)
+	if m == nil {
+		// Development versions have no release number.
+		return nil
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+
+	switch {
+	case major == 1 && minor < 5:
+		return []diagnosis{{
+			Problem: fmt.Sprintf("Go 1.%d does not support the vendor directory", minor),
+			Fix:     "Install Go 1.5 or newer",
+		}}
+	case major == 1 && minor == 5 && experiment != "1":
+		return []diagnosis{{
+			Problem: "Go 1.5 only uses the vendor directory when GO15VENDOREXPERIMENT=1",
+			Fix:     "Run 'export GO15VENDOREXPERIMENT=1' or install a newer Go",
+		}}
+	case major == 1 && minor <= 6 && experiment == "0":
+		return []diagnosis{{
+			Problem: "GO15VENDOREXPERIMENT=0 turns off the vendor directory",
+			Fix:     "Run 'unset GO15VENDOREXPERIMENT'",
+		}}
+	}
+	return nil
+}
+
+// checkGopath makes sure GOPATH is set up and holds the project.
+func checkGopath(base string) []diagnosis {
+	gps := gpath.Gopaths()
+	if len(gps) == 0 {
+		return []diagnosis{{
+			Problem: "GOPATH is not set",
+			Fix:     "Set GOPATH, e.g. 'export GOPATH=$HOME/go', and place the project below $GOPATH/src",
+		}}
+	}
+
+	var found []diagnosis
+	for _, gp := range gps {
+		if _, err := os.Stat(filepath.Join(gp, "src")); err != nil {
+			found = append(found, diagnosis{
+				Problem: fmt.Sprintf("%s has no src directory", gp),
+				Fix:     fmt.Sprintf("Run 'mkdir -p %s' or remove %s from GOPATH", filepath.Join(gp, "src"), gp),
+			})
+		}
+	}
+
+	if gp, _ := gopathPlacement(base, gps); gp != "" {
+		return found
+	}
+	abs, err := filepath.Abs(base)
+	if err != nil {
+		return found
+	}
+	return append(found, diagnosis{
+		Problem: fmt.Sprintf("%s is not below $GOPATH/src so the go tool ignores its vendor directory", abs),
+		Fix:     fmt.Sprintf("Move the project to %s/src/<import path>", gps[0]),
+	})
+}
+
+// checkCache makes sure the cache in GLIDE_HOME can be written to.
+func checkCache() []diagnosis {
+	loc := filepath.Join(gpath.Home(), "cache")
+	fix := fmt.Sprintf("Make %s writable, e.g. 'sudo chown -R $USER %s', or set GLIDE_HOME to another directory", loc, gpath.Home())
+
+	if _, err := os.Stat(loc); os.IsNotExist(err) {
+		// The cache is created when first used. Make sure that is possible.
+		loc = gpath.Home()
+		for {
+			if _, err := os.Stat(loc); err == nil || filepath.Dir(loc) == loc {
+				break
+			}
+			loc = filepath.Dir(loc)
+		}
+	}
+
+	f, err := ioutil.TempFile(loc, "glide-doctor")
+	if err != nil {
+		return []diagnosis{{
+			Problem: fmt.Sprintf("Unable to write to the cache: %s", err),
+			Fix:     fix,
+		}}
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return nil
+}
+
+// checkVendorSymlinks finds symlinks in the vendor directory whose target is
+// missing.
+func checkVendorSymlinks(vpath string) []diagnosis {
+	var found []diagnosis
+	filepath.Walk(vpath, func(p string, fi os.FileInfo, err error) error {
+		if err != nil || !gpath.IsLink(fi) {
+			return nil
+		}
+		if _, err := os.Stat(p); err != nil {
+			t, _ := os.Readlink(p)
+			found = append(found, diagnosis{
+				Problem: fmt.Sprintf("%s links to %s which does not exist", vendorRel(vpath, p), t),
+				Fix:     "Remove the link or restore its target, then run 'glide install'",
+			})
+		}
+		return nil
+	})
+	return found
+}
+
+// checkVendorLock compares the packages in the vendor directory to those in
+// the lock file.
+func checkVendorLock(yamlpath, vpath string) []diagnosis {
+	base := filepath.Dir(yamlpath)
+	if !gpath.HasLock(base) {
+		return []diagnosis{{
+			Problem: "There is no glide.lock file",
+			Fix:     "Run 'glide up' to create it",
+		}}
+	}
+	lock, err := cfg.ReadLockFile(filepath.Join(base, gpath.LockFile))
+	if err != nil {
+		return []diagnosis{{
+			Problem: fmt.Sprintf("Unable to read glide.lock: %s", err),
+			Fix:     "Fix the syntax of glide.lock or run 'glide up' to create it again",
+		}}
+	}
+
+	var ignored []string
+	if yml, err := ioutil.ReadFile(yamlpath); err == nil {
+		if conf, err := cfg.ConfigFromYaml(yml); err == nil {
+			ignored = conf.Ignore
+		}
+	}
+
+	var names []string
+	for _, l := range append(lock.Imports, lock.DevImports...) {
+		names = append(names, l.Name)
+	}
+	missing, extra, err := vendorLockDiff(vpath, names, ignored)
+	if err != nil {
+		return []diagnosis{{
+			Problem: fmt.Sprintf("Unable to read the vendor directory: %s", err),
+			Fix:     "Run 'glide install'",
+		}}
+	}
+
+	var found []diagnosis
+	for _, n := range missing {
+		found = append(found, diagnosis{
+			Problem: fmt.Sprintf("%s is in glide.lock but missing from %s", n, gpath.VendorDir),
+			Fix:     "Run 'glide install'",
+		})
+	}
+	for _, n := range extra {
+		found = append(found, diagnosis{
+			Problem: fmt.Sprintf("%s is in %s but not in glide.lock", n, gpath.VendorDir),
+			Fix:     "Run 'glide up' if the project uses it, or 'glide install' to remove it",
+		})
+	}
+	return found
+}
+
+// vendorLockDiff returns the locked packages missing from the vendor
+// directory and the packages in it that are not locked. Packages below a
+// locked one, including nested vendor directories, are part of it.
+func vendorLockDiff(vpath string, names, ignored []string) (missing, extra []string, err error) {
+	locked := make(map[string]bool)
+	for _, n := range names {
+		locked[n] = true
+		if fi, err := os.Stat(filepath.Join(vpath, filepath.FromSlash(n))); err != nil || !fi.IsDir() {
+			missing = append(missing, n)
+		}
+	}
+	for _, n := range ignored {
+		locked[n] = true
+	}
+
+	// A directory is a parent when a locked package is below it.
+	parents := make(map[string]bool)
+	for n := range locked {
+		for d := n; strings.Contains(d, "/"); {
+			d = d[:strings.LastIndex(d, "/")]
+			parents[d] = true
+		}
+	}
+
+	if _, serr := os.Stat(vpath); os.IsNotExist(serr) {
+		return missing, nil, nil
+	}
+	err = filepath.Walk(vpath, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == vpath {
+			return nil
+		}
+		name := vendorRel(vpath, p)
+		if fi.IsDir() {
+			if locked[name] || strings.HasPrefix(fi.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if parents[name] {
+				return nil
+			}
+			if hasGoFiles(p) {
+				extra = append(extra, name)
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return nil
+	})
+	sort.Strings(missing)
+	return missing, extra, err
+}
+
+// checkCaseDuplicates finds import paths in the lock file and vendor directory
+// that only differ by case. These clash on case-insensitive filesystems such
+// as the defaults on macOS and Windows.
+func checkCaseDuplicates(base, vpath string) []diagnosis {
+	var paths []string
+	if lock, err := cfg.ReadLockFile(filepath.Join(base, gpath.LockFile)); err == nil {
+		for _, l := range append(lock.Imports, lock.DevImports...) {
+			paths = append(paths, l.Name)
+		}
+	}
+	filepath.Walk(vpath, func(p string, fi os.FileInfo, err error) error {
+		if err == nil && fi.IsDir() && p != vpath {
+			paths = append(paths, vendorRel(vpath, p))
+		}
+		return nil
+	})
+
+	var found []diagnosis
+	for _, d := range caseDuplicates(paths) {
+		found = append(found, diagnosis{
+			Problem: fmt.Sprintf("%s and %s only differ by case", d[0], d[1]),
+			Fix:     "Run 'glide doctor --fix-case' to use one spelling, or change the imports yourself and run 'glide up'",
+		})
+	}
+	return found
+}
+
+// vendorRel returns the import path of a location in the vendor directory.
+func vendorRel(vpath, p string) string {
+	r, err := filepath.Rel(vpath, p)
+	if err != nil {
+		return p
+	}
+	return filepath.ToSlash(r)
+}
+
+// hasGoFiles returns true if the directory has Go source files.
+func hasGoFiles(dir string) bool {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, f := range files {
+		if !f.IsDir() && strings.HasSuffix(f.Name(), ".go") {
+			return true
+		}
+	}
+	return false
+}
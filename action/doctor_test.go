@@ -0,0 +1,108 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/glide/cfg"
+)
+
+func TestGoVersionProblems(t *testing.T) {
+	tests := []struct {
+		version, experiment string
+		problem             bool
+	}{
+		{"go version go1.4.3 linux/amd64", "", true},
+		{"go version go1.5.4 linux/amd64", "", true},
+		{"go version go1.5.4 linux/amd64", "1", false},
+		{"go version go1.6 darwin/amd64", "0", true},
+		{"go version go1.6 darwin/amd64", "", false},
+		{"go version go1.12.5 linux/amd64", "0", false},
+		{"go version devel +a1b2c3 linux/amd64", "", false},
+	}
+	for _, tt := range tests {
+		if got := goVersionProblems(tt.version, tt.experiment); (len(got) > 0) != tt.problem {
+			t.Errorf("goVersionProblems(%q, %q) = %v", tt.version, tt.experiment, got)
+		}
+	}
+}
+
+func TestVendorLockDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-doctor-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, f := range []string{
+		"github.com/Masterminds/semver/version.go",
+		"github.com/Masterminds/semver/vendor/github.com/foo/bar/bar.go",
+		"github.com/Masterminds/vcs/sub/sub.go",
+		"github.com/extra/pkg/pkg.go",
+		"golang.org/x/net/context/context.go",
+		"github.com/ignored/pkg/pkg.go",
+	} {
+		p := filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte("package x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names := []string{"github.com/Masterminds/semver", "github.com/Masterminds/vcs", "github.com/missing/pkg"}
+	missing, extra, err := vendorLockDiff(dir, names, []string{"github.com/ignored/pkg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(missing, []string{"github.com/missing/pkg"}) {
+		t.Errorf("Unexpected missing packages %v", missing)
+	}
+	if !reflect.DeepEqual(extra, []string{"github.com/extra/pkg", "golang.org/x/net/context"}) {
+		t.Errorf("Unexpected extra packages %v", extra)
+	}
+}
+
+func TestCaseDuplicates(t *testing.T) {
+	dups := caseDuplicates([]string{
+		"github.com/Sirupsen/logrus",
+		"github.com/Masterminds/vcs",
+		"github.com/sirupsen/logrus",
+		"github.com/sirupsen/logrus",
+	})
+	if len(dups) != 1 || dups[0] != [2]string{"github.com/Sirupsen/logrus", "github.com/sirupsen/logrus"} {
+		t.Errorf("Unexpected duplicates %v", dups)
+	}
+}
+
+func TestFixCase(t *testing.T) {
+	conf := &cfg.Config{
+		Imports: cfg.Dependencies{
+			{Name: "github.com/Sirupsen/logrus", Reference: "^1.0.0"},
+		},
+	}
+	keep, drop := fixCase(conf, [2]string{"github.com/Sirupsen/logrus", "github.com/sirupsen/logrus"})
+	if keep != "github.com/sirupsen/logrus" || drop != "github.com/Sirupsen/logrus" {
+		t.Errorf("Expected to keep the lower case spelling, kept %s", keep)
+	}
+	if len(conf.Imports) != 1 || conf.Imports[0].Name != keep || conf.Imports[0].Reference != "^1.0.0" {
+		t.Errorf("Expected the dependency to be renamed keeping its version, got %v", conf.Imports[0])
+	}
+	if !stringsContain(conf.Imports[0].Relocate, drop) || !stringsContain(conf.Ignore, drop) {
+		t.Errorf("Expected %s to be relocated and ignored", drop)
+	}
+
+	// Both spellings listed: the other is removed.
+	conf = &cfg.Config{
+		Imports:    cfg.Dependencies{{Name: "github.com/sirupsen/logrus"}},
+		DevImports: cfg.Dependencies{{Name: "github.com/Sirupsen/logrus"}},
+	}
+	fixCase(conf, [2]string{"github.com/sirupsen/logrus", "github.com/Sirupsen/logrus"})
+	if len(conf.Imports) != 1 || len(conf.DevImports) != 0 {
+		t.Errorf("Expected only github.com/sirupsen/logrus to be left, got %v and %v", conf.Imports, conf.DevImports)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/Masterminds/glide/cache"
 	"github.com/Masterminds/glide/cfg"
 	"github.com/Masterminds/glide/mirrors"
 	"github.com/Masterminds/glide/msg"
@@ -21,19 +22,19 @@ import (
 func EnsureConfig() *cfg.Config {
 	yamlpath, err := gpath.Glide()
 	if err != nil {
-		msg.ExitCode(2)
-		msg.Die("Failed to find %s file in directory tree: %s", gpath.GlideFile, err)
+		msg.DieKind(msg.KindConfig, "Failed to find %s file in directory tree: %s", gpath.GlideFile, err)
 	}
 
 	yml, err := ioutil.ReadFile(yamlpath)
 	if err != nil {
-		msg.ExitCode(2)
-		msg.Die("Failed to load %s: %s", yamlpath, err)
+		msg.DieKind(msg.KindConfig, "Failed to load %s: %s", yamlpath, err)
 	}
 	conf, err := cfg.ConfigFromYaml(yml)
 	if err != nil {
-		msg.ExitCode(3)
-		msg.Die("Failed to parse %s: %s", yamlpath, err)
+		msg.DieKind(msg.KindConfig, "Failed to parse %s: %s", yamlpath, err)
+	}
+	if err := conf.ResolveExtends(filepath.Dir(yamlpath)); err != nil {
+		msg.DieKind(msg.KindConfig, "Failed to load the config %s extends: %s", yamlpath, err)
 	}
 
 	b := filepath.Dir(yamlpath)
@@ -61,6 +62,7 @@ func EnsureConfig() *cfg.Config {
 	if err != nil {
 		msg.Err("Unable to load mirrors: %s", err)
 	}
+	mirrors.Add(conf.Mirrors)
 
 	return conf
 }
@@ -112,6 +114,47 @@ directory or move the _vendor/src/ directory to vendor/.` + "\n")
 	}
 }
 
+// EnsureCacheLock waits until no other Glide process works in the cache and
+// keeps others out until this one exits.
+func EnsureCacheLock() {
+	if err := cache.SystemLock(); err != nil {
+		msg.Die("Unable to lock the Glide cache: %s", err)
+	}
+}
+
+// EnsureProjectLock waits until no other Glide process changes the vendor/
+// directory and lock file of the project in the cwd and keeps others out until
+// this one exits. It is taken before the cache lock.
+func EnsureProjectLock() {
+	l, err := filepath.Abs(gpath.ProjectLockFile)
+	if err != nil {
+		msg.Die("Unable to lock the project: %s", err)
+	}
+	if err := cache.LockFile(l, "the project"); err != nil {
+		msg.Die("Unable to lock the project: %s", err)
+	}
+}
+
+// SetVendorDir uses the vendorDir and testVendorDir set in the glide.yaml file
+// of the project, if any, as the vendor directories. It runs before every
+// command so those that do not load the config find the same vendor
+// directories. Problems reading the config are left to be reported when it is
+// loaded.
+func SetVendorDir() {
+	yamlpath, err := gpath.Glide()
+	if err != nil {
+		return
+	}
+	yml, err := ioutil.ReadFile(yamlpath)
+	if err != nil {
+		return
+	}
+	if conf, err := cfg.ConfigFromYaml(yml); err == nil {
+		gpath.VendorDir = conf.VendorPath()
+		gpath.TestVendorDir = conf.TestVendorPath()
+	}
+}
+
 // EnsureVendorDir ensures that a vendor/ directory is present in the cwd.
 func EnsureVendorDir() {
 	fi, err := os.Stat(gpath.VendorDir)
@@ -0,0 +1,60 @@
+package action
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// Exec runs a command with the environment of the project, as Glide sees it:
+// the vendor directory turned on for Go 1.5, the GOPATH Glide uses, and the
+// bin directory the tools of the project are installed in first on the PATH.
+// The dependencies in the vendor directory for tests, if any, are linked into
+// the vendor directory while it runs. Glide exits with the exit code of the
+// command.
+//
+// Params:
+//  - args ([]string): The command and its arguments, optionally following "--".
+func Exec(args []string) {
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		msg.Die("Name the command to run, such as 'glide exec -- go generate ./...'")
+	}
+
+	if yamlpath, err := gpath.Glide(); err == nil {
+		bin := filepath.Join(filepath.Dir(yamlpath), ToolBinDir)
+		if err := os.Setenv("PATH", prependPath(bin, os.Getenv("PATH"))); err != nil {
+			msg.Die("Unable to set the PATH: %s", err)
+		}
+	} else {
+		msg.Warn("No %s found. Running %s without the tools of a project", gpath.GlideFile, args[0])
+	}
+
+	msg.Debug("Running %s", strings.Join(args, " "))
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = goToolEnv(os.Environ())
+	unmerge := mergeTestVendor()
+	err := cmd.Run()
+	unmerge()
+	exitWith(err, args[0])
+}
+
+// prependPath returns the PATH list with dir first, and not listed again.
+func prependPath(dir, list string) string {
+	parts := []string{dir}
+	for _, p := range filepath.SplitList(list) {
+		if p != "" && p != dir {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, string(filepath.ListSeparator))
+}
@@ -0,0 +1,21 @@
+package action
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrependPath(t *testing.T) {
+	sep := string(filepath.ListSeparator)
+	tests := map[string]string{
+		"":         "/p/bin",
+		"/usr/bin": "/p/bin" + sep + "/usr/bin",
+		strings.Join([]string{"/usr/bin", "/p/bin", ""}, sep): "/p/bin" + sep + "/usr/bin",
+	}
+	for list, want := range tests {
+		if got := prependPath("/p/bin", list); got != want {
+			t.Errorf("Expected %q for %q, got %q", want, list, got)
+		}
+	}
+}
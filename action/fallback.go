@@ -0,0 +1,45 @@
+package action
+
+import (
+	"path/filepath"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// recordFallbacks writes the fallback remotes dependencies were fetched from,
+// in place of repositories that are gone, to the lock file in base.
+func recordFallbacks(base string, conf *cfg.Config) {
+	lockpath := filepath.Join(base, gpath.LockFile)
+	lock, err := cfg.ReadLockFile(lockpath)
+	if err != nil {
+		msg.Die("Could not load lockfile.")
+	}
+	if !substituteFallbacks(lock, conf) {
+		return
+	}
+	if err := lock.WriteFile(lockpath); err != nil {
+		msg.Die("Could not write the fallback repositories to %s: %s", gpath.LockFile, err)
+	}
+	msg.Info("Recorded the fallback repositories in %s", gpath.LockFile)
+}
+
+// substituteFallbacks sets the repository of the locks of dependencies in
+// conf fetched from a fallback. It returns true when a lock changed.
+func substituteFallbacks(lock *cfg.Lockfile, conf *cfg.Config) bool {
+	changed := false
+	for _, d := range append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...) {
+		if d.Upstream == "" {
+			continue
+		}
+		for _, l := range append(append(cfg.Locks{}, lock.Imports...), lock.DevImports...) {
+			if l.Name == d.Name && (l.Repository != d.Repository || l.Upstream != d.Upstream) {
+				l.Repository = d.Repository
+				l.Upstream = d.Upstream
+				changed = true
+			}
+		}
+	}
+	return changed
+}
@@ -18,20 +18,26 @@ import (
 // Get fetches one or more dependencies and installs.
 //
 // This includes resolving dependency resolution and re-generating the lock file.
-func Get(names []string, installer *repo.Installer, insecure, skipRecursive, stripVendor, nonInteract, testDeps bool) {
-	cache.SystemLock()
+//
+// The version, groups, and OS and architecture filters of settings are set on
+// the packages added to the config. A version given with a package name takes
+// precedence.
+func Get(names []string, installer *repo.Installer, insecure, skipRecursive, stripVendor, nonInteract, testDeps bool, settings *cfg.Dependency) {
+	EnsureProjectLock()
+	EnsureCacheLock()
 
 	base := gpath.Basepath()
 	EnsureGopath()
 	EnsureVendorDir()
-	conf := EnsureConfig()
+	conf := EnsureValidConfig()
+	stripVendor = stripVendorPolicy(conf, stripVendor)
 	glidefile, err := gpath.Glide()
 	if err != nil {
 		msg.Die("Could not find Glide file: %s", err)
 	}
 
 	// Add the packages to the config.
-	if count, err2 := addPkgsToConfig(conf, names, insecure, nonInteract, testDeps); err2 != nil {
+	if count, err2 := addPkgsToConfig(conf, names, insecure, nonInteract, testDeps, settings); err2 != nil {
 		msg.Die("Failed to get new packages: %s", err2)
 	} else if count == 0 {
 		msg.Warn("Nothing to do")
@@ -70,11 +76,24 @@ func Get(names []string, installer *repo.Installer, insecure, skipRecursive, str
 		msg.Err("Failed to set references: %s", err)
 	}
 
+	if stripVendor {
+		reuseStripped(installer, confcopy)
+	}
+
+	// Patches are applied before the new vendor directory replaces the
+	// existing one, which is kept when they fail.
+	installer.Stage = func(vp string) error {
+		return applyPatches(conf, confcopy, vp)
+	}
 	err = installer.Export(confcopy)
 	if err != nil {
 		msg.Die("Unable to export dependencies to vendor directory: %s", err)
 	}
 
+	enforceCacheLimits()
+	relocateVendored(conf)
+	aliasVendored(conf)
+
 	// Write YAML
 	if err := conf.WriteFile(glidefile); err != nil {
 		msg.Die("Failed to write glide YAML file: %s", err)
@@ -88,13 +107,10 @@ func Get(names []string, installer *repo.Installer, insecure, skipRecursive, str
 	} else {
 		msg.Warn("Skipping lockfile generation because full dependency tree is not being calculated")
 	}
+	recordRedirects(base, conf)
 
 	if stripVendor {
-		msg.Info("Removing nested vendor and Godeps/_workspace directories...")
-		err := gpath.StripVendor()
-		if err != nil {
-			msg.Err("Unable to strip vendor directories: %s", err)
-		}
+		stripNestedVendor(installer, confcopy)
 	}
 }
 
@@ -110,6 +126,7 @@ func writeLock(conf, confcopy *cfg.Config, base string) {
 	if err := lock.WriteFile(filepath.Join(base, gpath.LockFile)); err != nil {
 		msg.Die("Failed to write glide lock file: %s", err)
 	}
+	warnStaleSignature(base)
 }
 
 // addPkgsToConfig adds the given packages to the config file.
@@ -121,7 +138,8 @@ func writeLock(conf, confcopy *cfg.Config, base string) {
 // - separates repo from packages
 // - sets up insecure repo URLs where necessary
 // - generates a list of subpackages
-func addPkgsToConfig(conf *cfg.Config, names []string, insecure, nonInteract, testDeps bool) (int, error) {
+// - sets the version, groups, and OS and architecture filters of settings
+func addPkgsToConfig(conf *cfg.Config, names []string, insecure, nonInteract, testDeps bool, settings *cfg.Dependency) (int, error) {
 
 	if len(names) == 1 {
 		msg.Info("Preparing to install %d package.", len(names))
@@ -191,6 +209,14 @@ func addPkgsToConfig(conf *cfg.Config, names []string, insecure, nonInteract, te
 		dep := &cfg.Dependency{
 			Name: root,
 		}
+		if settings != nil {
+			if version == "" {
+				version = settings.Reference
+			}
+			dep.Groups = settings.Groups
+			dep.Os = settings.Os
+			dep.Arch = settings.Arch
+		}
 
 		// When retriving from an insecure location set the repo to the
 		// insecure location.
@@ -2,6 +2,7 @@ package action
 
 import (
 	"io/ioutil"
+	"reflect"
 	"testing"
 
 	"github.com/Masterminds/glide/cfg"
@@ -24,7 +25,7 @@ func TestAddPkgsToConfig(t *testing.T) {
 		"github.com/Masterminds/semver",
 	}
 
-	addPkgsToConfig(conf, names, false, true, false)
+	addPkgsToConfig(conf, names, false, true, false, nil)
 
 	if !conf.HasDependency("github.com/Masterminds/semver") {
 		t.Error("addPkgsToConfig failed to add github.com/Masterminds/semver")
@@ -44,3 +45,34 @@ func TestAddPkgsToConfig(t *testing.T) {
 	// Restore messaging to original location
 	msg.Default.Stderr = o
 }
+
+func TestAddPkgsToConfigSettings(t *testing.T) {
+	o := msg.Default.Stderr
+	msg.Default.Stderr = ioutil.Discard
+	defer func() { msg.Default.Stderr = o }()
+
+	conf := new(cfg.Config)
+	settings := &cfg.Dependency{
+		Reference: "^1.1.0",
+		Groups:    []string{"dev"},
+		Os:        []string{"linux"},
+		Arch:      []string{"amd64"},
+	}
+	names := []string{
+		"github.com/stretchr/testify/assert",
+		"github.com/Masterminds/semver#~1.2.0",
+	}
+	addPkgsToConfig(conf, names, false, true, true, settings)
+
+	if len(conf.Imports) != 0 || len(conf.DevImports) != 2 {
+		t.Fatalf("Expected 2 test imports and no imports, got %d and %d", len(conf.DevImports), len(conf.Imports))
+	}
+	d := conf.DevImports.Get("github.com/stretchr/testify")
+	if d.Reference != "^1.1.0" || !reflect.DeepEqual(d.Groups, []string{"dev"}) ||
+		!reflect.DeepEqual(d.Os, []string{"linux"}) || !reflect.DeepEqual(d.Arch, []string{"amd64"}) {
+		t.Errorf("Settings were not applied to the added package: %+v", d)
+	}
+	if d := conf.DevImports.Get("github.com/Masterminds/semver"); d.Reference != "~1.2.0" {
+		t.Errorf("Expected the version given with the package name, got %s", d.Reference)
+	}
+}
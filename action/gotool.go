@@ -0,0 +1,137 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// GoTool runs a go command, such as test, build, or run, on the packages of
+// the project rather than those in vendor/. The environment is set up for the
+// vendor directory to be used, and for test the dependencies in the vendor
+// directory for tests, if any, are linked into it while the tests run. Glide
+// exits with the exit code of the command.
+//
+// Params:
+//  - command (string): The go command to run.
+//  - args ([]string): The packages, or for run the files, to pass to the
+//    command followed by "--" and the flags to pass through. For run the
+//    arguments after "--" are passed to the program instead. Without packages
+//    all the non-vendor packages are used, and for run the Go files in the
+//    current directory.
+func GoTool(command string, args []string) {
+	pkgs, flags := splitPassthrough(args)
+	if len(pkgs) == 0 {
+		var err error
+		if command == "run" {
+			pkgs, err = mainFiles(".")
+		} else {
+			pkgs, err = noVend(".", true, true)
+		}
+		if err != nil {
+			msg.Die("Unable to list the packages of the project: %s", err)
+		}
+		if len(pkgs) == 0 {
+			msg.Die("No Go source found to %s", command)
+		}
+	}
+
+	cmdArgs := goToolArgs(command, pkgs, flags)
+	msg.Debug("Running %s %s", goExecutable(), strings.Join(cmdArgs, " "))
+	cmd := exec.Command(goExecutable(), cmdArgs...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = goToolEnv(os.Environ())
+	unmerge := func() {}
+	if command == "test" {
+		unmerge = mergeTestVendor()
+	}
+	err := cmd.Run()
+	unmerge()
+	exitWith(err, goExecutable())
+}
+
+// exitWith exits with the exit code of a command that failed with err. It
+// returns when the command succeeded.
+func exitWith(err error, name string) {
+	if err == nil {
+		return
+	}
+	if ee, ok := err.(*exec.ExitError); ok {
+		if s, ok := ee.Sys().(syscall.WaitStatus); ok {
+			os.Exit(s.ExitStatus())
+		}
+		os.Exit(1)
+	}
+	msg.Die("Unable to run %s: %s", name, err)
+}
+
+// splitPassthrough splits arguments at the first "--" into those for Glide and
+// those passed through.
+func splitPassthrough(args []string) ([]string, []string) {
+	for i, a := range args {
+		if a == "--" {
+			return args[:i], args[i+1:]
+		}
+	}
+	return args, nil
+}
+
+// goToolArgs returns the arguments of a go command. The flags passed through
+// go before the packages, except for run where they are the arguments of the
+// program and follow the files.
+func goToolArgs(command string, pkgs, flags []string) []string {
+	args := []string{command}
+	if command == "run" {
+		args = append(args, pkgs...)
+		return append(args, flags...)
+	}
+	args = append(args, flags...)
+	return append(args, pkgs...)
+}
+
+// goToolEnv returns env with the vendor directory turned on for Go 1.5 and the
+// GOPATH set to the one Glide uses when it is not set.
+func goToolEnv(env []string) []string {
+	var hasGopath bool
+	out := make([]string, 0, len(env)+2)
+	for _, e := range env {
+		if strings.HasPrefix(e, "GO15VENDOREXPERIMENT=") || e == "GOPATH=" {
+			continue
+		}
+		if strings.HasPrefix(e, "GOPATH=") {
+			hasGopath = true
+		}
+		out = append(out, e)
+	}
+	out = append(out, "GO15VENDOREXPERIMENT=1")
+	if !hasGopath {
+		if gp := gpath.Gopaths(); len(gp) > 0 {
+			out = append(out, "GOPATH="+strings.Join(gp, string(filepath.ListSeparator)))
+		}
+	}
+	return out
+}
+
+// mainFiles lists the Go files of the program in dir, leaving out tests.
+func mainFiles(dir string) ([]string, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, fi := range fis {
+		n := fi.Name()
+		if !fi.IsDir() && strings.HasSuffix(n, ".go") && !strings.HasSuffix(n, "_test.go") {
+			files = append(files, filepath.Join(dir, n))
+		}
+	}
+	return files, nil
+}
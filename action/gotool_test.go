@@ -0,0 +1,34 @@
+package action
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGoToolArgs(t *testing.T) {
+	tests := []struct {
+		command string
+		args    []string
+		want    []string
+	}{
+		{"test", []string{"./foo/...", "--", "-race", "-run", "Test A"}, []string{"test", "-race", "-run", "Test A", "./foo/..."}},
+		{"build", []string{"./cmd/..."}, []string{"build", "./cmd/..."}},
+		{"run", []string{"main.go", "--", "--port", "8080"}, []string{"run", "main.go", "--port", "8080"}},
+		{"test", []string{"./foo", "--", "-args", "--", "x"}, []string{"test", "-args", "--", "x", "./foo"}},
+	}
+	for _, tt := range tests {
+		pkgs, flags := splitPassthrough(tt.args)
+		got := goToolArgs(tt.command, pkgs, flags)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Expected %q for %q but got %q", tt.want, tt.args, got)
+		}
+	}
+}
+
+func TestGoToolEnv(t *testing.T) {
+	env := goToolEnv([]string{"HOME=/home/a", "GO15VENDOREXPERIMENT=0", "GOPATH=/go"})
+	want := []string{"HOME=/home/a", "GOPATH=/go", "GO15VENDOREXPERIMENT=1"}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("Expected %q but got %q", want, env)
+	}
+}
@@ -0,0 +1,43 @@
+package action
+
+import (
+	"os/exec"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+)
+
+// GoVersionWarnOnly reports a Go toolchain outside of the range set with go in
+// glide.yaml as a warning rather than stopping.
+var GoVersionWarnOnly bool
+
+// enforceGoVersion checks the Go toolchain against the range of Go versions
+// the project supports, if any.
+func enforceGoVersion(conf *cfg.Config) {
+	con, err := conf.GoConstraint()
+	if err != nil {
+		msg.Die("%s", err)
+	} else if con == nil {
+		return
+	}
+
+	out, err := exec.Command(goExecutable(), "version").Output()
+	if err != nil {
+		msg.Warn("Unable to run %s version to check it against %s: %s", goExecutable(), conf.Go, err)
+		return
+	}
+	v, err := cfg.GoRelease(string(out))
+	if err != nil {
+		msg.Warn("Unable to check the Go toolchain against %s: %s", conf.Go, err)
+		return
+	}
+	if con.Check(v) {
+		return
+	}
+
+	if GoVersionWarnOnly {
+		msg.Warn("Go %s is outside of the versions the project supports: %s", v, conf.Go)
+		return
+	}
+	msg.Die("Go %s is outside of the versions the project supports: %s. Use --go-version-warn-only to continue anyway", v, conf.Go)
+}
@@ -0,0 +1,83 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/dependency"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+const dotFormat = "dot"
+
+// Graph prints the resolved dependency graph of the project.
+//
+// Params:
+//  - basedir (string): the project directory
+//  - format (string): The format to output (dot, json, json-pretty)
+func Graph(basedir, format string) {
+	conf := EnsureConfig()
+	if !gpath.HasLock(basedir) {
+		msg.Die("A lock file (%s) is required to graph dependencies. Please run 'glide up'", gpath.LockFile)
+	}
+	lock, err := cfg.ReadLockFile(filepath.Join(basedir, gpath.LockFile))
+	if err != nil {
+		msg.Die("Could not load lockfile: %s", err)
+	}
+
+	g, err := dependency.NewGraph(basedir, conf, lock)
+	if err != nil {
+		msg.Die("Unable to build the dependency graph: %s", err)
+	}
+
+	switch format {
+	case dotFormat:
+		writeDot(msg.Default.Stdout, g)
+	case jsonFormat:
+		json.NewEncoder(msg.Default.Stdout).Encode(g)
+	case jsonPrettyFormat:
+		b, err := json.MarshalIndent(g, "", "  ")
+		if err != nil {
+			msg.Die("could not marshal the graph: %s", err)
+		}
+		msg.Print(string(b) + "\n")
+	default:
+		msg.Die("invalid output format: must be one of: dot|json|json-pretty")
+	}
+}
+
+// writeDot writes the graph in the Graphviz DOT language.
+func writeDot(w io.Writer, g *dependency.Graph) {
+	fmt.Fprintf(w, "digraph %q {\n", g.Name)
+	for _, n := range g.Nodes {
+		label := n.Name
+		if n.Reference != "" {
+			label += "\n" + n.Reference
+		}
+		if n.Version != "" {
+			label += "\n" + shortVersion(n.Version)
+		}
+		if n.Test {
+			fmt.Fprintf(w, "\t%q [label=%q, style=dashed];\n", n.Name, label)
+		} else {
+			fmt.Fprintf(w, "\t%q [label=%q];\n", n.Name, label)
+		}
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(w, "\t%q -> %q;\n", e.From, e.To)
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// shortVersion shortens commit ids to make them easier to read. Other
+// versions, such as tags, are returned as is.
+func shortVersion(v string) string {
+	if len(v) == 40 {
+		return v[:10]
+	}
+	return v
+}
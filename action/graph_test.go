@@ -0,0 +1,41 @@
+package action
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/glide/dependency"
+)
+
+func TestWriteDot(t *testing.T) {
+	g := &dependency.Graph{
+		Name: "example.com/foo",
+		Nodes: []*dependency.GraphNode{
+			{Name: "example.com/foo"},
+			{Name: "github.com/Masterminds/semver", Version: "59c29afe1a994eacb71c833025ca7acf874bb1da", Reference: "^1.2.0"},
+			{Name: "github.com/Masterminds/vcs", Version: "v1.11.0", Test: true},
+		},
+		Edges: []*dependency.GraphEdge{
+			{From: "example.com/foo", To: "github.com/Masterminds/semver"},
+			{From: "github.com/Masterminds/semver", To: "github.com/Masterminds/vcs"},
+		},
+	}
+
+	var buf bytes.Buffer
+	writeDot(&buf, g)
+	out := buf.String()
+
+	expected := []string{
+		`digraph "example.com/foo" {`,
+		`"github.com/Masterminds/semver" [label="github.com/Masterminds/semver\n^1.2.0\n59c29afe1a"];`,
+		`"github.com/Masterminds/vcs" [label="github.com/Masterminds/vcs\nv1.11.0", style=dashed];`,
+		`"example.com/foo" -> "github.com/Masterminds/semver";`,
+		`"github.com/Masterminds/semver" -> "github.com/Masterminds/vcs";`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(out, e) {
+			t.Errorf("Expected dot output to contain %s\n%s", e, out)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package action
+
+import (
+	"fmt"
+	"go/build"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	"github.com/Masterminds/glide/repo"
+)
+
+// excludedDeps returns the dependencies left out of the vendor directory
+// along with the reason. A dependency in groups is left out when none of them
+// is selected. Groups are selected unless optional or listed in
+// installer.Without. Optional groups are selected when listed in
+// installer.With. Unless installer.AllPlatforms is set, dependencies whose os
+// and arch filters do not match the target platform are left out too. So are
+// the dependencies only required through the ones left out.
+//
+// Which dependencies require which is read from the locks. Those without a
+// record of it are only left out for their own groups and filters.
+func excludedDeps(installer *repo.Installer, conf *cfg.Config, locks cfg.Locks) map[string]string {
+	direct := make(map[string]string)
+	for _, n := range excludedGroups(installer, conf) {
+		direct[n] = "it is not in a selected group"
+	}
+	if !installer.AllPlatforms {
+		goos, goarch := targetPlatform()
+		for _, l := range locks {
+			if !l.UsedOn(goos, goarch) {
+				direct[l.Name] = fmt.Sprintf("it is not used on %s/%s", goos, goarch)
+			}
+		}
+	}
+	if len(direct) == 0 {
+		return nil
+	}
+
+	// Dependencies are kept when they can be reached from the project without
+	// going through one left out.
+	required := make(map[string][]string)
+	for _, l := range locks {
+		for _, r := range l.RequiredBy {
+			required[r.By] = append(required[r.By], l.Name)
+		}
+	}
+	reached := map[string]bool{conf.Name: true}
+	queue := []string{conf.Name}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, c := range required[n] {
+			if _, ok := direct[c]; !reached[c] && !ok {
+				reached[c] = true
+				queue = append(queue, c)
+			}
+		}
+	}
+
+	excluded := make(map[string]string)
+	for _, l := range locks {
+		if r, ok := direct[l.Name]; ok {
+			excluded[l.Name] = r
+		} else if !reached[l.Name] && len(l.RequiredBy) > 0 && !conf.HasDependency(l.Name) {
+			excluded[l.Name] = "only dependencies left out require it"
+		}
+	}
+	return excluded
+}
+
+// excludedGroups returns the dependencies of the project in none of the groups
+// selected for the installer.
+func excludedGroups(installer *repo.Installer, conf *cfg.Config) []string {
+	if len(installer.With) == 0 && len(installer.Without) == 0 && len(conf.OptionalGroups) == 0 {
+		return nil
+	}
+
+	var names []string
+	known := make(map[string]bool)
+	for _, d := range append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...) {
+		for _, g := range d.Groups {
+			known[g] = true
+		}
+		if len(d.Groups) > 0 && !groupSelected(installer, conf, d.Groups) {
+			names = append(names, d.Name)
+		}
+	}
+	for _, g := range append(append([]string{}, installer.With...), installer.Without...) {
+		if !known[g] {
+			msg.Warn("No dependencies are in the group %s", g)
+		}
+	}
+	return names
+}
+
+// targetPlatform returns the GOOS and GOARCH being built for.
+func targetPlatform() (string, string) {
+	return build.Default.GOOS, build.Default.GOARCH
+}
+
+// groupSelected returns true when any of the groups is selected.
+func groupSelected(installer *repo.Installer, conf *cfg.Config, groups []string) bool {
+	for _, g := range groups {
+		if stringsContain(installer.Without, g) {
+			continue
+		}
+		if !stringsContain(conf.OptionalGroups, g) || stringsContain(installer.With, g) {
+			return true
+		}
+	}
+	return false
+}
+
+// lockWithGroups returns a copy of the lock without the dependencies left
+// out by the groups selected for the installer or the target platform.
+func lockWithGroups(installer *repo.Installer, conf *cfg.Config, lock *cfg.Lockfile) *cfg.Lockfile {
+	excluded := excludedDeps(installer, conf, append(append(cfg.Locks{}, lock.Imports...), lock.DevImports...))
+	if len(excluded) == 0 {
+		return lock
+	}
+	l := lock.Clone()
+	l.Imports = locksWithout(l.Imports, excluded)
+	l.DevImports = locksWithout(l.DevImports, excluded)
+	return l
+}
+
+// configWithGroups returns a copy of conf without the dependencies left out
+// by the groups selected for the installer or the target platform.
+func configWithGroups(installer *repo.Installer, conf *cfg.Config) *cfg.Config {
+	var locks cfg.Locks
+	for _, d := range append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...) {
+		locks = append(locks, cfg.LockFromDependency(d))
+	}
+	excluded := excludedDeps(installer, conf, locks)
+	if len(excluded) == 0 {
+		return conf
+	}
+	c := conf.Clone()
+	c.Imports = dependenciesWithout(c.Imports, excluded)
+	c.DevImports = dependenciesWithout(c.DevImports, excluded)
+	return c
+}
+
+func locksWithout(locks cfg.Locks, excluded map[string]string) cfg.Locks {
+	var kept cfg.Locks
+	for _, l := range locks {
+		if r, ok := excluded[l.Name]; ok {
+			msg.Info("--> Skipping %s as %s", l.Name, r)
+			continue
+		}
+		kept = append(kept, l)
+	}
+	return kept
+}
+
+func dependenciesWithout(deps cfg.Dependencies, excluded map[string]string) cfg.Dependencies {
+	var kept cfg.Dependencies
+	for _, d := range deps {
+		if r, ok := excluded[d.Name]; ok {
+			msg.Info("--> Skipping %s as %s", d.Name, r)
+			continue
+		}
+		kept = append(kept, d)
+	}
+	return kept
+}
+
+func stringsContain(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
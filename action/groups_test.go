@@ -0,0 +1,88 @@
+package action
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/repo"
+)
+
+func TestExcludedGroups(t *testing.T) {
+	conf := &cfg.Config{
+		Name:           "example.com/app",
+		OptionalGroups: []string{"tools"},
+		Imports: cfg.Dependencies{
+			{Name: "github.com/a/a"},
+			{Name: "github.com/mock/mock", Groups: []string{"tools"}},
+		},
+		DevImports: cfg.Dependencies{
+			{Name: "github.com/assert/assert", Groups: []string{"dev"}},
+		},
+	}
+	locks := cfg.Locks{
+		{Name: "github.com/a/a", RequiredBy: []*cfg.Requirement{{By: "example.com/app"}}},
+		{Name: "github.com/mock/mock", RequiredBy: []*cfg.Requirement{{By: "example.com/app"}}},
+		{Name: "github.com/mock/dep", RequiredBy: []*cfg.Requirement{{By: "github.com/mock/mock"}}},
+		{Name: "github.com/shared/shared", RequiredBy: []*cfg.Requirement{
+			{By: "github.com/mock/mock"},
+			{By: "github.com/a/a"},
+		}},
+		{Name: "github.com/old/old"},
+		{Name: "github.com/assert/assert", RequiredBy: []*cfg.Requirement{{By: "example.com/app"}}},
+		{Name: "github.com/assert/dep", RequiredBy: []*cfg.Requirement{{By: "github.com/assert/assert"}}},
+	}
+
+	tests := []struct {
+		with, without []string
+		excluded      map[string]bool
+	}{
+		{nil, nil, map[string]bool{
+			"github.com/mock/mock": true,
+			"github.com/mock/dep":  true,
+		}},
+		{[]string{"tools"}, nil, map[string]bool{}},
+		{[]string{"tools"}, []string{"dev"}, map[string]bool{
+			"github.com/assert/assert": true,
+			"github.com/assert/dep":    true,
+		}},
+	}
+	for _, tt := range tests {
+		installer := &repo.Installer{With: tt.with, Without: tt.without}
+		excluded := map[string]bool{}
+		for n := range excludedDeps(installer, conf, locks) {
+			excluded[n] = true
+		}
+		if !reflect.DeepEqual(excluded, tt.excluded) {
+			t.Errorf("Expected %v excluded with %v and without %v, got %v", tt.excluded, tt.with, tt.without, excluded)
+		}
+	}
+}
+
+func TestExcludedPlatforms(t *testing.T) {
+	goos, goarch := targetPlatform()
+	conf := &cfg.Config{Name: "example.com/app"}
+	locks := cfg.Locks{
+		{Name: "github.com/any/any", RequiredBy: []*cfg.Requirement{{By: "example.com/app"}}},
+		{Name: "github.com/native/native", Os: []string{goos}, Arch: []string{goarch}, RequiredBy: []*cfg.Requirement{{By: "example.com/app"}}},
+		{Name: "github.com/other/other", Os: []string{"plan9"}, RequiredBy: []*cfg.Requirement{{By: "example.com/app"}}},
+		{Name: "github.com/other/dep", RequiredBy: []*cfg.Requirement{{By: "github.com/other/other"}}},
+		{Name: "github.com/wide/wide", Arch: []string{"mips64le", "s390x", "ppc64"}, RequiredBy: []*cfg.Requirement{{By: "example.com/app"}}},
+	}
+
+	excluded := excludedDeps(&repo.Installer{}, conf, locks)
+	expected := map[string]string{
+		"github.com/other/other": "it is not used on " + goos + "/" + goarch,
+		"github.com/other/dep":   "only dependencies left out require it",
+	}
+	if goarch != "mips64le" && goarch != "s390x" && goarch != "ppc64" {
+		expected["github.com/wide/wide"] = "it is not used on " + goos + "/" + goarch
+	}
+	if !reflect.DeepEqual(excluded, expected) {
+		t.Errorf("Expected %v excluded, got %v", expected, excluded)
+	}
+
+	if excluded := excludedDeps(&repo.Installer{AllPlatforms: true}, conf, locks); len(excluded) != 0 {
+		t.Errorf("Expected nothing excluded for all platforms, got %v", excluded)
+	}
+}
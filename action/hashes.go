@@ -0,0 +1,46 @@
+package action
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/msg"
+)
+
+// HashesList lists the commits tags pointed at when Glide first saw them.
+func HashesList() {
+	entries, err := cache.KnownEntries()
+	if err != nil {
+		msg.Die("Unable to read the known hashes: %s", err)
+	}
+	w := tabwriter.NewWriter(msg.Default.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTAG\tCOMMIT\tSEEN\tREPO")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Name, e.Tag, e.Commit, e.Seen.Format("2006-01-02 15:04"), e.Remote)
+	}
+	w.Flush()
+}
+
+// HashesForget removes the known commits of tags so they are recorded again
+// the next time they are resolved.
+//
+// Params:
+//  - patterns ([]string): package names or repos, optionally followed by @
+//    and a tag.
+func HashesForget(patterns []string) {
+	if len(patterns) == 0 {
+		msg.Die("Please name the packages to forget, such as github.com/foo/bar@v1.2.3")
+	}
+	for _, p := range patterns {
+		n, err := cache.ForgetKnown(p)
+		if err != nil {
+			msg.Die("Unable to update the known hashes: %s", err)
+		}
+		if n == 0 {
+			msg.Warn("--> No known hashes for %s", p)
+			continue
+		}
+		msg.Info("--> Forgot %d known hashes for %s", n, p)
+	}
+}
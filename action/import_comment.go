@@ -0,0 +1,178 @@
+package action
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// StripImportComments removes canonical import path comments from vendored
+// packages when they name a path other than the one the package is vendored
+// under, rather than stopping.
+var StripImportComments bool
+
+// importComment is a canonical import path comment on the package clause of a
+// vendored file, such as package yaml // import "gopkg.in/yaml.v2".
+type importComment struct {
+	// File is the path of the Go file.
+	File string
+
+	// Path is the import path the comment names.
+	Path string
+
+	// start and end are the offsets of the comment in the file.
+	start, end int
+}
+
+// enforceImportComments checks the dependencies in conf vendored at vpath for
+// import comments naming another path than the one they are vendored under. Go
+// refuses to build such packages when they are outside of vendor/, such as
+// after 'glide install --to-gopath', and the error it gives does not point at
+// the cause. With StripImportComments set the comments are removed, otherwise
+// an error is returned.
+func enforceImportComments(conf *cfg.Config, vpath string) error {
+	var found []*importComment
+	for _, d := range append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...) {
+		c, err := mismatchedImportComments(vpath, d.Name)
+		if err != nil {
+			msg.Warn("Unable to check the import comments of %s: %s", d.Name, err)
+			continue
+		}
+		found = append(found, c...)
+	}
+	if len(found) == 0 {
+		return nil
+	}
+
+	if StripImportComments {
+		for _, c := range found {
+			if err := stripImportComment(c); err != nil {
+				return fmt.Errorf("Unable to remove the import comment from %s: %s", vendorRel(vpath, c.File), err)
+			}
+			msg.Info("Removed the import comment for %s from %s", c.Path, vendorRel(vpath, c.File))
+		}
+		return nil
+	}
+	for _, c := range found {
+		msg.Err("%s declares its import path is %s", vendorRel(vpath, c.File), c.Path)
+	}
+	msg.Err("Go only builds a package with an import comment when it is imported by that path.")
+	msg.Err("This usually means a fork or a renamed package is used. Import the package by the")
+	msg.Err("path in its comment, or rerun with --strip-import-comments to remove the comments.")
+	return errors.New("Vendored packages have import comments naming other paths")
+}
+
+// mismatchedImportComments returns the import comments in the package of a
+// dependency, and its subpackages, that name another path than the one it is
+// vendored under. Nested vendor, testdata, and hidden directories, as well as
+// dependencies linked into vendor/, are not looked into.
+func mismatchedImportComments(vpath, name string) ([]*importComment, error) {
+	dir := filepath.Join(vpath, filepath.FromSlash(name))
+	if fi, err := os.Lstat(dir); err != nil || !fi.IsDir() {
+		return nil, nil
+	}
+
+	var found []*importComment
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			n := fi.Name()
+			if p != dir && (n == "vendor" || n == "testdata" || strings.HasPrefix(n, ".") || strings.HasPrefix(n, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !fi.Mode().IsRegular() || !strings.HasSuffix(p, ".go") || strings.HasSuffix(p, "_test.go") {
+			return nil
+		}
+		c, err := readImportComment(p)
+		if err != nil || c == nil {
+			return err
+		}
+		if want := vendorRel(vpath, filepath.Dir(p)); c.Path != want {
+			found = append(found, c)
+		}
+		return nil
+	})
+	return found, err
+}
+
+// readImportComment returns the import comment of a Go file, or nil when it
+// has none. Files that cannot be parsed have none.
+func readImportComment(f string) (*importComment, error) {
+	src, err := ioutil.ReadFile(f)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, f, src, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return nil, nil
+	}
+
+	line := fset.Position(file.Name.End()).Line
+	for _, cg := range file.Comments {
+		if cg.Pos() < file.Name.End() {
+			continue
+		}
+		c := cg.List[0]
+		if fset.Position(c.Pos()).Line != line {
+			break
+		}
+		text := c.Text
+		if strings.HasPrefix(text, "//") {
+			text = text[2:]
+		} else {
+			text = strings.TrimSuffix(text[2:], "*/")
+		}
+		text = strings.TrimSpace(text)
+		if !strings.HasPrefix(text, "import ") {
+			break
+		}
+		p, err := strconv.Unquote(strings.TrimSpace(strings.TrimPrefix(text, "import ")))
+		if err != nil {
+			break
+		}
+		return &importComment{
+			File:  f,
+			Path:  p,
+			start: fset.Position(file.Name.End()).Offset,
+			end:   fset.Position(c.End()).Offset,
+		}, nil
+	}
+	return nil, nil
+}
+
+// stripImportComment removes an import comment from its file, along with the
+// space between it and the package name.
+func stripImportComment(c *importComment) error {
+	src, err := ioutil.ReadFile(c.File)
+	if err != nil {
+		return err
+	}
+	if c.end > len(src) || c.start > c.end {
+		return fmt.Errorf("%s changed while being read", c.File)
+	}
+	var out bytes.Buffer
+	out.Write(src[:c.start])
+	out.Write(src[c.end:])
+
+	fi, err := os.Stat(c.File)
+	if err != nil {
+		return err
+	}
+	return gpath.ReplaceFile(c.File, out.Bytes(), fi.Mode())
+}
@@ -0,0 +1,65 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/glide/cfg"
+)
+
+func TestImportComments(t *testing.T) {
+	vpath, err := ioutil.TempDir("", "glide-import-comment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(vpath)
+
+	files := map[string]string{
+		"github.com/fork/yaml/yaml.go":       "// Package yaml is a fork.\npackage yaml // import \"gopkg.in/yaml.v2\"\n\nfunc A() {}\n",
+		"github.com/fork/yaml/sub/sub.go":    "package sub /* import \"github.com/fork/yaml/sub\" */\n",
+		"github.com/fork/yaml/other.go":      "package yaml // not an import comment\n",
+		"github.com/fork/yaml/yaml_test.go":  "package yaml // import \"gopkg.in/yaml.v2\"\n",
+		"github.com/fork/yaml/vendor/a/a.go": "package a // import \"example.com/a\"\n",
+		"github.com/fork/yaml/testdata/t.go": "package t // import \"example.com/t\"\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(vpath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	found, err := mismatchedImportComments(vpath, "github.com/fork/yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].Path != "gopkg.in/yaml.v2" || filepath.Base(found[0].File) != "yaml.go" {
+		t.Fatalf("Expected only the comment in yaml.go, got %v", found)
+	}
+
+	conf := &cfg.Config{Imports: cfg.Dependencies{{Name: "github.com/fork/yaml"}}}
+	if err := enforceImportComments(conf, vpath); err == nil {
+		t.Error("Expected the mismatched import comment to stop the export")
+	}
+
+	checkCached := linkCached(t, found[0].File)
+	if err := stripImportComment(found[0]); err != nil {
+		t.Fatal(err)
+	}
+	checkCached(files["github.com/fork/yaml/yaml.go"])
+	b, err := ioutil.ReadFile(found[0].File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "// Package yaml is a fork.\npackage yaml\n\nfunc A() {}\n"; string(b) != want {
+		t.Errorf("Expected the comment to be removed, got %q", b)
+	}
+	if found, _ := mismatchedImportComments(vpath, "github.com/fork/yaml"); len(found) != 0 {
+		t.Errorf("Expected no comments left, got %v", found)
+	}
+}
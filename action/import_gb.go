@@ -10,7 +10,7 @@ import (
 // ImportGB imports GB dependencies into the present glide config.
 func ImportGB(dest string) {
 	base := "."
-	config := EnsureConfig()
+	config := EnsureValidConfig()
 	if !gb.Has(base) {
 		msg.Die("There is no GB manifest to import.")
 	}
@@ -8,7 +8,7 @@ import (
 // ImportGodep imports a Godep file.
 func ImportGodep(dest string) {
 	base := "."
-	config := EnsureConfig()
+	config := EnsureValidConfig()
 	if !godep.Has(base) {
 		msg.Die("No Godep data found.")
 	}
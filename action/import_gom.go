@@ -8,7 +8,7 @@ import (
 // ImportGom imports a Gomfile.
 func ImportGom(dest string) {
 	base := "."
-	config := EnsureConfig()
+	config := EnsureValidConfig()
 	if !gom.Has(base) {
 		msg.Die("No gom data found.")
 	}
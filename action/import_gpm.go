@@ -8,7 +8,7 @@ import (
 // ImportGPM imports a GPM file.
 func ImportGPM(dest string) {
 	base := "."
-	config := EnsureConfig()
+	config := EnsureValidConfig()
 	if !gpm.Has(base) {
 		msg.Die("No GPM Godeps file found.")
 	}
@@ -3,7 +3,6 @@ package action
 import (
 	"path/filepath"
 
-	"github.com/Masterminds/glide/cache"
 	"github.com/Masterminds/glide/cfg"
 	"github.com/Masterminds/glide/msg"
 	gpath "github.com/Masterminds/glide/path"
@@ -11,39 +10,73 @@ import (
 )
 
 // Install installs a vendor directory based on an existing Glide configuration.
-func Install(installer *repo.Installer, stripVendor bool) {
-	cache.SystemLock()
-
+//
+// With toGopath the dependencies are installed into the GOPATH instead. That
+// requires a lock file.
+func Install(installer *repo.Installer, stripVendor, toGopath bool) {
 	base := "."
+	if !toGopath && installUpToDate(installer, base, stripVendor) {
+		msg.Info("Vendor directory is up to date with %s. Nothing to install.", gpath.LockFile)
+		return
+	}
+
+	EnsureProjectLock()
+	EnsureCacheLock()
+
 	// Ensure GOPATH
 	EnsureGopath()
 	EnsureVendorDir()
-	conf := EnsureConfig()
+	conf := EnsureValidConfig()
+	enforceGoVersion(conf)
+	stripVendor = stripVendorPolicy(conf, stripVendor)
+	if stripVendor && installer.Link {
+		msg.Warn("Not stripping nested vendor directories as they would be removed from the linked directories")
+		stripVendor = false
+	}
 
 	// Lockfile exists
 	if !gpath.HasLock(base) {
+		if toGopath {
+			msg.Die("Installing into the GOPATH requires a lock file (%s). Run 'glide update' first", gpath.LockFile)
+		}
 		msg.Info("Lock file (glide.lock) does not exist. Performing update.")
-		Update(installer, false, stripVendor)
+		Update(installer, false, stripVendor, false, false, nil)
 		return
 	}
 	// Load lockfile
 	lock, err := cfg.ReadLockFile(filepath.Join(base, gpath.LockFile))
 	if err != nil {
-		msg.Die("Could not load lockfile.")
+		msg.DieKind(msg.KindConfig, "Could not load lockfile: %s", err)
 	}
 	// Verify lockfile hasn't changed
 	hash, err := conf.Hash()
 	if err != nil {
-		msg.Die("Could not load lockfile.")
+		msg.DieKind(msg.KindConfig, "Could not load lockfile: %s", err)
 	} else if hash != lock.Hash {
 		msg.Warn("Lock file may be out of date. Hash check of YAML failed. You may need to run 'update'")
 	}
+	lock = lockWithGroups(installer, conf, lock)
+
+	var deps cfg.Dependencies
+	for _, l := range append(append(cfg.Locks{}, lock.Imports...), lock.DevImports...) {
+		deps = append(deps, cfg.DependencyFromLock(l))
+	}
+	enforcePolicy(conf, deps)
+	warnCaseDuplicates(lock)
+
+	if !toGopath && vendorUpToDate(installer, conf, lock, stripVendor) {
+		msg.Info("Vendor directory is up to date with %s. Nothing to install.", gpath.LockFile)
+		writeInstallDigest(installer, base, stripVendor)
+		return
+	}
 
 	// Install
 	newConf, err := installer.Install(lock, conf)
 	if err != nil {
 		msg.Die("Failed to install: %s", err)
 	}
+	recordFallbacks(base, newConf)
+	recordRedirects(base, conf)
 
 	msg.Info("Setting references.")
 
@@ -52,16 +85,44 @@ func Install(installer *repo.Installer, stripVendor bool) {
 		msg.Die("Failed to set references: %s (Skip to cleanup)", err)
 	}
 
+	if toGopath {
+		backup, err := installer.ExportGopath(newConf)
+		if backup != "" {
+			msg.Info("Replaced packages were backed up to %s", backup)
+		}
+		if err != nil {
+			msg.Die("Unable to export dependencies to the GOPATH: %s", err)
+		}
+		return
+	}
+
+	if stripVendor {
+		reuseStripped(installer, newConf)
+	} else {
+		reuseVendor(installer, newConf)
+	}
+
+	// Patches are applied and import comments checked before the new vendor
+	// directory replaces the existing one, which is kept when they fail.
+	installer.Stage = func(vp string) error {
+		if err := applyPatches(conf, newConf, vp); err != nil {
+			return err
+		}
+		return enforceImportComments(newConf, vp)
+	}
 	err = installer.Export(newConf)
 	if err != nil {
 		msg.Die("Unable to export dependencies to vendor directory: %s", err)
 	}
+	writeVendorState(installer, newConf, hash)
+
+	enforceCacheLimits()
+	relocateVendored(conf)
+	aliasVendored(conf)
 
 	if stripVendor {
-		msg.Info("Removing nested vendor and Godeps/_workspace directories...")
-		err := gpath.StripVendor()
-		if err != nil {
-			msg.Err("Unable to strip vendor directories: %s", err)
-		}
+		stripNestedVendor(installer, newConf)
 	}
+	writeVendorSums(installer, newConf)
+	writeInstallDigest(installer, base, stripVendor)
 }
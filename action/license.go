@@ -0,0 +1,120 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/vcs"
+)
+
+// licenseFileRe matches the names of files commonly holding a license.
+var licenseFileRe = regexp.MustCompile(`(?i)^(un)?licen[cs]e|^copying`)
+
+// licenseTexts identifies licenses by phrases found in their text. The more
+// specific licenses come first, such as the LGPL before the GPL.
+var licenseTexts = []struct {
+	id      string
+	phrases []string
+}{
+	{"Apache-2.0", []string{"apache license", "version 2.0"}},
+	{"MPL-2.0", []string{"mozilla public license", "2.0"}},
+	{"LGPL-3.0", []string{"gnu lesser general public license", "version 3"}},
+	{"LGPL-2.1", []string{"gnu lesser general public license", "version 2.1"}},
+	{"AGPL-3.0", []string{"gnu affero general public license", "version 3"}},
+	{"GPL-3.0", []string{"gnu general public license", "version 3"}},
+	{"GPL-2.0", []string{"gnu general public license", "version 2"}},
+	{"EPL-1.0", []string{"eclipse public license"}},
+	{"Unlicense", []string{"this is free and unencumbered software released into the public domain"}},
+	{"ISC", []string{"permission to use, copy, modify, and/or distribute this software for any purpose"}},
+	{"MIT", []string{"permission is hereby granted, free of charge"}},
+	{"BSD-3-Clause", []string{"redistributions of source code must retain", "neither the name"}},
+	{"BSD-2-Clause", []string{"redistributions of source code must retain"}},
+}
+
+// detectLicense returns the SPDX identifier of the license in the top level
+// directory of a package, or an empty string when none is recognized.
+func detectLicense(dir string) string {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	var names []string
+	for _, f := range files {
+		if f.Mode().IsRegular() && licenseFileRe.MatchString(f.Name()) {
+			names = append(names, f.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, n := range names {
+		b, err := ioutil.ReadFile(filepath.Join(dir, n))
+		if err != nil {
+			continue
+		}
+		if id := licenseID(string(b)); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// licenseAt returns the SPDX identifier of the license of a dependency at a
+// revision, read from the copy of its repo in the cache. An empty string is
+// returned when none is recognized or the repo is not a Git repo in the cache.
+func licenseAt(l *cfg.Lock, rev string) string {
+	repo, err := cachedRepo(cfg.DependencyFromLock(l))
+	if err != nil || repo.Vcs() != vcs.Git {
+		return ""
+	}
+	out, err := repo.RunFromDir("git", "ls-tree", "--name-only", rev)
+	if err != nil {
+		return ""
+	}
+	var names []string
+	for _, n := range strings.Split(string(out), "\n") {
+		if licenseFileRe.MatchString(n) {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+
+	for _, n := range names {
+		b, err := repo.RunFromDir("git", "show", rev+":"+n)
+		if err != nil {
+			continue
+		}
+		if id := licenseID(string(b)); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// licenseID identifies the license in a text.
+func licenseID(text string) string {
+	text = strings.Join(strings.Fields(strings.ToLower(text)), " ")
+	for _, l := range licenseTexts {
+		found := true
+		for _, p := range l.phrases {
+			if !strings.Contains(text, p) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return l.id
+		}
+	}
+	return ""
+}
+
+// hasDir returns true if p is a directory.
+func hasDir(p string) bool {
+	fi, err := os.Stat(p)
+	return err == nil && fi.IsDir()
+}
@@ -0,0 +1,15 @@
+package action
+
+import (
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// VendorLink sets how files are placed in the vendor directory from the cache.
+// The mode is one of auto, reflink, hardlink, or copy.
+func VendorLink(mode string) {
+	if err := gpath.ValidLinkMode(mode); err != nil {
+		msg.Die("%s", err)
+	}
+	gpath.LinkMode = mode
+}
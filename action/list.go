@@ -2,11 +2,15 @@ package action
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"path/filepath"
 	"sort"
 
+	"github.com/Masterminds/glide/cfg"
 	"github.com/Masterminds/glide/dependency"
 	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
 )
 
 // List lists all of the dependencies of the current project.
@@ -16,21 +20,31 @@ import (
 //  - deep (bool): whether to do a deep scan or a shallow scan
 //  - format (string): The format to output (text, json, json-pretty)
 func List(basedir string, deep bool, format string) {
+	l, err := listPackages(basedir, deep)
+	if err != nil {
+		msg.Die("%s", err)
+	}
+	outputList(l, format)
+}
+
+// listPackages finds the packages used by the project in basedir and where
+// each of them is located.
+func listPackages(basedir string, deep bool) (PackageList, error) {
 	basedir, err := filepath.Abs(basedir)
 	if err != nil {
-		msg.Die("Could not read directory: %s", err)
+		return PackageList{}, fmt.Errorf("Could not read directory: %s", err)
 	}
 
 	r, err := dependency.NewResolver(basedir)
 	if err != nil {
-		msg.Die("Could not create a resolver: %s", err)
+		return PackageList{}, fmt.Errorf("Could not create a resolver: %s", err)
 	}
-	h := &dependency.DefaultMissingPackageHandler{Missing: []string{}, Gopath: []string{}, Prefix: "vendor"}
+	h := &dependency.DefaultMissingPackageHandler{Missing: []string{}, Gopath: []string{}, Prefix: gpath.VendorDir}
 	r.Handler = h
 
 	localPkgs, _, err := r.ResolveLocal(deep)
 	if err != nil {
-		msg.Die("Error listing dependencies: %s", err)
+		return PackageList{}, fmt.Errorf("Error listing dependencies: %s", err)
 	}
 	sort.Strings(localPkgs)
 	installed := make([]string, len(localPkgs))
@@ -42,13 +56,11 @@ func List(basedir string, deep bool, format string) {
 		}
 		installed[i] = relPkg
 	}
-	l := PackageList{
+	return PackageList{
 		Installed: installed,
 		Missing:   h.Missing,
 		Gopath:    h.Gopath,
-	}
-
-	outputList(l, format)
+	}, nil
 }
 
 // PackageList contains the packages being used by their location
@@ -96,3 +108,257 @@ func outputList(l PackageList, format string) {
 		msg.Die("invalid output format: must be one of: json|json-pretty|text")
 	}
 }
+
+// AnnotatedDependency is a dependency from glide.yaml along with its
+// annotations, as listed by ListAnnotations.
+type AnnotatedDependency struct {
+	Name        string            `json:"name"`
+	Source      string            `json:"source"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ListAnnotations lists the dependencies in glide.yaml along with the
+// annotations recorded for them, such as the team owning each one.
+//
+// Params:
+//  - format (string): The format to output (text, json, json-pretty)
+func ListAnnotations(format string) {
+	conf := EnsureConfig()
+	var deps []*AnnotatedDependency
+	for _, d := range conf.Imports {
+		deps = append(deps, &AnnotatedDependency{Name: d.Name, Source: "import", Annotations: d.Annotations})
+	}
+	for _, d := range conf.DevImports {
+		deps = append(deps, &AnnotatedDependency{Name: d.Name, Source: "testImport", Annotations: d.Annotations})
+	}
+
+	switch format {
+	case textFormat:
+		for _, d := range deps {
+			if d.Source == "testImport" {
+				msg.Puts("%s (testImport)", d.Name)
+			} else {
+				msg.Puts("%s", d.Name)
+			}
+			keys := make([]string, 0, len(d.Annotations))
+			for k := range d.Annotations {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				msg.Puts("\t%s: %s", k, d.Annotations[k])
+			}
+		}
+	case jsonFormat:
+		json.NewEncoder(msg.Default.Stdout).Encode(deps)
+	case jsonPrettyFormat:
+		b, err := json.MarshalIndent(deps, "", "  ")
+		if err != nil {
+			msg.Die("could not marshal the dependency list: %s", err)
+		}
+		msg.Print(string(b) + "\n")
+	default:
+		msg.Die("invalid output format: must be one of: json|json-pretty|text")
+	}
+}
+
+// DependencyEntry describes a dependency listed by ListDependencies.
+type DependencyEntry struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Depth   int    `json:"depth"`
+	Source  string `json:"source"`
+	Via     string `json:"via,omitempty"`
+}
+
+// ListDependencies lists the dependencies recorded in the lock file along with
+// why each is needed: it is in glide.yaml, it is a testImport, or it is a
+// transitive dependency of another one.
+//
+// Params:
+//  - basedir (string): the project directory
+//  - depth (int): the transitive depth to list to. Direct dependencies are at
+//    depth 1. Zero means no limit.
+//  - tree (bool): whether to display the dependencies as a tree
+//  - format (string): The format to output (text, json, json-pretty)
+func ListDependencies(basedir string, depth int, tree bool, format string) {
+	conf := EnsureConfig()
+	if !gpath.HasLock(basedir) {
+		msg.Die("A lock file (%s) is required to list dependencies this way. Please run 'glide up'", gpath.LockFile)
+	}
+	lock, err := cfg.ReadLockFile(filepath.Join(basedir, gpath.LockFile))
+	if err != nil {
+		msg.Die("Could not load lockfile: %s", err)
+	}
+
+	g, err := dependency.NewGraph(basedir, conf, lock)
+	if err != nil {
+		msg.Die("Unable to build the dependency graph: %s", err)
+	}
+	entries := dependencyEntries(g, conf, depth)
+
+	switch format {
+	case textFormat:
+		if tree {
+			writeTree(msg.Default.Stdout, g, entries, depth)
+			return
+		}
+		for _, e := range entries {
+			msg.Puts("%s %s (%s)", e.Name, shortVersion(e.Version), entrySource(e))
+		}
+	case jsonFormat:
+		json.NewEncoder(msg.Default.Stdout).Encode(entries)
+	case jsonPrettyFormat:
+		b, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			msg.Die("could not marshal the dependency list: %s", err)
+		}
+		msg.Print(string(b) + "\n")
+	default:
+		msg.Die("invalid output format: must be one of: json|json-pretty|text")
+	}
+}
+
+// dependencyEntries walks the graph breadth first from the project so each
+// dependency is reported at the shortest distance from it. Dependencies deeper
+// than depth are left out unless depth is zero.
+func dependencyEntries(g *dependency.Graph, conf *cfg.Config, depth int) []*DependencyEntry {
+	nodes := make(map[string]*dependency.GraphNode, len(g.Nodes))
+	for _, n := range g.Nodes {
+		nodes[n.Name] = n
+	}
+
+	found := make(map[string]*DependencyEntry)
+	var entries, queue []*DependencyEntry
+	add := func(name string, e *DependencyEntry) {
+		n, ok := nodes[name]
+		if !ok || found[name] != nil || name == g.Name {
+			return
+		}
+		e.Name = name
+		e.Version = n.Version
+		found[name] = e
+		entries = append(entries, e)
+		queue = append(queue, e)
+	}
+
+	for _, d := range conf.Imports {
+		add(d.Name, &DependencyEntry{Depth: 1, Source: "glide.yaml"})
+	}
+	for _, d := range conf.DevImports {
+		add(d.Name, &DependencyEntry{Depth: 1, Source: "testImport"})
+	}
+	for _, c := range graphChildren(g, g.Name) {
+		add(c, &DependencyEntry{Depth: 1, Source: "import"})
+	}
+
+	for len(queue) > 0 {
+		e := queue[0]
+		queue = queue[1:]
+		for _, c := range graphChildren(g, e.Name) {
+			add(c, &DependencyEntry{Depth: e.Depth + 1, Source: "transitive", Via: e.Name})
+		}
+	}
+
+	// Locked dependencies that were not reached, such as those missing from
+	// the vendor/ directory, are listed at the end.
+	for _, n := range g.Nodes {
+		add(n.Name, &DependencyEntry{Depth: 0, Source: "glide.lock"})
+	}
+
+	filtered := entries[:0]
+	for _, e := range entries {
+		if depth <= 0 || (e.Depth > 0 && e.Depth <= depth) {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// graphChildren returns the names a node in the graph has edges to.
+func graphChildren(g *dependency.Graph, name string) []string {
+	var c []string
+	for _, e := range g.Edges {
+		if e.From == name {
+			c = append(c, e.To)
+		}
+	}
+	return c
+}
+
+// entrySource describes why a dependency is needed.
+func entrySource(e *DependencyEntry) string {
+	if e.Via != "" {
+		return "transitive via " + e.Via
+	}
+	return e.Source
+}
+
+// writeTree writes the dependencies as a tree below the project. Direct
+// dependencies are expanded at the top level and others at the place they are
+// first listed. Other occurrences are marked with (*) rather than being
+// expanded again.
+func writeTree(w io.Writer, g *dependency.Graph, entries []*DependencyEntry, depth int) {
+	listed := make(map[string]*DependencyEntry, len(entries))
+	for _, e := range entries {
+		listed[e.Name] = e
+	}
+	expanded := make(map[string]bool)
+	top := make(map[string]bool)
+
+	var walk func(name, indent string, level int)
+	walk = func(name, indent string, level int) {
+		var children []string
+		for _, c := range graphChildren(g, name) {
+			if listed[c] != nil {
+				children = append(children, c)
+			}
+		}
+		if name == g.Name {
+			// Direct dependencies from glide.yaml are shown even when the
+			// code does not import them (yet).
+			for _, e := range entries {
+				if e.Depth == 1 && e.Source != "import" && !containsString(children, e.Name) {
+					children = append(children, e.Name)
+				}
+			}
+			sort.Strings(children)
+			for _, c := range children {
+				top[c] = true
+			}
+		}
+
+		for i, c := range children {
+			branch, next := "|-- ", "|   "
+			if i == len(children)-1 {
+				branch, next = "`-- ", "    "
+			}
+			e := listed[c]
+			line := fmt.Sprintf("%s%s%s %s", indent, branch, c, shortVersion(e.Version))
+			if level == 1 {
+				line += " (" + e.Source + ")"
+			}
+			if expanded[c] || (level > 1 && top[c]) {
+				fmt.Fprintln(w, line+" (*)")
+				continue
+			}
+			fmt.Fprintln(w, line)
+			expanded[c] = true
+			if depth <= 0 || level < depth {
+				walk(c, indent+next, level+1)
+			}
+		}
+	}
+
+	fmt.Fprintln(w, g.Name)
+	walk(g.Name, "", 1)
+}
+
+func containsString(l []string, s string) bool {
+	for _, v := range l {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
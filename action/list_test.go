@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/dependency"
 	"github.com/Masterminds/glide/msg"
 )
 
@@ -48,3 +50,72 @@ func TestList(t *testing.T) {
 		t.Error("No packages found on json-pretty list")
 	}
 }
+
+func testListGraph() (*dependency.Graph, *cfg.Config) {
+	g := &dependency.Graph{
+		Name: "example.com/foo",
+		Nodes: []*dependency.GraphNode{
+			{Name: "example.com/foo"},
+			{Name: "example.com/a", Version: "1.0.0"},
+			{Name: "example.com/b", Version: "2.0.0"},
+			{Name: "example.com/c", Version: "3.0.0"},
+			{Name: "example.com/t", Version: "4.0.0", Test: true},
+		},
+		Edges: []*dependency.GraphEdge{
+			{From: "example.com/foo", To: "example.com/a"},
+			{From: "example.com/a", To: "example.com/b"},
+			{From: "example.com/b", To: "example.com/c"},
+			{From: "example.com/foo", To: "example.com/t"},
+		},
+	}
+	conf := &cfg.Config{
+		Name:       "example.com/foo",
+		Imports:    cfg.Dependencies{&cfg.Dependency{Name: "example.com/a"}},
+		DevImports: cfg.Dependencies{&cfg.Dependency{Name: "example.com/t"}},
+	}
+	return g, conf
+}
+
+func TestDependencyEntries(t *testing.T) {
+	g, conf := testListGraph()
+
+	entries := dependencyEntries(g, conf, 0)
+	if len(entries) != 4 {
+		t.Fatalf("Expected 4 entries, got %d", len(entries))
+	}
+	want := map[string]string{
+		"example.com/a": "glide.yaml",
+		"example.com/t": "testImport",
+		"example.com/b": "transitive via example.com/a",
+		"example.com/c": "transitive via example.com/b",
+	}
+	for _, e := range entries {
+		if entrySource(e) != want[e.Name] {
+			t.Errorf("Expected %s to come from %s, got %s", e.Name, want[e.Name], entrySource(e))
+		}
+	}
+	if entries[3].Name != "example.com/c" || entries[3].Depth != 3 {
+		t.Errorf("Unexpected last entry %+v", entries[3])
+	}
+
+	if entries = dependencyEntries(g, conf, 2); len(entries) != 3 {
+		t.Errorf("Expected 3 entries with a depth of 2, got %d", len(entries))
+	}
+	if entries = dependencyEntries(g, conf, 1); len(entries) != 2 {
+		t.Errorf("Expected 2 direct entries, got %d", len(entries))
+	}
+}
+
+func TestWriteTree(t *testing.T) {
+	g, conf := testListGraph()
+
+	var buf bytes.Buffer
+	writeTree(&buf, g, dependencyEntries(g, conf, 2), 2)
+	expected := "example.com/foo\n" +
+		"|-- example.com/a 1.0.0 (glide.yaml)\n" +
+		"|   `-- example.com/b 2.0.0\n" +
+		"`-- example.com/t 4.0.0 (testImport)\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected tree:\n%s\nExpected:\n%s", buf.String(), expected)
+	}
+}
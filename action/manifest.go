@@ -0,0 +1,201 @@
+package action
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/dependency"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/glide/util"
+)
+
+// ManifestReport lists the differences between the imports of the code and
+// the dependencies in glide.yaml.
+type ManifestReport struct {
+	// Missing are imported by the code but not listed in import.
+	Missing []string `json:"missing"`
+
+	// MissingTest are only imported by tests and listed nowhere.
+	MissingTest []string `json:"missingTest"`
+
+	// Unused are listed in import or testImport but imported by no code.
+	Unused []string `json:"unused"`
+}
+
+// Problems returns how many differences were found.
+func (r *ManifestReport) Problems() int {
+	return len(r.Missing) + len(r.MissingTest) + len(r.Unused)
+}
+
+// CheckManifest compares the imports of the code against the dependencies in
+// glide.yaml. It reports the repos imported directly but missing from
+// glide.yaml, and the dependencies no code imports. With fix, glide.yaml is
+// changed to add the missing repos and remove the unused dependencies.
+//
+// Params:
+//  - fix (bool): whether to change glide.yaml to match the code
+//  - format (string): The format to output (text, json, json-pretty)
+func CheckManifest(fix bool, format string) {
+	conf := EnsureConfig()
+	yamlpath, err := gpath.Glide()
+	if err != nil {
+		msg.Die("Failed to find %s file in directory tree: %s", gpath.GlideFile, err)
+	}
+
+	imports, testImports, err := codeImports(filepath.Dir(yamlpath), conf)
+	if err != nil {
+		msg.Die("Unable to scan the imports of the code: %s", err)
+	}
+	report := manifestDiff(conf, imports, testImports)
+
+	switch format {
+	case textFormat:
+		for _, n := range report.Missing {
+			msg.Puts("%s is imported but missing from import", n)
+		}
+		for _, n := range report.MissingTest {
+			msg.Puts("%s is imported by tests but missing from testImport", n)
+		}
+		for _, n := range report.Unused {
+			msg.Puts("%s is in %s but not imported", n, gpath.GlideFile)
+		}
+	case jsonFormat:
+		json.NewEncoder(msg.Default.Stdout).Encode(report)
+	case jsonPrettyFormat:
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			msg.Die("could not marshal the manifest report: %s", err)
+		}
+		msg.Print(string(b) + "\n")
+	default:
+		msg.Die("invalid output format: must be one of: json|json-pretty|text")
+	}
+
+	if report.Problems() == 0 {
+		msg.Info("%s matches the imports of the code", gpath.GlideFile)
+		return
+	}
+	if !fix {
+		msg.Die("Found %d difference(s). Run 'glide check-manifest --fix' to update %s", report.Problems(), gpath.GlideFile)
+	}
+
+	fixManifest(conf, report)
+	if err := conf.WriteFile(yamlpath); err != nil {
+		msg.Die("Unable to write %s: %s", gpath.GlideFile, err)
+	}
+	msg.Info("Updated %s. Run 'glide up' to update %s", gpath.GlideFile, gpath.LockFile)
+}
+
+// codeImports returns the roots of the repos imported directly by the code in
+// base and those only imported by its tests.
+func codeImports(base string, conf *cfg.Config) ([]string, []string, error) {
+	imports, testImports, err := localImports(base)
+	if err != nil {
+		return nil, nil, err
+	}
+	roots := func(pkgs []string) []string {
+		seen := make(map[string]bool)
+		var names []string
+		for _, p := range pkgs {
+			root, _ := util.NormalizeName(p)
+			if root == conf.Name || conf.HasIgnore(root) || seen[root] {
+				continue
+			}
+			seen[root] = true
+			names = append(names, root)
+		}
+		sort.Strings(names)
+		return names
+	}
+	return roots(imports), roots(testImports), nil
+}
+
+// localImports returns the packages imported directly by the code in base,
+// other than its own, and those only imported by its tests.
+func localImports(base string) ([]string, []string, error) {
+	r, err := dependency.NewResolver(base)
+	if err != nil {
+		return nil, nil, err
+	}
+	r.ResolveTest = true
+	r.Handler = &dependency.DefaultMissingPackageHandler{Missing: []string{}, Gopath: []string{}}
+
+	pkgs, testPkgs, err := r.ResolveLocal(false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	vpath := r.VendorDir
+	if !strings.HasSuffix(vpath, string(os.PathSeparator)) {
+		vpath += string(os.PathSeparator)
+	}
+	trim := func(pkgs []string) []string {
+		names := make([]string, 0, len(pkgs))
+		for _, p := range pkgs {
+			names = append(names, filepath.ToSlash(strings.TrimPrefix(p, vpath)))
+		}
+		return names
+	}
+	return trim(pkgs), trim(testPkgs), nil
+}
+
+// manifestDiff compares the roots of the repos imported by the code and by its
+// tests against the dependencies in conf. Dependencies limited to some
+// platforms are never reported as unused, as the code using them may not be
+// built on this one.
+func manifestDiff(conf *cfg.Config, imports, testImports []string) *ManifestReport {
+	report := &ManifestReport{Missing: []string{}, MissingTest: []string{}, Unused: []string{}}
+	used := make(map[string]bool)
+	for _, n := range imports {
+		used[n] = true
+		if !conf.Imports.Has(n) {
+			report.Missing = append(report.Missing, n)
+		}
+	}
+	for _, n := range testImports {
+		if used[n] {
+			continue
+		}
+		used[n] = true
+		if !conf.Imports.Has(n) && !conf.DevImports.Has(n) {
+			report.MissingTest = append(report.MissingTest, n)
+		}
+	}
+	for _, d := range append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...) {
+		if !used[d.Name] && len(d.Os) == 0 && len(d.Arch) == 0 {
+			report.Unused = append(report.Unused, d.Name)
+		}
+	}
+	sort.Strings(report.Unused)
+	return report
+}
+
+// fixManifest changes conf to add the missing dependencies in report and
+// remove the unused ones. A repo imported by the code that is only listed in
+// testImport is moved to import.
+func fixManifest(conf *cfg.Config, report *ManifestReport) {
+	for _, n := range report.Missing {
+		if d := conf.DevImports.Get(n); d != nil {
+			msg.Info("--> Moving %s from testImport to import", n)
+			conf.DevImports = conf.DevImports.Remove(n)
+			conf.Imports = append(conf.Imports, d)
+			continue
+		}
+		msg.Info("--> Adding %s to import", n)
+		conf.Imports = append(conf.Imports, &cfg.Dependency{Name: n})
+	}
+	for _, n := range report.MissingTest {
+		msg.Info("--> Adding %s to testImport", n)
+		conf.DevImports = append(conf.DevImports, &cfg.Dependency{Name: n})
+	}
+	for _, n := range report.Unused {
+		msg.Info("--> Removing %s", n)
+		conf.Imports = conf.Imports.Remove(n)
+		conf.DevImports = conf.DevImports.Remove(n)
+	}
+}
@@ -0,0 +1,44 @@
+package action
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/glide/cfg"
+)
+
+func TestManifestDiff(t *testing.T) {
+	conf := &cfg.Config{
+		Name: "example.com/app",
+		Imports: cfg.Dependencies{
+			{Name: "github.com/foo/used"},
+			{Name: "github.com/foo/unused"},
+			{Name: "github.com/foo/windows", Os: []string{"windows"}},
+		},
+		DevImports: cfg.Dependencies{
+			{Name: "github.com/foo/testing"},
+			{Name: "github.com/foo/moved"},
+		},
+	}
+	imports := []string{"github.com/foo/moved", "github.com/foo/new", "github.com/foo/used"}
+	testImports := []string{"github.com/foo/newtest", "github.com/foo/testing", "github.com/foo/used"}
+
+	r := manifestDiff(conf, imports, testImports)
+	if want := []string{"github.com/foo/moved", "github.com/foo/new"}; !reflect.DeepEqual(r.Missing, want) {
+		t.Errorf("Expected missing %v, got %v", want, r.Missing)
+	}
+	if want := []string{"github.com/foo/newtest"}; !reflect.DeepEqual(r.MissingTest, want) {
+		t.Errorf("Expected missing test imports %v, got %v", want, r.MissingTest)
+	}
+	if want := []string{"github.com/foo/unused"}; !reflect.DeepEqual(r.Unused, want) {
+		t.Errorf("Expected unused %v, got %v", want, r.Unused)
+	}
+
+	fixManifest(conf, r)
+	if r := manifestDiff(conf, imports, testImports); r.Problems() != 0 {
+		t.Errorf("Expected no differences after fixing, got %+v", r)
+	}
+	if conf.DevImports.Has("github.com/foo/moved") || !conf.Imports.Has("github.com/foo/moved") {
+		t.Error("Expected github.com/foo/moved to be moved to import")
+	}
+}
@@ -0,0 +1,67 @@
+package action
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/msg"
+	"github.com/Masterminds/glide/util"
+)
+
+// MetaList lists the roots of import paths in the remote metadata cache.
+func MetaList() {
+	entries, err := cache.MetaEntries()
+	if err != nil {
+		msg.Die("Unable to read the remote metadata cache: %s", err)
+	}
+	w := tabwriter.NewWriter(msg.Default.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PATH\tROOT\tFETCHED")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", e.Path, e.Root, e.Fetched.Format("2006-01-02 15:04"))
+	}
+	w.Flush()
+}
+
+// MetaRefresh looks up the import paths in the remote metadata cache again.
+//
+// Params:
+//  - paths ([]string): The import paths to look up. All of the cached ones
+//    when empty.
+func MetaRefresh(paths []string) {
+	if util.Offline {
+		msg.Die("Remote metadata cannot be refreshed in offline mode")
+	}
+	if len(paths) == 0 {
+		entries, err := cache.MetaEntries()
+		if err != nil {
+			msg.Die("Unable to read the remote metadata cache: %s", err)
+		}
+		for _, e := range entries {
+			paths = append(paths, e.Path)
+		}
+	}
+
+	failed := 0
+	for _, p := range paths {
+		root, err := util.LookupRoot(p)
+		if err != nil {
+			msg.Warn("--> Unable to look up %s: %s", p, err)
+			failed++
+			continue
+		}
+		if root != p {
+			// The path is not a root itself, so it is cached by its root.
+			if err := cache.RemoveMeta(p); err != nil {
+				msg.Die("Unable to update the remote metadata cache: %s", err)
+			}
+		}
+		if err := cache.SaveMeta(root, root); err != nil {
+			msg.Die("Unable to update the remote metadata cache: %s", err)
+		}
+		msg.Info("--> %s is in %s", p, root)
+	}
+	if failed > 0 {
+		msg.Die("Unable to refresh %d of %d import paths", failed, len(paths))
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
 )
 
 // NoVendor generates a list of source code directories, excepting `vendor/`.
@@ -57,6 +58,7 @@ func noVend(path string, onlyGo, suffix bool) ([]string, error) {
 	}
 
 	cur := false
+	var within []string
 
 	for _, fi := range fis {
 		if exclude(fi) {
@@ -64,7 +66,20 @@ func noVend(path string, onlyGo, suffix bool) ([]string, error) {
 		}
 
 		full := filepath.Join(path, fi.Name())
-		if fi.IsDir() && !isVend(fi) {
+		if fi.IsDir() && holdsVend(full) {
+			// Listing the directory would take in the vendor directory
+			// within it, so its contents are listed instead.
+			sub, err := noVend(full, onlyGo, suffix)
+			if err != nil {
+				return res, err
+			}
+			for _, p := range sub {
+				if p == "." {
+					p = "./" + full
+				}
+				within = append(within, p)
+			}
+		} else if fi.IsDir() && !isVend(full, fi) {
 			p := "./" + full + "/..."
 			res = append(res, p)
 		} else if !fi.IsDir() && isGoish(fi) {
@@ -77,6 +92,7 @@ func noVend(path string, onlyGo, suffix bool) ([]string, error) {
 	if onlyGo {
 		res = hasGoSource(res, suffix)
 	}
+	res = append(res, within...)
 
 	if cur {
 		res = append(res, ".")
@@ -117,11 +133,20 @@ func hasGoSource(dirs []string, suffix bool) []string {
 	return buf
 }
 
-// isVend returns true of this directory is a vendor directory.
+// isVend returns true of this directory is a vendor directory, or one set with
+// vendorDir or testVendorDir in glide.yaml.
 //
 // TODO: Should we return true for Godeps directory?
-func isVend(fi os.FileInfo) bool {
-	return fi.Name() == "vendor"
+func isVend(path string, fi os.FileInfo) bool {
+	p := filepath.Clean(path)
+	return fi.Name() == "vendor" || p == gpath.VendorDir || (gpath.TestVendorDir != "" && p == gpath.TestVendorDir)
+}
+
+// holdsVend returns true if a vendor directory set with vendorDir or
+// testVendorDir in glide.yaml is within the directory.
+func holdsVend(path string) bool {
+	prefix := filepath.Clean(path) + string(filepath.Separator)
+	return strings.HasPrefix(gpath.VendorDir, prefix) || strings.HasPrefix(gpath.TestVendorDir, prefix)
 }
 
 // exclude returns true if the directory should be excluded by Go toolchain tools.
@@ -0,0 +1,431 @@
+package action
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Masterminds/glide/archive"
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/glide/repo"
+)
+
+// packManifestFile is the first entry of a pack and describes its content.
+const packManifestFile = "glide-pack.json"
+
+// packVendorDir is the directory of a pack holding the vendor directory,
+// whatever the directory is called in the project.
+const packVendorDir = "vendor"
+
+// packManifest describes the vendor directory in a pack.
+type packManifest struct {
+	// Packages are the dependencies in the lock file of the project the pack
+	// was made for, with the hashes of their directories as glide sbom
+	// computes them.
+	Packages []*packPackage `json:"packages"`
+
+	// Files maps the slash separated paths of the regular files in the pack
+	// to the hex SHA-256 of their content.
+	Files map[string]string `json:"files"`
+
+	// Links maps the slash separated paths of the symlinks in the pack to
+	// their targets.
+	Links map[string]string `json:"links,omitempty"`
+
+	// Dirs lists the slash separated paths of the directories in the pack.
+	Dirs []string `json:"dirs,omitempty"`
+}
+
+type packPackage struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Hash    string `json:"hash,omitempty"`
+}
+
+// Pack writes the vendor directory of the project to a gzipped tarball at out
+// along with a manifest of hashes. The same vendor directory always produces
+// the same tarball: entries are sorted, timestamps zeroed, and owners left out.
+func Pack(out string) {
+	EnsureConfig()
+	yamlpath, err := gpath.Glide()
+	if err != nil {
+		msg.Die("Failed to find %s file in directory tree: %s", gpath.GlideFile, err)
+	}
+	lock, err := cfg.ReadLockFile(filepath.Join(filepath.Dir(yamlpath), gpath.LockFile))
+	if err != nil {
+		msg.Die("Could not load lockfile: %s. Run 'glide up' to create it", err)
+	}
+	vpath, err := gpath.Vendor()
+	if err != nil {
+		msg.Die("Could not find the vendor directory: %s", err)
+	}
+	if !hasDir(vpath) {
+		msg.Die("There is no vendor directory to pack. Run 'glide install' first")
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(out), ".glide-pack")
+	if err != nil {
+		msg.Die("Unable to create %s: %s", out, err)
+	}
+	err = writePack(f, vpath, lock)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), out)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		msg.Die("Unable to pack %s: %s", vpath, err)
+	}
+	msg.Info("Packed %s into %s", vpath, out)
+}
+
+// writePack writes the pack of the vendor directory at vpath to w.
+func writePack(w io.Writer, vpath string, lock *cfg.Lockfile) error {
+	entries, err := packEntries(vpath)
+	if err != nil {
+		return err
+	}
+
+	m := &packManifest{Files: make(map[string]string), Links: make(map[string]string)}
+	for _, e := range entries {
+		name := path.Join(packVendorDir, e.rel)
+		p := filepath.Join(vpath, filepath.FromSlash(e.rel))
+		switch {
+		case e.fi.IsDir():
+			m.Dirs = append(m.Dirs, name)
+		case e.fi.Mode()&os.ModeSymlink != 0:
+			if m.Links[name], err = os.Readlink(p); err != nil {
+				return err
+			}
+		default:
+			if m.Files[name], err = fileHash(p); err != nil {
+				return err
+			}
+		}
+	}
+	for _, l := range append(append(cfg.Locks{}, lock.Imports...), lock.DevImports...) {
+		p := &packPackage{Name: l.Name, Version: l.Version}
+		if dir := filepath.Join(vpath, filepath.FromSlash(l.Name)); hasDir(dir) {
+			if p.Hash, err = dirHash(dir); err != nil {
+				return err
+			}
+		}
+		m.Packages = append(m.Packages, p)
+	}
+	mb, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	mb = append(mb, '\n')
+
+	gz, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(packHeader(packManifestFile, tar.TypeReg, 0644, int64(len(mb)))); err != nil {
+		return err
+	}
+	if _, err := tw.Write(mb); err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(packHeader(packVendorDir+"/", tar.TypeDir, 0755, 0)); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		name := path.Join(packVendorDir, e.rel)
+		p := filepath.Join(vpath, filepath.FromSlash(e.rel))
+		switch {
+		case e.fi.IsDir():
+			err = tw.WriteHeader(packHeader(name+"/", tar.TypeDir, 0755, 0))
+		case e.fi.Mode()&os.ModeSymlink != 0:
+			h := packHeader(name, tar.TypeSymlink, 0777, 0)
+			if h.Linkname, err = os.Readlink(p); err == nil {
+				err = tw.WriteHeader(h)
+			}
+		default:
+			var mode int64 = 0644
+			if e.fi.Mode()&0111 != 0 {
+				mode = 0755
+			}
+			if err = tw.WriteHeader(packHeader(name, tar.TypeReg, mode, e.fi.Size())); err == nil {
+				err = copyFileTo(tw, p)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+type packEntry struct {
+	rel string
+	fi  os.FileInfo
+}
+
+// packEntries lists the entries of the vendor directory at vpath sorted by
+// their slash separated path. The records Glide keeps of the last install are
+// left out as they differ between machines. Dependencies symlinked by
+// 'glide install --link' cannot be packed.
+func packEntries(vpath string) ([]*packEntry, error) {
+	var entries []*packEntry
+	err := filepath.Walk(vpath, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == vpath {
+			return nil
+		}
+		rel, err := filepath.Rel(vpath, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == vendorStateFile || rel == installDigestFile {
+			return nil
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			if st, err := os.Stat(p); err == nil && st.IsDir() {
+				return fmt.Errorf("%s is a symlink to a directory. Run 'glide install --unlink' first", rel)
+			}
+		} else if !fi.IsDir() && !fi.Mode().IsRegular() {
+			return nil
+		}
+		entries = append(entries, &packEntry{rel: rel, fi: fi})
+		return nil
+	})
+	sort.Sort(packEntriesByPath(entries))
+	return entries, err
+}
+
+type packEntriesByPath []*packEntry
+
+func (p packEntriesByPath) Len() int           { return len(p) }
+func (p packEntriesByPath) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p packEntriesByPath) Less(i, j int) bool { return p[i].rel < p[j].rel }
+
+// packHeader returns a tar header without anything that differs between
+// machines or runs.
+func packHeader(name string, typ byte, mode, size int64) *tar.Header {
+	return &tar.Header{
+		Name:     name,
+		Typeflag: typ,
+		Mode:     mode,
+		Size:     size,
+		ModTime:  time.Unix(0, 0),
+	}
+}
+
+func copyFileTo(w io.Writer, p string) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// fileHash returns the hex SHA-256 of the content of a file.
+func fileHash(p string) (string, error) {
+	h := sha256.New()
+	if err := copyFileTo(h, p); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// Unpack replaces the vendor directory of the project with the one in the pack
+// at file. With verify the files are checked against the manifest of the pack
+// and the versions in it against the lock file of the project, when there is
+// one, before anything is replaced.
+func Unpack(file string, verify bool) {
+	EnsureProjectLock()
+	vpath, err := gpath.Vendor()
+	if err != nil {
+		msg.Die("Could not find the vendor directory: %s", err)
+	}
+
+	stage, err := ioutil.TempDir(filepath.Dir(vpath), ".glide-unpack")
+	if err != nil {
+		msg.Die("Unable to unpack %s: %s", file, err)
+	}
+	// Die exits right away so the staging directory is removed first.
+	fail := func(format string, args ...interface{}) {
+		os.RemoveAll(stage)
+		msg.Die(format, args...)
+	}
+	defer os.RemoveAll(stage)
+	if err := untarPack(file, stage); err != nil {
+		fail("Unable to unpack %s: %s", file, err)
+	}
+	if !hasDir(filepath.Join(stage, packVendorDir)) {
+		fail("%s is not a pack made by 'glide pack'", file)
+	}
+
+	if verify {
+		var lock *cfg.Lockfile
+		if yamlpath, err := gpath.Glide(); err == nil {
+			lp := filepath.Join(filepath.Dir(yamlpath), gpath.LockFile)
+			if _, err := os.Stat(lp); err == nil {
+				if lock, err = cfg.ReadLockFile(lp); err != nil {
+					fail("Could not load lockfile: %s", err)
+				}
+			}
+		}
+		problems, err := verifyPack(stage, lock)
+		if err != nil {
+			fail("Unable to verify %s: %s", file, err)
+		}
+		if len(problems) > 0 {
+			for _, p := range problems {
+				msg.Err("- %s", p)
+			}
+			fail("%s failed verification. The vendor directory was not changed", file)
+		}
+		msg.Info("Verified the content of %s", file)
+	}
+
+	if err := repo.SwapVendor(filepath.Join(stage, packVendorDir), vpath); err != nil {
+		fail("Unable to move the unpacked vendor directory into place: %s", err)
+	}
+	msg.Info("Unpacked %s into %s", file, vpath)
+}
+
+func untarPack(file, dir string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	return archive.Untar(gz, dir)
+}
+
+// verifyPack checks the pack unpacked in dir against its manifest, and the
+// versions in the manifest against lock when it is not nil. The problems found
+// are returned.
+func verifyPack(dir string, lock *cfg.Lockfile) ([]string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, packManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("the pack has no manifest: %s", err)
+	}
+	m := &packManifest{}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("unable to read the manifest: %s", err)
+	}
+
+	dirs := make(map[string]bool, len(m.Dirs))
+	for _, d := range m.Dirs {
+		dirs[d] = true
+	}
+	var problems []string
+	seen := make(map[string]bool)
+	vdir := filepath.Join(dir, packVendorDir)
+	err = filepath.Walk(vdir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil || p == vdir {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		seen[rel] = true
+		switch {
+		case fi.IsDir():
+			if !dirs[rel] {
+				problems = append(problems, fmt.Sprintf("%s is not in the manifest", rel))
+			}
+		case fi.Mode()&os.ModeSymlink != 0:
+			want, ok := m.Links[rel]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s is not in the manifest", rel))
+				return nil
+			}
+			got, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			if filepath.ToSlash(got) != filepath.ToSlash(want) {
+				problems = append(problems, fmt.Sprintf("%s does not link to %s as in the manifest", rel, want))
+			}
+		case fi.Mode().IsRegular():
+			want, ok := m.Files[rel]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s is not in the manifest", rel))
+				return nil
+			}
+			got, err := fileHash(p)
+			if err != nil {
+				return err
+			}
+			if got != want {
+				problems = append(problems, fmt.Sprintf("%s does not match its hash in the manifest", rel))
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("%s is not a file, directory or symlink", rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for f := range m.Files {
+		if !seen[f] {
+			missing = append(missing, fmt.Sprintf("%s is missing", f))
+		}
+	}
+	for l := range m.Links {
+		if !seen[l] {
+			missing = append(missing, fmt.Sprintf("%s is missing", l))
+		}
+	}
+	for d := range dirs {
+		if !seen[d] {
+			missing = append(missing, fmt.Sprintf("%s is missing", d))
+		}
+	}
+	sort.Strings(missing)
+	problems = append(problems, missing...)
+
+	if lock != nil {
+		versions := make(map[string]string, len(m.Packages))
+		for _, p := range m.Packages {
+			versions[p.Name] = p.Version
+		}
+		for _, l := range append(append(cfg.Locks{}, lock.Imports...), lock.DevImports...) {
+			v, ok := versions[l.Name]
+			switch {
+			case !ok:
+				problems = append(problems, fmt.Sprintf("%s is in %s but not in the pack", l.Name, gpath.LockFile))
+			case v != l.Version:
+				problems = append(problems, fmt.Sprintf("%s is at %s in the pack but %s in %s", l.Name, shortVersion(v), shortVersion(l.Version), gpath.LockFile))
+			}
+		}
+	}
+	return problems, nil
+}
@@ -0,0 +1,100 @@
+package action
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/glide/cfg"
+)
+
+func TestPack(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-pack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	vpath := filepath.Join(dir, "vendor")
+	a := filepath.Join(vpath, "example.com", "a")
+	os.MkdirAll(a, 0755)
+	ioutil.WriteFile(filepath.Join(a, "a.go"), []byte("package a\n"), 0644)
+	ioutil.WriteFile(filepath.Join(a, "gen.sh"), []byte("#!/bin/sh\n"), 0755)
+	if err := os.Symlink("a.go", filepath.Join(a, "current.go")); err != nil {
+		t.Fatal(err)
+	}
+	ioutil.WriteFile(filepath.Join(vpath, vendorStateFile), []byte("hash: abc\n"), 0644)
+
+	lock := &cfg.Lockfile{
+		Imports: cfg.Locks{{Name: "example.com/a", Version: "v1.0.0"}},
+	}
+	var first bytes.Buffer
+	if err := writePack(&first, vpath, lock); err != nil {
+		t.Fatal(err)
+	}
+
+	// Other timestamps and a new state file must not change the pack.
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(filepath.Join(a, "a.go"), old, old)
+	ioutil.WriteFile(filepath.Join(vpath, vendorStateFile), []byte("hash: def\n"), 0644)
+	var second bytes.Buffer
+	if err := writePack(&second, vpath, lock); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Fatal("Expected packing the same vendor directory twice to give the same result")
+	}
+
+	p := filepath.Join(dir, "vendor.tar.gz")
+	ioutil.WriteFile(p, first.Bytes(), 0644)
+	stage := filepath.Join(dir, "stage")
+	if err := untarPack(p, stage); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(stage, "vendor", vendorStateFile)); err == nil {
+		t.Error("Expected the vendor state file to be left out of the pack")
+	}
+	if fi, err := os.Stat(filepath.Join(stage, "vendor", "example.com", "a", "gen.sh")); err != nil || fi.Mode()&0111 == 0 {
+		t.Error("Expected gen.sh to be unpacked executable")
+	}
+	problems, err := verifyPack(stage, lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("Expected the pack to verify, got %v", problems)
+	}
+
+	ioutil.WriteFile(filepath.Join(stage, "vendor", "example.com", "a", "a.go"), []byte("package evil\n"), 0644)
+	ioutil.WriteFile(filepath.Join(stage, "vendor", "example.com", "a", "extra.go"), []byte("package a\n"), 0644)
+	os.Remove(filepath.Join(stage, "vendor", "example.com", "a", "gen.sh"))
+	os.Remove(filepath.Join(stage, "vendor", "example.com", "a", "current.go"))
+	os.Symlink("extra.go", filepath.Join(stage, "vendor", "example.com", "a", "current.go"))
+	os.Symlink("/etc/passwd", filepath.Join(stage, "vendor", "example.com", "a", "evil.go"))
+	os.MkdirAll(filepath.Join(stage, "vendor", "example.com", "b"), 0755)
+	lock.Imports[0].Version = "v1.1.0"
+	problems, err = verifyPack(stage, lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"vendor/example.com/a/a.go does not match",
+		"vendor/example.com/a/current.go does not link to a.go",
+		"vendor/example.com/a/evil.go is not in the manifest",
+		"vendor/example.com/a/extra.go is not in the manifest",
+		"vendor/example.com/b is not in the manifest",
+		"vendor/example.com/a/gen.sh is missing",
+		"example.com/a is at v1.0.0 in the pack but v1.1.0",
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %v", len(expected), problems)
+	}
+	for i, e := range expected {
+		if !strings.Contains(problems[i], e) {
+			t.Errorf("Expected %q to contain %q", problems[i], e)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package action
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/glide/repo"
+)
+
+// patchesDir is the directory next to glide.yaml holding the changes made to
+// vendored dependencies, one patch per dependency as in
+// patches/github.com/foo/bar.patch.
+const patchesDir = "patches"
+
+// PatchCapture stores the changes made to the vendored copy of a dependency,
+// compared to the revision locked in glide.lock, as a patch in the patches
+// directory. Install and update apply it again after exporting the
+// dependency.
+func PatchCapture(name string) {
+	conf := EnsureConfig()
+	yamlpath, err := gpath.Glide()
+	if err != nil {
+		msg.Die("Failed to find %s file in directory tree: %s", gpath.GlideFile, err)
+	}
+	base := filepath.Dir(yamlpath)
+	lock, err := cfg.ReadLockFile(filepath.Join(base, gpath.LockFile))
+	if err != nil {
+		msg.Die("A lock file (%s) is required to capture a patch: %s", gpath.LockFile, err)
+	}
+	l := lock.Imports.Get(name)
+	if l == nil {
+		l = lock.DevImports.Get(name)
+	}
+	if l == nil {
+		msg.Die("%s is not locked in %s", name, gpath.LockFile)
+	}
+	vpath, err := gpath.Vendor()
+	if err != nil {
+		msg.Die("Could not find the vendor directory: %s", err)
+	}
+	vdir := filepath.Join(vpath, filepath.FromSlash(name))
+	fi, err := os.Lstat(vdir)
+	if err != nil {
+		msg.Die("%s is not vendored: %s", name, err)
+	}
+	if fi.Mode()&os.ModeSymlink != 0 {
+		msg.Die("%s is a link to another copy, not a vendored copy that can be patched", vdir)
+	}
+
+	tmp, err := ioutil.TempDir(gpath.Tmp, "glide-patch")
+	if err != nil {
+		msg.Die("Unable to create a temporary directory: %s", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	dep := cfg.DependencyFromLock(l)
+	if d := conf.Imports.Get(name); d != nil && d.Local != "" {
+		msg.Die("%s uses the local copy at %s. Change it there instead", name, d.Local)
+	}
+	if err := repo.ExportPristine(dep, filepath.Join(tmp, "a")); err != nil {
+		os.RemoveAll(tmp)
+		msg.Die("Unable to export %s at %s: %s", name, l.Version, err)
+	}
+	if err := gpath.CopyDir(vdir, filepath.Join(tmp, "b")); err != nil {
+		os.RemoveAll(tmp)
+		msg.Die("Unable to copy %s: %s", vdir, err)
+	}
+	removeStrippedVendor(filepath.Join(tmp, "a"), filepath.Join(tmp, "b"))
+
+	patch, err := diffTrees(tmp, "a", "b")
+	if err != nil {
+		os.RemoveAll(tmp)
+		msg.Die("Unable to compare %s to %s: %s", vdir, l.Version, err)
+	}
+
+	pfile := patchFile(base, name)
+	if len(patch) == 0 {
+		msg.Info("The vendored copy of %s matches %s. There is nothing to capture", name, shortVersion(l.Version))
+		if err := os.Remove(pfile); err == nil {
+			msg.Info("Removed the patch %s", pfile)
+		}
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(pfile), 0755); err != nil {
+		os.RemoveAll(tmp)
+		msg.Die("Unable to create %s: %s", filepath.Dir(pfile), err)
+	}
+	if err := ioutil.WriteFile(pfile, patch, 0644); err != nil {
+		os.RemoveAll(tmp)
+		msg.Die("Unable to write %s: %s", pfile, err)
+	}
+	msg.Info("Captured the changes to %s in %s. Install and update apply them again", name, pfile)
+}
+
+// patchFile returns the location of the patch for a dependency.
+func patchFile(base, name string) string {
+	return filepath.Join(base, patchesDir, filepath.FromSlash(name)+".patch")
+}
+
+// removeStrippedVendor removes the nested vendor directories of the pristine
+// copy at a that were stripped from the vendored copy at b.
+func removeStrippedVendor(a, b string) {
+	filepath.Walk(a, func(p string, fi os.FileInfo, err error) error {
+		if err != nil || !fi.IsDir() || fi.Name() != "vendor" {
+			return nil
+		}
+		rel, err := filepath.Rel(a, p)
+		if err != nil {
+			return nil
+		}
+		if _, err := os.Stat(filepath.Join(b, rel)); os.IsNotExist(err) {
+			os.RemoveAll(p)
+		}
+		return filepath.SkipDir
+	})
+}
+
+// diffTrees returns the differences between the directories a and b in dir
+// as a patch to apply within a with 'git apply -p1'.
+func diffTrees(dir, a, b string) ([]byte, error) {
+	cmd := exec.Command("git", "diff", "--no-index", "--no-prefix", "--binary", "--no-color", a, b)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	// git diff exits with an error when there are differences.
+	if _, ok := err.(*exec.ExitError); ok && stderr.Len() == 0 {
+		err = nil
+	}
+	if err != nil {
+		return nil, gitError(err, stderr.Bytes())
+	}
+	return out, nil
+}
+
+// applyPatches applies the patches of the dependencies installed in
+// installed at vpath: those listed for them in the patches of conf, in order,
+// followed by the ones in the patches directory. Patches applied already, such
+// as those of copies kept from the last install, are skipped.
+func applyPatches(conf, installed *cfg.Config, vpath string) error {
+	yamlpath, err := gpath.Glide()
+	if err != nil {
+		return nil
+	}
+	base := filepath.Dir(yamlpath)
+	patches := make(map[string][]string)
+	for _, d := range append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...) {
+		for _, p := range d.Patches {
+			patches[d.Name] = append(patches[d.Name], filepath.Join(base, filepath.FromSlash(p)))
+		}
+	}
+	for name, p := range findPatches(base) {
+		if !conf.HasDependency(name) && !installed.HasDependency(name) {
+			msg.Warn("There is a patch for %s but it is not a dependency. Remove %s", name, p)
+			continue
+		}
+		patches[name] = append(patches[name], p)
+	}
+	if len(patches) == 0 {
+		return nil
+	}
+
+	failed := 0
+	for _, name := range sortedPatchNames(patches) {
+		vdir := filepath.Join(vpath, filepath.FromSlash(name))
+		fi, err := os.Lstat(vdir)
+		if err != nil || !installed.HasDependency(name) {
+			msg.Debug("Not patching %s as it is not vendored", name)
+			continue
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			msg.Warn("Not patching %s as it is a link to another copy", name)
+			continue
+		}
+		for _, p := range patches[name] {
+			applied, err := applyPatch(vdir, p)
+			if err != nil {
+				msg.Err("Unable to apply %s to %s: %s", p, name, err)
+				failed++
+			} else if applied {
+				msg.Info("--> Applied %s to %s", filepath.Base(p), name)
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d patch(es) no longer apply. Update them for the versions of the dependencies in %s", failed, gpath.LockFile)
+	}
+	return nil
+}
+
+// findPatches returns the patch of each dependency in the patches directory
+// of base by the name of the dependency.
+func findPatches(base string) map[string]string {
+	patches := make(map[string]string)
+	dir := filepath.Join(base, patchesDir)
+	filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() || !strings.HasSuffix(p, ".patch") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err == nil {
+			patches[filepath.ToSlash(strings.TrimSuffix(rel, ".patch"))] = p
+		}
+		return nil
+	})
+	return patches
+}
+
+func sortedPatchNames(patches map[string][]string) []string {
+	names := make([]string, 0, len(patches))
+	for n := range patches {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyPatch applies the patch in pfile to dir. It returns false when the
+// patch was applied already.
+func applyPatch(dir, pfile string) (bool, error) {
+	if _, err := os.Stat(pfile); err != nil {
+		return false, err
+	}
+	if _, err := gitApply(dir, "--reverse", "--check", pfile); err == nil {
+		return false, nil
+	}
+	patch, err := ioutil.ReadFile(pfile)
+	if err != nil {
+		return false, err
+	}
+	// Vendored files may be links to the cache, which patching them in
+	// place would change as well.
+	for _, p := range patchedPaths(patch) {
+		if err := unshareFile(filepath.Join(dir, filepath.FromSlash(p))); err != nil {
+			return false, err
+		}
+	}
+	if _, err := gitApply(dir, pfile); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// gitApply runs git apply on dir with args. The repo of the project, when
+// dir is within one, is not used, so the paths in the patch are relative to
+// dir.
+func gitApply(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"apply", "-p1"}, args...)...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_CEILING_DIRECTORIES="+filepath.Dir(dir))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return out, gitError(err, out)
+	}
+	return out, nil
+}
+
+// gitError returns the output of a failed git command as the error, if any.
+func gitError(err error, out []byte) error {
+	if o := strings.TrimSpace(string(out)); o != "" {
+		return errors.New(o)
+	}
+	return err
+}
+
+// patchedPaths returns the paths of the files a patch changes, relative to
+// the directory it applies to.
+func patchedPaths(patch []byte) []string {
+	var paths []string
+	s := bufio.NewScanner(bytes.NewReader(patch))
+	for s.Scan() {
+		line := s.Text()
+		if !strings.HasPrefix(line, "--- ") && !strings.HasPrefix(line, "+++ ") {
+			continue
+		}
+		p := strings.TrimSpace(line[4:])
+		if p == "/dev/null" {
+			continue
+		}
+		if i := strings.Index(p, "/"); i >= 0 {
+			paths = append(paths, p[i+1:])
+		}
+	}
+	return paths
+}
+
+// unshareFile replaces the file at p with a copy of itself, so changing it
+// does not change files it is linked to.
+func unshareFile(p string) error {
+	fi, err := os.Lstat(p)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil || !fi.Mode().IsRegular() {
+		return err
+	}
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		return err
+	}
+	return gpath.ReplaceFile(p, b, fi.Mode())
+}
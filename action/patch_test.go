@@ -0,0 +1,91 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPatchRoundTrip(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+	dir, err := ioutil.TempDir("", "glide-patch-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(p, content string) {
+		p = filepath.Join(dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a/foo.go", "package foo\n\nconst A = 1\n")
+	write("a/old.go", "package foo\n")
+	write("b/foo.go", "package foo\n\nconst A = 2\n")
+	write("b/new.go", "package foo\n\nconst B = 1\n")
+	write("vendor/foo/foo.go", "package foo\n\nconst A = 1\n")
+	write("vendor/foo/old.go", "package foo\n")
+
+	patch, err := diffTrees(dir, "a", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := patchedPaths(patch), []string{"foo.go", "foo.go", "new.go", "old.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected patched paths %v, got %v", want, got)
+	}
+	write("patches/example.com/foo.patch", string(patch))
+	patches := findPatches(dir)
+	pfile := patches["example.com/foo"]
+	if len(patches) != 1 || pfile == "" {
+		t.Fatalf("Expected the patch of example.com/foo, got %v", patches)
+	}
+
+	// A hard link stands in for a file linked to the cache.
+	vdir := filepath.Join(dir, "vendor", "foo")
+	cached := filepath.Join(dir, "cached.go")
+	if err := os.Link(filepath.Join(vdir, "foo.go"), cached); err != nil {
+		t.Logf("Unable to link: %s", err)
+		cached = ""
+	}
+
+	if applied, err := applyPatch(vdir, pfile); err != nil || !applied {
+		t.Fatalf("Expected the patch to be applied, got %t (%v)", applied, err)
+	}
+	if b, _ := ioutil.ReadFile(filepath.Join(vdir, "foo.go")); string(b) != "package foo\n\nconst A = 2\n" {
+		t.Errorf("Unexpected patched foo.go %q", b)
+	}
+	if _, err := os.Stat(filepath.Join(vdir, "new.go")); err != nil {
+		t.Errorf("Expected new.go to be added: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(vdir, "old.go")); !os.IsNotExist(err) {
+		t.Errorf("Expected old.go to be removed: %v", err)
+	}
+	if cached != "" {
+		if b, _ := ioutil.ReadFile(cached); string(b) != "package foo\n\nconst A = 1\n" {
+			t.Errorf("Expected the linked file to be left alone, got %q", b)
+		}
+	}
+
+	if applied, err := applyPatch(vdir, pfile); err != nil || applied {
+		t.Errorf("Expected the applied patch to be skipped, got %t (%v)", applied, err)
+	}
+
+	write("vendor/foo/foo.go", "package foo\n\nconst A = 3\n")
+	write("vendor/foo/old.go", "package foo\n")
+	if _, err := applyPatch(vdir, pfile); err == nil {
+		t.Error("Expected a patch that no longer fits to fail")
+	}
+
+	if _, err := applyPatch(vdir, filepath.Join(dir, "missing.patch")); err == nil {
+		t.Error("Expected a missing patch to fail")
+	}
+}
@@ -0,0 +1,183 @@
+package action
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/semver"
+)
+
+// Pin sets the version of dependencies in glide.yaml to the exact release
+// locked in glide.lock, such as =1.4.1. A dependency locked to a commit with
+// no release tag is pinned to the commit id.
+//
+// Params:
+//  - names ([]string): The dependencies to pin. All of them when empty.
+func Pin(names []string) {
+	changePins(names, func(d *cfg.Dependency, l *cfg.Lock) bool {
+		if d.Local != "" {
+			msg.Warn("--> %s uses a local copy and is not pinned", d.Name)
+			return false
+		}
+		if _, err := exactVersion(d.Reference); err == nil {
+			msg.Info("--> %s is already pinned to %s", d.Name, d.Reference)
+			return false
+		}
+		tags, err := cachedTags(d, l.Version)
+		if err != nil {
+			msg.Warn("--> Unable to find the release %s is locked to. Run 'glide install' first: %s", d.Name, err)
+			return false
+		}
+		ver := pinnedVersion(tags)
+		if ver == "" {
+			ver = l.Version
+		}
+		if ver == d.Reference {
+			return false
+		}
+		msg.Info("--> Pinning %s to %s", d.Name, ver)
+		d.Reference = ver
+		return true
+	})
+}
+
+// Unpin turns exact versions of dependencies in glide.yaml, such as =1.4.1,
+// back into ranges allowing newer compatible releases.
+//
+// Params:
+//  - names ([]string): The dependencies to unpin. All of them when empty.
+//  - tilde (bool): Allow newer patch releases only (~1.4.1) rather than newer
+//    minor releases (^1.4.1).
+func Unpin(names []string, tilde bool) {
+	changePins(names, func(d *cfg.Dependency, l *cfg.Lock) bool {
+		r, err := unpinnedRange(d.Reference, tilde)
+		if err != nil {
+			if d.Reference != "" {
+				msg.Debug("%s is not pinned to a release: %s", d.Name, err)
+			}
+			return false
+		}
+		msg.Info("--> Unpinning %s to %s", d.Name, r)
+		d.Reference = r
+		return true
+	})
+}
+
+// changePins calls change with each selected dependency listed in both
+// glide.yaml and glide.lock, then writes glide.yaml when any was changed. The
+// hash of glide.lock is updated as the locked versions still apply.
+func changePins(names []string, change func(*cfg.Dependency, *cfg.Lock) bool) {
+	EnsureProjectLock()
+	base := gpath.Basepath()
+	conf := EnsureValidConfig()
+	glidefile, err := gpath.Glide()
+	if err != nil {
+		msg.Die("Could not find Glide file: %s", err)
+	}
+	if !gpath.HasLock(base) {
+		msg.Die("Pinning dependencies requires a lock file (%s). Run 'glide update' first", gpath.LockFile)
+	}
+	lock, err := cfg.ReadLockFile(filepath.Join(base, gpath.LockFile))
+	if err != nil {
+		msg.Die("Could not load lockfile: %s", err)
+	}
+
+	selected := make(map[string]bool, len(names))
+	for _, n := range names {
+		selected[n] = true
+	}
+
+	changed := 0
+	seen := make(map[string]bool)
+	deps := append(cfg.Dependencies{}, conf.Imports...)
+	for _, d := range append(deps, conf.DevImports...) {
+		if len(names) > 0 && !selected[d.Name] {
+			continue
+		}
+		seen[d.Name] = true
+		l := lock.Imports.Get(d.Name)
+		if l == nil {
+			l = lock.DevImports.Get(d.Name)
+		}
+		if l == nil {
+			msg.Warn("--> %s is not in %s", d.Name, gpath.LockFile)
+			continue
+		}
+		if change(d, l) {
+			changed++
+		}
+	}
+	for _, n := range names {
+		if !seen[n] {
+			msg.Warn("--> %s is not listed in %s", n, gpath.GlideFile)
+		}
+	}
+	if changed == 0 {
+		msg.Info("Nothing to change")
+		return
+	}
+
+	if err := conf.WriteFile(glidefile); err != nil {
+		msg.Die("Failed to write glide YAML file: %s", err)
+	}
+	hash, err := conf.Hash()
+	if err != nil {
+		msg.Die("Failed to generate config hash. Unable to update the lock file.")
+	}
+	if lock.Hash != hash {
+		lock.Hash = hash
+		if err := lock.WriteFile(filepath.Join(base, gpath.LockFile)); err != nil {
+			msg.Die("Failed to write glide lock file: %s", err)
+		}
+		warnStaleSignature(base)
+	}
+	msg.Info("Changed the version of %d dependencies", changed)
+}
+
+// pinnedVersion returns the exact constraint for the highest release among
+// the tags of a commit, or an empty string when none is a release.
+func pinnedVersion(tags []string) string {
+	var vs []*semver.Version
+	for _, t := range tags {
+		if v, err := semver.NewVersion(t); err == nil {
+			vs = append(vs, v)
+		}
+	}
+	if len(vs) == 0 {
+		return ""
+	}
+	sort.Sort(sort.Reverse(semver.Collection(vs)))
+	return "=" + vs[0].String()
+}
+
+// exactRe matches full versions. Shorter ones, such as 1.4, are ranges.
+var exactRe = regexp.MustCompile(`^v?\d+\.\d+\.\d+`)
+
+// exactVersion returns the release an exact constraint, such as =1.4.1 or
+// 1.4.1, stands for.
+func exactVersion(ref string) (*semver.Version, error) {
+	ref = strings.TrimSpace(strings.TrimPrefix(ref, "="))
+	if !exactRe.MatchString(ref) {
+		return nil, fmt.Errorf("%q is not an exact version", ref)
+	}
+	return semver.NewVersion(ref)
+}
+
+// unpinnedRange returns the range of releases compatible with an exact
+// constraint.
+func unpinnedRange(ref string, tilde bool) (string, error) {
+	v, err := exactVersion(ref)
+	if err != nil {
+		return "", err
+	}
+	if tilde {
+		return "~" + v.String(), nil
+	}
+	return "^" + v.String(), nil
+}
@@ -0,0 +1,42 @@
+package action
+
+import "testing"
+
+func TestPinnedVersion(t *testing.T) {
+	tests := []struct {
+		tags []string
+		want string
+	}{
+		{[]string{"v1.4.1"}, "=1.4.1"},
+		{[]string{"latest", "1.4.0", "v1.4.1"}, "=1.4.1"},
+		{[]string{"latest"}, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := pinnedVersion(tt.tags); got != tt.want {
+			t.Errorf("Expected %v to pin to %q, got %q", tt.tags, tt.want, got)
+		}
+	}
+}
+
+func TestUnpinnedRange(t *testing.T) {
+	tests := []struct {
+		ref   string
+		tilde bool
+		want  string
+	}{
+		{"=1.4.1", false, "^1.4.1"},
+		{"1.4.1", true, "~1.4.1"},
+		{"=v2.0.0-rc.1", false, "^2.0.0-rc.1"},
+		{"^1.4.1", false, ""},
+		{"1.4", false, ""},
+		{"master", false, ""},
+		{"a9949121a2e2192ca92fa6dddfeaaa4a4412d955", false, ""},
+	}
+	for _, tt := range tests {
+		got, err := unpinnedRange(tt.ref, tt.tilde)
+		if (err != nil) != (tt.want == "") || got != tt.want {
+			t.Errorf("Expected %q to unpin to %q, got %q (%v)", tt.ref, tt.want, got, err)
+		}
+	}
+}
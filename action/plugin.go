@@ -1,14 +1,85 @@
 package action
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"text/tabwriter"
 
+	"github.com/Masterminds/glide/cfg"
 	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/semver"
 )
 
+// PluginAPIVersion is the version of the contract between Glide and plugins:
+// the environment variables set for them and the JSON project context passed
+// on stdin. Plugins declaring a newer API version are not run.
+const PluginAPIVersion = 1
+
+// PluginManifest is what a plugin declares about itself. It is read from a
+// line in the plugin executable of the form
+//
+//     glide-plugin: {"api": 1, "glide": ">=0.13.0", "context": true}
+//
+// which works for scripts as a comment and for compiled plugins as a string
+// constant. Plugins without one are run as they always have been.
+type PluginManifest struct {
+	// API is the plugin API version the plugin was written against.
+	API int `json:"api"`
+
+	// Glide is a semantic version constraint on the version of Glide.
+	Glide string `json:"glide,omitempty"`
+
+	// Context asks for the JSON project context on stdin.
+	Context bool `json:"context,omitempty"`
+
+	Description string `json:"description,omitempty"`
+}
+
+// PluginContext is the JSON project context passed on stdin to plugins that
+// ask for it. The project fields are empty outside of a project.
+type PluginContext struct {
+	API          int              `json:"api"`
+	GlideVersion string           `json:"glideVersion"`
+	Home         string           `json:"home"`
+	Args         []string         `json:"args"`
+	Project      string           `json:"project,omitempty"`
+	Name         string           `json:"name,omitempty"`
+	Yaml         string           `json:"yaml,omitempty"`
+	Lock         string           `json:"lock,omitempty"`
+	Vendor       string           `json:"vendor,omitempty"`
+	Imports      []*pluginDep     `json:"imports,omitempty"`
+	DevImports   []*pluginDep     `json:"devImports,omitempty"`
+	Locked       []*pluginLockDep `json:"locked,omitempty"`
+}
+
+type pluginDep struct {
+	Name       string `json:"name"`
+	Reference  string `json:"version,omitempty"`
+	Repository string `json:"repo,omitempty"`
+}
+
+type pluginLockDep struct {
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	Repository string `json:"repo,omitempty"`
+	Dev        bool   `json:"dev,omitempty"`
+}
+
 // Plugin attempts to find and execute a plugin based on a command.
 //
+// Plugins get the GLIDE_* environment variables described in the plugin
+// documentation and, when their manifest asks for it, the project context as
+// JSON on stdin.
+//
 // Exit code 99 means the plugin was never executed. Code 1 means the program
 // exited badly.
 func Plugin(command string, args []string) {
@@ -29,6 +100,17 @@ func Plugin(command string, args []string) {
 		}
 	}
 
+	manifest, err := readPluginManifest(fullcmd)
+	if err != nil {
+		msg.Warn("Unable to read the manifest of %s: %s", cmd, err)
+	}
+	if manifest != nil {
+		if err := pluginCompatible(manifest, glideVersion); err != nil {
+			msg.ExitCode(99)
+			msg.Die("Unable to run %s: %s", cmd, err)
+		}
+	}
+
 	// Turning os.Args first argument from `glide` to `glide-command`
 	args[0] = cmd
 	// Removing the first argument (command)
@@ -39,21 +121,343 @@ func Plugin(command string, args []string) {
 			removed = true
 		}
 	}
-	pa := os.ProcAttr{
-		Files: []*os.File{os.Stdin, os.Stdout, os.Stderr},
-		Dir:   cwd,
+
+	ctx := pluginContext(args[1:])
+	c := exec.Command(fullcmd, args[1:]...)
+	c.Args[0] = cmd
+	c.Dir = cwd
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	c.Env = append(os.Environ(), pluginEnv(ctx)...)
+	if manifest != nil && manifest.Context {
+		b, err := json.Marshal(ctx)
+		if err != nil {
+			msg.ExitCode(99)
+			msg.Die("Unable to encode the project context: %s", err)
+		}
+		c.Stdin = bytes.NewReader(append(b, '\n'))
+	} else {
+		c.Stdin = os.Stdin
 	}
 
 	msg.Debug("Delegating to plugin %s (%v)\n", fullcmd, args)
 
-	proc, err := os.StartProcess(fullcmd, args, &pa)
-	if err != nil {
+	if err := c.Start(); err != nil {
 		msg.Err("Failed to execute %s: %s", cmd, err)
 		os.Exit(98)
 	}
 
-	if _, err := proc.Wait(); err != nil {
-		msg.Err(err.Error())
+	if err := c.Wait(); err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			msg.Err(err.Error())
+		}
 		os.Exit(1)
 	}
 }
+
+// pluginContext gathers the project context handed to plugins. Failing to
+// find or read the project leaves its fields empty.
+func pluginContext(args []string) *PluginContext {
+	ctx := &PluginContext{
+		API:          PluginAPIVersion,
+		GlideVersion: glideVersion,
+		Home:         gpath.Home(),
+		Args:         args,
+	}
+	yamlpath, err := gpath.Glide()
+	if err != nil {
+		return ctx
+	}
+	ctx.Yaml = yamlpath
+	ctx.Project = filepath.Dir(yamlpath)
+	if vp, err := gpath.Vendor(); err == nil {
+		ctx.Vendor = vp
+	}
+	if yml, err := ioutil.ReadFile(yamlpath); err == nil {
+		if conf, err := cfg.ConfigFromYaml(yml); err == nil {
+			ctx.Name = conf.Name
+			ctx.Imports = pluginDeps(conf.Imports)
+			ctx.DevImports = pluginDeps(conf.DevImports)
+		} else {
+			msg.Debug("Unable to read %s for the plugin: %s", yamlpath, err)
+		}
+	}
+	if gpath.HasLock(ctx.Project) {
+		ctx.Lock = filepath.Join(ctx.Project, gpath.LockFile)
+		if lock, err := cfg.ReadLockFile(ctx.Lock); err == nil {
+			for _, l := range lock.Imports {
+				ctx.Locked = append(ctx.Locked, &pluginLockDep{Name: l.Name, Version: l.Version, Repository: l.Repository})
+			}
+			for _, l := range lock.DevImports {
+				ctx.Locked = append(ctx.Locked, &pluginLockDep{Name: l.Name, Version: l.Version, Repository: l.Repository, Dev: true})
+			}
+		} else {
+			msg.Debug("Unable to read %s for the plugin: %s", ctx.Lock, err)
+		}
+	}
+	return ctx
+}
+
+func pluginDeps(deps cfg.Dependencies) []*pluginDep {
+	var p []*pluginDep
+	for _, d := range deps {
+		p = append(p, &pluginDep{Name: d.Name, Reference: d.Reference, Repository: d.Repository})
+	}
+	return p
+}
+
+// pluginEnv returns the environment variables describing the context to a
+// plugin. They are set for every plugin, with or without a manifest.
+func pluginEnv(ctx *PluginContext) []string {
+	return []string{
+		fmt.Sprintf("GLIDE_PLUGIN_API=%d", ctx.API),
+		"GLIDE_VERSION=" + ctx.GlideVersion,
+		"GLIDE_HOME=" + ctx.Home,
+		"GLIDE_PROJECT=" + ctx.Project,
+		"GLIDE_YAML=" + ctx.Yaml,
+		"GLIDE_LOCK=" + ctx.Lock,
+		"GLIDE_VENDOR=" + ctx.Vendor,
+	}
+}
+
+// pluginManifestRe matches the manifest line of a plugin. The JSON object
+// must be on one line and flat so it can be found in compiled plugins too.
+var pluginManifestRe = regexp.MustCompile(`glide-plugin:[ \t]*(\{[^{}\n]*\})`)
+
+// readPluginManifest reads the manifest from a plugin executable. It returns
+// nil when there is none.
+func readPluginManifest(path string) (*PluginManifest, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := pluginManifestRe.FindSubmatch(b)
+	if m == nil {
+		return nil, nil
+	}
+	manifest := &PluginManifest{}
+	if err := json.Unmarshal(m[1], manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %s", m[1], err)
+	}
+	return manifest, nil
+}
+
+// pluginCompatible checks a plugin can run with this version of Glide. The
+// pre-release of a development version of Glide is ignored so it matches the
+// release it leads up to.
+func pluginCompatible(m *PluginManifest, version string) error {
+	if m.API > PluginAPIVersion {
+		return fmt.Errorf("it needs plugin API version %d but Glide %s supports version %d", m.API, version, PluginAPIVersion)
+	}
+	if m.Glide == "" {
+		return nil
+	}
+	c, err := semver.NewConstraint(m.Glide)
+	if err != nil {
+		return fmt.Errorf("invalid Glide version constraint %q: %s", m.Glide, err)
+	}
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		// Builds without a version are assumed to be compatible.
+		return nil
+	}
+	if r, err := semver.NewVersion(fmt.Sprintf("%d.%d.%d", v.Major(), v.Minor(), v.Patch())); err == nil {
+		v = r
+	}
+	if !c.Check(v) {
+		return fmt.Errorf("it needs Glide %s but this is Glide %s", m.Glide, version)
+	}
+	return nil
+}
+
+// pluginInfo is a plugin found by PluginList.
+type pluginInfo struct {
+	Name     string
+	Path     string
+	Manifest *PluginManifest
+	Err      error
+}
+
+// findPlugins returns the plugins in the directories on the PATH and then in
+// dir. When several have the same name the first one is the one run, as for
+// the PATH itself.
+func findPlugins(dir string) []*pluginInfo {
+	var plugins []*pluginInfo
+	seen := make(map[string]bool)
+	for _, d := range append(filepath.SplitList(os.Getenv("PATH")), dir) {
+		if d == "" {
+			continue
+		}
+		files, err := ioutil.ReadDir(d)
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			name, ok := pluginName(f)
+			if !ok || seen[name] {
+				continue
+			}
+			seen[name] = true
+			p := &pluginInfo{Name: name, Path: filepath.Join(d, f.Name())}
+			p.Manifest, p.Err = readPluginManifest(p.Path)
+			plugins = append(plugins, p)
+		}
+	}
+	return plugins
+}
+
+// pluginName returns the command a plugin executable provides, if it is one.
+func pluginName(fi os.FileInfo) (string, bool) {
+	n := fi.Name()
+	if !strings.HasPrefix(n, "glide-") || fi.IsDir() {
+		return "", false
+	}
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(n))
+		if ext != ".exe" && ext != ".bat" && ext != ".cmd" {
+			return "", false
+		}
+		n = strings.TrimSuffix(n, filepath.Ext(n))
+	} else if fi.Mode()&0111 == 0 {
+		return "", false
+	}
+	return strings.TrimPrefix(n, "glide-"), true
+}
+
+// PluginList lists the plugins on the PATH and in the current directory along
+// with whether they can run with this version of Glide.
+func PluginList() {
+	cwd, err := os.Getwd()
+	if err != nil {
+		msg.Die("Could not get working directory: %s", err)
+	}
+	plugins := findPlugins(cwd)
+	if len(plugins) == 0 {
+		msg.Info("No plugins found")
+		return
+	}
+	w := tabwriter.NewWriter(msg.Default.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATUS\tPATH\tDESCRIPTION")
+	for _, p := range plugins {
+		status, desc := "ok", ""
+		switch {
+		case p.Err != nil:
+			status = "invalid manifest"
+		case p.Manifest == nil:
+			status = "no manifest"
+		default:
+			desc = p.Manifest.Description
+			if err := pluginCompatible(p.Manifest, glideVersion); err != nil {
+				status = "incompatible"
+				msg.Debug("%s is incompatible: %s", p.Name, err)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Name, status, p.Path, desc)
+	}
+	w.Flush()
+}
+
+// pluginNameRe matches the names plugins can be created with.
+var pluginNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// PluginInit creates a plugin named name in the current directory, as a shell
+// script or, with lang set to go, as a Go program in a directory of its own.
+// The plugin has a manifest and reads the project context from stdin.
+func PluginInit(name, lang string) {
+	name = strings.TrimPrefix(name, "glide-")
+	if !pluginNameRe.MatchString(name) {
+		msg.Die("Invalid plugin name %q: use lower case letters, digits, dashes, and underscores", name)
+	}
+	cmd := "glide-" + name
+	if _, err := os.Stat(cmd); err == nil {
+		msg.Die("%s already exists", cmd)
+	}
+
+	manifest := fmt.Sprintf(`glide-plugin: {"api": %d, "glide": ">=%s", "context": true, "description": "TODO: describe %s"}`,
+		PluginAPIVersion, pluginMinVersion(glideVersion), name)
+	switch lang {
+	case "", "sh":
+		err := ioutil.WriteFile(cmd, []byte(fmt.Sprintf(pluginShTemplate, manifest, name)), 0755)
+		if err != nil {
+			msg.Die("Unable to create %s: %s", cmd, err)
+		}
+		msg.Info("Created the plugin %s. Run it with 'glide %s'", cmd, name)
+	case "go":
+		if err := os.MkdirAll(cmd, 0755); err != nil {
+			msg.Die("Unable to create %s: %s", cmd, err)
+		}
+		src := filepath.Join(cmd, "main.go")
+		if err := ioutil.WriteFile(src, []byte(fmt.Sprintf(pluginGoTemplate, manifest, name)), 0644); err != nil {
+			msg.Die("Unable to create %s: %s", src, err)
+		}
+		msg.Info("Created the plugin in %s. Build it with 'go build' and put the binary on your PATH", src)
+	default:
+		msg.Die("Unknown plugin language %q: must be one of sh|go", lang)
+	}
+}
+
+// pluginMinVersion returns the release a version of Glide belongs to, for
+// the Glide constraint of new plugins.
+func pluginMinVersion(version string) string {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return "0.13.0"
+	}
+	return fmt.Sprintf("%d.%d.0", v.Major(), v.Minor())
+}
+
+const pluginShTemplate = `#!/bin/sh
+# %s
+#
+# Glide runs this plugin for 'glide %[2]s'. The project context is passed as
+# JSON on stdin and the GLIDE_* environment variables describe the project.
+set -e
+
+context=$(cat)
+
+echo "Running %[2]s for ${GLIDE_PROJECT:-no project} with Glide ${GLIDE_VERSION}"
+echo "$context"
+`
+
+const pluginGoTemplate = `// Command glide-%[2]s is a Glide plugin run for 'glide %[2]s'.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// manifest tells Glide about the plugin. It must stay in the binary.
+var manifest = ` + "`%[1]s`" + `
+
+type dependency struct {
+	Name    string ` + "`json:\"name\"`" + `
+	Version string ` + "`json:\"version\"`" + `
+}
+
+// context is the project context Glide passes on stdin.
+type context struct {
+	GlideVersion string       ` + "`json:\"glideVersion\"`" + `
+	Project      string       ` + "`json:\"project\"`" + `
+	Args         []string     ` + "`json:\"args\"`" + `
+	Locked       []dependency ` + "`json:\"locked\"`" + `
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "--manifest" {
+		fmt.Println(manifest)
+		return
+	}
+
+	var ctx context
+	if err := json.NewDecoder(os.Stdin).Decode(&ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to read the context from Glide: %%s\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Running %[2]s for %%s with Glide %%s\n", ctx.Project, ctx.GlideVersion)
+	for _, d := range ctx.Locked {
+		fmt.Printf("%%s %%s\n", d.Name, d.Version)
+	}
+}
+`
@@ -1,7 +1,9 @@
 package action
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"runtime"
 	"testing"
 
@@ -26,3 +28,76 @@ func TestPlugin(t *testing.T) {
 	Plugin(cmd, args)
 	os.Chdir(wd)
 }
+
+func TestPluginManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-plugin-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := filepath.Join(dir, "glide-foo")
+	script := "#!/bin/sh\n# glide-plugin: {\"api\": 1, \"glide\": \">=0.13.0\", \"context\": true, \"description\": \"Foo\"}\necho foo\n"
+	if err := ioutil.WriteFile(p, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	m, err := readPluginManifest(p)
+	if err != nil || m == nil {
+		t.Fatalf("Unable to read the manifest: %v", err)
+	}
+	if m.API != 1 || m.Glide != ">=0.13.0" || !m.Context || m.Description != "Foo" {
+		t.Errorf("Unexpected manifest %+v", m)
+	}
+
+	if err := ioutil.WriteFile(p, []byte("#!/bin/sh\necho foo\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if m, err := readPluginManifest(p); m != nil || err != nil {
+		t.Errorf("Expected no manifest, got %+v (%v)", m, err)
+	}
+}
+
+func TestPluginCompatible(t *testing.T) {
+	tests := []struct {
+		manifest PluginManifest
+		version  string
+		ok       bool
+	}{
+		{PluginManifest{API: 1, Glide: ">=0.13.0"}, "0.13.4-dev", true},
+		{PluginManifest{API: 1, Glide: ">=0.14.0"}, "0.13.4", false},
+		{PluginManifest{API: PluginAPIVersion + 1}, "0.13.4", false},
+		{PluginManifest{API: 1, Glide: ">=0.14.0"}, "", true},
+		{PluginManifest{API: 1, Glide: "not a constraint"}, "0.13.4", false},
+	}
+	for _, tt := range tests {
+		err := pluginCompatible(&tt.manifest, tt.version)
+		if (err == nil) != tt.ok {
+			t.Errorf("Expected %+v with Glide %q to be compatible: %t, got %v", tt.manifest, tt.version, tt.ok, err)
+		}
+	}
+}
+
+func TestFindPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Plugins are found by extension on Windows")
+	}
+	dir, err := ioutil.TempDir("", "glide-plugin-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]os.FileMode{"glide-foo": 0755, "glide-notexec": 0644, "other": 0755}
+	for n, mode := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, n), []byte("#!/bin/sh\n"), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", "")
+
+	plugins := findPlugins(dir)
+	if len(plugins) != 1 || plugins[0].Name != "foo" || plugins[0].Manifest != nil {
+		t.Errorf("Expected to find the foo plugin only, got %v", plugins)
+	}
+}
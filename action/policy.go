@@ -0,0 +1,174 @@
+package action
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/hosting"
+	"github.com/Masterminds/glide/msg"
+	"github.com/Masterminds/glide/repo"
+)
+
+// PolicyWarnOnly reports dependencies violating the policy of the project as
+// warnings rather than stopping.
+var PolicyWarnOnly bool
+
+// enforcePolicy checks the dependencies against the policy in the config and
+// stops when any violate it. Licenses are read from the copies of the repos in
+// the cache, or the vendor directory when a repo is not cached.
+func enforcePolicy(conf *cfg.Config, deps cfg.Dependencies) {
+	p := conf.Policy
+	if p.Empty() {
+		return
+	}
+	msg.Info("Checking dependencies against the policy")
+
+	vpath := conf.VendorPath()
+	now := time.Now()
+	var report []string
+	for _, d := range deps {
+		license := ""
+		if len(p.Licenses) > 0 || len(p.DenyLicenses) > 0 {
+			license = policyLicense(d, vpath)
+		}
+		var date time.Time
+		if p.MaxAge > 0 {
+			date = policyDate(d)
+		}
+		for _, v := range policyViolations(p, d, license, date, now) {
+			report = append(report, fmt.Sprintf("%s: %s", d.Name, v))
+		}
+	}
+	if len(report) == 0 {
+		return
+	}
+
+	if PolicyWarnOnly {
+		msg.Warn("Dependencies violate the policy:")
+		for _, r := range report {
+			msg.Warn("- %s", r)
+		}
+		return
+	}
+	msg.Err("Dependencies violate the policy:")
+	for _, r := range report {
+		msg.Err("- %s", r)
+	}
+	msg.Die("Stopping due to the policy violations. Use --policy-warn-only to continue anyway")
+}
+
+// policyViolations returns the ways a dependency violates a policy. The date
+// of the commit it is pinned to is only checked when known.
+func policyViolations(p *cfg.Policy, d *cfg.Dependency, license string, date, now time.Time) []string {
+	var vs []string
+	if pat := matchPackages(p.Deny, d.Name); pat != "" {
+		vs = append(vs, fmt.Sprintf("denied by %q", pat))
+	} else if len(p.Allow) > 0 && matchPackages(p.Allow, d.Name) == "" {
+		vs = append(vs, "not in the allowed packages")
+	}
+
+	if len(p.Licenses) > 0 || len(p.DenyLicenses) > 0 {
+		switch {
+		case license != "" && hasLicense(p.DenyLicenses, license):
+			vs = append(vs, fmt.Sprintf("license %s is denied", license))
+		case len(p.Licenses) == 0:
+		case license == "":
+			vs = append(vs, "license is not recognized")
+		case !hasLicense(p.Licenses, license):
+			vs = append(vs, fmt.Sprintf("license %s is not allowed", license))
+		}
+	}
+
+	if p.MaxAge > 0 && !date.IsZero() && now.Sub(date) > time.Duration(p.MaxAge)*24*time.Hour {
+		vs = append(vs, fmt.Sprintf("pinned to a commit from %s, older than %d days", date.Format("2006-01-02"), p.MaxAge))
+	}
+
+	if len(p.Hosts) > 0 {
+		remote := d.Remote()
+		host := repo.RemoteHost(remote)
+		if !hasHost(p.Hosts, host) {
+			vs = append(vs, fmt.Sprintf("fetched from %s, which is not an allowed host", remote))
+		}
+	}
+	return vs
+}
+
+// matchPackages returns the first of the patterns matching the package name
+// or one of its parent paths, or an empty string when none do.
+func matchPackages(patterns []string, name string) string {
+	for _, pat := range patterns {
+		for n := name; n != ""; {
+			if ok, _ := path.Match(pat, n); ok {
+				return pat
+			}
+			i := strings.LastIndex(n, "/")
+			if i < 0 {
+				break
+			}
+			n = n[:i]
+		}
+	}
+	return ""
+}
+
+func hasLicense(ids []string, id string) bool {
+	for _, l := range ids {
+		if strings.EqualFold(l, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasHost reports whether host is one of hosts. A host listed without a port
+// allows any port.
+func hasHost(hosts []string, host string) bool {
+	if host == "" {
+		return false
+	}
+	name := host
+	if i := strings.LastIndex(host, ":"); i >= 0 && !strings.HasSuffix(host, "]") {
+		name = host[:i]
+	}
+	for _, h := range hosts {
+		if strings.EqualFold(h, host) || strings.EqualFold(h, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// policyLicense detects the license of a dependency.
+func policyLicense(d *cfg.Dependency, vpath string) string {
+	if key, err := cache.Key(d.Remote()); err == nil {
+		dir := filepath.Join(cache.Location(), "src", key)
+		if hasDir(dir) {
+			return detectLicense(dir)
+		}
+	}
+	return detectLicense(filepath.Join(vpath, filepath.FromSlash(d.Name)))
+}
+
+// policyDate returns the date of the commit a dependency is pinned to, or the
+// zero time when it cannot be found.
+func policyDate(d *cfg.Dependency) time.Time {
+	if d.Pin == "" {
+		return time.Time{}
+	}
+	if r, err := cachedRepo(d); err == nil {
+		if ci, err := r.CommitInfo(d.Pin); err == nil {
+			return ci.Date
+		}
+	}
+	t, err := hosting.CommitDate(d.Remote(), d.Pin)
+	if err != nil {
+		msg.Warn("Unable to find the date of %s in %s to check its age", d.Pin, d.Name)
+		return time.Time{}
+	}
+	return t
+}
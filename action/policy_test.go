@@ -0,0 +1,86 @@
+package action
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/glide/cfg"
+)
+
+func TestMatchPackages(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		name     string
+		expected string
+	}{
+		{[]string{"github.com/foo/*"}, "github.com/foo/bar", "github.com/foo/*"},
+		{[]string{"github.com/foo/*"}, "github.com/foo/bar/baz", "github.com/foo/*"},
+		{[]string{"github.com/foo/*"}, "github.com/foobar/baz", ""},
+		{[]string{"golang.org/x/net"}, "golang.org/x/net/context", "golang.org/x/net"},
+		{[]string{"example.com/a", "*.example.com"}, "git.example.com/b", "*.example.com"},
+	}
+	for _, tt := range tests {
+		if got := matchPackages(tt.patterns, tt.name); got != tt.expected {
+			t.Errorf("Expected %v to match %s with %q, got %q", tt.patterns, tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestPolicyViolations(t *testing.T) {
+	p := &cfg.Policy{
+		Allow:        []string{"github.com/*"},
+		Deny:         []string{"github.com/evil/*"},
+		Licenses:     []string{"MIT", "apache-2.0"},
+		DenyLicenses: []string{"GPL-3.0"},
+		MaxAge:       30,
+		Hosts:        []string{"github.com"},
+	}
+	now := time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC)
+	recent := now.Add(-24 * time.Hour)
+	tests := []struct {
+		dep      *cfg.Dependency
+		license  string
+		date     time.Time
+		expected []string
+	}{
+		{&cfg.Dependency{Name: "github.com/good/pkg"}, "Apache-2.0", recent, nil},
+		{&cfg.Dependency{Name: "github.com/evil/pkg"}, "MIT", recent, []string{`denied by "github.com/evil/*"`}},
+		{&cfg.Dependency{Name: "gopkg.in/yaml.v2", Repository: "https://github.com/go-yaml/yaml"}, "MIT", recent, []string{"not in the allowed packages"}},
+		{&cfg.Dependency{Name: "github.com/gpl/pkg"}, "GPL-3.0", recent, []string{"license GPL-3.0 is denied"}},
+		{&cfg.Dependency{Name: "github.com/bsd/pkg"}, "BSD-3-Clause", recent, []string{"license BSD-3-Clause is not allowed"}},
+		{&cfg.Dependency{Name: "github.com/none/pkg"}, "", recent, []string{"license is not recognized"}},
+		{&cfg.Dependency{Name: "github.com/old/pkg"}, "MIT", now.AddDate(0, -2, 0), []string{"older than 30 days"}},
+		{&cfg.Dependency{Name: "github.com/unknown/pkg"}, "MIT", time.Time{}, nil},
+		{&cfg.Dependency{Name: "github.com/mirror/pkg", Repository: "https://git.example.com/mirror/pkg"}, "MIT", recent, []string{"not an allowed host"}},
+	}
+	for _, tt := range tests {
+		got := policyViolations(p, tt.dep, tt.license, tt.date, now)
+		if len(got) != len(tt.expected) {
+			t.Errorf("Expected %d violations for %s, got %v", len(tt.expected), tt.dep.Name, got)
+			continue
+		}
+		for i, e := range tt.expected {
+			if !strings.Contains(got[i], e) {
+				t.Errorf("Expected violation of %s to contain %q, got %q", tt.dep.Name, e, got[i])
+			}
+		}
+	}
+}
+
+func TestHasHost(t *testing.T) {
+	hosts := []string{"github.com", "git.example.com:2222"}
+	tests := map[string]bool{
+		"github.com":           true,
+		"GitHub.com":           true,
+		"github.com:443":       true,
+		"git.example.com:2222": true,
+		"git.example.com":      false,
+		"":                     false,
+	}
+	for host, expected := range tests {
+		if got := hasHost(hosts, host); got != expected {
+			t.Errorf("Expected hasHost(%q) to be %t, got %t", host, expected, got)
+		}
+	}
+}
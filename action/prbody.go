@@ -0,0 +1,171 @@
+package action
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/hosting"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/semver"
+)
+
+// PRBody, when set, is where update writes a Markdown summary of the changes
+// to the dependencies, for the description of a pull request. "-" writes it
+// to stdout.
+var PRBody string
+
+// writePRBody writes the summary of the changes between two lock files to
+// PRBody, if set.
+func writePRBody(conf *cfg.Config, old, cur *cfg.Lockfile) {
+	if PRBody == "" {
+		return
+	}
+	if old == nil {
+		old = &cfg.Lockfile{}
+	}
+	body := prBody(conf, old, cur)
+	if PRBody == "-" {
+		msg.Print(body)
+		return
+	}
+	if err := ioutil.WriteFile(PRBody, []byte(body), 0644); err != nil {
+		msg.Err("Unable to write the pull request description to %s: %s", PRBody, err)
+		return
+	}
+	msg.Info("Wrote the pull request description to %s", PRBody)
+}
+
+// prBody returns a Markdown summary of the changes between two lock files: a
+// table of the changed dependencies with links to their changes, the licenses
+// that changed, and the commits of each changed dependency.
+func prBody(conf *cfg.Config, old, cur *cfg.Lockfile) string {
+	changes := lockDiff(old, cur)
+	var b bytes.Buffer
+	b.WriteString("## Dependency updates\n\n")
+	if len(changes) == 0 {
+		b.WriteString("No dependencies changed.\n")
+		return b.String()
+	}
+
+	var added, removed, updated int
+	for _, c := range changes {
+		c.Transitive = c.Old == nil && !conf.HasDependency(c.Name)
+		switch {
+		case c.Old == nil:
+			added++
+		case c.New == nil:
+			removed++
+		default:
+			updated++
+		}
+	}
+	fmt.Fprintf(&b, "Updated %d, added %d, and removed %d dependencies in `%s`.\n\n", updated, added, removed, gpath.LockFile)
+
+	b.WriteString("| Dependency | From | To | Changes |\n")
+	b.WriteString("|------------|------|----|---------|\n")
+	var licenses, commits bytes.Buffer
+	for _, c := range changes {
+		name := "`" + c.Name + "`"
+		if c.Transitive {
+			name += " (transitive)"
+		}
+		switch {
+		case c.Old == nil:
+			to := displayVersion(c.New)
+			fmt.Fprintf(&b, "| %s | | %s | %s |\n", name, to, link("added", treeURL(c.New, to)))
+			if l := licenseAt(c.New, c.New.Version); l != "" {
+				fmt.Fprintf(&licenses, "- `%s`: %s (added)\n", c.Name, l)
+			}
+		case c.New == nil:
+			fmt.Fprintf(&b, "| %s | %s | | removed |\n", name, displayVersion(c.Old))
+		case c.Old.Repository != c.New.Repository:
+			fmt.Fprintf(&b, "| %s | %s | %s | repo %s → %s |\n", name, displayVersion(c.Old), displayVersion(c.New), remoteOf(c.Old), remoteOf(c.New))
+		default:
+			from, to := displayVersion(c.Old), displayVersion(c.New)
+			u, _ := hosting.CompareURL(remoteOf(c.New), compareRev(c.Old, from), compareRev(c.New, to))
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", name, from, to, link("compare", u))
+
+			if c.Old.Version == c.New.Version {
+				continue
+			}
+			ol, nl := licenseAt(c.Old, c.Old.Version), licenseAt(c.New, c.New.Version)
+			if ol != nl && nl != "" {
+				if ol == "" {
+					ol = "unknown"
+				}
+				fmt.Fprintf(&licenses, "- `%s`: %s → %s\n", c.Name, ol, nl)
+			}
+			lines, more, err := commitLog(c.New, c.Old.Version, c.New.Version, changelogCommits)
+			if err != nil {
+				msg.Debug("%s", err)
+			}
+			if len(lines) > 0 {
+				fmt.Fprintf(&commits, "<details>\n<summary>%s %s → %s</summary>\n\n", c.Name, from, to)
+				for _, line := range lines {
+					fmt.Fprintf(&commits, "- %s\n", line)
+				}
+				if more > 0 {
+					fmt.Fprintf(&commits, "- ... and %d more\n", more)
+				}
+				commits.WriteString("\n</details>\n")
+			}
+		}
+	}
+
+	if licenses.Len() > 0 {
+		b.WriteString("\n### License changes\n\n")
+		b.Write(licenses.Bytes())
+	}
+	if commits.Len() > 0 {
+		b.WriteString("\n### Commits\n\n")
+		b.Write(commits.Bytes())
+	}
+	return b.String()
+}
+
+// displayVersion returns the newest semantic version tag pointing at the
+// locked revision of a dependency, or else the revision itself shortened.
+func displayVersion(l *cfg.Lock) string {
+	tags, err := cachedTags(cfg.DependencyFromLock(l), l.Version)
+	if err != nil || len(tags) == 0 {
+		return shortVersion(l.Version)
+	}
+	var best *semver.Version
+	for _, t := range tags {
+		if v, err := semver.NewVersion(t); err == nil && (best == nil || v.GreaterThan(best)) {
+			best = v
+		}
+	}
+	if best == nil {
+		return tags[0]
+	}
+	return best.Original()
+}
+
+// compareRev returns the revision to link to for a dependency: the tag it is
+// displayed with, or else the full revision.
+func compareRev(l *cfg.Lock, display string) string {
+	if display == shortVersion(l.Version) {
+		return l.Version
+	}
+	return display
+}
+
+// treeURL returns the page showing the files of a dependency at the version it
+// is displayed with, or an empty string when the host is not known.
+func treeURL(l *cfg.Lock, display string) string {
+	u, _ := hosting.TreeURL(remoteOf(l), compareRev(l, display))
+	return u
+}
+
+// link returns a Markdown link, or the text alone when there is no URL.
+func link(text, u string) string {
+	if u == "" {
+		return text
+	}
+	return "[" + text + "](" + strings.Replace(u, " ", "%20", -1) + ")"
+}
@@ -0,0 +1,60 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/cfg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+func TestPRBody(t *testing.T) {
+	home, err := ioutil.TempDir("", "glide-prbody")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer gpath.SetHome(gpath.Home())
+	gpath.SetHome(home)
+	cache.SetupReset()
+	defer cache.SetupReset()
+
+	conf := &cfg.Config{
+		Name: "example.com/app",
+		Imports: cfg.Dependencies{
+			{Name: "github.com/Masterminds/semver"},
+			{Name: "github.com/Masterminds/vcs"},
+		},
+	}
+	old := &cfg.Lockfile{
+		Imports: cfg.Locks{
+			{Name: "github.com/Masterminds/semver", Version: "1.3.0"},
+			{Name: "github.com/Masterminds/vcs", Version: "1.11.0"},
+		},
+	}
+	cur := &cfg.Lockfile{
+		Imports: cfg.Locks{
+			{Name: "github.com/Masterminds/semver", Version: "1.4.1"},
+			{Name: "gopkg.in/yaml.v2", Version: "a5b47d31c556af34a302ce5d659e6fea44d90de0"},
+		},
+	}
+
+	body := prBody(conf, old, cur)
+	for _, want := range []string{
+		"Updated 1, added 1, and removed 1 dependencies",
+		"| `github.com/Masterminds/semver` | 1.3.0 | 1.4.1 | [compare](https://github.com/Masterminds/semver/compare/1.3.0...1.4.1) |",
+		"| `github.com/Masterminds/vcs` | 1.11.0 | | removed |",
+		"| `gopkg.in/yaml.v2` (transitive) | | a5b47d31c5 | added |",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in the summary, got:\n%s", want, body)
+		}
+	}
+
+	if body := prBody(conf, old, old); !strings.Contains(body, "No dependencies changed.") {
+		t.Errorf("Expected no changes, got:\n%s", body)
+	}
+}
@@ -0,0 +1,194 @@
+package action
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// goMinVersion is the first release of Go using the vendor directory without
+// setting GO15VENDOREXPERIMENT.
+const goMinVersion = "1.6"
+
+// ProjectInfo describes a project and where Glide finds its parts.
+type ProjectInfo struct {
+	// Name is the name given in glide.yaml.
+	Name string `json:"name"`
+
+	// Module is the import path detected for the project: the module in a
+	// go.mod file next to glide.yaml, or else the path below the GOPATH the
+	// project is placed at. It is empty when neither is found.
+	Module string `json:"module,omitempty"`
+
+	Root   string `json:"root"`
+	Config string `json:"config"`
+	Lock   string `json:"lock"`
+
+	// HasLock is false when the lock file has not been written yet.
+	HasLock bool `json:"hasLock"`
+
+	VendorDir string `json:"vendorDir"`
+
+	// Gopath is the entry of GOPATH holding the project, empty when the
+	// project is outside of the GOPATH.
+	Gopath string `json:"gopath,omitempty"`
+
+	// GoVersion is the version of the Go toolchain, as in go1.8.3.
+	GoVersion string `json:"goVersion,omitempty"`
+
+	// GoMinVersion is the version of Go needed to build with the vendor
+	// directory. Go 1.5 does with GO15VENDOREXPERIMENT=1.
+	GoMinVersion string `json:"goMinVersion"`
+
+	// GoRange is the range of Go versions the project supports, as set with
+	// go in glide.yaml.
+	GoRange string `json:"goRange,omitempty"`
+
+	// GoVendor is true when the Go toolchain uses the vendor directory.
+	GoVendor bool `json:"goVendor"`
+}
+
+// Project prints information about the project, such as its paths and the Go
+// versions it needs, in one of the text, json, or json-pretty formats.
+func Project(format string) {
+	info := projectInfo()
+
+	switch format {
+	case textFormat:
+		w := tabwriter.NewWriter(msg.Default.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "Name:\t%s\n", info.Name)
+		fmt.Fprintf(w, "Module:\t%s\n", orNone(info.Module))
+		fmt.Fprintf(w, "Root:\t%s\n", info.Root)
+		fmt.Fprintf(w, "Config:\t%s\n", info.Config)
+		lock := info.Lock
+		if !info.HasLock {
+			lock += " (missing)"
+		}
+		fmt.Fprintf(w, "Lock:\t%s\n", lock)
+		fmt.Fprintf(w, "Vendor:\t%s\n", info.VendorDir)
+		fmt.Fprintf(w, "GOPATH:\t%s\n", orNone(info.Gopath))
+		fmt.Fprintf(w, "Go version:\t%s\n", orNone(info.GoVersion))
+		fmt.Fprintf(w, "Go needed:\t>= %s (1.5 with GO15VENDOREXPERIMENT=1)\n", info.GoMinVersion)
+		fmt.Fprintf(w, "Go supported:\t%s\n", orNone(info.GoRange))
+		fmt.Fprintf(w, "Go uses vendor:\t%t\n", info.GoVendor)
+		w.Flush()
+	case jsonFormat:
+		json.NewEncoder(msg.Default.Stdout).Encode(info)
+	case jsonPrettyFormat:
+		b, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			msg.Die("could not marshal the project information: %s", err)
+		}
+		msg.Print(string(b) + "\n")
+	default:
+		msg.Die("invalid output format: must be one of: json|json-pretty|text")
+	}
+}
+
+// Module prints the import path detected for the project. See
+// ProjectInfo.Module.
+func Module() {
+	info := projectInfo()
+	if info.Module == "" {
+		msg.Die("Unable to detect the import path of %s. It is outside of the GOPATH and has no go.mod file", info.Root)
+	}
+	msg.Puts("%s", info.Module)
+}
+
+func projectInfo() *ProjectInfo {
+	conf := EnsureConfig()
+	yamlpath, err := gpath.Glide()
+	if err != nil {
+		msg.Die("Failed to find %s file in directory tree: %s", gpath.GlideFile, err)
+	}
+	root := filepath.Dir(yamlpath)
+	vpath, err := gpath.Vendor()
+	if err != nil {
+		msg.Die("Could not find the vendor directory: %s", err)
+	}
+
+	info := &ProjectInfo{
+		Name:         conf.Name,
+		Root:         root,
+		Config:       yamlpath,
+		Lock:         filepath.Join(root, gpath.LockFile),
+		HasLock:      gpath.HasLock(root),
+		VendorDir:    vpath,
+		GoMinVersion: goMinVersion,
+		GoRange:      conf.Go,
+	}
+	var pkg string
+	info.Gopath, pkg = gopathPlacement(root, gpath.Gopaths())
+	if info.Module = goModule(root); info.Module == "" {
+		info.Module = pkg
+	}
+
+	if out, err := exec.Command(goExecutable(), "version").Output(); err == nil {
+		if f := strings.Fields(string(out)); len(f) > 2 {
+			info.GoVersion = f[2]
+		}
+		info.GoVendor = goVersionProblems(string(out), os.Getenv("GO15VENDOREXPERIMENT")) == nil
+	}
+	return info
+}
+
+// gopathPlacement returns the entry of gopaths whose src directory holds dir,
+// along with the import path of dir below it. Both are empty when dir is
+// outside of every entry.
+func gopathPlacement(dir string, gopaths []string) (string, string) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", ""
+	}
+	if r, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = r
+	}
+	for _, gp := range gopaths {
+		src, err := filepath.EvalSymlinks(filepath.Join(gp, "src"))
+		if err == nil && strings.HasPrefix(abs, src+string(os.PathSeparator)) {
+			return gp, filepath.ToSlash(abs[len(src)+1:])
+		}
+	}
+	return "", ""
+}
+
+// goModule returns the module path in the go.mod file in dir, if any.
+func goModule(dir string) string {
+	f, err := os.Open(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if !strings.HasPrefix(line, "module") {
+			continue
+		}
+		m := strings.TrimSpace(strings.TrimPrefix(line, "module"))
+		if i := strings.Index(m, "//"); i >= 0 {
+			m = strings.TrimSpace(m[:i])
+		}
+		if u, err := strconv.Unquote(m); err == nil {
+			m = u
+		}
+		return m
+	}
+	return ""
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
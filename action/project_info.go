@@ -2,13 +2,29 @@ package action
 
 import (
 	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
 
+	"github.com/Masterminds/glide/cfg"
 	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
 )
 
 // Info prints information about a project based on a passed in format.
+//
+// A format containing "{{" is a Go text/template executed with an InfoData,
+// which includes the dependencies pinned in the lock file. Otherwise the %
+// variables in the format are replaced.
 func Info(format string) {
 	conf := EnsureConfig()
+	if strings.Contains(format, "{{") {
+		infoTemplate(format, conf)
+		return
+	}
+
 	var buffer bytes.Buffer
 	varInit := false
 	for _, varfmt := range format {
@@ -38,3 +54,114 @@ func Info(format string) {
 	}
 	msg.Puts(buffer.String())
 }
+
+// InfoFile prints information about a project using the template in a file.
+func InfoFile(file string) {
+	tpl, err := ioutil.ReadFile(file)
+	if err != nil {
+		msg.Die("Unable to read template: %s", err)
+	}
+	infoTemplate(string(tpl), EnsureConfig())
+}
+
+// InfoData is the data info templates are executed with.
+type InfoData struct {
+	Name        string
+	Description string
+	Home        string
+	License     string
+
+	// Hash and Updated come from the lock file and are empty without one.
+	Hash    string
+	Updated time.Time
+
+	// Imports and DevImports are the dependencies pinned in the lock file.
+	// Dependencies holds both.
+	Imports      []*InfoDependency
+	DevImports   []*InfoDependency
+	Dependencies []*InfoDependency
+}
+
+// InfoDependency is a dependency pinned in the lock file. The fields of the
+// lock, such as Version and VcsType, are available along with these.
+type InfoDependency struct {
+	*cfg.Lock
+
+	// Remote is the location the dependency is fetched from.
+	Remote string
+
+	// Constraint is the version listed in glide.yaml, if any.
+	Constraint string
+
+	// Test is true for dependencies only needed by tests.
+	Test bool
+}
+
+var infoFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"short": shortVersion,
+}
+
+func infoTemplate(format string, conf *cfg.Config) {
+	tpl, err := template.New("info").Funcs(infoFuncs).Parse(format)
+	if err != nil {
+		msg.Die("Invalid template: %s", err)
+	}
+
+	var lock *cfg.Lockfile
+	yamlpath, err := gpath.Glide()
+	base := filepath.Dir(yamlpath)
+	if err == nil && gpath.HasLock(base) {
+		lock, err = cfg.ReadLockFile(filepath.Join(base, gpath.LockFile))
+		if err != nil {
+			msg.Die("Could not load lockfile: %s", err)
+		}
+	} else {
+		msg.Warn("No lock file found. Dependency information is not available")
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, infoData(conf, lock)); err != nil {
+		msg.Die("Unable to execute template: %s", err)
+	}
+	msg.Print(buf.String())
+	if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		msg.Print("\n")
+	}
+}
+
+// infoData collects the data for info templates. The lock may be nil.
+func infoData(conf *cfg.Config, lock *cfg.Lockfile) *InfoData {
+	d := &InfoData{
+		Name:        conf.Name,
+		Description: conf.Description,
+		Home:        conf.Home,
+		License:     conf.License,
+	}
+	if lock == nil {
+		return d
+	}
+
+	d.Hash = lock.Hash
+	d.Updated = lock.Updated
+	dep := func(l *cfg.Lock, test bool) *InfoDependency {
+		i := &InfoDependency{Lock: l, Remote: l.Repository, Test: test}
+		if i.Remote == "" {
+			i.Remote = "https://" + l.Name
+		}
+		if c := conf.Imports.Get(l.Name); c != nil {
+			i.Constraint = c.Reference
+		} else if c := conf.DevImports.Get(l.Name); c != nil {
+			i.Constraint = c.Reference
+		}
+		return i
+	}
+	for _, l := range lock.Imports {
+		d.Imports = append(d.Imports, dep(l, false))
+	}
+	for _, l := range lock.DevImports {
+		d.DevImports = append(d.DevImports, dep(l, true))
+	}
+	d.Dependencies = append(append(d.Dependencies, d.Imports...), d.DevImports...)
+	return d
+}
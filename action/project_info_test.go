@@ -0,0 +1,48 @@
+package action
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/glide/cfg"
+)
+
+func TestInfoTemplate(t *testing.T) {
+	conf := &cfg.Config{
+		Name:    "example.com/foo",
+		License: "MIT",
+		Imports: cfg.Dependencies{&cfg.Dependency{Name: "example.com/a", Reference: "^1.0.0"}},
+	}
+	lock := &cfg.Lockfile{
+		Hash:    "abc",
+		Updated: time.Date(2016, 8, 1, 0, 0, 0, 0, time.UTC),
+		Imports: cfg.Locks{
+			{Name: "example.com/a", Version: "0123456789abcdef0123456789abcdef01234567", VcsType: "git"},
+			{Name: "example.com/b", Version: "1.2.0", Repository: "https://mirror.example.com/b", Subpackages: []string{"x", "y"}},
+		},
+		DevImports: cfg.Locks{{Name: "example.com/t", Version: "2.0.0"}},
+	}
+
+	tpl := template.Must(template.New("info").Funcs(infoFuncs).Parse(
+		`{{.Name}} {{.License}} {{.Updated.Format "2006-01-02"}}
+{{range .Dependencies}}{{.Name}} {{short .Version}} {{.Remote}} {{.Constraint}} {{join .Subpackages ","}} {{.Test}}
+{{end}}`))
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, infoData(conf, lock)); err != nil {
+		t.Fatal(err)
+	}
+	want := `example.com/foo MIT 2016-08-01
+example.com/a 0123456789 https://example.com/a ^1.0.0  false
+example.com/b 1.2.0 https://mirror.example.com/b  x,y false
+example.com/t 2.0.0 https://example.com/t   true
+`
+	if buf.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, buf.String())
+	}
+
+	if d := infoData(conf, nil); d.Name != "example.com/foo" || len(d.Dependencies) != 0 {
+		t.Errorf("Expected only project data without a lock file, got %v", d)
+	}
+}
@@ -0,0 +1,47 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGopathPlacement(t *testing.T) {
+	gp, err := ioutil.TempDir("", "glide-project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(gp)
+	dir := filepath.Join(gp, "src", "example.com", "app")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, pkg := gopathPlacement(dir, []string{"/nonexistent", gp})
+	if entry != gp || pkg != "example.com/app" {
+		t.Errorf("Expected %s and example.com/app, got %q and %q", gp, entry, pkg)
+	}
+	if entry, pkg := gopathPlacement(gp, []string{gp}); entry != "" || pkg != "" {
+		t.Errorf("Expected nothing for a directory outside of src, got %q and %q", entry, pkg)
+	}
+}
+
+func TestGoModule(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if m := goModule(dir); m != "" {
+		t.Errorf("Expected no module without go.mod, got %q", m)
+	}
+	mod := "// The app.\nmodule \"example.com/app\" // moved\n\nrequire example.com/dep v1.0.0\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte(mod), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if m := goModule(dir); m != "example.com/app" {
+		t.Errorf("Expected example.com/app, got %q", m)
+	}
+}
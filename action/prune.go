@@ -0,0 +1,81 @@
+package action
+
+import (
+	"container/list"
+	"go/build"
+	"os"
+
+	"github.com/Masterminds/glide/dependency"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// Prune removes packages from the vendor directory that are not reachable
+// from the imports of the project, including its tests. When nonGo is true
+// files that are not Go source, other than legal files, are removed too.
+func Prune(basedir string, nonGo bool) {
+	conf := EnsureConfig()
+
+	r, err := dependency.NewResolver(basedir)
+	if err != nil {
+		msg.Die("Could not create a resolver: %s", err)
+	}
+	r.Config = conf
+	r.ResolveTest = true
+
+	msg.Info("Finding the vendored packages in use...")
+	used, err := usedVendorPackages(r)
+	if err != nil {
+		msg.Die("Unable to scan the vendored packages: %s", err)
+	}
+
+	msg.Info("Pruning unused packages from %s", r.VendorDir)
+	count, err := gpath.PruneVendor(r.VendorDir, used, nonGo)
+	if err != nil {
+		msg.Die("Unable to prune the vendor directory: %s", err)
+	}
+	msg.Info("Pruned %d unused packages", count)
+}
+
+// usedVendorPackages walks the import graph of the project and returns the
+// vendored packages it reaches. The keys are absolute package paths.
+func usedVendorPackages(r *dependency.Resolver) (map[string]bool, error) {
+	imps, timps, err := r.ResolveLocal(false)
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]bool)
+	queue := list.New()
+	for _, p := range append(imps, timps...) {
+		queue.PushBack(p)
+	}
+
+	for e := queue.Front(); e != nil; e = e.Next() {
+		p := e.Value.(string)
+		if used[p] {
+			continue
+		}
+		if _, err := os.Stat(p); err != nil {
+			msg.Warn("Package %s is not in the vendor directory", r.Stripv(p))
+			continue
+		}
+		used[p] = true
+
+		pkg, err := dependency.ScanImports(r.BuildContext, p)
+		if err != nil {
+			if _, ok := err.(*build.NoGoError); !ok {
+				msg.Warn("Unable to scan %s: %s", r.Stripv(p), err)
+			}
+			continue
+		}
+
+		for _, imp := range pkg.Imports {
+			if info := r.FindPkg(imp); info.Loc == dependency.LocVendor && !used[info.Path] {
+				queue.PushBack(info.Path)
+			}
+		}
+	}
+
+	return used, nil
+}
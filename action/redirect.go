@@ -0,0 +1,93 @@
+package action
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/glide/repo"
+)
+
+// recordRedirects writes the repos dependencies moved to, once the moves were
+// accepted, to glide.yaml and to the lock file in base, and sets them in conf.
+// glide.yaml is read again so only the values set in it are written. The hash
+// of the lock file is updated along with glide.yaml when it was current.
+func recordRedirects(base string, conf *cfg.Config) {
+	moved := repo.Redirects()
+	if len(moved) == 0 {
+		return
+	}
+	redirectDeps(append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...), moved)
+
+	yamlpath, err := gpath.Glide()
+	if err != nil {
+		msg.Die("Could not find Glide file: %s", err)
+	}
+	yml, err := ioutil.ReadFile(yamlpath)
+	if err != nil {
+		msg.Die("Failed to load %s: %s", yamlpath, err)
+	}
+	local, err := cfg.ConfigFromYaml(yml)
+	if err != nil {
+		msg.Die("Failed to parse %s: %s", yamlpath, err)
+	}
+	oldHash, err := local.Hash()
+	if err != nil {
+		msg.Die("Failed to generate config hash: %s", err)
+	}
+	if redirectDeps(append(append(cfg.Dependencies{}, local.Imports...), local.DevImports...), moved) {
+		if err := local.WriteFile(yamlpath); err != nil {
+			msg.Die("Could not write the moved repositories to %s: %s", gpath.GlideFile, err)
+		}
+		msg.Info("Recorded the moved repositories in %s", gpath.GlideFile)
+	}
+
+	if !gpath.HasLock(base) {
+		return
+	}
+	lockpath := filepath.Join(base, gpath.LockFile)
+	lock, err := cfg.ReadLockFile(lockpath)
+	if err != nil {
+		msg.Die("Could not load lockfile: %s", err)
+	}
+	changed := redirectLocks(append(append(cfg.Locks{}, lock.Imports...), lock.DevImports...), moved)
+	if hash, err := local.Hash(); err == nil && lock.Hash == oldHash && hash != oldHash {
+		lock.Hash = hash
+		changed = true
+	}
+	if !changed {
+		return
+	}
+	if err := lock.WriteFile(lockpath); err != nil {
+		msg.Die("Could not write the moved repositories to %s: %s", gpath.LockFile, err)
+	}
+	msg.Info("Recorded the moved repositories in %s", gpath.LockFile)
+}
+
+// redirectDeps sets the repository of the dependencies that moved. It returns
+// true when one changed.
+func redirectDeps(deps cfg.Dependencies, moved map[string]string) bool {
+	changed := false
+	for _, d := range deps {
+		if r, ok := moved[d.Name]; ok && d.Repository != r {
+			d.Repository = r
+			changed = true
+		}
+	}
+	return changed
+}
+
+// redirectLocks sets the repository of the locks of dependencies that moved.
+// It returns true when one changed.
+func redirectLocks(locks cfg.Locks, moved map[string]string) bool {
+	changed := false
+	for _, l := range locks {
+		if r, ok := moved[l.Name]; ok && l.Repository != r {
+			l.Repository = r
+			changed = true
+		}
+	}
+	return changed
+}
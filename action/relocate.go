@@ -0,0 +1,299 @@
+package action
+
+import (
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"gopkg.in/yaml.v2"
+)
+
+// relocateFile records the imports rewritten in the project so the changes can
+// be reverted. It is stored next to the glide.yaml file.
+const relocateFile = ".glide-relocate.yaml"
+
+// relocation is the content of the relocateFile.
+type relocation struct {
+	// Files maps the files of the project, relative to the project root, to
+	// the imports rewritten in them. Keys are the new import paths and values
+	// the original ones.
+	Files map[string]map[string]string `yaml:"files"`
+}
+
+// relocateRule rewrites imports of From, and the packages below it, to To.
+type relocateRule struct {
+	From, To string
+}
+
+// VendorRelocate rewrites the import paths listed under relocate for each
+// dependency to the name of that dependency. This is useful for forks whose
+// code still imports the upstream location.
+//
+// The vendored packages are rewritten along with the project. Changes to the
+// project are recorded so VendorRestore can revert them. While the record
+// exists, install, update, and get rewrite the vendored packages again.
+func VendorRelocate() {
+	conf := EnsureValidConfig()
+	rules := relocateRules(conf)
+	if len(rules) == 0 {
+		msg.Info("No dependencies list import paths to relocate")
+		return
+	}
+
+	vpath, err := gpath.Vendor()
+	if err != nil {
+		msg.Die("Could not get vendor path: %s", err)
+	}
+	n, err := relocateTree(vpath, "", rules, nil)
+	if err != nil {
+		msg.Die("Unable to rewrite vendored packages: %s", err)
+	}
+	msg.Info("Rewrote imports in %d vendored files", n)
+
+	base := gpath.Basepath()
+	rec, err := readRelocation(base)
+	if err != nil {
+		msg.Die("Unable to read %s: %s", relocateFile, err)
+	}
+	n, err = relocateTree(base, vpath, rules, func(f string, changed map[string]string) {
+		rel, err := filepath.Rel(base, f)
+		if err != nil {
+			rel = f
+		}
+		rel = filepath.ToSlash(rel)
+		if rec.Files[rel] == nil {
+			rec.Files[rel] = make(map[string]string)
+		}
+		for to, from := range changed {
+			// Keep the original path when a file is relocated more than once.
+			if orig, ok := rec.Files[rel][from]; ok {
+				delete(rec.Files[rel], from)
+				from = orig
+			}
+			rec.Files[rel][to] = from
+		}
+	})
+	if werr := writeRelocation(base, rec); werr != nil {
+		msg.Err("Unable to record the relocated imports: %s", werr)
+	}
+	if err != nil {
+		msg.Die("Unable to rewrite the project: %s", err)
+	}
+	msg.Info("Rewrote imports in %d project files. Use 'glide vendor --restore' to revert them", n)
+}
+
+// VendorRestore reverts the imports rewritten in the project by
+// VendorRelocate. The vendored packages are restored by installing them again.
+func VendorRestore() {
+	EnsureConfig()
+	base := gpath.Basepath()
+	rec, err := readRelocation(base)
+	if err != nil {
+		msg.Die("Unable to read %s: %s", relocateFile, err)
+	}
+	if len(rec.Files) == 0 {
+		msg.Info("No relocated imports to restore")
+		return
+	}
+
+	for rel, imps := range rec.Files {
+		f := filepath.Join(base, filepath.FromSlash(rel))
+		_, err := rewriteImports(f, func(p string) (string, bool) {
+			orig, ok := imps[p]
+			return orig, ok
+		})
+		if os.IsNotExist(err) {
+			msg.Warn("%s no longer exists", rel)
+		} else if err != nil {
+			msg.Die("Unable to restore %s: %s", rel, err)
+		}
+	}
+
+	if err := os.Remove(filepath.Join(base, relocateFile)); err != nil {
+		msg.Die("Unable to remove %s: %s", relocateFile, err)
+	}
+	msg.Info("Restored imports in %d project files. Run 'glide install' to restore the vendored packages", len(rec.Files))
+}
+
+// relocateVendored rewrites the vendored packages again after they were
+// exported when the project was relocated.
+func relocateVendored(conf *cfg.Config) {
+	if _, err := os.Stat(filepath.Join(gpath.Basepath(), relocateFile)); err != nil {
+		return
+	}
+	rules := relocateRules(conf)
+	if len(rules) == 0 {
+		return
+	}
+	vpath, err := gpath.Vendor()
+	if err != nil {
+		return
+	}
+	msg.Info("Relocating imports in vendored packages")
+	if _, err := relocateTree(vpath, "", rules, nil); err != nil {
+		msg.Err("Unable to rewrite vendored packages: %s", err)
+	}
+}
+
+// relocateRules returns the rewrites listed in conf, longest paths first so
+// the most specific rule wins.
+func relocateRules(conf *cfg.Config) []relocateRule {
+	var rules []relocateRule
+	for _, deps := range []cfg.Dependencies{conf.Imports, conf.DevImports} {
+		for _, d := range deps {
+			for _, r := range d.Relocate {
+				rules = append(rules, relocateRule{From: strings.TrimSuffix(r, "/"), To: d.Name})
+			}
+		}
+	}
+	sort.Sort(byRuleLength(rules))
+	return rules
+}
+
+type byRuleLength []relocateRule
+
+func (b byRuleLength) Len() int           { return len(b) }
+func (b byRuleLength) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byRuleLength) Less(i, j int) bool { return len(b[i].From) > len(b[j].From) }
+
+// relocatePath returns the import path p rewritten by the first matching rule.
+// Paths already below the new location are left alone so rewriting is safe to
+// repeat.
+func relocatePath(p string, rules []relocateRule) (string, bool) {
+	for _, r := range rules {
+		if p == r.To || strings.HasPrefix(p, r.To+"/") {
+			return "", false
+		}
+		if p == r.From || strings.HasPrefix(p, r.From+"/") {
+			return r.To + p[len(r.From):], true
+		}
+	}
+	return "", false
+}
+
+// relocateTree rewrites the imports of the Go files below dir with rules. See
+// walkGoFiles for the files rewritten.
+func relocateTree(dir, skip string, rules []relocateRule, done func(string, map[string]string)) (int, error) {
+	return walkGoFiles(dir, skip, func(imp string) (string, bool) {
+		return relocatePath(imp, rules)
+	}, done)
+}
+
+// walkGoFiles rewrites the imports of the Go files below dir using fn, skipping
+// the skip directory along with hidden and testdata directories. When set,
+// done is called with each changed file and its rewritten imports. The number
+// of changed files is returned.
+func walkGoFiles(dir, skip string, fn func(string) (string, bool), done func(string, map[string]string)) (int, error) {
+	count := 0
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			n := fi.Name()
+			if p != dir && (p == skip || n == "testdata" || strings.HasPrefix(n, ".") || strings.HasPrefix(n, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !fi.Mode().IsRegular() || !strings.HasSuffix(p, ".go") {
+			return nil
+		}
+
+		changed, err := rewriteImports(p, fn)
+		if err != nil {
+			return err
+		}
+		if len(changed) > 0 {
+			count++
+			if done != nil {
+				done(p, changed)
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+// rewriteImports rewrites the imports of a Go file using fn. Only the import
+// paths are changed so the rest of the file is left exactly as it was. The
+// rewritten imports are returned, mapping the new paths to the old ones.
+// Files that cannot be parsed are skipped. Changed files are replaced rather
+// than written to, as they may be hardlinks to the cache.
+func rewriteImports(f string, fn func(string) (string, bool)) (map[string]string, error) {
+	src, err := ioutil.ReadFile(f)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, f, src, parser.ImportsOnly)
+	if err != nil {
+		msg.Debug("Skipping %s: %s", f, err)
+		return nil, nil
+	}
+
+	changed := make(map[string]string)
+	var out []byte
+	last := 0
+	for _, imp := range file.Imports {
+		p, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		np, ok := fn(p)
+		if !ok || np == p {
+			continue
+		}
+		start := fset.Position(imp.Path.Pos()).Offset
+		end := fset.Position(imp.Path.End()).Offset
+		out = append(out, src[last:start]...)
+		out = append(out, strconv.Quote(np)...)
+		last = end
+		changed[np] = p
+	}
+	if len(changed) == 0 {
+		return changed, nil
+	}
+	out = append(out, src[last:]...)
+
+	fi, err := os.Stat(f)
+	if err != nil {
+		return nil, err
+	}
+	return changed, gpath.ReplaceFile(f, out, fi.Mode())
+}
+
+func readRelocation(base string) (*relocation, error) {
+	rec := &relocation{}
+	yml, err := ioutil.ReadFile(filepath.Join(base, relocateFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	} else if err == nil {
+		if err := yaml.Unmarshal(yml, rec); err != nil {
+			return nil, err
+		}
+	}
+	if rec.Files == nil {
+		rec.Files = make(map[string]map[string]string)
+	}
+	return rec, nil
+}
+
+func writeRelocation(base string, rec *relocation) error {
+	if len(rec.Files) == 0 {
+		return nil
+	}
+	yml, err := yaml.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(base, relocateFile), yml, 0644)
+}
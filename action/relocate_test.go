@@ -0,0 +1,99 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/glide/cfg"
+)
+
+func TestRelocatePath(t *testing.T) {
+	conf := &cfg.Config{
+		Imports: cfg.Dependencies{
+			&cfg.Dependency{Name: "github.com/example/fork", Relocate: []string{"github.com/upstream/project"}},
+			&cfg.Dependency{Name: "github.com/example/sub", Relocate: []string{"github.com/upstream/project/sub/"}},
+		},
+	}
+	rules := relocateRules(conf)
+
+	tests := map[string]string{
+		"github.com/upstream/project":         "github.com/example/fork",
+		"github.com/upstream/project/foo":     "github.com/example/fork/foo",
+		"github.com/upstream/project/sub/bar": "github.com/example/sub/bar",
+		"github.com/upstream/projects":        "",
+		"github.com/example/fork/foo":         "",
+		"fmt":                                 "",
+	}
+	for in, want := range tests {
+		got, ok := relocatePath(in, rules)
+		if ok != (want != "") || got != want {
+			t.Errorf("relocatePath(%s) = %q, %t, want %q", in, got, ok, want)
+		}
+	}
+}
+
+func TestRewriteImports(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-relocate-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := `package main
+
+import (
+	"fmt"
+
+	p "github.com/upstream/project/foo" // the upstream
+)
+
+// "github.com/upstream/project" is not an import.
+func main() { fmt.Println(p.Foo) }
+`
+	f := filepath.Join(dir, "main.go")
+	if err := ioutil.WriteFile(f, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	checkCached := linkCached(t, f)
+
+	rules := []relocateRule{{From: "github.com/upstream/project", To: "github.com/example/fork"}}
+	changed, err := rewriteImports(f, func(p string) (string, bool) {
+		return relocatePath(p, rules)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 1 || changed["github.com/example/fork/foo"] != "github.com/upstream/project/foo" {
+		t.Errorf("Unexpected rewritten imports %v", changed)
+	}
+	b, _ := ioutil.ReadFile(f)
+	want := `package main
+
+import (
+	"fmt"
+
+	p "github.com/example/fork/foo" // the upstream
+)
+
+// "github.com/upstream/project" is not an import.
+func main() { fmt.Println(p.Foo) }
+`
+	if string(b) != want {
+		t.Errorf("Unexpected rewritten file:\n%s", b)
+	}
+	checkCached(src)
+
+	// Reverting the recorded imports restores the original file.
+	_, err = rewriteImports(f, func(p string) (string, bool) {
+		o, ok := changed[p]
+		return o, ok
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(f); string(b) != src {
+		t.Errorf("Expected the original file after restoring, got:\n%s", b)
+	}
+}
@@ -1,20 +1,30 @@
 package action
 
 import (
-	"github.com/Masterminds/glide/cache"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
 	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/dependency"
 	"github.com/Masterminds/glide/msg"
 	gpath "github.com/Masterminds/glide/path"
 	"github.com/Masterminds/glide/repo"
 )
 
 // Remove removes a dependncy from the configuration.
-func Remove(packages []string, inst *repo.Installer) {
-	cache.SystemLock()
+//
+// When pruneTransitive is true the lock file is kept instead of being
+// regenerated. The removed packages, and the transitive dependencies nothing
+// else needs anymore, are dropped from it and deleted from vendor/.
+func Remove(packages []string, inst *repo.Installer, pruneTransitive bool) {
+	EnsureProjectLock()
+	EnsureCacheLock()
 	base := gpath.Basepath()
 	EnsureGopath()
 	EnsureVendorDir()
-	conf := EnsureConfig()
+	conf := EnsureValidConfig()
 	glidefile, err := gpath.Glide()
 	if err != nil {
 		msg.Die("Could not find Glide file: %s", err)
@@ -24,6 +34,14 @@ func Remove(packages []string, inst *repo.Installer) {
 	conf.Imports = rmDeps(packages, conf.Imports)
 	conf.DevImports = rmDeps(packages, conf.DevImports)
 
+	if pruneTransitive {
+		pruneOrphans(conf, packages, base)
+		if err := conf.WriteFile(glidefile); err != nil {
+			msg.Die("Failed to write glide YAML file: %s", err)
+		}
+		return
+	}
+
 	// Copy used to generate locks.
 	confcopy := conf.Clone()
 
@@ -33,6 +51,11 @@ func Remove(packages []string, inst *repo.Installer) {
 		msg.Err("Failed to set references: %s", err)
 	}
 
+	// Patches are applied before the new vendor directory replaces the
+	// existing one, which is kept when they fail.
+	inst.Stage = func(vp string) error {
+		return applyPatches(conf, confcopy, vp)
+	}
 	err = inst.Export(confcopy)
 	if err != nil {
 		msg.Die("Unable to export dependencies to vendor directory: %s", err)
@@ -66,3 +89,128 @@ func rmDeps(pkgs []string, deps []*cfg.Dependency) []*cfg.Dependency {
 	}
 	return res
 }
+
+// pruneOrphans removes the locked dependencies conf no longer needs from the
+// lock file and the vendor/ directory. A dependency is needed when it is listed
+// in conf or imported by the project or another needed dependency.
+func pruneOrphans(conf *cfg.Config, removed []string, base string) {
+	if !gpath.HasLock(base) {
+		msg.Die("Pruning transitive dependencies requires a lock file (%s)", gpath.LockFile)
+	}
+	lock, err := cfg.ReadLockFile(filepath.Join(base, gpath.LockFile))
+	if err != nil {
+		msg.Die("Could not load lockfile: %s", err)
+	}
+	vpath, err := gpath.Vendor()
+	if err != nil {
+		msg.Die("Could not get vendor path: %s", err)
+	}
+	// The imports of a dependency missing from vendor/ are unknown, so what
+	// only it imports would be taken for an orphan.
+	if missing := missingVendored(vpath, lock); len(missing) > 0 {
+		for _, m := range missing {
+			msg.Err("%s is locked but not in %s", m, gpath.VendorDir)
+		}
+		msg.Die("Pruning transitive dependencies requires every locked dependency in %s. Run glide install first", gpath.VendorDir)
+	}
+	g, err := dependency.NewGraph(base, conf, lock)
+	if err != nil {
+		msg.Die("Unable to build the dependency graph: %s", err)
+	}
+
+	roots := []string{conf.Name}
+	for _, d := range append(conf.Imports.Clone(), conf.DevImports...) {
+		roots = append(roots, d.Name)
+	}
+	needed := reachable(g.Edges, roots)
+	for _, r := range removed {
+		if needed[r] {
+			msg.Warn("%s is still imported so it is kept in %s as a transitive dependency", r, gpath.LockFile)
+		}
+	}
+
+	var orphans []string
+	prune := func(locks cfg.Locks) cfg.Locks {
+		var keep cfg.Locks
+		for _, l := range locks {
+			if needed[l.Name] {
+				keep = append(keep, l)
+			} else {
+				orphans = append(orphans, l.Name)
+			}
+		}
+		return keep
+	}
+	lock.Imports = prune(lock.Imports)
+	lock.DevImports = prune(lock.DevImports)
+	sort.Strings(orphans)
+
+	for _, o := range orphans {
+		msg.Info("--> Removing %s", o)
+		p := filepath.Join(vpath, filepath.FromSlash(o))
+		if err := os.RemoveAll(p); err != nil {
+			msg.Err("Unable to remove %s: %s", p, err)
+			continue
+		}
+		removeEmptyParents(filepath.Dir(p), vpath)
+	}
+
+	hash, err := conf.Hash()
+	if err != nil {
+		msg.Die("Failed to generate config hash. Unable to generate lock file.")
+	}
+	lock.Hash = hash
+	lock.Updated = time.Now()
+	if err := lock.WriteFile(filepath.Join(base, gpath.LockFile)); err != nil {
+		msg.Die("Failed to write glide lock file: %s", err)
+	}
+	warnStaleSignature(base)
+	msg.Info("Removed %d dependencies no longer needed", len(orphans))
+}
+
+// missingVendored returns the dependencies in lock that are not in the vendor
+// directory at vpath.
+func missingVendored(vpath string, lock *cfg.Lockfile) []string {
+	var missing []string
+	for _, l := range append(append(cfg.Locks{}, lock.Imports...), lock.DevImports...) {
+		if _, err := os.Stat(filepath.Join(vpath, filepath.FromSlash(l.Name))); err != nil {
+			missing = append(missing, l.Name)
+		}
+	}
+	return missing
+}
+
+// reachable returns the projects that can be reached from the roots by
+// following the edges of a dependency graph. The roots are included.
+func reachable(edges []*dependency.GraphEdge, roots []string) map[string]bool {
+	next := make(map[string][]string)
+	for _, e := range edges {
+		next[e.From] = append(next[e.From], e.To)
+	}
+	found := make(map[string]bool)
+	queue := append([]string{}, roots...)
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if found[n] {
+			continue
+		}
+		found[n] = true
+		queue = append(queue, next[n]...)
+	}
+	return found
+}
+
+// removeEmptyParents removes dir, and its parents below stop, while they are
+// empty.
+func removeEmptyParents(dir, stop string) {
+	for dir != stop && len(dir) > len(stop) {
+		if empty, err := gpath.IsDirectoryEmpty(dir); err != nil || !empty {
+			return
+		}
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
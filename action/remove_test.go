@@ -0,0 +1,55 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/dependency"
+)
+
+func TestMissingVendored(t *testing.T) {
+	vpath, err := ioutil.TempDir("", "glide-missing-vendored")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(vpath)
+	if err := os.MkdirAll(filepath.Join(vpath, "example.com", "a"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	lock := &cfg.Lockfile{
+		Imports:    cfg.Locks{{Name: "example.com/a"}, {Name: "example.com/b"}},
+		DevImports: cfg.Locks{{Name: "example.com/c"}},
+	}
+	missing := missingVendored(vpath, lock)
+	expected := []string{"example.com/b", "example.com/c"}
+	if !reflect.DeepEqual(missing, expected) {
+		t.Errorf("Expected %v to be missing, got %v", expected, missing)
+	}
+}
+
+func TestReachable(t *testing.T) {
+	edges := []*dependency.GraphEdge{
+		{From: "example.com/app", To: "example.com/a"},
+		{From: "example.com/a", To: "example.com/b"},
+		{From: "example.com/removed", To: "example.com/c"},
+		{From: "example.com/removed", To: "example.com/b"},
+		{From: "example.com/c", To: "example.com/d"},
+	}
+	found := reachable(edges, []string{"example.com/app", "example.com/listed"})
+
+	var names []string
+	for n := range found {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	expected := []string{"example.com/a", "example.com/app", "example.com/b", "example.com/listed"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected %v to be reachable, got %v", expected, names)
+	}
+}
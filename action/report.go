@@ -0,0 +1,191 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/hosting"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/semver"
+)
+
+// UnmaintainedAfter is how long a repo can go without activity before the
+// report calls it unmaintained.
+const UnmaintainedAfter = 365 * 24 * time.Hour
+
+// The maintenance statuses of a dependency in the report.
+const (
+	statusActive       = "active"
+	statusUnmaintained = "unmaintained"
+	statusArchived     = "archived"
+	statusUnknown      = "unknown"
+)
+
+// ReportEntry is the freshness of a locked dependency, as listed by Report.
+type ReportEntry struct {
+	Name    string     `json:"name"`
+	Version string     `json:"version"`
+	Pinned  *time.Time `json:"pinned,omitempty"`
+
+	// Latest is the newest release upstream, which is its newest semantic
+	// version tag.
+	Latest   string     `json:"latest,omitempty"`
+	Released *time.Time `json:"released,omitempty"`
+
+	LastActivity *time.Time `json:"lastActivity,omitempty"`
+	Status       string     `json:"status"`
+}
+
+// Report lists how fresh the locked dependencies are: how old the pinned
+// commit is, the newest release upstream and how old it is, and whether the
+// repo is archived or appears unmaintained. Archived status and activity come
+// from the APIs of GitHub, GitLab, and Bitbucket, so they are unknown for
+// other hosts.
+//
+// Params:
+//  - basedir (string): the project directory
+//  - format (string): The format to output (text, json, json-pretty)
+func Report(basedir, format string) {
+	if !gpath.HasLock(basedir) {
+		msg.Die("A lock file (%s) is required for the report. Please run 'glide up'", gpath.LockFile)
+	}
+	lock, err := cfg.ReadLockFile(filepath.Join(basedir, gpath.LockFile))
+	if err != nil {
+		msg.Die("Could not load lockfile: %s", err)
+	}
+
+	var entries []*ReportEntry
+	for _, l := range append(append(cfg.Locks{}, lock.Imports...), lock.DevImports...) {
+		entries = append(entries, reportEntry(cfg.DependencyFromLock(l), l.Version, time.Now()))
+	}
+
+	switch format {
+	case textFormat:
+		now := time.Now()
+		w := tabwriter.NewWriter(msg.Default.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tVERSION\tPINNED\tLATEST\tRELEASED\tSTATUS")
+		for _, e := range entries {
+			latest := e.Latest
+			if latest == "" {
+				latest = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Name, shortVersion(e.Version), formatAge(e.Pinned, now), latest, formatAge(e.Released, now), e.Status)
+		}
+		w.Flush()
+	case jsonFormat:
+		json.NewEncoder(msg.Default.Stdout).Encode(entries)
+	case jsonPrettyFormat:
+		b, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			msg.Die("could not marshal the report: %s", err)
+		}
+		msg.Print(string(b) + "\n")
+	default:
+		msg.Die("invalid output format: must be one of: json|json-pretty|text")
+	}
+}
+
+// reportEntry looks up the freshness of a dependency locked to version. The
+// copy of the repo in the cache is used for dates when there is one and the
+// API of the host otherwise. The newest release is taken from the API when
+// the host has one so it is not limited to what was last fetched.
+func reportEntry(d *cfg.Dependency, version string, now time.Time) *ReportEntry {
+	e := &ReportEntry{Name: d.Name, Version: version, Status: statusUnknown}
+	remote := d.Remote()
+	cached, cerr := cachedRepo(d)
+
+	commitDate := func(id, commit string) *time.Time {
+		if cerr == nil {
+			if ci, err := cached.CommitInfo(id); err == nil {
+				return &ci.Date
+			}
+		}
+		if commit == "" {
+			return nil
+		}
+		t, err := hosting.CommitDate(remote, commit)
+		if err != nil {
+			if err != hosting.ErrUnsupported {
+				msg.Debug("Unable to find the date of %s in %s: %s", commit, d.Name, err)
+			}
+			return nil
+		}
+		return &t
+	}
+	e.Pinned = commitDate(version, version)
+
+	if refs, err := hosting.ListRefs(remote); err == nil {
+		commits := make(map[string]string, len(refs.Tags))
+		for _, t := range refs.Tags {
+			commits[t.Name] = t.Commit
+		}
+		if e.Latest = latestRelease(refs.TagNames()); e.Latest != "" {
+			e.Released = commitDate(e.Latest, commits[e.Latest])
+		}
+	} else if cerr == nil {
+		if tags, err := cached.Tags(); err == nil {
+			if e.Latest = latestRelease(tags); e.Latest != "" {
+				e.Released = commitDate(e.Latest, "")
+			}
+		}
+	} else if err != hosting.ErrUnsupported {
+		msg.Debug("Unable to list the tags of %s: %s", d.Name, err)
+	}
+
+	info, err := hosting.RepoInfo(remote)
+	if err != nil {
+		if err != hosting.ErrUnsupported {
+			msg.Debug("Unable to look up %s: %s", d.Name, err)
+		}
+		return e
+	}
+	if !info.LastActivity.IsZero() {
+		e.LastActivity = &info.LastActivity
+	}
+	switch {
+	case info.Archived:
+		e.Status = statusArchived
+	case e.LastActivity != nil && now.Sub(*e.LastActivity) > UnmaintainedAfter:
+		e.Status = statusUnmaintained
+	default:
+		e.Status = statusActive
+	}
+	return e
+}
+
+// latestRelease returns the newest of the tags that are semantic versions,
+// leaving out pre-releases.
+func latestRelease(tags []string) string {
+	var latest *semver.Version
+	var name string
+	for _, t := range tags {
+		v, err := semver.NewVersion(t)
+		if err != nil || v.Prerelease() != "" {
+			continue
+		}
+		if latest == nil || v.GreaterThan(latest) {
+			latest, name = v, t
+		}
+	}
+	return name
+}
+
+// formatAge returns how long ago t was in days, months, or years.
+func formatAge(t *time.Time, now time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	days := int(now.Sub(*t).Hours() / 24)
+	switch {
+	case days < 60:
+		return fmt.Sprintf("%dd", days)
+	case days < 730:
+		return fmt.Sprintf("%dmo", days/30)
+	}
+	return fmt.Sprintf("%dy", days/365)
+}
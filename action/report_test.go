@@ -0,0 +1,38 @@
+package action
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatestRelease(t *testing.T) {
+	tags := []string{"v1.2.0", "v1.10.0", "v2.0.0-beta.1", "release", "v1.9.3"}
+	if l := latestRelease(tags); l != "v1.10.0" {
+		t.Errorf("Expected v1.10.0, got %s", l)
+	}
+	if l := latestRelease([]string{"master", "v1.0.0-rc1"}); l != "" {
+		t.Errorf("Expected no release, got %s", l)
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	now := time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{12 * time.Hour, "0d"},
+		{45 * 24 * time.Hour, "45d"},
+		{200 * 24 * time.Hour, "6mo"},
+		{1000 * 24 * time.Hour, "2y"},
+	}
+	for _, tt := range tests {
+		d := now.Add(-tt.ago)
+		if got := formatAge(&d, now); got != tt.want {
+			t.Errorf("Expected %s for %s, got %s", tt.want, tt.ago, got)
+		}
+	}
+	if got := formatAge(nil, now); got != "-" {
+		t.Errorf("Expected - for an unknown date, got %s", got)
+	}
+}
@@ -0,0 +1,223 @@
+package action
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/hooks"
+	"github.com/Masterminds/glide/mirrors"
+	"github.com/Masterminds/glide/msg"
+	"github.com/Masterminds/glide/repo"
+	"github.com/Masterminds/glide/util"
+	v "github.com/Masterminds/vcs"
+)
+
+// NoResolutionCache disables using and storing the outcome of resolving the
+// dependencies in the cache.
+var NoResolutionCache bool
+
+// headWorkers is how many remotes are asked for their refs at once.
+const headWorkers = 8
+
+// resolutionKey returns the key the resolution of the dependencies of conf is
+// cached under. It covers the configuration, the packages the code imports,
+// and the settings changing the outcome of resolving them. An empty key is
+// returned when the outcome depends on more than the remotes of the
+// dependencies, such as local copies, an inherited configuration, or resolver
+// hooks. The mirrors and rewrite rules in use are part of the key.
+func resolutionKey(installer *repo.Installer, conf *cfg.Config, base, hash string) string {
+	if NoResolutionCache || !cache.Enabled || conf.Extends != "" {
+		return ""
+	}
+	if hooks.Configured() {
+		msg.Debug("Not caching the resolution as resolver hooks take part in it")
+		return ""
+	}
+	for _, d := range append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...) {
+		if d.Local != "" {
+			return ""
+		}
+	}
+	imports, testImports, err := localImports(base)
+	if err != nil {
+		msg.Debug("Not caching the resolution as the imports of the code could not be scanned: %s", err)
+		return ""
+	}
+	sort.Strings(imports)
+	if !installer.ResolveTest {
+		testImports = nil
+	}
+	sort.Strings(testImports)
+
+	limits := make([]string, 0, len(repo.MajorLimits))
+	for n, m := range repo.MajorLimits {
+		limits = append(limits, fmt.Sprintf("%s=%d", n, m))
+	}
+	sort.Strings(limits)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", glideVersion, hash)
+	fmt.Fprintf(h, "imports=%s\x00test-imports=%s\x00", strings.Join(imports, ","), strings.Join(testImports, ","))
+	fmt.Fprintf(h, "test=%t all-files=%t prerelease=%t limits=%s", installer.ResolveTest, installer.ResolveAllFiles,
+		repo.Prerelease, strings.Join(limits, ","))
+	fmt.Fprintf(h, " mirrors=%s", mirrors.Fingerprint())
+	if util.ResolveCurrent {
+		goos, goarch := targetPlatform()
+		fmt.Fprintf(h, " current=%s/%s", goos, goarch)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// cachedResolution returns the lock file of the resolution cached under key
+// when none of the remotes it consulted changed since, or else nil.
+func cachedResolution(key string) *cfg.Lockfile {
+	if key == "" {
+		return nil
+	}
+	r, err := cache.LoadResolution(key)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			msg.Debug("Unable to read the cached resolution: %s", err)
+		}
+		return nil
+	}
+	lock, err := cfg.LockfileFromYaml([]byte(r.Lock))
+	if err != nil {
+		msg.Debug("Unable to read the cached resolution: %s", err)
+		return nil
+	}
+	remotes := make([]string, 0, len(r.Heads))
+	for remote := range r.Heads {
+		remotes = append(remotes, remote)
+	}
+	heads, err := remoteHeads(remotes)
+	if err != nil {
+		msg.Debug("Not using the cached resolution: %s", err)
+		return nil
+	}
+	for remote, h := range r.Heads {
+		if heads[remote] != h {
+			msg.Debug("Not using the cached resolution as %s changed", remote)
+			return nil
+		}
+	}
+	return lock
+}
+
+// saveResolution caches lock as the outcome of resolving the dependencies
+// under key, along with the refs of the remotes of the dependencies it locks.
+// It is not cached when a remote cannot be asked for its refs.
+func saveResolution(key string, lock *cfg.Lockfile) {
+	if key == "" {
+		return
+	}
+	var remotes []string
+	for _, l := range append(append(append(cfg.Locks{}, lock.Imports...), lock.DevImports...), lock.Tools...) {
+		remote, err := lockRemote(l)
+		if err != nil {
+			msg.Debug("Not caching the resolution: %s", err)
+			return
+		}
+		remotes = append(remotes, remote)
+	}
+	heads, err := remoteHeads(remotes)
+	if err != nil {
+		msg.Debug("Not caching the resolution: %s", err)
+		return
+	}
+	yml, err := lock.Marshal()
+	if err != nil {
+		msg.Debug("Not caching the resolution: %s", err)
+		return
+	}
+	r := &cache.Resolution{Heads: heads, Lock: string(yml), Resolved: time.Now()}
+	if err := cache.SaveResolution(key, r); err != nil {
+		msg.Debug("Unable to cache the resolution: %s", err)
+	}
+}
+
+// lockRemote returns the remote of a locked dependency. Only Git remotes are
+// supported, as they can be asked for their refs without fetching them.
+func lockRemote(l *cfg.Lock) (string, error) {
+	dep := cfg.DependencyFromLock(l)
+	if dep.IsArchive() {
+		return "", fmt.Errorf("%s is an archive", l.Name)
+	}
+	remote := dep.Remote()
+	vcs := dep.VcsType
+	if vcs == "" {
+		key, err := cache.Key(remote)
+		if err != nil {
+			return "", err
+		}
+		r, err := dep.GetRepo(filepath.Join(cache.Location(), "src", key))
+		if err != nil {
+			return "", fmt.Errorf("unable to detect the VCS of %s: %s", l.Name, err)
+		}
+		vcs = string(r.Vcs())
+	}
+	if vcs != string(v.Git) {
+		return "", fmt.Errorf("%s is not a Git repo", l.Name)
+	}
+	return remote, nil
+}
+
+// remoteHeads returns digests of the refs of the Git remotes, by remote.
+func remoteHeads(remotes []string) (map[string]string, error) {
+	heads := make(map[string]string, len(remotes))
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	queue := make(chan string)
+	for i := 0; i < headWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for remote := range queue {
+				h, err := remoteHead(remote)
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				heads[remote] = h
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, r := range remotes {
+		queue <- r
+	}
+	close(queue)
+	wg.Wait()
+	return heads, firstErr
+}
+
+// remoteHead returns a digest of the refs of a Git remote.
+func remoteHead(remote string) (string, error) {
+	cmd := exec.Command("git", "ls-remote", remote)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", msg.OutputLoggedEnv+"=1")
+	out, err := cmd.Output()
+	msg.Output("git", out)
+	if ee, ok := err.(*exec.ExitError); ok {
+		msg.Output("git", ee.Stderr)
+	}
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+			err = errors.New(strings.TrimSpace(string(ee.Stderr)))
+		}
+		return "", fmt.Errorf("unable to list the refs of %s: %s", remote, err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(out)), nil
+}
@@ -0,0 +1,120 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/mirrors"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/glide/repo"
+)
+
+func TestCachedResolution(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+	home, err := ioutil.TempDir("", "glide-resolution")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer gpath.SetHome(gpath.Home())
+	gpath.SetHome(home)
+	cache.SetupReset()
+	defer cache.SetupReset()
+
+	dir := filepath.Join(home, "dep")
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=glide", "GIT_AUTHOR_EMAIL=glide@example.com",
+			"GIT_COMMITTER_NAME=glide", "GIT_COMMITTER_EMAIL=glide@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s: %s", args, err, out)
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	git("init", "-q")
+	git("commit", "-q", "--allow-empty", "-m", "one")
+	git("tag", "v1.0.0")
+
+	lock := &cfg.Lockfile{
+		Hash: "abc",
+		Imports: cfg.Locks{
+			{Name: "example.com/dep", Version: "v1.0.0", Repository: "file://" + filepath.ToSlash(dir), VcsType: "git"},
+		},
+	}
+	if l := cachedResolution("key"); l != nil {
+		t.Fatal("Found a resolution in an empty cache")
+	}
+	saveResolution("key", lock)
+	l := cachedResolution("key")
+	if l == nil || len(l.Imports) != 1 || l.Imports[0].Version != "v1.0.0" {
+		t.Fatalf("Expected the cached resolution, got %v", l)
+	}
+	if l := cachedResolution("other"); l != nil {
+		t.Error("Found a resolution under another key")
+	}
+
+	git("tag", "v1.1.0")
+	if l := cachedResolution("key"); l != nil {
+		t.Error("Expected a changed remote to invalidate the cached resolution")
+	}
+
+	archived := &cfg.Lockfile{
+		Imports: cfg.Locks{
+			{Name: "example.com/archived", Version: "sha256:abc", Repository: "https://example.com/archived.tar.gz"},
+		},
+	}
+	saveResolution("archived", archived)
+	if l := cachedResolution("archived"); l != nil {
+		t.Error("Expected a resolution with an archive not to be cached")
+	}
+}
+
+func TestResolutionKey(t *testing.T) {
+	home, err := ioutil.TempDir("", "glide-resolution-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer gpath.SetHome(gpath.Home())
+	gpath.SetHome(home)
+
+	base := filepath.Join(home, "app")
+	if err := os.MkdirAll(base, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(base, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conf := &cfg.Config{Name: "example.com/app"}
+	installer := repo.NewInstaller()
+
+	key := resolutionKey(installer, conf, base, "hash")
+	if key == "" {
+		t.Fatal("Expected the resolution to be cached")
+	}
+
+	mirrors.SetRewrites([]*mirrors.Rewrite{{From: "k8s.io/*", To: "github.com/kubernetes/*"}})
+	rewritten := resolutionKey(installer, conf, base, "hash")
+	mirrors.SetRewrites(nil)
+	if rewritten == "" || rewritten == key {
+		t.Error("Expected the rewrite rules to change the key")
+	}
+
+	yml := "hooks:\n- command: /usr/local/bin/glide-policy\n"
+	if err := ioutil.WriteFile(filepath.Join(home, "config.yaml"), []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if k := resolutionKey(installer, conf, base, "hash"); k != "" {
+		t.Error("Expected no key with resolver hooks configured")
+	}
+}
@@ -0,0 +1,395 @@
+package action
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// The formats a software bill of materials can be written in.
+const (
+	SBOMCycloneDX = "cyclonedx"
+	SBOMSPDX      = "spdx-json"
+)
+
+// SBOM writes a software bill of materials listing the dependencies pinned in
+// glide.lock along with their repositories, a hash of their vendored files,
+// and the licenses detected in them.
+//
+// Params:
+//  - format (string): The format to write, cyclonedx or spdx-json
+//  - out (string): The file to write to. When empty Stdout is used.
+//  - version (string): The version of Glide recorded as the tool creating it
+func SBOM(format, out, version string) {
+	conf := EnsureConfig()
+	yamlpath, err := gpath.Glide()
+	if err != nil {
+		msg.Die("Failed to find %s file in directory tree: %s", gpath.GlideFile, err)
+	}
+	lock, err := cfg.ReadLockFile(filepath.Join(filepath.Dir(yamlpath), gpath.LockFile))
+	if err != nil {
+		msg.Die("Could not load lockfile: %s. Run 'glide up' to create it", err)
+	}
+	vpath, err := gpath.Vendor()
+	if err != nil {
+		msg.Die("Could not find the vendor directory: %s", err)
+	}
+
+	comps := sbomComponents(lock, vpath)
+	var doc interface{}
+	switch format {
+	case SBOMCycloneDX:
+		doc = cycloneDX(conf, lock, comps, version)
+	case SBOMSPDX:
+		doc = spdxDocument(conf, lock, comps, version)
+	default:
+		msg.Die("Unknown SBOM format %q. Use %s or %s", format, SBOMCycloneDX, SBOMSPDX)
+	}
+
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		msg.Die("Unable to generate the SBOM: %s", err)
+	}
+	b = append(b, '\n')
+	if out == "" {
+		msg.Print(string(b))
+		return
+	}
+	if err := ioutil.WriteFile(out, b, 0666); err != nil {
+		msg.Die("Unable to write the SBOM to %s: %s", out, err)
+	}
+	msg.Info("SBOM written to %s", out)
+}
+
+// sbomComponent is a dependency as listed in a bill of materials.
+type sbomComponent struct {
+	lock    *cfg.Lock
+	test    bool
+	remote  string
+	hash    string
+	license string
+}
+
+// purl returns the package URL of the component.
+func (c *sbomComponent) purl() string {
+	return "pkg:golang/" + c.lock.Name + "@" + c.lock.Version
+}
+
+// sbomComponents collects the dependencies in the lock file. Hashes and
+// licenses come from the vendor directory at vpath. The license of a
+// dependency that is not vendored is looked for in the cache instead.
+func sbomComponents(lock *cfg.Lockfile, vpath string) []*sbomComponent {
+	var comps []*sbomComponent
+	add := func(l *cfg.Lock, test bool) {
+		c := &sbomComponent{lock: l, test: test, remote: l.Repository}
+		if c.remote == "" {
+			c.remote = "https://" + l.Name
+		}
+
+		dir := filepath.Join(vpath, filepath.FromSlash(l.Name))
+		if hasDir(dir) {
+			h, err := dirHash(dir)
+			if err != nil {
+				msg.Warn("Unable to hash %s: %s", l.Name, err)
+			}
+			c.hash = h
+			c.license = detectLicense(dir)
+		} else {
+			msg.Warn("%s is not in the vendor directory. Its hash is not included", l.Name)
+			if key, err := cache.Key(c.remote); err == nil {
+				c.license = detectLicense(filepath.Join(cache.Location(), "src", key))
+			}
+		}
+		comps = append(comps, c)
+	}
+	for _, l := range lock.Imports {
+		add(l, false)
+	}
+	for _, l := range lock.DevImports {
+		add(l, true)
+	}
+	return comps
+}
+
+// dirHash returns a SHA-256 hash of the files in dir. Each file is summarized
+// by the hex SHA-256 of its content, two spaces, and its slash separated path
+// on a line, sorted by path. The hash is of the summary.
+func dirHash(dir string) (string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.Mode().IsRegular() {
+			rel, err := filepath.Rel(dir, p)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	summary := sha256.New()
+	for _, f := range files {
+		h := sha256.New()
+		r, err := os.Open(filepath.Join(dir, filepath.FromSlash(f)))
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, r)
+		r.Close()
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(summary, "%x  %s\n", h.Sum(nil), f)
+	}
+	return fmt.Sprintf("%x", summary.Sum(nil)), nil
+}
+
+// sbomUUID derives a UUID from the lock file so the same lock file produces
+// the same document.
+func sbomUUID(lock *cfg.Lockfile) string {
+	f, err := lock.Fingerprint()
+	if err != nil {
+		f = sha256.Sum256([]byte(lock.Hash))
+	}
+	// Mark it as a name based (version 5 style) UUID of the RFC 4122 variant.
+	f[6] = f[6]&0x0f | 0x50
+	f[8] = f[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", f[0:4], f[4:6], f[6:8], f[8:10], f[10:16])
+}
+
+// sbomTime is the time recorded in a document. The time the lock file was
+// updated is used so the same lock file produces the same document.
+func sbomTime(lock *cfg.Lockfile) string {
+	return lock.Updated.UTC().Format(time.RFC3339)
+}
+
+type cdxDocument struct {
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	SerialNumber string          `json:"serialNumber"`
+	Version      int             `json:"version"`
+	Metadata     cdxMetadata     `json:"metadata"`
+	Components   []*cdxComponent `json:"components"`
+}
+
+type cdxMetadata struct {
+	Timestamp string        `json:"timestamp"`
+	Tools     []cdxTool     `json:"tools"`
+	Component *cdxComponent `json:"component"`
+}
+
+type cdxTool struct {
+	Vendor  string `json:"vendor"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type cdxComponent struct {
+	Type       string       `json:"type"`
+	BOMRef     string       `json:"bom-ref"`
+	Name       string       `json:"name"`
+	Version    string       `json:"version,omitempty"`
+	Scope      string       `json:"scope,omitempty"`
+	Purl       string       `json:"purl,omitempty"`
+	Hashes     []cdxHash    `json:"hashes,omitempty"`
+	Licenses   []cdxLicense `json:"licenses,omitempty"`
+	References []cdxRef     `json:"externalReferences,omitempty"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cdxLicense struct {
+	License    *cdxLicenseID `json:"license,omitempty"`
+	Expression string        `json:"expression,omitempty"`
+}
+
+type cdxLicenseID struct {
+	ID string `json:"id"`
+}
+
+type cdxRef struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// cycloneDX builds a CycloneDX 1.4 document.
+func cycloneDX(conf *cfg.Config, lock *cfg.Lockfile, comps []*sbomComponent, version string) *cdxDocument {
+	root := &cdxComponent{Type: "application", BOMRef: conf.Name, Name: conf.Name}
+	if conf.License != "" {
+		root.Licenses = []cdxLicense{{Expression: conf.License}}
+	}
+
+	doc := &cdxDocument{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.4",
+		SerialNumber: "urn:uuid:" + sbomUUID(lock),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: sbomTime(lock),
+			Tools:     []cdxTool{{Vendor: "Masterminds", Name: "glide", Version: version}},
+			Component: root,
+		},
+		Components: []*cdxComponent{},
+	}
+	for _, c := range comps {
+		cc := &cdxComponent{
+			Type:       "library",
+			BOMRef:     c.purl(),
+			Name:       c.lock.Name,
+			Version:    c.lock.Version,
+			Scope:      "required",
+			Purl:       c.purl(),
+			References: []cdxRef{{Type: "vcs", URL: c.remote}},
+		}
+		if c.test {
+			cc.Scope = "optional"
+		}
+		if c.hash != "" {
+			cc.Hashes = []cdxHash{{Alg: "SHA-256", Content: c.hash}}
+		}
+		if c.license != "" {
+			cc.Licenses = []cdxLicense{{License: &cdxLicenseID{ID: c.license}}}
+		}
+		doc.Components = append(doc.Components, cc)
+	}
+	return doc
+}
+
+type spdxDoc struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []*spdxPackage     `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name             string         `json:"name"`
+	SPDXID           string         `json:"SPDXID"`
+	VersionInfo      string         `json:"versionInfo,omitempty"`
+	DownloadLocation string         `json:"downloadLocation"`
+	FilesAnalyzed    bool           `json:"filesAnalyzed"`
+	Checksums        []spdxChecksum `json:"checksums,omitempty"`
+	LicenseConcluded string         `json:"licenseConcluded"`
+	LicenseDeclared  string         `json:"licenseDeclared"`
+	CopyrightText    string         `json:"copyrightText"`
+	ExternalRefs     []spdxRef      `json:"externalRefs,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm string `json:"algorithm"`
+	Value     string `json:"checksumValue"`
+}
+
+type spdxRef struct {
+	Category string `json:"referenceCategory"`
+	Type     string `json:"referenceType"`
+	Locator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	Element string `json:"spdxElementId"`
+	Type    string `json:"relationshipType"`
+	Related string `json:"relatedSpdxElement"`
+}
+
+// spdxIDRe matches the characters not allowed in SPDX identifiers.
+var spdxIDRe = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+func spdxID(name string) string {
+	return "SPDXRef-Package-" + spdxIDRe.ReplaceAllString(name, "-")
+}
+
+// spdxDocument builds an SPDX 2.3 document.
+func spdxDocument(conf *cfg.Config, lock *cfg.Lockfile, comps []*sbomComponent, version string) *spdxDoc {
+	noAssertion := "NOASSERTION"
+	tool := "Tool: glide"
+	if version != "" {
+		tool += "-" + version
+	}
+	doc := &spdxDoc{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              conf.Name,
+		DocumentNamespace: "https://spdx.org/spdxdocs/" + strings.Replace(conf.Name, "/", "-", -1) + "-" + sbomUUID(lock),
+		CreationInfo: spdxCreationInfo{
+			Created:  sbomTime(lock),
+			Creators: []string{tool},
+		},
+	}
+
+	root := &spdxPackage{
+		Name:             conf.Name,
+		SPDXID:           spdxID(conf.Name),
+		DownloadLocation: noAssertion,
+		LicenseConcluded: noAssertion,
+		LicenseDeclared:  noAssertion,
+		CopyrightText:    noAssertion,
+	}
+	if conf.License != "" {
+		root.LicenseDeclared = conf.License
+	}
+	doc.Packages = append(doc.Packages, root)
+	doc.Relationships = append(doc.Relationships, spdxRelationship{doc.SPDXID, "DESCRIBES", root.SPDXID})
+
+	for _, c := range comps {
+		p := &spdxPackage{
+			Name:             c.lock.Name,
+			SPDXID:           spdxID(c.lock.Name),
+			VersionInfo:      c.lock.Version,
+			DownloadLocation: c.remote + "@" + c.lock.Version,
+			LicenseConcluded: noAssertion,
+			LicenseDeclared:  noAssertion,
+			CopyrightText:    noAssertion,
+			ExternalRefs:     []spdxRef{{"PACKAGE-MANAGER", "purl", c.purl()}},
+		}
+		if c.lock.VcsType != "" {
+			p.DownloadLocation = c.lock.VcsType + "+" + p.DownloadLocation
+		}
+		if c.hash != "" {
+			p.Checksums = []spdxChecksum{{"SHA256", c.hash}}
+		}
+		if c.license != "" {
+			p.LicenseDeclared = c.license
+		}
+		doc.Packages = append(doc.Packages, p)
+		if c.test {
+			doc.Relationships = append(doc.Relationships, spdxRelationship{p.SPDXID, "DEV_DEPENDENCY_OF", root.SPDXID})
+		} else {
+			doc.Relationships = append(doc.Relationships, spdxRelationship{root.SPDXID, "DEPENDS_ON", p.SPDXID})
+		}
+	}
+	return doc
+}
@@ -0,0 +1,75 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/glide/cfg"
+)
+
+func TestLicenseID(t *testing.T) {
+	tests := map[string]string{
+		"The MIT License\n\nPermission is hereby granted, free of charge, to any person":                                     "MIT",
+		"Apache License\n   Version 2.0, January 2004":                                                                       "Apache-2.0",
+		"Redistributions of source code must retain the above copyright notice.\n\nNeither the name of the copyright holder": "BSD-3-Clause",
+		"Redistributions of source code must retain the above copyright notice.":                                             "BSD-2-Clause",
+		"GNU LESSER GENERAL PUBLIC LICENSE\n Version 3, 29 June 2007":                                                        "LGPL-3.0",
+		"All rights reserved.": "",
+	}
+	for text, id := range tests {
+		if got := licenseID(text); got != id {
+			t.Errorf("Expected %q for %q, got %q", id, text, got)
+		}
+	}
+}
+
+func TestSBOM(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-sbom")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	a := filepath.Join(dir, "example.com", "a")
+	os.MkdirAll(filepath.Join(a, "sub"), 0755)
+	ioutil.WriteFile(filepath.Join(a, "LICENSE"), []byte("Permission is hereby granted, free of charge"), 0644)
+	ioutil.WriteFile(filepath.Join(a, "sub", "a.go"), []byte("package sub\n"), 0644)
+
+	lock := &cfg.Lockfile{
+		Hash:       "abc",
+		Updated:    time.Date(2016, 8, 1, 0, 0, 0, 0, time.UTC),
+		Imports:    cfg.Locks{{Name: "example.com/a", Version: "1.0.0", VcsType: "git"}},
+		DevImports: cfg.Locks{{Name: "example.com/t", Version: "2.0.0", Repository: "https://mirror.example.com/t"}},
+	}
+	comps := sbomComponents(lock, dir)
+	if len(comps) != 2 || comps[0].license != "MIT" || len(comps[0].hash) != 64 || comps[1].hash != "" {
+		t.Fatalf("Unexpected components %v %v", comps[0], comps[1])
+	}
+	if h, _ := dirHash(a); h != comps[0].hash {
+		t.Error("Expected the directory hash to be stable")
+	}
+
+	conf := &cfg.Config{Name: "example.com/foo", License: "MIT"}
+	cdx := cycloneDX(conf, lock, comps, "0.13.0")
+	if cdx.SerialNumber != cycloneDX(conf, lock, comps, "0.13.0").SerialNumber {
+		t.Error("Expected the same lock file to produce the same serial number")
+	}
+	c := cdx.Components[1]
+	if c.Purl != "pkg:golang/example.com/t@2.0.0" || c.Scope != "optional" || c.References[0].URL != "https://mirror.example.com/t" {
+		t.Errorf("Unexpected CycloneDX component %+v", c)
+	}
+	if cdx.Metadata.Timestamp != "2016-08-01T00:00:00Z" {
+		t.Errorf("Expected the lock time as the timestamp, got %s", cdx.Metadata.Timestamp)
+	}
+
+	spdx := spdxDocument(conf, lock, comps, "0.13.0")
+	p := spdx.Packages[1]
+	if p.SPDXID != "SPDXRef-Package-example.com-a" || p.DownloadLocation != "git+https://example.com/a@1.0.0" || p.LicenseDeclared != "MIT" || p.Checksums[0].Value != comps[0].hash {
+		t.Errorf("Unexpected SPDX package %+v", p)
+	}
+	if r := spdx.Relationships[2]; r.Type != "DEV_DEPENDENCY_OF" || r.Element != "SPDXRef-Package-example.com-t" {
+		t.Errorf("Expected the test dependency to be a dev dependency, got %+v", r)
+	}
+}
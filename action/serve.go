@@ -0,0 +1,195 @@
+package action
+
+import (
+	"errors"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/glide/api"
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/glide/repo"
+)
+
+// Serve runs Glide as a daemon answering JSON-RPC requests on a unix socket.
+//
+// The daemon holds the lock on the cache while it runs and remembers the
+// repositories it fetched so requests following each other within refresh do
+// not fetch them again.
+//
+// Params:
+//  - socket (string): The path of the socket. It defaults to glide.sock in the
+//    Glide home directory.
+//  - refresh (time.Duration): How long fetched repositories are considered up
+//    to date.
+func Serve(socket string, refresh time.Duration) {
+	if socket == "" {
+		socket = filepath.Join(gpath.Home(), "glide.sock")
+	}
+	l, err := listenSocket(socket)
+	if err != nil {
+		msg.Die("Unable to listen on %s: %s", socket, err)
+	}
+	defer l.Close()
+	defer api.Close()
+
+	s := rpc.NewServer()
+	if err := s.RegisterName("Glide", NewService(refresh)); err != nil {
+		msg.Die("Unable to start the service: %s", err)
+	}
+
+	msg.Info("Listening on %s", socket)
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			msg.Die("Unable to accept connections: %s", err)
+		}
+		go s.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
+
+// listenSocket listens on the unix socket at p. A socket left behind by a
+// daemon that did not shut down cleanly is replaced while one in use is an
+// error.
+func listenSocket(p string) (net.Listener, error) {
+	if _, err := os.Stat(p); err == nil {
+		if c, err := net.Dial("unix", p); err == nil {
+			c.Close()
+			return nil, errors.New("another glide daemon is using the socket")
+		}
+		if err := os.Remove(p); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return nil, err
+	}
+	return net.Listen("unix", p)
+}
+
+// Service holds the operations served by the daemon as the Glide RPC service.
+// Requests are handled one at a time because Glide works on the working
+// directory of the process.
+type Service struct {
+	mu sync.Mutex
+
+	refresh time.Duration
+	updated *repo.UpdateTracker
+	fetched time.Time
+}
+
+// NewService creates a Service that fetches repositories again once refresh has
+// passed since they were first fetched.
+func NewService(refresh time.Duration) *Service {
+	return &Service{refresh: refresh}
+}
+
+// Request describes the project a request operates on.
+type Request struct {
+	// Dir is the absolute path of the project.
+	Dir string
+
+	ResolveTest     bool
+	ResolveAllFiles bool
+	Force           bool
+
+	// Write, for Resolve, writes the resolved versions to glide.lock.
+	Write bool
+}
+
+// Resolve resolves the dependencies of a project and returns its lock file.
+func (s *Service) Resolve(req *Request, lock *cfg.Lockfile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conf, opts, err := s.load(req)
+	if err != nil {
+		return err
+	}
+	l, err := api.Resolve(conf, opts)
+	if err != nil {
+		return err
+	}
+	if req.Write {
+		if err := l.WriteFile(filepath.Join(req.Dir, gpath.LockFile)); err != nil {
+			return err
+		}
+	}
+	*lock = *l
+	return nil
+}
+
+// Install installs the dependencies pinned in the lock file of a project into
+// its vendor directory. A project without a lock file has its dependencies
+// resolved and the lock file written first.
+func (s *Service) Install(req *Request, lock *cfg.Lockfile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conf, opts, err := s.load(req)
+	if err != nil {
+		return err
+	}
+	l, err := api.LoadLock(req.Dir)
+	if os.IsNotExist(err) {
+		if l, err = api.Resolve(conf, opts); err != nil {
+			return err
+		}
+		err = l.WriteFile(filepath.Join(req.Dir, gpath.LockFile))
+	}
+	if err != nil {
+		return err
+	}
+	if err := api.Install(l, conf, opts); err != nil {
+		return err
+	}
+	*lock = *l
+	return nil
+}
+
+// List lists the packages used by a project and where they are found.
+func (s *Service) List(req *Request, list *PackageList) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conf, opts, err := s.load(req)
+	if err != nil {
+		return err
+	}
+	return api.InProject(conf, opts, func() error {
+		l, err := listPackages(req.Dir, true)
+		if err != nil {
+			return err
+		}
+		*list = l
+		return nil
+	})
+}
+
+// load reads the config of the project in a request and the options to handle
+// it with.
+func (s *Service) load(req *Request) (*cfg.Config, api.Options, error) {
+	if !filepath.IsAbs(req.Dir) {
+		return nil, api.Options{}, errors.New("the project directory must be an absolute path")
+	}
+	if s.updated == nil || time.Since(s.fetched) > s.refresh {
+		s.updated = repo.NewUpdateTracker()
+		s.fetched = time.Now()
+	}
+
+	conf, err := api.LoadConfig(req.Dir)
+	opts := api.Options{
+		Dir:             req.Dir,
+		ResolveTest:     req.ResolveTest,
+		ResolveAllFiles: req.ResolveAllFiles,
+		Force:           req.Force,
+		Updated:         s.updated,
+	}
+	return conf, opts, err
+}
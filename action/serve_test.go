@@ -0,0 +1,102 @@
+package action
+
+import (
+	"io/ioutil"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/glide/api"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+func TestService(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-serve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sock := filepath.Join(dir, "glide.sock")
+	l, err := listenSocket(sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if _, err := listenSocket(sock); err == nil {
+		t.Error("Expected an error listening on a socket in use")
+	}
+
+	s := rpc.NewServer()
+	s.RegisterName("Glide", NewService(time.Minute))
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.ServeCodec(jsonrpc.NewServerCodec(conn))
+		}
+	}()
+
+	c, err := jsonrpc.Dial("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var list PackageList
+	if err := c.Call("Glide.List", &Request{Dir: "relative"}, &list); err == nil {
+		t.Error("Expected a relative project directory to be rejected")
+	}
+
+	// The project is listed in its own directory, with its own vendor
+	// directory, whatever those of the daemon are.
+	gpath.SetHome(filepath.Join(dir, "home"))
+	defer gpath.SetHome(gpath.Home())
+	defer func(v string) { gpath.VendorDir = v }(gpath.VendorDir)
+	defer api.Close()
+	proj := filepath.Join(dir, "app")
+	files := map[string]string{
+		"glide.yaml":                "package: example.com/app\nvendorDir: deps\n",
+		"main.go":                   "package main\n\nimport _ \"example.com/dep\"\n\nfunc main() {}\n",
+		"deps/example.com/dep/a.go": "package dep\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(proj, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, _ := os.Getwd()
+	if err := c.Call("Glide.List", &Request{Dir: proj}, &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Installed) != 1 || list.Installed[0] != "example.com/dep" || len(list.Missing) != 0 {
+		t.Errorf("Unexpected list of the project %+v", list)
+	}
+	if now, _ := os.Getwd(); now != wd {
+		t.Errorf("Expected the working directory to be restored, got %s", now)
+	}
+}
+
+func TestListenSocketStale(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-serve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A socket file nothing listens on is left by a daemon that was killed.
+	sock := filepath.Join(dir, "glide.sock")
+	ioutil.WriteFile(sock, nil, 0644)
+	l, err := listenSocket(sock)
+	if err != nil {
+		t.Fatalf("Expected a stale socket to be replaced, got %s", err)
+	}
+	l.Close()
+}
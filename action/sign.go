@@ -0,0 +1,207 @@
+package action
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// The tools glide.lock files can be signed with.
+const (
+	SignGPG      = "gpg"
+	SignMinisign = "minisign"
+)
+
+// The detached signatures stored next to glide.lock by each tool.
+const (
+	gpgSignatureFile      = gpath.LockFile + ".asc"
+	minisignSignatureFile = gpath.LockFile + ".minisig"
+)
+
+// SignLock creates a detached signature of glide.lock using GPG or minisign.
+// The key is a GPG key id or the path to a minisign secret key. When empty the
+// default key of the tool is used. The tool may prompt for a passphrase.
+func SignLock(base, tool, key string) {
+	lockpath := filepath.Join(base, gpath.LockFile)
+	if _, err := os.Stat(lockpath); err != nil {
+		msg.Die("Lock file (glide.lock) does not exist. Run 'glide up' to create it")
+	}
+
+	var sig string
+	var args []string
+	switch tool {
+	case SignGPG:
+		sig = filepath.Join(base, gpgSignatureFile)
+		args = []string{"--yes", "--armor", "--detach-sign", "--output", sig}
+		if key != "" {
+			args = append(args, "--local-user", key)
+		}
+		args = append(args, lockpath)
+	case SignMinisign:
+		sig = filepath.Join(base, minisignSignatureFile)
+		args = []string{"-S", "-m", lockpath, "-x", sig}
+		if key != "" {
+			args = append(args, "-s", key)
+		}
+	default:
+		msg.Die("Unknown signing tool %q. Use %s or %s", tool, SignGPG, SignMinisign)
+	}
+
+	c := exec.Command(tool, args...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stderr
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		msg.Die("Unable to sign glide.lock with %s: %s", tool, err)
+	}
+
+	// Only one signature is kept so a stale one is never verified.
+	for _, f := range []string{gpgSignatureFile, minisignSignatureFile} {
+		if p := filepath.Join(base, f); p != sig {
+			os.Remove(p)
+		}
+	}
+	msg.Info("Signed glide.lock. The signature is in %s", filepath.Base(sig))
+}
+
+// VerifyLockSignature exits when glide.lock does not have a valid signature.
+//
+// GPG signatures are checked against the keyring of the user. When trusted
+// keys are passed, by fingerprint or key id, the signature must be made by one
+// of them. Otherwise the key must be fully or ultimately trusted in the
+// keyring, as any key can be imported into it. Minisign signatures are checked against the trusted keys, which are
+// public key files or the keys themselves. When none are passed the
+// minisign.pub file next to glide.yaml is used.
+func VerifyLockSignature(base string, trusted []string) {
+	lockpath := filepath.Join(base, gpath.LockFile)
+	if _, err := os.Stat(lockpath); err != nil {
+		msg.Die("Lock file (glide.lock) does not exist. Refusing to install without a signed lock file")
+	}
+
+	var err error
+	if sig := filepath.Join(base, gpgSignatureFile); fileExists(sig) {
+		err = verifyGPG(lockpath, sig, trusted)
+	} else if sig := filepath.Join(base, minisignSignatureFile); fileExists(sig) {
+		if len(trusted) == 0 {
+			trusted = []string{filepath.Join(base, "minisign.pub")}
+		}
+		err = verifyMinisign(lockpath, sig, trusted)
+	} else {
+		err = fmt.Errorf("no %s or %s signature found", gpgSignatureFile, minisignSignatureFile)
+	}
+	if err != nil {
+		msg.Die("glide.lock is not signed by a trusted key: %s", err)
+	}
+	msg.Info("The signature of glide.lock is valid")
+}
+
+// warnStaleSignature warns when glide.lock was written and the signature kept
+// next to it no longer matches.
+func warnStaleSignature(base string) {
+	for _, f := range []string{gpgSignatureFile, minisignSignatureFile} {
+		if fileExists(filepath.Join(base, f)) {
+			msg.Warn("glide.lock changed so %s is no longer valid. Run 'glide lock --sign' to sign it again", f)
+			return
+		}
+	}
+}
+
+// verifyGPG checks a detached GPG signature. The fingerprints of valid
+// signatures are read from the machine readable status output of gpg.
+func verifyGPG(lockpath, sig string, trusted []string) error {
+	c := exec.Command(SignGPG, "--batch", "--status-fd", "1", "--verify", sig, lockpath)
+	var stderr bytes.Buffer
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		return fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	fprs, valid := gpgStatus(out)
+	if len(fprs) == 0 {
+		return fmt.Errorf("no valid signature in %s", filepath.Base(sig))
+	}
+	if len(trusted) > 0 {
+		if keyTrusted(fprs, trusted) {
+			return nil
+		}
+		return fmt.Errorf("signed by %s which is not a trusted key", fprs[0])
+	}
+	if valid {
+		return nil
+	}
+	return fmt.Errorf("signed by %s which is not fully trusted in your keyring. Pass its fingerprint to --trusted-key or certify the key", fprs[0])
+}
+
+// gpgStatus reads the fingerprints of the keys valid signatures were made with
+// from the status output of gpg. It also returns true when the keyring fully
+// or ultimately trusts the keys.
+func gpgStatus(out []byte) ([]string, bool) {
+	var fprs []string
+	valid := false
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		f := strings.Fields(s.Text())
+		if len(f) < 2 || f[0] != "[GNUPG:]" {
+			continue
+		}
+		switch f[1] {
+		case "VALIDSIG":
+			if len(f) > 2 {
+				// The fingerprint of the signing key comes first and the
+				// one of the primary key last.
+				fprs = append(fprs, f[2], f[len(f)-1])
+			}
+		case "TRUST_FULLY", "TRUST_ULTIMATE":
+			valid = true
+		}
+	}
+	return fprs, valid && len(fprs) > 0
+}
+
+// keyTrusted returns true if one of the fingerprints matches a trusted key.
+// Trusted keys can be full fingerprints or key ids, which are their suffix.
+func keyTrusted(fprs, trusted []string) bool {
+	for _, t := range trusted {
+		t = strings.ToUpper(strings.Replace(strings.TrimPrefix(t, "0x"), " ", "", -1))
+		if len(t) < 8 {
+			continue
+		}
+		for _, fpr := range fprs {
+			if strings.HasSuffix(strings.ToUpper(fpr), t) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// verifyMinisign checks a minisign signature against each of the trusted keys
+// until one matches.
+func verifyMinisign(lockpath, sig string, trusted []string) error {
+	var err error
+	for _, t := range trusted {
+		keyArg := "-P"
+		if fileExists(t) {
+			keyArg = "-p"
+		}
+		out, cerr := exec.Command(SignMinisign, "-V", "-q", "-m", lockpath, "-x", sig, keyArg, t).CombinedOutput()
+		if cerr == nil {
+			return nil
+		}
+		err = fmt.Errorf("%s: %s", cerr, strings.TrimSpace(string(out)))
+	}
+	return err
+}
+
+func fileExists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
@@ -0,0 +1,40 @@
+package action
+
+import "testing"
+
+func TestKeyTrusted(t *testing.T) {
+	fprs := []string{"035FE23E7AE556E2A76709DFA8D40647895A5DBC"}
+	tests := map[string]bool{
+		"035FE23E7AE556E2A76709DFA8D40647895A5DBC":          true,
+		"035f e23e 7ae5 56e2 a767 09df a8d4 0647 895a 5dbc": true,
+		"0xA8D40647895A5DBC":                                true,
+		"895A5DBC":                                          true,
+		"5DBC":                                              false,
+		"DEADBEEFDEADBEEF":                                  false,
+	}
+	for in, want := range tests {
+		if got := keyTrusted(fprs, []string{in}); got != want {
+			t.Errorf("keyTrusted(%q) = %t, want %t", in, got, want)
+		}
+	}
+}
+
+func TestGpgStatus(t *testing.T) {
+	valid := "[GNUPG:] NEWSIG\n" +
+		"[GNUPG:] GOODSIG A8D40647895A5DBC Release <release@example.com>\n" +
+		"[GNUPG:] VALIDSIG 1111222233334444555566667777888899990000 2017-01-01 1483228800 0 4 0 1 8 00 035FE23E7AE556E2A76709DFA8D40647895A5DBC\n"
+
+	fprs, trusted := gpgStatus([]byte(valid + "[GNUPG:] TRUST_UNDEFINED 0 pgp\n"))
+	if len(fprs) != 2 || fprs[0] != "1111222233334444555566667777888899990000" || fprs[1] != "035FE23E7AE556E2A76709DFA8D40647895A5DBC" {
+		t.Errorf("Unexpected fingerprints %v", fprs)
+	}
+	if trusted {
+		t.Error("Expected a key of undefined trust not to be trusted")
+	}
+	if _, trusted := gpgStatus([]byte(valid + "[GNUPG:] TRUST_FULLY 0 pgp\n")); !trusted {
+		t.Error("Expected a fully trusted key to be trusted")
+	}
+	if _, trusted := gpgStatus([]byte("[GNUPG:] BADSIG A8D40647895A5DBC\n[GNUPG:] TRUST_ULTIMATE 0 pgp\n")); trusted {
+		t.Error("Expected trust without a valid signature to count for nothing")
+	}
+}
@@ -0,0 +1,81 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/glide/msg"
+	"github.com/Masterminds/glide/stats"
+)
+
+// statsFile is where the stats are written as JSON, if anywhere.
+var statsFile string
+
+// Stats turns recording how long the phases of the command take, and how much
+// is fetched for each dependency, on or off. When file is set the stats are
+// also written there as JSON, which turns recording on too.
+func Stats(on bool, file string) {
+	stats.Enabled = on || file != ""
+	statsFile = file
+}
+
+// PrintStats prints a summary of the recorded stats, when recording is on, and
+// writes them to the stats file if one was given.
+func PrintStats() {
+	if !stats.Enabled {
+		return
+	}
+	r := stats.Get()
+
+	msg.Info("Took %.2fs", r.Total)
+	for _, p := range r.Phases {
+		msg.Info("--> %s: %.2fs over %d operation(s), %.2fs wall clock", p.Name, p.Total, p.Count, p.Wall)
+	}
+	deps := append([]*stats.Dependency{}, r.Dependencies...)
+	sort.Stable(byStatsTime(deps))
+	if len(deps) > 10 {
+		msg.Info("The 10 slowest of %d dependencies:", len(deps))
+		deps = deps[:10]
+	} else if len(deps) > 0 {
+		msg.Info("Dependencies:")
+	}
+	for _, d := range deps {
+		var times []string
+		for _, p := range r.Phases {
+			if t, ok := d.Phases[p.Name]; ok {
+				times = append(times, fmt.Sprintf("%s %.2fs", p.Name, t))
+			}
+		}
+		msg.Info("--> %s: %s, %s fetched", d.Name, strings.Join(times, ", "), formatSize(d.Bytes))
+	}
+
+	if statsFile == "" {
+		return
+	}
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		msg.Warn("Unable to marshal the stats: %s", err)
+		return
+	}
+	if err := ioutil.WriteFile(statsFile, append(b, '\n'), 0644); err != nil {
+		msg.Warn("Unable to write the stats to %s: %s", statsFile, err)
+	}
+}
+
+// byStatsTime sorts dependencies by the time spent on them, the longest first.
+type byStatsTime []*stats.Dependency
+
+func (s byStatsTime) Len() int           { return len(s) }
+func (s byStatsTime) Less(i, j int) bool { return statsTime(s[i]) > statsTime(s[j]) }
+func (s byStatsTime) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func statsTime(d *stats.Dependency) float64 {
+	var t float64
+	for _, v := range d.Phases {
+		t += v
+	}
+	return t
+}
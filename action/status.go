@@ -0,0 +1,315 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/hosting"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/semver"
+)
+
+// StatusReport summarizes the state of the dependencies of a project, as
+// printed by Status.
+type StatusReport struct {
+	// Healthy is true when none of the problems below were found. Pending
+	// updates are not problems.
+	Healthy bool `json:"healthy"`
+
+	// Lock lists how glide.lock does not match glide.yaml. A missing lock
+	// file is one of them.
+	Lock []string `json:"lock"`
+
+	// Missing are locked but not in the vendor directory, and Extra are in
+	// the vendor directory but not locked. Stale were vendored by the last
+	// install or update at another version than the one locked.
+	Missing []string `json:"missing"`
+	Extra   []string `json:"extra"`
+	Stale   []string `json:"stale"`
+
+	// Modified are vendored dependencies whose files changed since the last
+	// install or update. It is empty when that did not record them.
+	Modified []string `json:"modified"`
+
+	// Updates are newer versions, within the constraints of glide.yaml, of
+	// the locked dependencies.
+	Updates []*StatusUpdate `json:"updates"`
+}
+
+// StatusUpdate is a newer version of a locked dependency.
+type StatusUpdate struct {
+	Name    string `json:"name"`
+	Current string `json:"current"`
+	Latest  string `json:"latest"`
+}
+
+// Problems returns how many problems were found.
+func (r *StatusReport) Problems() int {
+	return len(r.Lock) + len(r.Missing) + len(r.Extra) + len(r.Stale) + len(r.Modified)
+}
+
+// Status reports whether the checkout of a project is healthy: whether
+// glide.lock matches glide.yaml, whether the vendor directory holds the
+// packages and versions in glide.lock, which vendored dependencies were
+// changed locally, and how many dependencies have newer versions within their
+// constraints. Newer versions are found from the tags in the cache or else
+// from the API of the host. Glide exits non-zero when a problem is found.
+//
+// Params:
+//  - format (string): The format to output (text, json, json-pretty)
+func Status(format string) {
+	conf := EnsureConfig()
+	yamlpath, err := gpath.Glide()
+	if err != nil {
+		msg.Die("Failed to find %s file in directory tree: %s", gpath.GlideFile, err)
+	}
+	base := filepath.Dir(yamlpath)
+	vpath, err := gpath.Vendor()
+	if err != nil {
+		msg.Die("Unable to find the vendor directory: %s", err)
+	}
+
+	report := projectStatus(conf, base, vpath)
+
+	switch format {
+	case textFormat:
+		printStatus(report)
+	case jsonFormat:
+		json.NewEncoder(msg.Default.Stdout).Encode(report)
+	case jsonPrettyFormat:
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			msg.Die("could not marshal the status: %s", err)
+		}
+		msg.Print(string(b) + "\n")
+	default:
+		msg.Die("invalid output format: must be one of: json|json-pretty|text")
+	}
+
+	if !report.Healthy {
+		msg.Die("Found %d problem(s)", report.Problems())
+	}
+}
+
+// projectStatus collects the status of the project in base with its vendor
+// directory at vpath.
+func projectStatus(conf *cfg.Config, base, vpath string) *StatusReport {
+	r := &StatusReport{
+		Lock:     []string{},
+		Missing:  []string{},
+		Extra:    []string{},
+		Stale:    []string{},
+		Modified: []string{},
+		Updates:  []*StatusUpdate{},
+	}
+
+	if !gpath.HasLock(base) {
+		r.Lock = append(r.Lock, fmt.Sprintf("There is no %s file", gpath.LockFile))
+		return r
+	}
+	lock, err := cfg.ReadLockFile(filepath.Join(base, gpath.LockFile))
+	if err != nil {
+		r.Lock = append(r.Lock, fmt.Sprintf("Unable to read %s: %s", gpath.LockFile, err))
+		return r
+	}
+	r.Lock = append(r.Lock, lockProblems(conf, lock)...)
+
+	var names []string
+	for _, l := range lock.Imports {
+		names = append(names, l.Name)
+	}
+	// Test dependencies in a vendor directory of their own are not looked
+	// for in this one.
+	if gpath.TestVendorDir == "" {
+		for _, l := range lock.DevImports {
+			names = append(names, l.Name)
+		}
+	}
+	missing, extra, err := vendorLockDiff(vpath, names, conf.Ignore)
+	if err != nil {
+		r.Lock = append(r.Lock, fmt.Sprintf("Unable to read %s: %s", gpath.VendorDir, err))
+	}
+	r.Missing = append(r.Missing, missing...)
+	r.Extra = append(r.Extra, extra...)
+	r.Stale = append(r.Stale, staleVendored(lock, missing)...)
+	r.Modified = append(r.Modified, modifiedVendored(vpath, missing)...)
+	r.Updates = append(r.Updates, pendingUpdates(conf, lock)...)
+
+	r.Healthy = r.Problems() == 0
+	return r
+}
+
+// staleVendored returns the locked dependencies the last install or update
+// vendored at another version, leaving out those missing.
+func staleVendored(lock *cfg.Lockfile, missing []string) []string {
+	state, err := readVendorState()
+	if err != nil {
+		msg.Debug("No record of previously vendored dependencies: %s", err)
+		return nil
+	}
+	gone := make(map[string]bool)
+	for _, n := range missing {
+		gone[n] = true
+	}
+
+	var stale []string
+	for _, l := range append(append(cfg.Locks{}, lock.Imports...), lock.DevImports...) {
+		if v := state.Imports.Get(l.Name); v != nil && !gone[l.Name] && !sameLock(l, v) {
+			stale = append(stale, l.Name)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// modifiedVendored returns the vendored dependencies whose files no longer
+// have the hash recorded by the last install or update, leaving out those
+// missing.
+func modifiedVendored(vpath string, missing []string) []string {
+	sums, err := readVendorSums(vpath)
+	if err != nil {
+		msg.Debug("No record of the vendored files: %s", err)
+		return nil
+	}
+	gone := make(map[string]bool)
+	for _, n := range missing {
+		gone[n] = true
+	}
+
+	var modified []string
+	for name, sum := range sums {
+		if gone[name] {
+			continue
+		}
+		dir := filepath.Join(vpath, filepath.FromSlash(name))
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+		if h, err := dirHash(dir); err != nil || h != sum {
+			modified = append(modified, name)
+		}
+	}
+	sort.Strings(modified)
+	return modified
+}
+
+// pendingUpdates returns the locked dependencies of conf that have a newer
+// release within the constraint of glide.yaml. Dependencies without a
+// constraint or locked to a commit no release points at are left out.
+func pendingUpdates(conf *cfg.Config, lock *cfg.Lockfile) []*StatusUpdate {
+	var updates []*StatusUpdate
+	for _, d := range append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...) {
+		l := lock.Imports.Get(d.Name)
+		if l == nil {
+			l = lock.DevImports.Get(d.Name)
+		}
+		if l == nil || d.Reference == "" || conf.HasIgnore(d.Name) {
+			continue
+		}
+		con, err := cfg.NewConstraint(d.Reference)
+		if err != nil {
+			continue
+		}
+
+		dep := cfg.DependencyFromLock(l)
+		at, err := cachedTags(dep, l.Version)
+		if err != nil {
+			msg.Debug("Unable to find the version of %s: %s", d.Name, err)
+			continue
+		}
+		current := latestRelease(versionsOf(d, at))
+		if current == "" {
+			continue
+		}
+		tags, err := releaseTags(dep)
+		if err != nil {
+			msg.Debug("Unable to list the releases of %s: %s", d.Name, err)
+			continue
+		}
+
+		cv, _ := semver.NewVersion(current)
+		var newest *semver.Version
+		var name string
+		for _, t := range versionsOf(d, tags) {
+			v, err := semver.NewVersion(t)
+			if err != nil || v.Prerelease() != "" || !con.Check(v) || !v.GreaterThan(cv) {
+				continue
+			}
+			if newest == nil || v.GreaterThan(newest) {
+				newest, name = v, t
+			}
+		}
+		if newest != nil {
+			updates = append(updates, &StatusUpdate{Name: d.Name, Current: d.TagPrefix + current, Latest: d.TagPrefix + name})
+		}
+	}
+	return updates
+}
+
+// versionsOf returns the versions named by the tags of a dependency, leaving
+// out those without its tag prefix.
+func versionsOf(d *cfg.Dependency, tags []string) []string {
+	var versions []string
+	for _, t := range tags {
+		if v, ok := d.TagVersion(t); ok {
+			versions = append(versions, v)
+		}
+	}
+	return versions
+}
+
+// releaseTags returns the tags of a dependency from the copy of its repo in
+// the cache or, when it is not cached, the API of its host.
+func releaseTags(d *cfg.Dependency) ([]string, error) {
+	repo, err := cachedRepo(d)
+	if err != nil {
+		refs, herr := hosting.ListRefs(d.Remote())
+		if herr != nil {
+			return nil, err
+		}
+		return refs.TagNames(), nil
+	}
+	return repo.Tags()
+}
+
+// printStatus prints the status as text.
+func printStatus(r *StatusReport) {
+	if len(r.Lock) == 0 {
+		msg.Puts("%s matches %s: yes", gpath.LockFile, gpath.GlideFile)
+	} else {
+		msg.Puts("%s matches %s: no", gpath.LockFile, gpath.GlideFile)
+		for _, p := range r.Lock {
+			msg.Puts("  %s", p)
+		}
+	}
+
+	if len(r.Missing) == 0 && len(r.Extra) == 0 && len(r.Stale) == 0 {
+		msg.Puts("%s matches %s: yes", gpath.VendorDir, gpath.LockFile)
+	} else {
+		msg.Puts("%s matches %s: no", gpath.VendorDir, gpath.LockFile)
+		for _, n := range r.Missing {
+			msg.Puts("  missing %s", n)
+		}
+		for _, n := range r.Extra {
+			msg.Puts("  not locked %s", n)
+		}
+		for _, n := range r.Stale {
+			msg.Puts("  another version of %s", n)
+		}
+	}
+
+	msg.Puts("Modified vendored packages: %d", len(r.Modified))
+	for _, n := range r.Modified {
+		msg.Puts("  %s", n)
+	}
+
+	msg.Puts("Pending updates: %d", len(r.Updates))
+	for _, u := range r.Updates {
+		msg.Puts("  %s %s -> %s", u.Name, u.Current, u.Latest)
+	}
+}
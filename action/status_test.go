@@ -0,0 +1,52 @@
+package action
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestModifiedVendored(t *testing.T) {
+	vpath, err := ioutil.TempDir("", "glide-status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(vpath)
+
+	write := func(name, content string) {
+		p := filepath.Join(vpath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("github.com/foo/same/same.go", "package same\n")
+	write("github.com/foo/changed/changed.go", "package changed\n")
+	write("github.com/foo/added/added.go", "package added\n")
+
+	var sums string
+	for _, n := range []string{"github.com/foo/same", "github.com/foo/changed", "github.com/foo/added", "github.com/foo/gone"} {
+		h, err := dirHash(filepath.Join(vpath, filepath.FromSlash(n)))
+		if err != nil {
+			h = "0"
+		}
+		sums += fmt.Sprintf("%s  %s\n", h, n)
+	}
+	write(vendorSumFile, sums)
+
+	if got := modifiedVendored(vpath, nil); len(got) != 0 {
+		t.Errorf("Expected nothing modified right after recording, got %v", got)
+	}
+
+	write("github.com/foo/changed/changed.go", "package changed // edited\n")
+	write("github.com/foo/added/new.go", "package added\n")
+	got := modifiedVendored(vpath, []string{"github.com/foo/gone"})
+	if want := []string{"github.com/foo/added", "github.com/foo/changed"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v modified, got %v", want, got)
+	}
+}
@@ -0,0 +1,36 @@
+package action
+
+import (
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// aliasVendored rewrites the imports of packages aliased to standard library
+// packages in the stdAliases of conf, such as golang.org/x/net/context, to the
+// standard library package in the vendored packages. The aliased packages are
+// ignored so they are not vendored to be imported from there.
+func aliasVendored(conf *cfg.Config) {
+	if len(conf.StdAliases) == 0 {
+		return
+	}
+	vpath, err := gpath.Vendor()
+	if err != nil {
+		return
+	}
+	n, err := aliasTree(vpath, conf.StdAliases)
+	if err != nil {
+		msg.Err("Unable to rewrite the imports of aliased packages: %s", err)
+	} else if n > 0 {
+		msg.Info("Rewrote the imports of packages aliased to the standard library in %d vendored file(s)", n)
+	}
+}
+
+// aliasTree rewrites the imports of aliased packages in the Go files below dir
+// and returns the number of files changed.
+func aliasTree(dir string, aliases map[string]string) (int, error) {
+	return walkGoFiles(dir, "", func(imp string) (string, bool) {
+		to, ok := aliases[imp]
+		return to, ok
+	}, nil)
+}
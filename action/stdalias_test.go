@@ -0,0 +1,51 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAliasTree(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-alias-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	vendor := filepath.Join(dir, "vendor")
+	pkg := filepath.Join(vendor, "github.com", "example", "lib")
+	if err := os.MkdirAll(pkg, 0755); err != nil {
+		t.Fatal(err)
+	}
+	src := `package lib
+
+import (
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+var _ = ctxhttp.Get
+var _ context.Context
+`
+	f := filepath.Join(pkg, "lib.go")
+	if err := ioutil.WriteFile(f, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	checkCached := linkCached(t, f)
+
+	n, err := aliasTree(vendor, map[string]string{"golang.org/x/net/context": "context"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 file rewritten, got %d", n)
+	}
+	b, _ := ioutil.ReadFile(f)
+	if !strings.Contains(string(b), "\t\"context\"\n") || !strings.Contains(string(b), "\"golang.org/x/net/context/ctxhttp\"") {
+		t.Errorf("Unexpected rewritten file:\n%s", b)
+	}
+	checkCached(src)
+}
@@ -0,0 +1,132 @@
+package action
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/glide/repo"
+)
+
+// stripLockFile is stored in the vendor directory and records, in the lock
+// file format, the dependencies that had nested vendor directories stripped.
+const stripLockFile = ".glide-strip.lock"
+
+// reuseStripped tells the installer to keep the vendored copies of the
+// dependencies that were already stripped and have not changed since. The
+// versions are compared against the lock recorded by the last strip. Nothing
+// is reused when the installer is forced.
+func reuseStripped(installer *repo.Installer, conf *cfg.Config) {
+	if installer.Force {
+		return
+	}
+
+	vpath, err := gpath.Vendor()
+	if err != nil {
+		return
+	}
+	last, err := cfg.ReadLockFile(filepath.Join(vpath, stripLockFile))
+	if err != nil {
+		msg.Debug("No record of previously stripped dependencies: %s", err)
+		return
+	}
+
+	installer.Reuse = unchangedDeps(strippedDeps(installer, conf), last)
+}
+
+// stripVendorPolicy returns whether to strip nested vendor directories. They
+// are kept, whatever the flags, when the project sets nested: keep-nested.
+func stripVendorPolicy(conf *cfg.Config, stripVendor bool) bool {
+	if stripVendor && conf.NestedPolicy() == cfg.NestedKeep {
+		msg.Warn("Not stripping nested vendor directories as %s sets nested: %s", gpath.GlideFile, cfg.NestedKeep)
+		return false
+	}
+	return stripVendor
+}
+
+// stripNestedVendor removes nested vendor and Godeps/_workspace directories.
+// Only the dependencies not reused from the previous vendor directory are
+// processed, several at once. Afterwards the stripped versions are recorded
+// for the next run.
+func stripNestedVendor(installer *repo.Installer, conf *cfg.Config) {
+	msg.Info("Removing nested vendor and Godeps/_workspace directories...")
+	deps := strippedDeps(installer, conf)
+
+	names := make([]string, 0, len(deps))
+	for _, d := range deps {
+		if !installer.Reuse[d.Name] && !conf.HasIgnore(d.Name) {
+			names = append(names, d.Name)
+		}
+	}
+	if len(installer.Reuse) > 0 {
+		msg.Debug("Skipping %d unchanged dependencies", len(deps)-len(names))
+	}
+	if err := gpath.StripVendorDeps(names); err != nil {
+		msg.Err("Unable to strip vendor directories: %s", err)
+		return
+	}
+
+	lock, err := cfg.NewLockfile(deps, nil, "")
+	if err != nil {
+		msg.Debug("Unable to record the stripped dependencies: %s", err)
+		return
+	}
+	vpath, err := gpath.Vendor()
+	if err != nil {
+		return
+	}
+	if err := lock.WriteFile(filepath.Join(vpath, stripLockFile)); err != nil {
+		msg.Debug("Unable to record the stripped dependencies: %s", err)
+	}
+}
+
+// strippedDeps returns the dependencies that are placed in the vendor
+// directory by the installer.
+func strippedDeps(installer *repo.Installer, conf *cfg.Config) cfg.Dependencies {
+	deps := make(cfg.Dependencies, 0, len(conf.Imports)+len(conf.DevImports))
+	deps = append(deps, conf.Imports...)
+	if installer.ResolveTest {
+		for _, d := range conf.DevImports {
+			if conf.Imports.Get(d.Name) == nil {
+				deps = append(deps, d)
+			}
+		}
+	}
+	return deps
+}
+
+// unchangedDeps returns the dependencies locked at the same version and
+// repository as in lock. A dependency nested within another one is never
+// unchanged because their vendored copies cannot be moved separately.
+func unchangedDeps(deps cfg.Dependencies, lock *cfg.Lockfile) map[string]bool {
+	same := make(map[string]bool)
+	for _, d := range deps {
+		l := lock.Imports.Get(d.Name)
+		if l == nil || d.Pin == "" || l.Version != d.Pin || l.Repository != d.Repository || l.Submodules != d.Submodules {
+			continue
+		}
+		// Copies with only some subpackages are only reused for the same ones.
+		if l.SubpackagesOnly != d.SubpackagesOnly || (d.SubpackagesOnly && !reflect.DeepEqual(l.Subpackages, d.Subpackages)) {
+			continue
+		}
+		if nestedDep(d.Name, deps) {
+			continue
+		}
+		same[d.Name] = true
+	}
+	return same
+}
+
+// nestedDep returns true if the name contains or is contained in another
+// dependency.
+func nestedDep(name string, deps cfg.Dependencies) bool {
+	for _, d := range deps {
+		if strings.HasPrefix(d.Name, name+"/") || strings.HasPrefix(name, d.Name+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/Masterminds/glide/cfg"
+)
+
+func TestUnchangedDeps(t *testing.T) {
+	deps := cfg.Dependencies{
+		&cfg.Dependency{Name: "github.com/Masterminds/semver", Pin: "aaa"},
+		&cfg.Dependency{Name: "github.com/Masterminds/vcs", Pin: "ccc"},
+		&cfg.Dependency{Name: "github.com/Masterminds/cookoo", Pin: "ddd", Repository: "https://example.com/cookoo"},
+		&cfg.Dependency{Name: "github.com/foo/bar", Pin: "eee"},
+		&cfg.Dependency{Name: "github.com/foo/bar/baz", Pin: "fff"},
+		&cfg.Dependency{Name: "github.com/foo/new", Pin: "ggg"},
+	}
+	lock := &cfg.Lockfile{
+		Imports: cfg.Locks{
+			&cfg.Lock{Name: "github.com/Masterminds/semver", Version: "aaa"},
+			&cfg.Lock{Name: "github.com/Masterminds/vcs", Version: "bbb"},
+			&cfg.Lock{Name: "github.com/Masterminds/cookoo", Version: "ddd"},
+			&cfg.Lock{Name: "github.com/foo/bar", Version: "eee"},
+			&cfg.Lock{Name: "github.com/foo/bar/baz", Version: "fff"},
+		},
+	}
+
+	same := unchangedDeps(deps, lock)
+	if len(same) != 1 || !same["github.com/Masterminds/semver"] {
+		t.Errorf("Expected only semver to be unchanged, got %v", same)
+	}
+}
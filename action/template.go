@@ -0,0 +1,124 @@
+package action
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/mirrors"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// commitRe matches references that look like commit ids.
+var commitRe = regexp.MustCompile(`^[0-9a-f]{12,40}$`)
+
+// ExportTemplate writes a base glide.yaml built from the conventions of the
+// current project so other projects can start from the same policy.
+//
+// When out is empty the template is printed to Stdout.
+func ExportTemplate(out string) {
+	conf := EnsureConfig()
+	tpl := templateFromConfig(conf)
+	op := filepath.Join(gpath.Home(), "mirrors.yaml")
+	if _, err := os.Stat(op); err == nil {
+		mr, err := mirrors.ReadMirrorsFile(op)
+		if err != nil {
+			msg.Warn("Unable to read mirrors.yaml file: %s", err)
+		} else {
+			templateMirrors(tpl, mr)
+		}
+	}
+
+	yml, err := tpl.Marshal()
+	if err != nil {
+		msg.Die("Unable to generate the template: %s", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("# Template generated by Glide from " + conf.Name + "\n")
+	buf.Write(yml)
+
+	if out == "" {
+		msg.Print(buf.String())
+		return
+	}
+	if err := ioutil.WriteFile(out, buf.Bytes(), 0666); err != nil {
+		msg.Die("Unable to write template to %s: %s", out, err)
+	}
+	msg.Info("Template written to %s", out)
+}
+
+// templateFromConfig distills the parts of a config that are reusable across
+// projects. Project specific details, such as the name and commit id pins,
+// are left out.
+func templateFromConfig(conf *cfg.Config) *cfg.Config {
+	tpl := &cfg.Config{
+		Ignore:         conf.Ignore,
+		Exclude:        conf.Exclude,
+		Nested:         conf.Nested,
+		OptionalGroups: conf.OptionalGroups,
+		SyncGroups:     conf.SyncGroups,
+		StdAliases:     conf.StdAliases,
+		Policy:         conf.Policy,
+		Go:             conf.Go,
+	}
+	tpl.Mirrors = append(tpl.Mirrors, conf.Mirrors...)
+	tpl.Imports = templateDeps(conf.Imports)
+	tpl.DevImports = templateDeps(conf.DevImports)
+	tpl.Overrides = templateDeps(conf.Overrides)
+	return tpl
+}
+
+func templateDeps(deps cfg.Dependencies) cfg.Dependencies {
+	n := make(cfg.Dependencies, 0, len(deps))
+	for _, d := range deps {
+		t := &cfg.Dependency{
+			Name:       d.Name,
+			Reference:  d.Reference,
+			Repository: d.Repository,
+			VcsType:    d.VcsType,
+			Arch:       d.Arch,
+			Os:         d.Os,
+			Submodules: d.Submodules,
+		}
+		if commitRe.MatchString(t.Reference) {
+			msg.Debug("Leaving the commit id pin for %s out of the template", d.Name)
+			t.Reference = ""
+		}
+		n = append(n, t)
+	}
+	return n
+}
+
+// templateMirrors adds the mirrors from GLIDE_HOME used by the dependencies in
+// the template to its mirrors, so projects started from it use them too. The
+// mirrors of the project take precedence.
+func templateMirrors(tpl *cfg.Config, mr *mirrors.Mirrors) {
+	deps := append(append(cfg.Dependencies{}, tpl.Imports...), tpl.DevImports...)
+	deps = append(deps, tpl.Overrides...)
+	for _, r := range mr.Repos {
+		if templateHasMirror(tpl, r.Original) {
+			continue
+		}
+		for _, d := range deps {
+			if r.Original != d.Repository && r.Original != "https://"+d.Name {
+				continue
+			}
+			tpl.Mirrors = append(tpl.Mirrors, r)
+			break
+		}
+	}
+}
+
+func templateHasMirror(tpl *cfg.Config, original string) bool {
+	for _, m := range tpl.Mirrors {
+		if m.Original == original {
+			return true
+		}
+	}
+	return false
+}
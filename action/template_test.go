@@ -0,0 +1,96 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/mirrors"
+)
+
+func TestTemplateFromConfig(t *testing.T) {
+	conf := &cfg.Config{
+		Name:   "example.com/foo",
+		Ignore: []string{"appengine"},
+		Imports: cfg.Dependencies{
+			&cfg.Dependency{Name: "github.com/Masterminds/semver", Reference: "^1.2.0", Subpackages: []string{"foo"}},
+			&cfg.Dependency{Name: "github.com/Masterminds/vcs", Reference: "3084677c2c188840777bff30054f2b553729d329"},
+		},
+		Overrides: cfg.Dependencies{
+			&cfg.Dependency{Name: "github.com/pkg/errors", Reference: "v0.8.0", Repository: "https://example.com/errors"},
+		},
+		Mirrors: mirrors.MirrorRepos{
+			&mirrors.MirrorRepo{Original: "https://github.com/Masterminds/vcs", Repo: "https://example.com/vcs"},
+		},
+		Nested:         "skip",
+		OptionalGroups: []string{"metrics"},
+		SyncGroups:     cfg.SyncGroups{&cfg.SyncGroup{Name: "k8s", Packages: []string{"k8s.io/api", "k8s.io/client-go"}}},
+		StdAliases:     map[string]string{"context": "golang.org/x/net/context"},
+		Policy:         &cfg.Policy{Deny: []string{"github.com/bad/*"}},
+		Go:             ">=1.7",
+	}
+
+	tpl := templateFromConfig(conf)
+	if tpl.Name != "" {
+		t.Errorf("Expected the template to have no name, got %s", tpl.Name)
+	}
+	if len(tpl.Ignore) != 1 || tpl.Ignore[0] != "appengine" {
+		t.Errorf("Expected the ignore list to be kept, got %v", tpl.Ignore)
+	}
+	d := tpl.Imports.Get("github.com/Masterminds/semver")
+	if d == nil || d.Reference != "^1.2.0" || len(d.Subpackages) != 0 {
+		t.Errorf("Unexpected template dependency %+v", d)
+	}
+	d = tpl.Imports.Get("github.com/Masterminds/vcs")
+	if d == nil || d.Reference != "" {
+		t.Errorf("Expected commit id pin to be removed, got %+v", d)
+	}
+
+	yml, err := tpl.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt, err := cfg.ConfigFromYaml(yml)
+	if err != nil {
+		t.Fatalf("Unable to read the template back: %s", err)
+	}
+	if o := rt.Overrides.Get("github.com/pkg/errors"); o == nil || o.Reference != "v0.8.0" || o.Repository != "https://example.com/errors" {
+		t.Errorf("Expected the override to be kept, got %+v", o)
+	}
+	if len(rt.Mirrors) != 1 || rt.Mirrors[0].Repo != "https://example.com/vcs" {
+		t.Errorf("Expected the project mirrors to be kept, got %v", rt.Mirrors)
+	}
+	if rt.Nested != "skip" || rt.Go != ">=1.7" || rt.StdAliases["context"] != "golang.org/x/net/context" {
+		t.Errorf("Unexpected nested, go, or stdAliases in %s", yml)
+	}
+	if len(rt.OptionalGroups) != 1 || rt.SyncGroups.Get("k8s") == nil || rt.Policy == nil || len(rt.Policy.Deny) != 1 {
+		t.Errorf("Expected the groups and policy to be kept in %s", yml)
+	}
+}
+
+func TestTemplateMirrors(t *testing.T) {
+	tpl := &cfg.Config{
+		Imports: cfg.Dependencies{
+			&cfg.Dependency{Name: "github.com/Masterminds/semver"},
+			&cfg.Dependency{Name: "github.com/Masterminds/vcs"},
+		},
+		Mirrors: mirrors.MirrorRepos{
+			&mirrors.MirrorRepo{Original: "https://github.com/Masterminds/vcs", Repo: "https://example.com/vcs"},
+		},
+	}
+	mr := &mirrors.Mirrors{Repos: mirrors.MirrorRepos{
+		&mirrors.MirrorRepo{Original: "https://github.com/Masterminds/semver", Repo: "https://example.com/semver"},
+		&mirrors.MirrorRepo{Original: "https://github.com/Masterminds/vcs", Repo: "https://mirror.example.com/vcs"},
+		&mirrors.MirrorRepo{Original: "https://github.com/unused/dep", Repo: "https://example.com/dep"},
+	}}
+
+	templateMirrors(tpl, mr)
+	if len(tpl.Mirrors) != 2 {
+		t.Fatalf("Expected 2 mirrors, got %d", len(tpl.Mirrors))
+	}
+	if tpl.Mirrors[0].Repo != "https://example.com/vcs" {
+		t.Errorf("Expected the project mirror to take precedence, got %s", tpl.Mirrors[0].Repo)
+	}
+	if tpl.Mirrors[1].Repo != "https://example.com/semver" {
+		t.Errorf("Expected the mirror of a dependency to be added, got %s", tpl.Mirrors[1].Repo)
+	}
+}
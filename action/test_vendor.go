@@ -0,0 +1,116 @@
+package action
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// mergeTestVendor links the dependencies in the vendor directory for tests,
+// set with testVendorDir, into the vendor directory so tests find them. It
+// returns a function removing the links again, to call once the tests ran.
+//
+// The project is locked while the links exist so other Glide processes, such
+// as a second run of the tests, neither see nor remove them. They are also
+// removed when Glide is interrupted, and those left by a run that was killed
+// are removed first.
+func mergeTestVendor() func() {
+	tvp, err := gpath.VendorForTests()
+	if err != nil || tvp == "" {
+		return func() {}
+	}
+	vpath, err := gpath.Vendor()
+	if err != nil {
+		return func() {}
+	}
+	base := filepath.Dir(vpath)
+	lock, err := cfg.ReadLockFile(filepath.Join(base, gpath.LockFile))
+	if err != nil {
+		msg.Debug("Not merging %s into %s: %s", gpath.TestVendorDir, gpath.VendorDir, err)
+		return func() {}
+	}
+
+	plock := filepath.Join(base, gpath.ProjectLockFile)
+	if err := cache.LockFile(plock, "the project"); err != nil {
+		msg.Die("Unable to lock the project: %s", err)
+	}
+	unmergeVendor(tvp, vpath, lock)
+	mergeVendor(tvp, vpath, lock)
+	unregister := cache.OnInterrupt(func() {
+		unmergeVendor(tvp, vpath, lock)
+	})
+	return func() {
+		unregister()
+		unmergeVendor(tvp, vpath, lock)
+		cache.UnlockFile(plock)
+	}
+}
+
+// mergeVendor links the test dependencies of lock found in tvp into vpath,
+// unless vpath has them already. It returns the links made.
+func mergeVendor(tvp, vpath string, lock *cfg.Lockfile) []string {
+	var links []string
+	for _, l := range lock.DevImports {
+		if lock.Imports.Get(l.Name) != nil {
+			continue
+		}
+		src := filepath.Join(tvp, filepath.FromSlash(l.Name))
+		dest := filepath.Join(vpath, filepath.FromSlash(l.Name))
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		if _, err := os.Lstat(dest); err == nil {
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			msg.Warn("Unable to link %s for tests: %s", l.Name, err)
+			continue
+		}
+		if abs, err := filepath.Abs(src); err == nil {
+			src = abs
+		}
+		if err := os.Symlink(src, dest); err != nil {
+			msg.Warn("Unable to link %s for tests: %s", l.Name, err)
+			continue
+		}
+		msg.Debug("Linked %s into %s for tests", l.Name, gpath.VendorDir)
+		links = append(links, dest)
+	}
+	return links
+}
+
+// unmergeVendor removes the links mergeVendor made into vpath for the test
+// dependencies of lock, along with the directories left empty by them.
+func unmergeVendor(tvp, vpath string, lock *cfg.Lockfile) {
+	if abs, err := filepath.Abs(tvp); err == nil {
+		tvp = abs
+	}
+	for _, l := range lock.DevImports {
+		if lock.Imports.Get(l.Name) != nil {
+			continue
+		}
+		dest := filepath.Join(vpath, filepath.FromSlash(l.Name))
+		fi, err := os.Lstat(dest)
+		if err != nil || !gpath.IsLink(fi) {
+			continue
+		}
+		if src, err := os.Readlink(dest); err != nil || !strings.HasPrefix(src, tvp+string(os.PathSeparator)) {
+			continue
+		}
+		if err := os.Remove(dest); err != nil {
+			msg.Warn("Unable to remove the link %s: %s", dest, err)
+			continue
+		}
+		for p := filepath.Dir(dest); p != vpath && p != filepath.Dir(p); p = filepath.Dir(p) {
+			if os.Remove(p) != nil {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/glide/cfg"
+)
+
+func TestMergeVendor(t *testing.T) {
+	base, err := ioutil.TempDir("", "glide-test-vendor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(base)
+	vpath := filepath.Join(base, "vendor")
+	tvp := filepath.Join(base, "vendor-test")
+	for _, d := range []string{
+		filepath.Join(vpath, "github.com", "foo", "shared"),
+		filepath.Join(tvp, "github.com", "foo", "shared"),
+		filepath.Join(tvp, "github.com", "foo", "fixture"),
+		filepath.Join(tvp, "gopkg.in", "check.v1"),
+	} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lock := &cfg.Lockfile{
+		Imports: cfg.Locks{{Name: "github.com/foo/shared"}},
+		DevImports: cfg.Locks{
+			{Name: "github.com/foo/shared"},
+			{Name: "github.com/foo/fixture"},
+			{Name: "gopkg.in/check.v1"},
+			{Name: "github.com/foo/missing"},
+		},
+	}
+
+	links := mergeVendor(tvp, vpath, lock)
+	if len(links) != 2 {
+		t.Fatalf("Expected 2 links, got %v", links)
+	}
+	for _, l := range links {
+		if fi, err := os.Lstat(l); err != nil || fi.Mode()&os.ModeSymlink == 0 {
+			t.Errorf("Expected %s to be a link", l)
+		}
+	}
+	if fi, err := os.Lstat(filepath.Join(vpath, "github.com", "foo", "shared")); err != nil || fi.Mode()&os.ModeSymlink != 0 {
+		t.Error("Expected the dependency shared with the production code to be left alone")
+	}
+
+	// Links left by a run that was killed are removed the same way, along
+	// with the directories made for them, and nothing else.
+	mergeVendor(tvp, vpath, lock)
+	unmergeVendor(tvp, vpath, lock)
+	for _, l := range links {
+		if _, err := os.Lstat(l); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed", l)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(vpath, "gopkg.in")); !os.IsNotExist(err) {
+		t.Error("Expected the gopkg.in directory made for a link to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(vpath, "github.com", "foo", "shared")); err != nil {
+		t.Error("Expected the dependency shared with the production code to be kept")
+	}
+}
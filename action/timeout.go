@@ -0,0 +1,72 @@
+package action
+
+import (
+	"time"
+
+	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/msg"
+	"github.com/Masterminds/glide/repo"
+)
+
+// Timeout stops Glide if the running command does not complete within the
+// given duration. The dependencies with operations still in progress are
+// reported before exiting. A zero duration disables the timeout.
+func Timeout(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	time.AfterFunc(d, func() {
+		msg.Err("Command did not complete within %s", d)
+		pending := repo.Pending()
+		if len(pending) > 0 {
+			msg.Err("Operations were still pending for:")
+			for _, p := range pending {
+				msg.Err("--> %s", p)
+			}
+		}
+		cache.ReleaseLocks()
+		msg.Die("Timed out")
+	})
+}
+
+// Timeouts sets the limits for the command and its operations from the
+// timeouts in config.yaml. A duration that is not zero, set with a flag or
+// environment variable, takes precedence over the setting.
+func Timeouts(command, resolve, fetch, attempt, export time.Duration) {
+	d, err := repo.LoadTimeouts()
+	if err != nil {
+		msg.Err("Unable to load the timeouts: %s", err)
+	}
+	if command > 0 {
+		d = command
+	}
+	if resolve > 0 {
+		repo.ResolveTimeout = resolve
+	}
+	if fetch > 0 {
+		repo.FetchTimeout = fetch
+	}
+	if attempt > 0 {
+		repo.NetworkTimeout = attempt
+	}
+	if export > 0 {
+		repo.ExportTimeout = export
+	}
+	Timeout(d)
+}
+
+// Network sets the limits for network operations on repositories from the
+// network settings in config.yaml. Retries or a host concurrency that is not
+// zero takes precedence over the setting.
+func Network(retries, hostConcurrency int) {
+	if err := repo.LoadNetworkSettings(); err != nil {
+		msg.Err("Unable to load the network settings: %s", err)
+	}
+	if retries > 0 {
+		repo.NetworkRetries = retries
+	}
+	if hostConcurrency > 0 {
+		repo.HostConcurrency = hostConcurrency
+	}
+}
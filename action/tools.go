@@ -0,0 +1,165 @@
+package action
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/glide/repo"
+)
+
+// ToolBinDir is the directory of the project the tools are built into.
+const ToolBinDir = "bin"
+
+// ToolsInstall builds the tools listed in glide.yaml into the bin directory
+// of the project at the versions in glide.lock. A tool is a repo whose
+// subpackages, or root when it has none, are the commands to build. Tools missing from the lock
+// file are built at the newest version matching their constraint.
+//
+// Params:
+//  - basedir (string): the project directory
+func ToolsInstall(basedir string) {
+	conf := EnsureConfig()
+	if len(conf.Tools) == 0 {
+		msg.Info("No tools are listed in %s", gpath.GlideFile)
+		return
+	}
+	EnsureCacheLock()
+
+	var locked cfg.Locks
+	if gpath.HasLock(basedir) {
+		lock, err := cfg.ReadLockFile(filepath.Join(basedir, gpath.LockFile))
+		if err != nil {
+			msg.Die("Could not load lockfile: %s", err)
+		}
+		locked = lock.Tools
+	}
+	for _, t := range conf.Tools {
+		if locked.Get(t.Name) == nil {
+			msg.Warn("%s is not pinned in %s. Run 'glide update' to pin it", t.Name, gpath.LockFile)
+		}
+	}
+
+	tools, err := pinTools(conf.Tools, locked)
+	if err != nil {
+		msg.Die("Unable to fetch the tools: %s", err)
+	}
+
+	bin := filepath.Join(basedir, ToolBinDir)
+	if err := os.MkdirAll(bin, 0755); err != nil {
+		msg.Die("Unable to create %s: %s", bin, err)
+	}
+	for _, l := range tools {
+		if err := buildTool(l, bin); err != nil {
+			msg.Die("Unable to build %s: %s", l.Name, err)
+		}
+	}
+	msg.Info("Tools installed in %s", bin)
+}
+
+// pinTools fetches the tools into the cache and returns their locks. Tools
+// in locked are kept at their locked version and the others are set to the
+// newest version matching their constraint.
+func pinTools(tools cfg.Dependencies, locked cfg.Locks) (cfg.Locks, error) {
+	var locks cfg.Locks
+	for _, t := range tools {
+		d := t.Clone()
+		d.Pin = ""
+		if l := locked.Get(t.Name); l != nil {
+			d.Reference = l.Version
+		}
+
+		key, err := cache.Key(d.Remote())
+		if err != nil {
+			return nil, err
+		}
+		cache.Lock(key)
+		err = repo.VcsGet(d)
+		if err == nil {
+			err = repo.VcsVersion(d)
+		}
+		cache.Unlock(key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", t.Name, err)
+		}
+
+		locks = append(locks, cfg.LockFromDependency(d))
+	}
+	return locks, nil
+}
+
+// buildTool builds the commands of a locked tool into bin. The repo of the
+// tool is placed in a GOPATH of its own, ahead of the regular one, so the
+// packages it vendors are used and the rest come from the regular GOPATH.
+func buildTool(l *cfg.Lock, bin string) error {
+	tmp, err := ioutil.TempDir("", "glide-tool")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	d := cfg.DependencyFromLock(l)
+	d.Pin = l.Version
+	if err := repo.ExportDependency(d, filepath.Join(tmp, "src", filepath.FromSlash(d.Name))); err != nil {
+		return err
+	}
+
+	for _, pkg := range toolCommands(l) {
+		msg.Info("--> Building %s at %s", pkg, shortVersion(l.Version))
+		out, err := filepath.Abs(filepath.Join(bin, toolBinary(pkg)))
+		if err != nil {
+			return err
+		}
+		cmd := exec.Command(goExecutable(), "build", "-o", out, pkg)
+		cmd.Dir = tmp
+		cmd.Env = toolEnv(os.Environ(), tmp)
+		if o, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%s\n%s", err, o)
+		}
+	}
+	return nil
+}
+
+// toolCommands returns the main packages of a tool. They are its subpackages
+// or, when it has none, the root of its repo.
+func toolCommands(l *cfg.Lock) []string {
+	if len(l.Subpackages) == 0 {
+		return []string{l.Name}
+	}
+	cmds := make([]string, 0, len(l.Subpackages))
+	for _, sp := range l.Subpackages {
+		cmds = append(cmds, path.Join(l.Name, sp))
+	}
+	return cmds
+}
+
+// toolBinary returns the name of the binary built for a tool.
+func toolBinary(name string) string {
+	b := path.Base(name)
+	if runtime.GOOS == "windows" {
+		b += ".exe"
+	}
+	return b
+}
+
+// toolEnv returns env for building a tool with gopath ahead of the GOPATH
+// Glide uses.
+func toolEnv(env []string, gopath string) []string {
+	var out []string
+	for _, e := range goToolEnv(env) {
+		if !strings.HasPrefix(e, "GOPATH=") {
+			out = append(out, e)
+		}
+	}
+	gp := append([]string{gopath}, gpath.Gopaths()...)
+	return append(out, "GOPATH="+strings.Join(gp, string(filepath.ListSeparator)))
+}
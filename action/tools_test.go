@@ -0,0 +1,37 @@
+package action
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/glide/cfg"
+)
+
+func TestToolCommands(t *testing.T) {
+	l := &cfg.Lock{Name: "github.com/golang/lint"}
+	if c := toolCommands(l); !reflect.DeepEqual(c, []string{"github.com/golang/lint"}) {
+		t.Errorf("Expected the root to be built without subpackages, got %v", c)
+	}
+
+	l.Subpackages = []string{"golint", "cmd/other"}
+	expected := []string{"github.com/golang/lint/golint", "github.com/golang/lint/cmd/other"}
+	if c := toolCommands(l); !reflect.DeepEqual(c, expected) {
+		t.Errorf("Expected commands %v, got %v", expected, c)
+	}
+}
+
+func TestToolEnv(t *testing.T) {
+	env := toolEnv([]string{"HOME=/home/foo", "GOPATH=/go"}, "/tmp/tool")
+	var gopath []string
+	for _, e := range env {
+		if strings.HasPrefix(e, "GOPATH=") {
+			gopath = append(gopath, e)
+		}
+	}
+	expected := "GOPATH=/tmp/tool" + string(filepath.ListSeparator)
+	if len(gopath) != 1 || !strings.HasPrefix(gopath[0], expected) {
+		t.Errorf("Expected a single GOPATH starting with the tool GOPATH, got %v", gopath)
+	}
+}
@@ -1,60 +1,133 @@
 package action
 
 import (
+	"fmt"
 	"io/ioutil"
 	"path/filepath"
 
-	"github.com/Masterminds/glide/cache"
 	"github.com/Masterminds/glide/cfg"
 	"github.com/Masterminds/glide/msg"
 	gpath "github.com/Masterminds/glide/path"
 	"github.com/Masterminds/glide/repo"
+	"github.com/Masterminds/glide/util"
+	"github.com/Masterminds/semver"
 )
 
 // Update updates repos and the lock file from the main glide yaml.
-func Update(installer *repo.Installer, skipRecursive, stripVendor bool) {
-	cache.SystemLock()
+//
+// When only lists one or more packages, just those packages are updated. All
+// other dependencies are held at the versions recorded in the lock file.
+//
+// With changelog the dependencies that changed compared to the previous lock
+// file are printed along with the commits between their old and new versions.
+//
+// With PRBody set a Markdown summary of the changes is written for the
+// description of a pull request.
+//
+// With dryRun the dependency tree is resolved and the changes to the lock file
+// are printed, but neither vendor/ nor the lock file is touched. Glide exits
+// non-zero when anything would change.
+func Update(installer *repo.Installer, skipRecursive, stripVendor, changelog, dryRun bool, only []string) {
+	EnsureProjectLock()
+	EnsureCacheLock()
 
 	base := "."
 	EnsureGopath()
-	EnsureVendorDir()
-	conf := EnsureConfig()
+	if dryRun {
+		if skipRecursive {
+			msg.Die("A dry run requires resolving the full dependency tree. It cannot be combined with --no-recursive")
+		}
+	} else {
+		EnsureVendorDir()
+	}
+	conf := EnsureValidConfig()
+	enforceGoVersion(conf)
+	stripVendor = stripVendorPolicy(conf, stripVendor)
 
-	// Try to check out the initial dependencies.
-	if err := installer.Checkout(conf); err != nil {
-		msg.Die("Failed to do initial checkout of config: %s", err)
+	var prev *cfg.Lockfile
+	if (changelog || PRBody != "") && gpath.HasLock(base) {
+		l, err := cfg.ReadLockFile(filepath.Join(base, gpath.LockFile))
+		if err != nil {
+			msg.Warn("Unable to read the lock file to compare against: %s", err)
+		}
+		prev = l
 	}
 
-	// Set the versions for the initial dependencies so that resolved dependencies
-	// are rooted in the correct version of the base.
-	if err := repo.SetReference(conf, installer.ResolveTest); err != nil {
-		msg.Die("Failed to set initial config references: %s", err)
+	// The hash is generated before any locked versions are applied so it
+	// reflects the glide.yaml file rather than the frozen references.
+	hash, err := conf.Hash()
+	if err != nil {
+		msg.Die("Failed to generate config hash. Unable to generate lock file.")
 	}
 
-	// Prior to resolving dependencies we need to start working with a clone
-	// of the conf because we'll be making real changes to it.
-	confcopy := conf.Clone()
+	if len(only) > 0 {
+		conf = freezeDependencies(conf, installer, only, base)
+	}
 
-	if !skipRecursive {
-		// Get all repos and update them.
-		err := installer.Update(confcopy)
+	// A full resolution is used again while glide.yaml, the imports of the
+	// code, and the remotes it consulted are unchanged.
+	var rkey string
+	if !skipRecursive && len(only) == 0 {
+		rkey = resolutionKey(installer, conf, base, hash)
+	}
+
+	var confcopy *cfg.Config
+	var tools cfg.Locks
+	if cached := cachedResolution(rkey); cached != nil {
+		msg.Info("Nothing changed since the dependencies were last resolved. Using the cached resolution")
+		rkey = ""
+		tools = cached.Tools
+		confcopy, err = installer.Install(cached, conf)
 		if err != nil {
-			msg.Die("Could not update packages: %s", err)
+			msg.Die("Failed to install the cached resolution: %s", err)
 		}
-
-		// Set references. There may be no remaining references to set since the
-		// installer set them as it went to make sure it parsed the right imports
-		// from the right version of the package.
-		msg.Info("Setting references for remaining imports")
 		if err := repo.SetReference(confcopy, installer.ResolveTest); err != nil {
-			msg.Err("Failed to set references: %s (Skip to cleanup)", err)
+			msg.Die("Failed to set references: %s", err)
 		}
+	} else {
+		confcopy, tools = resolve(installer, conf, skipRecursive)
 	}
 
-	err := installer.Export(confcopy)
+	enforcePolicy(conf, append(append(cfg.Dependencies{}, confcopy.Imports...), confcopy.DevImports...))
+
+	if dryRun {
+		lock, err := cfg.NewLockfile(confcopy.Imports, confcopy.DevImports, hash)
+		if err != nil {
+			msg.Die("Failed to generate lock file: %s", err)
+		}
+		lock.Tools = tools
+		saveResolution(rkey, lock)
+		writePRBody(conf, prev, lock)
+		printPlan(conf, base, lock, changelog)
+		return
+	}
+
+	// The lock file records every dependency while only those in the selected
+	// groups are placed in the vendor directory.
+	vendored := configWithGroups(installer, confcopy)
+	if stripVendor {
+		reuseStripped(installer, vendored)
+	} else {
+		reuseVendor(installer, vendored)
+	}
+
+	// Patches are applied and import comments checked before the new vendor
+	// directory replaces the existing one, which is kept when they fail.
+	installer.Stage = func(vp string) error {
+		if err := applyPatches(conf, vendored, vp); err != nil {
+			return err
+		}
+		return enforceImportComments(vendored, vp)
+	}
+	err = installer.Export(vendored)
 	if err != nil {
 		msg.Die("Unable to export dependencies to vendor directory: %s", err)
 	}
+	writeVendorState(installer, vendored, hash)
+
+	enforceCacheLimits()
+	relocateVendored(conf)
+	aliasVendored(conf)
 
 	// Write glide.yaml (Why? Godeps/GPM/GB?)
 	// I think we don't need to write a new Glide file because update should not
@@ -67,14 +140,12 @@ func Update(installer *repo.Installer, skipRecursive, stripVendor bool) {
 
 	if !skipRecursive {
 		// Write lock
-		hash, err := conf.Hash()
-		if err != nil {
-			msg.Die("Failed to generate config hash. Unable to generate lock file.")
-		}
 		lock, err := cfg.NewLockfile(confcopy.Imports, confcopy.DevImports, hash)
 		if err != nil {
 			msg.Die("Failed to generate lock file: %s", err)
 		}
+		lock.Tools = tools
+		saveResolution(rkey, lock)
 		wl := true
 		if gpath.HasLock(base) {
 			yml, err := ioutil.ReadFile(filepath.Join(base, gpath.LockFile))
@@ -94,20 +165,229 @@ func Update(installer *repo.Installer, skipRecursive, stripVendor bool) {
 				msg.Err("Could not write lock file to %s: %s", base, err)
 				return
 			}
+			warnStaleSignature(base)
+			if changelog {
+				if prev == nil {
+					prev = &cfg.Lockfile{}
+				}
+				printChangelog(prev, lock)
+			}
 		} else {
 			msg.Info("Versions did not change. Skipping glide.lock update.")
 		}
+		writePRBody(conf, prev, lock)
 
 		msg.Info("Project relies on %d dependencies.", len(confcopy.Imports))
 	} else {
 		msg.Warn("Skipping lockfile generation because full dependency tree is not being calculated")
 	}
+	recordRedirects(base, conf)
 
 	if stripVendor {
-		msg.Info("Removing nested vendor and Godeps/_workspace directories...")
-		err := gpath.StripVendor()
+		stripNestedVendor(installer, vendored)
+	}
+	writeVendorSums(installer, vendored)
+	if !skipRecursive {
+		writeInstallDigest(installer, base, stripVendor)
+	}
+}
+
+// resolve checks out the dependencies of conf and, unless skipRecursive is
+// set, resolves the full dependency tree. It returns a copy of conf with the
+// versions to use set and the versions of the tools.
+func resolve(installer *repo.Installer, conf *cfg.Config, skipRecursive bool) (*cfg.Config, cfg.Locks) {
+	// Try to check out the initial dependencies.
+	if err := installer.Checkout(conf); err != nil {
+		msg.Die("Failed to do initial checkout of config: %s", err)
+	}
+
+	// Set the versions for the initial dependencies so that resolved dependencies
+	// are rooted in the correct version of the base.
+	if err := repo.SetReference(conf, installer.ResolveTest); err != nil {
+		msg.Die("Failed to set initial config references: %s", err)
+	}
+
+	// Prior to resolving dependencies we need to start working with a clone
+	// of the conf because we'll be making real changes to it.
+	confcopy := conf.Clone()
+
+	if !skipRecursive {
+		// Get all repos and update them.
+		err := installer.Update(confcopy)
+		if err != nil {
+			msg.Die("Could not update packages: %s", err)
+		}
+
+		// Set references. There may be no remaining references to set since the
+		// installer set them as it went to make sure it parsed the right imports
+		// from the right version of the package.
+		msg.Info("Setting references for remaining imports")
+		if err := repo.SetReference(confcopy, installer.ResolveTest); err != nil {
+			msg.Err("Failed to set references: %s (Skip to cleanup)", err)
+		}
+	}
+
+	var tools cfg.Locks
+	if !skipRecursive && len(conf.Tools) > 0 {
+		msg.Info("Setting versions for tools")
+		var err error
+		tools, err = pinTools(conf.Tools, nil)
+		if err != nil {
+			msg.Die("Could not update tools: %s", err)
+		}
+	}
+	return confcopy, tools
+}
+
+// changesPendingError is the number of dependencies a dry run found would
+// change.
+type changesPendingError int
+
+func (e changesPendingError) Error() string {
+	return fmt.Sprintf("%d dependencies would change. Run 'glide update' to apply the changes", int(e))
+}
+
+// Kind returns msg.KindChangesPending.
+func (e changesPendingError) Kind() msg.Kind {
+	return msg.KindChangesPending
+}
+
+// printPlan prints how the lock file would change. Glide exits with the exit
+// code of msg.KindChangesPending when anything would change.
+func printPlan(conf *cfg.Config, base string, lock *cfg.Lockfile, changelog bool) {
+	prev := &cfg.Lockfile{}
+	if gpath.HasLock(base) {
+		l, err := cfg.ReadLockFile(filepath.Join(base, gpath.LockFile))
+		if err != nil {
+			msg.Die("Could not load lockfile: %s", err)
+		}
+		prev = l
+	}
+
+	changes := lockDiff(prev, lock)
+	if len(changes) == 0 {
+		msg.Info("Dependencies are up to date. Nothing would change.")
+		return
+	}
+	for _, c := range changes {
+		c.Transitive = c.Old == nil && !conf.HasDependency(c.Name)
+	}
+	msg.Info("Planned changes to %s:", gpath.LockFile)
+	printChanges(changes, changelog, changelogCommits)
+	msg.Die("%s", changesPendingError(len(changes)))
+}
+
+// freezeDependencies returns a copy of conf where every dependency not listed
+// in only, and not tracking a branch, is set to the version found in the lock
+// file. Locked transitive dependencies are handed to the installer so they are
+// kept at their locked version unless they are no longer needed.
+func freezeDependencies(conf *cfg.Config, installer *repo.Installer, only []string, base string) *cfg.Config {
+	if !gpath.HasLock(base) {
+		msg.Die("Updating only some packages requires a lock file (%s)", gpath.LockFile)
+	}
+	lock, err := cfg.ReadLockFile(filepath.Join(base, gpath.LockFile))
+	if err != nil {
+		msg.Die("Could not load lockfile: %s", err)
+	}
+
+	selected := make(map[string]bool, len(only))
+	for _, o := range only {
+		root, _ := util.NormalizeName(o)
+		if !conf.HasDependency(root) && !lock.Imports.Has(root) && !lock.DevImports.Has(root) && conf.Tools.Get(root) == nil {
+			msg.Warn("%s is not a dependency of this project. Skipping", root)
+			continue
+		}
+		selected[root] = true
+	}
+	if len(selected) == 0 {
+		msg.Die("None of the packages to update were found")
+	}
+
+	frozen := conf.Clone()
+	installer.Frozen = nil
+	freeze := func(deps cfg.Dependencies, locks cfg.Locks) {
+		for _, l := range locks {
+			if selected[l.Name] {
+				continue
+			}
+			// Dependencies tracking a branch are always updated to its tip.
+			if d := deps.Get(l.Name); d != nil && d.Track {
+				msg.Info("Updating %s to the tip of the branch it tracks", l.Name)
+				continue
+			}
+			if d := deps.Get(l.Name); d != nil {
+				d.Reference = l.Version
+				continue
+			}
+			installer.Frozen = append(installer.Frozen, cfg.DependencyFromLock(l))
+		}
+	}
+	freeze(frozen.Imports, lock.Imports)
+	freeze(frozen.DevImports, lock.DevImports)
+	for _, l := range lock.Tools {
+		if t := frozen.Tools.Get(l.Name); t != nil && !selected[l.Name] {
+			t.Reference = l.Version
+		}
+	}
+
+	for name := range selected {
+		msg.Info("Updating %s", name)
+	}
+	msg.Info("Keeping all other dependencies at their locked versions")
+
+	return frozen
+}
+
+// LimitMajorVersions narrows the versions of the locked dependencies to their
+// current major version for the next update, whatever glide.yaml allows. The
+// current major version is that of the newest semantic version tag pointing at
+// the locked commit. Dependencies locked to an untagged commit are not
+// limited.
+//
+// Params:
+//  - base (string): the project directory
+//  - allow ([]string): packages that may move to another major version
+func LimitMajorVersions(base string, allow []string) {
+	if !gpath.HasLock(base) {
+		msg.Die("Updating within the current major versions requires a lock file (%s)", gpath.LockFile)
+	}
+	lock, err := cfg.ReadLockFile(filepath.Join(base, gpath.LockFile))
+	if err != nil {
+		msg.Die("Could not load lockfile: %s", err)
+	}
+
+	allowed := make(map[string]bool, len(allow))
+	for _, a := range allow {
+		root, _ := util.NormalizeName(a)
+		if !lock.Imports.Has(root) && !lock.DevImports.Has(root) {
+			msg.Warn("%s is not a locked dependency of this project. Skipping", root)
+		}
+		allowed[root] = true
+	}
+
+	limits := make(map[string]int64)
+	for _, l := range append(append(cfg.Locks{}, lock.Imports...), lock.DevImports...) {
+		if allowed[l.Name] {
+			msg.Info("Allowing %s to move to another major version", l.Name)
+			continue
+		}
+		tags, err := cachedTags(cfg.DependencyFromLock(l), l.Version)
 		if err != nil {
-			msg.Err("Unable to strip vendor directories: %s", err)
+			msg.Debug("Unable to find the tags of %s: %s", l.Name, err)
+			continue
+		}
+		var current *semver.Version
+		for _, t := range tags {
+			if v, err := semver.NewVersion(t); err == nil && (current == nil || v.GreaterThan(current)) {
+				current = v
+			}
+		}
+		if current == nil {
+			msg.Debug("%s is not locked to a semantic version. Its major version is not limited", l.Name)
+			continue
 		}
+		limits[l.Name] = current.Major()
 	}
+	repo.MajorLimits = limits
+	msg.Info("Keeping %d dependencies at their current major versions", len(limits))
 }
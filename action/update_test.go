@@ -0,0 +1,76 @@
+package action
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+func TestPrintPlan(t *testing.T) {
+	base, err := ioutil.TempDir("", "glide-plan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(base)
+
+	prev := &cfg.Lockfile{
+		Imports: cfg.Locks{{Name: "example.com/a", Version: "1.0.0"}},
+	}
+	if err := prev.WriteFile(filepath.Join(base, gpath.LockFile)); err != nil {
+		t.Fatal(err)
+	}
+	conf := &cfg.Config{
+		Name:    "example.com/app",
+		Imports: cfg.Dependencies{{Name: "example.com/a"}},
+	}
+
+	oldPanic, oldOut, oldErr := msg.Default.PanicOnDie, msg.Default.Stdout, msg.Default.Stderr
+	defer func() {
+		msg.Default.PanicOnDie, msg.Default.Stdout, msg.Default.Stderr = oldPanic, oldOut, oldErr
+	}()
+	var buf bytes.Buffer
+	msg.Default.PanicOnDie = true
+	msg.Default.Stdout = &buf
+	msg.Default.Stderr = &buf
+
+	plan := func(lock *cfg.Lockfile) (d *msg.Died) {
+		defer func() {
+			if r := recover(); r != nil {
+				d = r.(*msg.Died)
+			}
+		}()
+		printPlan(conf, base, lock, false)
+		return nil
+	}
+
+	if d := plan(prev); d != nil {
+		t.Errorf("Expected no changes to be pending, got %s", d)
+	}
+
+	lock := &cfg.Lockfile{
+		Imports: cfg.Locks{
+			{Name: "example.com/a", Version: "1.1.0"},
+			{Name: "example.com/b", Version: "2.0.0"},
+		},
+	}
+	buf.Reset()
+	d := plan(lock)
+	if d == nil {
+		t.Fatal("Expected pending changes to stop Glide")
+	}
+	if d.Kind != msg.KindChangesPending || d.Kind.ExitCode() != 8 {
+		t.Errorf("Expected to exit with the code for pending changes, got %s (%d)", d.Kind, d.Kind.ExitCode())
+	}
+	for _, want := range []string{"~ example.com/a 1.0.0 -> 1.1.0", "+ example.com/b 2.0.0 (transitive)", "2 dependencies would change"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %q in the plan, got:\n%s", want, buf.String())
+		}
+	}
+}
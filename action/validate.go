@@ -0,0 +1,61 @@
+package action
+
+import (
+	"io/ioutil"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// Validate strictly checks the glide.yaml file, reporting unknown fields,
+// values of the wrong type, and deprecated fields along with where they are.
+func Validate() {
+	yamlpath, errs := validateConfig()
+	n := 0
+	for _, e := range errs {
+		if !e.Warning {
+			n++
+		}
+	}
+	if n > 0 {
+		msg.DieKind(msg.KindConfig, "%s has %d error(s)", yamlpath, n)
+	}
+	msg.Info("%s is valid", yamlpath)
+}
+
+// EnsureValidConfig validates the glide.yaml file before loading it like
+// EnsureConfig. Commands that change the project use this so a typo does not
+// silently change what gets installed.
+func EnsureValidConfig() *cfg.Config {
+	yamlpath, errs := validateConfig()
+	for _, e := range errs {
+		if !e.Warning {
+			msg.DieKind(msg.KindConfig, "Invalid %s. Fix the errors above to continue", yamlpath)
+		}
+	}
+	return EnsureConfig()
+}
+
+// validateConfig validates the glide.yaml file and displays each problem
+// prefixed with its location in the file.
+func validateConfig() (string, []*cfg.ValidationError) {
+	yamlpath, err := gpath.Glide()
+	if err != nil {
+		msg.DieKind(msg.KindConfig, "Failed to find %s file in directory tree: %s", gpath.GlideFile, err)
+	}
+	yml, err := ioutil.ReadFile(yamlpath)
+	if err != nil {
+		msg.DieKind(msg.KindConfig, "Failed to load %s: %s", yamlpath, err)
+	}
+
+	errs := cfg.Validate(yml)
+	for _, e := range errs {
+		if e.Warning {
+			msg.Warn("%s: %s", yamlpath, e)
+		} else {
+			msg.Err("%s: %s", yamlpath, e)
+		}
+	}
+	return yamlpath, errs
+}
@@ -0,0 +1,62 @@
+package action
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/glide/msg"
+)
+
+func TestConfigErrorKind(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-validate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	defer func(p bool, w io.Writer) {
+		msg.Default.PanicOnDie = p
+		msg.Default.Stderr = w
+	}(msg.Default.PanicOnDie, msg.Default.Stderr)
+	msg.Default.PanicOnDie = true
+	msg.Default.Stderr = &buf
+
+	kind := func(f func()) (k msg.Kind) {
+		k = -1
+		defer func() {
+			if d, ok := recover().(*msg.Died); ok {
+				k = d.Kind
+			}
+		}()
+		f()
+		return
+	}
+
+	// A glide.yaml that is missing, unreadable, or invalid is a config error.
+	if k := kind(func() { EnsureConfig() }); k != msg.KindConfig {
+		t.Errorf("Expected a missing glide.yaml to be a config error, got %s", k)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "glide.yaml"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if k := kind(func() { EnsureConfig() }); k != msg.KindConfig {
+		t.Errorf("Expected an unreadable glide.yaml to be a config error, got %s", k)
+	}
+	os.Remove(filepath.Join(dir, "glide.yaml"))
+	if err := ioutil.WriteFile(filepath.Join(dir, "glide.yaml"), []byte("package: example.com/app\nimprot: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if k := kind(Validate); k != msg.KindConfig {
+		t.Errorf("Expected an invalid glide.yaml to be a config error, got %s", k)
+	}
+}
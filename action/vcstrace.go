@@ -0,0 +1,290 @@
+package action
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Masterminds/glide/msg"
+)
+
+// The environment variables telling a VCS shim where to record the commands
+// run and where to find the real command.
+const (
+	vcsTraceFileEnv = "GLIDE_VCS_TRACE_FILE"
+	vcsTracePathEnv = "GLIDE_VCS_TRACE_PATH"
+)
+
+// tracedVCS are the commands traced with --debug-vcs.
+var tracedVCS = []string{"git", "hg", "bzr", "svn", "darcs", "fossil"}
+
+// vcsCommand is a VCS command run while tracing, as recorded by its shim.
+type vcsCommand struct {
+	Cmd      string   `json:"cmd"`
+	Args     []string `json:"args"`
+	Dir      string   `json:"dir"`
+	Duration float64  `json:"duration"`
+	Exit     int      `json:"exit"`
+	Output   string   `json:"output,omitempty"`
+}
+
+// maxTracedOutput is how much of the output of a VCS command its shim records.
+const maxTracedOutput = 64 * 1024
+
+var vcsTrace struct {
+	sync.Mutex
+	dir  string
+	file *os.File
+	r    *bufio.Reader
+	part string
+	done chan struct{}
+
+	// commands logs the commands run, rather than only their output.
+	commands bool
+}
+
+// DebugVCS logs every VCS command Glide runs, including those run by the VCS
+// library, with its working directory, how long it took, and its exit code.
+// Credentials in the arguments are redacted.
+//
+// The commands are found by putting a directory first in the PATH where git,
+// hg, bzr, svn, darcs, and fossil link to Glide. Run under one of those names,
+// Glide runs the real command and records it. See VCSShim.
+func DebugVCS(on bool) {
+	if !on {
+		return
+	}
+	if runtime.GOOS == "windows" {
+		msg.Warn("--debug-vcs is not supported on Windows")
+		return
+	}
+	vcsTrace.Lock()
+	vcsTrace.commands = true
+	vcsTrace.Unlock()
+	if err := traceVCS(); err != nil {
+		msg.Warn("Unable to trace VCS commands: %s", err)
+	}
+}
+
+// traceVCS starts tracing VCS commands, unless they are traced already. The
+// output of each is logged at the debug level, which is how the output of the
+// commands the VCS library runs itself, such as fetches and checkouts, is
+// seen.
+func traceVCS() error {
+	vcsTrace.Lock()
+	tracing := vcsTrace.dir != ""
+	vcsTrace.Unlock()
+	if tracing {
+		return nil
+	}
+	self, err := glideExecutable()
+	if err != nil {
+		return fmt.Errorf("unable to find the Glide executable: %s", err)
+	}
+	dir, err := ioutil.TempDir("", "glide-vcs-trace")
+	if err != nil {
+		return err
+	}
+	for _, c := range tracedVCS {
+		if err := os.Symlink(self, filepath.Join(dir, c)); err != nil {
+			os.RemoveAll(dir)
+			return err
+		}
+	}
+	trace := filepath.Join(dir, "trace.json")
+	f, err := os.OpenFile(trace, os.O_RDONLY|os.O_CREATE, 0600)
+	if err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+
+	os.Setenv(vcsTracePathEnv, os.Getenv("PATH"))
+	os.Setenv(vcsTraceFileEnv, trace)
+	os.Setenv("PATH", prependPath(dir, os.Getenv("PATH")))
+
+	vcsTrace.Lock()
+	defer vcsTrace.Unlock()
+	vcsTrace.dir = dir
+	vcsTrace.file = f
+	vcsTrace.r = bufio.NewReader(f)
+	vcsTrace.done = make(chan struct{})
+	go func() {
+		t := time.NewTicker(100 * time.Millisecond)
+		defer t.Stop()
+		for {
+			select {
+			case <-vcsTrace.done:
+				return
+			case <-t.C:
+				logVCSCommands()
+			}
+		}
+	}()
+	return nil
+}
+
+// StopDebugVCS logs the VCS commands not logged yet and stops tracing them.
+func StopDebugVCS() {
+	vcsTrace.Lock()
+	dir := vcsTrace.dir
+	vcsTrace.Unlock()
+	if dir == "" {
+		return
+	}
+	close(vcsTrace.done)
+	logVCSCommands()
+
+	vcsTrace.Lock()
+	defer vcsTrace.Unlock()
+	vcsTrace.file.Close()
+	os.RemoveAll(vcsTrace.dir)
+	vcsTrace.dir = ""
+	os.Setenv("PATH", os.Getenv(vcsTracePathEnv))
+	os.Unsetenv(vcsTraceFileEnv)
+	os.Unsetenv(vcsTracePathEnv)
+}
+
+// logVCSCommands logs the commands recorded since it was last called.
+func logVCSCommands() {
+	vcsTrace.Lock()
+	defer vcsTrace.Unlock()
+	if vcsTrace.dir == "" {
+		return
+	}
+	for {
+		line, err := vcsTrace.r.ReadString('\n')
+		if err == io.EOF {
+			vcsTrace.part += line
+			return
+		} else if err != nil {
+			msg.Debug("Unable to read the trace of VCS commands: %s", err)
+			return
+		}
+		line, vcsTrace.part = vcsTrace.part+line, ""
+
+		c := &vcsCommand{}
+		if err := json.Unmarshal([]byte(line), c); err != nil {
+			msg.Debug("Unable to read the trace of VCS commands: %s", err)
+			continue
+		}
+		if vcsTrace.commands {
+			msg.Info("VCS: %s %s (in %s) exited with %d after %.2fs", c.Cmd, strings.Join(c.Args, " "), c.Dir, c.Exit, c.Duration)
+		}
+		msg.Output(c.Cmd, []byte(c.Output))
+	}
+}
+
+// VCSShim runs the VCS command Glide was run as, when it was run through the
+// PATH set up by DebugVCS, records it along with its output, and exits with the
+// exit code of the command. Otherwise it returns right away.
+func VCSShim() {
+	trace := os.Getenv(vcsTraceFileEnv)
+	name := filepath.Base(os.Args[0])
+	if trace == "" || !isTracedVCS(name) {
+		return
+	}
+
+	// The command and anything it runs see the PATH as it was before.
+	os.Setenv("PATH", os.Getenv(vcsTracePathEnv))
+	os.Unsetenv(vcsTraceFileEnv)
+	os.Unsetenv(vcsTracePathEnv)
+
+	// Output Glide logs itself is not recorded again.
+	out := &limitedBuffer{max: maxTracedOutput}
+	if os.Getenv(msg.OutputLoggedEnv) != "" {
+		out.max = 0
+		os.Unsetenv(msg.OutputLoggedEnv)
+	}
+
+	c := exec.Command(name, os.Args[1:]...)
+	c.Stdin = os.Stdin
+	c.Stdout = io.MultiWriter(os.Stdout, out)
+	c.Stderr = io.MultiWriter(os.Stderr, out)
+	start := time.Now()
+	err := c.Run()
+	d := time.Since(start)
+
+	code := 0
+	if ee, ok := err.(*exec.ExitError); ok {
+		code = 1
+		if s, ok := ee.Sys().(syscall.WaitStatus); ok {
+			code = s.ExitStatus()
+		}
+	} else if err != nil {
+		os.Stderr.WriteString(err.Error() + "\n")
+		code = 127
+	}
+
+	dir, _ := os.Getwd()
+	rec := &vcsCommand{Cmd: name, Dir: dir, Duration: d.Seconds(), Exit: code, Output: out.String()}
+	for _, a := range os.Args[1:] {
+		rec.Args = append(rec.Args, redactArg(a))
+	}
+	if b, err := json.Marshal(rec); err == nil {
+		// Each record is written at once so those of commands running at the
+		// same time are not interleaved.
+		if f, err := os.OpenFile(trace, os.O_WRONLY|os.O_APPEND, 0600); err == nil {
+			f.Write(append(b, '\n'))
+			f.Close()
+		}
+	}
+	os.Exit(code)
+}
+
+// limitedBuffer keeps the first max bytes written to it.
+type limitedBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if n := b.max - b.Len(); n > 0 {
+		if len(p) < n {
+			n = len(p)
+		}
+		b.Buffer.Write(p[:n])
+	}
+	return len(p), nil
+}
+
+func isTracedVCS(name string) bool {
+	for _, c := range tracedVCS {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// redactArg hides the credentials in an argument of a VCS command: the user
+// and password of http(s) URLs, and the value of Authorization headers and of
+// settings naming a password or token.
+func redactArg(a string) string {
+	if u, err := url.Parse(a); err == nil && u.User != nil && (u.Scheme == "http" || u.Scheme == "https") {
+		u.User = url.User("xxxxx")
+		return u.String()
+	}
+	lower := strings.ToLower(a)
+	if i := strings.Index(lower, "authorization:"); i >= 0 {
+		return a[:i+len("authorization:")] + " xxxxx"
+	}
+	if i := strings.Index(a, "="); i > 0 {
+		key := strings.ToLower(a[:i])
+		if strings.Contains(key, "password") || strings.Contains(key, "token") {
+			return a[:i+1] + "xxxxx"
+		}
+	}
+	return a
+}
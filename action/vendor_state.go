@@ -0,0 +1,345 @@
+package action
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/glide/repo"
+)
+
+// vendorStateFile is stored in the vendor directory and records, in the lock
+// file format, the dependencies exported there by the last install or update.
+// The hash is that of the glide.yaml file used.
+const vendorStateFile = ".glide-vendor.lock"
+
+// vendorSumFile is stored in the vendor directory and lists the hash of the
+// files of each dependency exported there by the last install or update, in
+// the format of sha256sum. See dirHash.
+const vendorSumFile = ".glide-vendor.sum"
+
+// installDigestFile is stored in the vendor directory and holds the digest of
+// the last install. See installDigest.
+const installDigestFile = ".glide-install"
+
+// glideVersion is the version of Glide. It is part of the install digest so a
+// new version of Glide installs again.
+var glideVersion string
+
+// SetVersion sets the version of Glide recorded with installs.
+func SetVersion(v string) {
+	glideVersion = v
+}
+
+// readVendorState reads the dependencies recorded by the last install.
+func readVendorState() (*cfg.Lockfile, error) {
+	vpath, err := gpath.Vendor()
+	if err != nil {
+		return nil, err
+	}
+	return cfg.ReadLockFile(filepath.Join(vpath, vendorStateFile))
+}
+
+// writeVendorState records the dependencies exported to the vendor directory
+// for the next install. Copies of local directories are left out as they may
+// change at any time.
+func writeVendorState(installer *repo.Installer, conf *cfg.Config, hash string) {
+	// The vendor directory no longer matches the last install.
+	removeInstallDigest()
+
+	var deps cfg.Dependencies
+	for _, d := range strippedDeps(installer, conf) {
+		if d.Local == "" && !conf.HasIgnore(d.Name) {
+			deps = append(deps, d)
+		}
+	}
+	state, err := cfg.NewLockfile(deps, nil, hash)
+	if err != nil {
+		msg.Debug("Unable to record the vendored dependencies: %s", err)
+		return
+	}
+	vpath, err := gpath.Vendor()
+	if err != nil {
+		return
+	}
+	if err := state.WriteFile(filepath.Join(vpath, vendorStateFile)); err != nil {
+		msg.Debug("Unable to record the vendored dependencies: %s", err)
+	}
+}
+
+// writeVendorSums records the hash of the files of each vendored dependency,
+// once the vendor directory is complete, so later changes to them can be
+// found. The hashes of the dependencies reused from the previous vendor
+// directory are carried over. Nothing is recorded for linked dependencies as
+// their files are not in the vendor directory.
+func writeVendorSums(installer *repo.Installer, conf *cfg.Config) {
+	vpath, err := gpath.Vendor()
+	if err != nil {
+		return
+	}
+	sumpath := filepath.Join(vpath, vendorSumFile)
+	if installer.Link {
+		os.Remove(sumpath)
+		return
+	}
+	prev, _ := readVendorSums(vpath)
+
+	var b bytes.Buffer
+	for _, d := range strippedDeps(installer, conf) {
+		if d.Local != "" || conf.HasIgnore(d.Name) {
+			continue
+		}
+		sum := prev[d.Name]
+		if sum == "" || !installer.Reuse[d.Name] {
+			var err error
+			sum, err = dirHash(filepath.Join(vpath, filepath.FromSlash(d.Name)))
+			if err != nil {
+				msg.Debug("Unable to hash the vendored %s: %s", d.Name, err)
+				continue
+			}
+		}
+		fmt.Fprintf(&b, "%s  %s\n", sum, d.Name)
+	}
+	if err := ioutil.WriteFile(sumpath, b.Bytes(), 0644); err != nil {
+		msg.Debug("Unable to record the hashes of the vendored dependencies: %s", err)
+	}
+}
+
+// readVendorSums reads the hashes recorded by the last install, by the name
+// of the dependency.
+func readVendorSums(vpath string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(vpath, vendorSumFile))
+	if err != nil {
+		return nil, err
+	}
+	sums := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		if f := strings.Fields(line); len(f) == 2 {
+			sums[f[1]] = f[0]
+		}
+	}
+	return sums, nil
+}
+
+// vendorUpToDate returns true when the vendor directory holds exactly the
+// dependencies locked in lock, as recorded by the last install for the same
+// glide.yaml, so there is nothing to install. Dependencies using a local copy
+// or tracking a branch are always installed again. With stripVendor the
+// dependencies must have been stripped too, and they must be symlinks exactly
+// when the installer links them.
+func vendorUpToDate(installer *repo.Installer, conf *cfg.Config, lock *cfg.Lockfile, stripVendor bool) bool {
+	if installer.Force {
+		return false
+	}
+	state, err := readVendorState()
+	if err != nil {
+		msg.Debug("No record of previously vendored dependencies: %s", err)
+		return false
+	}
+	hash, err := conf.Hash()
+	if err != nil || hash != state.Hash {
+		return false
+	}
+	for _, d := range append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...) {
+		if d.Local != "" || d.Track {
+			return false
+		}
+	}
+
+	wanted := lockedDeps(installer, conf, lock)
+	if len(wanted) != len(state.Imports) {
+		return false
+	}
+	var stripped *cfg.Lockfile
+	if stripVendor {
+		vpath, err := gpath.Vendor()
+		if err != nil {
+			return false
+		}
+		if stripped, err = cfg.ReadLockFile(filepath.Join(vpath, stripLockFile)); err != nil {
+			return false
+		}
+	}
+	for _, l := range wanted {
+		if !sameLock(l, state.Imports.Get(l.Name)) {
+			return false
+		}
+		if stripped != nil && !sameLock(l, stripped.Imports.Get(l.Name)) {
+			return false
+		}
+		dir := installer.VendorPath()
+		if tvp := installer.TestVendorPath(); tvp != "" && lock.Imports.Get(l.Name) == nil {
+			dir = tvp
+		}
+		fi, err := os.Lstat(filepath.Join(dir, filepath.FromSlash(l.Name)))
+		if err != nil || (fi.Mode()&os.ModeSymlink != 0) != installer.Link {
+			return false
+		}
+	}
+	return true
+}
+
+// reuseVendor tells the installer to keep the vendored copies of the
+// dependencies that have not changed since the last install. The vendored
+// copies are not reused when nested vendor directories were stripped from
+// them but are not to be stripped now, or when the installer is forced.
+func reuseVendor(installer *repo.Installer, conf *cfg.Config) {
+	if installer.Force {
+		return
+	}
+	vpath, err := gpath.Vendor()
+	if err != nil {
+		return
+	}
+	if _, err := os.Stat(filepath.Join(vpath, stripLockFile)); err == nil {
+		return
+	}
+	state, err := readVendorState()
+	if err != nil {
+		msg.Debug("No record of previously vendored dependencies: %s", err)
+		return
+	}
+	installer.Reuse = unchangedDeps(strippedDeps(installer, conf), state)
+}
+
+// lockedDeps returns the locks of the dependencies installed from lock.
+func lockedDeps(installer *repo.Installer, conf *cfg.Config, lock *cfg.Lockfile) cfg.Locks {
+	var locks cfg.Locks
+	for _, l := range lock.Imports {
+		if !conf.HasIgnore(l.Name) {
+			locks = append(locks, l)
+		}
+	}
+	if installer.ResolveTest {
+		for _, l := range lock.DevImports {
+			if !conf.HasIgnore(l.Name) && lock.Imports.Get(l.Name) == nil {
+				locks = append(locks, l)
+			}
+		}
+	}
+	return locks
+}
+
+// sameLock returns true if b exports the same code as a.
+func sameLock(a, b *cfg.Lock) bool {
+	if b == nil || a.Version != b.Version || a.Repository != b.Repository ||
+		a.VcsType != b.VcsType || a.Submodules != b.Submodules || a.SubpackagesOnly != b.SubpackagesOnly {
+		return false
+	}
+	return !a.SubpackagesOnly || reflect.DeepEqual(a.Subpackages, b.Subpackages)
+}
+
+// installDigest returns a digest of the glide.yaml and glide.lock files, the
+// install options, and the version of Glide. It returns "" when an install
+// may change the vendor directory even though none of them changed: when
+// glide.yaml extends another file or has dependencies using a local copy or
+// tracking a branch.
+func installDigest(installer *repo.Installer, base string, stripVendor bool) string {
+	yamlpath, err := gpath.Glide()
+	if err != nil {
+		return ""
+	}
+	yml, err := ioutil.ReadFile(yamlpath)
+	if err != nil {
+		return ""
+	}
+	conf, err := cfg.ConfigFromYaml(yml)
+	if err != nil || conf.Extends != "" {
+		return ""
+	}
+	for _, d := range append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...) {
+		if d.Local != "" || d.Track {
+			return ""
+		}
+	}
+	lock, err := ioutil.ReadFile(filepath.Join(base, gpath.LockFile))
+	if err != nil {
+		return ""
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00", glideVersion, yml, lock)
+	goos, goarch := targetPlatform()
+	fmt.Fprintf(h, "strip=%t test=%t link=%s symlink=%t unlink=%t with=%s without=%s platform=%s/%s all-platforms=%t", stripVendor, installer.ResolveTest,
+		gpath.LinkMode, installer.Link, installer.Unlink, strings.Join(installer.With, ","), strings.Join(installer.Without, ","),
+		goos, goarch, installer.AllPlatforms)
+	// Changed patches have to be applied to fresh copies.
+	for _, d := range append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...) {
+		for _, p := range d.Patches {
+			b, _ := ioutil.ReadFile(filepath.Join(base, filepath.FromSlash(p)))
+			fmt.Fprintf(h, " patch=%s\x00%s", p, b)
+		}
+	}
+	captured := findPatches(base)
+	names := make([]string, 0, len(captured))
+	for n := range captured {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		b, _ := ioutil.ReadFile(captured[n])
+		fmt.Fprintf(h, " patch=%s\x00%s", n, b)
+	}
+	// A change of the Go toolchain has to be checked against the versions the
+	// project supports.
+	if conf.Go != "" {
+		out, _ := exec.Command(goExecutable(), "version").Output()
+		fmt.Fprintf(h, " go=%s", out)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// installUpToDate returns true when the last install recorded the same digest
+// as the one for installing now, so there is nothing to install. It only
+// reads a few files, making it cheap to install unconditionally.
+func installUpToDate(installer *repo.Installer, base string, stripVendor bool) bool {
+	if installer.Force {
+		return false
+	}
+	digest := installDigest(installer, base, stripVendor)
+	if digest == "" {
+		return false
+	}
+	vpath, err := gpath.Vendor()
+	if err != nil {
+		return false
+	}
+	b, err := ioutil.ReadFile(filepath.Join(vpath, installDigestFile))
+	return err == nil && strings.TrimSpace(string(b)) == digest
+}
+
+// writeInstallDigest records the digest of a completed install.
+func writeInstallDigest(installer *repo.Installer, base string, stripVendor bool) {
+	digest := installDigest(installer, base, stripVendor)
+	if digest == "" {
+		return
+	}
+	vpath, err := gpath.Vendor()
+	if err != nil {
+		return
+	}
+	if err := ioutil.WriteFile(filepath.Join(vpath, installDigestFile), []byte(digest+"\n"), 0666); err != nil {
+		msg.Debug("Unable to record the install: %s", err)
+	}
+}
+
+// removeInstallDigest removes the digest of the last install.
+func removeInstallDigest() {
+	vpath, err := gpath.Vendor()
+	if err != nil {
+		return
+	}
+	if err := os.Remove(filepath.Join(vpath, installDigestFile)); err != nil && !os.IsNotExist(err) {
+		msg.Debug("Unable to remove the record of the last install: %s", err)
+	}
+}
@@ -0,0 +1,148 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/repo"
+)
+
+func TestVendorUpToDate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-vendor-state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := &cfg.Config{
+		Name: "example.com/app",
+		Imports: cfg.Dependencies{
+			&cfg.Dependency{Name: "github.com/Masterminds/semver", Reference: "^1.0.0"},
+		},
+	}
+	if err := conf.WriteFile("glide.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := conf.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock := &cfg.Lockfile{
+		Hash: hash,
+		Imports: cfg.Locks{
+			&cfg.Lock{Name: "github.com/Masterminds/semver", Version: "aaa"},
+		},
+	}
+	installer := repo.NewInstaller()
+
+	if vendorUpToDate(installer, conf, lock, false) {
+		t.Error("Vendor directory without a record of the last install is up to date")
+	}
+
+	if err := os.MkdirAll(filepath.Join("vendor", "github.com", "Masterminds", "semver"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	vendored := conf.Clone()
+	vendored.Imports[0].Pin = "aaa"
+	writeVendorState(installer, vendored, hash)
+	if !vendorUpToDate(installer, conf, lock, false) {
+		t.Error("Vendor directory is not up to date after writing its record")
+	}
+	if vendorUpToDate(installer, conf, lock, true) {
+		t.Error("Vendor directory that was not stripped is up to date when stripping")
+	}
+
+	lock.Imports[0].Version = "bbb"
+	if vendorUpToDate(installer, conf, lock, false) {
+		t.Error("Vendor directory is up to date with a changed version")
+	}
+	lock.Imports[0].Version = "aaa"
+
+	lock.Imports = append(lock.Imports, &cfg.Lock{Name: "github.com/Masterminds/vcs", Version: "ccc"})
+	if vendorUpToDate(installer, conf, lock, false) {
+		t.Error("Vendor directory is up to date with an added dependency")
+	}
+	lock.Imports = lock.Imports[:1]
+
+	installer.Force = true
+	if vendorUpToDate(installer, conf, lock, false) {
+		t.Error("Vendor directory is up to date when forced")
+	}
+}
+
+func TestInstallUpToDate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-install-digest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := &cfg.Config{
+		Name: "example.com/app",
+		Imports: cfg.Dependencies{
+			&cfg.Dependency{Name: "github.com/Masterminds/semver", Reference: "^1.0.0"},
+		},
+	}
+	if err := conf.WriteFile("glide.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	lock := &cfg.Lockfile{
+		Imports: cfg.Locks{
+			&cfg.Lock{Name: "github.com/Masterminds/semver", Version: "aaa"},
+		},
+	}
+	if err := lock.WriteFile("glide.lock"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir("vendor", 0755); err != nil {
+		t.Fatal(err)
+	}
+	installer := repo.NewInstaller()
+
+	if installUpToDate(installer, ".", false) {
+		t.Error("Install without a digest of the last one is up to date")
+	}
+	writeInstallDigest(installer, ".", false)
+	if !installUpToDate(installer, ".", false) {
+		t.Error("Install is not up to date after writing its digest")
+	}
+	if installUpToDate(installer, ".", true) {
+		t.Error("Install is up to date with different options")
+	}
+
+	lock.Imports[0].Version = "bbb"
+	if err := lock.WriteFile("glide.lock"); err != nil {
+		t.Fatal(err)
+	}
+	if installUpToDate(installer, ".", false) {
+		t.Error("Install is up to date with a changed lock file")
+	}
+	writeInstallDigest(installer, ".", false)
+
+	writeVendorState(installer, conf, "")
+	if installUpToDate(installer, ".", false) {
+		t.Error("Install is up to date after the vendor directory changed")
+	}
+
+	conf.Imports[0].Track = true
+	if err := conf.WriteFile("glide.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	writeInstallDigest(installer, ".", false)
+	if installUpToDate(installer, ".", false) {
+		t.Error("Install is up to date with a dependency tracking a branch")
+	}
+}
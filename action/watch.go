@@ -0,0 +1,169 @@
+package action
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// Watch scans the code of the project every interval and warns as soon as it
+// imports a repo that is neither listed in glide.yaml nor in the vendor
+// directory. With get, 'glide get' is run for those repos. It runs until the
+// process is stopped.
+//
+// Params:
+//  - interval (time.Duration): How often to look for changed Go files
+//  - get (bool): whether to run 'glide get' for the missing repos
+func Watch(interval time.Duration, get bool) {
+	yamlpath, err := gpath.Glide()
+	if err != nil {
+		msg.DieKind(msg.KindConfig, "Failed to find %s file in directory tree: %s", gpath.GlideFile, err)
+	}
+	if interval <= 0 {
+		msg.Die("The interval must be positive, got %s", interval)
+	}
+	base := filepath.Dir(yamlpath)
+
+	msg.Info("Watching the Go files in %s for new imports. Press Ctrl+C to stop", base)
+	var last string
+	warned := make(map[string]bool)
+	for ; ; time.Sleep(interval) {
+		sum, err := sourceSum(base, yamlpath)
+		if err != nil {
+			msg.Warn("Unable to scan %s: %s", base, err)
+			continue
+		}
+		if sum == last {
+			continue
+		}
+		last = sum
+
+		missing, missingTest, err := undeclaredImports(base, yamlpath)
+		if err != nil {
+			msg.Warn("Unable to check the imports of the code: %s", err)
+			continue
+		}
+
+		now := make(map[string]bool)
+		var added, addedTest []string
+		for _, n := range missing {
+			now[n] = true
+			if !warned[n] {
+				msg.Warn("%s is imported but not in %s or %s/", n, gpath.GlideFile, gpath.VendorDir)
+				added = append(added, n)
+			}
+		}
+		for _, n := range missingTest {
+			now[n] = true
+			if !warned[n] {
+				msg.Warn("%s is imported by tests but not in %s or %s/", n, gpath.GlideFile, gpath.VendorDir)
+				addedTest = append(addedTest, n)
+			}
+		}
+		for n := range warned {
+			if !now[n] {
+				msg.Info("%s is no longer missing", n)
+			}
+		}
+		warned = now
+
+		if get {
+			runGet(added, false)
+			runGet(addedTest, true)
+		}
+	}
+}
+
+// undeclaredImports returns the roots of the repos imported by the code, and
+// those only imported by its tests, that glide.yaml does not list and the
+// vendor directory does not hold.
+func undeclaredImports(base, yamlpath string) ([]string, []string, error) {
+	yml, err := ioutil.ReadFile(yamlpath)
+	if err != nil {
+		return nil, nil, err
+	}
+	conf, err := cfg.ConfigFromYaml(yml)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to parse %s: %s", yamlpath, err)
+	}
+	if err := conf.ResolveExtends(base); err != nil {
+		return nil, nil, err
+	}
+
+	imports, testImports, err := codeImports(base, conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	report := manifestDiff(conf, imports, testImports)
+	vendored := func(names []string) []string {
+		var left []string
+		for _, n := range names {
+			if _, err := os.Stat(filepath.Join(base, gpath.VendorDir, filepath.FromSlash(n))); err != nil {
+				left = append(left, n)
+			}
+		}
+		return left
+	}
+	return vendored(report.Missing), vendored(report.MissingTest), nil
+}
+
+// sourceSum returns a checksum of the names, sizes, and modification times of
+// the Go files of the project and of glide.yaml, changing whenever any of them
+// does. The vendor directory and those Go tooling ignores are skipped.
+func sourceSum(base, yamlpath string) (string, error) {
+	h := sha256.New()
+	if fi, err := os.Stat(yamlpath); err == nil {
+		fmt.Fprintf(h, "%s %d %d\n", yamlpath, fi.Size(), fi.ModTime().UnixNano())
+	}
+	err := filepath.Walk(base, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name := fi.Name()
+		if fi.IsDir() {
+			if p != base && (name == gpath.VendorDir || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(name, ".go") {
+			fmt.Fprintf(h, "%s %d %d\n", p, fi.Size(), fi.ModTime().UnixNano())
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// runGet runs 'glide get' for the named repos in its own process, so that a
+// failure to fetch one does not stop the watch.
+func runGet(names []string, test bool) {
+	if len(names) == 0 {
+		return
+	}
+	args := []string{"--home", gpath.Home(), "get", "--non-interactive"}
+	if test {
+		args = append(args, "--test")
+	}
+	args = append(args, names...)
+
+	msg.Info("Running glide get %s", strings.Join(names, " "))
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		msg.Err("Unable to get %s: %s", strings.Join(names, " "), err)
+	}
+}
@@ -0,0 +1,55 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSourceSum(t *testing.T) {
+	base, err := ioutil.TempDir("", "glide-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(base)
+
+	write := func(name, content string) {
+		p := filepath.Join(base, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	yamlpath := filepath.Join(base, "glide.yaml")
+	write("glide.yaml", "package: example.com/app\n")
+	write("main.go", "package main\n")
+
+	sum := func() string {
+		s, err := sourceSum(base, yamlpath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	first := sum()
+
+	write("vendor/github.com/foo/bar/bar.go", "package bar\n")
+	write("testdata/x.go", "package x\n")
+	write("README.md", "# app\n")
+	if s := sum(); s != first {
+		t.Error("Expected changes to the vendor directory, testdata, and other files to be ignored")
+	}
+
+	write("cmd/tool/main.go", "package main\n")
+	second := sum()
+	if second == first {
+		t.Error("Expected a new Go file to change the checksum")
+	}
+	write("glide.yaml", "package: example.com/app\nimport:\n- package: github.com/foo/bar\n")
+	if s := sum(); s == second {
+		t.Error("Expected a change to glide.yaml to change the checksum")
+	}
+}
@@ -0,0 +1,100 @@
+package action
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/glide/util"
+)
+
+// Why explains why a dependency is in the lock file. It lists the packages
+// requiring it along with the versions they ask for, and the shortest chain
+// of requirements leading to it from the project.
+//
+// Params:
+//  - basedir (string): the project directory
+//  - name (string): the dependency, or a package in it
+func Why(basedir, name string) {
+	conf := EnsureConfig()
+	if !gpath.HasLock(basedir) {
+		msg.Die("A lock file (%s) is required to explain dependencies. Please run 'glide up'", gpath.LockFile)
+	}
+	lock, err := cfg.ReadLockFile(filepath.Join(basedir, gpath.LockFile))
+	if err != nil {
+		msg.Die("Could not load lockfile: %s", err)
+	}
+
+	root, _ := util.NormalizeName(name)
+	l := lock.Imports.Get(root)
+	kind := "import"
+	if l == nil {
+		l = lock.DevImports.Get(root)
+		kind = "testImport"
+	}
+	if l == nil {
+		msg.Die("%s is not in %s", root, gpath.LockFile)
+	}
+
+	msg.Puts("%s is locked to %s (%s)", l.Name, shortVersion(l.Version), kind)
+	if len(l.RequiredBy) == 0 {
+		msg.Puts("No record of what requires it. Run 'glide up' to record it in %s", gpath.LockFile)
+		return
+	}
+
+	msg.Puts("Required by:")
+	for _, r := range l.RequiredBy {
+		switch {
+		case r.Version == "":
+			msg.Puts("  %s", r.By)
+		case r.Applied:
+			msg.Puts("  %s, asking for %s (used)", r.By, r.Version)
+		default:
+			msg.Puts("  %s, asking for %s", r.By, r.Version)
+		}
+	}
+	if o := conf.OverrideFor(l.Name); o != nil && o.Reference != "" {
+		msg.Puts("Overridden in %s to %s", gpath.GlideFile, o.Reference)
+	}
+	if chain := requirementChain(lock, conf.Name, l.Name); len(chain) > 0 {
+		msg.Puts("Required through: %s", strings.Join(chain, " -> "))
+	}
+}
+
+// requirementChain returns the shortest chain of requirements from the
+// project to a dependency, or nil when the dependency cannot be reached.
+func requirementChain(lock *cfg.Lockfile, project, name string) []string {
+	// Requirements point from a dependency to the packages requiring it, so
+	// the search runs backwards from the dependency to the project.
+	locks := append(append(cfg.Locks{}, lock.Imports...), lock.DevImports...)
+	reqs := make(map[string][]string, len(locks))
+	for _, l := range locks {
+		for _, r := range l.RequiredBy {
+			reqs[l.Name] = append(reqs[l.Name], r.By)
+		}
+	}
+
+	next := map[string]string{name: ""}
+	queue := []string{name}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if n == project {
+			chain := []string{n}
+			for n != name {
+				n = next[n]
+				chain = append(chain, n)
+			}
+			return chain
+		}
+		for _, by := range reqs[n] {
+			if _, seen := next[by]; !seen {
+				next[by] = n
+				queue = append(queue, by)
+			}
+		}
+	}
+	return nil
+}
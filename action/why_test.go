@@ -0,0 +1,41 @@
+package action
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/glide/cfg"
+)
+
+func TestRequirementChain(t *testing.T) {
+	lock := &cfg.Lockfile{
+		Imports: cfg.Locks{
+			{Name: "github.com/a/a", RequiredBy: []*cfg.Requirement{{By: "example.com/app"}}},
+			{Name: "github.com/b/b", RequiredBy: []*cfg.Requirement{{By: "github.com/a/a", Version: "^1.0.0"}}},
+			{Name: "github.com/c/c", RequiredBy: []*cfg.Requirement{
+				{By: "github.com/b/b"},
+				{By: "github.com/d/d"},
+			}},
+			{Name: "github.com/e/e"},
+		},
+		DevImports: cfg.Locks{
+			{Name: "github.com/d/d", RequiredBy: []*cfg.Requirement{{By: "example.com/app"}}},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		chain []string
+	}{
+		{"github.com/a/a", []string{"example.com/app", "github.com/a/a"}},
+		{"github.com/b/b", []string{"example.com/app", "github.com/a/a", "github.com/b/b"}},
+		{"github.com/c/c", []string{"example.com/app", "github.com/d/d", "github.com/c/c"}},
+		{"github.com/e/e", nil},
+	}
+	for _, tt := range tests {
+		chain := requirementChain(lock, "example.com/app", tt.name)
+		if !reflect.DeepEqual(chain, tt.chain) {
+			t.Errorf("Expected chain %v for %s, got %v", tt.chain, tt.name, chain)
+		}
+	}
+}
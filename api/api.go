@@ -0,0 +1,267 @@
+// Package api provides Glide's dependency resolution and installation to Go
+// programs, such as build tools, that embed Glide rather than running the glide
+// command and parsing its output.
+//
+// A typical use loads the config of a project, resolves its dependencies into a
+// lock file and installs them into the vendor directory:
+//
+//	conf, err := api.LoadConfig(dir)
+//	if err != nil {
+//		return err
+//	}
+//	opts := api.Options{Dir: dir, ResolveTest: true}
+//	lock, err := api.Resolve(conf, opts)
+//	defer api.Close()
+//	if err != nil {
+//		return err
+//	}
+//	if err := api.Install(lock, conf, opts); err != nil {
+//		return err
+//	}
+//	return lock.WriteFile(filepath.Join(dir, "glide.lock"))
+//
+// Progress is reported through msg.Default, which callers can configure to
+// redirect or silence it.
+//
+// The functions here are not safe for concurrent use. Glide keeps its settings
+// in package level variables, and Options.Dir is applied by changing the
+// working directory of the whole process for the length of a call. Each call
+// takes the lock on the project while it runs, and the lock on the cache until
+// Close is called, so other Glide processes wait on them meanwhile.
+//
+// Failures are returned as errors. A few, such as a resolver hook that cannot
+// be asked or a cache directory that cannot be created, still end the process
+// when they happen outside of the goroutine making the call.
+package api
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/mirrors"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/glide/repo"
+)
+
+// Options controls how dependencies are resolved and installed.
+type Options struct {
+	// Dir is the directory of the project. It defaults to the working
+	// directory.
+	Dir string
+
+	// Home is the Glide home directory holding the cache. It defaults to
+	// $GLIDE_HOME or ~/.glide.
+	Home string
+
+	// ResolveTest sets if test dependencies are resolved and installed.
+	ResolveTest bool
+
+	// ResolveAllFiles examines the imports of every file of every package
+	// rather than only the packages that are imported.
+	ResolveAllFiles bool
+
+	// Force the operation when normally stopping conditions occur, such as a
+	// dependency whose location changed.
+	Force bool
+
+	// Updated, when set, tracks the repositories fetched into the cache. Sharing
+	// it between calls skips fetching a repository again after the first call.
+	Updated *repo.UpdateTracker
+}
+
+// LoadConfig reads the glide.yaml file in dir, along with the configs it
+// extends, and registers the mirrors it lists.
+func LoadConfig(dir string) (*cfg.Config, error) {
+	p := filepath.Join(dir, gpath.GlideFile)
+	yml, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	if errs := cfg.Validate(yml); hasErrors(errs) {
+		return nil, &ValidationError{File: p, Errors: errs}
+	}
+	conf, err := cfg.ConfigFromYaml(yml)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse %s: %s", p, err)
+	}
+	if err := conf.ResolveExtends(dir); err != nil {
+		return nil, fmt.Errorf("Failed to load the config %s extends: %s", p, err)
+	}
+
+	if err := mirrors.Load(); err != nil {
+		return nil, fmt.Errorf("Unable to load mirrors: %s", err)
+	}
+	mirrors.Add(conf.Mirrors)
+	return conf, nil
+}
+
+// LoadLock reads the glide.lock file in dir.
+func LoadLock(dir string) (*cfg.Lockfile, error) {
+	return cfg.ReadLockFile(filepath.Join(dir, gpath.LockFile))
+}
+
+// ValidationError is returned by LoadConfig when a glide.yaml file is not
+// valid.
+type ValidationError struct {
+	File   string
+	Errors []*cfg.ValidationError
+}
+
+func (e *ValidationError) Error() string {
+	for _, v := range e.Errors {
+		if !v.Warning {
+			return fmt.Sprintf("%s: %s", e.File, v)
+		}
+	}
+	return e.File + " is not valid"
+}
+
+func hasErrors(errs []*cfg.ValidationError) bool {
+	for _, e := range errs {
+		if !e.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve fetches the dependencies of the project in opts.Dir, and those of
+// its dependencies, into the cache and returns a lock file pinning each of them
+// to a commit. Nothing in the project is changed.
+func Resolve(conf *cfg.Config, opts Options) (lock *cfg.Lockfile, err error) {
+	err = run(conf, opts, func(i *repo.Installer) error {
+		hash, err := conf.Hash()
+		if err != nil {
+			return fmt.Errorf("Failed to generate config hash: %s", err)
+		}
+
+		c := conf.Clone()
+		if err := i.Checkout(c); err != nil {
+			return fmt.Errorf("Failed to do initial checkout of config: %s", err)
+		}
+		if err := repo.SetReference(c, i.ResolveTest); err != nil {
+			return fmt.Errorf("Failed to set initial config references: %s", err)
+		}
+		if err := i.Update(c); err != nil {
+			return fmt.Errorf("Could not update packages: %s", err)
+		}
+		if err := repo.SetReference(c, i.ResolveTest); err != nil {
+			return fmt.Errorf("Failed to set references: %s", err)
+		}
+
+		lock, err = cfg.NewLockfile(c.Imports, c.DevImports, hash)
+		return err
+	})
+	return lock, err
+}
+
+// Install exports the dependencies pinned in lock from the cache into the
+// vendor directory of the project in opts.Dir, fetching any that are missing.
+// The config is used for the settings that apply when installing, such as
+// local copies and tracked branches.
+func Install(lock *cfg.Lockfile, conf *cfg.Config, opts Options) error {
+	return run(conf, opts, func(i *repo.Installer) error {
+		if err := os.MkdirAll(gpath.VendorDir, 0755); err != nil {
+			return err
+		}
+
+		c, err := i.Install(lock, conf)
+		if err != nil {
+			return fmt.Errorf("Failed to install: %s", err)
+		}
+		if err := repo.SetReference(c, i.ResolveTest); err != nil {
+			return fmt.Errorf("Failed to set references: %s", err)
+		}
+		if err := i.Export(c); err != nil {
+			return fmt.Errorf("Unable to export dependencies to vendor directory: %s", err)
+		}
+		return nil
+	})
+}
+
+// InProject calls fn in the project directory of opts, with the vendor
+// directory set in conf, the way Resolve and Install do their work. It lets
+// tools inspect a project, such as its vendored packages, under the same locks
+// and with a msg.Die call returned as an error.
+func InProject(conf *cfg.Config, opts Options, fn func() error) error {
+	return run(conf, opts, func(*repo.Installer) error {
+		return fn()
+	})
+}
+
+// Close releases the locks on the Glide cache and project taken while
+// resolving or installing dependencies. Call it once done using Glide.
+func Close() {
+	cache.ReleaseLocks()
+}
+
+// run calls fn with an installer configured from opts, in the project
+// directory, installing into the vendor directory set in conf. Calls to
+// msg.Die made along the way are returned as errors rather than ending the
+// process. The lock on the project is released once fn returns.
+func run(conf *cfg.Config, opts Options, fn func(*repo.Installer) error) (err error) {
+	if opts.Home != "" {
+		gpath.SetHome(opts.Home)
+	}
+	gpath.VendorDir = conf.VendorPath()
+	if opts.Dir != "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		if err := os.Chdir(opts.Dir); err != nil {
+			return err
+		}
+		defer os.Chdir(wd)
+	}
+
+	base, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	plock := filepath.Join(base, gpath.ProjectLockFile)
+	if err := cache.LockFile(plock, "the project"); err != nil {
+		return err
+	}
+	defer cache.UnlockFile(plock)
+	if err := cache.SystemLock(); err != nil {
+		return err
+	}
+
+	i := repo.NewInstaller()
+	i.Home = gpath.Home()
+	i.ResolveTest = opts.ResolveTest
+	i.ResolveAllFiles = opts.ResolveAllFiles
+	i.Force = opts.Force
+	if opts.Updated != nil {
+		i.Updated = opts.Updated
+	}
+
+	return catchDie(func() error {
+		return fn(i)
+	})
+}
+
+// catchDie calls fn, returning the message of a msg.Die call made by it as an
+// error. A panic in another goroutine cannot be recovered here, so the workers
+// fetching and exporting dependencies return their failures instead.
+func catchDie(fn func() error) (err error) {
+	old := msg.Default.PanicOnDie
+	msg.Default.PanicOnDie = true
+	defer func() {
+		msg.Default.PanicOnDie = old
+		if r := recover(); r != nil {
+			d, ok := r.(*msg.Died)
+			if !ok {
+				panic(r)
+			}
+			err = d
+		}
+	}()
+	return fn()
+}
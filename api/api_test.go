@@ -0,0 +1,54 @@
+package api
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/glide/msg"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := filepath.Join(dir, "glide.yaml")
+	ioutil.WriteFile(p, []byte("package: fake/testing\nimport:\n- package: github.com/Masterminds/semver\n  version: ^1.0.0\n"), 0644)
+	conf, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Name != "fake/testing" || !conf.HasDependency("github.com/Masterminds/semver") {
+		t.Errorf("Unexpected config loaded: %v", conf)
+	}
+
+	ioutil.WriteFile(p, []byte("package: fake/testing\nimports:\n- package: github.com/Masterminds/semver\n"), 0644)
+	_, err = LoadConfig(dir)
+	if _, ok := err.(*ValidationError); !ok {
+		t.Errorf("Expected a ValidationError, got %v", err)
+	}
+
+	if _, err := LoadLock(dir); err == nil {
+		t.Error("Expected an error loading a missing lock file")
+	}
+}
+
+func TestCatchDie(t *testing.T) {
+	msg.Default.Stderr = ioutil.Discard
+	defer func() { msg.Default.Stderr = os.Stderr }()
+
+	err := catchDie(func() error {
+		msg.Die("Failed to %s", "resolve")
+		return nil
+	})
+	if err == nil || err.Error() != "Failed to resolve" {
+		t.Errorf("Expected the Die message as the error, got %v", err)
+	}
+	if msg.Default.PanicOnDie {
+		t.Error("Expected PanicOnDie to be restored")
+	}
+}
@@ -0,0 +1,415 @@
+// Package archive provides dependencies distributed as tarballs or zip files
+// rather than through a VCS.
+//
+// Archives are downloaded over http(s), verified against a checksum, and
+// unpacked. The source tarball of a GitHub release, given as
+// github-release://owner/repo@tag, is found through the Releases API. When an
+// archive has a single top level directory, as is common for source
+// snapshots, its contents are used as the root of the dependency.
+//
+// Repo implements the vcs.Repo interface so archives can be handled like any
+// other dependency. The version of an archive is its checksum, in the form
+// sha256:<hex>, which is what ends up in the glide.lock file.
+package archive
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/glide/hosting"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/vcs"
+)
+
+// Type is the vcs.Type reported for archives.
+const Type vcs.Type = "archive"
+
+// metaFile is stored in the unpacked archive to record where it came from.
+const metaFile = ".glide-archive"
+
+// ErrNotSupported is returned for operations archives do not support.
+var ErrNotSupported = errors.New("Operation not supported for archives")
+
+// extensions lists the file extensions of the supported archive formats.
+var extensions = []string{".tar.gz", ".tgz", ".tar.bz2", ".tbz2", ".tar", ".zip"}
+
+// IsArchive returns true if the location looks like an archive URL or is a
+// GitHub release.
+func IsArchive(remote string) bool {
+	if IsRelease(remote) {
+		return true
+	}
+	if !strings.HasPrefix(remote, "https://") && !strings.HasPrefix(remote, "http://") {
+		return false
+	}
+	r := strings.ToLower(remote)
+	if i := strings.IndexAny(r, "?#"); i >= 0 {
+		r = r[:i]
+	}
+	for _, e := range extensions {
+		if strings.HasSuffix(r, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsChecksum returns true if the string is a checksum in the form
+// sha256:<hex>.
+func IsChecksum(s string) bool {
+	_, err := parseChecksum(s)
+	return err == nil && strings.HasPrefix(s, "sha256:")
+}
+
+// ChecksumError is returned when an archive does not have the checksum it is
+// expected to have.
+type ChecksumError struct {
+	Remote, Expected, Actual string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("Checksum mismatch for %s: expected %s, got %s", e.Remote, e.Expected, e.Actual)
+}
+
+// Kind returns msg.KindHashMismatch.
+func (e *ChecksumError) Kind() msg.Kind {
+	return msg.KindHashMismatch
+}
+
+// Repo is an archive unpacked into a local directory.
+type Repo struct {
+	remote, local, checksum string
+}
+
+type meta struct {
+	URL      string `json:"url"`
+	Checksum string `json:"checksum"`
+}
+
+// NewRepo creates a Repo for the archive at remote unpacked in local. When
+// checksum is set, in the form sha256:<hex> or as plain hex, downloads must
+// match it.
+func NewRepo(remote, local, checksum string) (*Repo, error) {
+	if checksum != "" {
+		c, err := parseChecksum(checksum)
+		if err != nil {
+			return nil, err
+		}
+		checksum = c
+	}
+	return &Repo{remote: remote, local: local, checksum: checksum}, nil
+}
+
+// Vcs returns the archive type.
+func (r *Repo) Vcs() vcs.Type {
+	return Type
+}
+
+// Remote returns the URL of the archive.
+func (r *Repo) Remote() string {
+	return r.remote
+}
+
+// LocalPath returns the directory the archive is unpacked in.
+func (r *Repo) LocalPath() string {
+	return r.local
+}
+
+// Get downloads, verifies, and unpacks the archive.
+func (r *Repo) Get() error {
+	tmp, err := ioutil.TempDir(gpath.Tmp, "glide-archive")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	u, err := downloadURL(r.remote)
+	if err != nil {
+		return err
+	}
+	f := filepath.Join(tmp, "archive")
+	sum, err := download(u, f)
+	if err != nil {
+		return err
+	}
+	if r.checksum == "" {
+		msg.Warn("No checksum is set for %s. Its checksum is %s", r.remote, sum)
+	} else if sum != r.checksum {
+		return &ChecksumError{Remote: r.remote, Expected: r.checksum, Actual: sum}
+	}
+
+	// Release tarballs are served without an extension.
+	name := r.remote
+	if IsRelease(r.remote) {
+		name += ".tar.gz"
+	}
+	dir := filepath.Join(tmp, "src")
+	if err := unpack(f, name, dir); err != nil {
+		return fmt.Errorf("Unable to unpack %s: %s", r.remote, err)
+	}
+	root, err := archiveRoot(dir)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(meta{URL: r.remote, Checksum: sum})
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, metaFile), b, 0644); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(r.local); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.local), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(root, r.local); err != nil {
+		return gpath.CopyDir(root, r.local)
+	}
+	return nil
+}
+
+// Init is not supported for archives.
+func (r *Repo) Init() error {
+	return ErrNotSupported
+}
+
+// Update downloads the archive again unless the unpacked copy already
+// matches the expected checksum.
+func (r *Repo) Update() error {
+	if v, err := r.Version(); err == nil && v == r.checksum {
+		msg.Debug("%s already matches %s", r.remote, r.checksum)
+		return nil
+	}
+	return r.Get()
+}
+
+// UpdateVersion verifies the unpacked archive has the given checksum. Other
+// versions, such as those used as labels, are accepted as is because an
+// archive holds a single version.
+func (r *Repo) UpdateVersion(v string) error {
+	if !IsChecksum(v) {
+		return nil
+	}
+	c, err := r.Version()
+	if err != nil {
+		return err
+	}
+	if c != v {
+		return &ChecksumError{Remote: r.remote, Expected: v, Actual: c}
+	}
+	return nil
+}
+
+// Version returns the checksum of the archive.
+func (r *Repo) Version() (string, error) {
+	m, err := r.meta()
+	if err != nil {
+		return "", err
+	}
+	return m.Checksum, nil
+}
+
+// Current returns the checksum of the archive.
+func (r *Repo) Current() (string, error) {
+	return r.Version()
+}
+
+// Date returns when the archive was unpacked.
+func (r *Repo) Date() (time.Time, error) {
+	fi, err := os.Stat(filepath.Join(r.local, metaFile))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fi.ModTime(), nil
+}
+
+// CheckLocal returns true if the archive was unpacked in the local path.
+func (r *Repo) CheckLocal() bool {
+	_, err := r.meta()
+	return err == nil
+}
+
+// Branches returns no branches as archives have none.
+func (r *Repo) Branches() ([]string, error) {
+	return []string{}, nil
+}
+
+// Tags returns no tags as archives have none.
+func (r *Repo) Tags() ([]string, error) {
+	return []string{}, nil
+}
+
+// IsReference returns true for every reference. An archive holds a single
+// version which UpdateVersion checks.
+func (r *Repo) IsReference(string) bool {
+	return true
+}
+
+// IsDirty returns false as changes to unpacked archives are not tracked.
+func (r *Repo) IsDirty() bool {
+	return false
+}
+
+// CommitInfo describes the archive as if it were a commit.
+func (r *Repo) CommitInfo(id string) (*vcs.CommitInfo, error) {
+	v, err := r.Version()
+	if err != nil {
+		return nil, err
+	}
+	if id != v {
+		return nil, vcs.ErrRevisionUnavailable
+	}
+	d, err := r.Date()
+	if err != nil {
+		return nil, err
+	}
+	return &vcs.CommitInfo{Commit: v, Date: d, Message: "Archive " + r.remote}, nil
+}
+
+// TagsFromCommit returns no tags as archives have none.
+func (r *Repo) TagsFromCommit(string) ([]string, error) {
+	return []string{}, nil
+}
+
+// Ping returns true if the archive can be reached.
+func (r *Repo) Ping() bool {
+	u, err := downloadURL(r.remote)
+	if err != nil {
+		return false
+	}
+	req, err := http.NewRequest("HEAD", u, nil)
+	if err != nil {
+		return false
+	}
+	hosting.Authorize(req)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
+
+// RunFromDir runs a command from the directory the archive is unpacked in,
+// logging its output at the debug level.
+func (r *Repo) RunFromDir(cmd string, args ...string) ([]byte, error) {
+	c := exec.Command(cmd, args...)
+	c.Dir = r.local
+	return msg.CombinedOutput(cmd, c)
+}
+
+// CmdFromDir creates a command that runs from the directory the archive is
+// unpacked in. Its stderr is logged at the debug level.
+func (r *Repo) CmdFromDir(cmd string, args ...string) *exec.Cmd {
+	c := exec.Command(cmd, args...)
+	c.Dir = r.local
+	c.Stderr = msg.Default.Writer(cmd)
+	return c
+}
+
+// ExportDir copies the unpacked archive to dir.
+func (r *Repo) ExportDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	files, err := ioutil.ReadDir(r.local)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.Name() == metaFile {
+			continue
+		}
+		from := filepath.Join(r.local, f.Name())
+		to := filepath.Join(dir, f.Name())
+		if f.IsDir() {
+			err = gpath.CopyDir(from, to)
+		} else {
+			err = gpath.CopyFile(from, to)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repo) meta() (*meta, error) {
+	b, err := ioutil.ReadFile(filepath.Join(r.local, metaFile))
+	if err != nil {
+		return nil, err
+	}
+	m := &meta{}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// parseChecksum normalizes a checksum to the form sha256:<hex>.
+func parseChecksum(s string) (string, error) {
+	h := strings.ToLower(strings.TrimPrefix(s, "sha256:"))
+	if b, err := hex.DecodeString(h); err != nil || len(b) != sha256.Size {
+		return "", fmt.Errorf("Invalid checksum %q: must be a sha256 in the form sha256:<hex>", s)
+	}
+	return "sha256:" + h, nil
+}
+
+// download saves the file at url to dest and returns its checksum.
+func download(url, dest string) (string, error) {
+	msg.Debug("Downloading %s", url)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	hosting.Authorize(req)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("Unable to download %s: %s", url, resp.Status)
+	}
+
+	f, err := os.Create(dest)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), resp.Body); err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// archiveRoot returns the directory holding the contents of an unpacked
+// archive. When the archive has a single top level directory that is used.
+func archiveRoot(dir string) (string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 1 && files[0].IsDir() {
+		return filepath.Join(dir, files[0].Name()), nil
+	}
+	return dir, nil
+}
@@ -0,0 +1,169 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testTarball(t *testing.T) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	files := map[string]string{
+		"foo-1.0/foo.go":     "package foo",
+		"foo-1.0/bar/bar.go": "package bar",
+	}
+	for n, c := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: n, Mode: 0644, Size: int64(len(c)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(c))
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestIsArchive(t *testing.T) {
+	tests := map[string]bool{
+		"https://example.com/foo-1.0.tar.gz":       true,
+		"https://example.com/foo-1.0.zip?raw=true": true,
+		"http://example.com/foo.tgz":               true,
+		"https://github.com/Masterminds/vcs":       false,
+		"git@example.com:foo.tar.gz":               false,
+		"github-release://foo/bar@v1.0.0":          true,
+		"github-release://foo/bar":                 false,
+	}
+	for in, want := range tests {
+		if IsArchive(in) != want {
+			t.Errorf("IsArchive(%s) = %t, want %t", in, !want, want)
+		}
+	}
+}
+
+func TestParseRelease(t *testing.T) {
+	repo, tag, err := parseRelease("github-release://foo/bar@v1.0.0")
+	if err != nil || repo != "github.com/foo/bar" || tag != "v1.0.0" {
+		t.Errorf("Unexpected release %s %s (%v)", repo, tag, err)
+	}
+	for _, r := range []string{"github-release://foo@v1.0.0", "github-release://foo/bar@", "https://github.com/foo/bar"} {
+		if _, _, err := parseRelease(r); err == nil {
+			t.Errorf("Expected %s to be invalid", r)
+		}
+	}
+}
+
+func TestRepo(t *testing.T) {
+	tb := testTarball(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(tb)
+	}))
+	defer srv.Close()
+	sum := sha256.Sum256(tb)
+	checksum := "sha256:" + hex.EncodeToString(sum[:])
+
+	dir, err := ioutil.TempDir("", "glide-archive-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r, err := NewRepo(srv.URL+"/foo-1.0.tar.gz", filepath.Join(dir, "cache"), checksum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Get(); err != nil {
+		t.Fatalf("Unable to get archive: %s", err)
+	}
+	if v, _ := r.Version(); v != checksum {
+		t.Errorf("Expected version %s, got %s", checksum, v)
+	}
+	if err := r.UpdateVersion(checksum); err != nil {
+		t.Errorf("Unexpected error setting version: %s", err)
+	}
+
+	out := filepath.Join(dir, "vendor")
+	if err := r.ExportDir(out); err != nil {
+		t.Fatalf("Unable to export archive: %s", err)
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(out, "bar", "bar.go")); err != nil || string(b) != "package bar" {
+		t.Errorf("Unexpected exported content %q (%v)", b, err)
+	}
+	if _, err := os.Stat(filepath.Join(out, metaFile)); err == nil {
+		t.Error("Expected the archive metadata not to be exported")
+	}
+
+	bad, _ := NewRepo(srv.URL+"/foo-1.0.tar.gz", filepath.Join(dir, "bad"), "sha256:"+hex.EncodeToString(make([]byte, 32)))
+	if err := bad.Get(); err == nil {
+		t.Error("Expected a checksum mismatch")
+	}
+}
+
+func TestUntarSymlinks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-untar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "a", "b", "out")
+
+	untar := func(entries ...*tar.Header) error {
+		os.RemoveAll(out)
+		if err := os.MkdirAll(out, 0755); err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for _, h := range entries {
+			if err := tw.WriteHeader(h); err != nil {
+				t.Fatal(err)
+			}
+			if h.Size > 0 {
+				tw.Write(bytes.Repeat([]byte("x"), int(h.Size)))
+			}
+		}
+		tw.Close()
+		return Untar(&buf, out)
+	}
+	link := func(name, target string) *tar.Header {
+		return &tar.Header{Name: name, Linkname: target, Typeflag: tar.TypeSymlink}
+	}
+	file := func(name string) *tar.Header {
+		return &tar.Header{Name: name, Mode: 0644, Size: 1, Typeflag: tar.TypeReg}
+	}
+
+	// Each link stays within out on its own, but together they lead out.
+	err = untar(link("d1/d2/x", "../.."), link("d1/d2/x/y", "../.."), file("d1/d2/x/y/escaped.txt"))
+	if err == nil {
+		t.Error("Expected an error for a chain of symlinks leading out")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a", "escaped.txt")); err == nil {
+		t.Error("Expected no file to be written outside of the archive")
+	}
+
+	if err := untar(link("foo", "../outside")); err == nil {
+		t.Error("Expected an error for a symlink pointing outside")
+	}
+
+	// A file replacing a symlink is not written through it.
+	err = untar(link("foo", "bar"), file("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Lstat(filepath.Join(out, "foo")); err != nil || fi.Mode()&os.ModeSymlink != 0 {
+		t.Error("Expected the symlink to be replaced by a file")
+	}
+
+	if err := untar(link("lib", "src"), file("src/a.go")); err != nil {
+		t.Errorf("Expected symlinks within the archive to be extracted: %s", err)
+	}
+}
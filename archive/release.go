@@ -0,0 +1,54 @@
+package archive
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/glide/hosting"
+)
+
+// releasePrefix starts the location of the source tarball of a GitHub release,
+// as in github-release://owner/repo@v1.2.0.
+const releasePrefix = "github-release://"
+
+// IsRelease returns true if the location is a GitHub release in the form
+// github-release://owner/repo@tag.
+func IsRelease(remote string) bool {
+	_, _, err := parseRelease(remote)
+	return err == nil
+}
+
+// parseRelease splits the location of a GitHub release into the repo, as in
+// github.com/owner/repo, and the tag.
+func parseRelease(remote string) (string, string, error) {
+	if !strings.HasPrefix(remote, releasePrefix) {
+		return "", "", fmt.Errorf("%s is not a GitHub release", remote)
+	}
+	r := strings.TrimPrefix(remote, releasePrefix)
+	i := strings.LastIndex(r, "@")
+	if i < 0 || i == len(r)-1 {
+		return "", "", fmt.Errorf("The GitHub release %s has no tag. Use %sowner/repo@tag", remote, releasePrefix)
+	}
+	parts := strings.Split(r[:i], "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("Invalid GitHub release %s. Use %sowner/repo@tag", remote, releasePrefix)
+	}
+	return "github.com/" + r[:i], r[i+1:], nil
+}
+
+// downloadURL returns the URL the archive at remote is downloaded from. The
+// source tarball of a GitHub release is looked up through the Releases API.
+func downloadURL(remote string) (string, error) {
+	if !strings.HasPrefix(remote, releasePrefix) {
+		return remote, nil
+	}
+	repo, tag, err := parseRelease(remote)
+	if err != nil {
+		return "", err
+	}
+	u, err := hosting.ReleaseTarball(repo, tag)
+	if err != nil {
+		return "", fmt.Errorf("Unable to find release %s of %s: %s", tag, repo, err)
+	}
+	return u, nil
+}
@@ -0,0 +1,193 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// unpack extracts the archive file f into dir. The format is taken from the
+// extension of name.
+func unpack(f, name, dir string) error {
+	n := strings.ToLower(name)
+	if i := strings.IndexAny(n, "?#"); i >= 0 {
+		n = n[:i]
+	}
+
+	if strings.HasSuffix(n, ".zip") {
+		return unzip(f, dir)
+	}
+
+	file, err := os.Open(f)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	switch {
+	case strings.HasSuffix(n, ".tar.gz") || strings.HasSuffix(n, ".tgz"):
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	case strings.HasSuffix(n, ".tar.bz2") || strings.HasSuffix(n, ".tbz2"):
+		r = bzip2.NewReader(file)
+	}
+	return Untar(r, dir)
+}
+
+// Untar extracts the tar stream r into dir. Entries may not point outside of
+// dir, nor may the targets of symlinks. No entry is written below a symlink,
+// so a chain of symlinks cannot lead outside of dir either.
+func Untar(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		p, err := entryPath(dir, h.Name)
+		if err != nil {
+			return err
+		}
+		switch h.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(p, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := writeFile(p, tr, os.FileMode(h.Mode)); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			// Links are not allowed to point outside of the archive.
+			if filepath.IsAbs(h.Linkname) {
+				return fmt.Errorf("Archive entry %s links outside of the archive", h.Name)
+			}
+			if _, err := archivePath(dir, filepath.Join(filepath.Dir(h.Name), h.Linkname)); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+				return err
+			}
+			if err := removeEntry(p); err != nil {
+				return err
+			}
+			if err := os.Symlink(h.Linkname, p); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func unzip(f, dir string) error {
+	zr, err := zip.OpenReader(f)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, zf := range zr.File {
+		p, err := entryPath(dir, zf.Name)
+		if err != nil {
+			return err
+		}
+		if zf.FileInfo().IsDir() {
+			if err := os.MkdirAll(p, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+
+		rc, err := zf.Open()
+		if err != nil {
+			return err
+		}
+		err = writeFile(p, rc, zf.Mode())
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archivePath returns where an entry of an archive is written, making sure it
+// stays within dir.
+func archivePath(dir, name string) (string, error) {
+	p := filepath.Join(dir, filepath.FromSlash(name))
+	if p != dir && !strings.HasPrefix(p, dir+string(os.PathSeparator)) {
+		return "", fmt.Errorf("Archive entry %s is outside of the archive", name)
+	}
+	return p, nil
+}
+
+// entryPath returns where an entry of an archive is written, making sure it
+// stays within dir and that none of the directories leading to it within dir
+// is a symlink, which could point anywhere.
+func entryPath(dir, name string) (string, error) {
+	p, err := archivePath(dir, name)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(dir, filepath.Dir(p))
+	if err != nil || rel == "." {
+		return p, err
+	}
+	cur := dir
+	for _, part := range strings.Split(rel, string(os.PathSeparator)) {
+		cur = filepath.Join(cur, part)
+		fi, err := os.Lstat(cur)
+		if os.IsNotExist(err) {
+			break
+		} else if err != nil {
+			return "", err
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("Archive entry %s is below a symlink", name)
+		}
+	}
+	return p, nil
+}
+
+// removeEntry removes what an earlier entry of an archive left at p, unless it
+// is a directory, so p is not written through a symlink.
+func removeEntry(p string) error {
+	fi, err := os.Lstat(p)
+	if os.IsNotExist(err) || (err == nil && fi.IsDir()) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return os.Remove(p)
+}
+
+func writeFile(p string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	if err := removeEntry(p); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode.Perm()|0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -9,10 +9,11 @@
 // To get the cache location use the `cache.Location()` function. This will
 // return the proper base location in your environment.
 //
-// Within the cache directory there are two subdirectories. They are the src
-// and info directories. The src directory contains version control checkouts
-// of the packages. The info direcory contains metadata. The metadata maps to
-// the RepoInfo struct. Both stores are happed to keys.
+// Within the cache directory there are three subdirectories. They are the src,
+// info, and export directories. The src directory contains version control
+// checkouts of the packages. The info direcory contains metadata. The metadata
+// maps to the RepoInfo struct. The export directory holds a single export of
+// each revision placed in a vendor directory. The stores are happed to keys.
 //
 // Using the `cache.Key()` function you can get a key for a repo. Pass in a
 // location such as `https://github.com/foo/bar` or `git@example.com:foo.git`
@@ -28,8 +29,10 @@
 package cache
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/url"
 	"os"
@@ -66,6 +69,7 @@ func Setup() {
 		"cache",
 		filepath.Join("cache", "src"),
 		filepath.Join("cache", "info"),
+		filepath.Join("cache", "export"),
 	}
 
 	for _, l := range pths {
@@ -92,6 +96,19 @@ func Location() string {
 	return p
 }
 
+// revRe matches revisions that are safe to use as a directory name.
+var revRe = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// ExportLocation returns the location where the export of a revision of the
+// repo with the given key is stored. Revisions are commit ids or other
+// immutable identifiers so the content at a location never changes.
+func ExportLocation(key, rev string) string {
+	if !revRe.MatchString(rev) {
+		rev = fmt.Sprintf("%x", sha256.Sum256([]byte(rev)))
+	}
+	return filepath.Join(Location(), "export", key, rev)
+}
+
 // scpSyntaxRe matches the SCP-like addresses used to access repos over SSH.
 var scpSyntaxRe = regexp.MustCompile(`^([a-zA-Z0-9_]+)@([a-zA-Z0-9._-]+):(.*)$`)
 
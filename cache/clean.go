@@ -0,0 +1,256 @@
+package cache
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// started is when Glide started. Entries used since then are never evicted.
+var started = time.Now()
+
+// Entry describes a repo stored in the cache.
+type Entry struct {
+	// Key is the cache key of the repo.
+	Key string
+
+	// Size is the disk space used by the checkout and exports in bytes.
+	Size int64
+
+	// LastUsed is when the entry was last used.
+	LastUsed time.Time
+}
+
+// Settings holds the cache settings from the config.yaml file in GLIDE_HOME.
+//
+// An example config.yaml file:
+//
+//     cache:
+//       max-size: 10G
+//       max-age: 90
+//       meta-ttl: 168h
+type Settings struct {
+	// MaxSize caps the total size of the cache (e.g., 500M or 10G). When the
+	// cache grows larger the least recently used entries are evicted.
+	MaxSize string `yaml:"max-size,omitempty"`
+
+	// MaxAge is the number of days an entry is kept without being used.
+	MaxAge int `yaml:"max-age,omitempty"`
+
+	// MetaTTL is how long the remote metadata of import paths is cached (e.g.,
+	// 24h).
+	MetaTTL string `yaml:"meta-ttl,omitempty"`
+}
+
+// ReadSettings reads the cache settings from the global config.yaml file. When
+// there is no such file empty settings are returned.
+func ReadSettings() (*Settings, error) {
+	s := &Settings{}
+	if err := gpath.GlobalConfig("cache", s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ParseSize parses a size such as 1024, 500K, 200M, or 10G into bytes.
+func ParseSize(s string) (int64, error) {
+	s = strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(s)), "B")
+	mult := int64(1)
+	if s != "" {
+		switch s[len(s)-1] {
+		case 'K':
+			mult = 1 << 10
+		case 'M':
+			mult = 1 << 20
+		case 'G':
+			mult = 1 << 30
+		case 'T':
+			mult = 1 << 40
+		}
+		if mult != 1 {
+			s = s[:len(s)-1]
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("Invalid size %q", s)
+	}
+	return n * mult, nil
+}
+
+// Touch records that the repo with the given key was used.
+func Touch(key string) {
+	now := time.Now()
+	for _, p := range entryPaths(key) {
+		if err := os.Chtimes(p, now, now); err != nil && !os.IsNotExist(err) {
+			msg.Debug("Unable to record use of %s: %s", p, err)
+		}
+	}
+}
+
+// Entries lists the repos in the cache, least recently used first.
+func Entries() ([]*Entry, error) {
+	keys := make(map[string]bool)
+	for _, d := range []string{"src", "export"} {
+		files, err := ioutil.ReadDir(filepath.Join(Location(), d))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, f := range files {
+			if f.IsDir() {
+				keys[f.Name()] = true
+			}
+		}
+	}
+
+	entries := make([]*Entry, 0, len(keys))
+	for k := range keys {
+		e := &Entry{Key: k}
+		for _, p := range entryPaths(k) {
+			fi, err := os.Stat(p)
+			if os.IsNotExist(err) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			if fi.ModTime().After(e.LastUsed) {
+				e.LastUsed = fi.ModTime()
+			}
+			s, err := dirSize(p)
+			if err != nil {
+				return nil, err
+			}
+			e.Size += s
+		}
+		entries = append(entries, e)
+	}
+
+	sort.Sort(byLastUsed(entries))
+	return entries, nil
+}
+
+// byLastUsed sorts entries by the time they were last used, oldest first.
+type byLastUsed []*Entry
+
+func (b byLastUsed) Len() int      { return len(b) }
+func (b byLastUsed) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byLastUsed) Less(i, j int) bool {
+	if b[i].LastUsed.Equal(b[j].LastUsed) {
+		return b[i].Key < b[j].Key
+	}
+	return b[i].LastUsed.Before(b[j].LastUsed)
+}
+
+// Remove deletes the repo with the given key from the cache.
+func Remove(key string) error {
+	for _, p := range entryPaths(key) {
+		if err := gpath.CustomRemoveAll(p); err != nil {
+			return err
+		}
+	}
+	err := os.Remove(filepath.Join(Location(), "info", key+".json"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Move moves the repo with the key from in the cache to the key to, such as
+// when it moved to another remote. Nothing is moved when to is cached already.
+func Move(from, to string) error {
+	l := Location()
+	if _, err := os.Stat(filepath.Join(l, "src", to)); err == nil {
+		return nil
+	}
+	for _, p := range []string{"src", "export"} {
+		src := filepath.Join(l, p, from)
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		dest := filepath.Join(l, p, to)
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(src, dest); err != nil {
+			return err
+		}
+	}
+	err := os.Rename(filepath.Join(l, "info", from+".json"), filepath.Join(l, "info", to+".json"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Evict removes the entries not used within maxAge, when maxAge is above zero,
+// and then the least recently used entries until the cache is no larger than
+// maxSize, when maxSize is above zero. Entries used since Glide started are
+// kept. The removed entries are returned.
+func Evict(maxAge time.Duration, maxSize int64) ([]*Entry, error) {
+	entries, err := Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, e := range entries {
+		total += e.Size
+	}
+
+	var removed []*Entry
+	for _, e := range entries {
+		if !e.LastUsed.Before(started) {
+			continue
+		}
+		old := maxAge > 0 && time.Since(e.LastUsed) > maxAge
+		big := maxSize > 0 && total > maxSize
+		if !old && !big {
+			continue
+		}
+		if err := Remove(e.Key); err != nil {
+			return removed, err
+		}
+		total -= e.Size
+		removed = append(removed, e)
+	}
+	return removed, nil
+}
+
+// entryPaths returns the directories the repo with the given key uses.
+func entryPaths(key string) []string {
+	l := Location()
+	return []string{
+		filepath.Join(l, "src", key),
+		filepath.Join(l, "export", key),
+	}
+}
+
+// RepoSize returns the size of the cached copy of the repo with the given key,
+// or 0 when it is not cached.
+func RepoSize(key string) int64 {
+	size, _ := dirSize(filepath.Join(Location(), "src", key))
+	return size
+}
+
+// dirSize returns the size of the files below dir.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.Mode().IsRegular() {
+			size += fi.Size()
+		}
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,26 @@
+package cache
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"1024":  1024,
+		"500K":  500 << 10,
+		"200mb": 200 << 20,
+		"10G":   10 << 30,
+	}
+	for in, want := range tests {
+		got, err := ParseSize(in)
+		if err != nil {
+			t.Errorf("Unexpected error parsing %s: %s", in, err)
+		} else if got != want {
+			t.Errorf("ParseSize(%s) = %d, want %d", in, got, want)
+		}
+	}
+
+	for _, in := range []string{"", "G", "-1", "10X"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Errorf("Expected an error parsing %q", in)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/glide/msg"
+)
+
+// LockTimeout is how long to wait on a lock held by another Glide process
+// before giving up. Zero waits for as long as the lock is held.
+var LockTimeout time.Duration
+
+const (
+	// lockRefresh is how often a held lock file is touched.
+	lockRefresh = 5 * time.Second
+
+	// lockStale is how long after it was last touched a lock file is taken
+	// to be left behind by a process that exited without removing it. The
+	// holder may be on another host sharing the cache so it is not checked
+	// on directly.
+	lockStale = 15 * time.Second
+
+	// lockPoll is how often a lock held by another process is checked on.
+	lockPoll = time.Second
+)
+
+type lockdata struct {
+	Comment string `json:"comment"`
+	Pid     int    `json:"pid"`
+	Host    string `json:"host,omitempty"`
+	Time    string `json:"time"`
+}
+
+// fileLock is an advisory lock shared between Glide processes. It is held by
+// the process that created the lock file and released by removing it.
+type fileLock struct {
+	path string
+
+	// name describes what is locked in messages.
+	name string
+
+	// mu keeps the lock file from being refreshed while it is removed.
+	mu       sync.Mutex
+	released bool
+	done     chan struct{}
+
+	// file is the lock file as created, to tell it from one another process
+	// created after taking the lock over.
+	file os.FileInfo
+}
+
+var (
+	fileLocksMu sync.Mutex
+	fileLocks   = make(map[string]*fileLock)
+)
+
+// LockFile takes the lock stored in the file at path, waiting up to
+// LockTimeout while another Glide process holds it. The name describes what
+// the lock guards in messages. Taking a lock already held by this process
+// does nothing.
+func LockFile(path, name string) error {
+	fileLocksMu.Lock()
+	_, ok := fileLocks[path]
+	fileLocksMu.Unlock()
+	if ok {
+		return nil
+	}
+
+	captureSignals()
+	l := &fileLock{path: path, name: name, done: make(chan struct{})}
+	if err := l.lock(); err != nil {
+		return err
+	}
+	fileLocksMu.Lock()
+	fileLocks[path] = l
+	fileLocksMu.Unlock()
+	return nil
+}
+
+// UnlockFile releases a lock taken with LockFile.
+func UnlockFile(path string) {
+	fileLocksMu.Lock()
+	defer fileLocksMu.Unlock()
+	if l, ok := fileLocks[path]; ok {
+		l.unlock()
+		delete(fileLocks, path)
+	}
+}
+
+// ReleaseLocks releases all of the locks held by this process, including the
+// system wide cache lock.
+func ReleaseLocks() {
+	fileLocksMu.Lock()
+	defer fileLocksMu.Unlock()
+	for p, l := range fileLocks {
+		l.unlock()
+		delete(fileLocks, p)
+	}
+}
+
+func (l *fileLock) lock() error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return err
+	}
+	start := time.Now()
+	var announced bool
+	for {
+		err := l.create()
+		if err == nil {
+			go l.refresh()
+			return nil
+		} else if !os.IsExist(err) {
+			return err
+		}
+
+		ld, fi, err := readLock(l.path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err == nil && abandoned(ld) {
+			msg.Debug("Removing the lock on %s left behind by process %d on %s", l.name, ld.Pid, ld.Host)
+			if err := l.takeOver(fi); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if LockTimeout > 0 && time.Since(start) >= LockTimeout {
+			return fmt.Errorf("timed out after %s waiting on %s, locked by process %d on %s (%s)", LockTimeout, l.name, ld.Pid, ld.Host, l.path)
+		}
+		if !announced {
+			announced = true
+			msg.Info("Waiting on %s, locked by process %d on %s", l.name, ld.Pid, ld.Host)
+		}
+		time.Sleep(lockPoll)
+	}
+}
+
+// create writes the lock file, failing when it already exists.
+func (l *fileLock) create() error {
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(lockContent())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(l.path)
+		return err
+	}
+	l.file, err = os.Stat(l.path)
+	return err
+}
+
+// takeOver removes the abandoned lock file stale was read from. Other
+// processes waiting on the lock may find it abandoned at the same time, and
+// one of them may have taken the lock already, so the file is renamed away
+// rather than removed and put back when it is not the one found abandoned.
+func (l *fileLock) takeOver(stale os.FileInfo) error {
+	tmp := fmt.Sprintf("%s.%d-%d", l.path, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(l.path, tmp); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if fi, err := os.Stat(tmp); err == nil && !sameLock(fi, stale) {
+		if err := os.Link(tmp, l.path); err != nil && !os.IsExist(err) {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	return os.Remove(tmp)
+}
+
+// sameLock returns true when a and b are the same lock file, untouched since.
+func sameLock(a, b os.FileInfo) bool {
+	return os.SameFile(a, b) && a.ModTime().Equal(b.ModTime())
+}
+
+// refresh touches the lock file until the lock is released so others can tell
+// it is still held. The file is never written again, so a refresh racing the
+// release cannot leave it behind.
+func (l *fileLock) refresh() {
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-time.After(lockRefresh):
+			if err := l.touch(); err != nil {
+				msg.Warn("Lost the lock on %s: %s", l.name, err)
+				return
+			}
+		}
+	}
+}
+
+// touch updates the modification time of the lock file while the lock is
+// held. It fails when the file is no longer the one created for the lock.
+func (l *fileLock) touch() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.released {
+		return nil
+	}
+	fi, err := os.Stat(l.path)
+	if err != nil {
+		return err
+	}
+	if l.file != nil && !os.SameFile(fi, l.file) {
+		return fmt.Errorf("%s was replaced by another process", l.path)
+	}
+	now := time.Now()
+	return os.Chtimes(l.path, now, now)
+}
+
+func (l *fileLock) unlock() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.released = true
+	close(l.done)
+	// A lock file replaced by another process is the lock of that process.
+	if fi, err := os.Stat(l.path); err == nil && (l.file == nil || os.SameFile(fi, l.file)) {
+		os.Remove(l.path)
+	}
+}
+
+func lockContent() []byte {
+	host, _ := os.Hostname()
+	ld := &lockdata{
+		Comment: "File managed by Glide (https://glide.sh)",
+		Pid:     os.Getpid(),
+		Host:    host,
+		Time:    time.Now().Format(time.RFC3339Nano),
+	}
+	out, _ := json.Marshal(ld)
+	return out
+}
+
+// readLock reads the holder of a lock file, and the file itself. The time is
+// taken from the file as it is touched while the lock is held.
+func readLock(path string) (*lockdata, os.FileInfo, error) {
+	ld := &lockdata{}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return ld, nil, err
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ld, nil, err
+	}
+	// A lock file being written may not be complete yet. It is judged by its
+	// age alone then.
+	json.Unmarshal(b, ld)
+	ld.Time = fi.ModTime().Format(time.RFC3339Nano)
+	return ld, fi, nil
+}
+
+// abandoned returns true when a lock has not been touched for a while.
+func abandoned(ld *lockdata) bool {
+	t, err := time.Parse(time.RFC3339Nano, ld.Time)
+	if err != nil {
+		return true
+	}
+	return time.Since(t) > lockStale
+}
@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := filepath.Join(dir, "lock.json")
+
+	defer func(d time.Duration) { LockTimeout = d }(LockTimeout)
+	LockTimeout = 100 * time.Millisecond
+
+	if err := LockFile(p, "test"); err != nil {
+		t.Fatalf("Unable to take the lock: %s", err)
+	}
+	if err := LockFile(p, "test"); err != nil {
+		t.Errorf("Taking a held lock again failed: %s", err)
+	}
+
+	// Another process is stood in for by a lock outside of the registry.
+	other := &fileLock{path: p, name: "test", done: make(chan struct{})}
+	if err := other.lock(); err == nil {
+		t.Error("Took a lock held by another process")
+	}
+
+	UnlockFile(p)
+	if _, err := os.Stat(p); !os.IsNotExist(err) {
+		t.Errorf("The lock file was not removed: %v", err)
+	}
+	if err := other.lock(); err != nil {
+		t.Errorf("Unable to take a released lock: %s", err)
+	}
+	other.unlock()
+
+	// A refresh coming after the release must not bring the file back.
+	if err := other.touch(); err != nil {
+		t.Errorf("Refreshing a released lock failed: %s", err)
+	}
+	if _, err := os.Stat(p); !os.IsNotExist(err) {
+		t.Errorf("Refreshing a released lock recreated its file: %v", err)
+	}
+}
+
+func TestLockFileAbandoned(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := filepath.Join(dir, "lock.json")
+
+	defer func(d time.Duration) { LockTimeout = d }(LockTimeout)
+	LockTimeout = 100 * time.Millisecond
+
+	if err := ioutil.WriteFile(p, []byte(`{"pid": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * lockStale)
+	if err := os.Chtimes(p, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := LockFile(p, "test"); err != nil {
+		t.Errorf("Unable to take an abandoned lock: %s", err)
+	}
+	ReleaseLocks()
+	if _, err := os.Stat(p); !os.IsNotExist(err) {
+		t.Errorf("The lock file was not removed: %v", err)
+	}
+}
+
+func TestLockFileTakeOverRace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := filepath.Join(dir, "lock.json")
+
+	if err := ioutil.WriteFile(p, []byte(`{"pid": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * lockStale)
+	if err := os.Chtimes(p, old, old); err != nil {
+		t.Fatal(err)
+	}
+	_, stale, err := readLock(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One waiter takes the abandoned lock over before another, which read
+	// the same abandoned file, gets to it.
+	first := &fileLock{path: p, name: "test", done: make(chan struct{})}
+	if err := first.lock(); err != nil {
+		t.Fatal(err)
+	}
+	defer first.unlock()
+	second := &fileLock{path: p, name: "test", done: make(chan struct{})}
+	if err := second.takeOver(stale); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.create(); !os.IsExist(err) {
+		t.Errorf("Expected the lock taken by the first waiter to be kept, got %v", err)
+	}
+	if err := first.touch(); err != nil {
+		t.Errorf("The first waiter lost the lock: %s", err)
+	}
+	if m, _ := filepath.Glob(p + ".*"); len(m) != 0 {
+		t.Errorf("Expected no files left from the take over, got %v", m)
+	}
+}
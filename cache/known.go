@@ -0,0 +1,189 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// KnownEntry is the commit a tag of a repo pointed at when Glide first saw it.
+// The known commits are shared by every project so a tag rewritten upstream is
+// noticed by the next project resolving it.
+type KnownEntry struct {
+	Remote string `json:"-"`
+	Tag    string `json:"-"`
+
+	// Name is the package the tag was first resolved for.
+	Name   string    `json:"name"`
+	Commit string    `json:"commit"`
+	Seen   time.Time `json:"seen"`
+}
+
+// HashMismatchError is returned when a tag points at another commit than the
+// one it pointed at when first seen.
+type HashMismatchError struct {
+	Name   string
+	Tag    string
+	Known  *KnownEntry
+	Commit string
+}
+
+func (e *HashMismatchError) Error() string {
+	return fmt.Sprintf("Tag %s of %s points at %s but pointed at %s when first seen on %s. "+
+		"It may have been rewritten upstream. If the change is expected run 'glide hashes forget %s@%s'",
+		e.Tag, e.Name, e.Commit, e.Known.Commit, e.Known.Seen.Format("2006-01-02"), e.Name, e.Tag)
+}
+
+// Kind returns msg.KindHashMismatch.
+func (e *HashMismatchError) Kind() msg.Kind {
+	return msg.KindHashMismatch
+}
+
+var knownMutex sync.Mutex
+
+// knownFile is kept in GLIDE_HOME rather than the cache so clearing the
+// cache does not forget the known commits.
+func knownFile() string {
+	return filepath.Join(gpath.Home(), "known_hashes.json")
+}
+
+func knownKey(remote, tag string) string {
+	return remote + "@" + tag
+}
+
+// CheckKnown compares the commit a tag of the repo at remote points at with
+// the one recorded when the tag was first seen. Tags seen for the first time
+// are recorded. A *HashMismatchError is returned when the commits differ.
+func CheckKnown(name, remote, tag, commit string) error {
+	knownMutex.Lock()
+	defer knownMutex.Unlock()
+	if err := LockFile(knownFile()+".lock", "the known hashes"); err != nil {
+		return err
+	}
+	defer UnlockFile(knownFile() + ".lock")
+
+	m, err := readKnown()
+	if err != nil {
+		return fmt.Errorf("Unable to read the known hashes: %s", err)
+	}
+	k := knownKey(remote, tag)
+	if e, ok := m[k]; ok {
+		if e.Commit != commit {
+			return &HashMismatchError{Name: name, Tag: tag, Known: e, Commit: commit}
+		}
+		return nil
+	}
+	m[k] = &KnownEntry{Name: name, Commit: commit, Seen: time.Now()}
+	return writeKnown(m)
+}
+
+// KnownEntries returns the known commits sorted by remote and tag.
+func KnownEntries() ([]*KnownEntry, error) {
+	knownMutex.Lock()
+	defer knownMutex.Unlock()
+	m, err := readKnown()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*KnownEntry, 0, len(m))
+	for k, e := range m {
+		i := strings.LastIndex(k, "@")
+		e.Remote, e.Tag = k[:i], k[i+1:]
+		entries = append(entries, e)
+	}
+	sort.Sort(knownByKey(entries))
+	return entries, nil
+}
+
+// ForgetKnown removes the known commits of the tags matching pattern, which
+// is a package name or remote optionally followed by @ and a tag. It returns
+// how many were removed.
+func ForgetKnown(pattern string) (int, error) {
+	knownMutex.Lock()
+	defer knownMutex.Unlock()
+	if err := LockFile(knownFile()+".lock", "the known hashes"); err != nil {
+		return 0, err
+	}
+	defer UnlockFile(knownFile() + ".lock")
+
+	m, err := readKnown()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for k, e := range m {
+		i := strings.LastIndex(k, "@")
+		remote, tag := k[:i], k[i+1:]
+		for _, n := range []string{e.Name, remote} {
+			if pattern == n || pattern == n+"@"+tag {
+				delete(m, k)
+				count++
+				break
+			}
+		}
+	}
+	if count == 0 {
+		return 0, nil
+	}
+	return count, writeKnown(m)
+}
+
+func readKnown() (map[string]*KnownEntry, error) {
+	m := make(map[string]*KnownEntry)
+	b, err := ioutil.ReadFile(knownFile())
+	if os.IsNotExist(err) {
+		return m, nil
+	} else if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+// writeKnown replaces the known hashes file so other Glide processes never
+// read a partially written one.
+func writeKnown(m map[string]*KnownEntry) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	p := knownFile()
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(p), "known_hashes.json")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), p)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+type knownByKey []*KnownEntry
+
+func (k knownByKey) Len() int      { return len(k) }
+func (k knownByKey) Swap(i, j int) { k[i], k[j] = k[j], k[i] }
+func (k knownByKey) Less(i, j int) bool {
+	if k[i].Remote != k[j].Remote {
+		return k[i].Remote < k[j].Remote
+	}
+	return k[i].Tag < k[j].Tag
+}
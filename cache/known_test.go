@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	gpath "github.com/Masterminds/glide/path"
+)
+
+func TestCheckKnown(t *testing.T) {
+	home, err := ioutil.TempDir("", "glide-known")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer gpath.SetHome(gpath.Home())
+	gpath.SetHome(home)
+
+	remote := "https://example.com/foo/bar"
+	if err := CheckKnown("example.com/foo/bar", remote, "v1.0.0", "aaa"); err != nil {
+		t.Fatalf("Unexpected error recording a new tag: %s", err)
+	}
+	if err := CheckKnown("example.com/foo/bar", remote, "v1.0.0", "aaa"); err != nil {
+		t.Errorf("Unexpected error for a matching commit: %s", err)
+	}
+	if err := CheckKnown("example.com/foo/bar", remote, "v1.1.0", "bbb"); err != nil {
+		t.Errorf("Unexpected error recording another tag: %s", err)
+	}
+
+	err = CheckKnown("example.com/foo/bar", remote, "v1.0.0", "ccc")
+	if e, ok := err.(*HashMismatchError); !ok || e.Known.Commit != "aaa" || e.Commit != "ccc" {
+		t.Errorf("Expected a mismatch for a rewritten tag, got %v", err)
+	}
+	// The same tag of another repo is separate.
+	if err := CheckKnown("example.com/other", "git@example.com:other", "v1.0.0", "ccc"); err != nil {
+		t.Errorf("Unexpected error for another repo: %s", err)
+	}
+
+	entries, err := KnownEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || entries[2].Remote != remote || entries[2].Tag != "v1.1.0" {
+		t.Fatalf("Unexpected known entries %v", entries)
+	}
+	if entries[0].Remote != "git@example.com:other" || entries[0].Tag != "v1.0.0" {
+		t.Errorf("Expected the remote containing @ to be split at the tag, got %s and %s", entries[0].Remote, entries[0].Tag)
+	}
+
+	if n, err := ForgetKnown("example.com/foo/bar@v1.0.0"); err != nil || n != 1 {
+		t.Errorf("Expected to forget 1 tag, forgot %d (%v)", n, err)
+	}
+	if err := CheckKnown("example.com/foo/bar", remote, "v1.0.0", "ccc"); err != nil {
+		t.Errorf("Unexpected error after forgetting the tag: %s", err)
+	}
+	if n, err := ForgetKnown(remote); err != nil || n != 2 {
+		t.Errorf("Expected to forget 2 tags of the repo, forgot %d (%v)", n, err)
+	}
+}
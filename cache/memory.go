@@ -34,6 +34,12 @@ func MemLatest(name string) string {
 	return defaultMemCache.getLatest(name)
 }
 
+// MemVersions returns the semver releases put for a name, in the order they
+// were put.
+func MemVersions(name string) []string {
+	return defaultMemCache.getVersions(name)
+}
+
 // MemSetCurrent is used to set the current version in use.
 func MemSetCurrent(name, version string) {
 	defaultMemCache.setCurrent(name, version)
@@ -137,3 +143,9 @@ func (m *memCache) getLatest(name string) string {
 	defer m.RUnlock()
 	return m.latest[name]
 }
+
+func (m *memCache) getVersions(name string) []string {
+	m.RLock()
+	defer m.RUnlock()
+	return append([]string{}, m.versions[name]...)
+}
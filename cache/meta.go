@@ -0,0 +1,173 @@
+package cache
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/glide/msg"
+)
+
+// DefaultMetaTTL is how long the remote metadata of an import path is used
+// before it is looked up again, unless meta-ttl is set in config.yaml.
+const DefaultMetaTTL = 24 * time.Hour
+
+// MetaEntry is the root of the repo holding an import path, as found in the
+// go-import meta tag served for it.
+type MetaEntry struct {
+	// Path is the import path that was looked up.
+	Path string `json:"-"`
+
+	Root    string    `json:"root"`
+	Fetched time.Time `json:"fetched"`
+}
+
+var metaMutex sync.Mutex
+
+func metaFile() string {
+	return filepath.Join(Location(), "meta.json")
+}
+
+// MetaEntries returns the remote metadata in the cache sorted by path.
+func MetaEntries() ([]*MetaEntry, error) {
+	metaMutex.Lock()
+	defer metaMutex.Unlock()
+	m, err := readMeta()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*MetaEntry, 0, len(m))
+	for p, e := range m {
+		e.Path = p
+		entries = append(entries, e)
+	}
+	sort.Sort(metaByPath(entries))
+	return entries, nil
+}
+
+// MetaRoot returns the cached root of the repo holding an import path. The
+// entry for the longest matching path is used. Stale is true when it was
+// fetched longer than the TTL ago.
+func MetaRoot(pkg string) (root string, stale, found bool) {
+	if !Enabled {
+		return pkg, false, false
+	}
+	metaMutex.Lock()
+	defer metaMutex.Unlock()
+	m, err := readMeta()
+	if err != nil {
+		msg.Debug("Unable to read the remote metadata cache: %s", err)
+		return pkg, false, false
+	}
+	var match string
+	for p := range m {
+		if (pkg == p || strings.HasPrefix(pkg, p+"/")) && len(p) > len(match) {
+			match = p
+		}
+	}
+	if match == "" {
+		return pkg, false, false
+	}
+	e := m[match]
+	return e.Root, time.Since(e.Fetched) > metaTTL(), true
+}
+
+// SaveMeta stores the root of the repo holding an import path.
+func SaveMeta(pkg, root string) error {
+	if !Enabled {
+		return ErrCacheDisabled
+	}
+	metaMutex.Lock()
+	defer metaMutex.Unlock()
+	m, err := readMeta()
+	if err != nil {
+		m = make(map[string]*MetaEntry)
+	}
+	m[pkg] = &MetaEntry{Root: root, Fetched: time.Now()}
+	return writeMeta(m)
+}
+
+// RemoveMeta removes import paths from the remote metadata cache.
+func RemoveMeta(pkgs ...string) error {
+	metaMutex.Lock()
+	defer metaMutex.Unlock()
+	m, err := readMeta()
+	if err != nil {
+		return err
+	}
+	for _, p := range pkgs {
+		delete(m, p)
+	}
+	return writeMeta(m)
+}
+
+func readMeta() (map[string]*MetaEntry, error) {
+	m := make(map[string]*MetaEntry)
+	b, err := ioutil.ReadFile(metaFile())
+	if os.IsNotExist(err) {
+		return m, nil
+	} else if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+// writeMeta replaces the metadata file so other Glide processes never read a
+// partially written one.
+func writeMeta(m map[string]*MetaEntry) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	p := metaFile()
+	f, err := ioutil.TempFile(filepath.Dir(p), "meta.json")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), p)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+var (
+	metaTTLOnce  sync.Once
+	metaTTLValue time.Duration
+)
+
+// metaTTL returns the meta-ttl from config.yaml or else DefaultMetaTTL.
+func metaTTL() time.Duration {
+	metaTTLOnce.Do(func() {
+		metaTTLValue = DefaultMetaTTL
+		s, err := ReadSettings()
+		if err != nil || s.MetaTTL == "" {
+			return
+		}
+		d, err := time.ParseDuration(s.MetaTTL)
+		if err != nil {
+			msg.Warn("Invalid meta-ttl %q in config.yaml: %s", s.MetaTTL, err)
+			return
+		}
+		metaTTLValue = d
+	})
+	return metaTTLValue
+}
+
+type metaByPath []*MetaEntry
+
+func (m metaByPath) Len() int           { return len(m) }
+func (m metaByPath) Less(i, j int) bool { return m[i].Path < m[j].Path }
+func (m metaByPath) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
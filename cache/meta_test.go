@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	gpath "github.com/Masterminds/glide/path"
+)
+
+func TestMetaRoot(t *testing.T) {
+	home, err := ioutil.TempDir("", "glide-meta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer gpath.SetHome(gpath.Home())
+	gpath.SetHome(home)
+	SetupReset()
+	defer SetupReset()
+
+	if _, _, found := MetaRoot("k8s.io/client-go/rest"); found {
+		t.Error("Found a root in an empty cache")
+	}
+
+	if err := SaveMeta("k8s.io/client-go", "k8s.io/client-go"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveMeta("k8s.io/client-go/tools", "k8s.io/client-go/tools"); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"k8s.io/client-go":               "k8s.io/client-go",
+		"k8s.io/client-go/rest":          "k8s.io/client-go",
+		"k8s.io/client-go/tools/cache":   "k8s.io/client-go/tools",
+		"k8s.io/client-go-other/package": "",
+	}
+	for pkg, want := range tests {
+		root, stale, found := MetaRoot(pkg)
+		if want == "" {
+			if found {
+				t.Errorf("Expected no root for %s but got %s", pkg, root)
+			}
+			continue
+		}
+		if !found || root != want || stale {
+			t.Errorf("Expected fresh root %s for %s but got %s (found %t, stale %t)", want, pkg, root, found, stale)
+		}
+	}
+
+	m, err := readMeta()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m["k8s.io/client-go"].Fetched = time.Now().Add(-2 * metaTTL())
+	if err := writeMeta(m); err != nil {
+		t.Fatal(err)
+	}
+	if _, stale, _ := MetaRoot("k8s.io/client-go/rest"); !stale {
+		t.Error("Expected an old entry to be stale")
+	}
+
+	if err := RemoveMeta("k8s.io/client-go"); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := MetaEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Path != "k8s.io/client-go/tools" {
+		t.Errorf("Unexpected entries after removing one: %v", entries)
+	}
+}
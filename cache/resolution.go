@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Resolution is the outcome of resolving the dependencies of a project. It is
+// stored under a key derived from the configuration of the project and used
+// again while the remotes it consulted have not changed.
+type Resolution struct {
+	// Heads are digests of the refs of each remote consulted, by remote.
+	Heads map[string]string `json:"heads"`
+
+	// Lock is the lock file the resolution produced.
+	Lock string `json:"lock"`
+
+	Resolved time.Time `json:"resolved"`
+}
+
+func resolutionFile(key string) string {
+	return filepath.Join(Location(), "resolutions", key+".json")
+}
+
+// LoadResolution returns the resolution stored under key. An error is
+// returned when there is none.
+func LoadResolution(key string) (*Resolution, error) {
+	if !Enabled {
+		return nil, ErrCacheDisabled
+	}
+	b, err := ioutil.ReadFile(resolutionFile(key))
+	if err != nil {
+		return nil, err
+	}
+	r := &Resolution{}
+	err = json.Unmarshal(b, r)
+	return r, err
+}
+
+// SaveResolution stores a resolution under key. The file is replaced so other
+// Glide processes never read a partially written one.
+func SaveResolution(key string, r *Resolution) error {
+	if !Enabled {
+		return ErrCacheDisabled
+	}
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	p := resolutionFile(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(p), key)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), p)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
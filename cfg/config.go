@@ -4,12 +4,20 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"io/ioutil"
+	"path"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/Masterminds/glide/archive"
+	"github.com/Masterminds/glide/darcs"
+	"github.com/Masterminds/glide/fossil"
+	"github.com/Masterminds/glide/hooks"
 	"github.com/Masterminds/glide/mirrors"
 	"github.com/Masterminds/glide/util"
+	"github.com/Masterminds/semver"
 	"github.com/Masterminds/vcs"
 	"gopkg.in/yaml.v2"
 )
@@ -20,6 +28,10 @@ type Config struct {
 	// Name is the name of the package or application.
 	Name string `yaml:"package"`
 
+	// Extends is the path or URL of a glide.yaml file this one inherits from.
+	// See ResolveExtends for details.
+	Extends string `yaml:"extends,omitempty"`
+
 	// Description is a short description for a package, application, or library.
 	// This description is similar but different to a Go package description as
 	// it is for marketing and presentation purposes rather than technical ones.
@@ -47,6 +59,10 @@ type Config struct {
 	// exclude from scanning for dependencies.
 	Exclude []string `yaml:"excludeDirs,omitempty"`
 
+	// Mirrors replace the location of repos for this project, like the ones in
+	// the mirrors.yaml file. Those in mirrors.yaml take precedence.
+	Mirrors mirrors.MirrorRepos `yaml:"mirrors,omitempty"`
+
 	// Imports contains a list of all non-development imports for a project. For
 	// more detail on how these are captured see the Dependency type.
 	Imports Dependencies `yaml:"import"`
@@ -54,19 +70,79 @@ type Config struct {
 	// DevImports contains the test or other development imports for a project.
 	// See the Dependency type for more details on how this is recorded.
 	DevImports Dependencies `yaml:"testImport,omitempty"`
+
+	// Overrides set the version, repository, or VCS type of dependencies
+	// wherever they are required, taking precedence over the versions asked
+	// for by the project and its dependencies. See Override.
+	Overrides Dependencies `yaml:"override,omitempty"`
+
+	// Nested sets how the dependencies that dependencies declare or vendor
+	// themselves are handled. See NestedPolicy.
+	Nested string `yaml:"nested,omitempty"`
+
+	// OptionalGroups lists the groups of dependencies that are only installed
+	// when asked for. See Dependency.Groups.
+	OptionalGroups []string `yaml:"optionalGroups,omitempty"`
+
+	// SyncGroups are sets of dependencies that must resolve to the same
+	// version. See SyncGroup.
+	SyncGroups SyncGroups `yaml:"syncGroups,omitempty"`
+
+	// StdAliases maps packages, such as golang.org/x/net/context, to the
+	// standard library packages that replace them, such as context. Aliased
+	// packages are ignored and the vendored packages importing them import
+	// the standard library package instead.
+	StdAliases map[string]string `yaml:"stdAliases,omitempty"`
+
+	// Tools are the repos of commands, such as linters and code generators,
+	// built into the bin directory of the project by 'glide tools install'.
+	// Their subpackages are the main packages to build.
+	Tools Dependencies `yaml:"tools,omitempty"`
+
+	// VendorDir is the directory, relative to the project, that dependencies
+	// are installed in when it is not vendor. See VendorPath.
+	VendorDir string `yaml:"vendorDir,omitempty"`
+
+	// TestVendorDir is the directory, relative to the project, that the
+	// dependencies only used by tests are installed in, keeping them out of
+	// the vendor directory. See TestVendorPath.
+	TestVendorDir string `yaml:"testVendorDir,omitempty"`
+
+	// Policy restricts the dependencies the project may use. See Policy.
+	Policy *Policy `yaml:"policy,omitempty"`
+
+	// Go is the range of Go versions the project supports, such as
+	// >=1.9 <1.13. Install and update stop when the Go toolchain is outside
+	// of it. See GoConstraint.
+	Go string `yaml:"go,omitempty"`
+
+	// inherited is the config this one extends.
+	inherited *Config
 }
 
 // A transitive representation of a dependency for importing and exporting to yaml.
 type cf struct {
-	Name        string       `yaml:"package"`
-	Description string       `yaml:"description,omitempty"`
-	Home        string       `yaml:"homepage,omitempty"`
-	License     string       `yaml:"license,omitempty"`
-	Owners      Owners       `yaml:"owners,omitempty"`
-	Ignore      []string     `yaml:"ignore,omitempty"`
-	Exclude     []string     `yaml:"excludeDirs,omitempty"`
-	Imports     Dependencies `yaml:"import"`
-	DevImports  Dependencies `yaml:"testImport,omitempty"`
+	Name           string              `yaml:"package"`
+	Extends        string              `yaml:"extends,omitempty"`
+	Description    string              `yaml:"description,omitempty"`
+	Home           string              `yaml:"homepage,omitempty"`
+	License        string              `yaml:"license,omitempty"`
+	Owners         Owners              `yaml:"owners,omitempty"`
+	Ignore         []string            `yaml:"ignore,omitempty"`
+	Exclude        []string            `yaml:"excludeDirs,omitempty"`
+	Mirrors        mirrors.MirrorRepos `yaml:"mirrors,omitempty"`
+	Imports        Dependencies        `yaml:"import"`
+	DevImports     Dependencies        `yaml:"testImport,omitempty"`
+	Overrides      Dependencies        `yaml:"override,omitempty"`
+	Nested         string              `yaml:"nested,omitempty"`
+	OptionalGroups []string            `yaml:"optionalGroups,omitempty"`
+	SyncGroups     SyncGroups          `yaml:"syncGroups,omitempty"`
+	StdAliases     map[string]string   `yaml:"stdAliases,omitempty"`
+	Tools          Dependencies        `yaml:"tools,omitempty"`
+	VendorDir      string              `yaml:"vendorDir,omitempty"`
+	TestVendorDir  string              `yaml:"testVendorDir,omitempty"`
+	Policy         *Policy             `yaml:"policy,omitempty"`
+	Go             string              `yaml:"go,omitempty"`
 }
 
 // ConfigFromYaml returns an instance of Config from YAML
@@ -92,14 +168,26 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		return err
 	}
 	c.Name = newConfig.Name
+	c.Extends = newConfig.Extends
 	c.Description = newConfig.Description
 	c.Home = newConfig.Home
 	c.License = newConfig.License
 	c.Owners = newConfig.Owners
 	c.Ignore = newConfig.Ignore
 	c.Exclude = newConfig.Exclude
+	c.Mirrors = newConfig.Mirrors
 	c.Imports = newConfig.Imports
 	c.DevImports = newConfig.DevImports
+	c.Overrides = newConfig.Overrides
+	c.Nested = newConfig.Nested
+	c.OptionalGroups = newConfig.OptionalGroups
+	c.SyncGroups = newConfig.SyncGroups
+	c.StdAliases = newConfig.StdAliases
+	c.Tools = newConfig.Tools
+	c.VendorDir = newConfig.VendorDir
+	c.TestVendorDir = newConfig.TestVendorDir
+	c.Policy = newConfig.Policy
+	c.Go = newConfig.Go
 
 	// Cleanup the Config object now that we have it.
 	err := c.DeDupe()
@@ -107,16 +195,31 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return err
 }
 
-// MarshalYAML is a hook for gopkg.in/yaml.v2 in the marshaling process
+// MarshalYAML is a hook for gopkg.in/yaml.v2 in the marshaling process.
+// Values inherited from an extended config are left out.
 func (c *Config) MarshalYAML() (interface{}, error) {
+	if c.inherited != nil {
+		c = c.local()
+	}
 	newConfig := &cf{
-		Name:        c.Name,
-		Description: c.Description,
-		Home:        c.Home,
-		License:     c.License,
-		Owners:      c.Owners,
-		Ignore:      c.Ignore,
-		Exclude:     c.Exclude,
+		Name:           c.Name,
+		Extends:        c.Extends,
+		Description:    c.Description,
+		Home:           c.Home,
+		License:        c.License,
+		Owners:         c.Owners,
+		Ignore:         c.Ignore,
+		Exclude:        c.Exclude,
+		Mirrors:        c.Mirrors,
+		Nested:         c.Nested,
+		OptionalGroups: c.OptionalGroups,
+		SyncGroups:     c.SyncGroups,
+		StdAliases:     c.StdAliases,
+		Tools:          c.Tools,
+		VendorDir:      c.VendorDir,
+		TestVendorDir:  c.TestVendorDir,
+		Policy:         c.Policy,
+		Go:             c.Go,
 	}
 	i, err := c.Imports.Clone().DeDupe()
 	if err != nil {
@@ -128,8 +231,14 @@ func (c *Config) MarshalYAML() (interface{}, error) {
 		return newConfig, err
 	}
 
+	o, err := c.Overrides.Clone().DeDupe()
+	if err != nil {
+		return newConfig, err
+	}
+
 	newConfig.Imports = i
 	newConfig.DevImports = di
+	newConfig.Overrides = o
 
 	return newConfig, nil
 }
@@ -149,8 +258,63 @@ func (c *Config) HasDependency(name string) bool {
 	return false
 }
 
-// HasIgnore returns true if the given name is listed on the ignore list.
+// OverrideFor returns the override for the dependency name: the one listing it
+// or, when there is none, the first whose pattern matches it.
+func (c *Config) OverrideFor(name string) *Dependency {
+	if o := c.Overrides.Get(name); o != nil {
+		return o
+	}
+	for _, o := range c.Overrides {
+		if !o.IsPattern() {
+			continue
+		}
+		if ok, _ := path.Match(o.Name, name); ok {
+			return o
+		}
+	}
+	return nil
+}
+
+// Override changes the version, repository, and VCS type of d to those set by
+// the override for it, if there is one. It returns true when there is. An
+// override matching d by a pattern only changes the version.
+func (c *Config) Override(d *Dependency) bool {
+	o := c.OverrideFor(d.Name)
+	if o == nil {
+		return false
+	}
+	if o.Reference != "" && o.Reference != d.Reference {
+		d.Reference = o.Reference
+		d.Pin = ""
+	}
+	if o.IsPattern() {
+		return true
+	}
+	if o.Repository != "" {
+		d.Repository = o.Repository
+	}
+	if o.VcsType != "" {
+		d.VcsType = o.VcsType
+	}
+	return true
+}
+
+// ApplyOverrides applies the overrides to the imports and dev imports.
+func (c *Config) ApplyOverrides() {
+	for _, d := range c.Imports {
+		c.Override(d)
+	}
+	for _, d := range c.DevImports {
+		c.Override(d)
+	}
+}
+
+// HasIgnore returns true if the given name is listed on the ignore list or
+// is aliased to a standard library package.
 func (c *Config) HasIgnore(name string) bool {
+	if _, ok := c.StdAliases[name]; ok {
+		return true
+	}
 	for _, v := range c.Ignore {
 
 		// Check for both a name and to make sure sub-packages are ignored as
@@ -175,30 +339,104 @@ func (c *Config) HasExclude(ex string) bool {
 	return false
 }
 
+// The policies for the dependencies that dependencies declare in their own
+// configuration or vendor themselves.
+const (
+	// NestedFlatten installs them in the top level vendor directory, using
+	// the versions asked for by the configuration of the dependencies when
+	// the project does not set one. This is the default.
+	NestedFlatten = "flatten"
+
+	// NestedKeep leaves the packages a dependency vendors itself in its
+	// nested vendor directory, even when stripping vendor directories, so a
+	// dependency embedding pinned forks builds with them. Only the packages
+	// it does not vendor are installed in the top level vendor directory.
+	NestedKeep = "keep-nested"
+
+	// NestedPreferPin installs them in the top level vendor directory like
+	// NestedFlatten, preferring the versions pinned in the glide.lock files
+	// of the dependencies to the ranges in their glide.yaml files.
+	NestedPreferPin = "prefer-nested-pin"
+)
+
+// NestedPolicy returns the policy for the dependencies of dependencies. It is
+// NestedFlatten unless set.
+func (c *Config) NestedPolicy() string {
+	if c.Nested == "" {
+		return NestedFlatten
+	}
+	return c.Nested
+}
+
+// VendorPath returns the directory dependencies are installed in, relative to
+// the project and using the separator of the OS. It is vendor unless set.
+func (c *Config) VendorPath() string {
+	if c.VendorDir == "" {
+		return "vendor"
+	}
+	return filepath.Clean(filepath.FromSlash(c.VendorDir))
+}
+
+// TestVendorPath returns the directory the dependencies only used by tests are
+// installed in, relative to the project and using the separator of the OS. It
+// is empty unless set, in which case they are installed in the vendor
+// directory with the others.
+func (c *Config) TestVendorPath() string {
+	if c.TestVendorDir == "" {
+		return ""
+	}
+	return filepath.Clean(filepath.FromSlash(c.TestVendorDir))
+}
+
 // Clone performs a deep clone of the Config instance
 func (c *Config) Clone() *Config {
 	n := &Config{}
 	n.Name = c.Name
+	n.Extends = c.Extends
 	n.Description = c.Description
 	n.Home = c.Home
 	n.License = c.License
 	n.Owners = c.Owners.Clone()
 	n.Ignore = c.Ignore
 	n.Exclude = c.Exclude
+	n.Mirrors = c.Mirrors
 	n.Imports = c.Imports.Clone()
 	n.DevImports = c.DevImports.Clone()
+	n.Overrides = c.Overrides.Clone()
+	n.Nested = c.Nested
+	n.OptionalGroups = c.OptionalGroups
+	n.SyncGroups = c.SyncGroups.Clone()
+	if c.StdAliases != nil {
+		n.StdAliases = make(map[string]string, len(c.StdAliases))
+		for k, v := range c.StdAliases {
+			n.StdAliases[k] = v
+		}
+	}
+	n.Tools = c.Tools.Clone()
+	n.VendorDir = c.VendorDir
+	n.TestVendorDir = c.TestVendorDir
+	n.Policy = c.Policy.Clone()
+	n.Go = c.Go
+	n.inherited = c.inherited
 	return n
 }
 
 // WriteFile writes a Glide YAML file.
 //
 // This is a convenience function that marshals the YAML and then writes it to
-// the given file. If the file exists, it will be clobbered.
+// the given file. If the file exists only the parts that changed are written
+// again so comments and formatting are preserved. When that is not possible
+// the file is clobbered.
 func (c *Config) WriteFile(glidepath string) error {
 	o, err := c.Marshal()
 	if err != nil {
 		return err
 	}
+	if orig, err := ioutil.ReadFile(glidepath); err == nil {
+		if r, err := rewriteYaml(orig, o); err == nil {
+			o = r
+		}
+	}
 	return ioutil.WriteFile(glidepath, o, 0666)
 }
 
@@ -275,9 +513,12 @@ func (c *Config) AddImport(deps ...*Dependency) error {
 	return nil
 }
 
-// Hash generates a sha256 hash for a given Config
+// Hash generates a sha256 hash for a given Config. Values inherited from an
+// extended config are included so changes to them are detected.
 func (c *Config) Hash() (string, error) {
-	yml, err := c.Marshal()
+	h := c.Clone()
+	h.inherited = nil
+	yml, err := h.Marshal()
 	if err != nil {
 		return "", err
 	}
@@ -369,38 +610,82 @@ func (d Dependencies) DeDupe() (Dependencies, error) {
 
 // Dependency describes a package that the present package depends upon.
 type Dependency struct {
-	Name        string   `yaml:"package"`
-	Reference   string   `yaml:"version,omitempty"`
-	Pin         string   `yaml:"-"`
-	Repository  string   `yaml:"repo,omitempty"`
-	VcsType     string   `yaml:"vcs,omitempty"`
-	Subpackages []string `yaml:"subpackages,omitempty"`
-	Arch        []string `yaml:"arch,omitempty"`
-	Os          []string `yaml:"os,omitempty"`
+	Name            string            `yaml:"package"`
+	Reference       string            `yaml:"version,omitempty"`
+	Pin             string            `yaml:"-"`
+	Repository      string            `yaml:"repo,omitempty"`
+	VcsType         string            `yaml:"vcs,omitempty"`
+	Subpackages     []string          `yaml:"subpackages,omitempty"`
+	Arch            []string          `yaml:"arch,omitempty"`
+	Os              []string          `yaml:"os,omitempty"`
+	Submodules      bool              `yaml:"submodules,omitempty"`
+	Shallow         bool              `yaml:"shallow,omitempty"`
+	SubpackagesOnly bool              `yaml:"subpackagesOnly,omitempty"`
+	Checksum        string            `yaml:"checksum,omitempty"`
+	Local           string            `yaml:"local,omitempty"`
+	Relocate        []string          `yaml:"relocate,omitempty"`
+	Track           bool              `yaml:"track,omitempty"`
+	MaxAge          int               `yaml:"maxAge,omitempty"`
+	ExcludeVersions []string          `yaml:"excludeVersions,omitempty"`
+	Prerelease      bool              `yaml:"prerelease,omitempty"`
+	TagPrefix       string            `yaml:"tagPrefix,omitempty"`
+	AsOf            string            `yaml:"asof,omitempty"`
+	Annotations     map[string]string `yaml:"annotations,omitempty"`
+	Groups          []string          `yaml:"groups,omitempty"`
+	Fallback        []string          `yaml:"fallback,omitempty"`
+	Patches         []string          `yaml:"patches,omitempty"`
+
+	// RequiredBy is set by the resolver and recorded in the lock file.
+	RequiredBy []*Requirement `yaml:"-"`
+
+	// Upstream is set to the repository the dependency was meant to be
+	// fetched from when it was gone and Repository was set to a fallback.
+	Upstream string `yaml:"-"`
 }
 
 // A transitive representation of a dependency for importing and exploting to yaml.
 type dep struct {
-	Name        string   `yaml:"package"`
-	Reference   string   `yaml:"version,omitempty"`
-	Ref         string   `yaml:"ref,omitempty"`
-	Repository  string   `yaml:"repo,omitempty"`
-	VcsType     string   `yaml:"vcs,omitempty"`
-	Subpackages []string `yaml:"subpackages,omitempty"`
-	Arch        []string `yaml:"arch,omitempty"`
-	Os          []string `yaml:"os,omitempty"`
+	Name            string            `yaml:"package"`
+	Reference       string            `yaml:"version,omitempty"`
+	Ref             string            `yaml:"ref,omitempty"`
+	Repository      string            `yaml:"repo,omitempty"`
+	VcsType         string            `yaml:"vcs,omitempty"`
+	Subpackages     []string          `yaml:"subpackages,omitempty"`
+	Arch            []string          `yaml:"arch,omitempty"`
+	Os              []string          `yaml:"os,omitempty"`
+	Submodules      bool              `yaml:"submodules,omitempty"`
+	Shallow         bool              `yaml:"shallow,omitempty"`
+	SubpackagesOnly bool              `yaml:"subpackagesOnly,omitempty"`
+	Checksum        string            `yaml:"checksum,omitempty"`
+	Local           string            `yaml:"local,omitempty"`
+	Relocate        []string          `yaml:"relocate,omitempty"`
+	Track           bool              `yaml:"track,omitempty"`
+	MaxAge          int               `yaml:"maxAge,omitempty"`
+	ExcludeVersions []string          `yaml:"excludeVersions,omitempty"`
+	Prerelease      bool              `yaml:"prerelease,omitempty"`
+	TagPrefix       string            `yaml:"tagPrefix,omitempty"`
+	AsOf            string            `yaml:"asof,omitempty"`
+	Annotations     map[string]string `yaml:"annotations,omitempty"`
+	Groups          []string          `yaml:"groups,omitempty"`
+	Fallback        []string          `yaml:"fallback,omitempty"`
+	Patches         []string          `yaml:"patches,omitempty"`
 }
 
 // DependencyFromLock converts a Lock to a Dependency
 func DependencyFromLock(lock *Lock) *Dependency {
 	return &Dependency{
-		Name:        lock.Name,
-		Reference:   lock.Version,
-		Repository:  lock.Repository,
-		VcsType:     lock.VcsType,
-		Subpackages: lock.Subpackages,
-		Arch:        lock.Arch,
-		Os:          lock.Os,
+		Name:            lock.Name,
+		Reference:       lock.Version,
+		Repository:      lock.Repository,
+		VcsType:         lock.VcsType,
+		Subpackages:     lock.Subpackages,
+		Arch:            lock.Arch,
+		Os:              lock.Os,
+		Submodules:      lock.Submodules,
+		Shallow:         lock.Shallow,
+		SubpackagesOnly: lock.SubpackagesOnly,
+		RequiredBy:      lock.RequiredBy,
+		Upstream:        lock.Upstream,
 	}
 }
 
@@ -418,6 +703,22 @@ func (d *Dependency) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	d.Subpackages = newDep.Subpackages
 	d.Arch = newDep.Arch
 	d.Os = newDep.Os
+	d.Submodules = newDep.Submodules
+	d.Shallow = newDep.Shallow
+	d.SubpackagesOnly = newDep.SubpackagesOnly
+	d.Checksum = newDep.Checksum
+	d.Local = newDep.Local
+	d.Relocate = newDep.Relocate
+	d.Track = newDep.Track
+	d.MaxAge = newDep.MaxAge
+	d.ExcludeVersions = newDep.ExcludeVersions
+	d.Prerelease = newDep.Prerelease
+	d.TagPrefix = newDep.TagPrefix
+	d.AsOf = newDep.AsOf
+	d.Annotations = newDep.Annotations
+	d.Groups = newDep.Groups
+	d.Fallback = newDep.Fallback
+	d.Patches = newDep.Patches
 
 	if d.Reference == "" && newDep.Ref != "" {
 		d.Reference = newDep.Ref
@@ -426,6 +727,11 @@ func (d *Dependency) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	// Make sure only legitimate VCS are listed.
 	d.VcsType = filterVcsType(d.VcsType)
 
+	// Patterns match the names of other packages and are kept as they are.
+	if d.IsPattern() {
+		return nil
+	}
+
 	// Get the root name for the package
 	tn, subpkg := util.NormalizeName(d.Name)
 	d.Name = tn
@@ -448,13 +754,29 @@ func (d *Dependency) MarshalYAML() (interface{}, error) {
 	// Make sure we only write the correct vcs type to file
 	t := filterVcsType(d.VcsType)
 	newDep := &dep{
-		Name:        d.Name,
-		Reference:   d.Reference,
-		Repository:  d.Repository,
-		VcsType:     t,
-		Subpackages: d.Subpackages,
-		Arch:        d.Arch,
-		Os:          d.Os,
+		Name:            d.Name,
+		Reference:       d.Reference,
+		Repository:      d.Repository,
+		VcsType:         t,
+		Subpackages:     d.Subpackages,
+		Arch:            d.Arch,
+		Os:              d.Os,
+		Submodules:      d.Submodules,
+		Shallow:         d.Shallow,
+		SubpackagesOnly: d.SubpackagesOnly,
+		Checksum:        d.Checksum,
+		Local:           d.Local,
+		Relocate:        d.Relocate,
+		Track:           d.Track,
+		MaxAge:          d.MaxAge,
+		ExcludeVersions: d.ExcludeVersions,
+		Prerelease:      d.Prerelease,
+		TagPrefix:       d.TagPrefix,
+		AsOf:            d.AsOf,
+		Annotations:     d.Annotations,
+		Groups:          d.Groups,
+		Fallback:        d.Fallback,
+		Patches:         d.Patches,
 	}
 
 	return newDep, nil
@@ -463,24 +785,43 @@ func (d *Dependency) MarshalYAML() (interface{}, error) {
 // Remote returns the remote location to fetch source from. This location is
 // the central place where mirrors can alter the location.
 func (d *Dependency) Remote() string {
-	var r string
-
-	if d.Repository != "" {
-		r = d.Repository
-	} else {
-		r = "https://" + d.Name
-	}
-
-	f, nr, _ := mirrors.Get(r)
-	if f {
-		return nr
-	}
-
+	r, _ := d.location()
 	return r
 }
 
 // Vcs returns the VCS type to fetch source from.
 func (d *Dependency) Vcs() string {
+	_, v := d.location()
+	return v
+}
+
+// UsedOn returns true when the dependency is used on the platform goos/goarch,
+// that is when its os and arch filters are empty or list them.
+func (d *Dependency) UsedOn(goos, goarch string) bool {
+	return usedOn(d.Os, d.Arch, goos, goarch)
+}
+
+func usedOn(oses, arches []string, goos, goarch string) bool {
+	return (len(oses) == 0 || len(stringsNotIn([]string{goos}, oses)) == 0) &&
+		(len(arches) == 0 || len(stringsNotIn([]string{goarch}, arches)) == 0)
+}
+
+// location returns the repo and VCS type to fetch source from. The resolver
+// hooks get the last word over Origin, short of a substitute set for the rest
+// of the run.
+func (d *Dependency) location() (string, string) {
+	remote, v := d.Origin()
+	remote, v = hooks.Rewritten(d.Name, remote, v)
+	if s, ok := mirrors.Substitution(remote); ok {
+		return s, "git"
+	}
+	return remote, v
+}
+
+// Origin returns the repo and VCS type to fetch source from before the
+// resolver hooks rewrite them. Mirrors come first, then rewrite rules for
+// dependencies without a repo.
+func (d *Dependency) Origin() (string, string) {
 	var r string
 
 	if d.Repository != "" {
@@ -489,12 +830,18 @@ func (d *Dependency) Vcs() string {
 		r = "https://" + d.Name
 	}
 
-	f, _, nv := mirrors.Get(r)
-	if f {
-		return nv
+	remote, v := r, d.VcsType
+	if f, nr, nv := mirrors.Get(r); f {
+		remote, v = nr, nv
+	} else if d.Repository == "" {
+		if f, nr, nv := mirrors.RewriteRemote(d.Name); f {
+			remote = nr
+			if d.VcsType == "" {
+				v = nv
+			}
+		}
 	}
-
-	return d.VcsType
+	return remote, v
 }
 
 // GetRepo retrieves a Masterminds/vcs repo object configured for the root
@@ -507,6 +854,16 @@ func (d *Dependency) GetRepo(dest string) (vcs.Repo, error) {
 
 	VcsType := d.Vcs()
 
+	// Archives are downloaded rather than checked out. When no checksum is
+	// set one coming from a lock file may be used as the version.
+	if VcsType == string(archive.Type) || archive.IsArchive(remote) {
+		sum := d.Checksum
+		if sum == "" && archive.IsChecksum(d.Reference) {
+			sum = d.Reference
+		}
+		return archive.NewRepo(remote, dest, sum)
+	}
+
 	// If the VCS type has a value we try that first.
 	if len(VcsType) > 0 && VcsType != "None" {
 		switch vcs.Type(VcsType) {
@@ -518,6 +875,10 @@ func (d *Dependency) GetRepo(dest string) (vcs.Repo, error) {
 			return vcs.NewHgRepo(remote, dest)
 		case vcs.Bzr:
 			return vcs.NewBzrRepo(remote, dest)
+		case fossil.Type:
+			return fossil.NewRepo(remote, dest)
+		case darcs.Type:
+			return darcs.NewRepo(remote, dest)
 		default:
 			return nil, fmt.Errorf("Unknown VCS type %s set for %s", VcsType, d.Name)
 		}
@@ -527,20 +888,107 @@ func (d *Dependency) GetRepo(dest string) (vcs.Repo, error) {
 	return vcs.NewRepo(remote, dest)
 }
 
+// IsArchive returns true if the dependency is fetched as a tarball or zip
+// file rather than from a VCS.
+func (d *Dependency) IsArchive() bool {
+	return d.Vcs() == string(archive.Type) || archive.IsArchive(d.Remote())
+}
+
 // Clone creates a clone of a Dependency
 func (d *Dependency) Clone() *Dependency {
 	return &Dependency{
-		Name:        d.Name,
-		Reference:   d.Reference,
-		Pin:         d.Pin,
-		Repository:  d.Repository,
-		VcsType:     d.VcsType,
-		Subpackages: d.Subpackages,
-		Arch:        d.Arch,
-		Os:          d.Os,
+		Name:            d.Name,
+		Reference:       d.Reference,
+		Pin:             d.Pin,
+		Repository:      d.Repository,
+		VcsType:         d.VcsType,
+		Subpackages:     d.Subpackages,
+		Arch:            d.Arch,
+		Os:              d.Os,
+		Submodules:      d.Submodules,
+		Shallow:         d.Shallow,
+		SubpackagesOnly: d.SubpackagesOnly,
+		Checksum:        d.Checksum,
+		Local:           d.Local,
+		Relocate:        d.Relocate,
+		Track:           d.Track,
+		MaxAge:          d.MaxAge,
+		ExcludeVersions: d.ExcludeVersions,
+		Prerelease:      d.Prerelease,
+		TagPrefix:       d.TagPrefix,
+		AsOf:            d.AsOf,
+		Annotations:     cloneAnnotations(d.Annotations),
+		Groups:          d.Groups,
+		Fallback:        d.Fallback,
+		Patches:         d.Patches,
+		RequiredBy:      d.RequiredBy,
+		Upstream:        d.Upstream,
 	}
 }
 
+func cloneAnnotations(a map[string]string) map[string]string {
+	if a == nil {
+		return nil
+	}
+	n := make(map[string]string, len(a))
+	for k, v := range a {
+		n[k] = v
+	}
+	return n
+}
+
+// Excludes returns true if version is one of the versions the dependency must
+// never be set to. Semantic versions match regardless of how they are written,
+// so excluding 1.4.2 also excludes the tag v1.4.2.
+func (d *Dependency) Excludes(version string) bool {
+	sv, err := semver.NewVersion(version)
+	for _, e := range d.ExcludeVersions {
+		if e == version {
+			return true
+		}
+		if err != nil {
+			continue
+		}
+		if ev, err := semver.NewVersion(e); err == nil && ev.Equal(sv) {
+			return true
+		}
+	}
+	return false
+}
+
+// TagVersion returns the version a tag names once the tag prefix of the
+// dependency is removed, such as 1.2.3 for api/v1.2.3 with the prefix api/.
+// It returns false for tags without the prefix, which are not releases of the
+// dependency. Without a prefix every tag names itself.
+func (d *Dependency) TagVersion(tag string) (string, bool) {
+	if !strings.HasPrefix(tag, d.TagPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(tag, d.TagPrefix), true
+}
+
+// AsOfTime returns the time set with asof. A date without a time of day is
+// midnight UTC.
+func (d *Dependency) AsOfTime() (time.Time, error) {
+	return parseAsOf(d.AsOf)
+}
+
+func parseAsOf(s string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, use a date such as 2019-06-01 or a time such as 2019-06-01T15:04:05Z", s)
+}
+
+// IsPattern returns true when the name of the dependency is a pattern, such as
+// k8s.io/*, matching the names of packages rather than naming one. Only
+// overrides can be patterns.
+func (d *Dependency) IsPattern() bool {
+	return strings.ContainsAny(d.Name, "*?[")
+}
+
 // HasSubpackage returns if the subpackage is present on the dependency
 func (d *Dependency) HasSubpackage(sub string) bool {
 
@@ -607,7 +1055,7 @@ func stringArrayDeDupe(s []string, items ...string) []string {
 
 func filterVcsType(vcs string) string {
 	switch vcs {
-	case "git", "hg", "bzr", "svn":
+	case "git", "hg", "bzr", "svn", "fossil", "darcs", "archive":
 		return vcs
 	case "mercurial":
 		return "hg"
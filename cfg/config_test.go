@@ -1,6 +1,8 @@
 package cfg
 
 import (
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"gopkg.in/yaml.v2"
@@ -33,6 +35,7 @@ import:
     arch:
       - i386
       - arm
+    submodules: true
   - package: github.com/Masterminds/structable
   - package: github.com/Masterminds/cookoo/color
   - package: github.com/Masterminds/cookoo/convert
@@ -73,6 +76,9 @@ func TestManualConfigFromYaml(t *testing.T) {
 			if i.Reference != ref {
 				t.Errorf("Config reference for cookoo is inaccurate. Expected '%s' found '%s'", ref, i.Reference)
 			}
+			if !i.Submodules {
+				t.Error("Expected submodules to be enabled for github.com/Masterminds/convert")
+			}
 		}
 
 		if i.Name == "github.com/Masterminds/cookoo" {
@@ -176,3 +182,174 @@ func TestOwners(t *testing.T) {
 		t.Error("Unable to parse owners from yaml")
 	}
 }
+
+func TestAnnotations(t *testing.T) {
+	c, err := ConfigFromYaml([]byte(`package: fake/testing
+import:
+- package: github.com/Masterminds/semver
+  version: ^1.0.0
+  annotations:
+    owner: team-platform
+    ticket: https://issues.example.com/42
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := c.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := ConfigFromYaml(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := c2.Imports[0].Annotations
+	if a["owner"] != "team-platform" || a["ticket"] != "https://issues.example.com/42" {
+		t.Errorf("Expected annotations to round-trip, got %v", a)
+	}
+
+	d := c2.Imports[0].Clone()
+	d.Annotations["owner"] = "someone-else"
+	if a["owner"] != "team-platform" {
+		t.Error("Expected cloned annotations to be a copy")
+	}
+}
+
+func TestOverride(t *testing.T) {
+	c, err := ConfigFromYaml([]byte(`package: fake/testing
+import:
+- package: github.com/Masterminds/semver
+  version: ^1.2.0
+override:
+- package: github.com/Masterminds/semver
+  version: 1.3.1
+- package: github.com/Masterminds/vcs
+  repo: https://example.com/vcs.git
+  vcs: git
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Overrides) != 2 {
+		t.Fatalf("Expected 2 overrides, got %d", len(c.Overrides))
+	}
+
+	c.Imports[0].Pin = "abc123"
+	c.ApplyOverrides()
+	if c.Imports[0].Reference != "1.3.1" || c.Imports[0].Pin != "" {
+		t.Errorf("Override not applied to import: %+v", c.Imports[0])
+	}
+
+	d := &Dependency{Name: "github.com/Masterminds/vcs", Reference: "^1.8.0"}
+	if !c.Override(d) {
+		t.Error("Expected an override for github.com/Masterminds/vcs")
+	}
+	if d.Reference != "^1.8.0" || d.Repository != "https://example.com/vcs.git" || d.VcsType != "git" {
+		t.Errorf("Override applied incorrectly: %+v", d)
+	}
+	if c.Override(&Dependency{Name: "github.com/Masterminds/cookoo"}) {
+		t.Error("Unexpected override for github.com/Masterminds/cookoo")
+	}
+
+	out, err := c.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := ConfigFromYaml(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c2.Overrides) != 2 || len(c.Clone().Overrides) != 2 {
+		t.Error("Overrides lost when writing or cloning the config")
+	}
+}
+
+func TestOverridePattern(t *testing.T) {
+	c, err := ConfigFromYaml([]byte(`package: fake/testing
+import:
+- package: k8s.io/api
+  version: ^0.16.0
+override:
+- package: k8s.io/*
+  version: ~0.17.0
+- package: k8s.io/klog
+  version: v1.0.0
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Overrides[0].Name != "k8s.io/*" {
+		t.Fatalf("Expected the pattern to be kept, got %s", c.Overrides[0].Name)
+	}
+
+	c.ApplyOverrides()
+	if c.Imports[0].Reference != "~0.17.0" {
+		t.Errorf("Expected the pattern to set the version of k8s.io/api, got %s", c.Imports[0].Reference)
+	}
+
+	tests := map[string]string{
+		"k8s.io/client-go":          "~0.17.0",
+		"k8s.io/klog":               "v1.0.0",
+		"k8s.io.example.com/api":    "",
+		"github.com/kubernetes/api": "",
+	}
+	for name, want := range tests {
+		d := &Dependency{Name: name, Repository: "https://example.com/repo"}
+		got := ""
+		if c.Override(d) {
+			got = d.Reference
+		}
+		if got != want {
+			t.Errorf("Expected %s to be overridden to %q, got %q", name, want, got)
+		}
+	}
+}
+
+func TestExcludes(t *testing.T) {
+	d := &Dependency{
+		Name:            "github.com/Masterminds/semver",
+		ExcludeVersions: []string{"1.4.2", "broken-branch"},
+	}
+	tests := []struct {
+		version string
+		exclude bool
+	}{
+		{"1.4.2", true},
+		{"v1.4.2", true},
+		{"1.4.3", false},
+		{"broken-branch", true},
+		{"master", false},
+	}
+	for _, tt := range tests {
+		if got := d.Excludes(tt.version); got != tt.exclude {
+			t.Errorf("Expected Excludes(%q) to be %t", tt.version, tt.exclude)
+		}
+	}
+}
+
+func TestVendorPath(t *testing.T) {
+	c, err := ConfigFromYaml([]byte("package: fake/testing\nvendorDir: third_party/go/\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := c.VendorPath(); p != filepath.Join("third_party", "go") {
+		t.Errorf("Expected third_party/go, got %s", p)
+	}
+	if p := c.Clone().VendorPath(); p != filepath.Join("third_party", "go") {
+		t.Errorf("Expected the vendor directory to be cloned, got %s", p)
+	}
+	out, err := c.Marshal()
+	if err != nil || !strings.Contains(string(out), "vendorDir: third_party/go/") {
+		t.Errorf("Expected vendorDir to be written, got %s (%v)", out, err)
+	}
+	if p := (&Config{}).VendorPath(); p != "vendor" {
+		t.Errorf("Expected vendor by default, got %s", p)
+	}
+	if p := (&Config{}).TestVendorPath(); p != "" {
+		t.Errorf("Expected no test vendor directory by default, got %s", p)
+	}
+	c.TestVendorDir = "vendor-test/"
+	if p := c.Clone().TestVendorPath(); p != "vendor-test" {
+		t.Errorf("Expected the test vendor directory to be cloned, got %s", p)
+	}
+}
@@ -0,0 +1,54 @@
+package cfg
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+// rangeSepRe finds the spaces between the comparisons of a range like
+// >=1.2 <1.4, which are joined with a comma before parsing. Hyphen ranges,
+// such as 1.2 - 1.4, are left alone.
+var rangeSepRe = regexp.MustCompile(`([0-9A-Za-z*])\s+([<>=!~^0-9])`)
+
+// NewConstraint parses a semantic version range as used for the version of a
+// dependency. Besides the syntax of github.com/Masterminds/semver, where a
+// comma is an and and || an or, the comparisons of a range may be separated
+// by spaces, as in >=1.2 <1.4 || ^2.0.
+func NewConstraint(s string) (*semver.Constraints, error) {
+	return semver.NewConstraint(rangeSepRe.ReplaceAllString(s, "$1, $2"))
+}
+
+// IntersectConstraints returns a range matching the versions both ranges
+// match. Each alternative of a is combined with each of b, so
+// ^1.2 || ^2.0 and !=2.1.0 become ^1.2, !=2.1.0 || ^2.0, !=2.1.0.
+func IntersectConstraints(a, b string) string {
+	var parts []string
+	seen := make(map[string]bool)
+	for _, x := range constraintAlternatives(a) {
+		for _, y := range constraintAlternatives(b) {
+			p := x + ", " + y
+			if x == y {
+				p = x
+			}
+			if !seen[p] {
+				seen[p] = true
+				parts = append(parts, p)
+			}
+		}
+	}
+	return strings.Join(parts, " || ")
+}
+
+// constraintAlternatives splits a range into the alternatives joined by ||,
+// with the comparisons of each separated by commas.
+func constraintAlternatives(s string) []string {
+	var alts []string
+	for _, a := range strings.Split(s, "||") {
+		if a = strings.TrimSpace(rangeSepRe.ReplaceAllString(a, "$1, $2")); a != "" {
+			alts = append(alts, a)
+		}
+	}
+	return alts
+}
@@ -0,0 +1,59 @@
+package cfg
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver"
+)
+
+func TestNewConstraint(t *testing.T) {
+	tests := []struct {
+		rng     string
+		version string
+		ok      bool
+	}{
+		{">=1.2 <1.4", "1.3.0", true},
+		{">=1.2 <1.4", "1.4.0", false},
+		{">=1.2 <1.4 || ^2.0", "2.1.0", true},
+		{"1.2 - 1.4.5", "1.4.5", true},
+		{"^1.2, !=1.4.x || ^2.0", "1.4.2", false},
+		{">= 1.2, < 2", "1.9.0", true},
+	}
+	for _, tt := range tests {
+		c, err := NewConstraint(tt.rng)
+		if err != nil {
+			t.Errorf("Unable to parse %q: %s", tt.rng, err)
+			continue
+		}
+		if c.Check(semver.MustParse(tt.version)) != tt.ok {
+			t.Errorf("Expected %q to be %t for %s", tt.rng, tt.ok, tt.version)
+		}
+	}
+}
+
+func TestIntersectConstraints(t *testing.T) {
+	tests := []struct {
+		a, b, want string
+	}{
+		{"^1.2", ">=1.3", "^1.2, >=1.3"},
+		{"^1.2 || ^2.0", "!=2.1.0", "^1.2, !=2.1.0 || ^2.0, !=2.1.0"},
+		{"^1.2 || ^2.0", "^2.0 || ^3.0", "^1.2, ^2.0 || ^1.2, ^3.0 || ^2.0 || ^2.0, ^3.0"},
+		{">=1.2 <1.4", "^1.3", ">=1.2, <1.4, ^1.3"},
+	}
+	for _, tt := range tests {
+		got := IntersectConstraints(tt.a, tt.b)
+		if got != tt.want {
+			t.Errorf("Expected %q and %q to give %q, got %q", tt.a, tt.b, tt.want, got)
+		}
+		if _, err := NewConstraint(got); err != nil {
+			t.Errorf("Unable to parse %q: %s", got, err)
+		}
+	}
+
+	c, _ := NewConstraint(IntersectConstraints("^1.2 || ^2.0", "!=2.1.0"))
+	for v, ok := range map[string]bool{"1.5.0": true, "2.0.3": true, "2.1.0": false, "3.0.0": false} {
+		if c.Check(semver.MustParse(v)) != ok {
+			t.Errorf("Expected the intersection to be %t for %s", ok, v)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package cfg
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/Masterminds/glide/credentials"
+	"github.com/Masterminds/glide/mirrors"
+	"gopkg.in/yaml.v2"
+)
+
+// ResolveExtends loads the config files c extends, and the ones they extend in
+// turn, merging them into c. The location of a base is a path, relative to dir
+// when not absolute, or an http(s) URL. Values set in c override inherited ones.
+//
+// Only the values set in c are written when it is marshaled so inherited ones
+// stay in the base.
+func (c *Config) ResolveExtends(dir string) error {
+	if c.Extends == "" {
+		return nil
+	}
+	base, err := loadBase(c.Extends, dir, nil)
+	if err != nil {
+		return err
+	}
+	return c.Extend(base)
+}
+
+// loadBase loads the config at loc along with the configs it extends. Seen
+// holds the locations already loaded to detect cycles.
+func loadBase(loc, dir string, seen []string) (*Config, error) {
+	var yml []byte
+	var err error
+	var next string
+	if isURL(dir) || isURL(loc) {
+		if loc, err = resolveURL(dir, loc); err != nil {
+			return nil, err
+		}
+		next = loc
+	} else {
+		if !filepath.IsAbs(loc) {
+			loc = filepath.Join(dir, loc)
+		}
+		next = filepath.Dir(loc)
+	}
+
+	for _, s := range seen {
+		if s == loc {
+			return nil, fmt.Errorf("%s extends itself through %s", loc, strings.Join(seen, " -> "))
+		}
+	}
+	seen = append(seen, loc)
+
+	if isURL(loc) {
+		yml, err = fetchBase(loc)
+	} else {
+		yml, err = ioutil.ReadFile(loc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	base, err := ConfigFromYaml(yml)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse %s: %s", loc, err)
+	}
+	if base.Extends == "" {
+		return base, nil
+	}
+
+	parent, err := loadBase(base.Extends, next, seen)
+	if err != nil {
+		return nil, err
+	}
+	return base, base.Extend(parent)
+}
+
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+// resolveURL returns the URL of loc when it is relative to the base at dir.
+func resolveURL(dir, loc string) (string, error) {
+	u, err := url.Parse(loc)
+	if err != nil {
+		return "", err
+	}
+	if !isURL(dir) {
+		return u.String(), nil
+	}
+	d, err := url.Parse(dir)
+	if err != nil {
+		return "", err
+	}
+	return d.ResolveReference(u).String(), nil
+}
+
+func fetchBase(loc string) ([]byte, error) {
+	req, err := http.NewRequest("GET", loc, nil)
+	if err != nil {
+		return nil, err
+	}
+	credentials.Authorize(req)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unable to fetch %s: %s", loc, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// Extend merges the values of base into c. Values set in c win. A dependency
+// listed in c replaces one with the same name in base rather than being merged
+// with it, and packages c ignores are not inherited.
+func (c *Config) Extend(base *Config) error {
+	if c.Description == "" {
+		c.Description = base.Description
+	}
+	if c.Home == "" {
+		c.Home = base.Home
+	}
+	if c.License == "" {
+		c.License = base.License
+	}
+	if c.Nested == "" {
+		c.Nested = base.Nested
+	}
+	if len(c.Owners) == 0 {
+		c.Owners = base.Owners.Clone()
+	}
+	if c.Policy == nil {
+		c.Policy = base.Policy.Clone()
+	}
+	if c.Go == "" {
+		c.Go = base.Go
+	}
+	c.Ignore = append(c.Ignore, stringsNotIn(base.Ignore, c.Ignore)...)
+	c.Exclude = append(c.Exclude, stringsNotIn(base.Exclude, c.Exclude)...)
+	c.OptionalGroups = append(c.OptionalGroups, stringsNotIn(base.OptionalGroups, c.OptionalGroups)...)
+
+	for _, d := range base.Imports {
+		if !c.HasDependency(d.Name) {
+			c.Imports = append(c.Imports, d.Clone())
+		}
+	}
+	for _, d := range base.DevImports {
+		if !c.HasDependency(d.Name) {
+			c.DevImports = append(c.DevImports, d.Clone())
+		}
+	}
+	for _, d := range base.Overrides {
+		if c.Overrides.Get(d.Name) == nil {
+			c.Overrides = append(c.Overrides, d.Clone())
+		}
+	}
+	for _, d := range base.Tools {
+		if c.Tools.Get(d.Name) == nil {
+			c.Tools = append(c.Tools, d.Clone())
+		}
+	}
+	for k, v := range base.StdAliases {
+		if _, ok := c.StdAliases[k]; !ok {
+			if c.StdAliases == nil {
+				c.StdAliases = make(map[string]string)
+			}
+			c.StdAliases[k] = v
+		}
+	}
+	for _, g := range base.SyncGroups {
+		if c.SyncGroups.Get(g.Name) == nil {
+			n := *g
+			c.SyncGroups = append(c.SyncGroups, &n)
+		}
+	}
+	for _, m := range base.Mirrors {
+		if !hasMirror(c.Mirrors, m.Original) {
+			n := *m
+			c.Mirrors = append(c.Mirrors, &n)
+		}
+	}
+
+	c.inherited = base
+	return c.DeDupe()
+}
+
+// local returns a copy of c without the values inherited from the configs it
+// extends.
+func (c *Config) local() *Config {
+	b := c.inherited
+	n := c.Clone()
+	n.inherited = nil
+	if b == nil {
+		return n
+	}
+
+	if n.Description == b.Description {
+		n.Description = ""
+	}
+	if n.Home == b.Home {
+		n.Home = ""
+	}
+	if n.License == b.License {
+		n.License = ""
+	}
+	if n.Nested == b.Nested {
+		n.Nested = ""
+	}
+	if reflect.DeepEqual(n.Owners, b.Owners) {
+		n.Owners = nil
+	}
+	if reflect.DeepEqual(n.Policy, b.Policy) {
+		n.Policy = nil
+	}
+	if n.Go == b.Go {
+		n.Go = ""
+	}
+	n.Ignore = stringsNotIn(n.Ignore, b.Ignore)
+	n.Exclude = stringsNotIn(n.Exclude, b.Exclude)
+	n.OptionalGroups = stringsNotIn(n.OptionalGroups, b.OptionalGroups)
+	all := append(b.Imports.Clone(), b.DevImports...)
+	n.Imports = depsNotIn(n.Imports, all)
+	n.DevImports = depsNotIn(n.DevImports, all)
+	n.Overrides = depsNotIn(n.Overrides, b.Overrides)
+	n.Tools = depsNotIn(n.Tools, b.Tools)
+
+	var groups SyncGroups
+	for _, g := range n.SyncGroups {
+		if !reflect.DeepEqual(g, b.SyncGroups.Get(g.Name)) {
+			groups = append(groups, g)
+		}
+	}
+	n.SyncGroups = groups
+
+	for k, v := range b.StdAliases {
+		if n.StdAliases[k] == v {
+			delete(n.StdAliases, k)
+		}
+	}
+	if len(n.StdAliases) == 0 {
+		n.StdAliases = nil
+	}
+
+	var m mirrors.MirrorRepos
+	for _, r := range n.Mirrors {
+		found := false
+		for _, br := range b.Mirrors {
+			if *r == *br {
+				found = true
+				break
+			}
+		}
+		if !found {
+			m = append(m, r)
+		}
+	}
+	n.Mirrors = m
+	return n
+}
+
+func hasMirror(m mirrors.MirrorRepos, original string) bool {
+	for _, r := range m {
+		if r.Original == original {
+			return true
+		}
+	}
+	return false
+}
+
+func stringsNotIn(s, base []string) []string {
+	var n []string
+	for _, v := range s {
+		found := false
+		for _, b := range base {
+			if v == b {
+				found = true
+				break
+			}
+		}
+		if !found {
+			n = append(n, v)
+		}
+	}
+	return n
+}
+
+// depsNotIn returns the dependencies in d that do not match one in base.
+// Dependencies are compared by the YAML they are written as.
+func depsNotIn(d, base Dependencies) Dependencies {
+	n := make(Dependencies, 0, len(d))
+	for _, dep := range d {
+		b := base.Get(dep.Name)
+		if b != nil {
+			dy, err1 := yaml.Marshal(dep)
+			by, err2 := yaml.Marshal(b)
+			if err1 == nil && err2 == nil && string(dy) == string(by) {
+				continue
+			}
+		}
+		n = append(n, dep)
+	}
+	return n
+}
@@ -0,0 +1,95 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveExtends(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-extends")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"org.yaml": `package: org
+license: MIT
+ignore:
+- appengine
+mirrors:
+- original: github.com/Masterminds/vcs
+  repo: https://git.example.com/vcs
+`,
+		"team/base.yaml": `package: team
+extends: ../org.yaml
+import:
+- package: github.com/Masterminds/semver
+  version: ^1.0.0
+- package: github.com/Masterminds/vcs
+  version: ^1.8.0
+`,
+	}
+	for n, c := range files {
+		p := filepath.Join(dir, n)
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := ioutil.WriteFile(p, []byte(c), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	yml := `package: fake/testing
+extends: team/base.yaml
+x-defaults: &defaults
+  repo: https://git.example.com/semver
+  vcs: git
+import:
+- package: github.com/Masterminds/semver
+  version: ^1.2.0
+  <<: *defaults
+`
+	if errs := Validate([]byte(yml)); len(errs) != 0 {
+		t.Errorf("Expected anchors and extends to be valid, got %v", errs)
+	}
+	c, err := ConfigFromYaml([]byte(yml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ResolveExtends(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.License != "MIT" || !c.HasIgnore("appengine") || len(c.Mirrors) != 1 {
+		t.Errorf("Expected values from the org config to be inherited")
+	}
+	if d := c.Imports.Get("github.com/Masterminds/vcs"); d == nil || d.Reference != "^1.8.0" {
+		t.Errorf("Expected github.com/Masterminds/vcs to be inherited")
+	}
+	d := c.Imports.Get("github.com/Masterminds/semver")
+	if d == nil || d.Reference != "^1.2.0" || d.Repository != "https://git.example.com/semver" {
+		t.Errorf("Expected the local github.com/Masterminds/semver to override the inherited one")
+	}
+
+	out, err := c.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"license", "appengine", "mirrors", "Masterminds/vcs"} {
+		if strings.Contains(string(out), s) {
+			t.Errorf("Expected inherited %s not to be written, got:\n%s", s, out)
+		}
+	}
+	if !strings.Contains(string(out), "^1.2.0") {
+		t.Errorf("Expected local values to be written, got:\n%s", out)
+	}
+
+	// Extending a config that extends this one is an error.
+	ioutil.WriteFile(filepath.Join(dir, "org.yaml"), []byte("package: org\nextends: team/base.yaml\n"), 0644)
+	c, _ = ConfigFromYaml([]byte("package: fake\nextends: team/base.yaml\n"))
+	if err := c.ResolveExtends(dir); err == nil || !strings.Contains(err.Error(), "extends itself") {
+		t.Errorf("Expected a cycle to be detected, got %v", err)
+	}
+}
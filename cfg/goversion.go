@@ -0,0 +1,45 @@
+package cfg
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/Masterminds/semver"
+)
+
+// goReleaseRe matches the release of a Go toolchain, as in go1.12.5 or
+// go1.13beta1.
+var goReleaseRe = regexp.MustCompile(`go(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// GoConstraint returns the range of Go versions set with go in glide.yaml,
+// or nil when there is none. It uses the syntax of NewConstraint.
+func (c *Config) GoConstraint() (*semver.Constraints, error) {
+	if c.Go == "" {
+		return nil, nil
+	}
+	return parseGoConstraint(c.Go)
+}
+
+func parseGoConstraint(s string) (*semver.Constraints, error) {
+	con, err := NewConstraint(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Go version range %q: %s", s, err)
+	}
+	return con, nil
+}
+
+// GoRelease returns the version of the Go release in the output of go
+// version, such as 1.12.5 for go1.12.5. Beta and release candidates count as
+// the release they lead up to. An error is returned for development builds,
+// which have no release number.
+func GoRelease(version string) (*semver.Version, error) {
+	m := goReleaseRe.FindStringSubmatch(version)
+	if m == nil {
+		return nil, fmt.Errorf("no Go release found in %q", version)
+	}
+	patch := m[3]
+	if patch == "" {
+		patch = "0"
+	}
+	return semver.NewVersion(m[1] + "." + m[2] + "." + patch)
+}
@@ -0,0 +1,43 @@
+package cfg
+
+import "testing"
+
+func TestGoConstraint(t *testing.T) {
+	c := &Config{}
+	if con, err := c.GoConstraint(); con != nil || err != nil {
+		t.Errorf("Expected no range without go, got %v and %v", con, err)
+	}
+
+	tests := []struct {
+		rng     string
+		version string
+		ok      bool
+	}{
+		{">=1.9 <1.13", "go version go1.12.5 linux/amd64", true},
+		{">=1.9 <1.13", "go version go1.13 darwin/amd64", false},
+		{">=1.9 <1.13", "go version go1.13beta1 linux/amd64", false},
+		{">=1.9, <1.13", "go version go1.8.7 linux/amd64", false},
+		{"~1.12", "go version go1.12.17 linux/amd64", true},
+		{"1.10.x || >=1.12", "go version go1.11 linux/amd64", false},
+	}
+	for _, tt := range tests {
+		c.Go = tt.rng
+		con, err := c.GoConstraint()
+		if err != nil {
+			t.Errorf("Unable to parse %q: %s", tt.rng, err)
+			continue
+		}
+		v, err := GoRelease(tt.version)
+		if err != nil {
+			t.Errorf("Unable to find the release in %q: %s", tt.version, err)
+			continue
+		}
+		if con.Check(v) != tt.ok {
+			t.Errorf("Expected %q to be %t for %s", tt.rng, tt.ok, v)
+		}
+	}
+
+	if _, err := GoRelease("go version devel +2b5d4e7 linux/amd64"); err == nil {
+		t.Error("Expected an error for a development build")
+	}
+}
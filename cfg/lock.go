@@ -17,6 +17,9 @@ type Lockfile struct {
 	Updated    time.Time `yaml:"updated"`
 	Imports    Locks     `yaml:"imports"`
 	DevImports Locks     `yaml:"testImports"`
+
+	// Tools are the versions the tools listed in glide.yaml are built at.
+	Tools Locks `yaml:"tools,omitempty"`
 }
 
 // LockfileFromYaml returns an instance of Lockfile from YAML
@@ -87,6 +90,7 @@ func (lf *Lockfile) Clone() *Lockfile {
 	n.Updated = lf.Updated
 	n.Imports = lf.Imports.Clone()
 	n.DevImports = lf.DevImports.Clone()
+	n.Tools = lf.Tools.Clone()
 
 	return n
 }
@@ -98,6 +102,7 @@ func (lf *Lockfile) Fingerprint() ([32]byte, error) {
 	c.Updated = time.Time{} // Set the time to be the nil equivalent
 	sort.Sort(c.Imports)
 	sort.Sort(c.DevImports)
+	sort.Sort(c.Tools)
 	yml, err := c.Marshal()
 	if err != nil {
 		return [32]byte{}, err
@@ -131,6 +136,21 @@ func (l Locks) Clone() Locks {
 	return n
 }
 
+// Get a lock by name
+func (l Locks) Get(name string) *Lock {
+	for _, lk := range l {
+		if lk.Name == name {
+			return lk
+		}
+	}
+	return nil
+}
+
+// Has checks if a lock with the given name is in the list
+func (l Locks) Has(name string) bool {
+	return l.Get(name) != nil
+}
+
 // Len returns the length of the Locks. This is needed for sorting with
 // the sort package.
 func (l Locks) Len() int {
@@ -162,31 +182,69 @@ type Lock struct {
 	Subpackages []string `yaml:"subpackages,omitempty"`
 	Arch        []string `yaml:"arch,omitempty"`
 	Os          []string `yaml:"os,omitempty"`
+	Submodules  bool     `yaml:"submodules,omitempty"`
+	Shallow     bool     `yaml:"shallow,omitempty"`
+
+	// SubpackagesOnly is true when only the subpackages are vendored.
+	SubpackagesOnly bool `yaml:"subpackagesOnly,omitempty"`
+
+	// Upstream is the repository the dependency is meant to come from when
+	// it was gone and Repository is the fallback it was fetched from instead.
+	Upstream string `yaml:"upstream,omitempty"`
+
+	// RequiredBy lists the packages requiring the dependency, as found by
+	// the last update.
+	RequiredBy []*Requirement `yaml:"requiredBy,omitempty"`
+}
+
+// Requirement is a package requiring a dependency. Version is the version it
+// asks for in its glide.yaml file, if any, and Applied is true when that is the
+// version that was used.
+type Requirement struct {
+	By      string `yaml:"by"`
+	Version string `yaml:"version,omitempty"`
+	Applied bool   `yaml:"applied,omitempty"`
 }
 
 // Clone creates a clone of a Lock.
 func (l *Lock) Clone() *Lock {
 	return &Lock{
-		Name:        l.Name,
-		Version:     l.Version,
-		Repository:  l.Repository,
-		VcsType:     l.VcsType,
-		Subpackages: l.Subpackages,
-		Arch:        l.Arch,
-		Os:          l.Os,
+		Name:            l.Name,
+		Version:         l.Version,
+		Repository:      l.Repository,
+		VcsType:         l.VcsType,
+		Subpackages:     l.Subpackages,
+		Arch:            l.Arch,
+		Os:              l.Os,
+		Submodules:      l.Submodules,
+		Shallow:         l.Shallow,
+		SubpackagesOnly: l.SubpackagesOnly,
+		RequiredBy:      l.RequiredBy,
+		Upstream:        l.Upstream,
 	}
 }
 
+// UsedOn returns true when the locked dependency is used on the platform
+// goos/goarch. See Dependency.UsedOn.
+func (l *Lock) UsedOn(goos, goarch string) bool {
+	return usedOn(l.Os, l.Arch, goos, goarch)
+}
+
 // LockFromDependency converts a Dependency to a Lock
 func LockFromDependency(dep *Dependency) *Lock {
 	return &Lock{
-		Name:        dep.Name,
-		Version:     dep.Pin,
-		Repository:  dep.Repository,
-		VcsType:     dep.VcsType,
-		Subpackages: dep.Subpackages,
-		Arch:        dep.Arch,
-		Os:          dep.Os,
+		Name:            dep.Name,
+		Version:         dep.Pin,
+		Repository:      dep.Repository,
+		VcsType:         dep.VcsType,
+		Subpackages:     dep.Subpackages,
+		Arch:            dep.Arch,
+		Os:              dep.Os,
+		Submodules:      dep.Submodules,
+		Shallow:         dep.Shallow,
+		SubpackagesOnly: dep.SubpackagesOnly,
+		RequiredBy:      dep.RequiredBy,
+		Upstream:        dep.Upstream,
 	}
 }
 
@@ -110,3 +110,20 @@ func TestSortSubpackages(t *testing.T) {
 		t.Errorf("Expected %q\n to contain\n%q", string(out), expectSubpkgYaml)
 	}
 }
+
+func TestLocksGet(t *testing.T) {
+	l := Locks{
+		&Lock{Name: "github.com/Masterminds/semver", Version: "abc"},
+		&Lock{Name: "github.com/Masterminds/vcs", Version: "def"},
+	}
+
+	if lk := l.Get("github.com/Masterminds/vcs"); lk == nil || lk.Version != "def" {
+		t.Error("Locks.Get failed to find github.com/Masterminds/vcs")
+	}
+	if !l.Has("github.com/Masterminds/semver") {
+		t.Error("Locks.Has failed to find github.com/Masterminds/semver")
+	}
+	if l.Has("github.com/Masterminds/cookoo") {
+		t.Error("Locks.Has found a lock that does not exist")
+	}
+}
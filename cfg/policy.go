@@ -0,0 +1,50 @@
+package cfg
+
+// Policy restricts the dependencies a project may use. It is checked after
+// the dependency tree is resolved and whenever a vendor directory is
+// installed. Empty fields place no restriction.
+//
+// Package patterns are globs, as used by path.Match, compared to the name of
+// a package and each of its parent paths. The pattern github.com/foo/* covers
+// every repo of github.com/foo and the packages within them.
+type Policy struct {
+	// Allow lists the packages that may be used. When set every dependency
+	// has to match one of the patterns.
+	Allow []string `yaml:"allow,omitempty"`
+
+	// Deny lists the packages that may not be used. It takes precedence over
+	// Allow.
+	Deny []string `yaml:"deny,omitempty"`
+
+	// Licenses lists the SPDX identifiers of the licenses dependencies may
+	// have. When set a dependency whose license is not recognized violates
+	// the policy.
+	Licenses []string `yaml:"licenses,omitempty"`
+
+	// DenyLicenses lists the SPDX identifiers of licenses dependencies may not
+	// have.
+	DenyLicenses []string `yaml:"denyLicenses,omitempty"`
+
+	// MaxAge is the age, in days, the commit a dependency is pinned to may
+	// have at most.
+	MaxAge int `yaml:"maxAge,omitempty"`
+
+	// Hosts lists the hosts the repos of dependencies have to be fetched from,
+	// such as github.com.
+	Hosts []string `yaml:"hosts,omitempty"`
+}
+
+// Clone returns a copy of the policy.
+func (p *Policy) Clone() *Policy {
+	if p == nil {
+		return nil
+	}
+	n := *p
+	return &n
+}
+
+// Empty returns true when the policy places no restrictions.
+func (p *Policy) Empty() bool {
+	return p == nil || (len(p.Allow) == 0 && len(p.Deny) == 0 && len(p.Licenses) == 0 &&
+		len(p.DenyLicenses) == 0 && p.MaxAge <= 0 && len(p.Hosts) == 0)
+}
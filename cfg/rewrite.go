@@ -0,0 +1,559 @@
+package cfg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/glide/util"
+	"gopkg.in/yaml.v2"
+)
+
+// errRewrite is returned when part of a YAML file cannot be edited in place.
+// That part is written out again instead.
+var errRewrite = errors.New("unable to edit the YAML in place")
+
+// listIdentity names the field identifying each item of a list of mappings so
+// the items can be matched between the old and new content.
+var listIdentity = map[string]string{
+	"import":     "package",
+	"testImport": "package",
+	"owners":     "name",
+	"mirrors":    "original",
+}
+
+// rewriteYaml returns the original YAML changed to hold the configuration in
+// normalized, which is the output of Marshal. Only the parts that changed are
+// written again so comments, the order of keys, and formatting elsewhere in
+// the file are preserved. An error is returned when the result would not hold
+// the same configuration.
+func rewriteYaml(orig, normalized []byte) ([]byte, error) {
+	if bytes.Contains(orig, []byte("\r")) {
+		return nil, errRewrite
+	}
+	var oldDoc, newDoc yaml.MapSlice
+	if err := yaml.Unmarshal(orig, &oldDoc); err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(normalized, &newDoc); err != nil {
+		return nil, err
+	}
+
+	text := strings.TrimSuffix(string(orig), "\n")
+	e := &yamlEditor{}
+	if text != "" {
+		e.lines = strings.Split(text, "\n")
+	}
+	entries, err := e.mapEntries(0, len(e.lines), 0, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.mergeMap(entries, oldDoc, newDoc, 0, true, e.lastContent(0, len(e.lines))); err != nil {
+		return nil, err
+	}
+	out, err := e.apply()
+	if err != nil {
+		return nil, err
+	}
+
+	// The edited file has to hold exactly the configuration asked for.
+	want, err := normalize(normalized)
+	if err != nil {
+		return nil, err
+	}
+	got, err := normalize(out)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(want, got) {
+		return nil, errRewrite
+	}
+	return out, nil
+}
+
+func normalize(yml []byte) ([]byte, error) {
+	c, err := ConfigFromYaml(yml)
+	if err != nil {
+		return nil, err
+	}
+	return c.Marshal()
+}
+
+// yamlEditor records edits to the lines of a block style YAML document.
+type yamlEditor struct {
+	lines []string
+	edits []yamlEdit
+}
+
+// yamlEdit replaces the lines from start up to end. Inserts have the same
+// start and end.
+type yamlEdit struct {
+	start, end int
+	lines      []string
+}
+
+// yamlEntry is a key of a block mapping along with the lines of its value.
+type yamlEntry struct {
+	key string
+
+	// start is the first line of the entry including the comments directly
+	// above it. line is the line of the key and end the line after the last
+	// one of the value.
+	start, line, end int
+
+	// col is the column of the key and value the offset of its value on the
+	// line.
+	col, value int
+
+	// dash is true when the key follows the dash of a list item.
+	dash bool
+}
+
+// yamlItem is an item of a block sequence.
+type yamlItem struct {
+	start, line, end int
+
+	// col is the column the content of the item starts at and dash is true
+	// when the content starts on the line of the dash.
+	col  int
+	dash bool
+}
+
+func (e *yamlEditor) replace(start, end int, lines []string) {
+	e.edits = append(e.edits, yamlEdit{start: start, end: end, lines: lines})
+}
+
+type byEditStart []yamlEdit
+
+func (b byEditStart) Len() int      { return len(b) }
+func (b byEditStart) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byEditStart) Less(i, j int) bool {
+	if b[i].start != b[j].start {
+		return b[i].start < b[j].start
+	}
+	// Inserts go before a replacement starting on the same line.
+	return b[i].start == b[i].end && b[j].start != b[j].end
+}
+
+// apply returns the document with the edits made.
+func (e *yamlEditor) apply() ([]byte, error) {
+	sort.Stable(byEditStart(e.edits))
+	var out []string
+	pos := 0
+	for _, ed := range e.edits {
+		if ed.start < pos {
+			return nil, errRewrite
+		}
+		out = append(out, e.lines[pos:ed.start]...)
+		out = append(out, ed.lines...)
+		pos = ed.end
+	}
+	out = append(out, e.lines[pos:]...)
+	return []byte(strings.Join(out, "\n") + "\n"), nil
+}
+
+// lineInfo returns the indentation of a line and whether it has content. Blank
+// lines and those only holding a comment have no content.
+func lineInfo(l string) (int, bool) {
+	t := strings.TrimLeft(l, " ")
+	return len(l) - len(t), t != "" && !strings.HasPrefix(t, "#")
+}
+
+func isDashLine(l string, col int) bool {
+	t := l[col:]
+	return t == "-" || strings.HasPrefix(t, "- ")
+}
+
+// lastContent returns the line after the last one with content in the range.
+func (e *yamlEditor) lastContent(from, to int) int {
+	for i := to - 1; i >= from; i-- {
+		if _, c := lineInfo(e.lines[i]); c {
+			return i + 1
+		}
+	}
+	return from
+}
+
+// leadingComments returns the first of the comment lines directly above line
+// without going above from.
+func (e *yamlEditor) leadingComments(line, from int) int {
+	start := line
+	for start > from {
+		t := strings.TrimSpace(e.lines[start-1])
+		if !strings.HasPrefix(t, "#") {
+			break
+		}
+		start--
+	}
+	return start
+}
+
+// mapEntries indexes the keys of the block mapping at col within the lines
+// from up to to. When dash is true the first key follows the dash of a list
+// item.
+func (e *yamlEditor) mapEntries(from, to, col int, dash bool) ([]*yamlEntry, error) {
+	var entries []*yamlEntry
+	var cur *yamlEntry
+	last := from
+	for i := from; i < to; i++ {
+		l := e.lines[i]
+		ind, content := lineInfo(l)
+		if !content {
+			continue
+		}
+		first := dash && i == from
+		switch {
+		case first || (ind == col && !isDashLine(l, col)):
+			key, value, ok := keyAt(l, col)
+			if !ok || key == "<<" {
+				return nil, errRewrite
+			}
+			if cur != nil {
+				cur.end = last
+			}
+			start := i
+			if !first {
+				prev := from
+				if cur != nil {
+					prev = cur.end
+				}
+				start = e.leadingComments(i, prev)
+			}
+			cur = &yamlEntry{key: key, start: start, line: i, col: col, value: value, dash: first}
+			entries = append(entries, cur)
+		case ind < col || cur == nil:
+			return nil, errRewrite
+		}
+		last = i + 1
+	}
+	if cur != nil {
+		cur.end = last
+	}
+	return entries, nil
+}
+
+// keyAt returns the key starting at col on a line along with the offset of
+// its value. Quoted and complex keys are not supported.
+func keyAt(l string, col int) (string, int, bool) {
+	if len(l) <= col {
+		return "", 0, false
+	}
+	rest := l[col:]
+	if strings.ContainsAny(rest[:1], "\"'?{[&*!|>%@`#-") {
+		return "", 0, false
+	}
+	i := strings.Index(rest, ":")
+	for i > 0 && i+1 < len(rest) && rest[i+1] != ' ' {
+		j := strings.Index(rest[i+1:], ":")
+		if j < 0 {
+			return "", 0, false
+		}
+		i += j + 1
+	}
+	if i <= 0 {
+		return "", 0, false
+	}
+	v := i + 1
+	for v < len(rest) && rest[v] == ' ' {
+		v++
+	}
+	return strings.TrimSpace(rest[:i]), col + v, true
+}
+
+// seqItems indexes the items of the block sequence within the lines from up
+// to to. The column of the dashes is returned too.
+func (e *yamlEditor) seqItems(from, to int) ([]*yamlItem, int, error) {
+	var items []*yamlItem
+	var cur *yamlItem
+	dashCol := -1
+	last := from
+	for i := from; i < to; i++ {
+		l := e.lines[i]
+		ind, content := lineInfo(l)
+		if !content {
+			continue
+		}
+		if dashCol < 0 {
+			if !isDashLine(l, ind) {
+				return nil, 0, errRewrite
+			}
+			dashCol = ind
+		}
+		switch {
+		case ind == dashCol && isDashLine(l, ind):
+			if cur != nil {
+				cur.end = last
+			}
+			prev := from
+			if cur != nil {
+				prev = cur.end
+			}
+			cur = &yamlItem{start: e.leadingComments(i, prev), line: i}
+			rest := strings.TrimLeft(l[ind+1:], " ")
+			if rest == "" {
+				cur.col = -1
+			} else {
+				cur.col = len(l) - len(rest)
+				cur.dash = true
+			}
+			items = append(items, cur)
+		case ind <= dashCol:
+			return nil, 0, errRewrite
+		case cur.col < 0:
+			cur.col = ind
+		}
+		last = i + 1
+	}
+	if cur != nil {
+		cur.end = last
+	}
+	return items, dashCol, nil
+}
+
+// mergeMap edits the entries of a mapping holding old to hold new instead.
+// Keys new adds are inserted at the line insert. At the top level keys that
+// are not configuration, such as those holding anchors, are kept.
+func (e *yamlEditor) mergeMap(entries []*yamlEntry, old, new yaml.MapSlice, col int, top bool, insert int) error {
+	seen := make(map[string]bool, len(entries))
+	for _, en := range entries {
+		if seen[en.key] {
+			return errRewrite
+		}
+		seen[en.key] = true
+		nv, inNew := mapItem(new, en.key)
+		ov, _ := mapItem(old, en.key)
+		if !inNew {
+			if _, known := configFields[en.key]; top && !known {
+				continue
+			}
+			if en.dash {
+				return errRewrite
+			}
+			e.replace(en.start, en.end, nil)
+			continue
+		}
+		if reflect.DeepEqual(ov, nv) {
+			continue
+		}
+
+		mark := len(e.edits)
+		if err := e.mergeValue(en, ov, nv); err != nil {
+			e.edits = e.edits[:mark]
+			lines, err := renderYaml(yaml.MapSlice{{Key: en.key, Value: nv}}, en.col)
+			if err != nil {
+				return err
+			}
+			if en.dash {
+				lines[0] = e.lines[en.line][:en.col] + lines[0][en.col:]
+			}
+			e.replace(en.line, en.end, lines)
+		}
+	}
+
+	var add yaml.MapSlice
+	for _, item := range new {
+		k := fmt.Sprint(item.Key)
+		if !seen[k] {
+			add = append(add, item)
+		}
+	}
+	if len(add) > 0 {
+		lines, err := renderYaml(add, col)
+		if err != nil {
+			return err
+		}
+		e.replace(insert, insert, lines)
+	}
+	return nil
+}
+
+// mergeValue edits the value of an entry in place.
+func (e *yamlEditor) mergeValue(en *yamlEntry, ov, nv interface{}) error {
+	l := e.lines[en.line]
+	value := l[en.value:]
+	inline := value != "" && !strings.HasPrefix(value, "#")
+
+	switch n := nv.(type) {
+	case yaml.MapSlice:
+		o, ok := ov.(yaml.MapSlice)
+		if !ok || inline {
+			return errRewrite
+		}
+		from := en.line + 1
+		col := -1
+		for i := from; i < en.end; i++ {
+			if ind, c := lineInfo(e.lines[i]); c {
+				col = ind
+				break
+			}
+		}
+		if col <= en.col {
+			return errRewrite
+		}
+		entries, err := e.mapEntries(from, en.end, col, false)
+		if err != nil {
+			return err
+		}
+		return e.mergeMap(entries, o, n, col, false, en.end)
+
+	case []interface{}:
+		o, ok := ov.([]interface{})
+		if !ok || inline {
+			return errRewrite
+		}
+		items, dashCol, err := e.seqItems(en.line+1, en.end)
+		if err != nil || len(items) != len(o) {
+			return errRewrite
+		}
+		return e.mergeSeq(en.key, items, dashCol, o, n, en.end)
+	}
+
+	// Scalars on a single line have their value replaced keeping a trailing
+	// comment.
+	if !inline || en.end != en.line+1 || strings.ContainsAny(value[:1], "|>&*!\"'[{") {
+		return errRewrite
+	}
+	if _, ok := ov.(yaml.MapSlice); ok {
+		return errRewrite
+	}
+	s, err := renderScalar(nv)
+	if err != nil {
+		return err
+	}
+	comment := ""
+	if i := strings.Index(value, " #"); i >= 0 {
+		for i > 0 && value[i-1] == ' ' {
+			i--
+		}
+		comment = value[i:]
+	}
+	e.replace(en.line, en.line+1, []string{l[:en.value] + s + comment})
+	return nil
+}
+
+// mergeSeq edits the items of a sequence holding old to hold new. Items are
+// matched by value, or for lists of mappings by the field identifying them.
+// Items keep their place, removed ones are deleted, and added ones go to the
+// end. When the order of the items changed the sequence cannot be edited.
+func (e *yamlEditor) mergeSeq(key string, items []*yamlItem, dashCol int, old, new []interface{}, insert int) error {
+	id := listIdentity[key]
+	identity := func(v interface{}) interface{} {
+		m, ok := v.(yaml.MapSlice)
+		if !ok || id == "" {
+			return v
+		}
+		i, _ := mapItem(m, id)
+		if id == "package" {
+			root, _ := util.NormalizeName(fmt.Sprint(i))
+			return root
+		}
+		return i
+	}
+
+	used := make([]bool, len(new))
+	last := -1
+	for i, it := range items {
+		j := -1
+		for k, nv := range new {
+			if !used[k] && reflect.DeepEqual(identity(old[i]), identity(nv)) {
+				j = k
+				break
+			}
+		}
+		if j < 0 {
+			e.replace(it.start, it.end, nil)
+			continue
+		}
+		if j < last {
+			return errRewrite
+		}
+		last = j
+		used[j] = true
+		if reflect.DeepEqual(old[i], new[j]) {
+			continue
+		}
+
+		om, ok1 := old[i].(yaml.MapSlice)
+		nm, ok2 := new[j].(yaml.MapSlice)
+		mark := len(e.edits)
+		err := errRewrite
+		if ok1 && ok2 && it.col > dashCol {
+			var entries []*yamlEntry
+			from := it.line
+			if !it.dash {
+				from++
+			}
+			entries, err = e.mapEntries(from, it.end, it.col, it.dash)
+			if err == nil {
+				err = e.mergeMap(entries, om, nm, it.col, false, it.end)
+			}
+		}
+		if err != nil {
+			e.edits = e.edits[:mark]
+			lines, err := renderYaml([]interface{}{new[j]}, dashCol)
+			if err != nil {
+				return err
+			}
+			e.replace(it.line, it.end, lines)
+		}
+	}
+
+	var add []interface{}
+	for k, nv := range new {
+		if used[k] {
+			continue
+		}
+		if k < last {
+			return errRewrite
+		}
+		add = append(add, nv)
+	}
+	if len(add) > 0 {
+		lines, err := renderYaml(add, dashCol)
+		if err != nil {
+			return err
+		}
+		e.replace(insert, insert, lines)
+	}
+	return nil
+}
+
+func mapItem(m yaml.MapSlice, key string) (interface{}, bool) {
+	for _, item := range m {
+		if fmt.Sprint(item.Key) == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
+
+// renderYaml marshals v indented to col.
+func renderYaml(v interface{}, col int) ([]string, error) {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	pad := strings.Repeat(" ", col)
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = pad + l
+		}
+	}
+	return lines, nil
+}
+
+func renderScalar(v interface{}) (string, error) {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	s := strings.TrimSuffix(string(b), "\n")
+	if strings.Contains(s, "\n") {
+		return "", errRewrite
+	}
+	return s, nil
+}
@@ -0,0 +1,111 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFilePreservesComments(t *testing.T) {
+	orig := `# Project config
+package: github.com/example/app   # the app
+
+# Things to skip
+ignore:
+  - appengine   # not used
+import:
+  # YAML parser
+  - package: gopkg.in/yaml.v2
+  - package: github.com/Masterminds/vcs
+    version: ^1.2.0  # keep in sync
+  # removed soon
+  - package: github.com/old/thing
+    version: v1.0.0
+testImport:
+- package: github.com/arschles/assert
+`
+	expected := `# Project config
+package: github.com/example/app   # the app
+
+# Things to skip
+ignore:
+  - appengine   # not used
+  - foo
+import:
+  # YAML parser
+  - package: gopkg.in/yaml.v2
+  - package: github.com/Masterminds/vcs
+    version: ^1.3.0  # keep in sync
+  - package: github.com/new/dep
+    version: ^2.0.0
+testImport:
+- package: github.com/arschles/assert
+  subpackages:
+  - assert
+`
+
+	dir, err := ioutil.TempDir("", "glide-rewrite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := filepath.Join(dir, "glide.yaml")
+	if err := ioutil.WriteFile(p, []byte(orig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := ConfigFromYaml([]byte(orig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Ignore = append(c.Ignore, "foo")
+	c.Imports = c.Imports.Remove("github.com/old/thing")
+	c.Imports.Get("github.com/Masterminds/vcs").Reference = "^1.3.0"
+	c.Imports = append(c.Imports, &Dependency{Name: "github.com/new/dep", Reference: "^2.0.0"})
+	c.DevImports[0].Subpackages = []string{"assert"}
+	if err := c.WriteFile(p); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != expected {
+		t.Errorf("Expected the file to be edited in place, got:\n%s", out)
+	}
+}
+
+func TestRewriteYaml(t *testing.T) {
+	// Reordered lists are written again as a whole.
+	orig := "package: fake # name\nimport:\n- package: a.example.com/a\n- package: b.example.com/b\n"
+	c, err := ConfigFromYaml([]byte(orig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Imports[0], c.Imports[1] = c.Imports[1], c.Imports[0]
+	o, err := c.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "package: fake # name\nimport:\n- package: b.example.com/b\n- package: a.example.com/a\n"
+	if out, err := rewriteYaml([]byte(orig), o); err != nil || string(out) != expected {
+		t.Errorf("Expected only the imports to be written again, got %q (%v)", out, err)
+	}
+
+	// Unchanged files are left exactly as they are.
+	orig = "# comment\npackage: fake\nimport: []\n"
+	c, err = ConfigFromYaml([]byte(orig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err = c.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := rewriteYaml([]byte(orig), o)
+	if err != nil || string(out) != orig {
+		t.Errorf("Expected the file to be unchanged, got %q (%v)", out, err)
+	}
+}
@@ -0,0 +1,40 @@
+package cfg
+
+// SyncGroup is a set of dependencies, such as the components of a project
+// released together, that must resolve to the same version. Versions are
+// compared by the semantic version their tags name, so v1.2.0 of one package
+// matches 1.2.0 of another.
+type SyncGroup struct {
+	// Name identifies the group in messages.
+	Name string `yaml:"name"`
+
+	// Packages are the root packages of the dependencies in the group.
+	Packages []string `yaml:"packages"`
+}
+
+// SyncGroups is a list of sync groups.
+type SyncGroups []*SyncGroup
+
+// Get returns the group with the name, or nil when there is none.
+func (s SyncGroups) Get(name string) *SyncGroup {
+	for _, g := range s {
+		if g.Name == name {
+			return g
+		}
+	}
+	return nil
+}
+
+// Clone returns a copy of the groups.
+func (s SyncGroups) Clone() SyncGroups {
+	if s == nil {
+		return nil
+	}
+	n := make(SyncGroups, 0, len(s))
+	for _, g := range s {
+		c := &SyncGroup{Name: g.Name}
+		c.Packages = append(c.Packages, g.Packages...)
+		n = append(n, c)
+	}
+	return n
+}
@@ -0,0 +1,561 @@
+package cfg
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ValidationError describes a problem found while validating a glide.yaml
+// file. Line and Column start at 1 and are 0 when the location is unknown.
+type ValidationError struct {
+	Line, Column int
+
+	// Path is the location of the problem within the file, such as
+	// import[2].version.
+	Path string
+
+	// Msg describes the problem.
+	Msg string
+
+	// Warning is true for problems, like deprecated fields, that do not stop
+	// the file from being used.
+	Warning bool
+}
+
+func (e *ValidationError) Error() string {
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+	case e.Line > 0:
+		return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+	}
+	return e.Msg
+}
+
+// fieldKind is the type of value a field holds.
+type fieldKind int
+
+const (
+	stringField fieldKind = iota
+	boolField
+	intField
+	stringsField
+	stringMapField
+	ownersField
+	mirrorsField
+	depsField
+	policyField
+	syncGroupsField
+)
+
+// configFields are the fields allowed at the top level of a glide.yaml file.
+var configFields = map[string]fieldKind{
+	"package":        stringField,
+	"extends":        stringField,
+	"description":    stringField,
+	"homepage":       stringField,
+	"license":        stringField,
+	"owners":         ownersField,
+	"ignore":         stringsField,
+	"excludeDirs":    stringsField,
+	"mirrors":        mirrorsField,
+	"import":         depsField,
+	"testImport":     depsField,
+	"override":       depsField,
+	"nested":         stringField,
+	"optionalGroups": stringsField,
+	"syncGroups":     syncGroupsField,
+	"stdAliases":     stringMapField,
+	"tools":          depsField,
+	"vendorDir":      stringField,
+	"testVendorDir":  stringField,
+	"policy":         policyField,
+	"go":             stringField,
+}
+
+// depFields are the fields allowed for each dependency.
+var depFields = map[string]fieldKind{
+	"package":         stringField,
+	"version":         stringField,
+	"ref":             stringField,
+	"repo":            stringField,
+	"vcs":             stringField,
+	"subpackages":     stringsField,
+	"arch":            stringsField,
+	"os":              stringsField,
+	"submodules":      boolField,
+	"shallow":         boolField,
+	"subpackagesOnly": boolField,
+	"checksum":        stringField,
+	"local":           stringField,
+	"relocate":        stringsField,
+	"track":           boolField,
+	"maxAge":          intField,
+	"excludeVersions": stringsField,
+	"prerelease":      boolField,
+	"tagPrefix":       stringField,
+	"asof":            stringField,
+	"annotations":     stringMapField,
+	"groups":          stringsField,
+	"fallback":        stringsField,
+	"patches":         stringsField,
+}
+
+// ownerFields are the fields allowed for each owner.
+var ownerFields = map[string]fieldKind{
+	"name":     stringField,
+	"email":    stringField,
+	"homepage": stringField,
+}
+
+// mirrorFields are the fields allowed for each mirror.
+var mirrorFields = map[string]fieldKind{
+	"original": stringField,
+	"repo":     stringField,
+	"vcs":      stringField,
+}
+
+// policyFields are the fields allowed in the policy.
+var policyFields = map[string]fieldKind{
+	"allow":        stringsField,
+	"deny":         stringsField,
+	"licenses":     stringsField,
+	"denyLicenses": stringsField,
+	"maxAge":       intField,
+	"hosts":        stringsField,
+}
+
+// syncGroupFields are the fields allowed for each sync group.
+var syncGroupFields = map[string]fieldKind{
+	"name":     stringField,
+	"packages": stringsField,
+}
+
+// deprecatedFields maps deprecated dependency fields to their replacement.
+var deprecatedFields = map[string]string{
+	"ref": "version",
+}
+
+// yamlLineRe finds the line number in errors from the yaml parser.
+var yamlLineRe = regexp.MustCompile(`line (\d+)`)
+
+// Validate strictly checks the content of a glide.yaml file. Unknown fields
+// and values of the wrong type are errors while deprecated fields produce
+// warnings. Problems are returned in the order they appear in the file.
+//
+// Top level fields starting with x- are not checked. They can hold YAML
+// anchors to reuse elsewhere in the file.
+func Validate(yml []byte) []*ValidationError {
+	var root yaml.MapSlice
+	if err := yaml.Unmarshal(yml, &root); err != nil {
+		e := &ValidationError{Msg: strings.TrimPrefix(err.Error(), "yaml: ")}
+		if m := yamlLineRe.FindStringSubmatch(err.Error()); m != nil {
+			e.Line, _ = strconv.Atoi(m[1])
+		}
+		return []*ValidationError{e}
+	}
+
+	v := &validator{pos: yamlPositions(yml)}
+	v.mapping("", root, configFields, true)
+	if !hasKey(root, "package") {
+		v.add("", "package is required", false)
+	}
+	if n, ok := mapValue(root, "nested").(string); ok && n != NestedFlatten && n != NestedKeep && n != NestedPreferPin {
+		v.add("nested", fmt.Sprintf("unknown policy %q, use %s, %s, or %s", n, NestedFlatten, NestedKeep, NestedPreferPin), false)
+	}
+	for _, key := range []string{"vendorDir", "testVendorDir"} {
+		if d, ok := mapValue(root, key).(string); ok {
+			if p := path.Clean(d); d == "" || path.IsAbs(d) || filepath.IsAbs(d) || p == "." || p == ".." || strings.HasPrefix(p, "../") {
+				v.add(key, fmt.Sprintf("%q must be a directory within the project", d), false)
+			}
+		}
+	}
+	if d, ok := mapValue(root, "testVendorDir").(string); ok {
+		vd, _ := mapValue(root, "vendorDir").(string)
+		if vd == "" {
+			vd = "vendor"
+		}
+		if path.Clean(d) == path.Clean(vd) {
+			v.add("testVendorDir", fmt.Sprintf("%q must differ from the vendor directory", d), false)
+		}
+	}
+	if g, ok := mapValue(root, "go").(string); ok {
+		if _, err := parseGoConstraint(g); err != nil {
+			v.add("go", err.Error(), false)
+		}
+	}
+	if m, ok := mapValue(root, "stdAliases").(yaml.MapSlice); ok {
+		for _, item := range m {
+			from := fmt.Sprint(item.Key)
+			if isStdPath(from) {
+				v.add(joinPath("stdAliases", from), fmt.Sprintf("%q is already a standard library package", from), false)
+			}
+			if to, ok := item.Value.(string); ok && !isStdPath(to) {
+				v.add(joinPath("stdAliases", from), fmt.Sprintf("%q is not a standard library package", to), false)
+			}
+		}
+	}
+	if p, ok := mapValue(root, "policy").(yaml.MapSlice); ok {
+		for _, key := range []string{"allow", "deny"} {
+			list, _ := mapValue(p, key).([]interface{})
+			for i, item := range list {
+				if s, ok := item.(string); ok {
+					if _, err := path.Match(s, ""); err != nil {
+						v.add(fmt.Sprintf("policy.%s[%d]", key, i), fmt.Sprintf("invalid pattern %q", s), false)
+					}
+				}
+			}
+		}
+	}
+
+	sort.Stable(byLocation(v.errs))
+	return v.errs
+}
+
+type validator struct {
+	pos  map[string][2]int
+	errs []*ValidationError
+}
+
+func (v *validator) add(path, m string, warning bool) {
+	p := v.pos[path]
+	if path != "" {
+		m = path + ": " + m
+	}
+	v.errs = append(v.errs, &ValidationError{Line: p[0], Column: p[1], Path: path, Msg: m, Warning: warning})
+}
+
+// mapping checks the keys of a mapping against the allowed fields.
+func (v *validator) mapping(path string, m yaml.MapSlice, fields map[string]fieldKind, top bool) {
+	for _, item := range m {
+		key := fmt.Sprint(item.Key)
+		p := joinPath(path, key)
+		kind, ok := fields[key]
+		if !ok && top && strings.HasPrefix(key, "x-") {
+			continue
+		} else if !ok {
+			msg := fmt.Sprintf("unknown field %q", key)
+			if s := suggest(key, fields); s != "" {
+				msg += fmt.Sprintf(", did you mean %q?", s)
+			}
+			v.add(p, msg, false)
+			continue
+		}
+		if r, ok := deprecatedFields[key]; ok && !top {
+			v.add(p, fmt.Sprintf("%s is deprecated, use %s instead", key, r), true)
+		}
+		v.value(p, item.Value, kind)
+	}
+}
+
+// value checks a value is of the kind the field expects.
+func (v *validator) value(path string, val interface{}, kind fieldKind) {
+	if val == nil {
+		return
+	}
+	switch kind {
+	case stringField:
+		if !isScalar(val) {
+			v.add(path, fmt.Sprintf("expected a string but found %s", describe(val)), false)
+		}
+	case boolField:
+		if _, ok := val.(bool); !ok {
+			v.add(path, fmt.Sprintf("expected true or false but found %s", describe(val)), false)
+		}
+	case intField:
+		if _, ok := val.(int); !ok {
+			v.add(path, fmt.Sprintf("expected a number but found %s", describe(val)), false)
+		}
+	case policyField:
+		m, ok := val.(yaml.MapSlice)
+		if !ok {
+			v.add(path, fmt.Sprintf("expected a mapping but found %s", describe(val)), false)
+			return
+		}
+		v.mapping(path, m, policyFields, false)
+	case stringMapField:
+		m, ok := val.(yaml.MapSlice)
+		if !ok {
+			v.add(path, fmt.Sprintf("expected a mapping but found %s", describe(val)), false)
+			return
+		}
+		for _, item := range m {
+			v.value(joinPath(path, fmt.Sprint(item.Key)), item.Value, stringField)
+		}
+	case stringsField, ownersField, mirrorsField, depsField, syncGroupsField:
+		list, ok := val.([]interface{})
+		if !ok {
+			v.add(path, fmt.Sprintf("expected a list but found %s", describe(val)), false)
+			return
+		}
+		for i, item := range list {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			switch kind {
+			case stringsField:
+				v.value(p, item, stringField)
+			case ownersField:
+				if m, ok := item.(yaml.MapSlice); ok {
+					v.mapping(p, m, ownerFields, false)
+				} else {
+					v.add(p, fmt.Sprintf("expected an owner but found %s", describe(item)), false)
+				}
+			case mirrorsField:
+				if m, ok := item.(yaml.MapSlice); ok {
+					v.mapping(p, m, mirrorFields, false)
+				} else {
+					v.add(p, fmt.Sprintf("expected a mirror but found %s", describe(item)), false)
+				}
+			case syncGroupsField:
+				m, ok := item.(yaml.MapSlice)
+				if !ok {
+					v.add(p, fmt.Sprintf("expected a sync group but found %s", describe(item)), false)
+					continue
+				}
+				v.mapping(p, m, syncGroupFields, false)
+				if !hasKey(m, "name") {
+					v.add(p, "name is required", false)
+				}
+				if pkgs, _ := mapValue(m, "packages").([]interface{}); len(pkgs) < 2 {
+					v.add(p, "a sync group needs at least two packages", false)
+				}
+			case depsField:
+				m, ok := item.(yaml.MapSlice)
+				if !ok {
+					v.add(p, fmt.Sprintf("expected a package but found %s", describe(item)), false)
+					continue
+				}
+				v.mapping(p, m, depFields, false)
+				if !hasKey(m, "package") {
+					v.add(p, "package is required", false)
+				}
+				if name, ok := mapValue(m, "package").(string); ok && (&Dependency{Name: name}).IsPattern() {
+					if !strings.HasPrefix(path, "override") {
+						v.add(joinPath(p, "package"), fmt.Sprintf("%q is a pattern, which only overrides can be", name), false)
+					} else if !validPattern(name) {
+						v.add(joinPath(p, "package"), fmt.Sprintf("invalid pattern %q", name), false)
+					} else if !hasKey(m, "version") || len(m) > 2 {
+						v.add(p, fmt.Sprintf("an override for the pattern %q can only set the version", name), false)
+					}
+				}
+				// A version with || is a range rather than a branch or tag.
+				if ver, ok := mapValue(m, "version").(string); ok && strings.Contains(ver, "||") {
+					if _, err := NewConstraint(ver); err != nil {
+						v.add(joinPath(p, "version"), fmt.Sprintf("invalid version range %q: %s", ver, err), false)
+					}
+				}
+				if a, ok := mapValue(m, "asof").(string); ok {
+					if _, err := parseAsOf(a); err != nil {
+						v.add(joinPath(p, "asof"), err.Error(), false)
+					}
+				}
+				if vcs, ok := mapValue(m, "vcs").(string); ok && filterVcsType(vcs) == "" {
+					v.add(joinPath(p, "vcs"), fmt.Sprintf("unknown VCS %q, use git, hg, bzr, svn, fossil, darcs, or archive", vcs), false)
+				}
+			}
+		}
+	}
+}
+
+// validPattern returns true when name is a well formed pattern.
+func validPattern(name string) bool {
+	_, err := path.Match(name, "")
+	return err == nil
+}
+
+// isStdPath returns true when an import path looks like one of the standard
+// library, without a dot in its first element.
+func isStdPath(p string) bool {
+	return p != "" && !strings.Contains(strings.SplitN(p, "/", 2)[0], ".")
+}
+
+// yamlPositions finds the line and column of the keys and list items in a
+// block style YAML document. The keys are paths such as import[2].version.
+// Flow style content is not indexed.
+func yamlPositions(yml []byte) map[string][2]int {
+	type frame struct {
+		col  int
+		path string
+		item bool
+	}
+	pos := make(map[string][2]int)
+	counts := make(map[string]int)
+	var stack []frame
+	blockIndent := -1
+
+	lines := strings.Split(string(yml), "\n")
+	for n, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimLeft(line, " ")
+		indent := len(line) - len(trimmed)
+		if blockIndent >= 0 {
+			if trimmed == "" || indent > blockIndent {
+				continue
+			}
+			blockIndent = -1
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+
+		col := indent
+		if trimmed == "-" || strings.HasPrefix(trimmed, "- ") {
+			for len(stack) > 0 {
+				t := stack[len(stack)-1]
+				if t.col > col || (t.item && t.col >= col) {
+					stack = stack[:len(stack)-1]
+					continue
+				}
+				break
+			}
+			parent := ""
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1].path
+			}
+			p := fmt.Sprintf("%s[%d]", parent, counts[parent])
+			counts[parent]++
+			rest := strings.TrimLeft(strings.TrimPrefix(trimmed, "-"), " ")
+			pos[p] = [2]int{n + 1, col + 1}
+			stack = append(stack, frame{col: col, path: p, item: true})
+			col += len(trimmed) - len(rest)
+			trimmed = rest
+		} else {
+			for len(stack) > 0 && stack[len(stack)-1].col >= col {
+				stack = stack[:len(stack)-1]
+			}
+		}
+
+		key, val, ok := splitKey(trimmed)
+		if !ok {
+			continue
+		}
+		parent := ""
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1].path
+		}
+		p := joinPath(parent, key)
+		pos[p] = [2]int{n + 1, col + 1}
+		stack = append(stack, frame{col: col, path: p})
+		if strings.HasPrefix(val, "|") || strings.HasPrefix(val, ">") {
+			blockIndent = col
+		}
+	}
+	return pos
+}
+
+// splitKey splits a line of a mapping into its key and value.
+func splitKey(s string) (string, string, bool) {
+	if strings.HasPrefix(s, "#") || strings.HasPrefix(s, "\"") || strings.HasPrefix(s, "'") {
+		return "", "", false
+	}
+	i := strings.Index(s, ":")
+	if i <= 0 || (i+1 < len(s) && s[i+1] != ' ' && s[i+1] != '\t') {
+		return "", "", false
+	}
+	return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:]), true
+}
+
+func joinPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+func hasKey(m yaml.MapSlice, key string) bool {
+	return mapValue(m, key) != nil
+}
+
+func mapValue(m yaml.MapSlice, key string) interface{} {
+	for _, item := range m {
+		if fmt.Sprint(item.Key) == key {
+			return item.Value
+		}
+	}
+	return nil
+}
+
+func isScalar(v interface{}) bool {
+	switch v.(type) {
+	case yaml.MapSlice, []interface{}:
+		return false
+	}
+	return true
+}
+
+func describe(v interface{}) string {
+	switch t := v.(type) {
+	case yaml.MapSlice:
+		return "a mapping"
+	case []interface{}:
+		return "a list"
+	case bool:
+		return fmt.Sprintf("the boolean %t", t)
+	}
+	return fmt.Sprintf("%q", fmt.Sprint(v))
+}
+
+// suggest returns the allowed field closest to a misspelled one.
+func suggest(key string, fields map[string]fieldKind) string {
+	best, dist := "", 3
+	for f := range fields {
+		if d := editDistance(strings.ToLower(key), strings.ToLower(f)); d < dist || (d == dist && f < best) {
+			best, dist = f, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Damerau-Levenshtein distance between a and b,
+// counting swapped letters as a single edit.
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = minInt(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+func minInt(n ...int) int {
+	m := n[0]
+	for _, v := range n[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+// byLocation sorts problems by where they are in the file.
+type byLocation []*ValidationError
+
+func (b byLocation) Len() int      { return len(b) }
+func (b byLocation) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byLocation) Less(i, j int) bool {
+	if b[i].Line != b[j].Line {
+		return b[i].Line < b[j].Line
+	}
+	return b[i].Column < b[j].Column
+}
@@ -0,0 +1,123 @@
+package cfg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	yml := `package: fake/testing
+description: foo bar baz
+owners:
+- name: Some One
+  email: one@example.com
+import:
+  - package: github.com/kylelemons/go-gypsy
+    subpackages:
+      - yaml
+  - package: github.com/Masterminds/convert
+    verison: ^1.0.0
+    shallow: yes please
+  - package: github.com/Masterminds/semver
+    ref: v1.0.0
+    vcs: mercurial
+testImport:
+  - package: github.com/Sirupsen/logrus
+    vcs: cvs
+    asof: last week
+  - package: github.com/Masterminds/*
+override:
+  - package: k8s.io/*
+    repo: https://example.com/k8s
+excludeDirs: foo
+nested: deep
+vendorDir: ../vendor
+policy:
+  deny:
+  - "github.com/[evil"
+  maxAge: soon
+go: ">=1.9 <"
+syncGroups:
+- name: opentracing
+  packages:
+  - github.com/opentracing/opentracing-go
+stdAliases:
+  golang.org/x/net/context: context
+  golang.org/x/sync/errgroup: golang.org/x/sync/v2/errgroup
+`
+	errs := Validate([]byte(yml))
+	expected := []struct {
+		line, col int
+		path      string
+		contains  string
+		warning   bool
+	}{
+		{11, 5, "import[1].verison", `did you mean "version"?`, false},
+		{12, 5, "import[1].shallow", "expected true or false", false},
+		{14, 5, "import[2].ref", "deprecated", true},
+		{18, 5, "testImport[0].vcs", `unknown VCS "cvs"`, false},
+		{19, 5, "testImport[0].asof", `invalid date "last week"`, false},
+		{20, 5, "testImport[1].package", "only overrides can be", false},
+		{22, 3, "override[0]", "can only set the version", false},
+		{24, 1, "excludeDirs", "expected a list", false},
+		{25, 1, "nested", `unknown policy "deep"`, false},
+		{26, 1, "vendorDir", "must be a directory within the project", false},
+		{29, 3, "policy.deny[0]", "invalid pattern", false},
+		{30, 3, "policy.maxAge", "expected a number", false},
+		{31, 1, "go", "invalid Go version range", false},
+		{33, 1, "syncGroups[0]", "at least two packages", false},
+		{38, 3, "stdAliases.golang.org/x/sync/errgroup", "is not a standard library package", false},
+	}
+	if len(errs) != len(expected) {
+		for _, e := range errs {
+			t.Log(e)
+		}
+		t.Fatalf("Expected %d problems, got %d", len(expected), len(errs))
+	}
+	for i, e := range expected {
+		got := errs[i]
+		if got.Line != e.line || got.Column != e.col || got.Path != e.path || got.Warning != e.warning {
+			t.Errorf("Expected %s at %d:%d (warning %t), got %s at %d:%d (warning %t)", e.path, e.line, e.col, e.warning, got.Path, got.Line, got.Column, got.Warning)
+		}
+		if !strings.Contains(got.Error(), e.contains) {
+			t.Errorf("Expected %q to contain %q", got.Error(), e.contains)
+		}
+	}
+}
+
+func TestValidateValid(t *testing.T) {
+	for _, e := range Validate([]byte(yml)) {
+		if !e.Warning {
+			t.Errorf("Expected the test config to be valid, got %s", e)
+		}
+	}
+
+	errs := Validate([]byte("package: foo\nimport:\n- package: [bar\n"))
+	if len(errs) != 1 || errs[0].Line == 0 {
+		t.Errorf("Expected a single syntax error with a line, got %v", errs)
+	}
+}
+
+func TestValidateTestVendorDir(t *testing.T) {
+	tests := []struct {
+		yml      string
+		contains string
+	}{
+		{"package: foo\ntestVendorDir: vendor-test\n", ""},
+		{"package: foo\ntestVendorDir: /tmp/vendor\n", "must be a directory within the project"},
+		{"package: foo\ntestVendorDir: vendor/\n", "must differ from the vendor directory"},
+		{"package: foo\nvendorDir: third_party\ntestVendorDir: third_party\n", "must differ from the vendor directory"},
+	}
+	for _, tt := range tests {
+		errs := Validate([]byte(tt.yml))
+		if tt.contains == "" {
+			if len(errs) != 0 {
+				t.Errorf("Expected %q to be valid, got %v", tt.yml, errs)
+			}
+			continue
+		}
+		if len(errs) != 1 || errs[0].Path != "testVendorDir" || !strings.Contains(errs[0].Error(), tt.contains) {
+			t.Errorf("Expected %q for %q, got %v", tt.contains, tt.yml, errs)
+		}
+	}
+}
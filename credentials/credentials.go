@@ -0,0 +1,243 @@
+// Package credentials finds the login and password to use for a host, so the
+// requests Glide makes itself and the VCS commands it runs are authenticated
+// alike for private hosts. Sources are consulted in this order, the first one
+// knowing the host winning:
+//
+//  1. The .netrc file, at $NETRC or in the home directory (_netrc on Windows).
+//     Only machine entries are used, not the default one.
+//  2. The Git credential helpers, through git credential fill. Prompts are
+//     turned off so a helper without credentials does not block.
+//  3. The credentials section of the config.yaml file in GLIDE_HOME:
+//
+//     credentials:
+//       git.example.com:
+//         username: ci
+//         password: <token>
+//
+// Git consults the first two itself when cloning and asks Glide for the last
+// one through GIT_ASKPASS, so clones follow the same order.
+package credentials
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/mitchellh/go-homedir"
+)
+
+// Credential is a login and password, or token, for a host.
+type Credential struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+var (
+	mu    sync.Mutex
+	found = make(map[string]*Credential)
+
+	netrcOnce sync.Once
+	netrc     map[string]*Credential
+
+	configOnce sync.Once
+	config     map[string]*Credential
+)
+
+// fill asks the Git credential helpers for the credentials of a host. It is a
+// variable so tests can stub it.
+var fill = gitCredentialFill
+
+// Get returns the credentials for host, which may include a port, or nil when
+// none are known. Results are kept for the rest of the run.
+func Get(host string) *Credential {
+	mu.Lock()
+	defer mu.Unlock()
+	if c, ok := found[host]; ok {
+		return c
+	}
+	c := lookupNetrc(host)
+	if c == nil {
+		c = fill(host)
+	}
+	if c == nil {
+		c = FromConfig(host)
+	}
+	found[host] = c
+	return c
+}
+
+// FromConfig returns the credentials for host from config.yaml, or nil when
+// there are none.
+func FromConfig(host string) *Credential {
+	return byHost(configured(), host)
+}
+
+// Configured returns true when config.yaml has credentials for some host.
+func Configured() bool {
+	return len(configured()) > 0
+}
+
+func configured() map[string]*Credential {
+	configOnce.Do(func() {
+		c, err := readConfig()
+		if err != nil {
+			msg.Warn("Unable to read the credentials: %s", err)
+		}
+		config = c
+	})
+	return config
+}
+
+// Authorize sets the credentials for the host of an https request on it,
+// unless it already carries some.
+func Authorize(req *http.Request) {
+	if req.URL.Scheme != "https" || req.URL.User != nil || req.Header.Get("Authorization") != "" {
+		return
+	}
+	if c := Get(req.URL.Host); c != nil {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+}
+
+// byHost returns the credentials in creds for host, trying it without its
+// port too.
+func byHost(creds map[string]*Credential, host string) *Credential {
+	if c, ok := creds[host]; ok {
+		return c
+	}
+	if i := strings.LastIndex(host, ":"); i > 0 && !strings.HasSuffix(host, "]") {
+		return creds[host[:i]]
+	}
+	return nil
+}
+
+func lookupNetrc(host string) *Credential {
+	netrcOnce.Do(func() {
+		p := os.Getenv("NETRC")
+		if p == "" {
+			h, err := homedir.Dir()
+			if err != nil {
+				return
+			}
+			name := ".netrc"
+			if runtime.GOOS == "windows" {
+				name = "_netrc"
+			}
+			p = filepath.Join(h, name)
+		}
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				msg.Warn("Unable to read %s: %s", p, err)
+			}
+			return
+		}
+		netrc = parseNetrc(string(data))
+	})
+	return byHost(netrc, host)
+}
+
+// parseNetrc reads the machine entries of a .netrc file. The first entry for a
+// machine wins. Macro definitions are skipped.
+func parseNetrc(data string) map[string]*Credential {
+	creds := make(map[string]*Credential)
+	var c *Credential
+	var machine string
+	add := func() {
+		if c != nil && machine != "" && creds[machine] == nil {
+			creds[machine] = c
+		}
+		c, machine = nil, ""
+	}
+
+	inMacro := false
+	s := bufio.NewScanner(strings.NewReader(data))
+	for s.Scan() {
+		line := s.Text()
+		if inMacro {
+			inMacro = strings.TrimSpace(line) != ""
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		f := strings.Fields(line)
+		for i := 0; i < len(f); i++ {
+			var val string
+			if i+1 < len(f) {
+				val = f[i+1]
+			}
+			switch f[i] {
+			case "machine":
+				add()
+				c, machine = &Credential{}, val
+				i++
+			case "default":
+				// The default entry would send credentials to every host.
+				add()
+				c = &Credential{}
+			case "login":
+				if c != nil {
+					c.Username = val
+				}
+				i++
+			case "password":
+				if c != nil {
+					c.Password = val
+				}
+				i++
+			case "account":
+				i++
+			case "macdef":
+				inMacro = true
+				i = len(f)
+			}
+		}
+	}
+	add()
+	return creds
+}
+
+// gitCredentialFill asks the Git credential helpers for the credentials of an
+// https host. Git is kept from prompting for them.
+func gitCredentialFill(host string) *Credential {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil
+	}
+	cmd := exec.Command("git", "credential", "fill")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("protocol=https\nhost=%s\n\n", host))
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "GCM_INTERACTIVE=never")
+	out, err := cmd.Output()
+	if err != nil {
+		msg.Debug("No credentials for %s from the Git credential helpers", host)
+		return nil
+	}
+	c := &Credential{}
+	for _, l := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(l, "username=") {
+			c.Username = strings.TrimPrefix(l, "username=")
+		} else if strings.HasPrefix(l, "password=") {
+			c.Password = strings.TrimPrefix(l, "password=")
+		}
+	}
+	if c.Password == "" {
+		return nil
+	}
+	return c
+}
+
+// readConfig reads the credentials section of the config.yaml file.
+func readConfig() (map[string]*Credential, error) {
+	var creds map[string]*Credential
+	err := gpath.GlobalConfig("credentials", &creds)
+	return creds, err
+}
@@ -0,0 +1,82 @@
+package credentials
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	gpath "github.com/Masterminds/glide/path"
+)
+
+func TestParseNetrc(t *testing.T) {
+	creds := parseNetrc(`# A comment
+machine git.example.com login alice password secret
+machine api.example.com
+  login bob
+  account ops
+  password token
+macdef init
+  machine evil.example.com login mallory password stolen
+
+machine git.example.com login carol password other
+default login anon password guest
+`)
+	if len(creds) != 2 {
+		t.Fatalf("Expected two machines, got %v", creds)
+	}
+	if c := creds["git.example.com"]; c == nil || c.Username != "alice" || c.Password != "secret" {
+		t.Errorf("Expected the first entry for git.example.com, got %+v", c)
+	}
+	if c := creds["api.example.com"]; c == nil || c.Username != "bob" || c.Password != "token" {
+		t.Errorf("Expected the entry for api.example.com spread over lines, got %+v", c)
+	}
+}
+
+func TestGet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	netrcFile := filepath.Join(dir, "netrc")
+	if err := ioutil.WriteFile(netrcFile, []byte("machine a.example.com login netrc password n\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	yml := "credentials:\n  a.example.com:\n    username: config\n    password: c\n  b.example.com:\n    username: config\n    password: c\n  c.example.com:\n    username: config\n    password: c\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "config.yaml"), []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Setenv("NETRC", os.Getenv("NETRC"))
+	os.Setenv("NETRC", netrcFile)
+	defer gpath.SetHome(gpath.Home())
+	gpath.SetHome(dir)
+	defer func() { fill = gitCredentialFill }()
+	fill = func(host string) *Credential {
+		if host == "b.example.com" {
+			return &Credential{Username: "helper", Password: "h"}
+		}
+		return nil
+	}
+	found = make(map[string]*Credential)
+	netrcOnce, configOnce = sync.Once{}, sync.Once{}
+
+	for host, user := range map[string]string{
+		"a.example.com":      "netrc",
+		"b.example.com":      "helper",
+		"c.example.com:8443": "config",
+	} {
+		if c := Get(host); c == nil || c.Username != user {
+			t.Errorf("Expected the credentials for %s from %s, got %+v", host, user, c)
+		}
+	}
+	if c := Get("d.example.com"); c != nil {
+		t.Errorf("Expected no credentials for d.example.com, got %+v", c)
+	}
+	if !Configured() {
+		t.Error("Expected credentials to be configured")
+	}
+}
@@ -0,0 +1,349 @@
+// Package darcs provides dependencies kept in Darcs repositories.
+//
+// Darcs has no branches and identifies patches rather than revisions, so the
+// version of a checkout is the hash of its newest patch. Checking out an
+// older version clones the checkout up to that patch. Repo implements the
+// vcs.Repo interface so Darcs dependencies are handled like any other.
+package darcs
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/vcs"
+)
+
+// Type is the vcs.Type reported for Darcs repositories.
+const Type vcs.Type = "darcs"
+
+// metaDir is the directory Darcs keeps its metadata in.
+const metaDir = "_darcs"
+
+// IsCheckout returns true if dir is a Darcs checkout.
+func IsCheckout(dir string) bool {
+	fi, err := os.Stat(filepath.Join(dir, metaDir))
+	return err == nil && fi.IsDir()
+}
+
+// Repo is a Darcs checkout in a local directory.
+type Repo struct {
+	remote, local string
+}
+
+// NewRepo creates a Repo for the Darcs repository at remote checked out in
+// local. It fails with vcs.ErrWrongRemote when local is a checkout of another
+// repository.
+func NewRepo(remote, local string) (*Repo, error) {
+	r := &Repo{remote: remote, local: local}
+	if r.CheckLocal() {
+		b, err := ioutil.ReadFile(r.defaultRepoFile())
+		if err != nil && !os.IsNotExist(err) {
+			return nil, vcs.NewLocalError("Unable to retrieve local repo information", err, "")
+		}
+		m := strings.TrimSpace(string(b))
+		if remote == "" {
+			r.remote = m
+		} else if m != "" && m != remote {
+			return nil, vcs.ErrWrongRemote
+		}
+	}
+	return r, nil
+}
+
+func (r *Repo) defaultRepoFile() string {
+	return filepath.Join(r.local, metaDir, "prefs", "defaultrepo")
+}
+
+// Vcs returns the Darcs type.
+func (r *Repo) Vcs() vcs.Type {
+	return Type
+}
+
+// Remote returns the location of the repository.
+func (r *Repo) Remote() string {
+	return r.remote
+}
+
+// LocalPath returns the directory of the checkout.
+func (r *Repo) LocalPath() string {
+	return r.local
+}
+
+// Get clones the repository.
+func (r *Repo) Get() error {
+	if err := os.MkdirAll(filepath.Dir(r.local), 0755); err != nil {
+		return err
+	}
+	out, err := msg.CombinedOutput("darcs", exec.Command("darcs", "clone", "--lazy", r.remote, r.local))
+	if err != nil {
+		return vcs.NewRemoteError("Unable to get repository", err, string(out))
+	}
+	return nil
+}
+
+// Init creates a new repository.
+func (r *Repo) Init() error {
+	if err := os.MkdirAll(r.local, 0755); err != nil {
+		return err
+	}
+	out, err := r.RunFromDir("darcs", "init")
+	if err != nil {
+		return vcs.NewLocalError("Unable to initialize repository", err, string(out))
+	}
+	return nil
+}
+
+// Update pulls all the patches from the remote.
+func (r *Repo) Update() error {
+	out, err := r.RunFromDir("darcs", "pull", "--all", r.remote)
+	if err != nil {
+		return vcs.NewRemoteError("Unable to update repository", err, string(out))
+	}
+	return nil
+}
+
+// UpdateVersion sets the checkout to a patch hash or a tag. Darcs cannot
+// unapply patches by version, so the checkout is cloned up to the version and
+// the clone replaces it. The remote is pulled from first when the version is
+// not known locally.
+func (r *Repo) UpdateVersion(v string) error {
+	if !r.IsReference(v) {
+		if err := r.Update(); err != nil {
+			return err
+		}
+	}
+
+	tmp, err := ioutil.TempDir(filepath.Dir(r.local), ".darcs-version")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	dest := filepath.Join(tmp, "repo")
+	out, err := r.RunFromDir("darcs", append([]string{"clone"}, append(r.versionArgs(v, "--to-hash", "--tag"), r.local, dest)...)...)
+	if err != nil {
+		return vcs.NewLocalError("Unable to update checked out version", err, string(out))
+	}
+	if r.remote != "" {
+		if err := ioutil.WriteFile(filepath.Join(dest, metaDir, "prefs", "defaultrepo"), []byte(r.remote+"\n"), 0644); err != nil {
+			return err
+		}
+	}
+
+	old := filepath.Join(tmp, "old")
+	if err := os.Rename(r.local, old); err != nil {
+		return err
+	}
+	if err := os.Rename(dest, r.local); err != nil {
+		os.Rename(old, r.local)
+		return err
+	}
+	return nil
+}
+
+// hashRe matches the hash of a Darcs patch.
+var hashRe = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// versionArgs returns the arguments selecting v as a hash or, failing that,
+// as a tag using the given flags.
+func (r *Repo) versionArgs(v, hashFlag, tagFlag string) []string {
+	if hashRe.MatchString(v) {
+		return []string{hashFlag, v}
+	}
+	return []string{tagFlag, "^" + regexp.QuoteMeta(v) + "$"}
+}
+
+// Version returns the hash of the newest patch.
+func (r *Repo) Version() (string, error) {
+	ci, err := r.log("--last=1")
+	if err != nil {
+		return "", err
+	}
+	return ci.Commit, nil
+}
+
+// Current returns the hash of the newest patch. Darcs has no branches.
+func (r *Repo) Current() (string, error) {
+	return r.Version()
+}
+
+// Date returns when the newest patch was recorded.
+func (r *Repo) Date() (time.Time, error) {
+	ci, err := r.log("--last=1")
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ci.Date, nil
+}
+
+// CheckLocal returns true if the local directory is a checkout.
+func (r *Repo) CheckLocal() bool {
+	return IsCheckout(r.local)
+}
+
+// Branches returns no branches as Darcs has none.
+func (r *Repo) Branches() ([]string, error) {
+	return []string{}, nil
+}
+
+// Tags returns the names of the tags.
+func (r *Repo) Tags() ([]string, error) {
+	out, err := r.RunFromDir("darcs", "show", "tags")
+	if err != nil {
+		return []string{}, vcs.NewLocalError("Unable to retrieve tags", err, string(out))
+	}
+	var tags []string
+	for _, t := range strings.Split(string(out), "\n") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags, nil
+}
+
+// IsReference returns true if the patch hash or tag exists.
+func (r *Repo) IsReference(ref string) bool {
+	_, err := r.log(r.versionArgs(ref, "--hash", "--tag")...)
+	return err == nil
+}
+
+// IsDirty returns true if the checkout has unrecorded changes.
+func (r *Repo) IsDirty() bool {
+	// whatsnew exits with 1 when there are no changes.
+	_, err := r.RunFromDir("darcs", "whatsnew", "--summary")
+	return err == nil
+}
+
+// CommitInfo returns the details of a patch.
+func (r *Repo) CommitInfo(id string) (*vcs.CommitInfo, error) {
+	if id == "" {
+		return nil, vcs.ErrRevisionUnavailable
+	}
+	return r.log("--hash", id)
+}
+
+// TagsFromCommit returns the tag recorded by a patch. Darcs tags are patches
+// themselves, so a patch has at most one.
+func (r *Repo) TagsFromCommit(id string) ([]string, error) {
+	ci, err := r.CommitInfo(id)
+	if err != nil {
+		return []string{}, err
+	}
+	if strings.HasPrefix(ci.Message, "TAG ") {
+		return []string{strings.TrimPrefix(ci.Message, "TAG ")}, nil
+	}
+	return []string{}, nil
+}
+
+// Ping returns true if the remote can be reached.
+func (r *Repo) Ping() bool {
+	if strings.HasPrefix(r.remote, "http://") || strings.HasPrefix(r.remote, "https://") {
+		resp, err := http.Head(strings.TrimSuffix(r.remote, "/") + "/" + metaDir + "/format")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode < 400
+	}
+	return IsCheckout(r.remote)
+}
+
+// RunFromDir runs a command from the directory of the checkout, logging its
+// output at the debug level.
+func (r *Repo) RunFromDir(cmd string, args ...string) ([]byte, error) {
+	c := exec.Command(cmd, args...)
+	c.Dir = r.local
+	return msg.CombinedOutput(cmd, c)
+}
+
+// CmdFromDir creates a command that runs from the directory of the checkout.
+// Its stderr is logged at the debug level.
+func (r *Repo) CmdFromDir(cmd string, args ...string) *exec.Cmd {
+	c := exec.Command(cmd, args...)
+	c.Dir = r.local
+	c.Stderr = msg.Default.Writer(cmd)
+	return c
+}
+
+// ExportDir copies the checked out files to dir, leaving out the Darcs
+// metadata.
+func (r *Repo) ExportDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	files, err := ioutil.ReadDir(r.local)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.Name() == metaDir {
+			continue
+		}
+		from := filepath.Join(r.local, f.Name())
+		to := filepath.Join(dir, f.Name())
+		if f.IsDir() {
+			err = gpath.CopyDir(from, to)
+		} else {
+			err = gpath.CopyFile(from, to)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// log returns the details of the first patch darcs log selects with args.
+func (r *Repo) log(args ...string) (*vcs.CommitInfo, error) {
+	out, err := r.RunFromDir("darcs", append([]string{"log", "--xml-output"}, args...)...)
+	if err != nil {
+		return nil, vcs.ErrRevisionUnavailable
+	}
+	return parseLog(out)
+}
+
+type xmlLog struct {
+	Patches []struct {
+		Author  string `xml:"author,attr"`
+		Date    string `xml:"date,attr"`
+		Hash    string `xml:"hash,attr"`
+		Name    string `xml:"name"`
+		Comment string `xml:"comment"`
+	} `xml:"patch"`
+}
+
+// parseLog reads the details of the first patch in the XML output of darcs
+// log.
+func parseLog(out []byte) (*vcs.CommitInfo, error) {
+	l := &xmlLog{}
+	if err := xml.Unmarshal(out, l); err != nil {
+		return nil, fmt.Errorf("Unable to read the darcs log: %s", err)
+	}
+	if len(l.Patches) == 0 {
+		return nil, vcs.ErrRevisionUnavailable
+	}
+	p := l.Patches[0]
+	ci := &vcs.CommitInfo{
+		Commit:  p.Hash,
+		Author:  p.Author,
+		Message: strings.TrimSpace(p.Name),
+	}
+	if c := strings.TrimSpace(p.Comment); c != "" {
+		ci.Message += "\n\n" + c
+	}
+	d, err := time.Parse("20060102150405", p.Date)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse the date %q: %s", p.Date, err)
+	}
+	ci.Date = d
+	return ci, nil
+}
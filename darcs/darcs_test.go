@@ -0,0 +1,108 @@
+package darcs
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseLog(t *testing.T) {
+	out := `<changelog>
+<patch author='Alice &lt;alice@example.com&gt;' date='20170304102030' local_date='Sat Mar  4 10:20:30 UTC 2017' inverted='False' hash='0123456789abcdef0123456789abcdef01234567'>
+	<name>Fix the parser</name>
+	<comment>It failed on empty input.</comment>
+</patch>
+<patch author='bob' date='20170301080000' local_date='' inverted='False' hash='1111111111111111111111111111111111111111'>
+	<name>Initial</name>
+</patch>
+</changelog>
+`
+	ci, err := parseLog([]byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ci.Commit != "0123456789abcdef0123456789abcdef01234567" {
+		t.Errorf("Unexpected commit %s", ci.Commit)
+	}
+	if ci.Author != "Alice <alice@example.com>" {
+		t.Errorf("Unexpected author %s", ci.Author)
+	}
+	if ci.Message != "Fix the parser\n\nIt failed on empty input." {
+		t.Errorf("Unexpected message %q", ci.Message)
+	}
+	if ci.Date.Format("2006-01-02 15:04:05") != "2017-03-04 10:20:30" {
+		t.Errorf("Unexpected date %s", ci.Date)
+	}
+
+	if _, err := parseLog([]byte("<changelog>\n</changelog>\n")); err == nil {
+		t.Error("Expected an error without patches")
+	}
+}
+
+func TestVersionArgs(t *testing.T) {
+	r := &Repo{}
+	if a := r.versionArgs("0123456789abcdef0123456789abcdef01234567", "--hash", "--tag"); a[0] != "--hash" {
+		t.Errorf("Expected a hash, got %v", a)
+	}
+	if a := r.versionArgs("1.0.0", "--hash", "--tag"); a[0] != "--tag" || a[1] != `^1\.0\.0$` {
+		t.Errorf("Expected a tag, got %v", a)
+	}
+}
+
+func TestRepo(t *testing.T) {
+	if _, err := exec.LookPath("darcs"); err != nil {
+		t.Skip("darcs is not installed")
+	}
+	dir, err := ioutil.TempDir("", "glide-darcs-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src, _ := NewRepo("", filepath.Join(dir, "src"))
+	if err := src.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "src", "foo.go"), []byte("package foo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"add", "foo.go"},
+		{"record", "--all", "-m", "Add foo", "--author", "test"},
+		{"tag", "-m", "v1.0.0", "--author", "test"},
+	} {
+		if out, err := src.RunFromDir("darcs", args...); err != nil {
+			t.Fatalf("%s: %s", err, out)
+		}
+	}
+
+	r, err := NewRepo(filepath.Join(dir, "src"), filepath.Join(dir, "clone"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Get(); err != nil {
+		t.Fatal(err)
+	}
+	if tags, err := r.Tags(); err != nil || len(tags) != 1 || tags[0] != "v1.0.0" {
+		t.Errorf("Unexpected tags %v (%v)", tags, err)
+	}
+	if err := r.UpdateVersion("v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	if !r.CheckLocal() {
+		t.Error("Expected a checkout after setting the version")
+	}
+
+	out := filepath.Join(dir, "vendor")
+	if err := r.ExportDir(out); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "foo.go")); err != nil {
+		t.Error("Expected foo.go to be exported")
+	}
+	if IsCheckout(out) {
+		t.Error("Expected the Darcs metadata not to be exported")
+	}
+}
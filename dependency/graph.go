@@ -0,0 +1,172 @@
+package dependency
+
+import (
+	"go/build"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/glide/util"
+)
+
+// Graph is the dependency graph of a project and its locked dependencies.
+//
+// Each dependency appears once as a node no matter how many other packages
+// import it. Edges point from the importing project to the imported one.
+type Graph struct {
+	Name  string       `json:"name"`
+	Nodes []*GraphNode `json:"nodes"`
+	Edges []*GraphEdge `json:"edges"`
+}
+
+// GraphNode is a project within a dependency graph.
+type GraphNode struct {
+	Name      string `json:"name"`
+	Version   string `json:"version,omitempty"`
+	Reference string `json:"reference,omitempty"`
+	Test      bool   `json:"test,omitempty"`
+}
+
+// GraphEdge is an import relationship between two projects in a graph.
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// NewGraph builds a dependency graph from a lock file by scanning the imports
+// of the project at basedir and of each locked dependency in its vendor/
+// directory.
+//
+// The config is used to add the version constraints of direct dependencies
+// to the graph.
+func NewGraph(basedir string, conf *cfg.Config, lock *cfg.Lockfile) (*Graph, error) {
+	basedir, err := filepath.Abs(basedir)
+	if err != nil {
+		return nil, err
+	}
+	b, err := util.GetBuildContext()
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Graph{Name: conf.Name}
+	g.Nodes = append(g.Nodes, &GraphNode{Name: conf.Name})
+
+	var names []string
+	add := func(locks cfg.Locks, test bool) {
+		for _, l := range locks {
+			n := &GraphNode{
+				Name:    l.Name,
+				Version: l.Version,
+				Test:    test,
+			}
+			if d := conf.Imports.Get(l.Name); d != nil {
+				n.Reference = d.Reference
+			} else if d := conf.DevImports.Get(l.Name); d != nil {
+				n.Reference = d.Reference
+			}
+			g.Nodes = append(g.Nodes, n)
+			names = append(names, l.Name)
+		}
+	}
+	add(lock.Imports, false)
+	add(lock.DevImports, true)
+
+	seen := make(map[string]bool)
+	link := func(from string, imps []string) {
+		for _, imp := range imps {
+			to := graphOwner(imp, names)
+			if to == "" || to == from || seen[from+" "+to] {
+				continue
+			}
+			seen[from+" "+to] = true
+			g.Edges = append(g.Edges, &GraphEdge{From: from, To: to})
+		}
+	}
+
+	vdir := filepath.Join(basedir, gpath.VendorDir)
+	imps, err := scanTreeImports(b, basedir, vdir, true)
+	if err != nil {
+		return nil, err
+	}
+	link(conf.Name, imps)
+
+	for _, name := range names {
+		p := filepath.Join(vdir, filepath.FromSlash(name))
+		if _, err := os.Stat(p); err != nil {
+			msg.Warn("%s is not in the %s directory. Its imports will not be graphed", name, gpath.VendorDir)
+			continue
+		}
+		imps, err := scanTreeImports(b, p, "", false)
+		if err != nil {
+			return nil, err
+		}
+		link(name, imps)
+	}
+
+	sort.Sort(graphEdges(g.Edges))
+
+	return g, nil
+}
+
+// graphOwner returns the name of the project, from the list of names, that
+// contains the package. When no project contains the package an empty string
+// is returned.
+func graphOwner(pkg string, names []string) string {
+	owner := ""
+	for _, n := range names {
+		if (pkg == n || strings.HasPrefix(pkg, n+"/")) && len(n) > len(owner) {
+			owner = n
+		}
+	}
+	return owner
+}
+
+// scanTreeImports walks the directory tree at base and returns the imports of
+// every package found. Test imports are included when test is true.
+func scanTreeImports(b *util.BuildCtxt, base, vdir string, test bool) ([]string, error) {
+	var imps []string
+	err := filepath.Walk(base, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() {
+			return nil
+		}
+		if path != base && (!srcDir(fi) || path == vdir) {
+			return filepath.SkipDir
+		}
+
+		pkg, err := ScanImports(b, path)
+		if err != nil {
+			if _, ok := err.(*build.NoGoError); !ok {
+				msg.Debug("Unable to scan %s: %s", path, err)
+			}
+			return nil
+		}
+		imps = append(imps, pkg.Imports...)
+		if test {
+			imps = append(imps, pkg.TestImports...)
+		}
+		return nil
+	})
+
+	return imps, err
+}
+
+type graphEdges []*GraphEdge
+
+func (e graphEdges) Len() int { return len(e) }
+
+func (e graphEdges) Less(i, j int) bool {
+	if e[i].From == e[j].From {
+		return e[i].To < e[j].To
+	}
+	return e[i].From < e[j].From
+}
+
+func (e graphEdges) Swap(i, j int) { e[i], e[j] = e[j], e[i] }
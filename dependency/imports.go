@@ -0,0 +1,186 @@
+package dependency
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/glide/msg"
+	"github.com/Masterminds/glide/util"
+)
+
+// PkgImports holds the imports found in the Go files of a directory.
+type PkgImports struct {
+	// Dir is the directory that was scanned.
+	Dir string
+
+	// Imports are the packages imported by the non-test files.
+	Imports []string
+
+	// TestImports are the packages imported by the test files, including
+	// those of an external _test package.
+	TestImports []string
+
+	// Goroot is true when the directory is within GOROOT.
+	Goroot bool
+}
+
+var (
+	importsMutex sync.Mutex
+	importsCache = make(map[string]*cachedImports)
+)
+
+type cachedImports struct {
+	sum  string
+	imps *PkgImports
+}
+
+// ScanImports returns the imports of the Go files in dir that the build
+// context selects. Unlike go/build it only parses the import declarations, so
+// a file that does not compile still has the imports ahead of its first error
+// found, and files of several packages in one directory do not stop the scan.
+// Files importing "C" are scanned whether or not cgo is enabled. When the
+// context uses all files, those constrained to the ignore tag are left out as
+// they usually hold examples or generators.
+//
+// Results are cached per directory for the rest of the run and scanned again
+// when the files in the directory change.
+//
+// When there are no Go files in dir the error is a *build.NoGoError.
+func ScanImports(b *util.BuildCtxt, dir string) (*PkgImports, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// The cache key covers the parts of the context that select files while
+	// the sum catches files changed since, such as by checking out another
+	// version.
+	key := fmt.Sprintf("%s\x00%s/%s\x00%v\x00%t", dir, b.GOOS, b.GOARCH, b.BuildTags, b.UseAllFiles)
+	var sum bytes.Buffer
+	for _, fi := range fis {
+		if !fi.IsDir() && strings.HasSuffix(fi.Name(), ".go") {
+			fmt.Fprintf(&sum, "%s %d %d\n", fi.Name(), fi.Size(), fi.ModTime().UnixNano())
+		}
+	}
+	importsMutex.Lock()
+	c, ok := importsCache[key]
+	importsMutex.Unlock()
+	if ok && c.sum == sum.String() {
+		return c.imps, nil
+	}
+
+	imps, err := scanImports(b, dir, fis)
+	if err != nil {
+		return nil, err
+	}
+	importsMutex.Lock()
+	importsCache[key] = &cachedImports{sum: sum.String(), imps: imps}
+	importsMutex.Unlock()
+	return imps, nil
+}
+
+func scanImports(b *util.BuildCtxt, dir string, fis []os.FileInfo) (*PkgImports, error) {
+	p := &PkgImports{Dir: dir}
+	if b.GOROOT != "" {
+		p.Goroot = dirHasPrefix(dir, filepath.Join(b.GOROOT, "src"))
+	}
+
+	imps := make(map[string]bool)
+	testImps := make(map[string]bool)
+	found := false
+	fset := token.NewFileSet()
+	for _, fi := range fis {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		// MatchFile skips names starting with _ or . even with all files.
+		if ok, err := b.MatchFile(dir, name); err != nil {
+			msg.Debug("Unable to read %s: %s", filepath.Join(dir, name), err)
+			continue
+		} else if !ok {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ImportsOnly|parser.ParseComments)
+		if f == nil {
+			msg.Debug("Unable to parse %s: %s", filepath.Join(dir, name), err)
+			continue
+		} else if err != nil {
+			msg.Debug("Using the imports parsed before the error in %s: %s", filepath.Join(dir, name), err)
+		}
+		if b.UseAllFiles && ignoredFile(f) {
+			continue
+		}
+		found = true
+
+		to := imps
+		if strings.HasSuffix(name, "_test.go") {
+			to = testImps
+		}
+		for _, spec := range f.Imports {
+			imp, err := strconv.Unquote(spec.Path.Value)
+			if err != nil || imp == "C" {
+				continue
+			}
+			to[imp] = true
+		}
+	}
+	if !found {
+		return nil, &build.NoGoError{Dir: dir}
+	}
+
+	p.Imports = sortedKeys(imps)
+	p.TestImports = sortedKeys(testImps)
+	return p, nil
+}
+
+// ignoredFile returns true when the build constraints of a file require the
+// ignore tag.
+func ignoredFile(f *ast.File) bool {
+	for _, cg := range f.Comments {
+		if cg.Pos() >= f.Package {
+			break
+		}
+		for _, c := range cg.List {
+			var expr string
+			switch {
+			case strings.HasPrefix(c.Text, "//go:build "):
+				expr = strings.TrimPrefix(c.Text, "//go:build ")
+			case strings.HasPrefix(strings.TrimSpace(strings.TrimPrefix(c.Text, "//")), "+build "):
+				expr = strings.TrimPrefix(strings.TrimSpace(strings.TrimPrefix(c.Text, "//")), "+build ")
+			default:
+				continue
+			}
+			terms := strings.FieldsFunc(expr, func(r rune) bool {
+				return strings.ContainsRune(" \t,()|&", r)
+			})
+			for _, t := range terms {
+				if t == "ignore" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]bool) []string {
+	s := make([]string, 0, len(m))
+	for k := range m {
+		s = append(s, k)
+	}
+	sort.Strings(s)
+	return s
+}
@@ -0,0 +1,75 @@
+package dependency
+
+import (
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/glide/util"
+)
+
+func TestScanImports(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-imports")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		// Does not compile, and the parser gives up after the first import.
+		"broken.go":   "package foo\n\nimport \"example.com/broken\"\n\nfunc {\n",
+		"cgo.go":      "package foo\n\n// #include <stdio.h>\nimport \"C\"\nimport \"example.com/cgo\"\n",
+		"example.go":  "// +build ignore\n\npackage main\n\nimport \"example.com/example\"\n",
+		"_skip.go":    "package foo\n\nimport \"example.com/skip\"\n",
+		"foo_test.go": "package foo\n\nimport \"example.com/test\"\n",
+		"x_test.go":   "package foo_test\n\nimport \"example.com/xtest\"\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b := &util.BuildCtxt{Context: build.Default}
+	b.UseAllFiles = true
+	p, err := ScanImports(b, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"example.com/broken", "example.com/cgo"}; !reflect.DeepEqual(p.Imports, want) {
+		t.Errorf("Expected imports %v, got %v", want, p.Imports)
+	}
+	if want := []string{"example.com/test", "example.com/xtest"}; !reflect.DeepEqual(p.TestImports, want) {
+		t.Errorf("Expected test imports %v, got %v", want, p.TestImports)
+	}
+
+	// A changed file is scanned again rather than taken from the cache.
+	f := filepath.Join(dir, "broken.go")
+	if err := ioutil.WriteFile(f, []byte("package foo\n\nimport \"example.com/fixed\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(f, later, later)
+	p, err = ScanImports(b, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"example.com/cgo", "example.com/fixed"}; !reflect.DeepEqual(p.Imports, want) {
+		t.Errorf("Expected imports %v after the change, got %v", want, p.Imports)
+	}
+
+	empty, err := ioutil.TempDir("", "glide-imports")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(empty)
+	if _, err := ScanImports(b, empty); err == nil {
+		t.Error("Expected an error scanning a directory without Go files")
+	} else if _, ok := err.(*build.NoGoError); !ok {
+		t.Errorf("Expected a *build.NoGoError, got %T", err)
+	}
+}
@@ -3,10 +3,10 @@ package dependency
 import (
 	"container/list"
 	"errors"
+	"go/build"
 	"runtime"
 	"sort"
 
-	//"go/build"
 	"os"
 	"path/filepath"
 	"strings"
@@ -170,6 +170,9 @@ type Resolver struct {
 	// findCache caches hits from Find. This reduces the number of filesystem
 	// touches that have to be done for dependency resolution.
 	findCache map[string]*PkgInfo
+
+	// importers maps each package found to the packages importing it.
+	importers map[string]map[string]bool
 }
 
 // NewResolver returns a new Resolver initialized with the DefaultMissingPackageHandler.
@@ -192,7 +195,7 @@ func NewResolver(basedir string) (*Resolver, error) {
 		return nil, err
 	}
 
-	vdir := filepath.Join(basedir, "vendor")
+	vdir := filepath.Join(basedir, gpath.VendorDir)
 
 	buildContext, err := util.GetBuildContext()
 	if err != nil {
@@ -209,6 +212,7 @@ func NewResolver(basedir string) (*Resolver, error) {
 		alreadyQ:       map[string]bool{},
 		hadError:       map[string]bool{},
 		findCache:      map[string]*PkgInfo{},
+		importers:      map[string]map[string]bool{},
 
 		// The config instance here should really be replaced with a real one.
 		Config: &cfg.Config{},
@@ -267,7 +271,11 @@ func (r *Resolver) ResolveLocal(deep bool) ([]string, []string, error) {
 	tl := list.New()
 	alreadySeen := map[string]bool{}
 	talreadySeen := map[string]bool{}
-	err := filepath.Walk(r.basedir, func(path string, fi os.FileInfo, err error) error {
+	ignore, err := gpath.ReadIgnore(r.basedir)
+	if err != nil {
+		msg.Warn("Unable to read %s: %s", gpath.IgnoreFile, err)
+	}
+	err = filepath.Walk(r.basedir, func(path string, fi os.FileInfo, err error) error {
 		if err != nil && err != filepath.SkipDir {
 			return err
 		}
@@ -277,37 +285,32 @@ func (r *Resolver) ResolveLocal(deep bool) ([]string, []string, error) {
 			msg.Debug("Excluding %s", pt)
 			return filepath.SkipDir
 		}
+		if path != r.basedir && ignore.Match(filepath.ToSlash(pt), fi.IsDir()) {
+			msg.Debug("Ignoring %s as listed in %s", pt, gpath.IgnoreFile)
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 		if !fi.IsDir() {
 			return nil
 		}
-		if !srcDir(fi) {
+		if !srcDir(fi) || path == r.VendorDir {
+			return filepath.SkipDir
+		}
+		if gpath.TestVendorDir != "" && pt == gpath.TestVendorDir {
 			return filepath.SkipDir
 		}
 
 		// Scan for dependencies, and anything that's not part of the local
 		// package gets added to the scan list.
-		var imps []string
-		var testImps []string
-		p, err := r.BuildContext.ImportDir(path, 0)
-		if err != nil {
-			if strings.HasPrefix(err.Error(), "no buildable Go source") {
-				return nil
-			} else if strings.HasPrefix(err.Error(), "found packages ") {
-				// If we got here it's because a package and multiple packages
-				// declared. This is often because of an example with a package
-				// or main but +build ignore as a build tag. In that case we
-				// try to brute force the packages with a slower scan.
-				imps, testImps, err = IterativeScan(path)
-				if err != nil {
-					return err
-				}
-			} else {
-				return err
-			}
-		} else {
-			imps = p.Imports
-			testImps = dedupeStrings(p.TestImports, p.XTestImports)
+		p, err := ScanImports(r.BuildContext, path)
+		if _, ok := err.(*build.NoGoError); ok {
+			return nil
+		} else if err != nil {
+			return err
 		}
+		imps, testImps := p.Imports, p.TestImports
 
 		// We are only looking for dependencies in vendor. No root, cgo, etc.
 		for _, imp := range imps {
@@ -321,6 +324,7 @@ func (r *Resolver) ResolveLocal(deep bool) ([]string, []string, error) {
 			info := r.FindPkg(imp)
 			switch info.Loc {
 			case LocUnknown, LocVendor:
+				r.addImporter(r.Config.Name, imp)
 				l.PushBack(filepath.Join(r.VendorDir, filepath.FromSlash(imp))) // Do we need a path on this?
 			case LocGopath:
 				if !dirHasPrefix(info.Path, r.basedir) {
@@ -328,6 +332,7 @@ func (r *Resolver) ResolveLocal(deep bool) ([]string, []string, error) {
 					// scanning. It should really be on vendor. But we don't
 					// want it to reference GOPATH. We want it to be detected
 					// and moved.
+					r.addImporter(r.Config.Name, imp)
 					l.PushBack(filepath.Join(r.VendorDir, filepath.FromSlash(imp)))
 				}
 			case LocRelative:
@@ -346,6 +351,7 @@ func (r *Resolver) ResolveLocal(deep bool) ([]string, []string, error) {
 				info := r.FindPkg(imp)
 				switch info.Loc {
 				case LocUnknown, LocVendor:
+					r.addImporter(r.Config.Name, imp)
 					tl.PushBack(filepath.Join(r.VendorDir, filepath.FromSlash(imp))) // Do we need a path on this?
 				case LocGopath:
 					if !dirHasPrefix(info.Path, r.basedir) {
@@ -353,6 +359,7 @@ func (r *Resolver) ResolveLocal(deep bool) ([]string, []string, error) {
 						// scanning. It should really be on vendor. But we don't
 						// want it to reference GOPATH. We want it to be detected
 						// and moved.
+						r.addImporter(r.Config.Name, imp)
 						tl.PushBack(filepath.Join(r.VendorDir, filepath.FromSlash(imp)))
 					}
 				case LocRelative:
@@ -426,6 +433,58 @@ func (r *Resolver) ResolveAll(deps []*cfg.Dependency, addTest bool) ([]string, e
 	return r.resolveImports(queue, false, addTest)
 }
 
+// Importers returns, for each package found while resolving, the packages
+// importing it. Imports from the packages of the project are listed under the
+// name of the project.
+func (r *Resolver) Importers() map[string][]string {
+	imps := make(map[string][]string, len(r.importers))
+	for pkg, by := range r.importers {
+		for b := range by {
+			imps[pkg] = append(imps[pkg], b)
+		}
+		sort.Strings(imps[pkg])
+	}
+	return imps
+}
+
+func (r *Resolver) addImporter(pkg, imp string) {
+	if r.importers == nil {
+		r.importers = map[string]map[string]bool{}
+	}
+	if r.importers[imp] == nil {
+		r.importers[imp] = map[string]bool{}
+	}
+	r.importers[imp][pkg] = true
+}
+
+// dependencyDir returns the directory of the dependency holding pkg, or an
+// empty string for the packages of the project.
+func (r *Resolver) dependencyDir(pkg string) string {
+	root := util.GetRootFromPackage(pkg)
+	if root == r.Config.Name {
+		return ""
+	}
+	return r.Handler.PkgPath(root)
+}
+
+// keptNested returns true when the policy is to keep nested vendor directories
+// and imp is vendored in dir, or in a parent of it within the dependency at
+// rootDir.
+func (r *Resolver) keptNested(dir, rootDir, imp string) bool {
+	if rootDir == "" || r.Config.NestedPolicy() != cfg.NestedKeep {
+		return false
+	}
+	for ; dirHasPrefix(dir, rootDir); dir = filepath.Dir(dir) {
+		if fi, err := os.Stat(filepath.Join(dir, "vendor", filepath.FromSlash(imp))); err == nil && fi.IsDir() {
+			return true
+		}
+		if dir == rootDir {
+			break
+		}
+	}
+	return false
+}
+
 // Stripv strips the vendor/ prefix from vendored packages.
 func (r *Resolver) Stripv(str string) string {
 	return strings.TrimPrefix(str, r.VendorDir+string(os.PathSeparator))
@@ -433,7 +492,7 @@ func (r *Resolver) Stripv(str string) string {
 
 // vpath adds an absolute vendor path.
 func (r *Resolver) vpath(str string) string {
-	return filepath.Join(r.basedir, "vendor", str)
+	return filepath.Join(r.VendorDir, str)
 }
 
 // resolveImports takes a list of existing packages and resolves their imports.
@@ -493,27 +552,11 @@ func (r *Resolver) resolveImports(queue *list.List, testDeps, addTest bool) ([]s
 		// Here, we want to import the package and see what imports it has.
 		msg.Debug("Trying to open %s (%s)", dep, r.Handler.PkgPath(dep))
 		var imps []string
-		pkg, err := r.BuildContext.ImportDir(r.Handler.PkgPath(dep), 0)
-		if err != nil && strings.HasPrefix(err.Error(), "found packages ") {
-			// If we got here it's because a package and multiple packages
-			// declared. This is often because of an example with a package
-			// or main but +build ignore as a build tag. In that case we
-			// try to brute force the packages with a slower scan.
-			msg.Debug("Using Iterative Scanning for %s", dep)
-			if testDeps {
-				_, imps, err = IterativeScan(r.Handler.PkgPath(dep))
-			} else {
-				imps, _, err = IterativeScan(r.Handler.PkgPath(dep))
-			}
-
-			if err != nil {
-				msg.Err("Iterative scanning error %s: %s", dep, err)
-				continue
-			}
-		} else if err != nil {
+		pkg, err := ScanImports(r.BuildContext, r.Handler.PkgPath(dep))
+		if err != nil {
 			errStr := err.Error()
-			msg.Debug("ImportDir error on %s: %s", r.Handler.PkgPath(dep), err)
-			if strings.HasPrefix(errStr, "no buildable Go source") {
+			msg.Debug("Scanning error on %s: %s", r.Handler.PkgPath(dep), err)
+			if _, ok := err.(*build.NoGoError); ok {
 				msg.Debug("No subpackages declared. Skipping %s.", dep)
 				continue
 			} else if osDirNotFound(err, r.Handler.PkgPath(dep)) && !foundErr && !foundQ {
@@ -556,15 +599,14 @@ func (r *Resolver) resolveImports(queue *list.List, testDeps, addTest bool) ([]s
 				msg.Err("Error scanning %s: %s", dep, err)
 			}
 			continue
+		} else if testDeps {
+			imps = pkg.TestImports
 		} else {
-			if testDeps {
-				imps = dedupeStrings(pkg.TestImports, pkg.XTestImports)
-			} else {
-				imps = pkg.Imports
-			}
-
+			imps = pkg.Imports
 		}
 
+		depDir, rootDir := r.Handler.PkgPath(dep), r.dependencyDir(dep)
+
 		// Range over all of the identified imports and see which ones we
 		// can locate.
 		for _, imp := range imps {
@@ -572,11 +614,19 @@ func (r *Resolver) resolveImports(queue *list.List, testDeps, addTest bool) ([]s
 				msg.Debug("Ignoring: %s", imp)
 				continue
 			}
+			if r.keptNested(depDir, rootDir, imp) {
+				msg.Debug("Keeping %s nested in the vendor directory of %s", imp, dep)
+				continue
+			}
 			pi := r.FindPkg(imp)
 			if pi.Loc != LocCgo && pi.Loc != LocGoroot && pi.Loc != LocAppengine {
 				msg.Debug("Package %s imports %s", dep, imp)
 			}
 			switch pi.Loc {
+			case LocVendor, LocUnknown, LocGopath:
+				r.addImporter(dep, imp)
+			}
+			switch pi.Loc {
 			case LocVendor:
 				msg.Debug("In vendor: %s", imp)
 				if _, ok := r.alreadyQ[imp]; !ok {
@@ -695,9 +745,14 @@ func (r *Resolver) resolveList(queue *list.List, testDeps, addTest bool) ([]stri
 		//msg.Info("Seen Count: %d", len(r.seen))
 		// Catch the outtermost dependency.
 		pkgPath = r.Handler.PkgPath(t)
+		rootDir := r.dependencyDir(t)
 		failedDep = t
 		failedDepPath = pkgPath
-		err := filepath.Walk(pkgPath, func(path string, fi os.FileInfo, err error) error {
+		ignore, err := gpath.ReadIgnore(pkgPath)
+		if err != nil {
+			msg.Warn("Unable to read %s of %s: %s", gpath.IgnoreFile, t, err)
+		}
+		err = filepath.Walk(pkgPath, func(path string, fi os.FileInfo, err error) error {
 			if err != nil && err != filepath.SkipDir {
 				return err
 			}
@@ -706,6 +761,10 @@ func (r *Resolver) resolveList(queue *list.List, testDeps, addTest bool) ([]stri
 			if !fi.IsDir() {
 				return nil
 			}
+			// Skip dirs the package asks to be ignored.
+			if rel, err := filepath.Rel(pkgPath, path); err == nil && rel != "." && ignore.Match(filepath.ToSlash(rel), true) {
+				return filepath.SkipDir
+			}
 			// Skip dirs that are not source.
 			if !srcDir(fi) {
 				//msg.Debug("Skip resource %s", fi.Name())
@@ -715,7 +774,7 @@ func (r *Resolver) resolveList(queue *list.List, testDeps, addTest bool) ([]stri
 			// Anything that comes through here has already been through
 			// the queue.
 			r.alreadyQ[path] = true
-			e := r.queueUnseen(path, queue, testDeps, addTest)
+			e := r.queueUnseen(path, rootDir, queue, testDeps, addTest)
 			if e != nil {
 				failedDepPath = path
 				//msg.Err("Failed to fetch dependency %s: %s", path, err)
@@ -770,7 +829,7 @@ func (r *Resolver) resolveList(queue *list.List, testDeps, addTest bool) ([]stri
 
 // queueUnseenImports scans a package's imports and adds any new ones to the
 // processing queue.
-func (r *Resolver) queueUnseen(pkg string, queue *list.List, testDeps, addTest bool) error {
+func (r *Resolver) queueUnseen(pkg, rootDir string, queue *list.List, testDeps, addTest bool) error {
 	// A pkg is marked "seen" as soon as we have inspected it the first time.
 	// Seen means that we have added all of its imports to the list.
 
@@ -778,7 +837,7 @@ func (r *Resolver) queueUnseen(pkg string, queue *list.List, testDeps, addTest b
 	// or intentionally not put it in the queue for fatal reasons (e.g. no
 	// buildable source).
 
-	deps, err := r.imports(pkg, testDeps, addTest)
+	deps, err := r.imports(pkg, rootDir, testDeps, addTest)
 	if err != nil && !strings.HasPrefix(err.Error(), "no buildable Go source") {
 		msg.Err("Could not find %s: %s", pkg, err)
 		return err
@@ -790,6 +849,9 @@ func (r *Resolver) queueUnseen(pkg string, queue *list.List, testDeps, addTest b
 	}
 
 	for _, d := range deps {
+		if strings.HasPrefix(d, r.VendorDir+string(os.PathSeparator)) {
+			r.addImporter(filepath.ToSlash(r.Stripv(pkg)), filepath.ToSlash(r.Stripv(d)))
+		}
 		if _, ok := r.alreadyQ[d]; !ok {
 			r.alreadyQ[d] = true
 			queue.PushBack(d)
@@ -803,7 +865,9 @@ func (r *Resolver) queueUnseen(pkg string, queue *list.List, testDeps, addTest b
 // If the package is in GOROOT, this will return an empty list (but not
 // an error).
 // If it cannot resolve the pkg, it will return an error.
-func (r *Resolver) imports(pkg string, testDeps, addTest bool) ([]string, error) {
+// rootDir is the directory of the dependency holding the package, where the
+// packages it vendors itself are found.
+func (r *Resolver) imports(pkg, rootDir string, testDeps, addTest bool) ([]string, error) {
 
 	if r.Config.HasIgnore(pkg) {
 		msg.Debug("Ignoring %s", pkg)
@@ -818,36 +882,19 @@ func (r *Resolver) imports(pkg string, testDeps, addTest bool) ([]string, error)
 
 	// FIXME: On error this should try to NotFound to the dependency, and then import
 	// it again.
-	var imps []string
-	p, err := r.BuildContext.ImportDir(pkg, 0)
-	if err != nil && strings.HasPrefix(err.Error(), "found packages ") {
-		// If we got here it's because a package and multiple packages
-		// declared. This is often because of an example with a package
-		// or main but +build ignore as a build tag. In that case we
-		// try to brute force the packages with a slower scan.
-		if testDeps {
-			_, imps, err = IterativeScan(r.Handler.PkgPath(pkg))
-		} else {
-			imps, _, err = IterativeScan(r.Handler.PkgPath(pkg))
-		}
-
-		if err != nil {
-			return []string{}, err
-		}
-	} else if err != nil {
+	p, err := ScanImports(r.BuildContext, pkg)
+	if err != nil {
 		return []string{}, err
-	} else {
-		if testDeps {
-			imps = dedupeStrings(p.TestImports, p.XTestImports)
-		} else {
-			imps = p.Imports
-		}
+	}
+	imps := p.Imports
+	if testDeps {
+		imps = p.TestImports
 	}
 
 	// It is okay to scan a package more than once. In some cases, this is
 	// desirable because the package can change between scans (e.g. as a result
 	// of a failed scan resolving the situation).
-	msg.Debug("=> Scanning %s (%s)", p.ImportPath, pkg)
+	msg.Debug("=> Scanning %s", pkg)
 	r.seen[pkg] = true
 
 	// Optimization: If it's in GOROOT, it has no imports worth scanning.
@@ -862,6 +909,10 @@ func (r *Resolver) imports(pkg string, testDeps, addTest bool) ([]string, error)
 			msg.Debug("Ignoring %s", imp)
 			continue
 		}
+		if r.keptNested(pkg, rootDir, imp) {
+			msg.Debug("Keeping %s nested in the vendor directory of %s", imp, pkg)
+			continue
+		}
 		info := r.FindPkg(imp)
 		switch info.Loc {
 		case LocUnknown:
@@ -1135,32 +1186,6 @@ func checkForBasedirSymlink(basedir string) (string, error) {
 	return basedir, nil
 }
 
-// helper func to merge, dedupe, and sort strings
-func dedupeStrings(s1, s2 []string) (r []string) {
-	dedupe := make(map[string]bool)
-
-	if len(s1) > 0 && len(s2) > 0 {
-		for _, i := range s1 {
-			dedupe[i] = true
-		}
-		for _, i := range s2 {
-			dedupe[i] = true
-		}
-
-		for i := range dedupe {
-			r = append(r, i)
-		}
-		// And then re-sort them
-		sort.Strings(r)
-	} else if len(s1) > 0 {
-		r = s1
-	} else if len(s2) > 0 {
-		r = s2
-	}
-
-	return
-}
-
 // In Go 1.9 go/build.ImportDir changed so that a missing dir
 // no longer responses with os.IsNotExist. Instead the error changed
 // one in the form of fmt.Errorf("cannot find package %q in:\n\t%s", path, p.Dir)
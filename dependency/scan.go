@@ -2,6 +2,7 @@ package dependency
 
 import (
 	"bytes"
+	"go/build"
 	"io"
 	"os"
 	"path/filepath"
@@ -27,14 +28,9 @@ func init() {
 }
 
 // IterativeScan attempts to obtain a list of imported dependencies from a
-// package. This scanning is different from ImportDir as part of the go/build
 // package. It looks over different permutations of the supported OS/Arch to
 // try and find all imports. This is different from setting UseAllFiles to
 // true on the build Context. It scopes down to just the supported OS/Arch.
-//
-// Note, there are cases where multiple packages are in the same directory. This
-// usually happens with an example that has a main package and a +build tag
-// of ignore. This is a bit of a hack. It causes UseAllFiles to have errors.
 func IterativeScan(path string) ([]string, []string, error) {
 
 	// TODO(mattfarina): Add support for release tags.
@@ -97,61 +93,38 @@ func IterativeScan(path string) ([]string, []string, error) {
 		b.BuildTags = ttgs
 		msg.Debug("Scanning with Arch(%s), OS(%s), and Build Tags(%v)", arch, ops, ttgs)
 
-		pk, err := b.ImportDir(path, 0)
+		pk, err := ScanImports(b, path)
 
 		// If there are no buildable souce with this permutation we skip it.
-		if err != nil && strings.HasPrefix(err.Error(), "no buildable Go source files in") {
-			continue
-		} else if err != nil && strings.HasPrefix(err.Error(), "found packages ") {
-			// A permutation may cause multiple packages to appear. For example,
-			// an example file with an ignore build tag. If this happens we
-			// ignore it.
-			// TODO(mattfarina): Find a better way.
-			msg.Debug("Found multiple packages while scanning %s: %s", path, err)
+		if _, ok := err.(*build.NoGoError); ok {
 			continue
 		} else if err != nil {
 			msg.Debug("Problem parsing package at %s for %s %s", path, ops, arch)
 			return []string{}, []string{}, err
 		}
 
-		for _, dep := range pk.Imports {
-			found := false
-			for _, p := range pkgs {
-				if p == dep {
-					found = true
-				}
-			}
-			if !found {
-				pkgs = append(pkgs, dep)
-			}
-		}
+		pkgs = appendNew(pkgs, pk.Imports...)
+		testPkgs = appendNew(testPkgs, pk.TestImports...)
+	}
 
-		for _, dep := range pk.TestImports {
-			found := false
-			for _, p := range pkgs {
-				if p == dep {
-					found = true
-				}
-			}
-			if !found {
-				testPkgs = append(testPkgs, dep)
-			}
-		}
+	return pkgs, testPkgs, nil
+}
 
-		for _, dep := range pk.XTestImports {
-			found := false
-			for _, p := range pkgs {
-				if p == dep {
-					found = true
-				}
-			}
-			if !found {
-				testPkgs = append(testPkgs, dep)
+// appendNew appends the items not already in s.
+func appendNew(s []string, items ...string) []string {
+	for _, i := range items {
+		found := false
+		for _, e := range s {
+			if e == i {
+				found = true
+				break
 			}
 		}
+		if !found {
+			s = append(s, i)
+		}
 	}
-
-	return pkgs, testPkgs, nil
+	return s
 }
 
 func readBuildTags(p string) ([]string, error) {
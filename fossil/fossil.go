@@ -0,0 +1,365 @@
+// Package fossil provides dependencies kept in Fossil repositories.
+//
+// A Fossil repository is a single file. It is cloned into the checkout
+// directory as .fossil and opened there, so the checkout holds everything
+// needed to update it. Repo implements the vcs.Repo interface so Fossil
+// dependencies are handled like any other.
+package fossil
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/vcs"
+)
+
+// Type is the vcs.Type reported for Fossil repositories.
+const Type vcs.Type = "fossil"
+
+// repoFile is the name of the repository file in the checkout.
+const repoFile = ".fossil"
+
+// checkoutFiles are the files Fossil keeps in the root of a checkout.
+var checkoutFiles = []string{repoFile, ".fslckout", "_FOSSIL_"}
+
+// IsCheckout returns true if dir is a Fossil checkout.
+func IsCheckout(dir string) bool {
+	for _, f := range checkoutFiles[1:] {
+		if _, err := os.Stat(filepath.Join(dir, f)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Repo is a Fossil checkout in a local directory.
+type Repo struct {
+	remote, local string
+}
+
+// NewRepo creates a Repo for the Fossil repository at remote checked out in
+// local. It fails with vcs.ErrWrongRemote when local is a checkout of another
+// repository.
+func NewRepo(remote, local string) (*Repo, error) {
+	r := &Repo{remote: remote, local: local}
+	if r.CheckLocal() {
+		out, err := r.RunFromDir("fossil", "remote-url")
+		if err != nil {
+			return nil, vcs.NewLocalError("Unable to retrieve local repo information", err, string(out))
+		}
+		m := strings.TrimSpace(string(out))
+		if remote == "" {
+			r.remote = m
+		} else if m != "off" && m != remote {
+			return nil, vcs.ErrWrongRemote
+		}
+	}
+	return r, nil
+}
+
+// Vcs returns the Fossil type.
+func (r *Repo) Vcs() vcs.Type {
+	return Type
+}
+
+// Remote returns the location of the repository.
+func (r *Repo) Remote() string {
+	return r.remote
+}
+
+// LocalPath returns the directory of the checkout.
+func (r *Repo) LocalPath() string {
+	return r.local
+}
+
+// Get clones the repository and opens a checkout of it.
+func (r *Repo) Get() error {
+	if err := os.MkdirAll(r.local, 0755); err != nil {
+		return err
+	}
+	out, err := r.RunFromDir("fossil", "clone", r.remote, repoFile)
+	if err != nil {
+		return vcs.NewRemoteError("Unable to get repository", err, string(out))
+	}
+	return r.open()
+}
+
+// Init creates a new repository and opens a checkout of it.
+func (r *Repo) Init() error {
+	if err := os.MkdirAll(r.local, 0755); err != nil {
+		return err
+	}
+	out, err := r.RunFromDir("fossil", "init", repoFile)
+	if err != nil {
+		return vcs.NewLocalError("Unable to initialize repository", err, string(out))
+	}
+	return r.open()
+}
+
+func (r *Repo) open() error {
+	out, err := r.RunFromDir("fossil", "open", "--force", repoFile)
+	if err != nil {
+		return vcs.NewLocalError("Unable to open checkout", err, string(out))
+	}
+	return nil
+}
+
+// Update pulls the changes from the remote and updates the checkout to the
+// tip of its branch.
+func (r *Repo) Update() error {
+	out, err := r.RunFromDir("fossil", "pull", r.remote)
+	if err != nil {
+		return vcs.NewRemoteError("Unable to update repository", err, string(out))
+	}
+	out, err = r.RunFromDir("fossil", "update")
+	if err != nil {
+		return vcs.NewLocalError("Unable to update checkout", err, string(out))
+	}
+	return nil
+}
+
+// UpdateVersion updates the checkout to a check-in, tag, or branch.
+func (r *Repo) UpdateVersion(v string) error {
+	out, err := r.RunFromDir("fossil", "update", v)
+	if err != nil {
+		return vcs.NewLocalError("Unable to update checked out version", err, string(out))
+	}
+	return nil
+}
+
+// Version returns the hash of the checked out check-in.
+func (r *Repo) Version() (string, error) {
+	ci, err := r.info("")
+	if err != nil {
+		return "", err
+	}
+	return ci.Commit, nil
+}
+
+// Current returns the branch when the checkout is at its tip and otherwise
+// the hash of the checked out check-in.
+func (r *Repo) Current() (string, error) {
+	v, err := r.Version()
+	if err != nil {
+		return "", err
+	}
+	out, err := r.RunFromDir("fossil", "branch", "current")
+	if err != nil {
+		return v, nil
+	}
+	b := strings.TrimSpace(string(out))
+	if tip, err := r.info(b); err == nil && tip.Commit == v {
+		return b, nil
+	}
+	return v, nil
+}
+
+// Date returns when the checked out check-in was made.
+func (r *Repo) Date() (time.Time, error) {
+	ci, err := r.info("")
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ci.Date, nil
+}
+
+// CheckLocal returns true if the local directory is a checkout.
+func (r *Repo) CheckLocal() bool {
+	return IsCheckout(r.local)
+}
+
+// Branches returns the names of the branches.
+func (r *Repo) Branches() ([]string, error) {
+	out, err := r.RunFromDir("fossil", "branch", "list")
+	if err != nil {
+		return []string{}, vcs.NewLocalError("Unable to retrieve branches", err, string(out))
+	}
+	return listLines(out), nil
+}
+
+// Tags returns the names of the tags.
+func (r *Repo) Tags() ([]string, error) {
+	out, err := r.RunFromDir("fossil", "tag", "list")
+	if err != nil {
+		return []string{}, vcs.NewLocalError("Unable to retrieve tags", err, string(out))
+	}
+	return listLines(out), nil
+}
+
+// IsReference returns true if the check-in, tag, or branch exists.
+func (r *Repo) IsReference(ref string) bool {
+	_, err := r.info(ref)
+	return err == nil
+}
+
+// IsDirty returns true if the checkout has changes.
+func (r *Repo) IsDirty() bool {
+	out, err := r.RunFromDir("fossil", "changes")
+	return err != nil || len(bytes.TrimSpace(out)) > 0
+}
+
+// CommitInfo returns the details of a check-in.
+func (r *Repo) CommitInfo(id string) (*vcs.CommitInfo, error) {
+	if id == "" {
+		return nil, vcs.ErrRevisionUnavailable
+	}
+	return r.info(id)
+}
+
+// TagsFromCommit returns the tags of a check-in.
+func (r *Repo) TagsFromCommit(id string) ([]string, error) {
+	out, err := r.RunFromDir("fossil", "tag", "list", id)
+	if err != nil {
+		return []string{}, vcs.NewLocalError("Unable to retrieve tags", err, string(out))
+	}
+	return listLines(out), nil
+}
+
+// Ping returns true if the remote can be reached.
+func (r *Repo) Ping() bool {
+	if strings.HasPrefix(r.remote, "http://") || strings.HasPrefix(r.remote, "https://") {
+		resp, err := http.Head(r.remote)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode < 400
+	}
+	_, err := os.Stat(strings.TrimPrefix(r.remote, "file://"))
+	return err == nil
+}
+
+// RunFromDir runs a command from the directory of the checkout, logging its
+// output at the debug level.
+func (r *Repo) RunFromDir(cmd string, args ...string) ([]byte, error) {
+	c := exec.Command(cmd, args...)
+	c.Dir = r.local
+	return msg.CombinedOutput(cmd, c)
+}
+
+// CmdFromDir creates a command that runs from the directory of the checkout.
+// Its stderr is logged at the debug level.
+func (r *Repo) CmdFromDir(cmd string, args ...string) *exec.Cmd {
+	c := exec.Command(cmd, args...)
+	c.Dir = r.local
+	c.Stderr = msg.Default.Writer(cmd)
+	return c
+}
+
+// ExportDir copies the checked out files to dir, leaving out the repository
+// and the files Fossil uses to track the checkout.
+func (r *Repo) ExportDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	files, err := ioutil.ReadDir(r.local)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if isCheckoutFile(f.Name()) {
+			continue
+		}
+		from := filepath.Join(r.local, f.Name())
+		to := filepath.Join(dir, f.Name())
+		if f.IsDir() {
+			err = gpath.CopyDir(from, to)
+		} else {
+			err = gpath.CopyFile(from, to)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isCheckoutFile(name string) bool {
+	for _, f := range checkoutFiles {
+		if name == f {
+			return true
+		}
+	}
+	return false
+}
+
+// info returns the details of a check-in, or of the checked out one when ref
+// is empty.
+func (r *Repo) info(ref string) (*vcs.CommitInfo, error) {
+	args := []string{"info"}
+	if ref != "" {
+		args = append(args, ref)
+	}
+	out, err := r.RunFromDir("fossil", args...)
+	if err != nil {
+		return nil, vcs.ErrRevisionUnavailable
+	}
+	return parseInfo(out)
+}
+
+// infoDateRe matches the hash and date in the output of fossil info.
+var infoDateRe = regexp.MustCompile(`^([0-9a-f]+)\s+(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})`)
+
+// commentUserRe matches the user at the end of a comment.
+var commentUserRe = regexp.MustCompile(`^(?s)(.*?)\s*\(user: ([^)]*)\)\s*$`)
+
+// parseInfo reads the details of a check-in from the output of fossil info.
+// The hash is on the checkout line for the checked out check-in, and on the
+// hash line, or uuid line for older versions of Fossil, otherwise.
+func parseInfo(out []byte) (*vcs.CommitInfo, error) {
+	ci := &vcs.CommitInfo{}
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		parts := strings.SplitN(s.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		switch key {
+		case "checkout", "hash", "uuid":
+			m := infoDateRe.FindStringSubmatch(value)
+			if m == nil || ci.Commit != "" {
+				continue
+			}
+			ci.Commit = m[1]
+			d, err := time.Parse("2006-01-02 15:04:05", m[2])
+			if err != nil {
+				return nil, fmt.Errorf("Unable to parse the date %q: %s", m[2], err)
+			}
+			ci.Date = d
+		case "comment":
+			if m := commentUserRe.FindStringSubmatch(value); m != nil {
+				ci.Message, ci.Author = m[1], m[2]
+			} else {
+				ci.Message = value
+			}
+		}
+	}
+	if ci.Commit == "" {
+		return nil, vcs.ErrRevisionUnavailable
+	}
+	return ci, nil
+}
+
+// listLines returns the names listed one per line, such as by fossil branch
+// list where the current branch is marked with a *.
+func listLines(out []byte) []string {
+	var l []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "*")); line != "" {
+			l = append(l, line)
+		}
+	}
+	return l
+}
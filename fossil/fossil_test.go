@@ -0,0 +1,106 @@
+package fossil
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseInfo(t *testing.T) {
+	out := `project-name: example
+repository:   /tmp/example/.fossil
+local-root:   /tmp/example/
+checkout:     3c8a1c0b2f9e4d5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f90 2017-03-04 10:20:30 UTC
+parent:       1111111111111111111111111111111111111111111111111111111111111111 2017-03-01 08:00:00 UTC
+tags:         trunk, v1.0.0
+comment:      Fix the parser (user: alice)
+`
+	ci, err := parseInfo([]byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ci.Commit != "3c8a1c0b2f9e4d5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f90" {
+		t.Errorf("Unexpected commit %s", ci.Commit)
+	}
+	if ci.Date.Format("2006-01-02 15:04:05") != "2017-03-04 10:20:30" {
+		t.Errorf("Unexpected date %s", ci.Date)
+	}
+	if ci.Author != "alice" || ci.Message != "Fix the parser" {
+		t.Errorf("Unexpected author %q and message %q", ci.Author, ci.Message)
+	}
+
+	ci, err = parseInfo([]byte("uuid:         abc123 2016-01-02 03:04:05 UTC\ncomment:      Initial\n"))
+	if err != nil || ci.Commit != "abc123" || ci.Message != "Initial" {
+		t.Errorf("Unexpected info %+v (%v)", ci, err)
+	}
+
+	if _, err := parseInfo([]byte("project-name: example\n")); err == nil {
+		t.Error("Expected an error without a check-in")
+	}
+}
+
+func TestListLines(t *testing.T) {
+	l := listLines([]byte("   feature\n * trunk\n\n"))
+	if !reflect.DeepEqual(l, []string{"feature", "trunk"}) {
+		t.Errorf("Unexpected list %v", l)
+	}
+}
+
+func TestRepo(t *testing.T) {
+	if _, err := exec.LookPath("fossil"); err != nil {
+		t.Skip("fossil is not installed")
+	}
+	dir, err := ioutil.TempDir("", "glide-fossil-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src, _ := NewRepo("", filepath.Join(dir, "src"))
+	if err := src.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "src", "foo.go"), []byte("package foo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := src.RunFromDir("fossil", "add", "foo.go"); err != nil {
+		t.Fatalf("%s: %s", err, out)
+	}
+	if out, err := src.RunFromDir("fossil", "commit", "-m", "Add foo", "--tag", "v1.0.0", "--no-warnings"); err != nil {
+		t.Fatalf("%s: %s", err, out)
+	}
+
+	r, err := NewRepo(filepath.Join(dir, "src", repoFile), filepath.Join(dir, "clone"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Get(); err != nil {
+		t.Fatal(err)
+	}
+	if !r.CheckLocal() || !IsCheckout(r.LocalPath()) {
+		t.Error("Expected a checkout")
+	}
+	if !r.IsReference("v1.0.0") {
+		t.Error("Expected v1.0.0 to be a reference")
+	}
+	if err := r.UpdateVersion("v1.0.0"); err != nil {
+		t.Error(err)
+	}
+	if v, err := r.Version(); err != nil || v == "" {
+		t.Errorf("Unexpected version %q (%v)", v, err)
+	}
+
+	out := filepath.Join(dir, "vendor")
+	if err := r.ExportDir(out); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "foo.go")); err != nil {
+		t.Error("Expected foo.go to be exported")
+	}
+	if IsCheckout(out) {
+		t.Error("Expected the checkout files not to be exported")
+	}
+}
@@ -5,12 +5,12 @@
 //
 // A glide.yaml file looks like:
 //
-//		package: github.com/Masterminds/glide
-//		imports:
-//		- package: github.com/Masterminds/cookoo
-//		- package: github.com/kylelemons/go-gypsy
-//		  subpackages:
-//		  - yaml
+//	package: github.com/Masterminds/glide
+//	imports:
+//	- package: github.com/Masterminds/cookoo
+//	- package: github.com/kylelemons/go-gypsy
+//	  subpackages:
+//	  - yaml
 //
 // Glide puts dependencies in a vendor directory. Go utilities require this to
 // be in your GOPATH. Glide makes this easy.
@@ -23,6 +23,8 @@ import (
 
 	"github.com/Masterminds/glide/action"
 	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/hooks"
 	"github.com/Masterminds/glide/msg"
 	gpath "github.com/Masterminds/glide/path"
 	"github.com/Masterminds/glide/repo"
@@ -32,6 +34,8 @@ import (
 
 	"fmt"
 	"os"
+	"strings"
+	"time"
 )
 
 var version = "0.13.4-dev"
@@ -57,6 +61,9 @@ const usage = `Vendor Package Management for your Go projects.
 var VendorDir = "vendor"
 
 func main() {
+	action.VCSShim()
+	action.AskPass()
+
 	app := cli.NewApp()
 	app.Name = "glide"
 	app.Usage = usage
@@ -84,16 +91,124 @@ func main() {
 		cli.StringFlag{
 			Name:   "tmp",
 			Value:  "",
-			Usage:  "The temp directory to use. Defaults to systems temp",
+			Usage:  "The temp directory to use. Defaults to systems temp, and to the project for staging vendor/",
 			EnvVar: "GLIDE_TMP",
 		},
+		cli.StringFlag{
+			Name:   "log-level",
+			Value:  "info",
+			Usage:  "The lowest level of messages to display: debug, info, warn, or error",
+			EnvVar: "GLIDE_LOG_LEVEL",
+		},
+		cli.StringFlag{
+			Name:   "log-format",
+			Value:  "text",
+			Usage:  "How to display messages: text or json",
+			EnvVar: "GLIDE_LOG_FORMAT",
+		},
+		cli.StringFlag{
+			Name:   "error-format",
+			Value:  "text",
+			Usage:  "How to display errors: text or json, which names the kind of each error and the exit code",
+			EnvVar: "GLIDE_ERROR_FORMAT",
+		},
+		cli.StringFlag{
+			Name:   "log-file",
+			Usage:  "Also write every message, including debug messages and VCS output, to this file",
+			EnvVar: "GLIDE_LOG_FILE",
+		},
+		cli.BoolFlag{
+			Name:   "debug-vcs",
+			Usage:  "Log every VCS command run, with its directory, duration, and exit code. Credentials are redacted",
+			EnvVar: "GLIDE_DEBUG_VCS",
+		},
+		cli.BoolFlag{
+			Name:   "no-progress",
+			Usage:  "Do not display the live progress of install and update, such as in CI logs",
+			EnvVar: "GLIDE_NO_PROGRESS",
+		},
 		cli.BoolFlag{
 			Name:  "no-color",
 			Usage: "Turn off colored output for log messages",
 		},
+		cli.DurationFlag{
+			Name:   "timeout",
+			Usage:  "Stop if the command takes longer than this (e.g., 10m). Defaults to the timeouts in config.yaml",
+			EnvVar: "GLIDE_TIMEOUT",
+		},
+		cli.DurationFlag{
+			Name:   "resolve-timeout",
+			Usage:  "Limit how long resolving the dependency tree can take. Defaults to the timeouts in config.yaml",
+			EnvVar: "GLIDE_RESOLVE_TIMEOUT",
+		},
+		cli.DurationFlag{
+			Name:   "fetch-timeout",
+			Usage:  "Limit how long fetching a single repository, including the attempts made again, can take. Defaults to the timeouts in config.yaml",
+			EnvVar: "GLIDE_FETCH_TIMEOUT",
+		},
+		cli.DurationFlag{
+			Name:   "network-timeout",
+			Usage:  "Limit how long each attempt to fetch a repository can take. Defaults to the timeouts in config.yaml",
+			EnvVar: "GLIDE_NETWORK_TIMEOUT",
+		},
+		cli.IntFlag{
+			Name:   "network-retries",
+			Usage:  "How many times to try a failed fetch of a repository again, waiting longer each time. Defaults to the network settings in config.yaml",
+			EnvVar: "GLIDE_NETWORK_RETRIES",
+		},
+		cli.IntFlag{
+			Name:   "host-concurrency",
+			Usage:  "Limit how many repositories are fetched from a single host at once. Defaults to the network settings in config.yaml",
+			EnvVar: "GLIDE_HOST_CONCURRENCY",
+		},
+		cli.DurationFlag{
+			Name:   "export-timeout",
+			Usage:  "Limit how long exporting a single dependency to vendor/ can take. Defaults to the timeouts in config.yaml",
+			EnvVar: "GLIDE_EXPORT_TIMEOUT",
+		},
+		cli.DurationFlag{
+			Name:   "lock-timeout",
+			Usage:  "Limit how long to wait on another Glide process using the cache or project. Defaults to no limit",
+			EnvVar: "GLIDE_LOCK_TIMEOUT",
+		},
+		cli.StringFlag{
+			Name:   "vendor-link",
+			Usage:  "How to place files from the cache in vendor/: auto, reflink, hardlink, or copy",
+			Value:  "auto",
+			EnvVar: "GLIDE_VENDOR_LINK",
+		},
+		cli.BoolFlag{
+			Name:   "shallow",
+			Usage:  "Shallow clone Git dependencies set to a specific tag, branch, or commit id",
+			EnvVar: "GLIDE_SHALLOW",
+		},
+		cli.BoolFlag{
+			Name:   "offline",
+			Usage:  "Use the cached metadata of import paths rather than looking them up over the network",
+			EnvVar: "GLIDE_OFFLINE",
+		},
+		cli.BoolFlag{
+			Name:   "prerelease",
+			Usage:  "Allow pre-release versions to satisfy the version ranges of dependencies",
+			EnvVar: "GLIDE_PRERELEASE",
+		},
+		cli.BoolFlag{
+			Name:   "accept-redirects",
+			Usage:  "Fetch dependencies whose repo moved from the new location without asking, and record it in glide.yaml",
+			EnvVar: "GLIDE_ACCEPT_REDIRECTS",
+		},
+		cli.BoolFlag{
+			Name:   "stats",
+			Usage:  "Print how long each phase took, such as fetching and exporting, and the time and bytes fetched for each dependency",
+			EnvVar: "GLIDE_STATS",
+		},
+		cli.StringFlag{
+			Name:   "stats-file",
+			Usage:  "Write the stats of --stats to this file as JSON",
+			EnvVar: "GLIDE_STATS_FILE",
+		},
 	}
 	app.CommandNotFound = func(c *cli.Context, command string) {
-		// TODO: Set some useful env vars.
 		action.Plugin(command, os.Args)
 	}
 	app.Before = startup
@@ -126,19 +241,46 @@ func commands() []cli.Command {
    glide.yaml file to update imported dependency properties such as the version
    or version range to include.
 
-   To fetch the dependencies you may run 'glide install'.`,
+   To fetch the dependencies you may run 'glide install'.
+
+   The '--from-vendor' flag adopts Glide for a project whose vendor/ directory
+   was populated by hand. The version of each dependency is read from the VCS
+   metadata of its vendored copy or, when there is none, found by comparing the
+   copy to the tags of its repo. A glide.lock file locking the vendored revisions
+   is written along with glide.yaml when all of them are found.
+
+   The '--from-imports-only' flag writes a minimal glide.yaml listing only the
+   packages imported by the code of the project, without versions, to fill in
+   by hand or with later commands. Nothing is imported from other package
+   managers or looked up over the network, and there are no prompts.
+
+   The '--suggest-versions' flag looks up the tags of each dependency left
+   without a version and sets its version to a caret constraint (^x.y.z) on the
+   latest release, so the first update is reproducible.`,
 			Flags: []cli.Flag{
 				cli.BoolFlag{
 					Name:  "skip-import",
 					Usage: "When initializing skip importing from other package managers.",
 				},
+				cli.BoolFlag{
+					Name:  "from-vendor",
+					Usage: "Take the versions of the dependencies from the existing vendor/ directory and write a glide.lock file.",
+				},
 				cli.BoolFlag{
 					Name:  "non-interactive",
 					Usage: "Disable interactive prompts.",
 				},
+				cli.BoolFlag{
+					Name:  "from-imports-only",
+					Usage: "Only list the packages the code imports, without versions, skipping other package managers and network lookups. Implies --non-interactive.",
+				},
+				cli.BoolFlag{
+					Name:  "suggest-versions",
+					Usage: "Look up the releases of each dependency without a version and use a caret constraint on the latest one.",
+				},
 			},
 			Action: func(c *cli.Context) error {
-				action.Create(".", c.Bool("skip-import"), c.Bool("non-interactive"))
+				action.Create(".", c.Bool("skip-import"), c.Bool("non-interactive"), c.Bool("from-vendor"), c.Bool("from-imports-only"), c.Bool("suggest-versions"))
 				return nil
 			},
 		},
@@ -178,12 +320,35 @@ func commands() []cli.Command {
    'Godeps/_workspace' folders after an update (along with undoing any Godep
    import rewriting). Note, The Godeps specific functionality is deprecated and
    will be removed when most Godeps users have migrated to using the vendor
-   folder.`,
+   folder. Only the dependencies that changed since the vendor folder was
+   last stripped are processed. Use '--force' to strip every dependency again.
+
+   The '--test' flag adds the packages to testImport rather than import. The
+   '--constraint', '--group', '--os', and '--arch' flags set the version and
+   the groups and filters of the added packages in glide.yaml.
+
+       $ glide get --test --group dev --constraint ^1.1.0 github.com/stretchr/testify`,
 			Flags: []cli.Flag{
 				cli.BoolFlag{
 					Name:  "test",
 					Usage: "Add test dependencies.",
 				},
+				cli.StringFlag{
+					Name:  "constraint",
+					Usage: "The version or version range of the added packages, unless given as package#version.",
+				},
+				cli.StringFlag{
+					Name:  "group",
+					Usage: "Comma separated list of the groups to put the added packages in.",
+				},
+				cli.StringFlag{
+					Name:  "os",
+					Usage: "Comma separated list of the operating systems to fetch the added packages for.",
+				},
+				cli.StringFlag{
+					Name:  "arch",
+					Usage: "Comma separated list of the architectures to fetch the added packages for.",
+				},
 				cli.BoolFlag{
 					Name:  "insecure",
 					Usage: "Use http:// rather than https:// to retrieve packages.",
@@ -281,7 +446,13 @@ func commands() []cli.Command {
 				inst.ResolveTest = !c.Bool("skip-test")
 				packages := []string(c.Args())
 				insecure := c.Bool("insecure")
-				action.Get(packages, inst, insecure, c.Bool("no-recursive"), c.Bool("strip-vendor"), c.Bool("non-interactive"), c.Bool("test"))
+				settings := &cfg.Dependency{
+					Reference: c.String("constraint"),
+					Groups:    commaList(c.String("group")),
+					Os:        commaList(c.String("os")),
+					Arch:      commaList(c.String("arch")),
+				}
+				action.Get(packages, inst, insecure, c.Bool("no-recursive"), c.Bool("strip-vendor"), c.Bool("non-interactive"), c.Bool("test"), settings)
 				return nil
 			},
 		},
@@ -290,12 +461,21 @@ func commands() []cli.Command {
 			ShortName: "rm",
 			Usage:     "Remove a package from the glide.yaml file, and regenerate the lock file.",
 			Description: `This takes one or more package names, and removes references from the glide.yaml file.
-   This will rebuild the glide lock file re-resolving the depencies.`,
+   This will rebuild the glide lock file re-resolving the depencies.
+
+   With --prune-transitive the lock file is kept instead. The removed packages
+   and the transitive dependencies nothing else imports anymore are dropped
+   from it and deleted from vendor/. Removed packages that are still imported
+   stay locked as transitive dependencies.`,
 			Flags: []cli.Flag{
 				cli.BoolFlag{
 					Name:  "delete,d",
 					Usage: "Also delete from vendor/ any packages that are no longer used.",
 				},
+				cli.BoolFlag{
+					Name:  "prune-transitive",
+					Usage: "Keep the lock file, dropping the removed packages and the transitive dependencies no longer needed from it and vendor/.",
+				},
 			},
 			Action: func(c *cli.Context) error {
 				if len(c.Args()) < 1 {
@@ -310,7 +490,69 @@ func commands() []cli.Command {
 				inst := repo.NewInstaller()
 				inst.Force = c.Bool("force")
 				packages := []string(c.Args())
-				action.Remove(packages, inst)
+				action.Remove(packages, inst, c.Bool("prune-transitive"))
+				return nil
+			},
+		},
+		{
+			Name:      "diff",
+			Usage:     "Show the dependencies changed between two lock files.",
+			ArgsUsage: "[from] [to]",
+			Description: `This lists the dependencies added (+), removed (-), and changed (~)
+   between two lock files, along with their old and new versions.
+
+   Each argument is the path of a lock file or a git revision to read
+   glide.lock from. 'from' defaults to HEAD and 'to' to the glide.lock of the
+   project, so with no arguments the uncommitted changes are shown. For
+   example, to review a branch:
+
+       glide diff master HEAD
+
+   With --log the commits between the old and new version of each changed
+   dependency are listed too, using the copies of the repos in the cache.`,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "log",
+					Usage: "List the commits between the old and new versions.",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				if len(c.Args()) > 2 {
+					msg.Die("At most two lock files or revisions can be compared")
+				}
+				action.Diff(c.Args().Get(0), c.Args().Get(1), c.Bool("log"))
+				return nil
+			},
+		},
+		{
+			Name:  "pin",
+			Usage: "Pin dependencies in glide.yaml to the exact releases locked in glide.lock.",
+			Description: `This sets the version of the named dependencies, or all of them when none
+   are named, to the release glide.lock holds, such as =1.4.1. Dependencies
+   locked to a commit without a release tag are pinned to the commit id.
+
+   Use this to freeze glide.yaml itself, for example before starting a long
+   lived support branch. The repositories need to be in the cache, which
+   'glide install' takes care of.`,
+			Action: func(c *cli.Context) error {
+				action.Pin([]string(c.Args()))
+				return nil
+			},
+		},
+		{
+			Name:  "unpin",
+			Usage: "Turn exact versions in glide.yaml back into ranges.",
+			Description: `This changes exact versions of the named dependencies, or all of them when
+   none are named, into ranges allowing newer compatible releases. =1.4.1
+   becomes ^1.4.1, or ~1.4.1 with --tilde.`,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "tilde",
+					Usage: "Only allow newer patch releases (~1.4.1) rather than newer minor releases (^1.4.1).",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				action.Unpin([]string(c.Args()), c.Bool("tilde"))
 				return nil
 			},
 		},
@@ -377,14 +619,79 @@ func commands() []cli.Command {
 			},
 		},
 		{
-			Name:        "name",
-			Usage:       "Print the name of this project.",
-			Description: `Read the glide.yaml file and print the name given on the 'package' line.`,
+			Name:  "export",
+			Usage: "Export parts of this project for use elsewhere.",
+			Subcommands: []cli.Command{
+				{
+					Name:  "template",
+					Usage: "Export the dependency policy of this project as a base glide.yaml",
+					Description: `Builds a base glide.yaml from the conventions of the current project.
+   This includes the ignore and excludeDirs lists, the overrides, mirrors,
+   policy, groups, standard library aliases, nested setting, and Go version,
+   along with the version constraints, repositories, and VCS types used for
+   each dependency. Details specific to this project, such as its name and
+   commit id pins, are left out. The mirrors in GLIDE_HOME used by the
+   dependencies are added to those of the project.
+
+   The template can be shared with other teams as a starting point for new
+   projects.`,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "output, o",
+							Usage: "Write the template to a file rather than Stdout.",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						action.ExportTemplate(c.String("output"))
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:  "name",
+			Usage: "Print the name of this project.",
+			Description: `Read the glide.yaml file and print the name given on the 'package' line.
+
+   With '--module' the import path detected for the project is printed
+   instead: the module in a go.mod file next to glide.yaml, or else the path
+   the project is placed at below $GOPATH/src.`,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "module",
+					Usage: "Print the import path detected from go.mod or the GOPATH.",
+				},
+			},
 			Action: func(c *cli.Context) error {
+				if c.Bool("module") {
+					action.Module()
+					return nil
+				}
 				action.Name()
 				return nil
 			},
 		},
+		{
+			Name:  "project",
+			Usage: "Print information about this project for tooling",
+			Description: `Prints the name in glide.yaml, the import path detected for the project
+   (see 'glide name --module'), the paths of the project root, glide.yaml,
+   glide.lock, and vendor/, the GOPATH entry holding the project, the version
+   of Go in use, and the version needed to build with vendor/.
+
+       $ glide project -o json`,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "output, o",
+					Usage: "Output format. One of: json|json-pretty|text",
+					Value: "text",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				action.Project(c.String("output"))
+				return nil
+			},
+		},
 		{
 			Name:      "novendor",
 			ShortName: "nv",
@@ -409,6 +716,186 @@ Example:
 				return nil
 			},
 		},
+		goToolCommand("test", "Run 'go test' on the non-vendor packages.", `Runs 'go test' on the packages of the project, leaving out vendor/, with
+   the environment set up for the vendor directory to be used. Packages can
+   be listed to test only those. Flags for 'go test' follow '--'. With a
+   testVendorDir set in glide.yaml the test dependencies in it are linked
+   into vendor/ while the tests run.
+
+Example:
+   $ glide test -- -race -v`),
+		goToolCommand("build", "Run 'go build' on the non-vendor packages.", `Runs 'go build' on the packages of the project, leaving out vendor/, with
+   the environment set up for the vendor directory to be used. Packages can
+   be listed to build only those. Flags for 'go build' follow '--'.
+
+Example:
+   $ glide build ./cmd/... -- -ldflags "-X main.version=1.0"`),
+		goToolCommand("run", "Run 'go run' on the Go files in the current directory.", `Runs 'go run' on the Go files in the current directory, leaving out tests,
+   with the environment set up for the vendor directory to be used. Files
+   can be listed to run only those. Arguments for the program follow '--'.
+
+Example:
+   $ glide run -- --port 8080`),
+		{
+			Name:      "exec",
+			Usage:     "Run a command with the environment of the project.",
+			ArgsUsage: "-- <command> [arguments]",
+			Description: `Runs a command with the environment set up for the vendor directory to be
+   used, as for 'glide test', and the bin/ directory the tools of the project
+   are installed in by 'glide tools install' first on the PATH. Glide exits
+   with the exit code of the command.
+
+Example:
+   $ glide exec -- go generate ./...`,
+			SkipFlagParsing: true,
+			Action: func(c *cli.Context) error {
+				action.Exec(c.Args())
+				return nil
+			},
+		},
+		{
+			Name:  "prune",
+			Usage: "Remove vendored packages that are not used by the project.",
+			Description: `Scans the imports of the project, including its tests, and follows them
+   through the vendor/ directory. The Go source of vendored packages that are
+   never reached is removed along with any directories left empty.
+
+   The '--non-go' flag removes the other files of unused packages as well, and
+   documentation everywhere. Used packages keep the assembly, cgo and other
+   files the go tool builds them from. License and other legal files are
+   always kept.
+
+   Nested vendor/ directories are not pruned. Use '--strip-vendor' when
+   installing to flatten them first.`,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "non-go",
+					Usage: "Also remove files that are not Go source, other than licenses.",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				action.Prune(".", c.Bool("non-go"))
+				return nil
+			},
+		},
+		{
+			Name:  "lock",
+			Usage: "Sign or verify the glide.lock file",
+			Description: `With '--sign' a detached signature of glide.lock is created using GPG
+   (glide.lock.asc) or minisign (glide.lock.minisig). Commit the signature
+   along with the lock file. 'glide install --verify-signature' then refuses to
+   install from a lock file without a valid signature.
+
+   GPG signatures are checked against your keyring and must be made by a key
+   passed to '--trusted-key' or, when there are none, a key the keyring fully
+   trusts. Minisign signatures are
+   checked against the keys passed to '--trusted-key' or, when there are none,
+   the minisign.pub file next to glide.yaml.`,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "sign",
+					Usage: "Sign glide.lock.",
+				},
+				cli.BoolFlag{
+					Name:  "verify",
+					Usage: "Verify the signature of glide.lock.",
+				},
+				cli.StringFlag{
+					Name:  "signer",
+					Value: action.SignGPG,
+					Usage: "The tool used to sign: gpg or minisign.",
+				},
+				cli.StringFlag{
+					Name:  "key",
+					Usage: "The GPG key id or minisign secret key file to sign with. Defaults to the default key of the tool.",
+				},
+				cli.StringSliceFlag{
+					Name:  "trusted-key",
+					Usage: "A GPG fingerprint or minisign public key the lock file must be signed with. Can be repeated.",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				switch {
+				case c.Bool("sign"):
+					action.SignLock(".", c.String("signer"), c.String("key"))
+				case c.Bool("verify"):
+					action.VerifyLockSignature(".", c.StringSlice("trusted-key"))
+				default:
+					cli.ShowCommandHelp(c, c.Command.Name)
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "vendor",
+			Usage: "Rewrite import paths in vendored packages and the project",
+			Description: `With '--relocate' imports of the paths listed under 'relocate' for a
+   dependency in glide.yaml are rewritten to the name of that dependency. This
+   is useful for forks whose code still imports the upstream location:
+
+       import:
+       - package: github.com/example/fork
+         relocate:
+         - github.com/upstream/project
+
+   The vendored packages and the project are rewritten. The changes to the
+   project are recorded in .glide-relocate.yaml and '--restore' reverts them.
+   While that file exists install, update, and get relocate the vendored
+   packages again.`,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "relocate",
+					Usage: "Rewrite imports to the dependencies they are relocated to.",
+				},
+				cli.BoolFlag{
+					Name:  "restore",
+					Usage: "Revert the imports rewritten in the project.",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				switch {
+				case c.Bool("relocate") && c.Bool("restore"):
+					msg.Die("Only one of --relocate and --restore can be used")
+				case c.Bool("relocate"):
+					action.VendorRelocate()
+				case c.Bool("restore"):
+					action.VendorRestore()
+				default:
+					cli.ShowCommandHelp(c, c.Command.Name)
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "patch",
+			Usage: "Keep local changes to vendored dependencies",
+			Description: `Changes made to the vendored copy of a dependency are lost the next time it
+   is installed. 'glide patch capture <package>' compares the vendored copy to the
+   revision locked in glide.lock and stores the differences as a patch in the
+   patches directory next to glide.yaml, as in patches/github.com/foo/bar.patch.
+   Commit the patches along with glide.yaml and glide.lock.
+
+   Install, update, get, and remove apply the patches again after exporting the
+   dependencies. Patches applied already are skipped. When a patch no longer
+   applies, such as after updating the dependency, Glide stops with an error.
+   Fix the vendored copy and capture the patch again.
+
+   Git is used to create and apply the patches.`,
+			Subcommands: []cli.Command{
+				{
+					Name:      "capture",
+					Usage:     "Store the changes to a vendored dependency as a patch",
+					ArgsUsage: "<package>",
+					Action: func(c *cli.Context) error {
+						if len(c.Args()) != 1 {
+							msg.Die("Exactly one package name is required")
+						}
+						action.PatchCapture(c.Args().First())
+						return nil
+					},
+				},
+			},
+		},
 		{
 			Name:  "rebuild",
 			Usage: "Rebuild ('go build') the dependencies",
@@ -429,7 +916,48 @@ Example:
    no lock file (glide.lock) the dependencies are installed using the "update"
    command and a glide.lock file is generated pinning all dependencies. If a
    glide.lock file is already present the dependencies are installed or updated
-   from the lock file.`,
+   from the lock file.
+
+   The '--check' flag verifies glide.lock is up to date with glide.yaml without
+   installing anything. The hash of glide.yaml, the listed dependencies, and
+   their constraints are checked. Glide exits non-zero when the lock file needs
+   to be regenerated, making this useful in CI.
+
+   The '--verify-signature' flag refuses to install unless glide.lock has a
+   valid signature created with 'glide lock --sign'. Use '--trusted-key' to
+   limit the keys the signature may be made with.
+
+   The '--to-gopath' flag installs the locked versions into the src directory
+   of the first GOPATH entry instead of vendor/, for tools that do not
+   understand vendor directories. Packages already there are moved to a backup
+   directory in the Glide home first.
+
+   The '--with' and '--without' flags take comma separated lists of the
+   dependency groups to install and to leave out. Dependencies in the groups
+   listed under 'optionalGroups' in glide.yaml are only installed when asked
+   for with '--with'.
+
+       $ glide install --with dev --without tools
+
+   The '--link' flag places symlinks in vendor/ rather than copies, for fast
+   edit-test cycles on dependencies. A dependency checked out in the GOPATH is
+   linked to that checkout, whatever version it is at. Other dependencies are
+   linked to the export of their locked version in the cache, which is shared
+   by all projects and must not be edited. Run 'glide install --unlink' to
+   replace the symlinks, including those to local replacements, with copies of
+   the locked versions before committing vendor/.
+
+   Dependencies whose 'os' or 'arch' settings do not include the target
+   platform, set by GOOS and GOARCH, are left out of vendor/, along with the
+   dependencies only they require. Use '--all-platforms' to install them anyway.
+
+   The locked dependencies are checked against the policy in glide.yaml, if
+   any, before installing. Use '--policy-warn-only' to report violations
+   without stopping.
+
+   Installing stops when a vendored package has an import comment, such as
+   'package yaml // import "gopkg.in/yaml.v2"', naming another path than the
+   one it is vendored under. Use '--strip-import-comments' to remove them.`,
 			Flags: []cli.Flag{
 				cli.BoolFlag{
 					Name:   "delete",
@@ -478,8 +1006,81 @@ Example:
 					Name:  "skip-test",
 					Usage: "Resolve dependencies in test files.",
 				},
+				cli.BoolFlag{
+					Name:  "prune",
+					Usage: "Remove vendored packages that are not used by the project.",
+				},
+				cli.BoolFlag{
+					Name:  "prune-non-go",
+					Usage: "Like --prune but also removes files that are not Go source, other than licenses.",
+				},
+				cli.BoolFlag{
+					Name:  "check",
+					Usage: "Only check that glide.lock is up to date with glide.yaml. Exits non-zero when it is not.",
+				},
+				cli.BoolFlag{
+					Name:  "verify-signature",
+					Usage: "Refuse to install unless glide.lock has a valid signature. See 'glide lock --sign'.",
+				},
+				cli.StringSliceFlag{
+					Name:  "trusted-key",
+					Usage: "A GPG fingerprint or minisign public key the lock file must be signed with. Can be repeated.",
+				},
+				cli.BoolFlag{
+					Name:  "to-gopath",
+					Usage: "Install the locked versions into the GOPATH instead of vendor/, backing up anything replaced.",
+				},
+				cli.StringFlag{
+					Name:  "with",
+					Usage: "Comma separated list of optional dependency groups to install.",
+				},
+				cli.StringFlag{
+					Name:  "without",
+					Usage: "Comma separated list of dependency groups not to install.",
+				},
+				cli.BoolFlag{
+					Name:  "link",
+					Usage: "Symlink dependencies from the GOPATH or the cache into vendor/ instead of copying them.",
+				},
+				cli.BoolFlag{
+					Name:  "unlink",
+					Usage: "Replace symlinks in vendor/, including those to local replacements, with copies.",
+				},
+				cli.BoolFlag{
+					Name:  "all-platforms",
+					Usage: "Install dependencies whatever their os and arch filters.",
+				},
+				cli.BoolFlag{
+					Name:  "policy-warn-only",
+					Usage: "Report dependencies violating the policy in glide.yaml as warnings instead of failing.",
+				},
+				cli.BoolFlag{
+					Name:  "strip-import-comments",
+					Usage: "Remove import comments naming other paths from vendored packages instead of failing.",
+				},
+				cli.BoolFlag{
+					Name:  "go-version-warn-only",
+					Usage: "Report a Go toolchain outside of the go range in glide.yaml as a warning instead of failing.",
+				},
 			},
 			Action: func(c *cli.Context) error {
+				if c.Bool("check") {
+					action.CheckLock(".")
+					return nil
+				}
+				if c.Bool("link") {
+					switch {
+					case c.Bool("unlink"):
+						msg.Die("The --link and --unlink flags cannot be used together")
+					case c.Bool("to-gopath"):
+						msg.Die("The --link flag cannot be used with --to-gopath")
+					case c.Bool("prune") || c.Bool("prune-non-go"):
+						msg.Die("The --link flag cannot be used with --prune as it would remove files from the linked directories")
+					}
+				}
+				if c.Bool("verify-signature") {
+					action.VerifyLockSignature(".", c.StringSlice("trusted-key"))
+				}
 				if c.Bool("delete") {
 					msg.Warn("The --delete flag is deprecated. This now works by default.")
 				}
@@ -506,8 +1107,23 @@ Example:
 				installer.Force = c.Bool("force")
 				installer.Home = c.GlobalString("home")
 				installer.ResolveTest = !c.Bool("skip-test")
-
-				action.Install(installer, c.Bool("strip-vendor"))
+				installer.With = commaList(c.String("with"))
+				installer.Without = commaList(c.String("without"))
+				installer.Link = c.Bool("link")
+				installer.Unlink = c.Bool("unlink")
+				installer.AllPlatforms = c.Bool("all-platforms")
+				action.PolicyWarnOnly = c.Bool("policy-warn-only")
+				action.StripImportComments = c.Bool("strip-import-comments")
+				action.GoVersionWarnOnly = c.Bool("go-version-warn-only")
+
+				action.Install(installer, c.Bool("strip-vendor"), c.Bool("to-gopath"))
+
+				if c.Bool("to-gopath") {
+					return nil
+				}
+				if c.Bool("prune") || c.Bool("prune-non-go") {
+					action.Prune(".", c.Bool("prune-non-go"))
+				}
 				return nil
 			},
 		},
@@ -535,13 +1151,107 @@ Example:
    'Godeps/_workspace' folders after an update (along with undoing any Godep
    import rewriting). Note, the Godeps specific functionality is deprecated and
    will be removed when most Godeps users have migrated to using the vendor
-   folder.`,
+   folder. Only the dependencies that changed since the vendor folder was
+   last stripped are processed. Use '--force' to strip every dependency again.
+
+   The '--only' flag takes a comma separated list of packages to update. All
+   other dependencies, including transitive ones, are kept at the versions
+   listed in the glide.lock file.
+
+       $ glide update --only github.com/Masterminds/semver,github.com/Masterminds/vcs
+
+   The '--safe' flag keeps every dependency locked to a semantic version at
+   its current major version, even when glide.yaml allows more or sets no
+   version, so routine updates do not pull in breaking releases. The
+   '--allow-major' flag takes a comma separated list of packages that may move
+   to another major version anyway.
+
+       $ glide update --safe --allow-major github.com/Masterminds/vcs
+
+   The '--with' and '--without' flags select the dependency groups placed in
+   vendor/, as they do for install. The lock file still lists every
+   dependency.
+
+   Dependencies for other platforms, going by their 'os' and 'arch' settings,
+   are locked but left out of vendor/ unless '--all-platforms' is set.
+
+   The resolved dependencies are checked against the policy in glide.yaml, if
+   any, before vendor/ and glide.lock are written. Use '--policy-warn-only' to
+   report violations without stopping.
+
+   Updating stops when a vendored package has an import comment naming
+   another path than the one it is vendored under. Use
+   '--strip-import-comments' to remove them.
+
+   The '--pr-body' flag writes a Markdown summary of the update to a file, or
+   to stdout with '-', for the description of a pull request: the changed
+   dependencies with links to their changes, the licenses that changed, and
+   the commits of each.
+
+       $ glide update --pr-body pr.md
+
+   The outcome of resolving the full dependency tree is kept in the cache.
+   While glide.yaml, the imports of the code, and the refs of the Git remotes
+   of the dependencies are unchanged, the next update installs it rather than
+   resolving the tree again. Use '--no-resolution-cache' to always resolve.`,
 			Flags: []cli.Flag{
 				cli.BoolFlag{
 					Name:   "delete",
 					Usage:  "Delete vendor packages not specified in config.",
 					Hidden: true,
 				},
+				cli.StringFlag{
+					Name:  "only",
+					Usage: "Comma separated list of packages to update. Other dependencies stay at their locked versions.",
+				},
+				cli.BoolFlag{
+					Name:  "changelog",
+					Usage: "List the dependencies that changed and the commits between their old and new versions.",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Resolve the dependencies and print the changes without touching vendor/ or glide.lock. Exits with code 8 if anything would change.",
+				},
+				cli.StringFlag{
+					Name:  "pr-body",
+					Usage: "Write a Markdown summary of the changes for a pull request description to this file, or to stdout with '-'.",
+				},
+				cli.BoolFlag{
+					Name:  "safe",
+					Usage: "Keep dependencies at the major version of their locked version.",
+				},
+				cli.StringFlag{
+					Name:  "allow-major",
+					Usage: "Comma separated list of packages that may move to another major version with --safe.",
+				},
+				cli.BoolFlag{
+					Name:  "all-platforms",
+					Usage: "Install dependencies whatever their os and arch filters.",
+				},
+				cli.BoolFlag{
+					Name:  "policy-warn-only",
+					Usage: "Report dependencies violating the policy in glide.yaml as warnings instead of failing.",
+				},
+				cli.BoolFlag{
+					Name:  "strip-import-comments",
+					Usage: "Remove import comments naming other paths from vendored packages instead of failing.",
+				},
+				cli.BoolFlag{
+					Name:  "go-version-warn-only",
+					Usage: "Report a Go toolchain outside of the go range in glide.yaml as a warning instead of failing.",
+				},
+				cli.BoolFlag{
+					Name:  "no-resolution-cache",
+					Usage: "Resolve the dependencies even when nothing changed since they were last resolved.",
+				},
+				cli.StringFlag{
+					Name:  "with",
+					Usage: "Comma separated list of optional dependency groups to install.",
+				},
+				cli.StringFlag{
+					Name:  "without",
+					Usage: "Comma separated list of dependency groups not to install.",
+				},
 				cli.BoolFlag{
 					Name:  "no-recursive, quick",
 					Usage: "Disable updating dependencies' dependencies. Only update things in glide.yaml.",
@@ -596,6 +1306,14 @@ Example:
 					Name:  "skip-test",
 					Usage: "Resolve dependencies in test files.",
 				},
+				cli.BoolFlag{
+					Name:  "prune",
+					Usage: "Remove vendored packages that are not used by the project.",
+				},
+				cli.BoolFlag{
+					Name:  "prune-non-go",
+					Usage: "Like --prune but also removes files that are not Go source, other than licenses.",
+				},
 			},
 			Action: func(c *cli.Context) error {
 				if c.Bool("delete") {
@@ -631,7 +1349,26 @@ Example:
 				installer.Home = c.GlobalString("home")
 				installer.ResolveTest = !c.Bool("skip-test")
 
-				action.Update(installer, c.Bool("no-recursive"), c.Bool("strip-vendor"))
+				installer.With = commaList(c.String("with"))
+				installer.Without = commaList(c.String("without"))
+				installer.AllPlatforms = c.Bool("all-platforms")
+				action.PolicyWarnOnly = c.Bool("policy-warn-only")
+				action.StripImportComments = c.Bool("strip-import-comments")
+				action.GoVersionWarnOnly = c.Bool("go-version-warn-only")
+				action.NoResolutionCache = c.Bool("no-resolution-cache")
+				action.PRBody = c.String("pr-body")
+
+				if c.Bool("safe") {
+					action.LimitMajorVersions(".", commaList(c.String("allow-major")))
+				} else if c.String("allow-major") != "" {
+					msg.Warn("The --allow-major flag has no effect without --safe")
+				}
+
+				action.Update(installer, c.Bool("no-recursive"), c.Bool("strip-vendor"), c.Bool("changelog"), c.Bool("dry-run"), commaList(c.String("only")))
+
+				if !c.Bool("dry-run") && (c.Bool("prune") || c.Bool("prune-non-go")) {
+					action.Prune(".", c.Bool("prune-non-go"))
+				}
 
 				return nil
 			},
@@ -662,8 +1399,29 @@ Example:
    imported.
 
    Directories that begin with . or _ are ignored, as are testdata directories. Packages in
-   vendor are only included if they are used by the project.`,
+   vendor are only included if they are used by the project.
+
+   The '--tree', '--depth', and '--direct-only' flags list dependencies rather than
+   packages. These use the glide.lock file and annotate each dependency with where it
+   comes from: glide.yaml, testImport, import (used by the code but not in glide.yaml),
+   or transitive via another dependency. '--depth' limits how many levels of transitive
+   dependencies are listed and '--direct-only' is the same as '--depth 1'.
+
+   The '--annotations' flag lists the dependencies in glide.yaml along with the
+   annotations recorded for each, such as the owning team or a ticket link.`,
 			Action: func(c *cli.Context) error {
+				depth := c.Int("depth")
+				if c.Bool("annotations") {
+					action.ListAnnotations(c.String("output"))
+					return nil
+				}
+				if c.Bool("direct-only") {
+					depth = 1
+				}
+				if c.Bool("tree") || depth > 0 {
+					action.ListDependencies(".", depth, c.Bool("tree"), c.String("output"))
+					return nil
+				}
 				action.List(".", true, c.String("output"))
 				return nil
 			},
@@ -673,6 +1431,232 @@ Example:
 					Usage: "Output format. One of: json|json-pretty|text",
 					Value: "text",
 				},
+				cli.BoolFlag{
+					Name:  "tree",
+					Usage: "Display the dependencies as a tree.",
+				},
+				cli.IntFlag{
+					Name:  "depth",
+					Usage: "Only list dependencies up to this many levels deep. Direct dependencies are level 1.",
+				},
+				cli.BoolFlag{
+					Name:  "direct-only",
+					Usage: "Only list the direct dependencies of the project.",
+				},
+				cli.BoolFlag{
+					Name:  "annotations",
+					Usage: "List the dependencies in glide.yaml along with their annotations.",
+				},
+			},
+		},
+		{
+			Name:  "check-manifest",
+			Usage: "Compare the imports of the code against glide.yaml.",
+			Description: `Check-manifest scans the code of the project, like 'glide list', and compares
+   the repos it imports against the dependencies in glide.yaml. It reports repos
+   imported directly but missing from import, repos only imported by tests but
+   missing from testImport, and dependencies no code imports. It exits with an
+   error when it finds any.
+
+   Dependencies limited to some platforms with os or arch are never reported as
+   unused. A dependency only listed to pin the version of a transitive dependency
+   is reported as unused; use override for that instead.
+
+   The '--fix' flag updates glide.yaml to match the code: missing repos are added,
+   those imported by the code but listed in testImport are moved to import, and
+   unused dependencies are removed. Run 'glide up' afterwards.`,
+			Action: func(c *cli.Context) error {
+				action.CheckManifest(c.Bool("fix"), c.String("output"))
+				return nil
+			},
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "fix",
+					Usage: "Add the missing dependencies to glide.yaml and remove the unused ones.",
+				},
+				cli.StringFlag{
+					Name:  "output, o",
+					Usage: "Output format. One of: json|json-pretty|text",
+					Value: "text",
+				},
+			},
+		},
+		{
+			Name:  "watch",
+			Usage: "Watch the code for imports missing from glide.yaml and vendor/.",
+			Description: `Watch scans the Go files of the project, like 'glide check-manifest', and
+   keeps looking for changes to them until it is stopped. As soon as the code
+   imports a repo that is neither listed in glide.yaml nor in the vendor
+   directory, a warning is printed, rather than finding out when building.
+
+   The '--get' flag runs 'glide get' for each such repo, adding it to glide.yaml
+   and fetching it into vendor/. Repos only imported by tests are added with
+   '--test'.
+
+       $ glide watch --get`,
+			Action: func(c *cli.Context) error {
+				action.Watch(c.Duration("interval"), c.Bool("get"))
+				return nil
+			},
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "get",
+					Usage: "Run 'glide get' for the imported repos that are missing.",
+				},
+				cli.DurationFlag{
+					Name:  "interval",
+					Usage: "How often to look for changed Go files.",
+					Value: time.Second,
+				},
+			},
+		},
+		{
+			Name:      "why",
+			Usage:     "Explain why a dependency is included and how its version was chosen.",
+			ArgsUsage: "<package>",
+			Description: `Why reads the glide.lock file and lists the packages requiring a dependency:
+   the project, the dependencies importing it, and those asking for it in their
+   glide.yaml file along with the version they ask for. The version that was used
+   is marked, as is any override. It then prints the shortest chain of requirements
+   leading from the project to the dependency.
+
+   This information is recorded in glide.lock by 'glide update'.
+
+       $ glide why github.com/Masterminds/semver`,
+			Action: func(c *cli.Context) error {
+				if len(c.Args()) != 1 {
+					msg.Die("Exactly one package name is required")
+				}
+				action.Why(".", c.Args().First())
+				return nil
+			},
+		},
+		{
+			Name:  "graph",
+			Usage: "Graph prints the resolved dependency graph of this project.",
+			Description: `Graph reads the glide.lock file and scans the project and its vendored
+   dependencies to build a graph of which dependencies import which others.
+   Each dependency is listed once along with its locked version.
+
+   The default output is in the Graphviz DOT language. For example,
+
+       $ glide graph | dot -Tpng -o deps.png
+
+   JSON output is available for use by other tools.`,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "format, f",
+					Usage: "Output format. One of: dot|json|json-pretty",
+					Value: "dot",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				action.Graph(".", c.String("format"))
+				return nil
+			},
+		},
+		{
+			Name:  "sbom",
+			Usage: "Write a software bill of materials for the dependencies in glide.lock",
+			Description: `Lists every dependency pinned in glide.lock with its version, repository,
+   a SHA-256 hash of its files in vendor/, and the license detected from its
+   LICENSE or COPYING file. Run 'glide install' first so the hashes and
+   licenses are available.
+
+   The formats are CycloneDX 1.4 JSON (cyclonedx, the default) and SPDX 2.3
+   JSON (spdx-json). The document is generated from the lock file alone, so
+   the same lock file and vendor directory produce the same document.`,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "format, f",
+					Usage: "Output format. One of: cyclonedx|spdx-json",
+					Value: action.SBOMCycloneDX,
+				},
+				cli.StringFlag{
+					Name:  "output, o",
+					Usage: "Write the SBOM to a file rather than standard output",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				action.SBOM(c.String("format"), c.String("output"), c.App.Version)
+				return nil
+			},
+		},
+		{
+			Name:  "pack",
+			Usage: "Write vendor/ to a reproducible tarball",
+			Description: `Writes the vendor directory to a gzipped tarball, vendor.tar.gz by default,
+   to ship prebuilt dependencies to build environments that cannot fetch them.
+   Entries are sorted and their timestamps and owners left out, so the same
+   vendor directory always produces the same tarball. A manifest, glide-pack.json,
+   lists the SHA-256 hash of every file and the versions from glide.lock.
+
+   Dependencies linked with 'glide install --link' have to be unlinked first.
+   Unpack the tarball with 'glide unpack'.`,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "output, o",
+					Usage: "The file to write the tarball to",
+					Value: "vendor.tar.gz",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				action.Pack(c.String("output"))
+				return nil
+			},
+		},
+		{
+			Name:      "unpack",
+			Usage:     "Replace vendor/ with the content of a tarball written by 'glide pack'",
+			ArgsUsage: "<file>",
+			Description: `Replaces the vendor directory with the one in a tarball written by
+   'glide pack'.
+
+   With '--verify' every entry is checked against the manifest of the tarball,
+   files by their hashes and symlinks by their targets, and the versions it was
+   made from against glide.lock when there is one. Nothing is changed when an
+   entry was altered, added, or removed, or a version differs.
+
+       $ glide unpack --verify vendor.tar.gz`,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "verify",
+					Usage: "Check the content against the manifest and glide.lock before unpacking",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				if len(c.Args()) != 1 {
+					msg.Die("Pass the tarball to unpack")
+				}
+				action.Unpack(c.Args()[0], c.Bool("verify"))
+				return nil
+			},
+		},
+		{
+			Name:  "report",
+			Usage: "Report how fresh and well maintained the dependencies in glide.lock are",
+			Description: `For each dependency in glide.lock, lists how long ago the pinned commit was
+   made, the newest release upstream and how long ago it was made, and whether
+   the repo is archived or unmaintained. A repo is unmaintained when it has had
+   no activity for a year.
+
+   Releases are semantic version tags. Archived status and activity come from the
+   GitHub, GitLab, and Bitbucket APIs and are unknown for other hosts. Dates come
+   from the cache when the repo is there and from the API otherwise. Set
+   GITHUB_TOKEN, GITLAB_TOKEN, or BITBUCKET_TOKEN to avoid the low rate limits of
+   anonymous requests.
+
+       $ glide report`,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "output, o",
+					Usage: "Output format. One of: json|json-pretty|text",
+					Value: "text",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				action.Report(".", c.String("output"))
+				return nil
 			},
 		},
 		{
@@ -683,6 +1667,10 @@ Example:
 					Name:  "format, f",
 					Usage: `Format of the information wanted (required).`,
 				},
+				cli.StringFlag{
+					Name:  "template, t",
+					Usage: "A file holding a template to use as the format",
+				},
 			},
 			Description: `A format containing the text with replacement variables
    has to be passed in. Those variables are:
@@ -708,9 +1696,24 @@ Example:
           prints 'License: MIT'
 
        glide info -f "%n - %d - %h - %l"
-          prints 'foo - Some example description - https://example.com - MIT'`,
+          prints 'foo - Some example description - https://example.com - MIT'
+
+   A format containing '{{' is a Go template instead. Along with .Name,
+   .Description, .Home, and .License it can use the lock file: .Hash,
+   .Updated, and the .Imports, .DevImports, and .Dependencies lists. Each
+   dependency has .Name, .Version, .VcsType, .Repository, .Remote (where it is
+   fetched from), .Subpackages, .Constraint (the version in glide.yaml), and
+   .Test. The 'join' and 'short' (a shortened commit id) functions are
+   available. For example:
+
+       glide info -f '{{range .Dependencies}}{{.Name}} {{short .Version}}
+       {{end}}'
+
+   The '--template' flag reads the template from a file.`,
 			Action: func(c *cli.Context) error {
-				if c.IsSet("format") {
+				if c.IsSet("template") {
+					action.InfoFile(c.String("template"))
+				} else if c.IsSet("format") {
 					action.Info(c.String("format"))
 				} else {
 					cli.ShowCommandHelp(c, c.Command.Name)
@@ -727,6 +1730,269 @@ Example:
 				return nil
 			},
 		},
+		{
+			Name:  "cache-list",
+			Usage: "List the repos in the Glide cache.",
+			Description: `Lists the repos in the Glide cache along with the disk space they use and
+   when they were last used. The least recently used repos are listed first.`,
+			Action: func(c *cli.Context) error {
+				action.CacheList()
+				return nil
+			},
+		},
+		{
+			Name:      "cache-warm",
+			Usage:     "Fetch the dependencies in a lock file into the Glide cache.",
+			ArgsUsage: "[glide.lock]",
+			Description: `Fetches the repos of the dependencies and tools listed in a lock file,
+   glide.lock in the current directory by default, into the Glide cache and
+   checks out their locked revisions there. Nothing is installed into vendor/,
+   so only the lock file is needed. This is meant for building base images
+   for CI, where later installs then only copy from the cache:
+
+       $ glide cache-warm ci/glide.lock
+
+   A glide.yaml file next to the lock file is read for its mirrors when there
+   is one.`,
+			Action: func(c *cli.Context) error {
+				lock := gpath.LockFile
+				if len(c.Args()) > 1 {
+					msg.Die("Only one lock file can be warmed at a time")
+				} else if len(c.Args()) == 1 {
+					lock = c.Args()[0]
+				}
+				installer := repo.NewInstaller()
+				installer.Home = c.GlobalString("home")
+				action.CacheWarm(installer, lock)
+				return nil
+			},
+		},
+		{
+			Name:  "meta",
+			Usage: "Manage the remote metadata cache",
+			Description: `Import paths that are not hosted on a known site, such as gopkg.in or
+   custom domains, are looked up over HTTP to find the go-import meta tag
+   naming the repo. The roots found are cached in GLIDE_HOME and used for a
+   day, or the meta-ttl set in the config.yaml file in GLIDE_HOME:
+
+       cache:
+         meta-ttl: 168h
+
+   With the global '--offline' flag cached roots are used however old they
+   are and nothing is looked up.
+
+   Rewrite rules in config.yaml skip the lookup for known hosts:
+
+       rewrite:
+       - from: k8s.io/*
+         to: github.com/kubernetes/*`,
+			Subcommands: []cli.Command{
+				{
+					Name:  "list",
+					Usage: "List the cached roots of import paths",
+					Action: func(c *cli.Context) error {
+						action.MetaList()
+						return nil
+					},
+				},
+				{
+					Name:      "refresh",
+					Usage:     "Look up the cached import paths again",
+					ArgsUsage: "[path...]",
+					Action: func(c *cli.Context) error {
+						action.MetaRefresh(c.Args())
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:  "hashes",
+			Usage: "Manage the commits tags are known to point at",
+			Description: `The first time any project resolves a tag of a dependency, the commit it
+   points at is recorded in GLIDE_HOME. When a tag later points at another
+   commit, in this project or any other, Glide stops with an error as the tag
+   may have been rewritten upstream. Forget the tag if the change is expected:
+
+       $ glide hashes forget github.com/foo/bar@v1.2.3`,
+			Subcommands: []cli.Command{
+				{
+					Name:  "list",
+					Usage: "List the known commits of tags",
+					Action: func(c *cli.Context) error {
+						action.HashesList()
+						return nil
+					},
+				},
+				{
+					Name:      "forget",
+					Usage:     "Forget the known commits of tags so they are recorded again",
+					ArgsUsage: "<package>[@tag]...",
+					Action: func(c *cli.Context) error {
+						action.HashesForget(c.Args())
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:  "cache-clean",
+			Usage: "Evict old or least recently used repos from the Glide cache.",
+			Description: `Removes repos that have not been used for more than the number of days
+   passed to '--older-than'. Afterwards the least recently used repos are
+   removed until the cache is no larger than '--max-size' (e.g., 500M or 10G).
+
+   Defaults for both can be set in the config.yaml file in GLIDE_HOME:
+
+       cache:
+         max-age: 90
+         max-size: 10G
+
+   When these are set the limits are applied after each install, update, and
+   get as well. Repos used by the running command are never evicted.`,
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "older-than",
+					Usage: "Evict repos not used in this many days.",
+				},
+				cli.StringFlag{
+					Name:  "max-size",
+					Usage: "Evict the least recently used repos until the cache fits this size.",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				action.CacheClean(c.Int("older-than"), c.String("max-size"))
+				return nil
+			},
+		},
+		{
+			Name:      "completion",
+			Usage:     "Output a shell completion script for bash, zsh, or fish",
+			ArgsUsage: "bash|zsh|fish",
+			Description: `Writes a script completing Glide's commands and flags to standard output.
+   Commands that take dependencies, such as 'glide rm', complete the names
+   listed in glide.yaml. To load completions in the current shell:
+
+       $ source <(glide completion bash)
+
+   The script is generated from the commands this version of Glide has, so
+   regenerate it after upgrading.`,
+			Action: func(c *cli.Context) error {
+				if c.Bool("dependencies") {
+					action.CompletionDependencies()
+					return nil
+				}
+				action.Completion(c.Args().First(), completionCommand(c.App))
+				return nil
+			},
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:   "dependencies",
+					Usage:  "List the dependencies in glide.yaml for completion scripts",
+					Hidden: true,
+				},
+			},
+		},
+		{
+			Name:  "serve",
+			Usage: "Run a daemon answering resolve, install, and list requests",
+			Description: `Runs until interrupted, answering JSON-RPC requests on a unix socket. The
+   Glide.Resolve, Glide.Install, and Glide.List methods take the absolute path
+   of a project as 'Dir' along with the 'ResolveTest', 'ResolveAllFiles', and
+   'Force' options. Glide.Resolve writes glide.lock when 'Write' is set.
+
+   The daemon holds the cache lock and does not fetch a repository again for
+   the '--refresh' period after it was fetched, so tools such as editors and
+   CI agents can make many requests without paying for it each time.`,
+			Action: func(c *cli.Context) error {
+				action.Serve(c.String("socket"), c.Duration("refresh"))
+				return nil
+			},
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "socket",
+					Usage: "The unix socket to listen on. Defaults to glide.sock in the Glide home directory",
+				},
+				cli.DurationFlag{
+					Name:  "refresh",
+					Usage: "How long a fetched repository is used before it is fetched again",
+					Value: 5 * time.Minute,
+				},
+			},
+		},
+		{
+			Name:  "validate",
+			Usage: "Strictly check the glide.yaml file for mistakes",
+			Description: `Reports fields glide.yaml does not support, such as a 'verison' typo,
+   values of the wrong type, and unknown VCS types along with the line and
+   column they are on. Deprecated fields, like 'ref', produce warnings.
+
+   The same checks run before commands that change the project, such as
+   install, update, get, and remove, which refuse to run on an invalid file.`,
+			Action: func(c *cli.Context) error {
+				action.Validate()
+				return nil
+			},
+		},
+		{
+			Name:  "doctor",
+			Usage: "Check the environment and project for common problems",
+			Description: `Checks the Go version (including GO15VENDOREXPERIMENT), the GOPATH
+   layout, that the cache in GLIDE_HOME is writable, and, within a project,
+   for dangling symlinks in vendor/, packages in vendor/ but not in glide.lock
+   (and vice versa), and import paths that only differ by case.
+
+   A fix is suggested for each problem found. Glide exits non-zero when there
+   are problems.
+
+   With '--fix-case' one spelling is chosen for each dependency whose name
+   only differs by case from another, preferring the fewest upper case
+   letters. The other spelling is added to 'ignore' and to the 'relocate' list
+   of the chosen one in glide.yaml. Run 'glide up' and 'glide vendor
+   --relocate' afterwards to rewrite the imports using it.`,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "fix-case",
+					Usage: "Use one spelling of dependencies whose names only differ by case.",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				if c.Bool("fix-case") {
+					action.FixCase()
+					return nil
+				}
+				action.Doctor(".")
+				return nil
+			},
+		},
+		{
+			Name:  "status",
+			Usage: "Summarize whether glide.lock and vendor/ are in a healthy state.",
+			Description: `Status reports, in one place, whether the checkout of the project is
+   healthy:
+
+   - whether glide.lock matches glide.yaml, like 'glide install --check'
+   - whether vendor/ holds exactly the packages and versions in glide.lock
+   - the vendored packages whose files changed since the last install or
+     update
+   - how many dependencies have a newer release within their constraints
+
+   Newer releases are found from the tags in the cache, or from the API of
+   GitHub, GitLab, or Bitbucket for repos that are not cached. Glide exits
+   non-zero when there are problems. Pending updates are not problems. Use
+   '--output json' in CI.`,
+			Action: func(c *cli.Context) error {
+				action.Status(c.String("output"))
+				return nil
+			},
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "output, o",
+					Usage: "Output format. One of: json|json-pretty|text",
+					Value: "text",
+				},
+			},
+		},
 		{
 			Name:  "about",
 			Usage: "Learn about Glide",
@@ -735,6 +2001,74 @@ Example:
 				return nil
 			},
 		},
+		{
+			Name:  "tools",
+			Usage: "Manage the tools a project is built with",
+			Description: `Tools are commands, such as linters and code generators, listed under
+   'tools' in glide.yaml with their repo, an optional version, and the
+   subpackages holding the commands. Without subpackages the root of the repo
+   is built.
+
+       tools:
+       - package: github.com/golang/lint
+         subpackages:
+         - golint
+       - package: github.com/golang/protobuf
+         version: ^1.0.0
+         subpackages:
+         - protoc-gen-go
+
+   'glide update' pins them in glide.lock. Use 'install' to build them into the
+   bin directory of the project at the pinned versions:
+
+       glide tools install`,
+			Subcommands: []cli.Command{
+				{
+					Name:  "install",
+					Usage: "Build the tools into the bin directory at the versions in glide.lock",
+					Action: func(c *cli.Context) error {
+						action.ToolsInstall(".")
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:  "plugin",
+			Usage: "List and create Glide plugins",
+			Description: `Commands Glide does not know are run by plugins: executables named
+   glide-<command> on the PATH or in the current directory. See
+   https://glide.sh/docs/plugins for what Glide passes to them.`,
+			Subcommands: []cli.Command{
+				{
+					Name:  "list",
+					Usage: "List the plugins on the PATH and in the current directory",
+					Action: func(c *cli.Context) error {
+						action.PluginList()
+						return nil
+					},
+				},
+				{
+					Name:      "init",
+					Usage:     "Create a plugin in the current directory",
+					ArgsUsage: "<name>",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "lang",
+							Value: "sh",
+							Usage: "The language of the plugin: sh or go.",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						if len(c.Args()) != 1 {
+							msg.Die("Exactly one plugin name is required")
+						}
+						action.PluginInit(c.Args().First(), c.String("lang"))
+						return nil
+					},
+				},
+			},
+		},
 		{
 			Name:  "mirror",
 			Usage: "Manage mirrors",
@@ -830,17 +2164,112 @@ func startup(c *cli.Context) error {
 	action.Debug(c.Bool("debug"))
 	action.NoColor(c.Bool("no-color"))
 	action.Quiet(c.Bool("quiet"))
+	action.Logging(c.String("log-level"), c.String("log-format"), c.String("log-file"))
+	action.ErrorFormat(c.String("error-format"))
+	action.DebugVCS(c.Bool("debug-vcs"))
+	action.Progress(!c.Bool("no-progress"))
 	action.Init(c.String("yaml"), c.String("home"))
-	action.EnsureGoVendor()
+	action.Credentials()
+	action.SetVendorDir()
+	action.SetVersion(c.App.Version)
+	hooks.GlideVersion = c.App.Version
+	// The doctor reports problems with the Go toolchain rather than exiting.
+	if c.Args().First() != "doctor" {
+		action.EnsureGoVendor()
+	}
+	action.Timeouts(c.Duration("timeout"), c.Duration("resolve-timeout"), c.Duration("fetch-timeout"), c.Duration("network-timeout"), c.Duration("export-timeout"))
+	action.Network(c.Int("network-retries"), c.Int("host-concurrency"))
+	action.VendorLink(c.String("vendor-link"))
+	action.Stats(c.Bool("stats"), c.String("stats-file"))
+	repo.Shallow = c.Bool("shallow")
+	repo.Prerelease = c.Bool("prerelease")
+	repo.AcceptRedirects = c.Bool("accept-redirects")
+	util.Offline = c.Bool("offline")
+	cache.LockTimeout = c.Duration("lock-timeout")
+	msg.Default.OnDie = func() {
+		hooks.Stop()
+		cache.ReleaseLocks()
+		action.StopDebugVCS()
+		action.PrintStats()
+	}
 	gpath.Tmp = c.String("tmp")
 	return nil
 }
 
 func shutdown(c *cli.Context) error {
-	cache.SystemUnlock()
+	msg.EndProgress()
+	hooks.Stop()
+	cache.ReleaseLocks()
+	action.StopDebugVCS()
+	action.PrintStats()
 	return nil
 }
 
+// goToolCommand returns a command running the go command of the same name.
+// Its arguments are passed on as they are, including flags after "--".
+func goToolCommand(name, usage, description string) cli.Command {
+	return cli.Command{
+		Name:            name,
+		Usage:           usage,
+		Description:     description,
+		SkipFlagParsing: true,
+		Action: func(c *cli.Context) error {
+			action.GoTool(name, c.Args())
+			return nil
+		},
+	}
+}
+
+// completionCommand describes the commands and flags of app for generating
+// shell completions.
+func completionCommand(app *cli.App) *action.CompletionCommand {
+	root := &action.CompletionCommand{
+		Names: []string{app.Name},
+		Flags: completionFlags(app.VisibleFlags()),
+	}
+	for _, c := range app.VisibleCommands() {
+		cc := completionSubcommand(c)
+		cc.Dependencies = dependencyCommands[c.Name]
+		root.Subcommands = append(root.Subcommands, cc)
+	}
+	return root
+}
+
+// dependencyCommands take the names of dependencies as arguments.
+var dependencyCommands = map[string]bool{
+	"remove": true,
+	"update": true,
+	"pin":    true,
+	"unpin":  true,
+	"why":    true,
+}
+
+func completionSubcommand(c cli.Command) *action.CompletionCommand {
+	cc := &action.CompletionCommand{
+		Names: c.Names(),
+		Usage: c.Usage,
+		Flags: completionFlags(c.VisibleFlags()),
+	}
+	for _, s := range c.Subcommands {
+		if !s.Hidden {
+			cc.Subcommands = append(cc.Subcommands, completionSubcommand(s))
+		}
+	}
+	return cc
+}
+
+func completionFlags(flags []cli.Flag) []string {
+	var names []string
+	for _, f := range flags {
+		for _, n := range strings.Split(f.GetName(), ",") {
+			if n = strings.TrimSpace(n); n != "" {
+				names = append(names, n)
+			}
+		}
+	}
+	return names
+}
+
 // Get the path to the glide.yaml file.
 //
 // This returns the name of the path, even if the file does not exist. The value
@@ -859,3 +2288,14 @@ func glidefile(c *cli.Context) string {
 	}
 	return a
 }
+
+// commaList splits a comma separated flag value, dropping empty entries.
+func commaList(s string) []string {
+	var list []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			list = append(list, p)
+		}
+	}
+	return list
+}
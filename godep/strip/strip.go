@@ -19,16 +19,17 @@ import (
 	"github.com/Masterminds/glide/msg"
 )
 
-var godepMark = map[string]bool{}
-
-var vPath = "vendor"
-
 // GodepWorkspace removes any Godeps/_workspace directories and makes sure
 // any rewrites are undone.
-// Note, this is not concuccency safe.
 func GodepWorkspace(v string) error {
-	vPath = v
-	if _, err := os.Stat(vPath); err != nil {
+	return GodepWorkspaceWith(v, msg.Info)
+}
+
+// GodepWorkspaceWith is GodepWorkspace reporting what it removes and rewrites
+// with info rather than msg.Info. It can be called for several directories at
+// once.
+func GodepWorkspaceWith(v string, info func(string, ...interface{})) error {
+	if _, err := os.Stat(v); err != nil {
 		if os.IsNotExist(err) {
 			msg.Debug("Vendor directory does not exist.")
 		}
@@ -36,14 +37,15 @@ func GodepWorkspace(v string) error {
 		return err
 	}
 
-	err := filepath.Walk(vPath, stripGodepWorkspaceHandler)
+	marks := make(map[string]bool)
+	err := filepath.Walk(v, stripGodepWorkspaceHandler(v, marks, info))
 	if err != nil {
 		return err
 	}
 
 	// Walk the marked projects to make sure rewrites are undone.
-	for k := range godepMark {
-		msg.Info("Removing Godep rewrites for %s", k)
+	for k := range marks {
+		info("Removing Godep rewrites for %s", k)
 		err := filepath.Walk(k, rewriteGodepfilesHandler)
 		if err != nil {
 			return err
@@ -53,34 +55,39 @@ func GodepWorkspace(v string) error {
 	return nil
 }
 
-func stripGodepWorkspaceHandler(path string, info os.FileInfo, err error) error {
-	// Skip the base vendor directory
-	if path == vPath {
-		return nil
-	}
+// stripGodepWorkspaceHandler returns a walk function removing the
+// Godeps/_workspace directories below v and marking the projects holding them
+// in marks.
+func stripGodepWorkspaceHandler(v string, marks map[string]bool, info func(string, ...interface{})) filepath.WalkFunc {
+	return func(path string, fi os.FileInfo, err error) error {
+		// Skip the base vendor directory
+		if path == v {
+			return nil
+		}
 
-	name := info.Name()
-	p := filepath.Dir(path)
-	pn := filepath.Base(p)
-	if name == "_workspace" && pn == "Godeps" {
-		if _, err := os.Stat(path); err == nil {
-			if info.IsDir() {
-				// Marking this location to make sure rewrites are undone.
-				pp := filepath.Dir(p)
-				godepMark[pp] = true
-
-				msg.Info("Removing: %s", path)
-				if err := os.RemoveAll(path); err != nil {
-					return err
+		name := fi.Name()
+		p := filepath.Dir(path)
+		pn := filepath.Base(p)
+		if name == "_workspace" && pn == "Godeps" {
+			if _, err := os.Stat(path); err == nil {
+				if fi.IsDir() {
+					// Marking this location to make sure rewrites are undone.
+					pp := filepath.Dir(p)
+					marks[pp] = true
+
+					info("Removing: %s", path)
+					if err := os.RemoveAll(path); err != nil {
+						return err
+					}
+					return filepath.SkipDir
 				}
-				return filepath.SkipDir
-			}
 
-			msg.Debug("%s is not a directory. Skipping removal", path)
-			return nil
+				msg.Debug("%s is not a directory. Skipping removal", path)
+				return nil
+			}
 		}
+		return nil
 	}
-	return nil
 }
 
 func rewriteGodepfilesHandler(path string, info os.FileInfo, err error) error {
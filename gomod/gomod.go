@@ -0,0 +1,127 @@
+// Package gomod reads the requirements in the go.mod files of Go modules.
+//
+// Only the require directives are used. Replace and exclude directives only
+// apply to the main module, so those of a dependency are ignored like the go
+// tool does.
+package gomod
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/glide/util"
+	"github.com/Masterminds/semver"
+)
+
+// Has returns true if this dir has a go.mod file.
+func Has(dir string) bool {
+	path := filepath.Join(dir, "go.mod")
+	fi, err := os.Stat(path)
+	return err == nil && !fi.IsDir()
+}
+
+// Parse parses a go.mod file.
+//
+// A required version is the minimum version of the module, so it becomes a
+// range up to the next major version. Pseudo-versions are pinned to the
+// commit they name.
+func Parse(dir string) ([]*cfg.Dependency, error) {
+	path := filepath.Join(dir, "go.mod")
+	if fi, err := os.Stat(path); err != nil || fi.IsDir() {
+		return []*cfg.Dependency{}, nil
+	}
+
+	msg.Info("Found go.mod file in %s", gpath.StripBasepath(dir))
+	msg.Info("--> Parsing go.mod metadata...")
+
+	file, err := os.Open(path)
+	if err != nil {
+		return []*cfg.Dependency{}, err
+	}
+	defer file.Close()
+
+	reqs, err := parseRequires(bufio.NewScanner(file))
+	if err != nil {
+		return []*cfg.Dependency{}, err
+	}
+
+	buf := []*cfg.Dependency{}
+	seen := make(map[string]bool, len(reqs))
+	for _, r := range reqs {
+		pkg, _ := util.NormalizeName(r[0])
+		if seen[pkg] {
+			continue
+		}
+		seen[pkg] = true
+		buf = append(buf, &cfg.Dependency{
+			Name:      pkg,
+			Reference: constraint(r[1]),
+		})
+	}
+
+	return buf, nil
+}
+
+// parseRequires returns the module paths and versions in the require
+// directives, both the single line and block forms.
+func parseRequires(scanner *bufio.Scanner) ([][2]string, error) {
+	var reqs [][2]string
+	var block string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if block != "" {
+			if fields[0] == ")" {
+				block = ""
+			} else if block == "require" && len(fields) >= 2 {
+				reqs = append(reqs, [2]string{unquote(fields[0]), unquote(fields[1])})
+			}
+			continue
+		}
+		if len(fields) == 2 && fields[1] == "(" {
+			block = fields[0]
+			continue
+		}
+		if fields[0] == "require" && len(fields) >= 3 {
+			reqs = append(reqs, [2]string{unquote(fields[1]), unquote(fields[2])})
+		}
+	}
+	return reqs, scanner.Err()
+}
+
+// pseudoRe matches pseudo-versions, such as v0.0.0-20170915032832-14c0d48ead0c,
+// capturing the commit.
+var pseudoRe = regexp.MustCompile(`^v[0-9]+\.[0-9]+\.[0-9]+-(?:[0-9A-Za-z.]+\.)?(?:0\.)?[0-9]{14}-([0-9a-f]{12,})(?:\+incompatible)?$`)
+
+// constraint converts the minimum version of a module into a Glide version.
+func constraint(v string) string {
+	if m := pseudoRe.FindStringSubmatch(v); m != nil {
+		return m[1]
+	}
+	v = strings.TrimSuffix(v, "+incompatible")
+	sv, err := semver.NewVersion(v)
+	if err != nil {
+		return v
+	}
+	if sv.Major() == 0 {
+		return ">=" + sv.String() + ", <1.0.0"
+	}
+	return "^" + sv.String()
+}
+
+func unquote(s string) string {
+	return strings.Trim(s, "\"`")
+}
@@ -0,0 +1,59 @@
+package gomod
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRequires(t *testing.T) {
+	mod := `module github.com/example/app
+
+go 1.12
+
+require github.com/pkg/errors v0.8.1
+
+require (
+	github.com/Masterminds/semver v1.4.2
+	golang.org/x/sys v0.0.0-20190412213103-97732733099d // indirect
+	"github.com/quoted/mod" v1.0.0
+)
+
+replace github.com/pkg/errors => github.com/fork/errors v0.9.0
+
+exclude (
+	github.com/Masterminds/semver v1.4.0
+)
+`
+	reqs, err := parseRequires(bufio.NewScanner(strings.NewReader(mod)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][2]string{
+		{"github.com/pkg/errors", "v0.8.1"},
+		{"github.com/Masterminds/semver", "v1.4.2"},
+		{"golang.org/x/sys", "v0.0.0-20190412213103-97732733099d"},
+		{"github.com/quoted/mod", "v1.0.0"},
+	}
+	if !reflect.DeepEqual(reqs, expected) {
+		t.Errorf("Expected %v, got %v", expected, reqs)
+	}
+}
+
+func TestConstraint(t *testing.T) {
+	tests := map[string]string{
+		"v1.4.2":                                    "^1.4.2",
+		"v0.8.1":                                    ">=0.8.1, <1.0.0",
+		"v2.1.0+incompatible":                       "^2.1.0",
+		"v0.0.0-20190412213103-97732733099d":        "97732733099d",
+		"v1.2.4-0.20191109021931-daa7c04131f5":      "daa7c04131f5",
+		"v1.3.0-rc.1.0.20191109021931-daa7c04131f5": "daa7c04131f5",
+		"master": "master",
+	}
+	for v, e := range tests {
+		if c := constraint(v); c != e {
+			t.Errorf("Expected %s to become %s, got %s", v, e, c)
+		}
+	}
+}
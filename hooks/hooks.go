@@ -0,0 +1,384 @@
+// Package hooks lets external programs take part in resolving dependencies,
+// such as to enforce the policies of an organization.
+//
+// Resolver hooks are programs listed in the config.yaml file in GLIDE_HOME:
+//
+//     hooks:
+//     - name: policy
+//       command: /usr/local/bin/glide-policy
+//       args: ["--strict"]
+//
+// Each is started once, the first time Glide needs it, and is spoken to with
+// JSON-RPC 1.0 over its stdin and stdout for the rest of the run. Its stderr is
+// passed through. Right after starting, Glide calls Hooks.Register and the
+// program answers with the hooks it provides:
+//
+//   - Hooks.FilterVersions: narrows the tags and branches a version of a
+//     dependency may be picked from.
+//   - Hooks.Veto: refuses the version picked for a dependency, with a reason.
+//   - Hooks.RewriteRepo: replaces the repo, or VCS type, a dependency is
+//     fetched from.
+//
+// Programs are asked in the order they are listed. Any failure to talk to a
+// program stops Glide, so a broken policy is never silently skipped. Go
+// programs can use Server to implement the protocol.
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// APIVersion is the version of the protocol spoken with hook programs.
+const APIVersion = 1
+
+// The names of the hooks a program can provide.
+const (
+	FilterVersionsHook = "filter-versions"
+	VetoHook           = "veto"
+	RewriteRepoHook    = "rewrite-repo"
+)
+
+// Timeout is how long a hook program may take to answer a call.
+var Timeout = 30 * time.Second
+
+// Spec is a hook program as listed in config.yaml.
+type Spec struct {
+	Name    string   `yaml:"name"`
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args,omitempty"`
+}
+
+// RegisterArgs is passed to Hooks.Register.
+type RegisterArgs struct {
+	API          int    `json:"api"`
+	GlideVersion string `json:"glideVersion"`
+}
+
+// RegisterReply lists the hooks a program provides.
+type RegisterReply struct {
+	Hooks []string `json:"hooks"`
+}
+
+// VersionsArgs is passed to Hooks.FilterVersions with the tags and branches
+// of the repo of a dependency.
+type VersionsArgs struct {
+	Name       string   `json:"name"`
+	Repository string   `json:"repo"`
+	Versions   []string `json:"versions"`
+}
+
+// VersionsReply holds the versions that may be used.
+type VersionsReply struct {
+	Versions []string `json:"versions"`
+}
+
+// VetoArgs is passed to Hooks.Veto with the version picked for a dependency.
+// Version is the reference it was picked by, which is empty when the tip of
+// the default branch is used, and Commit is what it resolved to.
+type VetoArgs struct {
+	Name       string `json:"name"`
+	Repository string `json:"repo"`
+	Version    string `json:"version"`
+	Commit     string `json:"commit"`
+}
+
+// VetoReply refuses the version when Veto is set.
+type VetoReply struct {
+	Veto   bool   `json:"veto"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// RepoArgs is passed to Hooks.RewriteRepo with the repo a dependency would be
+// fetched from.
+type RepoArgs struct {
+	Name       string `json:"name"`
+	Repository string `json:"repo"`
+	VcsType    string `json:"vcs,omitempty"`
+}
+
+// RepoReply holds the repo and VCS type to use instead. Empty fields leave
+// them as they are.
+type RepoReply struct {
+	Repository string `json:"repo,omitempty"`
+	VcsType    string `json:"vcs,omitempty"`
+}
+
+// VetoError is returned when a hook refuses the version of a dependency.
+type VetoError struct {
+	Hook    string
+	Name    string
+	Version string
+	Reason  string
+}
+
+func (e *VetoError) Error() string {
+	v := e.Version
+	if v == "" {
+		v = "the default branch"
+	}
+	return fmt.Sprintf("The %s hook refused %s of %s: %s", e.Hook, v, e.Name, e.Reason)
+}
+
+// GlideVersion is passed to hook programs when they register.
+var GlideVersion string
+
+// hook is a running hook program.
+type hook struct {
+	name     string
+	client   *rpc.Client
+	cmd      *exec.Cmd
+	provides map[string]bool
+}
+
+var (
+	startOnce sync.Once
+	running   []*hook
+	startErr  error
+
+	repoMutex sync.Mutex
+	repoCache = make(map[string][2]string)
+)
+
+// active returns the running hook programs, starting them on first use.
+func active() ([]*hook, error) {
+	startOnce.Do(func() {
+		specs, err := readSpecs()
+		if err != nil {
+			startErr = fmt.Errorf("Unable to read the resolver hooks: %s", err)
+			return
+		}
+		for _, s := range specs {
+			h, err := start(s)
+			if err != nil {
+				startErr = fmt.Errorf("Unable to start the %s resolver hook: %s", s.Name, err)
+				return
+			}
+			running = append(running, h)
+		}
+	})
+	return running, startErr
+}
+
+// Configured returns true when config.yaml lists resolver hooks, or cannot be
+// read to tell.
+func Configured() bool {
+	specs, err := readSpecs()
+	return err != nil || len(specs) > 0
+}
+
+// readSpecs reads the hooks section of the config.yaml file.
+func readSpecs() ([]Spec, error) {
+	var specs []Spec
+	if err := gpath.GlobalConfig("hooks", &specs); err != nil {
+		return nil, err
+	}
+	for i, s := range specs {
+		if s.Command == "" {
+			return nil, fmt.Errorf("hook %d in config.yaml has no command", i+1)
+		}
+		if s.Name == "" {
+			specs[i].Name = filepath.Base(s.Command)
+		}
+	}
+	return specs, nil
+}
+
+// start runs a hook program and registers it.
+func start(s Spec) (*hook, error) {
+	cmd := exec.Command(s.Command, s.Args...)
+	cmd.Stderr = os.Stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	h, err := register(s.Name, &stdio{out, in})
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, err
+	}
+	h.cmd = cmd
+	return h, nil
+}
+
+// register sets up the hook program at the other end of conn.
+func register(name string, conn io.ReadWriteCloser) (*hook, error) {
+	h := &hook{name: name, client: jsonrpc.NewClient(conn), provides: make(map[string]bool)}
+	reply := &RegisterReply{}
+	if err := h.call("Hooks.Register", &RegisterArgs{API: APIVersion, GlideVersion: GlideVersion}, reply); err != nil {
+		h.client.Close()
+		return nil, err
+	}
+	for _, p := range reply.Hooks {
+		h.provides[p] = true
+	}
+	msg.Debug("Resolver hook %s provides %s", name, strings.Join(reply.Hooks, ", "))
+	return h, nil
+}
+
+// call calls a method of the hook program, giving up after Timeout.
+func (h *hook) call(method string, args, reply interface{}) error {
+	select {
+	case c := <-h.client.Go(method, args, reply, make(chan *rpc.Call, 1)).Done:
+		return c.Error
+	case <-time.After(Timeout):
+		return errors.New("timed out waiting for an answer")
+	}
+}
+
+// FilterVersions returns the versions, tags and branches, of a dependency that
+// the hooks allow picking from.
+func FilterVersions(name, repo string, versions []string) ([]string, error) {
+	hs, err := active()
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range hs {
+		if !h.provides[FilterVersionsHook] {
+			continue
+		}
+		reply := &VersionsReply{}
+		if err := h.call("Hooks.FilterVersions", &VersionsArgs{Name: name, Repository: repo, Versions: versions}, reply); err != nil {
+			return nil, fmt.Errorf("The %s resolver hook failed to filter the versions of %s: %s", h.name, name, err)
+		}
+		if len(reply.Versions) < len(versions) {
+			msg.Debug("The %s hook left %d of %d versions of %s", h.name, len(reply.Versions), len(versions), name)
+		}
+		versions = reply.Versions
+	}
+	return versions, nil
+}
+
+// Veto returns a *VetoError when a hook refuses the version picked for a
+// dependency, or another error when a hook could not be asked.
+func Veto(name, repo, version, commit string) error {
+	hs, err := active()
+	if err != nil {
+		return err
+	}
+	for _, h := range hs {
+		if !h.provides[VetoHook] {
+			continue
+		}
+		reply := &VetoReply{}
+		if err := h.call("Hooks.Veto", &VetoArgs{Name: name, Repository: repo, Version: version, Commit: commit}, reply); err != nil {
+			return fmt.Errorf("The %s resolver hook failed to check %s: %s", h.name, name, err)
+		}
+		if reply.Veto {
+			return &VetoError{Hook: h.name, Name: name, Version: version, Reason: reply.Reason}
+		}
+	}
+	return nil
+}
+
+// RewriteRepo asks the hooks for the repo and VCS type a dependency is fetched
+// from and returns them. Answers are kept for the rest of the run, where
+// Rewritten looks them up.
+func RewriteRepo(name, repo, vcsType string) (string, string, error) {
+	hs, err := active()
+	if err != nil {
+		return "", "", err
+	}
+	if len(hs) == 0 {
+		return repo, vcsType, nil
+	}
+	key := name + "\x00" + repo + "\x00" + vcsType
+	repoMutex.Lock()
+	r, ok := repoCache[key]
+	repoMutex.Unlock()
+	if ok {
+		return r[0], r[1], nil
+	}
+
+	for _, h := range hs {
+		if !h.provides[RewriteRepoHook] {
+			continue
+		}
+		reply := &RepoReply{}
+		if err := h.call("Hooks.RewriteRepo", &RepoArgs{Name: name, Repository: repo, VcsType: vcsType}, reply); err != nil {
+			return "", "", fmt.Errorf("The %s resolver hook failed to rewrite the repo of %s: %s", h.name, name, err)
+		}
+		if reply.Repository != "" && reply.Repository != repo {
+			msg.Debug("The %s hook rewrote the repo of %s to %s", h.name, name, reply.Repository)
+			repo = reply.Repository
+		}
+		if reply.VcsType != "" {
+			vcsType = reply.VcsType
+		}
+	}
+	repoMutex.Lock()
+	repoCache[key] = [2]string{repo, vcsType}
+	repoMutex.Unlock()
+	return repo, vcsType, nil
+}
+
+// Rewritten returns the repo and VCS type RewriteRepo answered for a
+// dependency, or those passed in when it was not asked about them. It never
+// starts the hook programs.
+func Rewritten(name, repo, vcsType string) (string, string) {
+	repoMutex.Lock()
+	r, ok := repoCache[name+"\x00"+repo+"\x00"+vcsType]
+	repoMutex.Unlock()
+	if ok {
+		return r[0], r[1]
+	}
+	return repo, vcsType
+}
+
+// Stop ends the hook programs, killing those that do not exit within a few
+// seconds of their stdin being closed.
+func Stop() {
+	for _, h := range running {
+		h.client.Close()
+		if h.cmd == nil {
+			continue
+		}
+		done := make(chan error, 1)
+		go func(cmd *exec.Cmd) { done <- cmd.Wait() }(h.cmd)
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			h.cmd.Process.Kill()
+			<-done
+		}
+	}
+	running = nil
+}
+
+// stdio joins the stdout and stdin of a hook program into one connection.
+type stdio struct {
+	io.ReadCloser
+	w io.WriteCloser
+}
+
+func (s *stdio) Write(p []byte) (int, error) {
+	return s.w.Write(p)
+}
+
+func (s *stdio) Close() error {
+	err := s.w.Close()
+	if rerr := s.ReadCloser.Close(); err == nil {
+		err = rerr
+	}
+	return err
+}
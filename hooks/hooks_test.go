@@ -0,0 +1,97 @@
+package hooks
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// serve registers a hook program served in-process by s as the only running
+// hook.
+func serve(t *testing.T, s *Server) func() {
+	client, server := net.Pipe()
+	go s.Serve(server)
+	h, err := register("test", client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	startOnce.Do(func() {})
+	running = []*hook{h}
+	return func() {
+		Stop()
+		startOnce = sync.Once{}
+		repoCache = make(map[string][2]string)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	calls := 0
+	defer serve(t, &Server{
+		FilterVersions: func(name, repo string, versions []string) ([]string, error) {
+			var keep []string
+			for _, v := range versions {
+				if !strings.HasSuffix(v, "-beta") {
+					keep = append(keep, v)
+				}
+			}
+			return keep, nil
+		},
+		Veto: func(name, repo, version, commit string) (string, error) {
+			if strings.HasPrefix(name, "bad.example.com/") {
+				return "not approved", nil
+			}
+			return "", nil
+		},
+		RewriteRepo: func(name, repo, vcsType string) (string, string, error) {
+			calls++
+			return strings.Replace(repo, "https://github.com/", "https://git.example.com/", 1), "", nil
+		},
+	})()
+
+	v, err := FilterVersions("example.com/foo", "https://example.com/foo", []string{"v1.0.0", "v1.1.0-beta", "master"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(v, ",") != "v1.0.0,master" {
+		t.Errorf("Unexpected filtered versions %v", v)
+	}
+
+	if err := Veto("example.com/foo", "", "v1.0.0", "abc"); err != nil {
+		t.Errorf("Unexpected veto %s", err)
+	}
+	err = Veto("bad.example.com/foo", "", "", "abc")
+	if e, ok := err.(*VetoError); !ok || e.Reason != "not approved" || e.Hook != "test" {
+		t.Errorf("Expected a veto, got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		r, vt, err := RewriteRepo("github.com/foo/bar", "https://github.com/foo/bar", "git")
+		if err != nil || r != "https://git.example.com/foo/bar" || vt != "git" {
+			t.Errorf("Unexpected rewrite to %s (%s): %v", r, vt, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected the rewrite to be asked for once, got %d calls", calls)
+	}
+	if r, _ := Rewritten("github.com/foo/bar", "https://github.com/foo/bar", "git"); r != "https://git.example.com/foo/bar" {
+		t.Errorf("Expected the rewrite to be kept, got %s", r)
+	}
+	if r, _ := Rewritten("github.com/foo/baz", "https://github.com/foo/baz", "git"); r != "https://github.com/foo/baz" || calls != 1 {
+		t.Errorf("Expected a repo not asked about to be left alone, got %s after %d calls", r, calls)
+	}
+}
+
+func TestHooksNotProvided(t *testing.T) {
+	defer serve(t, &Server{})()
+
+	if v, err := FilterVersions("example.com/foo", "", []string{"v1.0.0"}); err != nil || len(v) != 1 {
+		t.Errorf("Expected the versions to be kept, got %v (%v)", v, err)
+	}
+	if err := Veto("example.com/foo", "", "v1.0.0", "abc"); err != nil {
+		t.Errorf("Unexpected veto %s", err)
+	}
+	if r, _, err := RewriteRepo("example.com/foo", "https://example.com/foo", ""); err != nil || r != "https://example.com/foo" {
+		t.Errorf("Unexpected rewrite to %s (%v)", r, err)
+	}
+}
@@ -0,0 +1,75 @@
+package hooks
+
+import (
+	"io"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+)
+
+// Server implements the hook protocol for Go programs. The hooks left nil
+// are not provided.
+type Server struct {
+	// FilterVersions returns the versions of a dependency that may be used.
+	FilterVersions func(name, repo string, versions []string) ([]string, error)
+
+	// Veto returns a reason to refuse the version of a dependency, or an
+	// empty string to allow it.
+	Veto func(name, repo, version, commit string) (string, error)
+
+	// RewriteRepo returns the repo and VCS type to use for a dependency.
+	RewriteRepo func(name, repo, vcsType string) (string, string, error)
+}
+
+// Serve answers calls from Glide on conn until it is closed.
+func (s *Server) Serve(conn io.ReadWriteCloser) error {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("Hooks", &service{s}); err != nil {
+		return err
+	}
+	srv.ServeCodec(jsonrpc.NewServerCodec(conn))
+	return nil
+}
+
+// ServeStdio answers calls from Glide on stdin and stdout, as a hook program
+// listed in config.yaml does.
+func (s *Server) ServeStdio() error {
+	return s.Serve(&stdio{os.Stdin, os.Stdout})
+}
+
+// service is registered with the RPC server on behalf of a Server.
+type service struct {
+	s *Server
+}
+
+func (v *service) Register(args *RegisterArgs, reply *RegisterReply) error {
+	reply.Hooks = []string{}
+	if v.s.FilterVersions != nil {
+		reply.Hooks = append(reply.Hooks, FilterVersionsHook)
+	}
+	if v.s.Veto != nil {
+		reply.Hooks = append(reply.Hooks, VetoHook)
+	}
+	if v.s.RewriteRepo != nil {
+		reply.Hooks = append(reply.Hooks, RewriteRepoHook)
+	}
+	return nil
+}
+
+func (v *service) FilterVersions(args *VersionsArgs, reply *VersionsReply) error {
+	versions, err := v.s.FilterVersions(args.Name, args.Repository, args.Versions)
+	reply.Versions = versions
+	return err
+}
+
+func (v *service) Veto(args *VetoArgs, reply *VetoReply) error {
+	reason, err := v.s.Veto(args.Name, args.Repository, args.Version, args.Commit)
+	reply.Veto, reply.Reason = reason != "", reason
+	return err
+}
+
+func (v *service) RewriteRepo(args *RepoArgs, reply *RepoReply) error {
+	repo, vcsType, err := v.s.RewriteRepo(args.Name, args.Repository, args.VcsType)
+	reply.Repository, reply.VcsType = repo, vcsType
+	return err
+}
@@ -0,0 +1,498 @@
+// Package hosting looks up the tags, branches, and details of repos on GitHub,
+// GitLab, and Bitbucket through their APIs.
+//
+// Commands that only need version metadata, such as the tags of a dependency
+// or the date of a commit, use it to avoid cloning the repo into the cache. Requests are authenticated
+// when a token is known for the host, which raises the rate limits. The
+// tokens come from the GITHUB_TOKEN, GITLAB_TOKEN, and BITBUCKET_TOKEN
+// environment variables or from the config.yaml file in GLIDE_HOME:
+//
+//     api-tokens:
+//       github.com: <token>
+//       gitlab.com: <token>
+//
+// Without a token the credentials for the host are used, as found by the
+// credentials package.
+//
+// When a host reports its rate limit is used up, requests to it are skipped
+// until the limit resets so callers fall back to cloning.
+package hosting
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/glide/credentials"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+var (
+	// ErrUnsupported is returned for repos not on a host with a known API.
+	ErrUnsupported = errors.New("no API is known for the host")
+
+	// ErrRateLimited is returned when the rate limit of a host is used up.
+	ErrRateLimited = errors.New("the API rate limit is exceeded")
+)
+
+// Client is the HTTP client used for API requests.
+var Client = &http.Client{Timeout: 30 * time.Second}
+
+// MaxRateLimitWait is the longest a request waits for a rate limit to reset
+// before failing with ErrRateLimited.
+var MaxRateLimitWait = 10 * time.Second
+
+// Ref is a tag or branch along with the commit it points at.
+type Ref struct {
+	Name   string
+	Commit string
+}
+
+// Refs are the tags and branches of a repo.
+type Refs struct {
+	Tags     []Ref
+	Branches []Ref
+}
+
+// TagNames returns the names of the tags.
+func (r *Refs) TagNames() []string {
+	return refNames(r.Tags)
+}
+
+// BranchNames returns the names of the branches.
+func (r *Refs) BranchNames() []string {
+	return refNames(r.Branches)
+}
+
+// TagsAt returns the names of the tags pointing at a commit, which may be
+// abbreviated.
+func (r *Refs) TagsAt(commit string) []string {
+	var tags []string
+	for _, t := range r.Tags {
+		if commit != "" && strings.HasPrefix(t.Commit, commit) {
+			tags = append(tags, t.Name)
+		}
+	}
+	return tags
+}
+
+func refNames(refs []Ref) []string {
+	n := make([]string, len(refs))
+	for i, r := range refs {
+		n[i] = r.Name
+	}
+	return n
+}
+
+var (
+	refsMutex sync.Mutex
+	refsCache = make(map[string]*Refs)
+)
+
+// Supported returns true if the remote is on a host with a known API.
+func Supported(remote string) bool {
+	_, err := parseRemote(remote)
+	return err == nil
+}
+
+// ListRefs returns the tags and branches of the repo at remote. The result is
+// kept for the rest of the run.
+func ListRefs(remote string) (*Refs, error) {
+	p, err := parseRemote(remote)
+	if err != nil {
+		return nil, err
+	}
+	refsMutex.Lock()
+	r, ok := refsCache[remote]
+	refsMutex.Unlock()
+	if ok {
+		return r, nil
+	}
+
+	r = &Refs{}
+	if r.Tags, err = p.host.refs(p, "tags"); err != nil {
+		return nil, err
+	}
+	if r.Branches, err = p.host.refs(p, "branches"); err != nil {
+		return nil, err
+	}
+	msg.Debug("Listed %d tags and %d branches of %s using the %s API", len(r.Tags), len(r.Branches), remote, p.host.name)
+	refsMutex.Lock()
+	refsCache[remote] = r
+	refsMutex.Unlock()
+	return r, nil
+}
+
+// Info is what a host knows about a repo.
+type Info struct {
+	DefaultBranch string
+	Archived      bool
+
+	// LastActivity is when the repo was last pushed to or otherwise changed.
+	LastActivity time.Time
+}
+
+// RepoInfo returns what the host knows about the repo at remote.
+func RepoInfo(remote string) (*Info, error) {
+	p, err := parseRemote(remote)
+	if err != nil {
+		return nil, err
+	}
+	body, _, err := p.host.get(p.host.repoURL(p))
+	if err != nil {
+		return nil, err
+	}
+	i, err := p.host.decodeRepo(body)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read %s from the %s API: %s", p.path(), p.host.name, err)
+	}
+	return i, nil
+}
+
+// DefaultBranch returns the default branch of the repo at remote.
+func DefaultBranch(remote string) (string, error) {
+	i, err := RepoInfo(remote)
+	if err != nil {
+		return "", err
+	}
+	return i.DefaultBranch, nil
+}
+
+// CommitDate returns when a commit of the repo at remote was made.
+func CommitDate(remote, commit string) (time.Time, error) {
+	p, err := parseRemote(remote)
+	if err != nil {
+		return time.Time{}, err
+	}
+	body, _, err := p.host.get(p.host.commitURL(p, commit))
+	if err != nil {
+		return time.Time{}, err
+	}
+	d, err := p.host.decodeCommitDate(body)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Unable to read commit %s of %s from the %s API: %s", commit, p.path(), p.host.name, err)
+	}
+	return d, nil
+}
+
+// CompareURL returns the web page of the host showing the changes to the repo
+// at remote between two revisions.
+func CompareURL(remote, from, to string) (string, error) {
+	p, err := parseRemote(remote)
+	if err != nil {
+		return "", err
+	}
+	return p.host.compareURL(p, from, to), nil
+}
+
+// TreeURL returns the web page of the host showing the files of the repo at
+// remote at a revision.
+func TreeURL(remote, rev string) (string, error) {
+	p, err := parseRemote(remote)
+	if err != nil {
+		return "", err
+	}
+	return p.host.treeURL(p, rev), nil
+}
+
+// ReleaseTarball returns the URL of the source tarball of the release tagged
+// tag in the repo at remote, as given by the GitHub Releases API. Other hosts
+// are not supported.
+func ReleaseTarball(remote, tag string) (string, error) {
+	p, err := parseRemote(remote)
+	if err != nil {
+		return "", err
+	}
+	if p.host != github {
+		return "", ErrUnsupported
+	}
+	body, _, err := p.host.get(fmt.Sprintf("%s/repos/%s/releases/tags/%s", p.host.api, p.path(), tag))
+	if err != nil {
+		return "", err
+	}
+	var data struct {
+		TarballURL string `json:"tarball_url"`
+	}
+	if err := decodeJSON(body, &data); err != nil {
+		return "", fmt.Errorf("Unable to read release %s of %s from the %s API: %s", tag, p.path(), p.host.name, err)
+	}
+	if data.TarballURL == "" {
+		return "", fmt.Errorf("Release %s of %s has no source tarball", tag, p.path())
+	}
+	return data.TarballURL, nil
+}
+
+// Authorize sets the token for a host on a request to its API, if one is
+// known, or else its credentials. Requests elsewhere get the credentials for
+// their host.
+func Authorize(req *http.Request) {
+	u := req.URL.String()
+	for _, h := range hosts {
+		if strings.HasPrefix(u, h.api+"/") {
+			h.authorize(req)
+			return
+		}
+	}
+	credentials.Authorize(req)
+}
+
+// project is a repo on a known host.
+type project struct {
+	host        *host
+	owner, name string
+}
+
+func (p *project) path() string {
+	return p.owner + "/" + p.name
+}
+
+// scpRe matches SCP-like addresses, such as git@github.com:foo/bar.
+var scpRe = regexp.MustCompile(`^[a-zA-Z0-9_]+@([a-zA-Z0-9._-]+):(.*)$`)
+
+func parseRemote(remote string) (*project, error) {
+	var hostname, path string
+	if m := scpRe.FindStringSubmatch(remote); m != nil {
+		hostname, path = m[1], m[2]
+	} else {
+		r := remote
+		if !strings.Contains(r, "://") {
+			r = "https://" + r
+		}
+		u, err := url.Parse(r)
+		if err != nil {
+			return nil, ErrUnsupported
+		}
+		hostname, path = u.Host, u.Path
+	}
+	h, ok := hosts[strings.ToLower(hostname)]
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, ErrUnsupported
+	}
+	return &project{host: h, owner: parts[0], name: strings.TrimSuffix(parts[1], ".git")}, nil
+}
+
+// host is a code host with an API for listing refs.
+type host struct {
+	name     string
+	domain   string
+	api      string
+	tokenEnv string
+
+	// auth sets the token on a request.
+	auth func(req *http.Request, token string)
+
+	// basicAuth is true when the API takes the credentials for the host with
+	// basic authentication. Otherwise their password is set as the token.
+	basicAuth bool
+
+	// refsURL returns the first page of the tags or branches.
+	refsURL func(p *project, kind string) string
+
+	// decode reads the refs from a page. It returns the next page, if any,
+	// when the host gives it in the body rather than in the headers.
+	decode func(body []byte) ([]Ref, string, error)
+
+	repoURL    func(p *project) string
+	decodeRepo func(body []byte) (*Info, error)
+
+	commitURL        func(p *project, commit string) string
+	decodeCommitDate func(body []byte) (time.Time, error)
+
+	// compareURL returns the web page showing the changes between two
+	// revisions and treeURL the one showing the files at a revision.
+	compareURL func(p *project, from, to string) string
+	treeURL    func(p *project, rev string) string
+
+	mutex        sync.Mutex
+	limitedUntil time.Time
+	noLogin      bool
+}
+
+var hosts = map[string]*host{
+	github.domain:    github,
+	gitlab.domain:    gitlab,
+	bitbucket.domain: bitbucket,
+}
+
+// refs fetches all the pages of the tags or branches of a project.
+func (h *host) refs(p *project, kind string) ([]Ref, error) {
+	var refs []Ref
+	for u := h.refsURL(p, kind); u != ""; {
+		body, next, err := h.get(u)
+		if err != nil {
+			return nil, err
+		}
+		r, bnext, err := h.decode(body)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read the %s of %s from the %s API: %s", kind, p.path(), h.name, err)
+		}
+		refs = append(refs, r...)
+		if next == "" {
+			next = bnext
+		}
+		u = next
+	}
+	return refs, nil
+}
+
+// get requests a page from the API. It returns the body along with the next
+// page when the host gives it in the headers. A request hitting the rate
+// limit is retried once when the limit resets within MaxRateLimitWait.
+func (h *host) get(u string) ([]byte, string, error) {
+	for attempt := 0; ; attempt++ {
+		h.mutex.Lock()
+		until := h.limitedUntil
+		h.mutex.Unlock()
+		if wait := until.Sub(time.Now()); wait > 0 {
+			if attempt > 1 || wait > MaxRateLimitWait {
+				return nil, "", ErrRateLimited
+			}
+			msg.Debug("Waiting %s for the %s API rate limit to reset", wait, h.name)
+			time.Sleep(wait)
+		}
+
+		req, err := http.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, "", err
+		}
+		login := h.authorize(req)
+		resp, err := Client.Do(req)
+		if err != nil {
+			return nil, "", err
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, "", err
+		}
+
+		// Credentials meant for cloning may not be accepted by the API, which
+		// serves public repos without them.
+		if login && resp.StatusCode == http.StatusUnauthorized {
+			msg.Debug("The %s API did not accept the credentials for %s, trying without them", h.name, h.domain)
+			h.mutex.Lock()
+			h.noLogin = true
+			h.mutex.Unlock()
+			attempt--
+			continue
+		}
+
+		if reset, limited := rateLimitReset(resp, time.Now()); limited {
+			h.mutex.Lock()
+			h.limitedUntil = reset
+			h.mutex.Unlock()
+			msg.Debug("The %s API rate limit is exceeded until %s", h.name, reset.Format(time.RFC3339))
+			continue
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil, "", fmt.Errorf("%s returned %s", u, resp.Status)
+		}
+		return body, nextLink(resp), nil
+	}
+}
+
+// rateLimitReset returns when the rate limit resets if the response says it
+// is used up. GitHub answers with a 403 and no remaining requests while the
+// others answer with a 429.
+func rateLimitReset(resp *http.Response, now time.Time) (time.Time, bool) {
+	used := resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0")
+	if !used {
+		return time.Time{}, false
+	}
+	if s := resp.Header.Get("Retry-After"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil {
+			return now.Add(time.Duration(n) * time.Second), true
+		}
+	}
+	for _, k := range []string{"X-RateLimit-Reset", "RateLimit-Reset"} {
+		if n, err := strconv.ParseInt(resp.Header.Get(k), 10, 64); err == nil {
+			return time.Unix(n, 0), true
+		}
+	}
+	return now.Add(time.Minute), true
+}
+
+// linkNextRe matches the next page in a Link header.
+var linkNextRe = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// nextLink returns the next page given in the Link header.
+func nextLink(resp *http.Response) string {
+	if m := linkNextRe.FindStringSubmatch(resp.Header.Get("Link")); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+var (
+	tokensOnce sync.Once
+	tokens     map[string]string
+)
+
+// token returns the token for the host from its environment variable or
+// from config.yaml.
+func (h *host) token() string {
+	if t := os.Getenv(h.tokenEnv); t != "" {
+		return t
+	}
+	tokensOnce.Do(func() {
+		t, err := readTokens()
+		if err != nil {
+			msg.Warn("Unable to read the API tokens: %s", err)
+		}
+		tokens = t
+	})
+	return tokens[h.domain]
+}
+
+// authorize sets the token for the host on a request to its API or, when there
+// is none, the credentials for the host, unless the API refused them before.
+// It returns true when the credentials were set.
+func (h *host) authorize(req *http.Request) bool {
+	if t := h.token(); t != "" {
+		h.auth(req, t)
+		return false
+	}
+	h.mutex.Lock()
+	noLogin := h.noLogin
+	h.mutex.Unlock()
+	c := credentials.Get(h.domain)
+	if c == nil || noLogin {
+		return false
+	}
+	if h.basicAuth {
+		req.SetBasicAuth(c.Username, c.Password)
+	} else {
+		h.auth(req, c.Password)
+	}
+	return true
+}
+
+// readTokens reads the api-tokens section of the config.yaml file.
+func readTokens() (map[string]string, error) {
+	var tokens map[string]string
+	err := gpath.GlobalConfig("api-tokens", &tokens)
+	return tokens, err
+}
+
+// decodeJSON reads a JSON body into v, reporting bodies that are not JSON.
+func decodeJSON(body []byte, v interface{}) error {
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("unexpected response: %s", err)
+	}
+	return nil
+}
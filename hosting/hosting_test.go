@@ -0,0 +1,239 @@
+package hosting
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRemote(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/Masterminds/glide":      "GitHub Masterminds/glide",
+		"github.com/Masterminds/glide":              "GitHub Masterminds/glide",
+		"git@github.com:Masterminds/glide.git":      "GitHub Masterminds/glide",
+		"https://gitlab.com/foo/bar.git":            "GitLab foo/bar",
+		"ssh://git@bitbucket.org/foo/bar":           "Bitbucket foo/bar",
+		"https://example.com/foo/bar":               "",
+		"https://github.com/Masterminds/glide/tree": "",
+	}
+	for remote, want := range tests {
+		got := ""
+		if p, err := parseRemote(remote); err == nil {
+			got = p.host.name + " " + p.path()
+		}
+		if got != want {
+			t.Errorf("Expected %q for %s, got %q", want, remote, got)
+		}
+	}
+}
+
+func TestListRefsGitHub(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			t.Errorf("Expected the token to be sent, got %q", r.Header.Get("Authorization"))
+		}
+		switch r.URL.Path + "?" + r.URL.RawQuery {
+		case "/repos/foo/bar/tags?per_page=100":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/foo/bar/tags?page=2>; rel="next", <%s/repos/foo/bar/tags?page=2>; rel="last"`, srv.URL, srv.URL))
+			fmt.Fprint(w, `[{"name": "v1.1.0", "commit": {"sha": "bbbb"}}]`)
+		case "/repos/foo/bar/tags?page=2":
+			fmt.Fprint(w, `[{"name": "v1.0.0", "commit": {"sha": "aaaa"}}]`)
+		case "/repos/foo/bar/branches?per_page=100":
+			fmt.Fprint(w, `[{"name": "master", "commit": {"sha": "cccc"}}]`)
+		case "/repos/foo/bar?":
+			fmt.Fprint(w, `{"default_branch": "master", "archived": true, "pushed_at": "2017-03-04T10:20:30Z"}`)
+		case "/repos/foo/bar/commits/aaaa?":
+			fmt.Fprint(w, `{"sha": "aaaa", "commit": {"committer": {"date": "2016-01-02T03:04:05Z"}}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	defer swapAPI(github, srv.URL)()
+	defer setenv("GITHUB_TOKEN", "secret")()
+
+	refs, err := ListRefs("https://github.com/foo/bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(refs.TagNames(), []string{"v1.1.0", "v1.0.0"}) {
+		t.Errorf("Unexpected tags %v", refs.TagNames())
+	}
+	if !reflect.DeepEqual(refs.BranchNames(), []string{"master"}) {
+		t.Errorf("Unexpected branches %v", refs.BranchNames())
+	}
+	if tags := refs.TagsAt("aaa"); !reflect.DeepEqual(tags, []string{"v1.0.0"}) {
+		t.Errorf("Unexpected tags at aaa %v", tags)
+	}
+	if b, err := DefaultBranch("git@github.com:foo/bar.git"); err != nil || b != "master" {
+		t.Errorf("Unexpected default branch %q (%v)", b, err)
+	}
+	if i, err := RepoInfo("https://github.com/foo/bar"); err != nil || !i.Archived || i.LastActivity.Year() != 2017 {
+		t.Errorf("Unexpected info %+v (%v)", i, err)
+	}
+	if d, err := CommitDate("https://github.com/foo/bar", "aaaa"); err != nil || !d.Equal(time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("Unexpected commit date %s (%v)", d, err)
+	}
+}
+
+func TestListRefsBitbucket(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path + "?" + r.URL.RawQuery {
+		case "/repositories/foo/bar/refs/tags?pagelen=100":
+			fmt.Fprintf(w, `{"values": [{"name": "1.0", "target": {"hash": "aaaa"}}], "next": "%s/repositories/foo/bar/refs/tags?page=2"}`, srv.URL)
+		case "/repositories/foo/bar/refs/tags?page=2":
+			fmt.Fprint(w, `{"values": [{"name": "2.0", "target": {"hash": "bbbb"}}]}`)
+		case "/repositories/foo/bar/refs/branches?pagelen=100":
+			fmt.Fprint(w, `{"values": [{"name": "default", "target": {"hash": "bbbb"}}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	defer swapAPI(bitbucket, srv.URL)()
+
+	refs, err := ListRefs("https://bitbucket.org/foo/bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(refs.TagNames(), []string{"1.0", "2.0"}) {
+		t.Errorf("Unexpected tags %v", refs.TagNames())
+	}
+	if tags := refs.TagsAt("bbbb"); !reflect.DeepEqual(tags, []string{"2.0"}) {
+		t.Errorf("Unexpected tags at bbbb %v", tags)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("PRIVATE-TOKEN") != "" {
+			t.Error("Expected no token to be sent")
+		}
+		if requests == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"default_branch": "main"}`)
+	}))
+	defer srv.Close()
+	defer swapAPI(gitlab, srv.URL)()
+	defer setenv("GITLAB_TOKEN", "")()
+
+	if b, err := DefaultBranch("https://gitlab.com/foo/bar"); err != nil || b != "main" {
+		t.Errorf("Unexpected default branch %q (%v)", b, err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected the request to be retried, got %d requests", requests)
+	}
+
+	gitlab.limitedUntil = time.Now().Add(time.Hour)
+	if _, err := DefaultBranch("https://gitlab.com/foo/bar"); err != ErrRateLimited {
+		t.Errorf("Expected ErrRateLimited, got %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected no request while rate limited, got %d requests", requests)
+	}
+}
+
+func TestRateLimitReset(t *testing.T) {
+	now := time.Unix(1000, 0)
+	resp := &http.Response{StatusCode: http.StatusForbidden, Header: http.Header{}}
+	if _, limited := rateLimitReset(resp, now); limited {
+		t.Error("Expected a 403 with requests remaining not to be a rate limit")
+	}
+	resp.Header.Set("X-RateLimit-Remaining", "0")
+	resp.Header.Set("X-RateLimit-Reset", "1060")
+	if reset, limited := rateLimitReset(resp, now); !limited || !reset.Equal(time.Unix(1060, 0)) {
+		t.Errorf("Unexpected reset %s (%t)", reset, limited)
+	}
+}
+
+// swapAPI points a host at a test server and returns a function restoring
+// it.
+func TestReleaseTarball(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/foo/bar/releases/tags/v1.0.0":
+			fmt.Fprintf(w, `{"tag_name": "v1.0.0", "tarball_url": "%s/repos/foo/bar/tarball/v1.0.0"}`, srv.URL)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	defer swapAPI(github, srv.URL)()
+	defer setenv("GITHUB_TOKEN", "secret")()
+
+	u, err := ReleaseTarball("github.com/foo/bar", "v1.0.0")
+	if err != nil || u != srv.URL+"/repos/foo/bar/tarball/v1.0.0" {
+		t.Errorf("Unexpected tarball %q (%v)", u, err)
+	}
+	if _, err := ReleaseTarball("github.com/foo/bar", "v2.0.0"); err == nil {
+		t.Error("Expected an error for a missing release")
+	}
+	if _, err := ReleaseTarball("gitlab.com/foo/bar", "v1.0.0"); err != ErrUnsupported {
+		t.Errorf("Expected releases on GitLab to be unsupported, got %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", u, nil)
+	Authorize(req)
+	if req.Header.Get("Authorization") != "token secret" {
+		t.Errorf("Expected the token to be set for the API, got %q", req.Header.Get("Authorization"))
+	}
+	req, _ = http.NewRequest("GET", "https://example.com/foo.tar.gz", nil)
+	Authorize(req)
+	if req.Header.Get("Authorization") != "" {
+		t.Error("Expected no token for other hosts")
+	}
+}
+
+func swapAPI(h *host, api string) func() {
+	old := h.api
+	h.api = api
+	return func() {
+		h.api = old
+		h.limitedUntil = time.Time{}
+	}
+}
+
+func setenv(k, v string) func() {
+	old, ok := os.LookupEnv(k)
+	os.Setenv(k, v)
+	return func() {
+		if ok {
+			os.Setenv(k, old)
+		} else {
+			os.Unsetenv(k)
+		}
+	}
+}
+
+func TestCompareURL(t *testing.T) {
+	tests := []struct {
+		remote, compare, tree string
+	}{
+		{"https://github.com/foo/bar", "https://github.com/foo/bar/compare/v1.0.0...v1.1.0", "https://github.com/foo/bar/tree/v1.1.0"},
+		{"git@gitlab.com:foo/bar.git", "https://gitlab.com/foo/bar/-/compare/v1.0.0...v1.1.0", "https://gitlab.com/foo/bar/-/tree/v1.1.0"},
+		{"https://bitbucket.org/foo/bar", "https://bitbucket.org/foo/bar/branches/compare/v1.1.0%0Dv1.0.0", "https://bitbucket.org/foo/bar/src/v1.1.0"},
+	}
+	for _, tt := range tests {
+		if u, err := CompareURL(tt.remote, "v1.0.0", "v1.1.0"); err != nil || u != tt.compare {
+			t.Errorf("Expected %q for %s, got %q (%v)", tt.compare, tt.remote, u, err)
+		}
+		if u, err := TreeURL(tt.remote, "v1.1.0"); err != nil || u != tt.tree {
+			t.Errorf("Expected %q for %s, got %q (%v)", tt.tree, tt.remote, u, err)
+		}
+	}
+	if _, err := CompareURL("https://example.com/foo/bar", "a", "b"); err != ErrUnsupported {
+		t.Errorf("Expected unknown hosts to be unsupported, got %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package hosting
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var github = &host{
+	name:      "GitHub",
+	domain:    "github.com",
+	api:       "https://api.github.com",
+	tokenEnv:  "GITHUB_TOKEN",
+	basicAuth: true,
+	auth: func(req *http.Request, token string) {
+		req.Header.Set("Authorization", "token "+token)
+	},
+	refsURL: func(p *project, kind string) string {
+		return fmt.Sprintf("%s/repos/%s/%s?per_page=100", p.host.api, p.path(), kind)
+	},
+	decode: func(body []byte) ([]Ref, string, error) {
+		var data []struct {
+			Name   string `json:"name"`
+			Commit struct {
+				Sha string `json:"sha"`
+			} `json:"commit"`
+		}
+		if err := decodeJSON(body, &data); err != nil {
+			return nil, "", err
+		}
+		refs := make([]Ref, len(data))
+		for i, d := range data {
+			refs[i] = Ref{Name: d.Name, Commit: d.Commit.Sha}
+		}
+		return refs, "", nil
+	},
+	repoURL: func(p *project) string {
+		return fmt.Sprintf("%s/repos/%s", p.host.api, p.path())
+	},
+	decodeRepo: func(body []byte) (*Info, error) {
+		var data struct {
+			DefaultBranch string    `json:"default_branch"`
+			Archived      bool      `json:"archived"`
+			PushedAt      time.Time `json:"pushed_at"`
+		}
+		err := decodeJSON(body, &data)
+		return &Info{DefaultBranch: data.DefaultBranch, Archived: data.Archived, LastActivity: data.PushedAt}, err
+	},
+	commitURL: func(p *project, commit string) string {
+		return fmt.Sprintf("%s/repos/%s/commits/%s", p.host.api, p.path(), commit)
+	},
+	decodeCommitDate: func(body []byte) (time.Time, error) {
+		var data struct {
+			Commit struct {
+				Committer struct {
+					Date time.Time `json:"date"`
+				} `json:"committer"`
+			} `json:"commit"`
+		}
+		err := decodeJSON(body, &data)
+		return data.Commit.Committer.Date, err
+	},
+	compareURL: func(p *project, from, to string) string {
+		return fmt.Sprintf("https://github.com/%s/compare/%s...%s", p.path(), from, to)
+	},
+	treeURL: func(p *project, rev string) string {
+		return fmt.Sprintf("https://github.com/%s/tree/%s", p.path(), rev)
+	},
+}
+
+var gitlab = &host{
+	name:     "GitLab",
+	domain:   "gitlab.com",
+	api:      "https://gitlab.com/api/v4",
+	tokenEnv: "GITLAB_TOKEN",
+	auth: func(req *http.Request, token string) {
+		req.Header.Set("PRIVATE-TOKEN", token)
+	},
+	refsURL: func(p *project, kind string) string {
+		return fmt.Sprintf("%s/projects/%s/repository/%s?per_page=100", p.host.api, gitlabID(p), kind)
+	},
+	decode: func(body []byte) ([]Ref, string, error) {
+		var data []struct {
+			Name   string `json:"name"`
+			Commit struct {
+				ID string `json:"id"`
+			} `json:"commit"`
+		}
+		if err := decodeJSON(body, &data); err != nil {
+			return nil, "", err
+		}
+		refs := make([]Ref, len(data))
+		for i, d := range data {
+			refs[i] = Ref{Name: d.Name, Commit: d.Commit.ID}
+		}
+		return refs, "", nil
+	},
+	repoURL: func(p *project) string {
+		return fmt.Sprintf("%s/projects/%s", p.host.api, gitlabID(p))
+	},
+	decodeRepo: func(body []byte) (*Info, error) {
+		var data struct {
+			DefaultBranch  string    `json:"default_branch"`
+			Archived       bool      `json:"archived"`
+			LastActivityAt time.Time `json:"last_activity_at"`
+		}
+		err := decodeJSON(body, &data)
+		return &Info{DefaultBranch: data.DefaultBranch, Archived: data.Archived, LastActivity: data.LastActivityAt}, err
+	},
+	commitURL: func(p *project, commit string) string {
+		return fmt.Sprintf("%s/projects/%s/repository/commits/%s", p.host.api, gitlabID(p), commit)
+	},
+	decodeCommitDate: func(body []byte) (time.Time, error) {
+		var data struct {
+			CommittedDate time.Time `json:"committed_date"`
+		}
+		err := decodeJSON(body, &data)
+		return data.CommittedDate, err
+	},
+	compareURL: func(p *project, from, to string) string {
+		return fmt.Sprintf("https://gitlab.com/%s/-/compare/%s...%s", p.path(), from, to)
+	},
+	treeURL: func(p *project, rev string) string {
+		return fmt.Sprintf("https://gitlab.com/%s/-/tree/%s", p.path(), rev)
+	},
+}
+
+// gitlabID returns the ID GitLab accepts for a project, which is its path
+// with the slash escaped.
+func gitlabID(p *project) string {
+	return strings.Replace(p.path(), "/", "%2F", -1)
+}
+
+var bitbucket = &host{
+	name:      "Bitbucket",
+	domain:    "bitbucket.org",
+	api:       "https://api.bitbucket.org/2.0",
+	tokenEnv:  "BITBUCKET_TOKEN",
+	basicAuth: true,
+	auth: func(req *http.Request, token string) {
+		req.Header.Set("Authorization", "Bearer "+token)
+	},
+	refsURL: func(p *project, kind string) string {
+		return fmt.Sprintf("%s/repositories/%s/refs/%s?pagelen=100", p.host.api, p.path(), kind)
+	},
+	decode: func(body []byte) ([]Ref, string, error) {
+		var data struct {
+			Values []struct {
+				Name   string `json:"name"`
+				Target struct {
+					Hash string `json:"hash"`
+				} `json:"target"`
+			} `json:"values"`
+			Next string `json:"next"`
+		}
+		if err := decodeJSON(body, &data); err != nil {
+			return nil, "", err
+		}
+		refs := make([]Ref, len(data.Values))
+		for i, d := range data.Values {
+			refs[i] = Ref{Name: d.Name, Commit: d.Target.Hash}
+		}
+		return refs, data.Next, nil
+	},
+	repoURL: func(p *project) string {
+		return fmt.Sprintf("%s/repositories/%s", p.host.api, p.path())
+	},
+	decodeRepo: func(body []byte) (*Info, error) {
+		// Bitbucket has no archived repos.
+		var data struct {
+			MainBranch struct {
+				Name string `json:"name"`
+			} `json:"mainbranch"`
+			UpdatedOn time.Time `json:"updated_on"`
+		}
+		err := decodeJSON(body, &data)
+		return &Info{DefaultBranch: data.MainBranch.Name, LastActivity: data.UpdatedOn}, err
+	},
+	commitURL: func(p *project, commit string) string {
+		return fmt.Sprintf("%s/repositories/%s/commit/%s", p.host.api, p.path(), commit)
+	},
+	decodeCommitDate: func(body []byte) (time.Time, error) {
+		var data struct {
+			Date time.Time `json:"date"`
+		}
+		err := decodeJSON(body, &data)
+		return data.Date, err
+	},
+	compareURL: func(p *project, from, to string) string {
+		return fmt.Sprintf("https://bitbucket.org/%s/branches/compare/%s%%0D%s", p.path(), to, from)
+	},
+	treeURL: func(p *project, rev string) string {
+		return fmt.Sprintf("https://bitbucket.org/%s/src/%s", p.path(), rev)
+	},
+}
@@ -1,4 +1,5 @@
-// Package importer imports dependency configuration from Glide, Godep, GPM, GB and gom
+// Package importer imports dependency configuration from Glide, Go modules,
+// Godep, GPM, GB and gom
 package importer
 
 import (
@@ -10,12 +11,14 @@ import (
 	"github.com/Masterminds/glide/gb"
 	"github.com/Masterminds/glide/godep"
 	"github.com/Masterminds/glide/gom"
+	"github.com/Masterminds/glide/gomod"
 	"github.com/Masterminds/glide/gpm"
 )
 
 var i = &DefaultImporter{}
 
-// Import uses the DefaultImporter to import from Glide, Godep, GPM, GB and gom.
+// Import uses the DefaultImporter to import from Glide, Go modules, Godep, GPM,
+// GB and gom.
 func Import(path string) (bool, []*cfg.Dependency, error) {
 	return i.Import(path)
 }
@@ -30,10 +33,11 @@ type Importer interface {
 	Import(path string) (bool, []*cfg.Dependency, error)
 }
 
-// DefaultImporter imports from Glide, Godep, GPM, GB and gom.
+// DefaultImporter imports from Glide, Go modules, Godep, GPM, GB and gom.
 type DefaultImporter struct{}
 
-// Import tries to import configuration from Glide, Godep, GPM, GB and gom.
+// Import tries to import configuration from Glide, Go modules, Godep, GPM, GB
+// and gom.
 func (d *DefaultImporter) Import(path string) (bool, []*cfg.Dependency, error) {
 
 	// Try importing from Glide first.
@@ -51,6 +55,16 @@ func (d *DefaultImporter) Import(path string) (bool, []*cfg.Dependency, error) {
 		return true, conf.Imports, nil
 	}
 
+	// Try importing from a go.mod file. Projects that moved to Go modules
+	// often leave older Godep files behind, so it comes first.
+	if gomod.Has(path) {
+		deps, err := gomod.Parse(path)
+		if err != nil {
+			return false, []*cfg.Dependency{}, err
+		}
+		return true, deps, nil
+	}
+
 	// Try importing from Godep
 	if godep.Has(path) {
 		deps, err := godep.Parse(path)
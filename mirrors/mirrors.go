@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/Masterminds/glide/msg"
 	gpath "github.com/Masterminds/glide/path"
@@ -33,8 +35,12 @@ func Get(k string) (bool, string, string) {
 	return true, o.Repo, o.Vcs
 }
 
-// Load pulls the mirrors into memory
+// Load pulls the mirrors, and the rewrite rules in config.yaml, into memory
 func Load() error {
+	if err := LoadRewrites(); err != nil {
+		return err
+	}
+
 	home := gpath.Home()
 
 	op := filepath.Join(home, "mirrors.yaml")
@@ -71,3 +77,33 @@ func Load() error {
 
 	return nil
 }
+
+// Add registers mirrors that are not already known, such as those listed in a
+// glide.yaml file. Mirrors loaded from mirrors.yaml take precedence.
+func Add(repos MirrorRepos) {
+	for _, o := range repos {
+		if _, f := mirrors[o.Original]; f {
+			continue
+		}
+		msg.Debug("Found mirror: %s to %s (%s)", o.Original, o.Repo, o.Vcs)
+		mirrors[o.Original] = &mirror{
+			Repo: o.Repo,
+			Vcs:  o.Vcs,
+		}
+	}
+}
+
+// Fingerprint describes the mirrors and rewrite rules in use, so results that
+// depend on where repos are fetched from can be told apart.
+func Fingerprint() string {
+	var lines []string
+	for k, o := range mirrors {
+		lines = append(lines, fmt.Sprintf("mirror %s %s %s", k, o.Repo, o.Vcs))
+	}
+	sort.Strings(lines)
+	// The first matching rewrite wins, so their order is kept.
+	for _, r := range rewrites {
+		lines = append(lines, fmt.Sprintf("rewrite %s %s %s", r.From, r.To, r.Vcs))
+	}
+	return strings.Join(lines, "\n")
+}
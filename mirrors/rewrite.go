@@ -0,0 +1,131 @@
+package mirrors
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// Rewrite maps the import paths on a host to the repos holding them so they
+// are found without looking up the go-import meta tag the host serves.
+//
+// From is an import path or a pattern ending in /*, which matches one path
+// element, such as k8s.io/*. To is the repo, with the * replaced by the
+// matched element. An https:// scheme is used when To has none.
+type Rewrite struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+	Vcs  string `yaml:"vcs,omitempty"`
+}
+
+var rewrites []*Rewrite
+
+// gopkgInRe matches the import paths of gopkg.in. They are served from Git
+// repos at the root of the path.
+var gopkgInRe = regexp.MustCompile(`^(gopkg\.in/([A-Za-z0-9_\-]+/)?[A-Za-z0-9_.\-]+\.v[0-9]+(-unstable)?)(/[A-Za-z0-9_.\-]+)*$`)
+
+// LoadRewrites reads the rewrite rules in the config.yaml file in GLIDE_HOME:
+//
+//     rewrite:
+//     - from: k8s.io/*
+//       to: github.com/kubernetes/*
+func LoadRewrites() error {
+	rewrites = nil
+	var rs []*Rewrite
+	if err := gpath.GlobalConfig("rewrite", &rs); err != nil {
+		return err
+	}
+	for _, r := range rs {
+		if err := r.validate(); err != nil {
+			return fmt.Errorf("Invalid rewrite in config.yaml file: %s", err)
+		}
+		msg.Debug("Found rewrite: %s to %s", r.From, r.To)
+	}
+	rewrites = rs
+	return nil
+}
+
+// SetRewrites replaces the rewrite rules in use.
+func SetRewrites(r []*Rewrite) {
+	rewrites = r
+}
+
+// RewriteRoot returns the root of the repo holding an import path when a
+// rewrite rule, or the known layout of gopkg.in, gives it.
+func RewriteRoot(pkg string) (string, bool) {
+	if r, root := matchRewrite(pkg); r != nil {
+		return root, true
+	}
+	if m := gopkgInRe.FindStringSubmatch(pkg); m != nil {
+		return m[1], true
+	}
+	return pkg, false
+}
+
+// RewriteRemote returns the repo and VCS type for the root of a package when
+// a rewrite rule, or the known layout of gopkg.in, gives it.
+func RewriteRemote(name string) (bool, string, string) {
+	r, root := matchRewrite(name)
+	if r != nil {
+		to := r.To
+		if strings.HasSuffix(r.From, "/*") {
+			to = strings.Replace(to, "*", strings.TrimPrefix(root, strings.TrimSuffix(r.From, "*")), 1)
+		}
+		if !strings.Contains(to, "://") && !scpRe.MatchString(to) {
+			to = "https://" + to
+		}
+		return true, to, r.Vcs
+	}
+	if m := gopkgInRe.FindStringSubmatch(name); m != nil && m[1] == name {
+		return true, "https://" + name, "git"
+	}
+	return false, "", ""
+}
+
+// scpRe matches SCP-like addresses, such as git@github.com:foo/bar.
+var scpRe = regexp.MustCompile(`^[a-zA-Z0-9_]+@[a-zA-Z0-9._-]+:`)
+
+// matchRewrite returns the rule matching an import path along with the root
+// it gives. The rule with the longest prefix wins.
+func matchRewrite(pkg string) (*Rewrite, string) {
+	var match *Rewrite
+	var root string
+	for _, r := range rewrites {
+		var rr string
+		if strings.HasSuffix(r.From, "/*") {
+			prefix := strings.TrimSuffix(r.From, "*")
+			if !strings.HasPrefix(pkg, prefix) {
+				continue
+			}
+			elem := strings.SplitN(strings.TrimPrefix(pkg, prefix), "/", 2)[0]
+			if elem == "" {
+				continue
+			}
+			rr = prefix + elem
+		} else if pkg == r.From || strings.HasPrefix(pkg, r.From+"/") {
+			rr = r.From
+		} else {
+			continue
+		}
+		if match == nil || len(r.From) > len(match.From) {
+			match, root = r, rr
+		}
+	}
+	return match, root
+}
+
+func (r *Rewrite) validate() error {
+	if r.From == "" || r.To == "" {
+		return fmt.Errorf("both from and to are required")
+	}
+	if strings.Count(r.From, "*") > 1 || (strings.Contains(r.From, "*") && !strings.HasSuffix(r.From, "/*")) {
+		return fmt.Errorf("%s: * may only be used as the last path element", r.From)
+	}
+	if strings.Count(r.To, "*") > 1 || (strings.Contains(r.To, "*") && !strings.HasSuffix(r.From, "/*")) {
+		return fmt.Errorf("%s: * in to requires from to end in /*", r.To)
+	}
+	return nil
+}
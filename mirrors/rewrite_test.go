@@ -0,0 +1,58 @@
+package mirrors
+
+import "testing"
+
+func TestRewrite(t *testing.T) {
+	defer SetRewrites(nil)
+	SetRewrites([]*Rewrite{
+		{From: "k8s.io/*", To: "github.com/kubernetes/*"},
+		{From: "k8s.io/kubernetes", To: "git@github.com:kubernetes/kubernetes", Vcs: "git"},
+		{From: "go.example.com/tools", To: "https://git.example.com/tools.git"},
+	})
+
+	roots := map[string]string{
+		"k8s.io/client-go":                  "k8s.io/client-go",
+		"k8s.io/client-go/rest":             "k8s.io/client-go",
+		"k8s.io/kubernetes/pkg/api":         "k8s.io/kubernetes",
+		"go.example.com/tools/cmd/lint":     "go.example.com/tools",
+		"gopkg.in/yaml.v2":                  "gopkg.in/yaml.v2",
+		"gopkg.in/src-d/go-git.v4/plumbing": "gopkg.in/src-d/go-git.v4",
+	}
+	for pkg, want := range roots {
+		root, ok := RewriteRoot(pkg)
+		if !ok || root != want {
+			t.Errorf("Expected root %s for %s but got %s (%t)", want, pkg, root, ok)
+		}
+	}
+	if _, ok := RewriteRoot("go.example.com/other"); ok {
+		t.Error("Rewrote a path no rule matches")
+	}
+
+	remotes := []struct {
+		name, remote, vcs string
+	}{
+		{"k8s.io/client-go", "https://github.com/kubernetes/client-go", ""},
+		{"k8s.io/kubernetes", "git@github.com:kubernetes/kubernetes", "git"},
+		{"go.example.com/tools", "https://git.example.com/tools.git", ""},
+		{"gopkg.in/yaml.v2", "https://gopkg.in/yaml.v2", "git"},
+	}
+	for _, tt := range remotes {
+		f, remote, vcs := RewriteRemote(tt.name)
+		if !f || remote != tt.remote || vcs != tt.vcs {
+			t.Errorf("Expected %s (%s) for %s but got %s (%s)", tt.remote, tt.vcs, tt.name, remote, vcs)
+		}
+	}
+}
+
+func TestRewriteValidate(t *testing.T) {
+	invalid := []*Rewrite{
+		{From: "k8s.io/*"},
+		{From: "k8s.io/*/foo", To: "github.com/kubernetes/*"},
+		{From: "k8s.io/client-go", To: "github.com/kubernetes/*"},
+	}
+	for _, r := range invalid {
+		if err := r.validate(); err == nil {
+			t.Errorf("Expected %s to %s to be invalid", r.From, r.To)
+		}
+	}
+}
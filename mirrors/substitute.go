@@ -0,0 +1,31 @@
+package mirrors
+
+import "sync"
+
+var (
+	substitutes   = make(map[string]string)
+	substitutesMu sync.RWMutex
+)
+
+// Substitute makes the Git repo at remote be fetched from repo for the rest of
+// the run, such as over SSH once fetching it over https failed to
+// authenticate. An empty repo removes the substitute. Unlike mirrors,
+// substitutes are not recorded anywhere.
+func Substitute(remote, repo string) {
+	substitutesMu.Lock()
+	defer substitutesMu.Unlock()
+	if repo == "" {
+		delete(substitutes, remote)
+		return
+	}
+	substitutes[remote] = repo
+}
+
+// Substitution returns the location the repo at remote is fetched from in
+// place of it, if any.
+func Substitution(remote string) (string, bool) {
+	substitutesMu.RLock()
+	defer substitutesMu.RUnlock()
+	r, ok := substitutes[remote]
+	return r, ok
+}
@@ -0,0 +1,171 @@
+package msg
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/Masterminds/vcs"
+	"github.com/codegangsta/cli"
+)
+
+// Kind is the category of a failure. It decides the exit code Die uses and is
+// reported with errors in the JSON error format, so scripts running Glide can
+// tell failures apart without matching messages.
+type Kind int
+
+// The kinds of failures.
+const (
+	// KindGeneral is any failure not in another category.
+	KindGeneral Kind = iota
+
+	// KindConfig is an invalid or unreadable glide.yaml or lock file.
+	KindConfig
+
+	// KindNetwork is a remote that could not be reached, or an operation
+	// that timed out.
+	KindNetwork
+
+	// KindVCS is a VCS command that failed for another reason.
+	KindVCS
+
+	// KindConflict is a version constraint no version satisfies.
+	KindConflict
+
+	// KindHashMismatch is a download or tag not matching the checksum or
+	// commit recorded for it.
+	KindHashMismatch
+
+	// KindChangesPending is a dry run finding dependencies that would
+	// change.
+	KindChangesPending
+)
+
+// String returns the name of the kind used in the JSON error format.
+func (k Kind) String() string {
+	switch k {
+	case KindConfig:
+		return "config"
+	case KindNetwork:
+		return "network"
+	case KindVCS:
+		return "vcs"
+	case KindConflict:
+		return "conflict"
+	case KindHashMismatch:
+		return "hash-mismatch"
+	case KindChangesPending:
+		return "changes-pending"
+	}
+	return "general"
+}
+
+// ExitCode returns the exit code for failures of the kind. It is 0 for
+// KindGeneral, which uses the exit code set on the Messenger.
+func (k Kind) ExitCode() int {
+	switch k {
+	case KindConfig:
+		return 3
+	case KindNetwork:
+		return 4
+	case KindVCS:
+		return 5
+	case KindConflict:
+		return 6
+	case KindHashMismatch:
+		return 7
+	case KindChangesPending:
+		return 8
+	}
+	return 0
+}
+
+// Kinded is implemented by errors that know the kind of failure they are.
+type Kinded interface {
+	Kind() Kind
+}
+
+// networkMarkers are found in the output of a VCS when the remote could not
+// be reached.
+var networkMarkers = []string{
+	"could not resolve host",
+	"could not resolve hostname",
+	"temporary failure in name resolution",
+	"connection refused",
+	"connection timed out",
+	"connection reset",
+	"network is unreachable",
+	"no route to host",
+	"operation timed out",
+	"ssl certificate problem",
+	"ssl_connect",
+	"tls handshake",
+	"unable to access",
+	"early eof",
+}
+
+// ErrorKind returns the kind of failure err is. Errors implementing Kinded
+// say so themselves. Errors of the VCS are network failures when their output
+// says the remote could not be reached.
+func ErrorKind(err error) Kind {
+	switch t := err.(type) {
+	case nil:
+		return KindGeneral
+	case Kinded:
+		return t.Kind()
+	case *cli.MultiError:
+		return multiKind(t.Errors)
+	case cli.MultiError:
+		return multiKind(t.Errors)
+	case *vcs.RemoteError:
+		if hasNetworkMarker(t.Error() + " " + t.Out()) {
+			return KindNetwork
+		}
+		return KindVCS
+	case *vcs.LocalError:
+		return KindVCS
+	case *url.Error, net.Error:
+		return KindNetwork
+	}
+	switch err {
+	case vcs.ErrCannotDetectVCS, vcs.ErrWrongVCS, vcs.ErrWrongRemote, vcs.ErrRevisionUnavailable:
+		return KindVCS
+	}
+	return KindGeneral
+}
+
+// multiKind returns the kind shared by errs, or KindGeneral when they differ.
+func multiKind(errs []error) Kind {
+	k := KindGeneral
+	for i, e := range errs {
+		ek := ErrorKind(e)
+		if i > 0 && ek != k {
+			return KindGeneral
+		}
+		k = ek
+	}
+	return k
+}
+
+func hasNetworkMarker(out string) bool {
+	out = strings.ToLower(out)
+	for _, m := range networkMarkers {
+		if strings.Contains(out, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// argsKind returns the kind of the first error in args that is of a known
+// kind.
+func argsKind(args []interface{}) Kind {
+	for _, a := range args {
+		if err, ok := a.(error); ok {
+			if k := ErrorKind(err); k != KindGeneral {
+				return k
+			}
+		}
+	}
+	return KindGeneral
+}
@@ -0,0 +1,91 @@
+package msg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Masterminds/vcs"
+	"github.com/codegangsta/cli"
+)
+
+type conflictError struct{}
+
+func (conflictError) Error() string { return "conflict" }
+func (conflictError) Kind() Kind    { return KindConflict }
+
+func TestErrorKind(t *testing.T) {
+	offline := vcs.NewRemoteError("Unable to get repository", errors.New("exit status 128"),
+		"fatal: unable to access 'https://example.com/foo/': Could not resolve host: example.com")
+	missing := vcs.NewRemoteError("Unable to get repository", errors.New("exit status 128"),
+		"fatal: couldn't find remote ref refs/heads/nope")
+	handshake := vcs.NewRemoteError("Unable to get repository", errors.New("exit status 128"),
+		"error: RPC failed; OpenSSL SSL_connect: SSL_ERROR_SYSCALL in connection to example.com:443")
+	tlsInName := vcs.NewRemoteError("Unable to update repository", errors.New("exit status 1"),
+		"error: pathspec 'feature/tls-config' did not match any file(s) known to git")
+	tests := []struct {
+		err  error
+		kind Kind
+	}{
+		{nil, KindGeneral},
+		{errors.New("boom"), KindGeneral},
+		{conflictError{}, KindConflict},
+		{offline, KindNetwork},
+		{missing, KindVCS},
+		{handshake, KindNetwork},
+		{tlsInName, KindVCS},
+		{vcs.ErrCannotDetectVCS, KindVCS},
+		{cli.NewMultiError(offline, offline), KindNetwork},
+		{cli.NewMultiError(offline, missing), KindGeneral},
+	}
+	for i, tt := range tests {
+		if k := ErrorKind(tt.err); k != tt.kind {
+			t.Errorf("%d: expected %s for %v, got %s", i, tt.kind, tt.err, k)
+		}
+	}
+	if c := KindHashMismatch.ExitCode(); c != 7 {
+		t.Errorf("Expected exit code 7 for a hash mismatch, got %d", c)
+	}
+	if c := KindChangesPending.ExitCode(); c != 8 {
+		t.Errorf("Expected exit code 8 for pending changes, got %d", c)
+	}
+}
+
+func TestDieKind(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewMessenger()
+	m.Stderr = &buf
+	m.PanicOnDie = true
+	m.ErrorFormat = JSONFormat
+
+	die := func(f func()) (d *Died) {
+		defer func() {
+			d, _ = recover().(*Died)
+		}()
+		f()
+		return nil
+	}
+
+	d := die(func() { m.Die("Failed: %s", conflictError{}) })
+	if d == nil || d.Kind != KindConflict || d.Msg != "Failed: conflict" {
+		t.Fatalf("Expected to die of a conflict, got %+v", d)
+	}
+	var e errorEntry
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatalf("Expected a JSON error object, got %q: %s", buf.String(), err)
+	}
+	if e.Kind != "conflict" || e.ExitCode != 6 || e.Msg != "Failed: conflict" {
+		t.Errorf("Unexpected error object %+v", e)
+	}
+
+	buf.Reset()
+	m.ExitCode(2)
+	d = die(func() { m.DieKind(KindGeneral, "missing") })
+	if d == nil || d.Kind != KindGeneral {
+		t.Fatalf("Expected to die of a general failure, got %+v", d)
+	}
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil || e.ExitCode != 2 {
+		t.Errorf("Expected the exit code set on the Messenger, got %q", buf.String())
+	}
+}
@@ -0,0 +1,259 @@
+package msg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/vcs"
+)
+
+// Level is the severity of a message. Messages below the level of a Messenger
+// are not displayed.
+type Level int
+
+// The levels messages are logged at. The zero value is InfoLevel.
+const (
+	DebugLevel Level = iota - 1
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+// The formats messages can be displayed in.
+const (
+	// TextFormat displays messages prefixed with their level.
+	TextFormat = "text"
+
+	// JSONFormat displays each message as a JSON object on its own line.
+	JSONFormat = "json"
+)
+
+// String returns the name of the level.
+func (l Level) String() string {
+	switch l {
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case WarnLevel:
+		return "warn"
+	case ErrorLevel:
+		return "error"
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// ParseLevel returns the level with the given name.
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return DebugLevel, nil
+	case "info", "":
+		return InfoLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	}
+	return InfoLevel, fmt.Errorf("Unknown log level %q. Use debug, info, warn, or error", s)
+}
+
+// entry is a message in the JSON format.
+type entry struct {
+	Time   string `json:"time"`
+	Level  string `json:"level"`
+	Source string `json:"source,omitempty"`
+	Msg    string `json:"msg"`
+	Output string `json:"output,omitempty"`
+}
+
+// errorEntry is an error in the JSON error format.
+type errorEntry struct {
+	Kind     string `json:"kind"`
+	ExitCode int    `json:"exitCode,omitempty"`
+	Msg      string `json:"msg"`
+	Output   string `json:"output,omitempty"`
+}
+
+// enabled returns true if messages at the level are displayed.
+func (m *Messenger) enabled(l Level) bool {
+	switch {
+	case l >= ErrorLevel:
+		return true
+	case l == DebugLevel:
+		return !m.Quiet && (m.IsDebugging || m.Level <= DebugLevel)
+	case l == InfoLevel && m.Quiet:
+		return false
+	}
+	return l >= m.Level
+}
+
+// log displays a message at a level, when enabled, and writes it to the log
+// file. Output of a failed VCS command passed as the last argument is included
+// at the debug level.
+func (m *Messenger) log(l Level, source, msg string, args ...interface{}) {
+	msg, out := formatMsg(msg, args)
+
+	// When operations in Glide are happening concurrently messaging needs to be
+	// locked to avoid displaying one message in the middle of another one.
+	m.Lock()
+	defer m.Unlock()
+
+	if m.enabled(l) {
+		o := ""
+		if m.enabled(DebugLevel) {
+			o = out
+		}
+		m.clearStatus()
+		m.write(m.Stderr, l, source, msg, o, !m.NoColor)
+		m.drawStatus()
+	}
+	if m.LogFile != nil {
+		m.write(m.LogFile, l, source, msg, out, false)
+	}
+}
+
+// logErr logs an error of kind k. A code other than 0 is the exit code Glide
+// exits with because of it. In the JSON error format the error is displayed
+// as an error object instead of a message.
+func (m *Messenger) logErr(k Kind, code int, msg string, args ...interface{}) {
+	m.Lock()
+	m.hasErrored = true
+	asJSON := m.ErrorFormat == JSONFormat
+	m.Unlock()
+	if !asJSON {
+		m.log(ErrorLevel, "", msg, args...)
+		return
+	}
+
+	msg, out := formatMsg(msg, args)
+	m.Lock()
+	defer m.Unlock()
+	b, err := json.Marshal(errorEntry{Kind: k.String(), ExitCode: code, Msg: msg, Output: out})
+	if err == nil {
+		m.clearStatus()
+		fmt.Fprintf(m.Stderr, "%s\n", b)
+		m.drawStatus()
+	}
+	if m.LogFile != nil {
+		m.write(m.LogFile, ErrorLevel, "", msg, out, false)
+	}
+}
+
+// formatMsg returns the message with args applied and the output of a failed
+// VCS command passed as the last argument, if any.
+func formatMsg(msg string, args []interface{}) (string, string) {
+	if len(args) != 0 {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	msg = strings.TrimSuffix(msg, "\n")
+
+	var out string
+	if len(args) != 0 {
+		if err, ok := args[len(args)-1].(error); ok {
+			switch t := err.(type) {
+			case *vcs.LocalError:
+				out = strings.TrimSpace(t.Out())
+			case *vcs.RemoteError:
+				out = strings.TrimSpace(t.Out())
+			}
+		}
+	}
+	return msg, out
+}
+
+// write formats a message for w.
+func (m *Messenger) write(w io.Writer, l Level, source, msg, out string, color bool) {
+	if m.Format == JSONFormat {
+		b, err := json.Marshal(entry{
+			Time:   time.Now().Format(time.RFC3339),
+			Level:  l.String(),
+			Source: source,
+			Msg:    msg,
+			Output: out,
+		})
+		if err == nil {
+			fmt.Fprintf(w, "%s\n", b)
+		}
+		return
+	}
+
+	prefix := "[" + strings.ToUpper(l.String()) + "]\t"
+	if color {
+		switch l {
+		case InfoLevel:
+			prefix = m.Color(Green, prefix)
+		case WarnLevel:
+			prefix = m.Color(Yellow, prefix)
+		case ErrorLevel:
+			prefix = m.Color(Red, prefix)
+		}
+	}
+	if source != "" {
+		msg = source + ": " + msg
+	}
+	fmt.Fprintf(w, "%s%s\n", prefix, msg)
+	if out != "" {
+		fmt.Fprintf(w, "[DEBUG]\tOutput was: %s\n", out)
+	}
+}
+
+// Output logs the output of a command, such as a VCS, at the debug level. Each
+// line is logged separately with source naming the command.
+func (m *Messenger) Output(source string, out []byte) {
+	for _, line := range strings.Split(string(bytes.TrimSpace(out)), "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			m.log(DebugLevel, source, "%s", line)
+		}
+	}
+}
+
+// Output logs the output of a command, such as a VCS, at the debug level using
+// the Default Messenger.
+func Output(source string, out []byte) {
+	Default.Output(source, out)
+}
+
+// OutputLoggedEnv is set in the environment of the commands CombinedOutput
+// runs, so the shim tracing VCS commands knows their output is logged already.
+const OutputLoggedEnv = "GLIDE_OUTPUT_LOGGED"
+
+// CombinedOutput runs c and returns its combined stdout and stderr, logging
+// them as the output of source.
+func (m *Messenger) CombinedOutput(source string, c *exec.Cmd) ([]byte, error) {
+	if c.Env == nil {
+		c.Env = os.Environ()
+	}
+	c.Env = append(c.Env, OutputLoggedEnv+"=1")
+	out, err := c.CombinedOutput()
+	m.Output(source, out)
+	return out, err
+}
+
+// CombinedOutput runs c and returns its combined stdout and stderr, logging
+// them as the output of source using the Default Messenger.
+func CombinedOutput(source string, c *exec.Cmd) ([]byte, error) {
+	return Default.CombinedOutput(source, c)
+}
+
+// Writer returns an io.Writer logging everything written to it as the output
+// of a command. This can be passed to loggers and subprocesses.
+func (m *Messenger) Writer(source string) io.Writer {
+	return &outputWriter{m: m, source: source}
+}
+
+type outputWriter struct {
+	m      *Messenger
+	source string
+}
+
+func (w *outputWriter) Write(p []byte) (int, error) {
+	w.m.Output(w.source, p)
+	return len(p), nil
+}
@@ -0,0 +1,92 @@
+package msg
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestLevels(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewMessenger()
+	m.Stderr = &buf
+	m.NoColor = true
+	m.Level = WarnLevel
+
+	m.Debug("debug")
+	m.Info("info")
+	m.Warn("warn %d", 1)
+	m.Err("err")
+	if got := buf.String(); got != "[WARN]\twarn 1\n[ERROR]\terr\n" {
+		t.Errorf("Unexpected output at the warn level: %q", got)
+	}
+
+	buf.Reset()
+	m.Level = InfoLevel
+	m.IsDebugging = true
+	m.Debug("debug")
+	m.Output("git", []byte("line 1\n\nline 2\n"))
+	if got := buf.String(); got != "[DEBUG]\tdebug\n[DEBUG]\tgit: line 1\n[DEBUG]\tgit: line 2\n" {
+		t.Errorf("Unexpected debug output: %q", got)
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+	if l, err := ParseLevel("WARNING"); err != nil || l != WarnLevel {
+		t.Errorf("Expected the warn level, got %s (%v)", l, err)
+	}
+}
+
+func TestCombinedOutput(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewMessenger()
+	m.Stderr = &buf
+	m.NoColor = true
+	m.IsDebugging = true
+
+	c := exec.Command("git", "--version")
+	out, err := m.CombinedOutput("git", c)
+	if err != nil {
+		t.Skipf("Unable to run git: %s", err)
+	}
+	if got := buf.String(); got != "[DEBUG]\tgit: "+strings.TrimSpace(string(out))+"\n" {
+		t.Errorf("Expected the output to be logged, got %q", got)
+	}
+	if last := c.Env[len(c.Env)-1]; last != OutputLoggedEnv+"=1" {
+		t.Errorf("Expected the command to be told its output is logged, got %s", last)
+	}
+}
+
+func TestJSONFormatAndLogFile(t *testing.T) {
+	var buf, file bytes.Buffer
+	m := NewMessenger()
+	m.Stderr = &buf
+	m.Format = JSONFormat
+	m.LogFile = &file
+
+	m.Info("Fetching %s", "github.com/Masterminds/vcs")
+	m.Debug("hidden")
+	m.Writer("vcs").Write([]byte("Cloning into 'vcs'...\n"))
+
+	e := &entry{}
+	if err := json.Unmarshal(buf.Bytes(), e); err != nil {
+		t.Fatalf("Unable to parse output %q: %s", buf.String(), err)
+	}
+	if e.Level != "info" || e.Msg != "Fetching github.com/Masterminds/vcs" || e.Time == "" {
+		t.Errorf("Unexpected entry %+v", e)
+	}
+
+	lines := strings.Split(strings.TrimSpace(file.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected every message in the log file, got %q", file.String())
+	}
+	if err := json.Unmarshal([]byte(lines[2]), e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Level != "debug" || e.Source != "vcs" || e.Msg != "Cloning into 'vcs'..." {
+		t.Errorf("Unexpected entry for command output %+v", e)
+	}
+}
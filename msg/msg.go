@@ -7,8 +7,6 @@ import (
 	"os"
 	"strings"
 	"sync"
-
-	"github.com/Masterminds/vcs"
 )
 
 // Messenger provides the underlying implementation that displays output to
@@ -25,6 +23,23 @@ type Messenger struct {
 	// NoColor, if true, will not use color in the output.
 	NoColor bool
 
+	// Level is the lowest level of messages displayed. Quiet and IsDebugging
+	// take precedence over it.
+	Level Level
+
+	// Format is how messages are displayed, TextFormat or JSONFormat. It
+	// defaults to TextFormat.
+	Format string
+
+	// ErrorFormat is how errors are displayed. With JSONFormat each error is
+	// displayed as a JSON object naming its kind and, for the error Glide
+	// exits with, the exit code. It defaults to TextFormat.
+	ErrorFormat string
+
+	// LogFile, if set, receives every message, including debug messages and
+	// command output, in the configured format without color.
+	LogFile io.Writer
+
 	// Stdout is the location where this prints output.
 	Stdout io.Writer
 
@@ -37,6 +52,17 @@ type Messenger struct {
 	// PanicOnDie if true Die() will panic instead of exiting.
 	PanicOnDie bool
 
+	// OnDie, if set, is called by Die before exiting to clean up.
+	OnDie func()
+
+	// Progress, if true, keeps a status line showing what is being done with
+	// each dependency below the messages on Stderr. It should only be set
+	// when Stderr is a terminal.
+	Progress bool
+
+	// The dependency states shown by the status line.
+	progress *progress
+
 	// The default exit code to use when dyping
 	ecode int
 
@@ -65,11 +91,7 @@ var Default = NewMessenger()
 
 // Info logs information
 func (m *Messenger) Info(msg string, args ...interface{}) {
-	if m.Quiet {
-		return
-	}
-	prefix := m.Color(Green, "[INFO]\t")
-	m.Msg(prefix+msg, args...)
+	m.log(InfoLevel, "", msg, args...)
 }
 
 // Info logs information using the Default Messenger
@@ -79,11 +101,7 @@ func Info(msg string, args ...interface{}) {
 
 // Debug logs debug information
 func (m *Messenger) Debug(msg string, args ...interface{}) {
-	if m.Quiet || !m.IsDebugging {
-		return
-	}
-	prefix := "[DEBUG]\t"
-	m.Msg(prefix+msg, args...)
+	m.log(DebugLevel, "", msg, args...)
 }
 
 // Debug logs debug information using the Default Messenger
@@ -93,8 +111,7 @@ func Debug(msg string, args ...interface{}) {
 
 // Warn logs a warning
 func (m *Messenger) Warn(msg string, args ...interface{}) {
-	prefix := m.Color(Yellow, "[WARN]\t")
-	m.Msg(prefix+msg, args...)
+	m.log(WarnLevel, "", msg, args...)
 }
 
 // Warn logs a warning using the Default Messenger
@@ -104,9 +121,7 @@ func Warn(msg string, args ...interface{}) {
 
 // Err logs an error.
 func (m *Messenger) Err(msg string, args ...interface{}) {
-	prefix := m.Color(Red, "[ERROR]\t")
-	m.Msg(prefix+msg, args...)
-	m.hasErrored = true
+	m.logErr(argsKind(args), 0, msg, args...)
 }
 
 // Err logs anderror using the Default Messenger
@@ -115,14 +130,42 @@ func Err(msg string, args ...interface{}) {
 }
 
 // Die prints an error message and immediately exits the application.
-// If PanicOnDie is set to true a panic will occur instead of os.Exit being
-// called.
+// If PanicOnDie is set to true a panic with a *Died value will occur instead of
+// os.Exit being called. The exit code is that of the kind of the first error
+// in args of a known kind, or else the one set with ExitCode.
 func (m *Messenger) Die(msg string, args ...interface{}) {
-	m.Err(msg, args...)
+	m.DieKind(argsKind(args), msg, args...)
+}
+
+// DieKind is like Die for a failure of kind k, which decides the exit code
+// unless it is KindGeneral.
+func (m *Messenger) DieKind(k Kind, msg string, args ...interface{}) {
+	code := k.ExitCode()
+	if code == 0 {
+		m.Lock()
+		code = m.ecode
+		m.Unlock()
+	}
+	m.logErr(k, code, msg, args...)
+	m.EndProgress()
 	if m.PanicOnDie {
-		panic("trapped a Die() call")
+		panic(&Died{Msg: fmt.Sprintf(msg, args...), Kind: k})
+	}
+	if m.OnDie != nil {
+		m.OnDie()
 	}
-	os.Exit(m.ecode)
+	os.Exit(code)
+}
+
+// Died is the value Die panics with when PanicOnDie is set. It holds the
+// message that was displayed and the kind of failure.
+type Died struct {
+	Msg  string
+	Kind Kind
+}
+
+func (d *Died) Error() string {
+	return d.Msg
 }
 
 // Die prints an error message and immediately exits the application using the
@@ -132,6 +175,11 @@ func Die(msg string, args ...interface{}) {
 	Default.Die(msg, args...)
 }
 
+// DieKind is like Die for a failure of kind k using the Default Messenger.
+func DieKind(k Kind, msg string, args ...interface{}) {
+	Default.DieKind(k, msg, args...)
+}
+
 // ExitCode sets the exit code used by Die.
 //
 // The default is 1.
@@ -155,37 +203,29 @@ func ExitCode(e int) int {
 }
 
 // Msg prints a message with optional arguments, that can be printed, of
-// varying types.
+// varying types. It is not prefixed with a level.
 func (m *Messenger) Msg(msg string, args ...interface{}) {
+	if len(args) != 0 {
+		msg = fmt.Sprintf(msg, args...)
+	}
+
 	// When operations in Glide are happening concurrently messaging needs to be
 	// locked to avoid displaying one message in the middle of another one.
 	m.Lock()
 	defer m.Unlock()
-	// Get rid of the annoying fact that messages need \n at the end, but do
-	// it in a backward compatible way.
-	if !strings.HasSuffix(msg, "\n") {
-		msg += "\n"
-	}
 
-	if len(args) == 0 {
-		fmt.Fprint(m.Stderr, msg)
+	if m.Format == JSONFormat {
+		m.write(m.Stderr, InfoLevel, "", strings.TrimSuffix(msg, "\n"), "", false)
 	} else {
-		fmt.Fprintf(m.Stderr, msg, args...)
-	}
-
-	// If an arg is a vcs error print the output if in debug mode. This is
-	// capured here rather than calling Debug because concurrent operations
-	// could cause other messages to appear between the initial error and the
-	// debug output by unlocking and calling Debug.
-	if len(args) != 0 && !m.Quiet && m.IsDebugging {
-		if err, ok := args[len(args)-1].(error); ok {
-			switch t := err.(type) {
-			case *vcs.LocalError:
-				fmt.Fprintf(m.Stderr, "[DEBUG]\tOutput was: %s", strings.TrimSpace(t.Out()))
-			case *vcs.RemoteError:
-				fmt.Fprintf(m.Stderr, "[DEBUG]\tOutput was: %s", strings.TrimSpace(t.Out()))
-			}
+		// Get rid of the annoying fact that messages need \n at the end, but do
+		// it in a backward compatible way.
+		if !strings.HasSuffix(msg, "\n") {
+			msg += "\n"
 		}
+		fmt.Fprint(m.Stderr, msg)
+	}
+	if m.LogFile != nil {
+		m.write(m.LogFile, InfoLevel, "", strings.TrimSuffix(msg, "\n"), "", false)
 	}
 }
 
@@ -206,8 +246,10 @@ func (m *Messenger) Puts(msg string, args ...interface{}) {
 	m.Lock()
 	defer m.Unlock()
 
+	m.clearStatus()
 	fmt.Fprintf(m.Stdout, msg, args...)
 	fmt.Fprintln(m.Stdout)
+	m.drawStatus()
 }
 
 // Puts formats a message and then prints to Stdout using the Default Messenger.
@@ -228,6 +270,7 @@ func (m *Messenger) Print(msg string) {
 	m.Lock()
 	defer m.Unlock()
 
+	m.clearStatus()
 	fmt.Fprint(m.Stdout, msg)
 }
 
@@ -0,0 +1,176 @@
+package msg
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// The states of a dependency shown by the progress display.
+const (
+	Resolving   = "resolving"
+	Fetching    = "fetching"
+	CheckingOut = "checking out"
+	Exporting   = "exporting"
+)
+
+// progressStates lists the states in the order they are displayed.
+var progressStates = []string{Resolving, Fetching, CheckingOut, Exporting}
+
+// statusWidth is the most characters the status line takes up so it fits on a
+// line of a terminal.
+const statusWidth = 79
+
+// progress holds what is being done with each dependency.
+type progress struct {
+	// names holds the dependencies in the order they were first seen.
+	names []string
+
+	// states holds the state of each dependency. It is empty while nothing
+	// is being done with the dependency.
+	states map[string]string
+
+	done map[string]bool
+
+	// shown is the length of the status line on the screen.
+	shown int
+}
+
+// SetState records what is being done with a dependency, one of Resolving,
+// Fetching, CheckingOut, or Exporting, or "" once that is finished. When
+// Progress is set a status line summarizing the states is kept below the
+// messages.
+func (m *Messenger) SetState(name, state string) {
+	if !m.Progress {
+		return
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	p := m.progressState()
+	if _, ok := p.states[name]; !ok {
+		p.names = append(p.names, name)
+	}
+	p.states[name] = state
+	m.drawStatus()
+}
+
+// SetState records what is being done with a dependency using the Default
+// Messenger.
+func SetState(name, state string) {
+	Default.SetState(name, state)
+}
+
+// SetDone records that a dependency is installed.
+func (m *Messenger) SetDone(name string) {
+	if !m.Progress {
+		return
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	p := m.progressState()
+	if _, ok := p.states[name]; !ok {
+		p.names = append(p.names, name)
+	}
+	p.states[name] = ""
+	p.done[name] = true
+	m.drawStatus()
+}
+
+// SetDone records that a dependency is installed using the Default Messenger.
+func SetDone(name string) {
+	Default.SetDone(name)
+}
+
+// EndProgress removes the status line and forgets the recorded states.
+func (m *Messenger) EndProgress() {
+	m.Lock()
+	defer m.Unlock()
+
+	m.clearStatus()
+	m.progress = nil
+}
+
+// EndProgress removes the status line of the Default Messenger.
+func EndProgress() {
+	Default.EndProgress()
+}
+
+func (m *Messenger) progressState() *progress {
+	if m.progress == nil {
+		m.progress = &progress{
+			states: make(map[string]string),
+			done:   make(map[string]bool),
+		}
+	}
+	return m.progress
+}
+
+// clearStatus removes the status line from the screen so a message can be
+// written in its place. The lock must be held.
+func (m *Messenger) clearStatus() {
+	if m.progress == nil || m.progress.shown == 0 {
+		return
+	}
+	fmt.Fprintf(m.Stderr, "\r%s\r", strings.Repeat(" ", m.progress.shown))
+	m.progress.shown = 0
+}
+
+// drawStatus displays the status line, replacing the one on the screen. The
+// lock must be held.
+func (m *Messenger) drawStatus() {
+	if !m.Progress || m.progress == nil {
+		return
+	}
+	s := m.progress.status()
+	pad := m.progress.shown - utf8.RuneCountInString(s)
+	if pad < 0 {
+		pad = 0
+	}
+	fmt.Fprintf(m.Stderr, "\r%s%s\r", s, strings.Repeat(" ", pad))
+	m.progress.shown = utf8.RuneCountInString(s)
+}
+
+// status returns the status line, a bar of the installed dependencies followed
+// by the first dependency in each state, such as:
+//
+//	[#####---------------] 3/12 fetching github.com/foo/bar (+2)
+func (p *progress) status() string {
+	const barWidth = 20
+	total := len(p.names)
+	filled := 0
+	if total > 0 {
+		filled = len(p.done) * barWidth / total
+	}
+	s := fmt.Sprintf("[%s%s] %d/%d", strings.Repeat("#", filled), strings.Repeat("-", barWidth-filled), len(p.done), total)
+
+	var parts []string
+	for _, st := range progressStates {
+		var first string
+		n := 0
+		for _, name := range p.names {
+			if p.states[name] == st {
+				if n == 0 {
+					first = name
+				}
+				n++
+			}
+		}
+		switch {
+		case n == 1:
+			parts = append(parts, st+" "+first)
+		case n > 1:
+			parts = append(parts, fmt.Sprintf("%s %s (+%d)", st, first, n-1))
+		}
+	}
+	if len(parts) > 0 {
+		s += " " + strings.Join(parts, ", ")
+	}
+
+	if utf8.RuneCountInString(s) > statusWidth {
+		r := []rune(s)
+		s = string(r[:statusWidth-3]) + "..."
+	}
+	return s
+}
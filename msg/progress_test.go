@@ -0,0 +1,52 @@
+package msg
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewMessenger()
+	m.Stderr = &buf
+	m.NoColor = true
+
+	// Nothing is displayed unless Progress is set.
+	m.SetState("example.com/a", Fetching)
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output without Progress, got %q", buf.String())
+	}
+
+	m.Progress = true
+	m.SetState("example.com/a", Fetching)
+	m.SetState("example.com/b", Fetching)
+	m.SetDone("example.com/c")
+	want := "[######--------------] 1/3 fetching example.com/a (+1)"
+	if got := m.progress.status(); got != want {
+		t.Errorf("Expected status %q, got %q", want, got)
+	}
+
+	// Messages replace the status line, which is drawn again below them.
+	buf.Reset()
+	m.Info("hello")
+	if got := buf.String(); !strings.HasPrefix(got, "\r"+strings.Repeat(" ", len(want))+"\r[INFO]\thello\n\r"+want) {
+		t.Errorf("Unexpected output around the status line: %q", got)
+	}
+
+	m.SetState("example.com/a", "")
+	m.SetState("example.com/b", CheckingOut)
+	if got := m.progress.status(); got != "[######--------------] 1/3 checking out example.com/b" {
+		t.Errorf("Unexpected status %q", got)
+	}
+
+	m.SetState("example.com/"+strings.Repeat("x", 100), Exporting)
+	if got := m.progress.status(); len(got) != statusWidth || !strings.HasSuffix(got, "...") {
+		t.Errorf("Expected the status to be shortened, got %q", got)
+	}
+
+	m.EndProgress()
+	if m.progress != nil || !strings.HasSuffix(buf.String(), "\r") {
+		t.Error("Expected the status line to be removed")
+	}
+}
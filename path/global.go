@@ -0,0 +1,82 @@
+package path
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// GlobalConfigFile is the name of the file in GLIDE_HOME holding the global
+// settings.
+const GlobalConfigFile = "config.yaml"
+
+// globalConfig is the last parsed config.yaml file. It is parsed again only
+// when the home directory or the file changes.
+var globalConfig struct {
+	sync.Mutex
+	path     string
+	modTime  time.Time
+	size     int64
+	sections map[string]interface{}
+	err      error
+}
+
+// GlobalConfig reads the section named key of the config.yaml file in
+// GLIDE_HOME into v. The file is parsed once and shared by all the sections
+// read from it. A missing file or section leaves v untouched.
+func GlobalConfig(key string, v interface{}) error {
+	sections, err := globalSections()
+	if err != nil {
+		return err
+	}
+	s, ok := sections[key]
+	if !ok {
+		return nil
+	}
+	yml, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("Error reading config.yaml file: %s", err)
+	}
+	if err := yaml.Unmarshal(yml, v); err != nil {
+		return fmt.Errorf("Error reading config.yaml file: %s", err)
+	}
+	return nil
+}
+
+// globalSections returns the top-level sections of the config.yaml file in
+// GLIDE_HOME, parsing the file when it was not parsed before.
+func globalSections() (map[string]interface{}, error) {
+	p := filepath.Join(Home(), GlobalConfigFile)
+	fi, err := os.Stat(p)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	c := &globalConfig
+	c.Lock()
+	defer c.Unlock()
+	if c.path == p && c.modTime.Equal(fi.ModTime()) && c.size == fi.Size() {
+		return c.sections, c.err
+	}
+
+	yml, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	c.path, c.modTime, c.size = p, fi.ModTime(), fi.Size()
+	c.sections, c.err = nil, nil
+	sections := map[string]interface{}{}
+	if err := yaml.Unmarshal(yml, &sections); err != nil {
+		c.err = fmt.Errorf("Error reading config.yaml file: %s", err)
+		return nil, c.err
+	}
+	c.sections = sections
+	return sections, nil
+}
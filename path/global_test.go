@@ -0,0 +1,67 @@
+package path
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGlobalConfig(t *testing.T) {
+	home, err := ioutil.TempDir("", "glide-global")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer SetHome(Home())
+	SetHome(home)
+
+	type section struct {
+		Timeout string `yaml:"timeout"`
+		Retries int    `yaml:"retries"`
+	}
+
+	// Without a config.yaml file nothing is read.
+	s := &section{Retries: 1}
+	if err := GlobalConfig("network", s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Retries != 1 {
+		t.Errorf("Expected a missing config.yaml to leave the section alone, got %+v", s)
+	}
+
+	p := filepath.Join(home, GlobalConfigFile)
+	if err := ioutil.WriteFile(p, []byte("network:\n  timeout: 2m\n  retries: 3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s = &section{}
+	if err := GlobalConfig("network", s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Timeout != "2m" || s.Retries != 3 {
+		t.Errorf("Unexpected network section %+v", s)
+	}
+	var hooks []string
+	if err := GlobalConfig("hooks", &hooks); err != nil || hooks != nil {
+		t.Errorf("Expected a missing section to be empty, got %v, %v", hooks, err)
+	}
+
+	// A changed file is parsed again.
+	if err := ioutil.WriteFile(p, []byte("network:\n  retries: 5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s = &section{}
+	if err := GlobalConfig("network", s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Timeout != "" || s.Retries != 5 {
+		t.Errorf("Expected the changed config.yaml to be read, got %+v", s)
+	}
+
+	if err := ioutil.WriteFile(p, []byte("network: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := GlobalConfig("network", s); err == nil {
+		t.Error("Expected an invalid config.yaml to be an error")
+	}
+}
@@ -0,0 +1,178 @@
+package path
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFile is the name of the file listing paths Glide skips within a
+// project or package, in .gitignore syntax.
+const IgnoreFile = ".glideignore"
+
+// Ignore holds the patterns of an ignore file.
+type Ignore struct {
+	patterns []ignorePattern
+}
+
+type ignorePattern struct {
+	// glob is the pattern without the markers below. It holds slash
+	// separated segments.
+	glob string
+
+	// negate re-includes paths matched by an earlier pattern (!pattern).
+	negate bool
+
+	// dirOnly matches directories only (pattern/).
+	dirOnly bool
+
+	// anchored matches paths relative to the directory of the ignore file
+	// rather than at any depth. Patterns with a slash before the last
+	// character are anchored.
+	anchored bool
+}
+
+// ReadIgnore reads the ignore file in dir. An Ignore matching nothing is
+// returned when there is no such file.
+func ReadIgnore(dir string) (*Ignore, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, IgnoreFile))
+	if os.IsNotExist(err) {
+		return &Ignore{}, nil
+	} else if err != nil {
+		return &Ignore{}, err
+	}
+	return ParseIgnore(string(b)), nil
+}
+
+// ParseIgnore parses the patterns of an ignore file. Blank lines and lines
+// starting with # are skipped.
+func ParseIgnore(text string) *Ignore {
+	ig := &Ignore{}
+	s := bufio.NewScanner(strings.NewReader(text))
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p := ignorePattern{}
+		if strings.HasPrefix(line, "!") {
+			p.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			p.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		p.glob = line
+		ig.patterns = append(ig.patterns, p)
+	}
+	return ig
+}
+
+// Match returns true if the path, relative to the directory of the ignore
+// file and slash separated, is ignored. The last matching pattern decides.
+// Paths in ignored directories are not matched themselves; callers skip the
+// contents of ignored directories.
+func (ig *Ignore) Match(rel string, isDir bool) bool {
+	if ig == nil {
+		return false
+	}
+	rel = strings.Trim(rel, "/")
+	ignored := false
+	for _, p := range ig.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if p.match(rel) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// Empty returns true when there are no patterns.
+func (ig *Ignore) Empty() bool {
+	return ig == nil || len(ig.patterns) == 0
+}
+
+func (p ignorePattern) match(rel string) bool {
+	if !p.anchored {
+		// Unanchored patterns match the name of the file or directory.
+		ok, _ := path.Match(p.glob, path.Base(rel))
+		return ok
+	}
+	return matchSegments(strings.Split(p.glob, "/"), strings.Split(rel, "/"))
+}
+
+// matchSegments matches path segments against pattern segments, where a **
+// segment matches any number of path segments.
+func matchSegments(pat, segs []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(segs); i++ {
+				if matchSegments(pat[1:], segs[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segs) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], segs[0]); !ok {
+			return false
+		}
+		pat, segs = pat[1:], segs[1:]
+	}
+	return len(segs) == 0
+}
+
+// RemoveIgnored deletes the files and directories under dir matched by the
+// ignore file in dir, if there is one.
+func RemoveIgnored(dir string) error {
+	ig, err := ReadIgnore(dir)
+	if err != nil || ig.Empty() {
+		return err
+	}
+	var remove []string
+	err = filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == dir {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if ig.Match(filepath.ToSlash(rel), fi.IsDir()) {
+			remove = append(remove, p)
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, p := range remove {
+		if err := os.RemoveAll(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package path
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreMatch(t *testing.T) {
+	ig := ParseIgnore(`# Generated code and examples
+/examples/
+generated/
+*.pb.go
+!keep.pb.go
+internal/**/fixtures
+`)
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"examples", true, true},
+		{"examples", false, false},
+		{"sub/examples", true, false},
+		{"generated", true, true},
+		{"sub/generated", true, true},
+		{"generated", false, false},
+		{"api/foo.pb.go", false, true},
+		{"api/keep.pb.go", false, false},
+		{"api/foo.go", false, false},
+		{"internal/fixtures", true, true},
+		{"internal/a/b/fixtures", true, true},
+		{"fixtures", true, false},
+	}
+	for _, tt := range tests {
+		if got := ig.Match(tt.path, tt.isDir); got != tt.ignored {
+			t.Errorf("Expected %s (dir: %t) ignored to be %t", tt.path, tt.isDir, tt.ignored)
+		}
+	}
+
+	if (&Ignore{}).Match("anything", false) {
+		t.Error("Expected an empty ignore file to match nothing")
+	}
+}
+
+func TestRemoveIgnored(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-ignore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := []string{"foo.go", "examples/main.go", "api/foo.pb.go", "api/keep.go"}
+	for _, f := range files {
+		p := filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte("package foo"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, IgnoreFile), []byte("examples/\n*.pb.go\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RemoveIgnored(dir); err != nil {
+		t.Fatal(err)
+	}
+	for f, exists := range map[string]bool{"foo.go": true, "examples": false, "api/foo.pb.go": false, "api/keep.go": true} {
+		_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(f)))
+		if (err == nil) != exists {
+			t.Errorf("Expected %s to exist to be %t", f, exists)
+		}
+	}
+}
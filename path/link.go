@@ -0,0 +1,122 @@
+package path
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Masterminds/glide/msg"
+)
+
+// The ways files can be placed in the vendor directory by LinkTree.
+const (
+	// LinkAuto uses a reflink where the filesystem supports it and falls
+	// back to copying. Hardlinks are never used unless asked for.
+	LinkAuto = "auto"
+
+	// LinkReflink uses copy-on-write clones of the files.
+	LinkReflink = "reflink"
+
+	// LinkHardlink uses hardlinks to the files, which share their content
+	// with the cache.
+	LinkHardlink = "hardlink"
+
+	// LinkCopy copies the files.
+	LinkCopy = "copy"
+)
+
+// LinkMode sets how LinkTree places files. It is one of LinkAuto,
+// LinkReflink, LinkHardlink, or LinkCopy.
+//
+// Note, hardlinked files share their content with the cache, so files in the
+// vendor directory are changed with ReplaceFile rather than written in place.
+var LinkMode = LinkAuto
+
+// ValidLinkMode returns an error if mode is not a known link mode.
+func ValidLinkMode(mode string) error {
+	switch mode {
+	case LinkAuto, LinkReflink, LinkHardlink, LinkCopy:
+		return nil
+	}
+	return fmt.Errorf("Unknown link mode %q: must be one of auto|reflink|hardlink|copy", mode)
+}
+
+// LinkTree recreates the directory tree at from within to. Directories are
+// created while files are placed according to LinkMode. Symbolic links and
+// directory junctions are recreated as symbolic links.
+func LinkTree(from, to string) error {
+	from, to = LongPath(from), LongPath(to)
+	return filepath.Walk(from, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(from, p)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(to, rel)
+
+		switch {
+		case p != from && isLinkOrJunction(p, fi):
+			ln, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			if err := os.Symlink(ln, dest); err != nil {
+				return err
+			}
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		case fi.IsDir():
+			return os.MkdirAll(dest, fi.Mode().Perm()|0700)
+		}
+		return linkFile(p, dest)
+	})
+}
+
+// linkFile places the file at from at to following LinkMode.
+func linkFile(from, to string) error {
+	switch LinkMode {
+	case LinkReflink:
+		return reflink(from, to)
+	case LinkHardlink:
+		return os.Link(from, to)
+	case LinkCopy:
+		return CopyFile(from, to)
+	}
+
+	if err := reflink(from, to); err == nil {
+		return nil
+	}
+	msg.Debug("Unable to reflink %s, copying instead", from)
+	return CopyFile(from, to)
+}
+
+// ReplaceFile replaces the file at p with one holding data. The new file is
+// written next to it and renamed over it, so a file hardlinked to the cache
+// is unlinked from it rather than changed along with the cached copy. Every
+// change to a file in the vendor directory goes through ReplaceFile.
+func ReplaceFile(p string, data []byte, mode os.FileMode) error {
+	f, err := ioutil.TempFile(filepath.Dir(p), "."+filepath.Base(p)+".glide-")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, mode.Perm())
+	}
+	if err == nil {
+		err = os.Rename(tmp, p)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package path
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestLinkTree(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-link")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	from := filepath.Join(dir, "from")
+	if err := os.MkdirAll(filepath.Join(from, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(from, "sub", "a.go"), []byte("package sub"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Creating symlinks on Windows requires extra privileges.
+	symlinks := runtime.GOOS != "windows"
+	if symlinks {
+		if err := os.Symlink("sub", filepath.Join(from, "link")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	defer func(m string) { LinkMode = m }(LinkMode)
+	for _, m := range []string{LinkAuto, LinkHardlink, LinkCopy} {
+		LinkMode = m
+		to := filepath.Join(dir, "to-"+m)
+		if err := LinkTree(from, to); err != nil {
+			t.Fatalf("LinkTree with %s failed: %s", m, err)
+		}
+		b, err := ioutil.ReadFile(filepath.Join(to, "sub", "a.go"))
+		if err != nil || string(b) != "package sub" {
+			t.Errorf("Unexpected content with %s: %q (%v)", m, b, err)
+		}
+		if !symlinks {
+			continue
+		}
+		if ln, err := os.Readlink(filepath.Join(to, "link")); err != nil || ln != "sub" {
+			t.Errorf("Expected the symlink to be recreated with %s, got %q (%v)", m, ln, err)
+		}
+	}
+
+	if err := ValidLinkMode("symlink"); err == nil {
+		t.Error("Expected an unknown link mode to be invalid")
+	}
+}
+
+func TestReplaceFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-replace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cached := filepath.Join(dir, "cached.go")
+	vendored := filepath.Join(dir, "vendored.go")
+	if err := ioutil.WriteFile(cached, []byte("package a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(cached, vendored); err != nil {
+		t.Skipf("Unable to create a hardlink: %s", err)
+	}
+
+	if err := ReplaceFile(vendored, []byte("package b"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(vendored); string(b) != "package b" {
+		t.Errorf("Expected the file to be replaced, got %q", b)
+	}
+	if b, _ := ioutil.ReadFile(cached); string(b) != "package a" {
+		t.Errorf("Expected the hardlinked file to be left alone, got %q", b)
+	}
+	if fi, err := os.Stat(vendored); err != nil || (runtime.GOOS != "windows" && fi.Mode().Perm() != 0640) {
+		t.Errorf("Expected the mode to be set, got %v (%v)", fi, err)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 2 {
+		t.Errorf("Expected no temporary files to be left, got %d files", len(files))
+	}
+}
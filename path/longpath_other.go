@@ -0,0 +1,16 @@
+// +build !windows
+
+package path
+
+import "os"
+
+// LongPath returns p as is. Only Windows limits the length of paths.
+func LongPath(p string) string {
+	return p
+}
+
+// isLinkOrJunction returns true if fi, as returned by os.Lstat for p, is a
+// symbolic link. Directory junctions only exist on Windows.
+func isLinkOrJunction(p string, fi os.FileInfo) bool {
+	return fi.Mode()&os.ModeSymlink != 0
+}
@@ -0,0 +1,52 @@
+// +build windows
+
+package path
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// longPathPrefix lifts the 260 character limit of Windows paths. Paths with it
+// must be absolute and are not normalized by Windows.
+const longPathPrefix = `\\?\`
+
+// LongPath returns p as an absolute path with the \\?\ prefix so deep nested
+// vendor directories can be handled. Paths within it, such as those found by
+// walking it, carry the prefix along. p is returned as is when it cannot be
+// made absolute.
+func LongPath(p string) string {
+	if strings.HasPrefix(p, longPathPrefix) {
+		return p
+	}
+	a, err := filepath.Abs(p)
+	if err != nil {
+		return p
+	}
+	if strings.HasPrefix(a, `\\`) {
+		// A UNC path, \\server\share, becomes \\?\UNC\server\share.
+		return longPathPrefix + `UNC\` + a[2:]
+	}
+	return longPathPrefix + a
+}
+
+// isLinkOrJunction returns true if p is a symbolic link or a directory
+// junction. Both are reparse points that must be removed themselves rather
+// than have the directory they point to emptied. fi, as returned by os.Lstat
+// for p, may be nil.
+func isLinkOrJunction(p string, fi os.FileInfo) bool {
+	if fi != nil && fi.Mode()&os.ModeSymlink != 0 {
+		return true
+	}
+	ptr, err := syscall.UTF16PtrFromString(p)
+	if err != nil {
+		return false
+	}
+	attrs, err := syscall.GetFileAttributes(ptr)
+	if err != nil {
+		return false
+	}
+	return attrs&syscall.FILE_ATTRIBUTE_REPARSE_POINT != 0
+}
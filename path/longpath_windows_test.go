@@ -0,0 +1,18 @@
+package path
+
+import "testing"
+
+func TestLongPath(t *testing.T) {
+	tests := []struct {
+		in, out string
+	}{
+		{`C:\src\app\vendor`, `\\?\C:\src\app\vendor`},
+		{`\\?\C:\src\app\vendor`, `\\?\C:\src\app\vendor`},
+		{`\\server\share\app\vendor`, `\\?\UNC\server\share\app\vendor`},
+	}
+	for _, tt := range tests {
+		if got := LongPath(tt.in); got != tt.out {
+			t.Errorf("Expected LongPath(%q) to be %q, got %q", tt.in, tt.out, got)
+		}
+	}
+}
@@ -23,6 +23,10 @@ const DefaultGlideFile = "glide.yaml"
 // As of Go 1.5, this is always vendor.
 var VendorDir = "vendor"
 
+// TestVendorDir is the name of the directory that holds the dependencies only
+// used by tests when they are kept out of VendorDir. It is empty otherwise.
+var TestVendorDir = ""
+
 // Tmp is the temporary directory Glide should use. Defaults to "" which
 // signals using the system default.
 var Tmp = ""
@@ -39,6 +43,10 @@ var GlideFile = DefaultGlideFile
 // LockFile is the default name for the lock file.
 const LockFile = "glide.lock"
 
+// ProjectLockFile is the name of the file held by the Glide process changing
+// the vendor/ directory and lock file of a project.
+const ProjectLockFile = ".glide-lock.json"
+
 func init() {
 
 	// As of Go 1.8 the GOPATH is no longer required to be set. Instead there
@@ -128,6 +136,24 @@ func Vendor() (string, error) {
 	return gopath, nil
 }
 
+// VendorForTests returns the path to the directory holding the dependencies only
+// used by tests, next to the closest glide file. It returns an empty string
+// when TestVendorDir is not set.
+func VendorForTests() (string, error) {
+	if TestVendorDir == "" {
+		return "", nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	yamldir, err := GlideWD(cwd)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(yamldir, TestVendorDir), nil
+}
+
 // Glide gets the path to the closest glide file.
 func Glide() (string, error) {
 	cwd, err := os.Getwd()
@@ -246,6 +272,7 @@ func IsDirectoryEmpty(dir string) (bool, error) {
 // We copy the directory here rather than jumping out to a shell so we can
 // support multiple operating systems.
 func CopyDir(source string, dest string) error {
+	source, dest = LongPath(source), LongPath(dest)
 
 	// get properties of source dir
 	si, err := os.Stat(source)
@@ -272,7 +299,9 @@ func CopyDir(source string, dest string) error {
 
 		dp := filepath.Join(dest, "/", obj.Name())
 
-		if obj.IsDir() {
+		// Junctions are copied as links, like symbolic links, rather than
+		// copying the directory they point to.
+		if obj.IsDir() && !isLinkOrJunction(sp, obj) {
 			err = CopyDir(sp, dp)
 			if err != nil {
 				return err
@@ -0,0 +1,217 @@
+package path
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/glide/msg"
+)
+
+// legalPrefixes are the beginnings of file names that hold licensing or other
+// legal information. These files are never pruned.
+var legalPrefixes = []string{
+	"license",
+	"licence",
+	"copying",
+	"copyright",
+	"notice",
+	"patents",
+	"authors",
+	"contributors",
+	"unlicense",
+}
+
+// IsLegalFile returns true if the file name looks like a license or other
+// legal notice that needs to be retained alongside the source.
+func IsLegalFile(name string) bool {
+	n := strings.ToLower(name)
+	for _, p := range legalPrefixes {
+		if strings.HasPrefix(n, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildExts are the extensions of the files other than Go source the go tool
+// builds packages from: cgo, assembly, SWIG, and system object files.
+var buildExts = map[string]bool{
+	".c": true, ".h": true, ".cc": true, ".cpp": true, ".cxx": true,
+	".hh": true, ".hpp": true, ".hxx": true, ".m": true, ".s": true,
+	".S": true, ".sx": true, ".f": true, ".F": true, ".for": true,
+	".f90": true, ".swig": true, ".swigcxx": true, ".syso": true,
+}
+
+// docExts are the extensions of documentation files, which no build needs.
+var docExts = map[string]bool{
+	".md": true, ".markdown": true, ".rst": true, ".adoc": true,
+}
+
+// PruneVendor removes the Go source of packages in the vendor directory that
+// are not listed in used. The keys of used are absolute paths to package
+// directories.
+//
+// When nonGo is true the other files of the packages not used are removed as
+// well, along with documentation everywhere. Used packages keep every file the
+// go tool may build them from, such as assembly and cgo sources, and
+// directories without Go source keep those files too as packages may include
+// them. Legal files such as licenses are never removed. Nested vendor/
+// directories are skipped because the packages within them are resolved
+// differently. Directories left empty are removed.
+//
+// The number of pruned packages is returned.
+func PruneVendor(vdir string, used map[string]bool, nonGo bool) (int, error) {
+	vdir, err := filepath.Abs(vdir)
+	if err != nil {
+		return 0, err
+	}
+
+	// The directories are gathered before removing anything because Walk
+	// reads the contents of a directory before visiting it.
+	var dirs []string
+	err = filepath.Walk(vdir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() || p == vdir {
+			return nil
+		}
+		if fi.Name() == "vendor" {
+			msg.Debug("Skipping nested vendor directory %s", p)
+			return filepath.SkipDir
+		}
+		dirs = append(dirs, p)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, p := range dirs {
+		files, err := ioutil.ReadDir(p)
+		if err != nil {
+			return count, err
+		}
+		keep := used[p]
+		isPkg := false
+		for _, f := range files {
+			if !f.IsDir() && filepath.Ext(f.Name()) == ".go" {
+				isPkg = true
+			}
+		}
+		removed := false
+		for _, f := range files {
+			if f.IsDir() {
+				continue
+			}
+			if !pruneFile(f.Name(), keep, isPkg, nonGo) {
+				continue
+			}
+			if err := os.Remove(filepath.Join(p, f.Name())); err != nil {
+				return count, err
+			}
+			if filepath.Ext(f.Name()) == ".go" {
+				removed = true
+			}
+		}
+		if removed {
+			msg.Debug("Pruned %s", p)
+			count++
+		}
+	}
+
+	return count, removeEmptyDirs(vdir)
+}
+
+// pruneFile returns true when the file name is to be removed from a directory
+// of the vendor directory. used is whether the directory is a used package and
+// isPkg whether it holds Go source.
+func pruneFile(name string, used, isPkg, nonGo bool) bool {
+	ext := filepath.Ext(name)
+	switch {
+	case ext == ".go":
+		return !used
+	case !nonGo || IsLegalFile(name):
+		return false
+	case docExts[strings.ToLower(ext)]:
+		return true
+	case used:
+		return false
+	case buildExts[ext]:
+		return isPkg
+	}
+	return true
+}
+
+// removeEmptyDirs removes the empty directories below dir.
+func removeEmptyDirs(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if !f.IsDir() {
+			continue
+		}
+		p := filepath.Join(dir, f.Name())
+		if err := removeEmptyDirs(p); err != nil {
+			return err
+		}
+		empty, err := IsDirectoryEmpty(p)
+		if err != nil {
+			return err
+		}
+		if empty {
+			if err := os.Remove(p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// KeepSubpackages removes everything in the package at dir except the listed
+// subpackages, given relative to dir with forward slashes. A subpackage keeps
+// its whole subtree. "." or "" keeps the files of the package at dir itself,
+// but none of its subdirectories. Legal files are kept at dir and in the
+// directories leading to a kept subpackage. Directories left empty are
+// removed.
+func KeepSubpackages(dir string, subs []string) error {
+	trees := make(map[string]bool, len(subs))
+	parents := map[string]bool{".": true}
+	for _, s := range subs {
+		s = filepath.Clean(filepath.FromSlash(s))
+		trees[s] = true
+		for p := filepath.Dir(s); p != "."; p = filepath.Dir(p) {
+			parents[p] = true
+		}
+	}
+
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			if trees[rel] && rel != "." {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		d := filepath.Dir(rel)
+		if (d == "." && trees["."]) || (parents[d] && IsLegalFile(fi.Name())) {
+			return nil
+		}
+		return os.Remove(p)
+	})
+	if err != nil {
+		return err
+	}
+	return removeEmptyDirs(dir)
+}
@@ -0,0 +1,138 @@
+package path
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPruneVendor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-prune")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := []string{
+		"example.com/used/a.go",
+		"example.com/used/README.md",
+		"example.com/used/LICENSE",
+		"example.com/used/asm_amd64.s",
+		"example.com/used/cgo.c",
+		"example.com/used/data.bin",
+		"example.com/include/defs.h",
+		"example.com/include/notes.txt",
+		"example.com/gone/asm_amd64.s",
+		"example.com/used/unused/b.go",
+		"example.com/gone/c.go",
+		"example.com/gone/LICENSE",
+		"example.com/nested/vendor/example.com/x/d.go",
+	}
+	for _, f := range files {
+		p := filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte("package x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	used := map[string]bool{
+		filepath.Join(dir, "example.com", "used"): true,
+	}
+	count, err := PruneVendor(dir, used, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 packages to be pruned, got %d", count)
+	}
+
+	exists := map[string]bool{
+		"example.com/used/a.go":                        true,
+		"example.com/used/README.md":                   false,
+		"example.com/used/LICENSE":                     true,
+		"example.com/used/asm_amd64.s":                 true,
+		"example.com/used/cgo.c":                       true,
+		"example.com/used/data.bin":                    true,
+		"example.com/include/defs.h":                   true,
+		"example.com/include/notes.txt":                false,
+		"example.com/gone/asm_amd64.s":                 false,
+		"example.com/used/unused":                      false,
+		"example.com/gone/c.go":                        false,
+		"example.com/gone/LICENSE":                     true,
+		"example.com/nested/vendor/example.com/x/d.go": true,
+	}
+	for f, e := range exists {
+		_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(f)))
+		if e && err != nil {
+			t.Errorf("Expected %s to exist", f)
+		} else if !e && err == nil {
+			t.Errorf("Expected %s to be removed", f)
+		}
+	}
+}
+
+func TestKeepSubpackages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-keep")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := []string{
+		"root.go",
+		"LICENSE",
+		"README.md",
+		"api/v1/types.go",
+		"api/v1/client/client.go",
+		"api/v2/types.go",
+		"api/NOTICE",
+		"api/doc.go",
+		"cmd/tool/main.go",
+	}
+	for _, f := range files {
+		p := filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte("package x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := KeepSubpackages(dir, []string{"api/v1"}); err != nil {
+		t.Fatal(err)
+	}
+	exists := map[string]bool{
+		"root.go":                 false,
+		"LICENSE":                 true,
+		"README.md":               false,
+		"api/v1/types.go":         true,
+		"api/v1/client/client.go": true,
+		"api/v2":                  false,
+		"api/NOTICE":              true,
+		"api/doc.go":              false,
+		"cmd":                     false,
+	}
+	for f, e := range exists {
+		_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(f)))
+		if e && err != nil {
+			t.Errorf("Expected %s to exist", f)
+		} else if !e && err == nil {
+			t.Errorf("Expected %s to be removed", f)
+		}
+	}
+
+	if err := KeepSubpackages(dir, []string{"."}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "api")); err == nil {
+		t.Error("Expected only the root package to be kept")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "LICENSE")); err != nil {
+		t.Error("Expected the LICENSE to be kept")
+	}
+}
@@ -0,0 +1,42 @@
+// +build linux
+
+package path
+
+import (
+	"os"
+	"syscall"
+)
+
+// ficlone is the FICLONE ioctl request. It makes the destination file share
+// the data of the source until either is changed.
+const ficlone = 0x40049409
+
+// reflink creates to as a copy-on-write clone of from. It fails when the
+// filesystem does not support clones, such as when the files are on
+// different filesystems.
+func reflink(from, to string) error {
+	s, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	fi, err := s.Stat()
+	if err != nil {
+		return err
+	}
+
+	d, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fi.Mode())
+	if err != nil {
+		return err
+	}
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, d.Fd(), ficlone, s.Fd())
+	if errno != 0 {
+		d.Close()
+		os.Remove(to)
+		return &os.LinkError{Op: "reflink", Old: from, New: to, Err: errno}
+	}
+
+	return d.Close()
+}
@@ -0,0 +1,16 @@
+// +build !linux
+
+package path
+
+import (
+	"errors"
+	"os"
+)
+
+// errNoReflink is returned where Glide does not support reflinks.
+var errNoReflink = errors.New("reflinks are not supported on this system")
+
+// reflink is not supported on this system so it always fails.
+func reflink(from, to string) error {
+	return &os.LinkError{Op: "reflink", Old: from, New: to, Err: errNoReflink}
+}
@@ -1,7 +1,9 @@
 package path
 
 import (
+	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 
 	"github.com/Masterminds/glide/godep/strip"
@@ -21,7 +23,6 @@ func getWalkFunction(searchPath string, removeAll func(p string) error) func(pat
 		}
 
 		if info.Name() == "vendor" && info.IsDir() {
-			msg.Info("Removing: %s", path)
 			err = removeAll(path)
 			if nil != err {
 				return err
@@ -43,7 +44,12 @@ func StripVendor() error {
 		return err
 	}
 
-	err := filepath.Walk(searchPath, getWalkFunction(searchPath, CustomRemoveAll))
+	searchPath = LongPath(searchPath)
+	removeAll := func(p string) error {
+		msg.Info("Removing: %s", p)
+		return CustomRemoveAll(p)
+	}
+	err := filepath.Walk(searchPath, getWalkFunction(searchPath, removeAll))
 
 	if err != nil {
 		return err
@@ -51,3 +57,110 @@ func StripVendor() error {
 
 	return strip.GodepWorkspace(searchPath)
 }
+
+// stripWorkers is the number of dependencies StripVendorDeps strips at once.
+// It is bounded as the work is mostly waiting on the filesystem, which may be
+// a network one.
+var stripWorkers = 8
+
+// StripVendorDeps removes nested vendor and Godeps/_workspace/ directories
+// from the named dependencies only. This avoids walking the entire vendor
+// directory when just a few dependencies changed. Dependencies missing from
+// the vendor directory are skipped. Several dependencies are stripped at once
+// while what is removed is reported in the order of the names. The first
+// error is returned once all of them were processed.
+func StripVendorDeps(names []string) error {
+	searchPath, _ := Vendor()
+	names = outermost(names)
+
+	type result struct {
+		lines []string
+		err   error
+	}
+	results := make([]result, len(names))
+	done := make([]chan struct{}, len(names))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	jobs := make(chan int)
+	for w := 0; w < stripWorkers && w < len(names); w++ {
+		go func() {
+			for i := range jobs {
+				r := &results[i]
+				info := func(format string, v ...interface{}) {
+					r.lines = append(r.lines, fmt.Sprintf(format, v...))
+				}
+				r.err = stripDep(filepath.Join(searchPath, filepath.FromSlash(names[i])), names[i], info)
+				close(done[i])
+			}
+		}()
+	}
+	go func() {
+		for i := range names {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+
+	var err error
+	for i := range names {
+		<-done[i]
+		for _, l := range results[i].lines {
+			msg.Info("%s", l)
+		}
+		if results[i].err != nil && err == nil {
+			err = results[i].err
+		}
+	}
+	return err
+}
+
+// stripDep removes nested vendor and Godeps/_workspace/ directories from the
+// dependency at p, reporting what it removes with info.
+func stripDep(p, name string, info func(string, ...interface{})) error {
+	p = LongPath(p)
+	if _, err := os.Stat(p); err != nil {
+		if os.IsNotExist(err) {
+			msg.Debug("%s is not in the vendor directory.", name)
+			return nil
+		}
+		return err
+	}
+
+	removeAll := func(path string) error {
+		info("Removing: %s", path)
+		return CustomRemoveAll(path)
+	}
+	if err := filepath.Walk(p, getWalkFunction(p, removeAll)); err != nil {
+		return err
+	}
+	return strip.GodepWorkspaceWith(p, info)
+}
+
+// outermost returns the names leaving out those within another one, as
+// stripping that one covers them.
+func outermost(names []string) []string {
+	listed := make(map[string]bool, len(names))
+	for _, n := range names {
+		listed[n] = true
+	}
+	var out []string
+	seen := make(map[string]bool, len(names))
+	for _, n := range names {
+		if seen[n] {
+			continue
+		}
+		seen[n] = true
+		within := false
+		for p := path.Dir(n); p != "." && p != "/"; p = path.Dir(p) {
+			if listed[p] {
+				within = true
+				break
+			}
+		}
+		if !within {
+			out = append(out, n)
+		}
+	}
+	return out
+}
@@ -42,3 +42,46 @@ func TestNestVendorNoError(t *testing.T) {
 		t.Errorf("Unexpected error in StripVendor: %s", err.Error())
 	}
 }
+
+func TestStripVendorDeps(t *testing.T) {
+	workingDir := generateTestDirectory(t)
+	defer os.RemoveAll(workingDir)
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(workingDir)
+
+	err := StripVendorDeps([]string{
+		"github.com/phoney/foo",
+		"github.com/aws/aws-sdk-go",
+		"github.com/aws/aws-sdk-go/awsmigrate",
+		"github.com/missing/dep",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error in StripVendorDeps: %s", err)
+	}
+	for _, p := range []string{
+		"github.com/phoney/foo/vendor",
+		"github.com/aws/aws-sdk-go/vendor",
+		"github.com/aws/aws-sdk-go/awsmigrate/awsmigrate-renamer/vendor",
+	} {
+		if _, err := os.Stat(path.Join(workingDir, "vendor", p)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed", p)
+		}
+	}
+	if _, err := os.Stat(path.Join(workingDir, "vendor", "github.com/fake/log", "log.go")); err != nil {
+		t.Error("Expected other dependencies to be left alone")
+	}
+}
+
+func TestOutermost(t *testing.T) {
+	got := outermost([]string{"github.com/a/b", "github.com/a/b-c", "github.com/a/b/sub", "github.com/a/b", "github.com/x/y"})
+	want := []string{"github.com/a/b", "github.com/a/b-c", "github.com/x/y"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	}
+}
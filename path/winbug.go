@@ -43,8 +43,13 @@ func CustomRemoveAll(p string) error {
 
 	// Handle the windows case first
 	if runtime.GOOS == "windows" {
+		// A junction is removed itself. rd would empty the directory it
+		// points to.
+		if isLinkOrJunction(p, nil) {
+			return os.Remove(LongPath(p))
+		}
 		msg.Debug("Detected Windows. Removing files using windows command")
-		cmd := exec.Command("cmd.exe", "/c", "rd", "/s", "/q", p)
+		cmd := exec.Command("cmd.exe", "/c", "rd", "/s", "/q", LongPath(p))
 		output, err := cmd.CombinedOutput()
 		if err != nil {
 			exitCode := getExitCode(err)
@@ -71,6 +76,10 @@ func CustomRename(o, n string) error {
 
 	// Handking windows cases first
 	if runtime.GOOS == "windows" {
+		// Renaming with long paths copes with deep trees that move does not.
+		if err := os.Rename(LongPath(o), LongPath(n)); err == nil {
+			return nil
+		}
 		msg.Debug("Detected Windows. Moving files using windows command")
 		cmd := exec.Command("cmd.exe", "/c", "move", o, n)
 		output, err := cmd.CombinedOutput()
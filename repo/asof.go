@@ -0,0 +1,61 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	v "github.com/Masterminds/vcs"
+)
+
+// asOfCommit returns the last commit made before the asof time of a
+// dependency on the branch set as its version or, when there is none, on the
+// default branch. Only the first parents of merges are followed, so the commit
+// is one the branch pointed at.
+func asOfCommit(dep *cfg.Dependency, repo v.Repo) (string, error) {
+	t, err := dep.AsOfTime()
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", dep.Name, err)
+	}
+	if repo.Vcs() != v.Git {
+		return "", fmt.Errorf("%s uses %s but asof is only supported for Git", dep.Name, repo.Vcs())
+	}
+	if isShallow(repo) {
+		msg.Info("--> Fetching the full history of %s to find its commit as of %s", dep.Name, dep.AsOf)
+		if err := unshallow(repo); err != nil {
+			return "", err
+		}
+	}
+
+	branch := dep.Reference
+	if branch == "" {
+		branch = defaultBranch(repo)
+	} else if ib, err := isBranch(branch, repo); err != nil {
+		return "", err
+	} else if !ib {
+		return "", fmt.Errorf("%s sets asof with the version %s which is not a branch", dep.Name, branch)
+	}
+	start := "origin/HEAD"
+	if branch != "" {
+		start = "origin/" + branch
+	}
+
+	out, err := repo.RunFromDir("git", "rev-list", "-n", "1", "--first-parent", "--before="+asOfDate(t), start)
+	if err != nil {
+		return "", fmt.Errorf("Unable to find the commit of %s as of %s: %s (%s)", dep.Name, dep.AsOf, err, strings.TrimSpace(string(out)))
+	}
+	commit := strings.TrimSpace(string(out))
+	if commit == "" {
+		return "", fmt.Errorf("%s has no commit on %s before %s", dep.Name, start, dep.AsOf)
+	}
+	return commit, nil
+}
+
+// asOfDate formats t for Git. Git takes dates without a time of day to be at
+// the current time of day, so the time is always included to give the same
+// commit whenever it runs.
+func asOfDate(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
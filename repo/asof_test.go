@@ -0,0 +1,80 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/vcs"
+)
+
+func TestAsOfCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-asof")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	git := func(date string, args ...string) string {
+		c := exec.Command("git", append([]string{"-c", "user.name=glide", "-c", "user.email=glide@example.com"}, args...)...)
+		c.Dir = src
+		c.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+		out, err := c.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %s %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	os.MkdirAll(src, 0755)
+	git("", "init")
+	git("", "symbolic-ref", "HEAD", "refs/heads/master")
+	git("2019-05-20T12:00:00Z", "commit", "--allow-empty", "-m", "first")
+	first := git("", "rev-parse", "HEAD")
+	git("2019-05-31T23:30:00Z", "commit", "--allow-empty", "-m", "second")
+	second := git("", "rev-parse", "HEAD")
+	git("2019-06-01T00:30:00Z", "commit", "--allow-empty", "-m", "third")
+
+	r, err := vcs.NewGitRepo("file://"+src, filepath.Join(dir, "dest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		asof string
+		want string
+	}{
+		{"2019-06-01", second},
+		{"2019-05-31T12:00:00Z", first},
+		{"2019-05-01", ""},
+	}
+	for _, tt := range tests {
+		got, err := asOfCommit(&cfg.Dependency{Name: "example.com/src", Reference: "master", AsOf: tt.asof}, r)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("Expected no commit as of %s, got %s", tt.asof, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unable to find the commit as of %s: %s", tt.asof, err)
+		} else if got != tt.want {
+			t.Errorf("Expected commit %s as of %s, got %s", tt.want, tt.asof, got)
+		}
+	}
+
+	if _, err := asOfCommit(&cfg.Dependency{Name: "example.com/src", Reference: "v1.0.0", AsOf: "2019-06-01"}, r); err == nil {
+		t.Error("Expected an error for asof with a version that is not a branch")
+	}
+}
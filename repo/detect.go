@@ -0,0 +1,47 @@
+package repo
+
+import (
+	"github.com/Masterminds/glide/darcs"
+	"github.com/Masterminds/glide/fossil"
+	v "github.com/Masterminds/vcs"
+)
+
+// DetectVcs returns the type of the checkout in dir. Besides the types known
+// to the vcs package it detects Fossil and Darcs checkouts.
+func DetectVcs(dir string) (v.Type, error) {
+	t, err := v.DetectVcsFromFS(dir)
+	if err != v.ErrCannotDetectVCS {
+		return t, err
+	}
+	switch {
+	case fossil.IsCheckout(dir):
+		return fossil.Type, nil
+	case darcs.IsCheckout(dir):
+		return darcs.Type, nil
+	}
+	return "", v.ErrCannotDetectVCS
+}
+
+// OpenRepo returns the repo for the checkout in dir, with the remote it was
+// checked out from.
+func OpenRepo(dir string) (v.Repo, error) {
+	t, err := DetectVcs(dir)
+	if err != nil {
+		return nil, err
+	}
+	switch t {
+	case v.Git:
+		return v.NewGitRepo("", dir)
+	case v.Svn:
+		return v.NewSvnRepo("", dir)
+	case v.Hg:
+		return v.NewHgRepo("", dir)
+	case v.Bzr:
+		return v.NewBzrRepo("", dir)
+	case fossil.Type:
+		return fossil.NewRepo("", dir)
+	case darcs.Type:
+		return darcs.NewRepo("", dir)
+	}
+	return nil, v.ErrCannotDetectVCS
+}
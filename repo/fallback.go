@@ -0,0 +1,151 @@
+package repo
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	v "github.com/Masterminds/vcs"
+)
+
+// goneMarkers are found in the output of a VCS when the repository does not
+// exist at the remote, such as when it was deleted upstream. GitHub asks for
+// credentials for a repository that is gone rather than saying so.
+var goneMarkers = []string{
+	"not found",
+	"404",
+	"does not exist",
+	"does not appear to be a",
+	"could not read username",
+	"repository unavailable",
+}
+
+// repoGone returns true when err says the repository of a dependency does not
+// exist at its remote.
+func repoGone(err error) bool {
+	if err == nil {
+		return false
+	}
+	if err == v.ErrCannotDetectVCS {
+		return true
+	}
+	out := err.Error()
+	if re, ok := err.(*v.RemoteError); ok {
+		out += " " + re.Out()
+	}
+	out = strings.ToLower(out)
+	for _, m := range goneMarkers {
+		if strings.Contains(out, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// fallbackRemotes returns the remotes to try for dep when its repository is
+// gone: the fallback set for it in glide.yaml followed by the Fallbacks from
+// config.yaml.
+func fallbackRemotes(dep *cfg.Dependency) []string {
+	var remotes []string
+	seen := map[string]bool{dep.Remote(): true}
+	for _, f := range dep.Fallback {
+		if !seen[f] {
+			seen[f] = true
+			remotes = append(remotes, f)
+		}
+	}
+	for _, f := range Fallbacks {
+		f = strings.Replace(f, "{name}", dep.Name, -1)
+		if !seen[f] {
+			seen[f] = true
+			remotes = append(remotes, f)
+		}
+	}
+	return remotes
+}
+
+// getFallback fetches dep from one of its fallback remotes after fetching it
+// failed with cause. The fallback has to have the version dep is pinned to.
+// The repository of dep is set to the fallback used and its Upstream to the
+// remote that is gone, so the substitution is recorded in the lock file. cause
+// is returned when the repository is not gone or no fallback has the version.
+func getFallback(dep *cfg.Dependency, cause error) error {
+	if !repoGone(cause) {
+		return cause
+	}
+	remotes := fallbackRemotes(dep)
+	if len(remotes) == 0 {
+		return cause
+	}
+
+	upstream := dep.Remote()
+	ref := dep.Pin
+	if ref == "" {
+		ref = dep.Reference
+	}
+	for _, r := range remotes {
+		msg.Warn("--> %s is gone from %s. Trying the fallback %s", dep.Name, upstream, r)
+		fd := dep.Clone()
+		fd.Repository = r
+		fd.Fallback = nil
+		if err := VcsGet(fd); err != nil {
+			msg.Warn("--> Unable to fetch %s from %s: %s", dep.Name, r, err)
+			continue
+		}
+		if ref != "" && !hasRevision(fd, ref) {
+			msg.Warn("--> The fallback %s does not have version %s of %s", r, ref, dep.Name)
+			continue
+		}
+
+		msg.Warn("--> Using %s for %s in place of %s", r, dep.Name, upstream)
+		if dep.Upstream == "" {
+			dep.Upstream = upstream
+		}
+		dep.Repository = r
+		return nil
+	}
+	return cause
+}
+
+// hasRevision returns true when the cached repository of dep has ref, or when
+// ref is a semantic version range to be resolved later on. A commit id always
+// has to be there.
+func hasRevision(dep *cfg.Dependency, ref string) bool {
+	key, err := cache.Key(dep.Remote())
+	if err != nil {
+		return false
+	}
+	repo, err := dep.GetRepo(filepath.Join(cache.Location(), "src", key))
+	if err != nil {
+		return false
+	}
+	if fullCommitRe.MatchString(ref) {
+		// git rev-parse --verify accepts any full commit id.
+		if repo.Vcs() == v.Git {
+			_, err := repo.RunFromDir("git", "cat-file", "-e", ref+"^{commit}")
+			return err == nil
+		}
+		return repo.IsReference(ref)
+	}
+	if repo.IsReference(ref) {
+		return true
+	}
+	_, err = cfg.NewConstraint(ref)
+	return err == nil
+}
+
+// applyFallback copies the fallback remotes of the dependencies in conf to the
+// same dependencies in deps.
+func applyFallback(deps cfg.Dependencies, conf *cfg.Config) {
+	for _, d := range deps {
+		c := conf.Imports.Get(d.Name)
+		if c == nil {
+			c = conf.DevImports.Get(d.Name)
+		}
+		if c != nil {
+			d.Fallback = c.Fallback
+		}
+	}
+}
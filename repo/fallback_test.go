@@ -0,0 +1,115 @@
+package repo
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/glide/cfg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/vcs"
+)
+
+func TestRepoGone(t *testing.T) {
+	tests := []struct {
+		err  error
+		gone bool
+	}{
+		{nil, false},
+		{vcs.ErrCannotDetectVCS, true},
+		{vcs.NewRemoteError("Unable to get repository", errors.New("exit status 128"), "remote: Repository not found.\nfatal: repository 'https://github.com/foo/bar/' not found"), true},
+		{vcs.NewRemoteError("Unable to get repository", errors.New("exit status 128"), "fatal: could not read Username for 'https://github.com': terminal prompts disabled"), true},
+		{vcs.NewRemoteError("Unable to get repository", errors.New("exit status 128"), "fatal: unable to access 'https://github.com/foo/bar/': Could not resolve host: github.com"), false},
+		{errors.New("timed out"), false},
+	}
+	for i, tt := range tests {
+		if gone := repoGone(tt.err); gone != tt.gone {
+			t.Errorf("Test %d: repoGone(%v) = %t, want %t", i, tt.err, gone, tt.gone)
+		}
+	}
+}
+
+func TestFallbackRemotes(t *testing.T) {
+	defer func(f []string) { Fallbacks = f }(Fallbacks)
+	Fallbacks = []string{"https://archive.example.com/{name}", "https://github.com/fork/bar"}
+
+	dep := &cfg.Dependency{
+		Name:     "github.com/foo/bar",
+		Fallback: []string{"https://github.com/fork/bar", "https://github.com/foo/bar"},
+	}
+	want := []string{"https://github.com/fork/bar", "https://archive.example.com/github.com/foo/bar"}
+	if got := fallbackRemotes(dep); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestGetFallback(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-fallback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer gpath.SetHome(gpath.Home())
+	gpath.SetHome(filepath.Join(dir, "home"))
+
+	src := filepath.Join(dir, "fork")
+	git := func(args ...string) string {
+		c := exec.Command("git", append([]string{"-c", "user.name=glide", "-c", "user.email=glide@example.com"}, args...)...)
+		c.Dir = src
+		out, err := c.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %s %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	os.MkdirAll(src, 0755)
+	git("init")
+	git("commit", "--allow-empty", "-m", "first")
+	pinned := git("rev-parse", "HEAD")
+
+	upstream := "file://" + filepath.Join(dir, "gone")
+	dep := &cfg.Dependency{
+		Name:       "example.com/gone",
+		Reference:  pinned,
+		Repository: upstream,
+		VcsType:    "git",
+		Fallback:   []string{"file://" + src},
+	}
+	if err := VcsGet(dep); !repoGone(err) {
+		t.Fatalf("Expected the upstream to be gone, got %v", err)
+	}
+	cause := errors.New("Repository not found")
+	if err := getFallback(dep, cause); err != nil {
+		t.Fatalf("Expected the fallback to be used, got %s", err)
+	}
+	if dep.Repository != "file://"+src || dep.Upstream != upstream {
+		t.Errorf("Expected the substitution to be recorded, got repo %s and upstream %s", dep.Repository, dep.Upstream)
+	}
+	l := cfg.LockFromDependency(dep)
+	if l.Repository != "file://"+src || l.Upstream != upstream {
+		t.Errorf("Expected the lock to record the substitution, got %v", l)
+	}
+
+	missing := &cfg.Dependency{
+		Name:       "example.com/gone",
+		Reference:  strings.Repeat("0", 40),
+		Repository: upstream,
+		VcsType:    "git",
+		Fallback:   []string{"file://" + src},
+	}
+	if err := getFallback(missing, cause); err != cause {
+		t.Errorf("Expected a fallback without the pinned commit to be skipped, got %v", err)
+	}
+	if missing.Repository != upstream || missing.Upstream != "" {
+		t.Errorf("Expected the dependency to be left alone, got repo %s", missing.Repository)
+	}
+}
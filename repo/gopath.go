@@ -0,0 +1,146 @@
+package repo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// ExportGopath places the dependencies in the src directory of the first
+// GOPATH entry rather than in vendor/. This is for tools that do not
+// understand vendor directories.
+//
+// Anything already at the location of a dependency is moved to a backup
+// directory in the Glide home first. Files are copied rather than linked to
+// the cache as code in the GOPATH is often edited in place. The location of
+// the backups is returned when anything was backed up.
+func (i *Installer) ExportGopath(conf *cfg.Config) (string, error) {
+	gp := gpath.Gopath()
+	if gp == "" {
+		return "", fmt.Errorf("no GOPATH is set")
+	}
+	backup := filepath.Join(gpath.Home(), "backups", time.Now().Format("20060102-150405"))
+	return i.exportTo(conf, filepath.Join(gp, "src"), backup)
+}
+
+// exportTo places the dependencies in src, moving anything in the way to
+// backup.
+func (i *Installer) exportTo(conf *cfg.Config, src, backup string) (string, error) {
+	backedUp := false
+
+	defer func(m string) { gpath.LinkMode = m }(gpath.LinkMode)
+	if gpath.LinkMode != gpath.LinkReflink {
+		gpath.LinkMode = gpath.LinkCopy
+	}
+
+	deps := append(cfg.Dependencies{}, conf.Imports...)
+	if i.ResolveTest {
+		deps = append(deps, conf.DevImports...)
+	}
+
+	msg.Info("Exporting resolved dependencies to %s...", src)
+	for _, dep := range deps {
+		if conf.HasIgnore(dep.Name) {
+			continue
+		}
+		dest := filepath.Join(src, filepath.FromSlash(dep.Name))
+
+		// Each dependency is exported next to its final location so a
+		// failed export leaves what is there untouched.
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return backupDir(backup, backedUp), err
+		}
+		tmp, err := ioutil.TempDir(filepath.Dir(dest), ".glide-"+filepath.Base(dest))
+		if err != nil {
+			return backupDir(backup, backedUp), err
+		}
+		out := filepath.Join(tmp, "export")
+		if err := exportGopathDep(dep, out); err != nil {
+			os.RemoveAll(tmp)
+			return backupDir(backup, backedUp), fmt.Errorf("Export failed for %s: %s", dep.Name, err)
+		}
+
+		if _, err := os.Lstat(dest); err == nil {
+			to := filepath.Join(backup, filepath.FromSlash(dep.Name))
+			msg.Info("--> Backing up %s to %s", dest, to)
+			if err := moveDir(dest, to); err != nil {
+				os.RemoveAll(tmp)
+				return backupDir(backup, backedUp), fmt.Errorf("Unable to back up %s: %s", dest, err)
+			}
+			backedUp = true
+		}
+		err = os.Rename(out, dest)
+		os.RemoveAll(tmp)
+		if err != nil {
+			return backupDir(backup, backedUp), err
+		}
+		msg.SetDone(dep.Name)
+	}
+
+	return backupDir(backup, backedUp), nil
+}
+
+// ExportDependency places the pinned revision of a dependency at dest.
+func ExportDependency(dep *cfg.Dependency, dest string) error {
+	return exportGopathDep(dep, dest)
+}
+
+// exportGopathDep places the pinned revision of a dependency at dest.
+func exportGopathDep(dep *cfg.Dependency, dest string) error {
+	if dep.Local != "" {
+		msg.Info("--> Linking local copy of %s", dep.Name)
+		return exportLocal(dep, dest, false)
+	}
+
+	key, err := cache.Key(dep.Remote())
+	if err != nil {
+		return err
+	}
+	cache.Lock(key)
+	cache.Touch(key)
+
+	repo, err := dep.GetRepo(filepath.Join(cache.Location(), "src", key))
+	if err != nil {
+		cache.Unlock(key)
+		return err
+	}
+	msg.Info("--> Exporting %s", dep.Name)
+	msg.SetState(dep.Name, msg.Exporting)
+	defer msg.SetState(dep.Name, "")
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		cache.Unlock(key)
+		return err
+	}
+	err = withTimeout("export", dep.Name, ExportTimeout, func() error {
+		return exportDep(repo, key, dep, dest)
+	})
+	unlockWhenDone(key, err)
+	return err
+}
+
+// moveDir moves the directory at from to to, creating the parents of to.
+func moveDir(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+	err := os.Rename(from, to)
+	if terr, ok := err.(*os.LinkError); ok {
+		return fixcle(from, to, terr)
+	}
+	return err
+}
+
+// backupDir returns the backup directory when anything was moved there.
+func backupDir(dir string, used bool) string {
+	if used {
+		return dir
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/glide/cfg"
+)
+
+func TestExportTo(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-gopath-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	local := filepath.Join(dir, "foo")
+	if err := os.MkdirAll(local, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(local, "foo.go"), []byte("package foo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	src := filepath.Join(dir, "gopath", "src")
+	old := filepath.Join(src, "example.com", "foo")
+	if err := os.MkdirAll(old, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(old, "old.go"), []byte("package foo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := &cfg.Config{
+		Name:    "example.com/app",
+		Imports: cfg.Dependencies{{Name: "example.com/foo", Local: local}},
+	}
+	backup := filepath.Join(dir, "backup")
+	i := NewInstaller()
+	b, err := i.exportTo(conf, src, backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b != backup {
+		t.Errorf("Expected the backup directory %s, got %q", backup, b)
+	}
+	if _, err := os.Stat(filepath.Join(old, "foo.go")); err != nil {
+		t.Errorf("Expected the dependency in the GOPATH: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(backup, "example.com", "foo", "old.go")); err != nil {
+		t.Errorf("Expected the replaced package to be backed up: %s", err)
+	}
+	files, _ := ioutil.ReadDir(filepath.Join(src, "example.com"))
+	if len(files) != 1 {
+		t.Errorf("Expected no temporary files left behind, found %d entries", len(files))
+	}
+
+	// Nothing is backed up when nothing is in the way.
+	os.RemoveAll(src)
+	if b, err := i.exportTo(conf, src, filepath.Join(dir, "backup2")); err != nil || b != "" {
+		t.Errorf("Expected no backup, got %q (%v)", b, err)
+	}
+}
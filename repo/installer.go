@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"syscall"
@@ -45,6 +46,42 @@ type Installer struct {
 
 	// Updated tracks the packages that have been remotely fetched.
 	Updated *UpdateTracker
+
+	// Frozen contains dependencies, typically from a lock file, that should
+	// be kept at their listed reference when they are encountered while
+	// resolving transitive dependencies.
+	Frozen cfg.Dependencies
+
+	// Reuse lists dependencies whose copy in the existing vendor directory
+	// is moved into the new one rather than exported again from the cache.
+	// This keeps work done on the vendor directory, such as stripping nested
+	// vendor directories, for dependencies that have not changed.
+	Reuse map[string]bool
+
+	// With lists the optional groups of dependencies to place in the vendor
+	// directory and Without the groups to leave out of it.
+	With    []string
+	Without []string
+
+	// Link places dependencies in the vendor directory as symlinks, to their
+	// checkout in the GOPATH when there is one and to their export in the
+	// cache otherwise, rather than as copies.
+	Link bool
+
+	// Unlink places real copies in the vendor directory for every
+	// dependency, including those with a local replacement, so it can be
+	// committed.
+	Unlink bool
+
+	// AllPlatforms places dependencies in the vendor directory whatever
+	// their os and arch filters. Otherwise those not used on the target
+	// platform, set by GOOS and GOARCH, are left out.
+	AllPlatforms bool
+
+	// Stage is called by Export with the new vendor directory once every
+	// dependency is in it and before it replaces the existing one. When it
+	// returns an error the existing vendor directory is left as it was.
+	Stage func(vendor string) error
 }
 
 // NewInstaller returns an Installer instance ready to use. This is the constructor.
@@ -62,12 +99,26 @@ func (i *Installer) VendorPath() string {
 
 	vp, err := gpath.Vendor()
 	if err != nil {
-		return filepath.FromSlash("./vendor")
+		return gpath.VendorDir
 	}
 
 	return vp
 }
 
+// TestVendorPath returns the location to put the dependencies only used by
+// tests in, or an empty string when they go in the vendor directory with the
+// others or test dependencies are not resolved.
+func (i *Installer) TestVendorPath() string {
+	if !i.ResolveTest {
+		return ""
+	}
+	tp, err := gpath.VendorForTests()
+	if err != nil {
+		return gpath.TestVendorDir
+	}
+	return tp
+}
+
 // Install installs the dependencies from a Lockfile.
 func (i *Installer) Install(lock *cfg.Lockfile, conf *cfg.Config) (*cfg.Config, error) {
 
@@ -86,6 +137,13 @@ func (i *Installer) Install(lock *cfg.Lockfile, conf *cfg.Config) (*cfg.Config,
 		newConf.DevImports[k] = cfg.DependencyFromLock(v)
 	}
 
+	applyLocal(newConf.Imports, conf)
+	applyLocal(newConf.DevImports, conf)
+	applyFallback(newConf.Imports, conf)
+	applyFallback(newConf.DevImports, conf)
+	applyTrack(newConf.Imports, conf)
+	applyTrack(newConf.DevImports, conf)
+
 	newConf.DeDupe()
 
 	if len(newConf.Imports) == 0 && len(newConf.DevImports) == 0 {
@@ -100,8 +158,16 @@ func (i *Installer) Install(lock *cfg.Lockfile, conf *cfg.Config) (*cfg.Config,
 		return newConf, err
 	}
 	err = LazyConcurrentUpdate(newConf.DevImports, i, newConf)
+	if err != nil {
+		return newConf, err
+	}
 
-	return newConf, err
+	// Dependencies fetched for the first time can be checked now. A fresh
+	// checkout already has the tip of the branch they track.
+	applyTrack(newConf.Imports, conf)
+	applyTrack(newConf.DevImports, conf)
+
+	return newConf, nil
 }
 
 // Checkout reads the config file and checks out all dependencies mentioned there.
@@ -109,6 +175,7 @@ func (i *Installer) Install(lock *cfg.Lockfile, conf *cfg.Config) (*cfg.Config,
 // This is used when initializing an empty vendor directory, or when updating a
 // vendor directory based on changed config.
 func (i *Installer) Checkout(conf *cfg.Config) error {
+	conf.ApplyOverrides()
 
 	msg.Info("Downloading dependencies. Please wait...")
 
@@ -133,7 +200,31 @@ func (i *Installer) Checkout(conf *cfg.Config) error {
 func (i *Installer) Update(conf *cfg.Config) error {
 	base := "."
 
+	// The versions asked for in glide.yaml, before any override.
+	declared := make(map[string]string)
+	for _, d := range append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...) {
+		declared[d.Name] = d.Reference
+	}
+	// The subpackages asked for, before the resolver adds those it finds.
+	kept := keptSubpackages(conf)
+
+	conf.ApplyOverrides()
+	if err := rewriteRepos(append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...)...); err != nil {
+		return err
+	}
+
 	ic := newImportCache()
+	for _, d := range i.Frozen {
+		ic.Add(d.Name, d, "glide.lock")
+	}
+	// Overrides come last so they replace the frozen versions and, as the
+	// cache keeps the first version imported, any asked for by dependencies.
+	// Those for patterns are applied as the packages they match are found.
+	for _, d := range conf.Overrides {
+		if !d.IsPattern() {
+			ic.Add(d.Name, d.Clone(), "override")
+		}
+	}
 
 	m := &MissingPackageHandler{
 		home:    i.Home,
@@ -162,6 +253,97 @@ func (i *Installer) Update(conf *cfg.Config) error {
 	res.ResolveAllFiles = i.ResolveAllFiles
 	msg.Info("Resolving imports")
 
+	err = withTimeout("resolve", "", ResolveTimeout, func() error {
+		i.resolve(conf, res)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	importers := res.Importers()
+	if err := checkSubpackages(kept, importers); err != nil {
+		return err
+	}
+	setRequiredBy(conf, declared, importers, v.Required, ic)
+
+	msg.Info("Downloading dependencies. Please wait...")
+
+	err = ConcurrentUpdate(conf.Imports, i, conf)
+	if err != nil {
+		return err
+	}
+
+	if i.ResolveTest {
+		err = ConcurrentUpdate(conf.DevImports, i, conf)
+		if err != nil {
+			return err
+		}
+	}
+
+	return alignSyncGroups(conf)
+}
+
+// setRequiredBy records on each dependency the packages requiring it. These
+// are the project for the dependencies in its glide.yaml file, the packages
+// importing it, and those asking for it in their glide.yaml file along with
+// the version they ask for. The version asked for by the project wins,
+// otherwise the one put in the import cache first was used.
+func setRequiredBy(conf *cfg.Config, declared map[string]string, importers map[string][]string, required map[string]map[string]string, ic *importCache) {
+	reqs := make(map[string]map[string]string)
+	add := func(name, by, version string) {
+		if name == by || name == conf.Name {
+			return
+		}
+		if reqs[name] == nil {
+			reqs[name] = make(map[string]string)
+		}
+		if reqs[name][by] == "" {
+			reqs[name][by] = version
+		}
+	}
+	for name, version := range declared {
+		add(name, conf.Name, version)
+	}
+	for name, by := range required {
+		for b, version := range by {
+			add(name, b, version)
+		}
+	}
+	for pkg, by := range importers {
+		name := util.GetRootFromPackage(pkg)
+		for _, b := range by {
+			if b != conf.Name && !strings.HasPrefix(b, conf.Name+"/") {
+				b = util.GetRootFromPackage(b)
+			} else {
+				b = conf.Name
+			}
+			add(name, b, required[name][b])
+		}
+	}
+
+	for _, d := range append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...) {
+		applied := conf.Name
+		if declared[d.Name] == "" {
+			_, applied = ic.Get(d.Name)
+		}
+		d.RequiredBy = nil
+		for b, version := range reqs[d.Name] {
+			r := &cfg.Requirement{By: b, Version: version, Applied: version != "" && b == applied}
+			d.RequiredBy = append(d.RequiredBy, r)
+		}
+		sort.Sort(requirementsByName(d.RequiredBy))
+	}
+}
+
+type requirementsByName []*cfg.Requirement
+
+func (r requirementsByName) Len() int           { return len(r) }
+func (r requirementsByName) Less(i, j int) bool { return r[i].By < r[j].By }
+func (r requirementsByName) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+
+// resolve walks the local project and its dependencies to find all of the
+// packages that are needed. The config is updated along the way.
+func (i *Installer) resolve(conf *cfg.Config, res *dependency.Resolver) {
 	imps, timps, err := res.ResolveLocal(false)
 	if err != nil {
 		msg.Die("Failed to resolve local packages: %s", err)
@@ -226,27 +408,13 @@ func (i *Installer) Update(conf *cfg.Config) error {
 			msg.Die("Failed to retrieve a list of test dependencies: %s", err)
 		}
 	}
-
-	msg.Info("Downloading dependencies. Please wait...")
-
-	err = ConcurrentUpdate(conf.Imports, i, conf)
-	if err != nil {
-		return err
-	}
-
-	if i.ResolveTest {
-		err = ConcurrentUpdate(conf.DevImports, i, conf)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
 }
 
 // Export from the cache to the vendor directory
 func (i *Installer) Export(conf *cfg.Config) error {
-	tempDir, err := ioutil.TempDir(gpath.Tmp, "glide-vendor")
+	recoverVendor(i.VendorPath())
+
+	tempDir, err := ioutil.TempDir(vendorStage(i.VendorPath()), ".glide-vendor")
 	if err != nil {
 		return err
 	}
@@ -260,6 +428,29 @@ func (i *Installer) Export(conf *cfg.Config) error {
 	vp := filepath.Join(tempDir, "vendor")
 	err = os.MkdirAll(vp, 0755)
 
+	// Dependencies only used by tests go in a vendor directory of their own
+	// when there is one.
+	tvp := i.TestVendorPath()
+	tp := filepath.Join(tempDir, "vendor-test")
+	testOnly := make(map[string]bool)
+	if tvp != "" {
+		recoverVendor(tvp)
+		if err := os.MkdirAll(tp, 0755); err != nil {
+			return err
+		}
+		for _, dep := range conf.DevImports {
+			if !conf.Imports.Has(dep.Name) {
+				testOnly[dep.Name] = true
+			}
+		}
+	}
+	destOf := func(dep *cfg.Dependency) string {
+		if testOnly[dep.Name] {
+			return filepath.Join(tp, filepath.ToSlash(dep.Name))
+		}
+		return filepath.Join(vp, filepath.ToSlash(dep.Name))
+	}
+
 	msg.Info("Exporting resolved dependencies...")
 	done := make(chan struct{}, concurrentWorkers)
 	in := make(chan *cfg.Dependency, concurrentWorkers)
@@ -272,20 +463,52 @@ func (i *Installer) Export(conf *cfg.Config) error {
 			for {
 				select {
 				case dep := <-ch:
-					loc := dep.Remote()
-					key, err := cache.Key(loc)
-					if err != nil {
-						msg.Die(err.Error())
+					if dep.Local != "" {
+						msg.Info("--> Linking local copy of %s", dep.Name)
+						if err := exportLocal(dep, destOf(dep), i.Unlink); err != nil {
+							msg.Err("Export failed for %s: %s\n", dep.Name, err)
+							lock.Lock()
+							if returnErr == nil {
+								returnErr = err
+							} else {
+								returnErr = cli.NewMultiError(returnErr, err)
+							}
+							lock.Unlock()
+						} else {
+							msg.SetDone(dep.Name)
+						}
+						wg.Done()
+						continue
 					}
-					cache.Lock(key)
-
-					cdir := filepath.Join(cache.Location(), "src", key)
-					repo, err := dep.GetRepo(cdir)
-					if err != nil {
-						msg.Die(err.Error())
+					// Failures are returned rather than ending the process,
+					// which may be a program embedding Glide.
+					key, err := cache.Key(dep.Remote())
+					if err == nil {
+						cache.Lock(key)
+						cache.Touch(key)
+						if i.Link {
+							msg.Info("--> Linking %s", dep.Name)
+						} else {
+							msg.Info("--> Exporting %s", dep.Name)
+						}
+						msg.SetState(dep.Name, msg.Exporting)
+						err = withTimeout("export", dep.Name, ExportTimeout, func() error {
+							repo, err := dep.GetRepo(filepath.Join(cache.Location(), "src", key))
+							if err != nil {
+								return err
+							}
+							dest := destOf(dep)
+							if i.Link {
+								return linkDep(repo, key, dep, dest)
+							}
+							return exportDep(repo, key, dep, dest)
+						})
+						unlockWhenDone(key, err)
 					}
-					msg.Info("--> Exporting %s", dep.Name)
-					if err := repo.ExportDir(filepath.Join(vp, filepath.ToSlash(dep.Name))); err != nil {
+					if err == nil {
+						msg.SetDone(dep.Name)
+					} else {
+						msg.SetState(dep.Name, "")
 						msg.Err("Export failed for %s: %s\n", dep.Name, err)
 						// Capture the error while making sure the concurrent
 						// operations don't step on each other.
@@ -297,7 +520,6 @@ func (i *Installer) Export(conf *cfg.Config) error {
 						}
 						lock.Unlock()
 					}
-					cache.Unlock(key)
 					wg.Done()
 				case <-done:
 					return
@@ -306,8 +528,13 @@ func (i *Installer) Export(conf *cfg.Config) error {
 		}(in)
 	}
 
+	var reuse []*cfg.Dependency
 	for _, dep := range conf.Imports {
 		if !conf.HasIgnore(dep.Name) {
+			if i.Reuse[dep.Name] {
+				reuse = append(reuse, dep)
+				continue
+			}
 			err = os.MkdirAll(filepath.Join(vp, filepath.ToSlash(dep.Name)), 0755)
 			if err != nil {
 				lock.Lock()
@@ -326,7 +553,11 @@ func (i *Installer) Export(conf *cfg.Config) error {
 	if i.ResolveTest {
 		for _, dep := range conf.DevImports {
 			if !conf.HasIgnore(dep.Name) {
-				err = os.MkdirAll(filepath.Join(vp, filepath.ToSlash(dep.Name)), 0755)
+				if i.Reuse[dep.Name] && !testOnly[dep.Name] {
+					reuse = append(reuse, dep)
+					continue
+				}
+				err = os.MkdirAll(destOf(dep), 0755)
 				if err != nil {
 					lock.Lock()
 					if returnErr == nil {
@@ -344,6 +575,27 @@ func (i *Installer) Export(conf *cfg.Config) error {
 
 	wg.Wait()
 
+	// The existing copies are only moved once everything else exported so a
+	// failed export leaves the vendor directory untouched. When a copy cannot
+	// be moved it is exported instead.
+	if returnErr == nil {
+		for _, dep := range reuse {
+			if err := i.reuseVendored(dep.Name, vp); err != nil {
+				msg.Debug("Unable to reuse vendored %s, exporting instead: %s", dep.Name, err)
+				delete(i.Reuse, dep.Name)
+				err = os.MkdirAll(filepath.Join(vp, filepath.ToSlash(dep.Name)), 0755)
+				if err != nil {
+					return err
+				}
+				wg.Add(1)
+				in <- dep
+				continue
+			}
+			msg.SetDone(dep.Name)
+		}
+		wg.Wait()
+	}
+
 	// Close goroutines setting the version
 	for ii := 0; ii < concurrentWorkers; ii++ {
 		done <- struct{}{}
@@ -353,45 +605,123 @@ func (i *Installer) Export(conf *cfg.Config) error {
 		return returnErr
 	}
 
+	if i.Stage != nil {
+		if err := i.Stage(vp); err != nil {
+			return err
+		}
+	}
+
 	msg.Info("Replacing existing vendor dependencies")
+	if err := swapVendor(vp, i.VendorPath()); err != nil {
+		return err
+	}
+	if tvp != "" {
+		return swapVendor(tp, tvp)
+	}
+	return nil
+}
 
-	// Check if a .git directory exists under the old vendor dir. If it does,
-	// move it over to the newly-generated vendor dir - the user is probably
-	// submoduling, and it's easy enough not to break their setup.
-	ivg := filepath.Join(i.VendorPath(), ".git")
-	gitInfo, err := os.Stat(ivg)
-	if err == nil {
-		msg.Info("Preserving existing vendor/.git")
-		vpg := filepath.Join(vp, ".git")
-		err = os.Rename(ivg, vpg)
+// exportDep places the pinned revision of dep at dest. Each revision is
+// exported to the cache once and dest is populated from there, using links
+// where the filesystem supports them. Without a revision the repo is exported
+// directly. Paths listed in the .glideignore of the package are removed, and
+// so is everything outside the subpackages of dep when it has subpackagesOnly
+// set.
+func exportDep(repo vcs.Repo, key string, dep *cfg.Dependency, dest string) error {
+	if dep.Pin == "" {
+		if err := repo.ExportDir(dest); err != nil {
+			return err
+		}
+		return trimExport(dep, dest)
+	}
 
-		if terr, ok := err.(*os.LinkError); ok {
-			if gitInfo.IsDir() {
-				err = fixcle(ivg, vpg, terr)
-			} else {
-				// When this is a submodule, .git is just a file. Don't try to copy
-				// it as a directory in this case (see #828).
-				err = gpath.CopyFile(ivg, vpg)
-			}
+	loc, err := cachedExport(repo, key, dep)
+	if err != nil {
+		return err
+	}
+	if err := gpath.LinkTree(loc, dest); err != nil {
+		return err
+	}
+	return trimExport(dep, dest)
+}
 
-			if err != nil {
-				msg.Warn("Failed to preserve existing vendor/.git")
+// trimExport removes what is not to be vendored from the export of dep at dest.
+func trimExport(dep *cfg.Dependency, dest string) error {
+	if err := gpath.RemoveIgnored(dest); err != nil {
+		return err
+	}
+	if !dep.SubpackagesOnly {
+		return nil
+	}
+	return gpath.KeepSubpackages(dest, dep.Subpackages)
+}
+
+// cachedExport returns the export of the pinned revision of dep in the cache,
+// exporting it first when needed.
+func cachedExport(repo vcs.Repo, key string, dep *cfg.Dependency) (string, error) {
+	// Exports with submodules are stored separately from those without.
+	rev := dep.Pin
+	if dep.Submodules {
+		rev += "-submodules"
+	}
+	loc := cache.ExportLocation(key, rev)
+	if _, err := os.Stat(loc); os.IsNotExist(err) {
+		// Exporting to a temporary location first keeps a failed export from
+		// being used later on.
+		if err := os.MkdirAll(filepath.Dir(loc), 0755); err != nil {
+			return "", err
+		}
+		tmp, err := ioutil.TempDir(filepath.Dir(loc), filepath.Base(loc)+".tmp")
+		if err != nil {
+			return "", err
+		}
+		if err := os.Chmod(tmp, 0755); err != nil {
+			os.RemoveAll(tmp)
+			return "", err
+		}
+		if err := repo.ExportDir(tmp); err != nil {
+			os.RemoveAll(tmp)
+			return "", err
+		}
+		if err := os.Rename(tmp, loc); err != nil {
+			os.RemoveAll(tmp)
+			// Another Glide process may have stored the same revision.
+			if _, serr := os.Stat(loc); serr != nil {
+				return "", err
 			}
 		}
+	} else if err != nil {
+		return "", err
 	}
+	return loc, nil
+}
 
-	err = gpath.CustomRemoveAll(i.VendorPath())
+// reuseVendored moves the copy of a dependency in the existing vendor
+// directory to the vendor directory being generated at vp.
+func (i *Installer) reuseVendored(name, vp string) error {
+	from := filepath.Join(i.VendorPath(), filepath.FromSlash(name))
+	to := filepath.Join(vp, filepath.FromSlash(name))
+	fi, err := os.Lstat(from)
 	if err != nil {
 		return err
 	}
+	// A copy is not reused as a link or the other way around.
+	if linked := fi.Mode()&os.ModeSymlink != 0; linked != i.Link {
+		if linked {
+			return fmt.Errorf("%s is a symlink", from)
+		}
+		return fmt.Errorf("%s is not a symlink", from)
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
 
-	err = gpath.CustomRename(vp, i.VendorPath())
+	msg.Info("--> Keeping vendored %s", name)
+	err = os.Rename(from, to)
 	if terr, ok := err.(*os.LinkError); ok {
-		return fixcle(vp, i.VendorPath(), terr)
+		return fixcle(from, to, terr)
 	}
-
 	return err
-
 }
 
 // fixcle is a helper function that tries to recover from cross-device rename
@@ -465,9 +795,16 @@ func (i *Installer) List(conf *cfg.Config) []*cfg.Dependency {
 //
 // This is only safe when updating from a lock file.
 func LazyConcurrentUpdate(deps []*cfg.Dependency, i *Installer, c *cfg.Config) error {
+	if err := rewriteRepos(deps...); err != nil {
+		return err
+	}
 
 	newDeps := []*cfg.Dependency{}
 	for _, dep := range deps {
+		if dep.Local != "" {
+			newDeps = append(newDeps, dep)
+			continue
+		}
 
 		key, err := cache.Key(dep.Remote())
 		if err != nil {
@@ -508,6 +845,10 @@ func LazyConcurrentUpdate(deps []*cfg.Dependency, i *Installer, c *cfg.Config) e
 
 // ConcurrentUpdate takes a list of dependencies and updates in parallel.
 func ConcurrentUpdate(deps []*cfg.Dependency, i *Installer, c *cfg.Config) error {
+	if err := rewriteRepos(deps...); err != nil {
+		return err
+	}
+
 	done := make(chan struct{}, concurrentWorkers)
 	in := make(chan *cfg.Dependency, concurrentWorkers)
 	var wg sync.WaitGroup
@@ -519,13 +860,13 @@ func ConcurrentUpdate(deps []*cfg.Dependency, i *Installer, c *cfg.Config) error
 			for {
 				select {
 				case dep := <-ch:
-					loc := dep.Remote()
-					key, err := cache.Key(loc)
-					if err != nil {
-						msg.Die(err.Error())
+					key, err := cache.Key(dep.Remote())
+					if err == nil {
+						cache.Lock(key)
+						err = fetch(dep, i.Force, i.Updated)
+						unlockWhenDone(key, err)
 					}
-					cache.Lock(key)
-					if err := VcsUpdate(dep, i.Force, i.Updated); err != nil {
+					if err != nil {
 						msg.Err("Update failed for %s: %s\n", dep.Name, err)
 						// Capture the error while making sure the concurrent
 						// operations don't step on each other.
@@ -537,7 +878,6 @@ func ConcurrentUpdate(deps []*cfg.Dependency, i *Installer, c *cfg.Config) error
 						}
 						lock.Unlock()
 					}
-					cache.Unlock(key)
 					wg.Done()
 				case <-done:
 					return
@@ -652,6 +992,10 @@ func (m *MissingPackageHandler) PkgPath(pkg string) string {
 		}
 	}
 
+	if d.Local != "" {
+		return filepath.Join(localPath(d), filepath.FromSlash(sub))
+	}
+
 	key, err := cache.Key(d.Remote())
 	if err != nil {
 		msg.Die("Error generating cache key for %s", d.Name)
@@ -686,8 +1030,18 @@ func (m *MissingPackageHandler) fetchToCache(pkg string, addTest bool) error {
 			m.Config.Imports = append(m.Config.Imports, d)
 		}
 	}
+	if err := rewriteRepos(d); err != nil {
+		return err
+	}
 
-	return VcsUpdate(d, m.force, m.updated)
+	key, err := cache.Key(d.Remote())
+	if err != nil {
+		return err
+	}
+	cache.Lock(key)
+	err = fetch(d, m.force, m.updated)
+	unlockWhenDone(key, err)
+	return err
 }
 
 // VersionHandler handles setting the proper version in the VCS.
@@ -707,6 +1061,10 @@ type VersionHandler struct {
 	// same. We are keeping track to only display them once.
 	// the parent pac
 	Conflicts map[string]bool
+
+	// Required maps each dependency asked for in the glide.yaml file of a
+	// package to the packages asking for it and the versions they ask for.
+	Required map[string]map[string]string
 }
 
 // Process imports dependencies for a package
@@ -722,10 +1080,16 @@ func (d *VersionHandler) Process(pkg string) (e error) {
 	// Should we look in places other than the root of the project?
 	if d.Imported[root] == false {
 		d.Imported[root] = true
+		msg.SetState(root, msg.Resolving)
+		defer msg.SetState(root, "")
 		p := d.pkgPath(root)
 		f, deps, err := importer.Import(p)
+		if f && err == nil && d.Config.NestedPolicy() == cfg.NestedPreferPin {
+			deps = nestedPins(p, deps)
+		}
 		if f && err == nil {
 			for _, dep := range deps {
+				d.require(dep.Name, root, dep.Reference)
 
 				// The fist one wins. Would something smater than this be better?
 				exists, _ := d.Use.Get(dep.Name)
@@ -742,6 +1106,42 @@ func (d *VersionHandler) Process(pkg string) (e error) {
 	return
 }
 
+// nestedPins replaces the versions asked for by the dependency at p with the
+// ones pinned in its lock file, and adds the dependencies only pinned there.
+func nestedPins(p string, deps []*cfg.Dependency) []*cfg.Dependency {
+	lock, err := cfg.ReadLockFile(filepath.Join(p, gpath.LockFile))
+	if err != nil {
+		return deps
+	}
+	pinned := make([]*cfg.Dependency, 0, len(lock.Imports))
+	for _, l := range lock.Imports {
+		n := cfg.DependencyFromLock(l)
+		n.RequiredBy = nil
+		for _, dep := range deps {
+			if dep.Name == l.Name && n.Repository == "" {
+				n.Repository, n.VcsType = dep.Repository, dep.VcsType
+			}
+		}
+		pinned = append(pinned, n)
+	}
+	for _, dep := range deps {
+		if !lock.Imports.Has(dep.Name) {
+			pinned = append(pinned, dep)
+		}
+	}
+	return pinned
+}
+
+func (d *VersionHandler) require(name, by, version string) {
+	if d.Required == nil {
+		d.Required = make(map[string]map[string]string)
+	}
+	if d.Required[name] == nil {
+		d.Required[name] = make(map[string]string)
+	}
+	d.Required[name][by] = version
+}
+
 // SetVersion sets the version for a package. If that package version is already
 // set it handles the case by:
 // - keeping the already set version
@@ -772,7 +1172,15 @@ func (d *VersionHandler) SetVersion(pkg string, addTest bool) (e error) {
 	}
 
 	dep, req := d.Use.Get(root)
-	if dep != nil && v != nil {
+	if addTest && v != nil && d.Config.TestVendorDir != "" && d.Config.Imports.Has(root) {
+		// With a vendor directory of their own the tests share the copy of
+		// the production code, so test dependencies never change its version.
+		peerVersion(v, dep, req)
+		dep = v
+	} else if v != nil && d.Config.Override(v) {
+		// An override wins over the versions asked for by dependencies.
+		dep = v
+	} else if dep != nil && v != nil {
 		if v.Reference == "" && dep.Reference != "" {
 			v.Reference = dep.Reference
 			// Clear the pin, if set, so the new version can be used.
@@ -811,6 +1219,8 @@ func (d *VersionHandler) SetVersion(pkg string, addTest bool) (e error) {
 		}
 	}
 
+	d.Config.Override(dep)
+
 	err := VcsVersion(dep)
 	if err != nil {
 		msg.Warn("Unable to set version on %s to %s. Err: %s", root, dep.Reference, err)
@@ -842,6 +1252,10 @@ func (d *VersionHandler) pkgPath(pkg string) string {
 		}
 	}
 
+	if dep.Local != "" {
+		return filepath.Join(localPath(dep), filepath.FromSlash(sub))
+	}
+
 	key, err := cache.Key(dep.Remote())
 	if err != nil {
 		msg.Die("Error generating cache key for %s", dep.Name)
@@ -872,7 +1286,7 @@ func determineDependency(v, dep *cfg.Dependency, dest, req string) *cfg.Dependen
 		return v
 	} else if vIsRef {
 		// The current one is a reference and the suggestion is a SemVer constraint.
-		con, err := semver.NewConstraint(dep.Reference)
+		con, err := cfg.NewConstraint(dep.Reference)
 		if err != nil {
 			singleWarn("Version issue for %s: '%s' is neither a reference or semantic version constraint\n", dep.Name, dep.Reference)
 			singleInfo("Keeping %s %s", v.Name, v.Reference)
@@ -897,7 +1311,7 @@ func determineDependency(v, dep *cfg.Dependency, dest, req string) *cfg.Dependen
 		return v
 	} else if depIsRef {
 
-		con, err := semver.NewConstraint(v.Reference)
+		con, err := cfg.NewConstraint(v.Reference)
 		if err != nil {
 			singleWarn("Version issue for %s: '%s' is neither a reference or semantic version constraint\n", v.Name, v.Reference)
 			singleInfo("Keeping %s %s", v.Name, v.Reference)
@@ -924,7 +1338,7 @@ func determineDependency(v, dep *cfg.Dependency, dest, req string) *cfg.Dependen
 	// Neither is a vcs reference and both could be semantic version
 	// constraints that are different.
 
-	_, err = semver.NewConstraint(dep.Reference)
+	_, err = cfg.NewConstraint(dep.Reference)
 	if err != nil {
 		// dd.Reference is not a reference or a valid constraint.
 		singleWarn("Version %s %s is not a reference or valid semantic version constraint\n", dep.Name, dep.Reference)
@@ -932,7 +1346,7 @@ func determineDependency(v, dep *cfg.Dependency, dest, req string) *cfg.Dependen
 		return v
 	}
 
-	_, err = semver.NewConstraint(v.Reference)
+	_, err = cfg.NewConstraint(v.Reference)
 	if err != nil {
 		// existing.Reference is not a reference or a valid constraint.
 		// We really should never end up here.
@@ -944,23 +1358,30 @@ func determineDependency(v, dep *cfg.Dependency, dest, req string) *cfg.Dependen
 		return v
 	}
 
-	// Both versions are constraints. Try to merge them.
-	// If either comparison has an || skip merging. That's complicated.
-	ddor := strings.Index(dep.Reference, "||")
-	eor := strings.Index(v.Reference, "||")
-	if ddor == -1 && eor == -1 {
-		// Add the comparisons together.
-		newRef := v.Reference + ", " + dep.Reference
-		v.Reference = newRef
-		v.Pin = ""
-		singleInfo("Combining %s semantic version constraints %s and %s", v.Name, v.Reference, dep.Reference)
-		return v
-	}
-	singleWarn("Conflict: %s version is %s, but also asked for %s\n", v.Name, v.Reference, dep.Reference)
-	singleInfo("Keeping %s %s", v.Name, v.Reference)
+	// Both versions are constraints. Merge them into one matching the
+	// versions both do, combining each alternative of one with each of the
+	// other.
+	singleInfo("Combining %s semantic version constraints %s and %s", v.Name, v.Reference, dep.Reference)
+	v.Reference = cfg.IntersectConstraints(v.Reference, dep.Reference)
+	v.Pin = ""
 	return v
 }
 
+// peerVersion reports on a dependency of the production code that the tests
+// use as it is, when a test dependency asks for another version of it.
+func peerVersion(v, dep *cfg.Dependency, req string) {
+	if dep == nil || dep.Reference == "" || dep.Reference == v.Reference {
+		return
+	}
+	if con, err := cfg.NewConstraint(dep.Reference); err == nil {
+		if ver, err := semver.NewVersion(v.Reference); err == nil && !con.Check(ver) {
+			singleWarn("Conflict: %s version is %s for the production code but %s wants %s for tests\n", v.Name, v.Reference, req, dep.Reference)
+			return
+		}
+	}
+	singleInfo("Keeping %s %s for tests as the production code uses it, rather than %s asked for by %s", v.Name, v.Reference, dep.Reference, req)
+}
+
 var warningMessage = make(map[string]bool)
 var infoMessage = make(map[string]bool)
 
@@ -0,0 +1,72 @@
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/vcs"
+)
+
+// linkDep places a symlink to dep at dest. A checkout of dep in the GOPATH is
+// linked so changes made there show up right away, whatever version it is at.
+// Otherwise the export of the pinned revision in the cache is linked. Paths
+// listed in the .glideignore of the package are not removed as that would
+// change the linked directory. When no link can be made dep is exported.
+func linkDep(repo vcs.Repo, key string, dep *cfg.Dependency, dest string) error {
+	target := gopathCheckout(dep.Name, gpath.Gopaths())
+	if target == "" {
+		if dep.Pin == "" {
+			msg.Debug("No revision of %s to link to, exporting it instead", dep.Name)
+			return exportDep(repo, key, dep, dest)
+		}
+		loc, err := cachedExport(repo, key, dep)
+		if err != nil {
+			return err
+		}
+		target = loc
+	}
+
+	if err := os.RemoveAll(dest); err != nil {
+		return err
+	}
+	if err := os.Symlink(target, dest); err != nil {
+		msg.Debug("Unable to symlink %s, exporting it instead: %s", target, err)
+		if err := os.MkdirAll(dest, 0755); err != nil {
+			return err
+		}
+		return exportDep(repo, key, dep, dest)
+	}
+	if dep.SubpackagesOnly {
+		msg.Warn("All of %s is vendored as it is linked, not only its subpackages", dep.Name)
+	}
+	msg.Debug("Linked %s to %s", dep.Name, target)
+	return nil
+}
+
+// gopathCheckout returns the directory of the checkout of a package in the
+// first of the gopaths holding one, or an empty string when there is none. Checkouts containing the
+// current project are skipped so a project is never linked into itself.
+func gopathCheckout(name string, gopaths []string) string {
+	base, err := filepath.Abs(gpath.Basepath())
+	if err != nil {
+		return ""
+	}
+	for _, gp := range gopaths {
+		p, err := filepath.Abs(filepath.Join(gp, "src", filepath.FromSlash(name)))
+		if err != nil {
+			continue
+		}
+		if fi, err := os.Stat(p); err != nil || !fi.IsDir() {
+			continue
+		}
+		if p == base || strings.HasPrefix(base, p+string(filepath.Separator)) {
+			continue
+		}
+		return p
+	}
+	return ""
+}
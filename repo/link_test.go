@@ -0,0 +1,78 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGopathCheckout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-link-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	first := filepath.Join(dir, "first")
+	second := filepath.Join(dir, "second")
+	foo := filepath.Join(second, "src", "example.com", "foo")
+	if err := os.MkdirAll(foo, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(first, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	gopaths := []string{first, second}
+	if p := gopathCheckout("example.com/foo", gopaths); p != foo {
+		t.Errorf("Expected the checkout %s, got %q", foo, p)
+	}
+	if p := gopathCheckout("example.com/bar", gopaths); p != "" {
+		t.Errorf("Expected no checkout for a missing package, got %q", p)
+	}
+
+	// The project itself is never linked.
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(foo); err != nil {
+		t.Fatal(err)
+	}
+	if p := gopathCheckout("example.com/foo", gopaths); p != "" {
+		t.Errorf("Expected the project not to be linked, got %q", p)
+	}
+}
+
+func TestReuseVendoredLink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-link-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	target := filepath.Join(dir, "foo")
+	if err := os.MkdirAll(target, 0755); err != nil {
+		t.Fatal(err)
+	}
+	vendor := filepath.Join(dir, "vendor")
+	if err := os.MkdirAll(filepath.Join(vendor, "example.com"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, filepath.Join(vendor, "example.com", "foo")); err != nil {
+		t.Skipf("Symlinks are not available: %s", err)
+	}
+
+	stage := filepath.Join(dir, "stage")
+	i := NewInstaller()
+	i.Vendor = vendor
+	if err := i.reuseVendored("example.com/foo", stage); err == nil {
+		t.Error("Expected a symlink not to be reused for a copy")
+	}
+	i.Link = true
+	if err := i.reuseVendored("example.com/foo", stage); err != nil {
+		t.Errorf("Expected the symlink to be reused: %s", err)
+	}
+}
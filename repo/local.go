@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// localPath returns the directory a dependency with a local replacement is
+// read from. Relative paths are relative to the directory of the glide.yaml
+// file.
+func localPath(dep *cfg.Dependency) string {
+	p := filepath.FromSlash(dep.Local)
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(gpath.Basepath(), p)
+}
+
+// checkLocal makes sure the local replacement of a dependency exists.
+func checkLocal(dep *cfg.Dependency) error {
+	p := localPath(dep)
+	fi, err := os.Stat(p)
+	if err != nil {
+		return fmt.Errorf("Unable to use the local copy of %s: %s", dep.Name, err)
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("Unable to use the local copy of %s: %s is not a directory", dep.Name, p)
+	}
+	return nil
+}
+
+// localVersion returns the version checked out in the local replacement of a
+// dependency. An empty string is returned when it is not a VCS checkout.
+func localVersion(dep *cfg.Dependency) string {
+	p := localPath(dep)
+	repo, err := OpenRepo(p)
+	if err != nil {
+		return ""
+	}
+
+	ver, err := repo.Version()
+	if err != nil {
+		msg.Debug("Unable to read the version of %s from %s: %s", dep.Name, p, err)
+		return ""
+	}
+	return ver
+}
+
+// exportLocal places the local replacement of a dependency at dest. A symlink
+// is used so changes are picked up without running Glide again. When symlinks
+// are not available, or copy is set, the directory is copied.
+func exportLocal(dep *cfg.Dependency, dest string, copy bool) error {
+	p, err := filepath.Abs(localPath(dep))
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dest); err != nil {
+		return err
+	}
+	if copy {
+		return gpath.CopyDir(p, dest)
+	}
+	if err := os.Symlink(p, dest); err == nil {
+		return nil
+	}
+	msg.Debug("Unable to symlink %s, copying it instead", p)
+	return gpath.CopyDir(p, dest)
+}
+
+// applyLocal copies the local replacements set in conf to the dependencies
+// read from a lock file.
+func applyLocal(deps cfg.Dependencies, conf *cfg.Config) {
+	for _, d := range deps {
+		c := conf.Imports.Get(d.Name)
+		if c == nil {
+			c = conf.DevImports.Get(d.Name)
+		}
+		if c != nil {
+			d.Local = c.Local
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/glide/cfg"
+)
+
+func TestExportLocal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-local-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "foo")
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "foo.go"), []byte("package foo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dep := &cfg.Dependency{Name: "example.com/foo", Local: src}
+	if err := checkLocal(dep); err != nil {
+		t.Fatalf("Unexpected error checking local copy: %s", err)
+	}
+	if err := checkLocal(&cfg.Dependency{Name: "example.com/bar", Local: filepath.Join(dir, "bar")}); err == nil {
+		t.Error("Expected an error for a missing local copy")
+	}
+	if v := localVersion(dep); v != "" {
+		t.Errorf("Expected no version for a directory without a VCS, got %s", v)
+	}
+
+	dest := filepath.Join(dir, "vendor", "example.com", "foo")
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := exportLocal(dep, dest, false); err != nil {
+		t.Fatalf("Unable to export local copy: %s", err)
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(dest, "foo.go")); err != nil || string(b) != "package foo" {
+		t.Errorf("Unexpected exported content %q (%v)", b, err)
+	}
+
+	// Changes to the local copy show up in the vendor directory.
+	if fi, err := os.Lstat(dest); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+		if err := ioutil.WriteFile(filepath.Join(src, "bar.go"), []byte("package foo"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(dest, "bar.go")); err != nil {
+			t.Errorf("Expected changes to the local copy to be visible: %s", err)
+		}
+	}
+}
+
+func TestApplyLocal(t *testing.T) {
+	conf := &cfg.Config{
+		Imports:    cfg.Dependencies{{Name: "example.com/foo", Local: "../foo"}},
+		DevImports: cfg.Dependencies{{Name: "example.com/bar", Local: "../bar"}},
+	}
+	deps := cfg.Dependencies{
+		{Name: "example.com/foo", Reference: "v1.0.0"},
+		{Name: "example.com/bar"},
+		{Name: "example.com/baz"},
+	}
+	applyLocal(deps, conf)
+	if deps[0].Local != "../foo" || deps[1].Local != "../bar" || deps[2].Local != "" {
+		t.Errorf("Unexpected local copies %q, %q, %q", deps[0].Local, deps[1].Local, deps[2].Local)
+	}
+}
@@ -0,0 +1,57 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/glide/cfg"
+)
+
+func TestNestedPins(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-nested-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	deps := []*cfg.Dependency{
+		{Name: "example.com/a", Reference: "^1.0.0", Repository: "https://example.com/fork/a"},
+		{Name: "example.com/b", Reference: "~2.1"},
+	}
+	if got := nestedPins(dir, deps); len(got) != 2 || got[0].Reference != "^1.0.0" {
+		t.Fatal("Expected the dependencies to be unchanged without a lock file")
+	}
+
+	lock := []byte(`hash: abc
+updated: 2016-01-01T00:00:00Z
+imports:
+- name: example.com/a
+  version: 1.2.3
+- name: example.com/c
+  version: 4a1b2c
+testImports: []
+`)
+	if err := ioutil.WriteFile(filepath.Join(dir, "glide.lock"), lock, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := nestedPins(dir, deps)
+	expected := map[string]string{
+		"example.com/a": "1.2.3",
+		"example.com/b": "~2.1",
+		"example.com/c": "4a1b2c",
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d dependencies, got %d", len(expected), len(got))
+	}
+	for _, d := range got {
+		if d.Reference != expected[d.Name] {
+			t.Errorf("Expected %s at %s, got %s", d.Name, expected[d.Name], d.Reference)
+		}
+		if d.Name == "example.com/a" && d.Repository != "https://example.com/fork/a" {
+			t.Errorf("Expected the repo of example.com/a to be kept, got %s", d.Repository)
+		}
+	}
+}
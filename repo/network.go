@@ -0,0 +1,160 @@
+package repo
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/glide/archive"
+	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/glide/stats"
+)
+
+// NetworkTimeout is the longest a single attempt to fetch a repository can
+// take. A zero value means there is no limit.
+var NetworkTimeout time.Duration
+
+// NetworkRetries is how many times a failed fetch of a repository is tried
+// again. Fetches that timed out are not tried again as the VCS command keeps
+// running in the background.
+var NetworkRetries int
+
+// HostConcurrency is the most fetches run at once from a single host. A zero
+// value means there is no limit beyond the number of workers.
+var HostConcurrency int
+
+// RetryBackoff is how long to wait before trying a failed fetch again. The
+// wait doubles with each attempt, up to maxBackoff.
+var RetryBackoff = time.Second
+
+const maxBackoff = 30 * time.Second
+
+// Fallbacks are the remotes, such as an archive service, tried for a
+// repository that is gone. {name} is replaced with the name of the dependency.
+var Fallbacks []string
+
+// NetworkSettings are the network settings in the config.yaml file in
+// GLIDE_HOME:
+//
+//     network:
+//       retries: 3
+//       host-concurrency: 4
+//       fallbacks:
+//         - https://archive.example.com/{name}
+//       ssh-fallback:
+//         git.example.com: git@git.example.com
+//         github.com: "off"
+type NetworkSettings struct {
+	// Timeout is the former name of fetch-attempt in the timeouts settings.
+	// It is used when that is not set.
+	Timeout string `yaml:"timeout,omitempty"`
+
+	Retries         int               `yaml:"retries,omitempty"`
+	HostConcurrency int               `yaml:"host-concurrency,omitempty"`
+	Fallbacks       []string          `yaml:"fallbacks,omitempty"`
+	SSHFallback     map[string]string `yaml:"ssh-fallback,omitempty"`
+}
+
+// LoadNetworkSettings sets NetworkRetries, HostConcurrency, Fallbacks, and
+// SSHFallbacks from the network settings in config.yaml.
+func LoadNetworkSettings() error {
+	n := &NetworkSettings{}
+	if err := gpath.GlobalConfig("network", n); err != nil {
+		return err
+	}
+	NetworkRetries = n.Retries
+	HostConcurrency = n.HostConcurrency
+	Fallbacks = n.Fallbacks
+	SSHFallbacks = n.SSHFallback
+	return nil
+}
+
+// fetch updates the repository of a dependency in the cache within the limits
+// set for network operations.
+// With stats enabled, the growth of the cached copy is recorded as the bytes
+// fetched.
+func fetch(dep *cfg.Dependency, force bool, updated *UpdateTracker) error {
+	var key string
+	var before int64
+	if stats.Enabled {
+		key, _ = cache.Key(dep.Remote())
+		before = cache.RepoSize(key)
+	}
+	err := withTimeout("fetch", dep.Name, FetchTimeout, func() error {
+		return withRetry("fetch", dep, func() error {
+			return VcsUpdate(dep, force, updated)
+		})
+	})
+	if stats.Enabled && key != "" {
+		stats.AddBytes(dep.Name, cache.RepoSize(key)-before)
+	}
+	return err
+}
+
+// withRetry runs fn, a network operation on the repository of dep, trying it
+// again when it fails. Each attempt is limited to NetworkTimeout and waits its
+// turn when HostConcurrency operations are already running on the host.
+func withRetry(op string, dep *cfg.Dependency, fn func() error) error {
+	host := RemoteHost(dep.Remote())
+	wait := RetryBackoff
+	for attempt := 0; ; attempt++ {
+		release := acquireHost(host)
+		err := within(op, dep.Name, NetworkTimeout, fn)
+		release()
+		if err == nil || attempt >= NetworkRetries {
+			return err
+		}
+		if _, ok := err.(*TimeoutError); ok {
+			return err
+		}
+		msg.Warn("--> The %s of %s failed: %s. Trying again in %s", op, dep.Name, err, wait)
+		time.Sleep(wait)
+		if wait *= 2; wait > maxBackoff {
+			wait = maxBackoff
+		}
+	}
+}
+
+var (
+	hostSlots   = make(map[string]chan struct{})
+	hostSlotsMu sync.Mutex
+)
+
+// acquireHost waits until fewer than HostConcurrency operations are running
+// on host and returns the func to call when the operation is done.
+func acquireHost(host string) func() {
+	if HostConcurrency <= 0 || host == "" {
+		return func() {}
+	}
+	hostSlotsMu.Lock()
+	slots, ok := hostSlots[host]
+	if !ok {
+		slots = make(chan struct{}, HostConcurrency)
+		hostSlots[host] = slots
+	}
+	hostSlotsMu.Unlock()
+
+	slots <- struct{}{}
+	return func() { <-slots }
+}
+
+// RemoteHost returns the host of a remote repository location, which is a URL
+// or an SCP-like address such as git@github.com:foo/bar. GitHub releases are
+// on github.com.
+func RemoteHost(remote string) string {
+	if archive.IsRelease(remote) {
+		return "github.com"
+	}
+	if u, err := url.Parse(remote); err == nil && u.Host != "" {
+		return u.Host
+	}
+	if i := strings.Index(remote, ":"); i > 0 && !strings.Contains(remote[:i], "/") {
+		h := remote[:i]
+		return h[strings.LastIndex(h, "@")+1:]
+	}
+	return ""
+}
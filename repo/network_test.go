@@ -0,0 +1,123 @@
+package repo
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+func TestWithRetry(t *testing.T) {
+	o := msg.Default.Stderr
+	msg.Default.Stderr = ioutil.Discard
+	defer func() { msg.Default.Stderr = o }()
+	defer func(r int, b time.Duration) { NetworkRetries, RetryBackoff = r, b }(NetworkRetries, RetryBackoff)
+	NetworkRetries = 2
+	RetryBackoff = time.Millisecond
+
+	dep := &cfg.Dependency{Name: "github.com/Masterminds/vcs"}
+	attempts := 0
+	err := withRetry("fetch", dep, func() error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("connection reset")
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 {
+		t.Errorf("Expected success on the third attempt, got %v after %d", err, attempts)
+	}
+
+	attempts = 0
+	err = withRetry("fetch", dep, func() error {
+		attempts++
+		return errors.New("connection reset")
+	})
+	if err == nil || attempts != 3 {
+		t.Errorf("Expected failure after 3 attempts, got %v after %d", err, attempts)
+	}
+
+	defer func(d time.Duration) { NetworkTimeout = d }(NetworkTimeout)
+	NetworkTimeout = 10 * time.Millisecond
+	block := make(chan struct{})
+	defer close(block)
+	attempts = 0
+	err = withRetry("fetch", dep, func() error {
+		attempts++
+		<-block
+		return nil
+	})
+	if terr, ok := err.(*TimeoutError); !ok || terr.Name != dep.Name || attempts != 1 {
+		t.Errorf("Expected a timeout of %s without trying again, got %v after %d attempts", dep.Name, err, attempts)
+	}
+}
+
+func TestAcquireHost(t *testing.T) {
+	defer func(c int) { HostConcurrency = c }(HostConcurrency)
+	HostConcurrency = 1
+
+	release := acquireHost("example.com")
+	acquired := make(chan struct{})
+	go func() {
+		acquireHost("example.com")()
+		close(acquired)
+	}()
+	acquireHost("example.org")()
+
+	select {
+	case <-acquired:
+		t.Fatal("A second operation ran on a host limited to one")
+	case <-time.After(20 * time.Millisecond):
+	}
+	release()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("The host was not released")
+	}
+}
+
+func TestRemoteHost(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/Masterminds/vcs": "github.com",
+		"ssh://git@example.com:2222/foo.git": "example.com:2222",
+		"git@github.com:Masterminds/vcs.git": "github.com",
+		"/path/to/repo":                      "",
+		"github-release://foo/bar@v1.0.0":    "github.com",
+	}
+	for remote, host := range tests {
+		if h := RemoteHost(remote); h != host {
+			t.Errorf("Expected host %q for %s, got %q", host, remote, h)
+		}
+	}
+}
+
+func TestLoadNetworkSettings(t *testing.T) {
+	home, err := ioutil.TempDir("", "glide-network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer gpath.SetHome(gpath.Home())
+	gpath.SetHome(home)
+	defer func(r, c int) {
+		NetworkRetries, HostConcurrency = r, c
+	}(NetworkRetries, HostConcurrency)
+
+	yml := "network:\n  retries: 3\n  host-concurrency: 2\n"
+	if err := ioutil.WriteFile(filepath.Join(home, "config.yaml"), []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadNetworkSettings(); err != nil {
+		t.Fatal(err)
+	}
+	if NetworkRetries != 3 || HostConcurrency != 2 {
+		t.Errorf("Unexpected settings %d, %d", NetworkRetries, HostConcurrency)
+	}
+}
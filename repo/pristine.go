@@ -0,0 +1,46 @@
+package repo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/cfg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// ExportPristine places the revision of dep set in Reference at dest the way
+// it is exported to the vendor directory. It is exported from the repo rather
+// than the exports in the cache, which vendored files linked to them may have
+// changed. The repo is fetched into the cache when it is not there yet.
+func ExportPristine(dep *cfg.Dependency, dest string) error {
+	if dep.Local != "" {
+		return fmt.Errorf("%s uses the local copy at %s", dep.Name, dep.Local)
+	}
+
+	key, err := cache.Key(dep.Remote())
+	if err != nil {
+		return err
+	}
+	cdir := filepath.Join(cache.Location(), "src", key)
+	if _, err := os.Stat(cdir); os.IsNotExist(err) {
+		if err := VcsGet(dep); err != nil {
+			return err
+		}
+	}
+
+	cache.Lock(key)
+	defer cache.Unlock(key)
+	if err := VcsVersion(dep); err != nil {
+		return err
+	}
+	repo, err := dep.GetRepo(cdir)
+	if err != nil {
+		return err
+	}
+	if err := repo.ExportDir(dest); err != nil {
+		return err
+	}
+	return gpath.RemoveIgnored(dest)
+}
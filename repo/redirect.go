@@ -0,0 +1,184 @@
+package repo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/credentials"
+	"github.com/Masterminds/glide/msg"
+	v "github.com/Masterminds/vcs"
+)
+
+// AcceptRedirects follows dependencies whose repo moved to another location
+// without asking first.
+var AcceptRedirects bool
+
+// redirectClient probes where a repo moved to. Redirects are returned rather
+// than followed.
+var redirectClient = &http.Client{
+	Timeout: 30 * time.Second,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+var (
+	redirectMu sync.Mutex
+	redirects  = make(map[string]string)
+	declined   = make(map[string]bool)
+)
+
+// Redirects returns the repos that dependencies moved to, by name, for the
+// moves that were accepted.
+func Redirects() map[string]string {
+	redirectMu.Lock()
+	defer redirectMu.Unlock()
+	r := make(map[string]string, len(redirects))
+	for k, v := range redirects {
+		r[k] = v
+	}
+	return r
+}
+
+// followRedirect fetches dep from the location its repo moved to after fetching
+// it failed with cause. A move is found from the redirect a Git host answers
+// with for the old location or, when the cached copy is of another remote than
+// the one the import path now resolves to, from its go-import meta tag. Once
+// the move is accepted the cached copy is moved along and the repository of dep
+// is set to the new location so it is recorded. cause is returned when the repo
+// did not move or the move is declined.
+func followRedirect(dep *cfg.Dependency, cause error) error {
+	old := dep.Remote()
+	var moved string
+	if cause == v.ErrWrongRemote {
+		moved = resolvedRemote(dep)
+	} else if vt := dep.Vcs(); vt == "" || vt == string(v.Git) {
+		moved = movedRemote(old)
+	}
+	if moved == "" || moved == old || !acceptRedirect(dep, old, moved) {
+		return cause
+	}
+
+	if err := moveCached(old, moved); err != nil {
+		msg.Warn("--> Unable to move the cached copy of %s: %s", dep.Name, err)
+	}
+	dep.Repository = moved
+	return VcsGet(dep)
+}
+
+// movedRemote returns the location the Git repo at remote moved to, as told by
+// a redirect from the host, or an empty string when it did not move. Only
+// http and https remotes can be asked.
+func movedRemote(remote string) string {
+	u, err := url.Parse(remote)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	req, err := http.NewRequest("GET", strings.TrimSuffix(remote, "/")+"/info/refs?service=git-upload-pack", nil)
+	if err != nil {
+		return ""
+	}
+	credentials.Authorize(req)
+	resp, err := redirectClient.Do(req)
+	if err != nil {
+		msg.Debug("Unable to ask %s whether it moved: %s", remote, err)
+		return ""
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 300 || resp.StatusCode >= 400 {
+		return ""
+	}
+	loc, err := resp.Location()
+	if err != nil {
+		return ""
+	}
+	loc.RawQuery = ""
+	moved := strings.TrimSuffix(loc.String(), "/info/refs")
+	if moved == strings.TrimSuffix(remote, "/") {
+		return ""
+	}
+	return moved
+}
+
+// resolvedRemote returns the remote the import path of dep resolves to now,
+// which its go-import meta tag may have changed since it was cached.
+func resolvedRemote(dep *cfg.Dependency) string {
+	tmp, err := ioutil.TempDir("", "glide-redirect")
+	if err != nil {
+		return ""
+	}
+	defer os.RemoveAll(tmp)
+	repo, err := dep.GetRepo(filepath.Join(tmp, "repo"))
+	if err != nil {
+		msg.Debug("Unable to find the remote of %s: %s", dep.Name, err)
+		return ""
+	}
+	return repo.Remote()
+}
+
+// acceptRedirect asks whether to fetch dep from the location it moved to from
+// now on, unless AcceptRedirects is set. The answer is kept so it is only asked
+// once for each dependency.
+func acceptRedirect(dep *cfg.Dependency, old, moved string) bool {
+	redirectMu.Lock()
+	defer redirectMu.Unlock()
+	if r, ok := redirects[dep.Name]; ok {
+		return r == moved
+	}
+	if declined[dep.Name] {
+		return false
+	}
+
+	msg.Warn("--> %s moved from %s to %s", dep.Name, old, moved)
+	if !AcceptRedirects {
+		msg.Info("Fetch %s from %s from now on? Yes (Y) or No (N)?", dep.Name, moved)
+		res, err := msg.PromptUntil([]string{"y", "yes", "n", "no"})
+		if err != nil {
+			msg.Warn("--> Unable to ask whether to follow %s: %s. Use --accept-redirects to follow moved repos", dep.Name, err)
+		}
+		if err != nil || res == "n" || res == "no" {
+			declined[dep.Name] = true
+			return false
+		}
+	}
+	redirects[dep.Name] = moved
+	return true
+}
+
+// moveCached moves the cached copy of the repo at old to where the repo at
+// moved is cached and points a Git checkout at moved. Checkouts of other VCS
+// are left in place and moved is fetched anew.
+func moveCached(old, moved string) error {
+	from, err := cache.Key(old)
+	if err != nil {
+		return err
+	}
+	to, err := cache.Key(moved)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(cache.Location(), "src", from, ".git")); err != nil {
+		return nil
+	}
+	if err := cache.Move(from, to); err != nil {
+		return err
+	}
+
+	repo, err := v.NewGitRepo("", filepath.Join(cache.Location(), "src", to))
+	if err != nil {
+		return err
+	}
+	if out, err := repo.RunFromDir("git", "remote", "set-url", "origin", moved); err != nil {
+		return fmt.Errorf("%s (%s)", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
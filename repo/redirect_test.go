@@ -0,0 +1,34 @@
+package repo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMovedRemote(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old/repo/info/refs":
+			http.Redirect(w, r, "/new/repo/info/refs?"+r.URL.RawQuery, http.StatusMovedPermanently)
+		case "/new/repo/info/refs":
+			w.Write([]byte("refs"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := map[string]string{
+		srv.URL + "/old/repo":  srv.URL + "/new/repo",
+		srv.URL + "/old/repo/": srv.URL + "/new/repo",
+		srv.URL + "/new/repo":  "",
+		srv.URL + "/gone/repo": "",
+		"git@example.com:a/b":  "",
+	}
+	for remote, want := range tests {
+		if got := movedRemote(remote); got != want {
+			t.Errorf("movedRemote(%q) = %q, want %q", remote, got, want)
+		}
+	}
+}
@@ -1,10 +1,48 @@
 package repo
 
 import (
+	"fmt"
+	"sort"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
 	"github.com/Masterminds/semver"
 	"github.com/Masterminds/vcs"
 )
 
+// Prerelease sets if pre-release versions, such as 1.5.0-rc.1, may satisfy
+// semantic version constraints. Dependencies can also ask for this
+// individually.
+var Prerelease bool
+
+// MajorLimits holds, by package name, the major versions constraints are
+// narrowed to, keeping updates from pulling in breaking releases. A constraint
+// that no release of the major version meets asks for another major version
+// explicitly and is used as is. Dependencies without a version take the newest
+// release of the major version rather than the tip of the default branch.
+var MajorLimits map[string]int64
+
+// ConstraintError is returned when no version of a dependency satisfies the
+// constraint it is asked for with.
+type ConstraintError struct {
+	Name       string
+	Constraint string
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("Unable to find a version of %s satisfying %s", e.Name, e.Constraint)
+}
+
+// Kind returns msg.KindConflict.
+func (e *ConstraintError) Kind() msg.Kind {
+	return msg.KindConflict
+}
+
+// majorRange returns the constraint matching the releases of a major version.
+func majorRange(major int64) string {
+	return fmt.Sprintf(">=%d.0.0, <%d.0.0", major, major+1)
+}
+
 // Filter a list of versions to only included semantic versions. The response
 // is a mapping of the original version to the semantic version.
 func getSemVers(refs []string) []*semver.Version {
@@ -19,6 +57,23 @@ func getSemVers(refs []string) []*semver.Version {
 	return sv
 }
 
+// prefixedRefs returns the references of a repo carrying the tag prefix of the
+// dependency, such as api/v1.2.3 for a monorepo tagging releases of its api
+// directory, with the prefix removed. Without a prefix they are returned as
+// they are.
+func prefixedRefs(dep *cfg.Dependency, refs []string) []string {
+	if dep.TagPrefix == "" {
+		return refs
+	}
+	var trimmed []string
+	for _, r := range refs {
+		if v, ok := dep.TagVersion(r); ok {
+			trimmed = append(trimmed, v)
+		}
+	}
+	return trimmed
+}
+
 // Get all the references for a repo. This includes the tags and branches.
 func getAllVcsRefs(repo vcs.Repo) ([]string, error) {
 	tags, err := repo.Tags()
@@ -33,3 +88,69 @@ func getAllVcsRefs(repo vcs.Repo) ([]string, error) {
 
 	return append(branches, tags...), nil
 }
+
+// byPrecedence sorts versions from the highest to the lowest. Versions with
+// the same precedence, which differ only in build metadata or in how they are
+// written, are ordered so the same one is always picked: the one without
+// build metadata first, then by metadata and the reference itself.
+type byPrecedence []*semver.Version
+
+func (s byPrecedence) Len() int      { return len(s) }
+func (s byPrecedence) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byPrecedence) Less(i, j int) bool {
+	if c := s[i].Compare(s[j]); c != 0 {
+		return c > 0
+	}
+	mi, mj := s[i].Metadata(), s[j].Metadata()
+	if mi != mj {
+		if mi == "" || mj == "" {
+			return mi == ""
+		}
+		return mi < mj
+	}
+	return s[i].Original() < s[j].Original()
+}
+
+// matchVersion returns the highest of the versions satisfying the constraint
+// that the dependency does not exclude, or nil when there is none.
+//
+// A pre-release version only satisfies a constraint comparing it to another
+// pre-release of the same release, such as >=1.5.0-rc.1, unless pre-releases
+// are allowed for the dependency or globally. Then one satisfies any
+// constraint the release it leads up to does.
+//
+// With a limit in MajorLimits for the dependency, versions of its major version
+// are preferred.
+func matchVersion(dep *cfg.Dependency, constraint *semver.Constraints, versions []*semver.Version) *semver.Version {
+	sort.Sort(byPrecedence(versions))
+	pre := Prerelease || dep.Prerelease
+	match := func(major int64, limited bool) *semver.Version {
+		for _, v := range versions {
+			if dep.Excludes(v.Original()) || (limited && v.Major() != major) {
+				continue
+			}
+			if constraint.Check(v) {
+				return v
+			}
+			if pre && v.Prerelease() != "" && constraint.Check(releaseOf(v)) {
+				return v
+			}
+		}
+		return nil
+	}
+	if major, ok := MajorLimits[dep.Name]; ok {
+		if v := match(major, true); v != nil {
+			return v
+		}
+	}
+	return match(0, false)
+}
+
+// releaseOf returns the release a pre-release version leads up to.
+func releaseOf(v *semver.Version) *semver.Version {
+	r, err := semver.NewVersion(fmt.Sprintf("%d.%d.%d", v.Major(), v.Minor(), v.Patch()))
+	if err != nil {
+		return v
+	}
+	return r
+}
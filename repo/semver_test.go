@@ -0,0 +1,118 @@
+package repo
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/semver"
+)
+
+func TestMatchVersion(t *testing.T) {
+	refs := []string{"master", "v1.4.0", "1.4.1+b", "1.4.1+a", "v1.4.1", "1.5.0-rc.1", "2.0.0"}
+	tests := []struct {
+		constraint string
+		prerelease bool
+		global     bool
+		exclude    []string
+		want       string
+	}{
+		{"^1.2.0", false, false, nil, "v1.4.1"},
+		{"^1.2.0", true, false, nil, "1.5.0-rc.1"},
+		{"^1.2.0", false, true, nil, "1.5.0-rc.1"},
+		{">=1.5.0-rc.1", false, false, nil, "2.0.0"},
+		{">=1.5.0-rc.1", false, false, []string{"2.0.0"}, "1.5.0-rc.1"},
+		{"^1.2.0", false, false, []string{"1.4.1"}, "v1.4.0"},
+		{"~1.4.1", false, false, []string{"v1.4.1"}, ""},
+		{"^3.0.0", true, false, nil, ""},
+		{"^1.2 || ^2.0, !=2.0.0", false, false, nil, "v1.4.1"},
+		{"~1.3 || >=2", false, false, nil, "2.0.0"},
+		{">=1.4 <1.4.1 || ^3", false, false, nil, "v1.4.0"},
+	}
+	for _, tt := range tests {
+		Prerelease = tt.global
+		c, err := cfg.NewConstraint(tt.constraint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dep := &cfg.Dependency{Prerelease: tt.prerelease, ExcludeVersions: tt.exclude}
+		got := ""
+		if v := matchVersion(dep, c, getSemVers(refs)); v != nil {
+			got = v.Original()
+		}
+		if got != tt.want {
+			t.Errorf("Expected %s with pre-releases %t/%t to match %q, got %q", tt.constraint, tt.prerelease, tt.global, tt.want, got)
+		}
+	}
+	Prerelease = false
+}
+
+func TestMatchVersionMajorLimit(t *testing.T) {
+	refs := []string{"v1.4.0", "v1.4.1", "1.5.0-rc.1", "2.0.0", "2.1.0"}
+	MajorLimits = map[string]int64{"example.com/foo": 1}
+	defer func() { MajorLimits = nil }()
+
+	tests := []struct {
+		name       string
+		constraint string
+		want       string
+	}{
+		{"example.com/foo", ">=1.0.0", "v1.4.1"},
+		{"example.com/foo", majorRange(1), "v1.4.1"},
+		{"example.com/foo", "^2.0.0", "2.1.0"},
+		{"example.com/bar", ">=1.0.0", "2.1.0"},
+	}
+	for _, tt := range tests {
+		c, err := semver.NewConstraint(tt.constraint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if v := matchVersion(&cfg.Dependency{Name: tt.name}, c, getSemVers(refs)); v != nil {
+			got = v.Original()
+		}
+		if got != tt.want {
+			t.Errorf("Expected %s of %s to match %q, got %q", tt.constraint, tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestMatchVersionTagPrefix(t *testing.T) {
+	refs := []string{"master", "v2.0.0", "api/v1.2.3", "api/v1.3.0", "api/v2.0.0-rc.1", "cli/v1.9.0"}
+	tests := []struct {
+		prefix     string
+		constraint string
+		want       string
+	}{
+		{"api/", "^1.2.0", "v1.3.0"},
+		{"api/", "~1.2.0", "v1.2.3"},
+		{"api/", "^2.0.0", ""},
+		{"cli/", ">=1.0.0", "v1.9.0"},
+		{"", ">=1.0.0", "v2.0.0"},
+	}
+	for _, tt := range tests {
+		c, err := cfg.NewConstraint(tt.constraint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dep := &cfg.Dependency{TagPrefix: tt.prefix}
+		got := ""
+		if v := matchVersion(dep, c, getSemVers(prefixedRefs(dep, refs))); v != nil {
+			got = v.Original()
+		}
+		if got != tt.want {
+			t.Errorf("Expected %s with prefix %q to match %q, got %q", tt.constraint, tt.prefix, tt.want, got)
+		}
+	}
+}
+
+func TestByPrecedence(t *testing.T) {
+	v := getSemVers([]string{"1.4.1+b", "v1.4.1", "1.4.1+a", "1.4.1", "1.5.0-rc.1", "1.4.0"})
+	sort.Sort(byPrecedence(v))
+	want := []string{"1.5.0-rc.1", "1.4.1", "v1.4.1", "1.4.1+a", "1.4.1+b", "1.4.0"}
+	for i, w := range want {
+		if v[i].Original() != w {
+			t.Errorf("Expected %s at position %d, got %s", w, i, v[i].Original())
+		}
+	}
+}
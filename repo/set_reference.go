@@ -17,6 +17,9 @@ func SetReference(conf *cfg.Config, resolveTest bool) error {
 		msg.Info("No references set.\n")
 		return nil
 	}
+	if err := rewriteRepos(append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...)...); err != nil {
+		return err
+	}
 
 	done := make(chan struct{}, concurrentWorkers)
 	in := make(chan *cfg.Dependency, concurrentWorkers)
@@ -30,18 +33,13 @@ func SetReference(conf *cfg.Config, resolveTest bool) error {
 				select {
 				case dep := <-ch:
 
-					var loc string
-					if dep.Repository != "" {
-						loc = dep.Repository
-					} else {
-						loc = "https://" + dep.Name
+					key, err := cache.Key(dep.Remote())
+					if err == nil {
+						cache.Lock(key)
+						err = VcsVersion(dep)
+						cache.Unlock(key)
 					}
-					key, err := cache.Key(loc)
 					if err != nil {
-						msg.Die(err.Error())
-					}
-					cache.Lock(key)
-					if err := VcsVersion(dep); err != nil {
 						msg.Err("Failed to set version on %s to %s: %s\n", dep.Name, dep.Reference, err)
 
 						// Capture the error while making sure the concurrent
@@ -54,7 +52,6 @@ func SetReference(conf *cfg.Config, resolveTest bool) error {
 						}
 						lock.Unlock()
 					}
-					cache.Unlock(key)
 					wg.Done()
 				case <-done:
 					return
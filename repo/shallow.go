@@ -0,0 +1,130 @@
+package repo
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+	v "github.com/Masterminds/vcs"
+)
+
+// Shallow sets if Git dependencies are shallow cloned when their version is a
+// specific tag, branch, or commit id. Dependencies can also ask for this
+// individually.
+var Shallow bool
+
+// hexRe matches references that may be abbreviated commit ids.
+var hexRe = regexp.MustCompile(`^[0-9a-f]{7,39}$`)
+
+// fullCommitRe matches complete Git commit ids.
+var fullCommitRe = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// shallowRef returns the tag, branch, or commit id a dependency can be
+// shallow cloned at. An empty string is returned when a full clone is needed,
+// such as when the version is a semantic version constraint or an
+// abbreviated commit id.
+func shallowRef(dep *cfg.Dependency, repo v.Repo) string {
+	if !Shallow && !dep.Shallow {
+		return ""
+	}
+	if repo.Vcs() != v.Git {
+		return ""
+	}
+	ref := dep.Reference
+	if ref == "" || hexRe.MatchString(ref) || strings.ContainsAny(ref, "^~<>=!*, |") {
+		return ""
+	}
+	return ref
+}
+
+// getShallow clones a single tag, branch, or commit of the Git repo of dep
+// without its history. Submodules are only cloned when dep asks for them.
+// Fetching a single commit requires the server to allow it, as most hosting
+// services do.
+func getShallow(dep *cfg.Dependency, repo v.Repo, ref string) error {
+	dest := repo.LocalPath()
+	if !fullCommitRe.MatchString(ref) {
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		args := []string{"clone", "--depth", "1", "--single-branch", "--branch", ref}
+		if dep.Submodules {
+			args = append(args, "--recursive")
+		}
+		return runGit(filepath.Dir(dest), append(args, repo.Remote(), dest)...)
+	}
+
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+	cmds := [][]string{
+		{"init"},
+		{"remote", "add", "origin", repo.Remote()},
+		{"fetch", "--depth", "1", "origin", ref},
+		{"checkout", "FETCH_HEAD"},
+	}
+	for _, args := range cmds {
+		if err := runGit(dest, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runGit runs a git command within dir.
+func runGit(dir string, args ...string) error {
+	c := exec.Command("git", args...)
+	c.Dir = dir
+	c.Env = envForDir(dir)
+	out, err := c.CombinedOutput()
+	msg.Output("git", out)
+	if err != nil {
+		return fmt.Errorf("%s: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// isShallow returns true if a Git repo was cloned without its full history.
+func isShallow(repo v.Repo) bool {
+	if repo.Vcs() != v.Git {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(repo.LocalPath(), ".git", "shallow"))
+	return err == nil
+}
+
+// unshallow fetches the full history, including all branches and tags, of a
+// shallow cloned Git repo.
+func unshallow(repo v.Repo) error {
+	out, err := repo.RunFromDir("git", "config", "remote.origin.fetch", "+refs/heads/*:refs/remotes/origin/*")
+	msg.Output("git", out)
+	if err != nil {
+		return fmt.Errorf("%s: %s", err, strings.TrimSpace(string(out)))
+	}
+	out, err = repo.RunFromDir("git", "fetch", "--unshallow", "--tags", "origin")
+	msg.Output("git", out)
+	if err != nil {
+		return fmt.Errorf("%s: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// updateVersion sets the version of a checkout. When the version cannot be
+// found in a shallow clone the full history is fetched and it is tried again.
+func updateVersion(dep *cfg.Dependency, repo v.Repo, ver string) error {
+	err := repo.UpdateVersion(ver)
+	if err == nil || !isShallow(repo) {
+		return err
+	}
+
+	msg.Info("--> %s is not in the shallow clone of %s. Fetching the full history", ver, dep.Name)
+	if err := unshallow(repo); err != nil {
+		return err
+	}
+	return repo.UpdateVersion(ver)
+}
@@ -0,0 +1,94 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/vcs"
+)
+
+func TestShallowRef(t *testing.T) {
+	r, err := vcs.NewGitRepo("https://github.com/Masterminds/vcs", "/tmp/does-not-exist")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(s bool) { Shallow = s }(Shallow)
+	Shallow = false
+	if ref := shallowRef(&cfg.Dependency{Reference: "v1.0.0"}, r); ref != "" {
+		t.Errorf("Expected no shallow clone when not enabled, got %s", ref)
+	}
+
+	Shallow = true
+	tests := map[string]string{
+		"":        "",
+		"v1.0.0":  "v1.0.0",
+		"master":  "master",
+		"^1.2.0":  "",
+		">=1, <2": "",
+		"3084677": "",
+		"3084677c2c188840777bff30054f2b553729d329": "3084677c2c188840777bff30054f2b553729d329",
+	}
+	for in, want := range tests {
+		if ref := shallowRef(&cfg.Dependency{Reference: in}, r); ref != want {
+			t.Errorf("shallowRef(%q) = %q, want %q", in, ref, want)
+		}
+	}
+}
+
+func TestGetShallow(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-shallow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	git := func(args ...string) string {
+		c := exec.Command("git", append([]string{"-c", "user.name=glide", "-c", "user.email=glide@example.com"}, args...)...)
+		c.Dir = src
+		out, err := c.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %s %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	os.MkdirAll(src, 0755)
+	git("init")
+	git("symbolic-ref", "HEAD", "refs/heads/master")
+	git("commit", "--allow-empty", "-m", "first")
+	first := git("rev-parse", "HEAD")
+	git("tag", "v1.0.0")
+	git("commit", "--allow-empty", "-m", "second")
+
+	r, err := vcs.NewGitRepo("file://"+src, filepath.Join(dir, "dest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dep := &cfg.Dependency{Name: "example.com/src"}
+	if err := getShallow(dep, r, "v1.0.0"); err != nil {
+		t.Fatalf("Unable to shallow clone: %s", err)
+	}
+	if !isShallow(r) {
+		t.Error("Expected a shallow clone")
+	}
+	if v, _ := r.Version(); v != first {
+		t.Errorf("Expected version %s, got %s", first, v)
+	}
+
+	if err := updateVersion(dep, r, "master"); err != nil {
+		t.Fatalf("Unable to update to a version outside the shallow clone: %s", err)
+	}
+	if isShallow(r) {
+		t.Error("Expected the full history to be fetched")
+	}
+}
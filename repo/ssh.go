@@ -0,0 +1,117 @@
+package repo
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/mirrors"
+	"github.com/Masterminds/glide/msg"
+	v "github.com/Masterminds/vcs"
+	"github.com/mitchellh/go-homedir"
+)
+
+// SSHFallbacks are the SSH logins, such as git@github.com, to fetch Git repos
+// on a host over when fetching them over https fails to authenticate, by host.
+// "off" turns the fallback off for a host.
+var SSHFallbacks = map[string]string{}
+
+// sshHosts are the hosts falling back to SSH by default when SSH is set up.
+var sshHosts = []string{"github.com", "gitlab.com", "bitbucket.org"}
+
+// authMarkers are found in the output of Git when a host refused to serve a
+// repo over https without credentials.
+var authMarkers = []string{
+	"authentication failed",
+	"could not read username",
+	"could not read password",
+	"terminal prompts disabled",
+	"the requested url returned error: 401",
+	"the requested url returned error: 403",
+}
+
+// authFailed returns true when err says fetching a repo failed for lack of
+// credentials.
+func authFailed(err error) bool {
+	if err == nil {
+		return false
+	}
+	out := err.Error()
+	if re, ok := err.(*v.RemoteError); ok {
+		out += " " + re.Out()
+	}
+	out = strings.ToLower(out)
+	for _, m := range authMarkers {
+		if strings.Contains(out, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// sshRemote returns the SSH form, such as git@github.com:foo/bar, of an https
+// remote on a host with an SSH fallback, or an empty string when there is none.
+func sshRemote(remote string) string {
+	u, err := url.Parse(remote)
+	if err != nil || u.Scheme != "https" {
+		return ""
+	}
+	login, ok := SSHFallbacks[u.Host]
+	if !ok {
+		for _, h := range sshHosts {
+			if h == u.Host && sshConfigured() {
+				login = "git@" + h
+			}
+		}
+	}
+	p := strings.Trim(u.Path, "/")
+	if login == "" || login == "off" || p == "" {
+		return ""
+	}
+	return login + ":" + p
+}
+
+// sshConfigured returns true when the user has SSH set up, with an agent
+// running or keys in ~/.ssh.
+func sshConfigured() bool {
+	if os.Getenv("SSH_AUTH_SOCK") != "" {
+		return true
+	}
+	h, err := homedir.Dir()
+	if err != nil {
+		return false
+	}
+	keys, _ := filepath.Glob(filepath.Join(h, ".ssh", "id_*"))
+	return len(keys) > 0
+}
+
+// retrySSH fetches dep over SSH after fetching it over https failed with
+// cause for lack of credentials, when its host has an SSH fallback. The SSH
+// remote is used in place of the https one for the rest of the run without
+// being recorded, so glide.yaml and glide.lock keep working for those fetching
+// over https. cause is returned when the fetch over SSH fails too.
+func retrySSH(dep *cfg.Dependency, cause error) error {
+	if !authFailed(cause) {
+		return cause
+	}
+	if vt := dep.Vcs(); vt != "" && vt != string(v.Git) {
+		return cause
+	}
+	remote := dep.Remote()
+	ssh := sshRemote(remote)
+	if ssh == "" {
+		return cause
+	}
+
+	msg.Warn("--> Unable to authenticate to %s for %s. Trying %s over SSH", RemoteHost(remote), dep.Name, ssh)
+	mirrors.Substitute(remote, ssh)
+	if err := VcsGet(dep); err != nil {
+		msg.Warn("--> Unable to fetch %s over SSH: %s", dep.Name, err)
+		mirrors.Substitute(remote, "")
+		return cause
+	}
+	msg.Info("--> Fetched %s from %s in place of %s", dep.Name, ssh, remote)
+	return nil
+}
@@ -0,0 +1,50 @@
+package repo
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	v "github.com/Masterminds/vcs"
+)
+
+func TestSSHRemote(t *testing.T) {
+	defer func(f map[string]string, sock string) {
+		SSHFallbacks = f
+		os.Setenv("SSH_AUTH_SOCK", sock)
+	}(SSHFallbacks, os.Getenv("SSH_AUTH_SOCK"))
+	os.Setenv("SSH_AUTH_SOCK", "/tmp/agent.sock")
+	SSHFallbacks = map[string]string{
+		"git.example.com": "glide@git.example.com",
+		"gitlab.com":      "off",
+	}
+
+	tests := map[string]string{
+		"https://github.com/foo/bar":         "git@github.com:foo/bar",
+		"https://github.com/foo/bar.git":     "git@github.com:foo/bar.git",
+		"https://git.example.com/team/repo/": "glide@git.example.com:team/repo",
+		"https://gitlab.com/foo/bar":         "",
+		"https://example.org/foo/bar":        "",
+		"http://github.com/foo/bar":          "",
+		"git@github.com:foo/bar":             "",
+		"https://github.com":                 "",
+	}
+	for remote, want := range tests {
+		if got := sshRemote(remote); got != want {
+			t.Errorf("sshRemote(%q) = %q, want %q", remote, got, want)
+		}
+	}
+}
+
+func TestAuthFailed(t *testing.T) {
+	denied := v.NewRemoteError("Unable to get repository", errors.New("exit status 128"), "fatal: could not read Username for 'https://github.com': terminal prompts disabled")
+	if !authFailed(denied) {
+		t.Error("Expected a missing username to be an authentication failure")
+	}
+	if authFailed(v.NewRemoteError("Unable to get repository", errors.New("exit status 128"), "fatal: unable to access: Could not resolve host")) {
+		t.Error("Expected an unknown host not to be an authentication failure")
+	}
+	if authFailed(nil) {
+		t.Error("Expected no error not to be an authentication failure")
+	}
+}
@@ -0,0 +1,109 @@
+package repo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+)
+
+// SubpackagesError is returned when a package is imported from a dependency
+// that only vendors its subpackages but is not in one of them.
+type SubpackagesError struct {
+	Dependency string
+	Package    string
+	Importers  []string
+}
+
+func (e *SubpackagesError) Error() string {
+	return fmt.Sprintf("%s is imported by %s but is not vendored as %s only vendors its subpackages; add %q to its subpackages",
+		e.Package, strings.Join(e.Importers, ", "), e.Dependency, subpackageOf(e.Dependency, e.Package))
+}
+
+// Kind returns msg.KindConfig.
+func (e *SubpackagesError) Kind() msg.Kind {
+	return msg.KindConfig
+}
+
+// keptSubpackages returns the subpackages listed in conf for each dependency
+// vendoring only its subpackages.
+func keptSubpackages(conf *cfg.Config) map[string][]string {
+	kept := make(map[string][]string)
+	for _, d := range append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...) {
+		if d.SubpackagesOnly {
+			kept[d.Name] = append([]string{}, d.Subpackages...)
+		}
+	}
+	return kept
+}
+
+// checkSubpackages returns an error for the first package, in name order,
+// imported from a dependency in kept that is not within the subpackages it
+// vendors. Imports by packages of the dependency that are not vendored either
+// are not counted.
+func checkSubpackages(kept map[string][]string, importers map[string][]string) error {
+	if len(kept) == 0 {
+		return nil
+	}
+	var pkgs []string
+	for pkg := range importers {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	for _, pkg := range pkgs {
+		name, ok := keptDependency(kept, pkg)
+		if !ok || inSubpackages(subpackageOf(name, pkg), kept[name]) {
+			continue
+		}
+		var by []string
+		for _, b := range importers[pkg] {
+			if n, ok := keptDependency(kept, b); ok && n == name && !inSubpackages(subpackageOf(name, b), kept[name]) {
+				continue
+			}
+			by = append(by, b)
+		}
+		if len(by) > 0 {
+			return &SubpackagesError{Dependency: name, Package: pkg, Importers: by}
+		}
+	}
+	return nil
+}
+
+// keptDependency returns the dependency in kept holding pkg.
+func keptDependency(kept map[string][]string, pkg string) (string, bool) {
+	for name := range kept {
+		if pkg == name || strings.HasPrefix(pkg, name+"/") {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// subpackageOf returns the path of pkg within the dependency name, or "." for
+// the package at its root.
+func subpackageOf(name, pkg string) string {
+	if pkg == name {
+		return "."
+	}
+	return strings.TrimPrefix(pkg, name+"/")
+}
+
+// inSubpackages returns true if sub is vendored along with subs: when it is
+// one of them or below one of them other than the root package.
+func inSubpackages(sub string, subs []string) bool {
+	for _, s := range subs {
+		s = strings.Trim(s, "/")
+		if s == "" || s == "." {
+			if sub == "." {
+				return true
+			}
+			continue
+		}
+		if sub == s || strings.HasPrefix(sub, s+"/") {
+			return true
+		}
+	}
+	return false
+}
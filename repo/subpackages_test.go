@@ -0,0 +1,43 @@
+package repo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckSubpackages(t *testing.T) {
+	kept := map[string][]string{
+		"github.com/kubernetes/client-go": {"rest", "tools/cache"},
+		"github.com/foo/bar":              {"."},
+	}
+
+	importers := map[string][]string{
+		"github.com/kubernetes/client-go/rest":              {"example.com/app"},
+		"github.com/kubernetes/client-go/tools/cache/store": {"github.com/kubernetes/client-go/tools/cache"},
+		"github.com/kubernetes/client-go/util/flowcontrol":  {"github.com/kubernetes/client-go/kubernetes"},
+		"github.com/foo/bar":                                {"example.com/app"},
+		"github.com/foo/other":                              {"example.com/app"},
+	}
+	if err := checkSubpackages(kept, importers); err != nil {
+		t.Errorf("Expected the imports to be vendored, got %s", err)
+	}
+
+	importers["github.com/kubernetes/client-go/util/flowcontrol"] = append(importers["github.com/kubernetes/client-go/util/flowcontrol"], "github.com/kubernetes/client-go/rest")
+	err := checkSubpackages(kept, importers)
+	if err == nil {
+		t.Fatal("Expected an error for an import outside the subpackages")
+	}
+	e, ok := err.(*SubpackagesError)
+	if !ok || e.Package != "github.com/kubernetes/client-go/util/flowcontrol" || len(e.Importers) != 1 || e.Importers[0] != "github.com/kubernetes/client-go/rest" {
+		t.Errorf("Unexpected error %#v", err)
+	}
+	if !strings.Contains(err.Error(), `add "util/flowcontrol" to its subpackages`) {
+		t.Errorf("Expected the error to suggest the subpackage, got %s", err)
+	}
+
+	delete(importers, "github.com/kubernetes/client-go/util/flowcontrol")
+	importers["github.com/foo/bar/sub"] = []string{"example.com/app"}
+	if err := checkSubpackages(kept, importers); err == nil {
+		t.Error("Expected an error for a package below the root package")
+	}
+}
@@ -0,0 +1,125 @@
+package repo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+// vendorStage returns the directory to stage a new vendor directory in. It is
+// next to the vendor directory so the new one can be renamed into place
+// rather than copied, unless a temporary directory was set explicitly.
+func vendorStage(vendor string) string {
+	if gpath.Tmp != "" {
+		return gpath.Tmp
+	}
+	return filepath.Dir(vendor)
+}
+
+// vendorBackup returns where the previous vendor directory is kept while a new
+// one is swapped in. The name starts with a dot so it is never scanned for
+// packages.
+func vendorBackup(vendor string) string {
+	return filepath.Join(filepath.Dir(vendor), "."+filepath.Base(vendor)+"-glide-old")
+}
+
+// swapVendor replaces the vendor directory with the one staged at staged. The
+// previous vendor directory is moved aside first and put back when the new one
+// cannot be moved into place, so vendor/ holds either the old or the new tree
+// rather than a mix of both. A vendor/.git directory or file is carried over to
+// the new tree. The previous tree is removed once the swap succeeded.
+func swapVendor(staged, vendor string) error {
+	backup := vendorBackup(vendor)
+	if err := gpath.CustomRemoveAll(backup); err != nil {
+		return err
+	}
+	hadOld := false
+	if _, err := os.Lstat(vendor); err == nil {
+		if err := gpath.CustomRename(vendor, backup); err != nil {
+			return fmt.Errorf("Unable to move the existing vendor directory aside: %s", err)
+		}
+		hadOld = true
+	}
+
+	err := gpath.CustomRename(staged, vendor)
+	if terr, ok := err.(*os.LinkError); ok {
+		err = fixcle(staged, vendor, terr)
+	}
+	if err != nil {
+		msg.Err("Unable to move the new vendor directory into place. Restoring the previous one")
+		if rerr := gpath.CustomRemoveAll(vendor); rerr != nil {
+			msg.Debug("Unable to remove the partial vendor directory: %s", rerr)
+		}
+		if hadOld {
+			if rerr := gpath.CustomRename(backup, vendor); rerr != nil {
+				return fmt.Errorf("%s. The previous vendor directory is kept at %s: %s", err, backup, rerr)
+			}
+		}
+		return err
+	}
+
+	if !hadOld {
+		return nil
+	}
+	preserveVendorGit(filepath.Join(backup, ".git"), filepath.Join(vendor, ".git"))
+	if err := gpath.CustomRemoveAll(backup); err != nil {
+		msg.Warn("Unable to remove the previous vendor directory at %s: %s", backup, err)
+	}
+	return nil
+}
+
+// SwapVendor replaces the vendor directory with the one staged at staged, the
+// way installing does. A swap left unfinished by an earlier run is recovered
+// from first.
+func SwapVendor(staged, vendor string) error {
+	recoverVendor(vendor)
+	return swapVendor(staged, vendor)
+}
+
+// recoverVendor puts back a vendor directory moved aside by a swap that was
+// interrupted before the new one was in place. When the swap got further only
+// the previous tree is removed.
+func recoverVendor(vendor string) {
+	backup := vendorBackup(vendor)
+	if _, err := os.Stat(backup); err != nil {
+		return
+	}
+	if _, err := os.Lstat(vendor); err == nil {
+		msg.Debug("Removing the previous vendor directory left at %s", backup)
+		if err := gpath.CustomRemoveAll(backup); err != nil {
+			msg.Warn("Unable to remove the previous vendor directory at %s: %s", backup, err)
+		}
+		return
+	}
+	msg.Warn("Restoring the vendor directory left at %s by an interrupted install", backup)
+	if err := gpath.CustomRename(backup, vendor); err != nil {
+		msg.Err("Unable to restore the vendor directory: %s", err)
+	}
+}
+
+// preserveVendorGit moves a .git directory or file from the previous vendor
+// directory to the new one. The user is probably submoduling, and it's easy
+// enough not to break their setup.
+func preserveVendorGit(from, to string) {
+	gitInfo, err := os.Stat(from)
+	if err != nil {
+		return
+	}
+	msg.Info("Preserving existing vendor/.git")
+	err = os.Rename(from, to)
+	if terr, ok := err.(*os.LinkError); ok {
+		if gitInfo.IsDir() {
+			err = fixcle(from, to, terr)
+		} else {
+			// When this is a submodule, .git is just a file. Don't try to copy
+			// it as a directory in this case (see #828).
+			err = gpath.CopyFile(from, to)
+		}
+	}
+	if err != nil {
+		msg.Warn("Failed to preserve existing vendor/.git")
+	}
+}
@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSwapVendor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-swap-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	vendor := filepath.Join(dir, "vendor")
+	staged := filepath.Join(dir, ".stage", "vendor")
+	write := func(p, content string) {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(p string) string {
+		b, _ := ioutil.ReadFile(p)
+		return string(b)
+	}
+	write(filepath.Join(vendor, "example.com", "foo", "foo.go"), "old")
+	write(filepath.Join(vendor, ".git"), "gitdir: ../.git/modules/vendor")
+
+	// A failed swap leaves the previous vendor directory in place.
+	if err := swapVendor(filepath.Join(dir, "missing"), vendor); err == nil {
+		t.Error("Expected an error swapping in a missing directory")
+	}
+	if read(filepath.Join(vendor, "example.com", "foo", "foo.go")) != "old" {
+		t.Error("Expected the previous vendor directory to be restored")
+	}
+
+	write(filepath.Join(staged, "example.com", "foo", "foo.go"), "new")
+	if err := swapVendor(staged, vendor); err != nil {
+		t.Fatal(err)
+	}
+	if read(filepath.Join(vendor, "example.com", "foo", "foo.go")) != "new" {
+		t.Error("Expected the new vendor directory in place")
+	}
+	if read(filepath.Join(vendor, ".git")) != "gitdir: ../.git/modules/vendor" {
+		t.Error("Expected vendor/.git to be preserved")
+	}
+	if _, err := os.Stat(vendorBackup(vendor)); !os.IsNotExist(err) {
+		t.Error("Expected the previous vendor directory to be removed")
+	}
+}
+
+func TestRecoverVendor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-swap-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	vendor := filepath.Join(dir, "vendor")
+	backup := vendorBackup(vendor)
+	if err := os.MkdirAll(filepath.Join(backup, "example.com"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Interrupted before the new vendor directory was in place.
+	recoverVendor(vendor)
+	if _, err := os.Stat(filepath.Join(vendor, "example.com")); err != nil {
+		t.Errorf("Expected the vendor directory to be restored: %s", err)
+	}
+
+	// Interrupted while removing the previous vendor directory.
+	if err := os.MkdirAll(backup, 0755); err != nil {
+		t.Fatal(err)
+	}
+	recoverVendor(vendor)
+	if _, err := os.Stat(backup); !os.IsNotExist(err) {
+		t.Error("Expected the previous vendor directory to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(vendor, "example.com")); err != nil {
+		t.Errorf("Expected the vendor directory to be kept: %s", err)
+	}
+}
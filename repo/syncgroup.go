@@ -0,0 +1,225 @@
+package repo
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	cp "github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/hooks"
+	"github.com/Masterminds/glide/msg"
+	"github.com/Masterminds/semver"
+)
+
+// SyncGroupError is returned when the dependencies of a sync group have no
+// version in common that all of them allow.
+type SyncGroupError struct {
+	Group  string
+	Reason string
+}
+
+func (e *SyncGroupError) Error() string {
+	return fmt.Sprintf("Unable to find a version of the sync group %s for all of its packages: %s", e.Group, e.Reason)
+}
+
+// Kind returns msg.KindConflict.
+func (e *SyncGroupError) Kind() msg.Kind {
+	return msg.KindConflict
+}
+
+// alignSyncGroups sets the dependencies of each sync group of conf to the
+// newest release all of them have and allow, once they were fetched and set to
+// versions of their own. Members that are not dependencies of the project are
+// left out, as are local copies.
+func alignSyncGroups(conf *cfg.Config) error {
+	for _, g := range conf.SyncGroups {
+		var members cfg.Dependencies
+		for _, name := range g.Packages {
+			d := conf.Imports.Get(name)
+			if d == nil {
+				d = conf.DevImports.Get(name)
+			}
+			if d == nil {
+				continue
+			}
+			if d.Local != "" {
+				msg.Warn("--> Using the local copy of %s as it is rather than aligning it with the sync group %s", d.Name, g.Name)
+				continue
+			}
+			members = append(members, d)
+		}
+		if len(members) < 2 {
+			continue
+		}
+
+		allowed := make(map[string][]*semver.Version, len(members))
+		for _, d := range members {
+			versions, err := syncVersions(d)
+			if err != nil {
+				return &SyncGroupError{Group: g.Name, Reason: err.Error()}
+			}
+			allowed[d.Name] = versions
+		}
+		ver, err := commonVersion(members, allowed)
+		if err != nil {
+			return &SyncGroupError{Group: g.Name, Reason: err.Error()}
+		}
+
+		msg.Info("--> Aligning the sync group %s to %s", g.Name, ver)
+		for _, d := range members {
+			if err := setSyncVersion(d, allowed[d.Name], ver); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// syncVersions returns the releases of a fetched dependency its version allows,
+// from the highest to the lowest. A dependency set to a branch or commit rather
+// than a release cannot be aligned.
+func syncVersions(dep *cfg.Dependency) ([]*semver.Version, error) {
+	if dep.AsOf != "" {
+		return nil, fmt.Errorf("%s is pinned to a date rather than a release", dep.Name)
+	}
+	key, err := cp.Key(dep.Remote())
+	if err != nil {
+		return nil, err
+	}
+	repo, err := dep.GetRepo(filepath.Join(cp.Location(), "src", key))
+	if err != nil {
+		return nil, err
+	}
+	tags, err := repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+	tags, err = hooks.FilterVersions(dep.Name, dep.Remote(), tags)
+	if err != nil {
+		return nil, err
+	}
+	versions := getSemVers(prefixedRefs(dep, tags))
+	sort.Sort(byPrecedence(versions))
+
+	ref := dep.Reference
+	if major, ok := MajorLimits[dep.Name]; ok && ref == "" {
+		ref = majorRange(major)
+	}
+	if ref == "" {
+		var releases []*semver.Version
+		for _, v := range versions {
+			if !dep.Excludes(v.Original()) && (v.Prerelease() == "" || Prerelease || dep.Prerelease) {
+				releases = append(releases, v)
+			}
+		}
+		return releases, nil
+	}
+
+	// A tag allows only the release it names. Other references are branches
+	// or commits.
+	if repo.IsReference(ref) && !strings.HasPrefix(ref, "^") {
+		for _, t := range tags {
+			if t != ref {
+				continue
+			}
+			if v, ok := dep.TagVersion(t); ok {
+				if sv, err := semver.NewVersion(v); err == nil {
+					return []*semver.Version{sv}, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("%s is set to %s, which is not a release", dep.Name, ref)
+	}
+
+	constraint, err := cfg.NewConstraint(ref)
+	if err != nil {
+		return nil, err
+	}
+	pre := Prerelease || dep.Prerelease
+	var matching []*semver.Version
+	for _, v := range versions {
+		if dep.Excludes(v.Original()) {
+			continue
+		}
+		if constraint.Check(v) || (pre && v.Prerelease() != "" && constraint.Check(releaseOf(v))) {
+			matching = append(matching, v)
+		}
+	}
+	return matching, nil
+}
+
+// commonVersion returns the highest version all of the members allow, given
+// the versions each allows from the highest to the lowest. Versions are
+// compared without their tag prefix and leading v. The error tells which
+// versions each allows when they have none in common.
+func commonVersion(members cfg.Dependencies, allowed map[string][]*semver.Version) (string, error) {
+	count := make(map[string]int)
+	for _, d := range members {
+		seen := make(map[string]bool)
+		for _, v := range allowed[d.Name] {
+			if !seen[v.String()] {
+				seen[v.String()] = true
+				count[v.String()]++
+			}
+		}
+	}
+	for _, v := range allowed[members[0].Name] {
+		if count[v.String()] == len(members) {
+			return v.String(), nil
+		}
+	}
+
+	var reasons []string
+	for _, d := range members {
+		reasons = append(reasons, fmt.Sprintf("%s allows %s", d.Name, describeVersions(allowed[d.Name])))
+	}
+	return "", fmt.Errorf("%s", strings.Join(reasons, "; "))
+}
+
+// describeVersions lists the highest few of versions.
+func describeVersions(versions []*semver.Version) string {
+	if len(versions) == 0 {
+		return "no release"
+	}
+	var names []string
+	for i, v := range versions {
+		if i == 3 {
+			names = append(names, fmt.Sprintf("and %d more", len(versions)-i))
+			break
+		}
+		names = append(names, v.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// setSyncVersion checks out the tag of a dependency naming the version of its
+// sync group, unless it is already pinned to the commit the tag points at.
+func setSyncVersion(dep *cfg.Dependency, versions []*semver.Version, ver string) error {
+	var tag string
+	for _, v := range versions {
+		if v.String() == ver {
+			tag = dep.TagPrefix + v.Original()
+			break
+		}
+	}
+
+	key, err := cp.Key(dep.Remote())
+	if err != nil {
+		return err
+	}
+	repo, err := dep.GetRepo(filepath.Join(cp.Location(), "src", key))
+	if err != nil {
+		return err
+	}
+	if ci, err := repo.CommitInfo(tag); err == nil && ci.Commit == dep.Pin {
+		return nil
+	}
+
+	ref := dep.Reference
+	dep.Reference, dep.Pin = tag, ""
+	err = VcsVersion(dep)
+	dep.Reference = ref
+	return err
+}
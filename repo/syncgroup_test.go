@@ -0,0 +1,45 @@
+package repo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/semver"
+)
+
+func TestCommonVersion(t *testing.T) {
+	versions := func(vs ...string) []*semver.Version {
+		return getSemVers(vs)
+	}
+	members := cfg.Dependencies{
+		&cfg.Dependency{Name: "github.com/opentracing/opentracing-go"},
+		&cfg.Dependency{Name: "github.com/opentracing/basictracer-go"},
+	}
+
+	allowed := map[string][]*semver.Version{
+		"github.com/opentracing/opentracing-go": versions("v1.3.0", "v1.2.0", "v1.1.0"),
+		"github.com/opentracing/basictracer-go": versions("1.2.0", "1.1.0"),
+	}
+	ver, err := commonVersion(members, allowed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ver != "1.2.0" {
+		t.Errorf("Expected the common version 1.2.0, got %s", ver)
+	}
+
+	allowed["github.com/opentracing/basictracer-go"] = versions("v1.5.0", "v1.4.0", "v1.3.1", "v1.3.0-rc.1")
+	_, err = commonVersion(members, allowed)
+	if err == nil {
+		t.Fatal("Expected an error when there is no common version")
+	}
+	for _, s := range []string{
+		"github.com/opentracing/opentracing-go allows 1.3.0, 1.2.0, 1.1.0",
+		"github.com/opentracing/basictracer-go allows 1.5.0, 1.4.0, 1.3.1, and 1 more",
+	} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("Expected %q to contain %q", err, s)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package repo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/msg"
+	gpath "github.com/Masterminds/glide/path"
+	"github.com/Masterminds/glide/stats"
+)
+
+// FetchTimeout is the longest a single fetch (clone or update) of a
+// repository can take. A zero value means there is no limit.
+var FetchTimeout time.Duration
+
+// ExportTimeout is the longest exporting a single dependency to the vendor
+// directory can take. A zero value means there is no limit.
+var ExportTimeout time.Duration
+
+// ResolveTimeout is the longest resolving the complete dependency tree can
+// take. A zero value means there is no limit.
+var ResolveTimeout time.Duration
+
+// TimeoutSettings are the timeouts in the config.yaml file in GLIDE_HOME:
+//
+//     timeouts:
+//       command: 30m
+//       resolve: 10m
+//       fetch: 5m
+//       fetch-attempt: 2m
+//       export: 1m
+type TimeoutSettings struct {
+	// Command limits the whole command.
+	Command string `yaml:"command,omitempty"`
+
+	// Resolve limits resolving the dependency tree.
+	Resolve string `yaml:"resolve,omitempty"`
+
+	// Fetch limits the fetch of a single repository, including the attempts
+	// made again.
+	Fetch string `yaml:"fetch,omitempty"`
+
+	// FetchAttempt limits each attempt to fetch a repository.
+	FetchAttempt string `yaml:"fetch-attempt,omitempty"`
+
+	// Export limits exporting a single dependency to the vendor directory.
+	Export string `yaml:"export,omitempty"`
+}
+
+// LoadTimeouts sets ResolveTimeout, FetchTimeout, NetworkTimeout, and
+// ExportTimeout from the timeouts in config.yaml. It returns the timeout for
+// the whole command. The timeout in the network settings is used for each
+// attempt to fetch a repository when fetch-attempt is not set.
+func LoadTimeouts() (time.Duration, error) {
+	t := &TimeoutSettings{}
+	if err := gpath.GlobalConfig("timeouts", t); err != nil {
+		return 0, err
+	}
+	if t.FetchAttempt == "" {
+		n := &NetworkSettings{}
+		if err := gpath.GlobalConfig("network", n); err != nil {
+			return 0, err
+		}
+		if n.Timeout != "" {
+			msg.Warn("The network timeout in config.yaml is deprecated. Use fetch-attempt in the timeouts instead")
+			t.FetchAttempt = n.Timeout
+		}
+	}
+
+	var command time.Duration
+	for _, s := range []struct {
+		name  string
+		value string
+		d     *time.Duration
+	}{
+		{"command", t.Command, &command},
+		{"resolve", t.Resolve, &ResolveTimeout},
+		{"fetch", t.Fetch, &FetchTimeout},
+		{"fetch-attempt", t.FetchAttempt, &NetworkTimeout},
+		{"export", t.Export, &ExportTimeout},
+	} {
+		if s.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(s.value)
+		if err != nil {
+			return 0, fmt.Errorf("Invalid %s timeout %q in config.yaml: %s", s.name, s.value, err)
+		}
+		*s.d = d
+	}
+	return command, nil
+}
+
+// inFlight tracks the repositories that have an operation in progress so they
+// can be reported when an operation times out.
+var inFlight = NewUpdateTracker()
+
+// Pending returns the names of the dependencies with an operation currently
+// in progress, sorted by name.
+func Pending() []string {
+	return inFlight.List()
+}
+
+// TimeoutError is returned when an operation takes longer than allowed.
+type TimeoutError struct {
+	// Op is the operation that timed out (e.g., fetch or export).
+	Op string
+
+	// Name is the dependency being worked on, if any.
+	Name string
+
+	// Timeout is the limit that was exceeded.
+	Timeout time.Duration
+
+	// Pending lists the dependencies still being worked on at the time.
+	Pending []string
+
+	// done is closed once the operation, which keeps running in the
+	// background, returns.
+	done chan struct{}
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Name != "" {
+		return fmt.Sprintf("%s of %s timed out after %s", e.Op, e.Name, e.Timeout)
+	}
+	return fmt.Sprintf("%s timed out after %s (pending: %v)", e.Op, e.Timeout, e.Pending)
+}
+
+// Kind returns msg.KindNetwork as operations time out waiting on remotes.
+func (e *TimeoutError) Kind() msg.Kind {
+	return msg.KindNetwork
+}
+
+// withTimeout runs fn, returning a TimeoutError if it takes longer than d.
+// When d is zero fn runs without a limit. While fn is running name is listed
+// as pending. The time fn takes is recorded as the op phase of name.
+//
+// Note, the underlying VCS commands cannot be canceled. When a timeout
+// occurs fn keeps running in the background and its result is discarded. Use
+// unlockWhenDone to release the cache lock fn runs under.
+func withTimeout(op, name string, d time.Duration, fn func() error) error {
+	defer stats.Time(op, name)()
+	if name != "" {
+		inFlight.Add(name)
+		defer inFlight.Remove(name)
+	}
+	return within(op, name, d, fn)
+}
+
+// within runs fn, returning a TimeoutError if it takes longer than d. When d
+// is zero fn runs without a limit.
+func within(op, name string, d time.Duration, fn func() error) error {
+	if d <= 0 {
+		return fn()
+	}
+
+	ch := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		err := fn()
+		ch <- err
+		// fn may have given up on an operation of its own that is still
+		// running.
+		if terr, ok := err.(*TimeoutError); ok && terr.done != nil {
+			<-terr.done
+		}
+		close(done)
+	}()
+
+	select {
+	case err := <-ch:
+		return err
+	case <-time.After(d):
+		return &TimeoutError{
+			Op:      op,
+			Name:    name,
+			Timeout: d,
+			Pending: Pending(),
+			done:    done,
+		}
+	}
+}
+
+// unlockWhenDone releases the cache lock on key held for an operation that
+// returned err. An operation that timed out keeps running in the background
+// and the lock is only released once it returns, so the cached repo is not
+// worked on by two operations at once.
+func unlockWhenDone(key string, err error) {
+	if terr, ok := err.(*TimeoutError); ok && terr.done != nil {
+		go func() {
+			<-terr.done
+			cache.Unlock(key)
+		}()
+		return
+	}
+	cache.Unlock(key)
+}
@@ -0,0 +1,122 @@
+package repo
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/glide/cache"
+	gpath "github.com/Masterminds/glide/path"
+)
+
+func TestWithTimeout(t *testing.T) {
+	e := errors.New("foo")
+	err := withTimeout("fetch", "github.com/Masterminds/vcs", 0, func() error {
+		return e
+	})
+	if err != e {
+		t.Errorf("Expected the error from the operation, got %v", err)
+	}
+
+	block := make(chan struct{})
+	defer close(block)
+	err = withTimeout("fetch", "github.com/Masterminds/semver", 10*time.Millisecond, func() error {
+		<-block
+		return nil
+	})
+	terr, ok := err.(*TimeoutError)
+	if !ok {
+		t.Fatalf("Expected a timeout error, got %v", err)
+	}
+	if terr.Name != "github.com/Masterminds/semver" || terr.Op != "fetch" {
+		t.Errorf("Unexpected timeout error details %+v", terr)
+	}
+	if len(terr.Pending) != 1 || terr.Pending[0] != "github.com/Masterminds/semver" {
+		t.Errorf("Expected github.com/Masterminds/semver to be pending, got %v", terr.Pending)
+	}
+	if len(Pending()) != 0 {
+		t.Errorf("Expected nothing to be pending after the timeout, got %v", Pending())
+	}
+}
+
+func TestUnlockWhenDone(t *testing.T) {
+	key := "glide-timeout-test"
+	cache.Lock(key)
+	block := make(chan struct{})
+	err := withTimeout("fetch", "github.com/Masterminds/semver", 10*time.Millisecond, func() error {
+		return within("fetch", "github.com/Masterminds/semver", time.Millisecond, func() error {
+			<-block
+			return nil
+		})
+	})
+	if _, ok := err.(*TimeoutError); !ok {
+		t.Fatalf("Expected a timeout error, got %v", err)
+	}
+	unlockWhenDone(key, err)
+
+	locked := make(chan struct{})
+	go func() {
+		cache.Lock(key)
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		t.Fatal("The lock was released while the operation was still running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(block)
+	select {
+	case <-locked:
+		cache.Unlock(key)
+	case <-time.After(time.Second):
+		t.Error("The lock was not released once the operation returned")
+	}
+}
+
+func TestLoadTimeouts(t *testing.T) {
+	home, err := ioutil.TempDir("", "glide-timeouts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer gpath.SetHome(gpath.Home())
+	gpath.SetHome(home)
+	defer func(r, f, n, e time.Duration) {
+		ResolveTimeout, FetchTimeout, NetworkTimeout, ExportTimeout = r, f, n, e
+	}(ResolveTimeout, FetchTimeout, NetworkTimeout, ExportTimeout)
+
+	p := filepath.Join(home, "config.yaml")
+	yml := "timeouts:\n  command: 30m\n  resolve: 10m\n  fetch: 5m\n  fetch-attempt: 2m\n  export: 1m\nnetwork:\n  timeout: 90s\n"
+	if err := ioutil.WriteFile(p, []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	d, err := LoadTimeouts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d != 30*time.Minute || ResolveTimeout != 10*time.Minute || FetchTimeout != 5*time.Minute || NetworkTimeout != 2*time.Minute || ExportTimeout != time.Minute {
+		t.Errorf("Unexpected timeouts %s, %s, %s, %s, %s", d, ResolveTimeout, FetchTimeout, NetworkTimeout, ExportTimeout)
+	}
+
+	// The former network timeout is used for each attempt without
+	// fetch-attempt.
+	if err := ioutil.WriteFile(p, []byte("network:\n  timeout: 90s\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTimeouts(); err != nil {
+		t.Fatal(err)
+	}
+	if NetworkTimeout != 90*time.Second {
+		t.Errorf("Expected the network timeout to be used, got %s", NetworkTimeout)
+	}
+
+	if err := ioutil.WriteFile(p, []byte("timeouts:\n  resolve: soon\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTimeouts(); err == nil {
+		t.Error("Expected an invalid timeout to be an error")
+	}
+}
@@ -0,0 +1,57 @@
+package repo
+
+import (
+	"path/filepath"
+	"time"
+
+	cp "github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/msg"
+)
+
+// applyTrack moves dependencies installed from a lock file to the tip of the
+// branch they track when the locked commit is older than their maxAge. The
+// commit date is read from the cache so dependencies not in it yet are
+// skipped. Those are checked out fresh and can be checked again afterwards.
+func applyTrack(deps cfg.Dependencies, conf *cfg.Config) {
+	for _, d := range deps {
+		c := conf.Imports.Get(d.Name)
+		if c == nil {
+			c = conf.DevImports.Get(d.Name)
+		}
+		if c == nil || !c.Track || c.MaxAge <= 0 || d.Track {
+			continue
+		}
+
+		key, err := cp.Key(d.Remote())
+		if err != nil {
+			continue
+		}
+		repo, err := d.GetRepo(filepath.Join(cp.Location(), "src", key))
+		if err != nil {
+			continue
+		}
+		ci, err := repo.CommitInfo(d.Reference)
+		if err != nil {
+			continue
+		}
+
+		if !pinTooOld(ci.Date, c.MaxAge, time.Now()) {
+			continue
+		}
+		branch := c.Reference
+		if branch == "" {
+			branch = defaultBranch(repo)
+		}
+		msg.Warn("--> The locked version of %s is more than %d days old. Updating it to the tip of %s", d.Name, c.MaxAge, branch)
+		d.Reference = branch
+		d.Track = true
+		d.MaxAge = c.MaxAge
+	}
+}
+
+// pinTooOld returns true if a commit made at date is more than maxAge days
+// older than now.
+func pinTooOld(date time.Time, maxAge int, now time.Time) bool {
+	return now.Sub(date) > time.Duration(maxAge)*24*time.Hour
+}
@@ -0,0 +1,25 @@
+package repo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPinTooOld(t *testing.T) {
+	now := time.Date(2017, 3, 20, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		date   time.Time
+		maxAge int
+		old    bool
+	}{
+		{now.Add(-time.Hour), 1, false},
+		{now.Add(-25 * time.Hour), 1, true},
+		{now.AddDate(0, 0, -6), 7, false},
+		{now.AddDate(0, 0, -8), 7, true},
+	}
+	for _, tt := range tests {
+		if got := pinTooOld(tt.date, tt.maxAge, now); got != tt.old {
+			t.Errorf("Expected a commit from %s to be too old for %d days to be %t", tt.date, tt.maxAge, tt.old)
+		}
+	}
+}
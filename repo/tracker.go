@@ -1,6 +1,7 @@
 package repo
 
 import (
+	"sort"
 	"sync"
 )
 
@@ -40,3 +41,15 @@ func (u *UpdateTracker) Remove(name string) {
 	delete(u.updated, name)
 	u.Unlock()
 }
+
+// List returns the names of all the items being tracked, sorted by name.
+func (u *UpdateTracker) List() []string {
+	u.RLock()
+	l := make([]string, 0, len(u.updated))
+	for k := range u.updated {
+		l = append(l, k)
+	}
+	u.RUnlock()
+	sort.Strings(l)
+	return l
+}
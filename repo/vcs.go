@@ -1,22 +1,21 @@
 package repo
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
-	"runtime"
-	"sort"
 	"strings"
 
+	"github.com/Masterminds/glide/archive"
 	cp "github.com/Masterminds/glide/cache"
 	"github.com/Masterminds/glide/cfg"
+	"github.com/Masterminds/glide/darcs"
+	"github.com/Masterminds/glide/fossil"
+	"github.com/Masterminds/glide/hooks"
+	"github.com/Masterminds/glide/hosting"
 	"github.com/Masterminds/glide/msg"
 	gpath "github.com/Masterminds/glide/path"
-	"github.com/Masterminds/semver"
+	"github.com/Masterminds/glide/stats"
 	v "github.com/Masterminds/vcs"
 )
 
@@ -35,15 +34,17 @@ func VcsUpdate(dep *cfg.Dependency, force bool, updated *UpdateTracker) error {
 		return nil
 	}
 	updated.Add(dep.Name)
+	msg.SetState(dep.Name, msg.Fetching)
+	defer msg.SetState(dep.Name, "")
 
-	if filterArchOs(dep) {
-		msg.Info("%s is not used for %s/%s.\n", dep.Name, runtime.GOOS, runtime.GOARCH)
-		return nil
+	if dep.Local != "" {
+		msg.Info("--> Using local copy of %s from %s", dep.Name, dep.Local)
+		return checkLocal(dep)
 	}
 
 	key, err := cp.Key(dep.Remote())
 	if err != nil {
-		msg.Die("Cache key generation error: %s", err)
+		return fmt.Errorf("Cache key generation error: %s", err)
 	}
 	location := cp.Location()
 	dest := filepath.Join(location, "src", key)
@@ -52,6 +53,15 @@ func VcsUpdate(dep *cfg.Dependency, force bool, updated *UpdateTracker) error {
 	if _, err := os.Stat(dest); os.IsNotExist(err) {
 		msg.Info("--> Fetching %s", dep.Name)
 		if err = VcsGet(dep); err != nil {
+			if err = retrySSH(dep, err); err == nil {
+				return nil
+			}
+			if err = followRedirect(dep, err); err == nil {
+				return nil
+			}
+			if err = getFallback(dep, err); err == nil {
+				return nil
+			}
 			msg.Warn("Unable to checkout %s\n", dep.Name)
 			return err
 		}
@@ -65,7 +75,7 @@ func VcsUpdate(dep *cfg.Dependency, force bool, updated *UpdateTracker) error {
 		if err != nil {
 			return err
 		}
-		_, err = v.DetectVcsFromFS(dest)
+		_, err = DetectVcs(dest)
 		if empty == true && err == v.ErrCannotDetectVCS {
 			msg.Warn("Cached version of %s is an empty directory. Fetching a new copy of the dependency", dep.Name)
 			msg.Debug("Removing empty directory %s", dest)
@@ -102,6 +112,9 @@ func VcsUpdate(dep *cfg.Dependency, force bool, updated *UpdateTracker) error {
 				if err != nil {
 					return err
 				}
+			} else if err == v.ErrWrongRemote {
+				// The import path may resolve to a repo that moved.
+				return followRedirect(dep, err)
 			} else if err != nil {
 				return err
 			} else if repo.IsDirty() {
@@ -136,6 +149,15 @@ func VcsUpdate(dep *cfg.Dependency, force bool, updated *UpdateTracker) error {
 			}
 
 			if err := repo.Update(); err != nil {
+				if err = retrySSH(dep, err); err == nil {
+					return nil
+				}
+				if err = followRedirect(dep, err); err == nil {
+					return nil
+				}
+				if err = getFallback(dep, err); err == nil {
+					return nil
+				}
 				msg.Warn("Download failed.\n")
 				return err
 			}
@@ -154,16 +176,47 @@ func VcsVersion(dep *cfg.Dependency) error {
 		msg.Debug("Dependency %s has already been pinned. Setting version skipped", dep.Name)
 		return nil
 	}
+	msg.SetState(dep.Name, msg.CheckingOut)
+	defer msg.SetState(dep.Name, "")
+	defer stats.Time(stats.Checkout, dep.Name)()
+
+	// Local copies are used as they are. The version checked out there, if
+	// any, is recorded.
+	if dep.Local != "" {
+		dep.Pin = localVersion(dep)
+		if dep.Pin == "" {
+			msg.Warn("--> Unable to find the version of the local copy of %s. It is not locked", dep.Name)
+		}
+		return nil
+	}
 
 	key, err := cp.Key(dep.Remote())
 	if err != nil {
-		msg.Die("Cache key generation error: %s", err)
+		return fmt.Errorf("Cache key generation error: %s", err)
 	}
 	location := cp.Location()
 	cwd := filepath.Join(location, "src", key)
 
+	// A date pins the dependency to the last commit of its branch before it.
+	// Otherwise, without a reference, the newest release of the major version
+	// the dependency is limited to is used, if any.
+	ref := dep.Reference
+	if dep.AsOf != "" {
+		repo, err := dep.GetRepo(cwd)
+		if err != nil {
+			return err
+		}
+		if ref, err = asOfCommit(dep, repo); err != nil {
+			return err
+		}
+		msg.Info("--> Using the last commit of %s before %s", dep.Name, dep.AsOf)
+	} else if major, ok := MajorLimits[dep.Name]; ok && ref == "" {
+		ref = majorRange(major)
+		msg.Info("--> Limiting %s to major version %d", dep.Name, major)
+	}
+
 	// If there is no reference configured there is nothing to set.
-	if dep.Reference == "" {
+	if ref == "" {
 		// Before exiting update the pinned version
 		repo, err := dep.GetRepo(cwd)
 		if err != nil {
@@ -173,7 +226,10 @@ func VcsVersion(dep *cfg.Dependency) error {
 		if err != nil {
 			return err
 		}
-		return nil
+		if err := hooks.Veto(dep.Name, dep.Remote(), "", dep.Pin); err != nil {
+			return err
+		}
+		return updateSubmodules(dep, repo)
 	}
 
 	// When the directory is not empty and has no VCS directory it's
@@ -182,8 +238,8 @@ func VcsVersion(dep *cfg.Dependency) error {
 	if err != nil {
 		return err
 	}
-	_, err = v.DetectVcsFromFS(cwd)
-	if empty == false && err == v.ErrCannotDetectVCS {
+	_, err = DetectVcs(cwd)
+	if empty == false && err == v.ErrCannotDetectVCS && !dep.IsArchive() {
 		return fmt.Errorf("Cache directory missing VCS information for %s", dep.Name)
 	}
 
@@ -192,17 +248,26 @@ func VcsVersion(dep *cfg.Dependency) error {
 		return err
 	}
 
-	ver := dep.Reference
+	ver := ref
+	if dep.Track {
+		if ib, err := isBranch(ver, repo); err == nil && !ib {
+			msg.Warn("--> %s tracks %s which is not a branch. It is used as a regular version", dep.Name, ver)
+		}
+	}
+
 	// References in Git can begin with a ^ which is similar to semver.
 	// If there is a ^ prefix we assume it's a semver constraint rather than
 	// part of the git/VCS commit id.
 	if repo.IsReference(ver) && !strings.HasPrefix(ver, "^") {
 		msg.Info("--> Setting version for %s to %s.\n", dep.Name, ver)
+		if dep.Excludes(ver) {
+			msg.Warn("--> Version %s of %s is excluded but is set explicitly. Using it anyway", ver, dep.Name)
+		}
 	} else {
 
 		// Create the constraint first to make sure it's valid before
 		// working on the repo.
-		constraint, err := semver.NewConstraint(ver)
+		constraint, err := cfg.NewConstraint(ver)
 
 		// Make sure the constriant is valid. At this point it's not a valid
 		// reference so if it's not a valid constrint we can exit early.
@@ -211,40 +276,106 @@ func VcsVersion(dep *cfg.Dependency) error {
 			return err
 		}
 
+		// A shallow clone lacks the tags needed to find a matching version.
+		if isShallow(repo) {
+			msg.Info("--> Fetching the full history of %s to resolve %s", dep.Name, ver)
+			if err := unshallow(repo); err != nil {
+				return err
+			}
+		}
+
 		// Get the tags and branches (in that order)
 		refs, err := getAllVcsRefs(repo)
 		if err != nil {
 			return err
 		}
+		refs, err = hooks.FilterVersions(dep.Name, dep.Remote(), refs)
+		if err != nil {
+			return err
+		}
 
 		// Convert and filter the list to semver.Version instances
-		semvers := getSemVers(refs)
+		semvers := getSemVers(prefixedRefs(dep, refs))
 
-		// Sort semver list
-		sort.Sort(sort.Reverse(semver.Collection(semvers)))
+		// Pick the highest version passing the constraint and get its
+		// original reference.
 		found := false
-		for _, v := range semvers {
-			if constraint.Check(v) {
-				found = true
-				// If the constrint passes get the original reference
-				ver = v.Original()
-				break
-			}
+		if v := matchVersion(dep, constraint, semvers); v != nil {
+			found = true
+			ver = dep.TagPrefix + v.Original()
 		}
-		if found {
-			msg.Info("--> Detected semantic version. Setting version for %s to %s", dep.Name, ver)
-		} else {
-			msg.Warn("--> Unable to find semantic version for constraint %s %s", dep.Name, ver)
+		if !found {
+			return &ConstraintError{Name: dep.Name, Constraint: ver}
 		}
+		msg.Info("--> Detected semantic version. Setting version for %s to %s", dep.Name, ver)
 	}
-	if err := repo.UpdateVersion(ver); err != nil {
+	if err := updateVersion(dep, repo, ver); err != nil {
 		return err
 	}
 	dep.Pin, err = repo.Version()
 	if err != nil {
 		return err
 	}
+	if err := checkKnownTag(dep, repo, ver); err != nil {
+		return err
+	}
+	if err := hooks.Veto(dep.Name, dep.Remote(), ver, dep.Pin); err != nil {
+		return err
+	}
 
+	return updateSubmodules(dep, repo)
+}
+
+// checkKnownTag makes sure the tag ver, if it is one, points at the commit it
+// pointed at when Glide first saw it in any project. Branches and commit ids
+// are not checked as branches move and commit ids cannot.
+func checkKnownTag(dep *cfg.Dependency, repo v.Repo, ver string) error {
+	tags, err := repo.Tags()
+	if err != nil {
+		msg.Debug("Unable to list the tags of %s: %s", dep.Name, err)
+		return nil
+	}
+	for _, t := range tags {
+		if t == ver {
+			return cp.CheckKnown(dep.Name, dep.Remote(), ver, dep.Pin)
+		}
+	}
+	return nil
+}
+
+// updateSubmodules initializes and updates the Git submodules of a checkout
+// when the dependency asks for them.
+func updateSubmodules(dep *cfg.Dependency, repo v.Repo) error {
+	if !dep.Submodules {
+		return nil
+	}
+	if repo.Vcs() != v.Git {
+		msg.Warn("--> Submodules are only supported for Git. Skipping them for %s", dep.Name)
+		return nil
+	}
+
+	msg.Info("--> Updating submodules for %s", dep.Name)
+	out, err := repo.RunFromDir("git", "submodule", "update", "--init", "--recursive")
+	msg.Output("git", out)
+	if err != nil {
+		return fmt.Errorf("Unable to update submodules for %s: %s (%s)", dep.Name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// rewriteRepos asks the resolver hooks where deps are fetched from. It is done
+// up front, before deps are handed to workers, so looking up the repo of a
+// dependency never starts a hook program.
+func rewriteRepos(deps ...*cfg.Dependency) error {
+	for _, dep := range deps {
+		if dep.Local != "" {
+			continue
+		}
+		r, vcsType := dep.Origin()
+		if _, _, err := hooks.RewriteRepo(dep.Name, r, vcsType); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -255,7 +386,7 @@ func VcsGet(dep *cfg.Dependency) error {
 
 	key, err := cp.Key(dep.Remote())
 	if err != nil {
-		msg.Die("Cache key generation error: %s", err)
+		return fmt.Errorf("Cache key generation error: %s", err)
 	}
 	location := cp.Location()
 	d := filepath.Join(location, "src", key)
@@ -267,11 +398,29 @@ func VcsGet(dep *cfg.Dependency) error {
 	// If the directory does not exist this is a first cache.
 	if _, err = os.Stat(d); os.IsNotExist(err) {
 		msg.Debug("Adding %s to the cache for the first time", dep.Name)
-		err = repo.Get()
-		if err != nil {
-			return err
+		shallow := false
+		if ref := shallowRef(dep, repo); ref != "" {
+			msg.Debug("Shallow cloning %s at %s", dep.Name, ref)
+			err = getShallow(dep, repo, ref)
+			if err == nil {
+				shallow = true
+			} else {
+				msg.Debug("Unable to shallow clone %s, falling back to a full clone: %s", dep.Name, err)
+				os.RemoveAll(d)
+			}
+		}
+		if !shallow {
+			err = repo.Get()
+			if err != nil {
+				return err
+			}
+		}
+
+		// The checked out branch of a shallow clone is not the default one.
+		branch := ""
+		if !shallow {
+			branch = findCurrentBranch(repo)
 		}
-		branch := findCurrentBranch(repo)
 		if branch != "" {
 			msg.Debug("Saving default branch for %s", repo.Remote())
 			c := cp.RepoInfo{DefaultBranch: branch}
@@ -293,40 +442,6 @@ func VcsGet(dep *cfg.Dependency) error {
 	return nil
 }
 
-// filterArchOs indicates a dependency should be filtered out because it is
-// the wrong GOOS or GOARCH.
-//
-// FIXME: Should this be moved to the dependency package?
-func filterArchOs(dep *cfg.Dependency) bool {
-	found := false
-	if len(dep.Arch) > 0 {
-		for _, a := range dep.Arch {
-			if a == runtime.GOARCH {
-				found = true
-			}
-		}
-		// If it's not found, it should be filtered out.
-		if !found {
-			return true
-		}
-	}
-
-	found = false
-	if len(dep.Os) > 0 {
-		for _, o := range dep.Os {
-			if o == runtime.GOOS {
-				found = true
-			}
-		}
-		if !found {
-			return true
-		}
-
-	}
-
-	return false
-}
-
 // isBranch returns true if the given string is a branch in VCS.
 func isBranch(branch string, repo v.Repo) (bool, error) {
 	branches, err := repo.Branches()
@@ -347,8 +462,9 @@ func isBranch(branch string, repo v.Repo) (bool, error) {
 func defaultBranch(repo v.Repo) string {
 
 	// Svn and Bzr use different locations (paths or entire locations)
-	// for branches so we won't have a default branch.
-	if repo.Vcs() == v.Svn || repo.Vcs() == v.Bzr {
+	// for branches so we won't have a default branch. Archives and Darcs
+	// have no branches at all.
+	if repo.Vcs() == v.Svn || repo.Vcs() == v.Bzr || repo.Vcs() == archive.Type || repo.Vcs() == darcs.Type {
 		return ""
 	}
 
@@ -364,91 +480,24 @@ func defaultBranch(repo v.Repo) string {
 		}
 	}
 
-	// If we don't have it in the store try some APIs
-	r := repo.Remote()
-	u, err := url.Parse(r)
+	// If we don't have it in the store try the API of the host.
+	db, err := hosting.DefaultBranch(repo.Remote())
 	if err != nil {
-		return ""
-	}
-	if u.Scheme == "" {
-		// Where there is no scheme we try urls like git@github.com:foo/bar
-		r = strings.Replace(r, ":", "/", -1)
-		r = "ssh://" + r
-		u, err = url.Parse(r)
-		if err != nil {
-			return ""
-		}
-		u.Scheme = ""
-	}
-	if u.Host == "github.com" {
-		parts := strings.Split(u.Path, "/")
-		if len(parts) != 2 {
-			return ""
-		}
-		api := fmt.Sprintf("https://api.github.com/repos/%s/%s", parts[0], parts[1])
-		resp, err := http.Get(api)
-		if err != nil {
-			return ""
+		if err != hosting.ErrUnsupported {
+			msg.Debug("Unable to find the default branch of %s: %s", repo.Remote(), err)
 		}
-		defer resp.Body.Close()
-		if resp.StatusCode >= 300 || resp.StatusCode < 200 {
-			return ""
-		}
-		body, err := ioutil.ReadAll(resp.Body)
-		var data interface{}
-		err = json.Unmarshal(body, &data)
-		if err != nil {
-			return ""
-		}
-		gh := data.(map[string]interface{})
-		db := gh["default_branch"].(string)
-		if kerr == nil {
-			d.DefaultBranch = db
-			err := cp.SaveRepoData(key, d)
-			if err == cp.ErrCacheDisabled {
-				msg.Debug("Unable to cache default branch because caching is disabled")
-			} else if err != nil {
-				msg.Debug("Error saving %s to cache. Error: %s", repo.Remote(), err)
-			}
-		}
-		return db
+		return ""
 	}
-
-	if u.Host == "bitbucket.org" {
-		parts := strings.Split(u.Path, "/")
-		if len(parts) != 2 {
-			return ""
-		}
-		api := fmt.Sprintf("https://bitbucket.org/api/1.0/repositories/%s/%s/main-branch/", parts[0], parts[1])
-		resp, err := http.Get(api)
-		if err != nil {
-			return ""
-		}
-		defer resp.Body.Close()
-		if resp.StatusCode >= 300 || resp.StatusCode < 200 {
-			return ""
-		}
-		body, err := ioutil.ReadAll(resp.Body)
-		var data interface{}
-		err = json.Unmarshal(body, &data)
-		if err != nil {
-			return ""
-		}
-		bb := data.(map[string]interface{})
-		db := bb["name"].(string)
-		if kerr == nil {
-			d.DefaultBranch = db
-			err := cp.SaveRepoData(key, d)
-			if err == cp.ErrCacheDisabled {
-				msg.Debug("Unable to cache default branch because caching is disabled")
-			} else if err != nil {
-				msg.Debug("Error saving %s to cache. Error: %s", repo.Remote(), err)
-			}
+	if kerr == nil {
+		d.DefaultBranch = db
+		err := cp.SaveRepoData(key, d)
+		if err == cp.ErrCacheDisabled {
+			msg.Debug("Unable to cache default branch because caching is disabled")
+		} else if err != nil {
+			msg.Debug("Error saving %s to cache. Error: %s", repo.Remote(), err)
 		}
-		return db
 	}
-
-	return ""
+	return db
 }
 
 // From a local repo find out the current branch name if there is one.
@@ -461,7 +510,7 @@ func findCurrentBranch(repo v.Repo) string {
 		return ""
 	}
 
-	if repo.Vcs() == v.Git || repo.Vcs() == v.Hg {
+	if repo.Vcs() == v.Git || repo.Vcs() == v.Hg || repo.Vcs() == fossil.Type {
 		ver, err := repo.Current()
 		if err != nil {
 			msg.Debug("Unable to find current branch for %s, error: %s", repo.Remote(), err)
@@ -0,0 +1,159 @@
+// Package stats records how long the phases of installing and updating
+// dependencies take and how much is fetched for each dependency, so slow runs
+// can be diagnosed. Nothing is recorded unless Enabled is set.
+//
+// Operations of a phase run concurrently, so the time of a phase is reported
+// both as the sum of its operations and as the wall clock time from the start
+// of the first to the end of the last. Phases overlap too: resolving includes
+// the fetches and metadata lookups it needs.
+package stats
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// The phases timed.
+const (
+	Metadata = "metadata"
+	Resolve  = "resolve"
+	Fetch    = "fetch"
+	Checkout = "checkout"
+	Export   = "export"
+)
+
+// phases lists the phases in the order they are reported.
+var phases = []string{Metadata, Resolve, Fetch, Checkout, Export}
+
+// Enabled turns recording on.
+var Enabled bool
+
+var (
+	mu      sync.Mutex
+	started = time.Now()
+	spans   = make(map[string]*span)
+	deps    = make(map[string]*Dependency)
+)
+
+// span is the time recorded for a phase.
+type span struct {
+	count      int
+	total      time.Duration
+	first, end time.Time
+}
+
+// Report is the summary of what was recorded.
+type Report struct {
+	// Total is the time, in seconds, since Glide started.
+	Total float64 `json:"total"`
+
+	Phases       []*Phase      `json:"phases"`
+	Dependencies []*Dependency `json:"dependencies"`
+}
+
+// Phase is the time spent in one phase, in seconds.
+type Phase struct {
+	Name  string  `json:"name"`
+	Count int     `json:"count"`
+	Total float64 `json:"total"`
+	Wall  float64 `json:"wall"`
+}
+
+// Dependency is the time, in seconds, spent on one dependency in each phase
+// and the bytes its fetches added to the cache.
+type Dependency struct {
+	Name   string             `json:"name"`
+	Phases map[string]float64 `json:"phases"`
+	Bytes  int64              `json:"bytes"`
+}
+
+// Time starts timing an operation of phase on the dependency name, which may
+// be empty. The returned function ends it:
+//
+//     defer stats.Time(stats.Fetch, dep.Name)()
+func Time(phase, name string) func() {
+	if !Enabled {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		end := time.Now()
+		d := end.Sub(start)
+
+		mu.Lock()
+		defer mu.Unlock()
+		s, ok := spans[phase]
+		if !ok {
+			s = &span{first: start}
+			spans[phase] = s
+		}
+		s.count++
+		s.total += d
+		if start.Before(s.first) {
+			s.first = start
+		}
+		if end.After(s.end) {
+			s.end = end
+		}
+		if name != "" {
+			dep(name).Phases[phase] += d.Seconds()
+		}
+	}
+}
+
+// AddBytes records n more bytes fetched for the dependency name.
+func AddBytes(name string, n int64) {
+	if !Enabled || n <= 0 {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	dep(name).Bytes += n
+}
+
+// dep returns the record of the dependency name. mu must be held.
+func dep(name string) *Dependency {
+	d, ok := deps[name]
+	if !ok {
+		d = &Dependency{Name: name, Phases: make(map[string]float64)}
+		deps[name] = d
+	}
+	return d
+}
+
+// Get returns what was recorded so far. Phases are in the order they happen
+// and dependencies are sorted by name.
+func Get() *Report {
+	mu.Lock()
+	defer mu.Unlock()
+	r := &Report{
+		Total:        time.Since(started).Seconds(),
+		Phases:       []*Phase{},
+		Dependencies: []*Dependency{},
+	}
+	for _, p := range phases {
+		if s, ok := spans[p]; ok {
+			r.Phases = append(r.Phases, &Phase{
+				Name:  p,
+				Count: s.count,
+				Total: s.total.Seconds(),
+				Wall:  s.end.Sub(s.first).Seconds(),
+			})
+		}
+	}
+	var names []string
+	for n := range deps {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		d := *deps[n]
+		d.Phases = make(map[string]float64, len(deps[n].Phases))
+		for p, t := range deps[n].Phases {
+			d.Phases[p] = t
+		}
+		r.Dependencies = append(r.Dependencies, &d)
+	}
+	return r
+}
@@ -0,0 +1,36 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecord(t *testing.T) {
+	Enabled = true
+	defer func() { Enabled = false }()
+
+	Time(Fetch, "github.com/foo/bar")()
+	stop := Time(Fetch, "github.com/foo/baz")
+	time.Sleep(10 * time.Millisecond)
+	stop()
+	Time(Resolve, "")()
+	AddBytes("github.com/foo/bar", 2048)
+	AddBytes("github.com/foo/bar", -1)
+
+	r := Get()
+	if len(r.Phases) != 2 || r.Phases[0].Name != Resolve || r.Phases[1].Name != Fetch {
+		t.Fatalf("Expected the resolve and fetch phases in order, got %v", r.Phases)
+	}
+	if f := r.Phases[1]; f.Count != 2 || f.Total < 0.01 || f.Wall < f.Total {
+		t.Errorf("Unexpected fetch phase %+v", f)
+	}
+	if len(r.Dependencies) != 2 || r.Dependencies[0].Name != "github.com/foo/bar" {
+		t.Fatalf("Expected two dependencies sorted by name, got %v", r.Dependencies)
+	}
+	if r.Dependencies[0].Bytes != 2048 {
+		t.Errorf("Expected 2048 bytes fetched, got %d", r.Dependencies[0].Bytes)
+	}
+	if r.Dependencies[1].Phases[Fetch] < 0.01 {
+		t.Errorf("Expected the fetch of github.com/foo/baz to be timed")
+	}
+}
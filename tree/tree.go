@@ -2,6 +2,7 @@ package tree
 
 import (
 	"container/list"
+	"go/build"
 	"os"
 	"path/filepath"
 	"strings"
@@ -49,40 +50,26 @@ func walkDeps(b *util.BuildCtxt, base, myName string) []string {
 			return err
 		}
 
-		if !dependency.IsSrcDir(fi) {
+		if !dependency.IsSrcDir(fi) || path == filepath.Join(base, gpath.VendorDir) {
 			if fi.IsDir() {
 				return filepath.SkipDir
 			}
 			return nil
 		}
 
-		var imps []string
-		pkg, err := b.ImportDir(path, 0)
-		if err != nil && strings.HasPrefix(err.Error(), "found packages ") {
-			// If we got here it's because a package and multiple packages
-			// declared. This is often because of an example with a package
-			// or main but +build ignore as a build tag. In that case we
-			// try to brute force the packages with a slower scan.
-			imps, _, err = dependency.IterativeScan(path)
-			if err != nil {
-				msg.Err("Error walking dependencies for %s: %s", path, err)
-				return err
-			}
-		} else if err != nil {
-			if !strings.HasPrefix(err.Error(), "no buildable Go source") {
+		pkg, err := dependency.ScanImports(b, path)
+		if err != nil {
+			if _, ok := err.(*build.NoGoError); !ok {
 				msg.Warn("Error: %s (%s)", err, path)
-				// Not sure if we should return here.
-				//return err
 			}
-		} else {
-			imps = pkg.Imports
+			return nil
 		}
 
 		if pkg.Goroot {
 			return nil
 		}
 
-		for _, imp := range imps {
+		for _, imp := range pkg.Imports {
 			//if strings.HasPrefix(imp, myName) {
 			////Info("Skipping %s because it is a subpackage of %s", imp, myName)
 			//continue
@@ -136,12 +123,14 @@ func findPkg(b *util.BuildCtxt, name, cwd string) *dependency.PkgInfo {
 			if wd == b.GOPATH || wd+string(os.PathSeparator) == b.GOPATH {
 				break
 			}
-			p = filepath.Join(wd, "vendor", name)
-			if fi, err = os.Stat(p); err == nil && (fi.IsDir() || gpath.IsLink(fi)) {
-				info.Path = p
-				info.Loc = dependency.LocVendor
-				info.Vendored = true
-				return info
+			for _, v := range vendorDirs() {
+				p = filepath.Join(wd, v, name)
+				if fi, err = os.Stat(p); err == nil && (fi.IsDir() || gpath.IsLink(fi)) {
+					info.Path = p
+					info.Loc = dependency.LocVendor
+					info.Vendored = true
+					return info
+				}
 			}
 		}
 	}
@@ -186,6 +175,15 @@ func findPkg(b *util.BuildCtxt, name, cwd string) *dependency.PkgInfo {
 }
 
 // copyList copies an existing list to a new list.
+// vendorDirs returns the names of the directories packages are vendored in.
+// Dependencies use vendor while the project may set another one.
+func vendorDirs() []string {
+	if gpath.VendorDir == "vendor" {
+		return []string{"vendor"}
+	}
+	return []string{gpath.VendorDir, "vendor"}
+}
+
 func copyList(l *list.List) *list.List {
 	n := list.New()
 	for e := l.Front(); e != nil; e = e.Next() {
@@ -13,6 +13,11 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/Masterminds/glide/cache"
+	"github.com/Masterminds/glide/credentials"
+	"github.com/Masterminds/glide/mirrors"
+	"github.com/Masterminds/glide/msg"
+	"github.com/Masterminds/glide/stats"
 	"github.com/Masterminds/vcs"
 )
 
@@ -22,6 +27,10 @@ import (
 // other needs arise it may need to be re-written.
 var ResolveCurrent = false
 
+// Offline, if true, keeps import paths from being looked up over the network.
+// Only the remote metadata cache is used.
+var Offline = false
+
 // goRoot caches the GOROOT variable for build contexts. If $GOROOT is not set in
 // the user's environment, then the context's root path is 'go env GOROOT'.
 var goRoot string
@@ -52,8 +61,13 @@ func toSlash(v string) string {
 // From a package name find the root repo. For example,
 // the package github.com/Masterminds/cookoo/io has a root repo
 // at github.com/Masterminds/cookoo
+//
+// Rewrite rules in the config.yaml file in GLIDE_HOME are checked first.
 func GetRootFromPackage(pkg string) string {
 	pkg = toSlash(pkg)
+	if root, ok := mirrors.RewriteRoot(pkg); ok {
+		return root
+	}
 	for _, v := range vcsList {
 		m := v.regex.FindStringSubmatch(pkg)
 		if m == nil {
@@ -80,6 +94,10 @@ func GetRootFromPackage(pkg string) string {
 // should match the vcsURL and the repo is a location that can be
 // checked out. Note, to get the html document you you need to add
 // ?go-get=1 to the url.
+//
+// Roots found are kept in the remote metadata cache of Glide for the next run.
+// In offline mode the cache is used however old its entries are and nothing
+// is looked up.
 func getRootFromGoGet(pkg string) string {
 
 	p, found := checkRemotePackageCache(pkg)
@@ -87,10 +105,45 @@ func getRootFromGoGet(pkg string) string {
 		return p
 	}
 
+	root, stale, found := cache.MetaRoot(pkg)
+	if found && (!stale || Offline) {
+		addToRemotePackageCache(pkg, root)
+		return root
+	}
+	if Offline {
+		addToRemotePackageCache(pkg, pkg)
+		return pkg
+	}
+
+	nu, err := LookupRoot(pkg)
+	if err != nil {
+		// A root that was cached before is better than none when the lookup
+		// fails.
+		if found {
+			addToRemotePackageCache(pkg, root)
+			return root
+		}
+		addToRemotePackageCache(pkg, pkg)
+		return pkg
+	}
+
+	// The root is cached rather than the package so the other packages in
+	// the repo are found too.
+	if err := cache.SaveMeta(nu, nu); err != nil && err != cache.ErrCacheDisabled {
+		msg.Debug("Unable to cache the root of %s: %s", pkg, err)
+	}
+	addToRemotePackageCache(pkg, nu)
+	return nu
+}
+
+// LookupRoot fetches the go-import meta tag served for an import path and
+// returns the root of the repo holding it. An import path without a meta tag
+// is its own root. An error is returned when the page cannot be fetched.
+func LookupRoot(pkg string) (string, error) {
 	vcsURL := "https://" + pkg
 	u, err := url.Parse(vcsURL)
 	if err != nil {
-		return pkg
+		return pkg, err
 	}
 	if u.RawQuery == "" {
 		u.RawQuery = "go-get=1"
@@ -98,24 +151,23 @@ func getRootFromGoGet(pkg string) string {
 		u.RawQuery = u.RawQuery + "&go-get=1"
 	}
 	checkURL := u.String()
-	resp, err := http.Get(checkURL)
+	defer stats.Time(stats.Metadata, "")()
+	req, err := http.NewRequest("GET", checkURL, nil)
 	if err != nil {
-		addToRemotePackageCache(pkg, pkg)
-		return pkg
+		return pkg, err
+	}
+	credentials.Authorize(req)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return pkg, err
 	}
 	defer resp.Body.Close()
 
 	nu, err := parseImportFromBody(u, resp.Body)
-	if err != nil {
-		addToRemotePackageCache(pkg, pkg)
-		return pkg
-	} else if nu == "" {
-		addToRemotePackageCache(pkg, pkg)
-		return pkg
+	if err != nil || nu == "" {
+		return pkg, nil
 	}
-
-	addToRemotePackageCache(pkg, nu)
-	return nu
+	return nu, nil
 }
 
 // The caching is not concurrency safe but should be made to be that way.